@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,15 +17,19 @@ import (
 	"github.com/gorilla/mux"
 
 	"backend/config"
+	"backend/internal/announcements"
 	"backend/internal/identity"
 	"backend/internal/learning"
+	"backend/internal/notifications"
 	"backend/internal/platform/ai"
+	"backend/internal/platform/cache"
 	"backend/internal/platform/database"
 	"backend/internal/platform/health"
 	"backend/internal/platform/logger"
 	"backend/internal/platform/metrics"
 	"backend/internal/platform/middleware"
 	"backend/internal/platform/server"
+	"backend/internal/platform/validation"
 	"backend/internal/social"
 )
 
@@ -39,10 +45,17 @@ func main() {
 	}
 
 	// 2. Initialize Logger
-	appLogger := logger.New(cfg.Server.Env)
+	appLogger := logger.NewWithConfig(logger.Config{
+		Env:            cfg.Server.Env,
+		Level:          slog.LevelInfo,
+		Output:         os.Stdout,
+		SanitizeFields: cfg.Log.SanitizeFields,
+	})
 	if appLogger == nil {
 		log.Fatal("Failed to initialize logger")
 	}
+	// Route package-level slog calls (services, middleware) through the same handler
+	slog.SetDefault(appLogger.Logger)
 	appLogger.Info("Logger initialized", "env", cfg.Server.Env)
 
 	// 3. Connect to Database
@@ -67,6 +80,11 @@ func main() {
 		Password: cfg.Database.Password,
 		DBName:   cfg.Database.DBName,
 		SSLMode:  cfg.Database.SSLMode,
+
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.ConnMaxIdleTime,
 	}
 
 	db, err := database.Connect(dbConfig)
@@ -85,7 +103,9 @@ func main() {
 		appLogger.Error("Database ping failed", "error", err)
 		log.Fatalf("Database not responding: %v", err)
 	}
-	appLogger.Info("Database connected successfully")
+	appLogger.Info("Database connected successfully",
+		"max_open_conns", dbConfig.MaxOpenConns, "max_idle_conns", dbConfig.MaxIdleConns,
+		"conn_max_lifetime", dbConfig.ConnMaxLifetime, "conn_max_idle_time", dbConfig.ConnMaxIdleTime)
 
 	// 4. Initialize AI Client
 	aiClient, err := ai.New(cfg.AI.Provider, cfg.AI.APIKey, cfg.AI.Model)
@@ -93,26 +113,140 @@ func main() {
 		appLogger.Error("Failed to initialize AI client", "error", err)
 		log.Fatalf("AI client initialization failed: %v", err)
 	}
-	appLogger.Info("AI client initialized", "provider", cfg.AI.Provider, "model", cfg.AI.Model)
+	// Per-call options were validated when the config was loaded
+	aiClient.
+		WithCompletionOptions(ai.CallValidateDomain, cfg.AI.Validation.Options()).
+		WithCompletionOptions(ai.CallExtractVariables, cfg.AI.Extraction.Options()).
+		WithCompletionOptions(ai.CallGenerateCurriculum, cfg.AI.Curriculum.Options()).
+		WithCompletionOptions(ai.CallReviewCode, cfg.AI.Review.Options()).
+		WithFallbackModels(cfg.AI.FallbackModels...)
+	appLogger.Info("AI client initialized", "provider", cfg.AI.Provider, "model", cfg.AI.Model,
+		"fallback_models", aiClient.FallbackModels())
+	aiBudget := ai.NewBudget(db.DB, cfg.AI.DailyCallBudget)
+	if aiBudget == nil {
+		appLogger.Warn("Per-user AI call budget is disabled")
+	} else {
+		appLogger.Info("Per-user AI call budget enabled", "daily_calls", aiBudget.DailyCalls())
+	}
 
 	// 5. Initialize Repositories
 	identityRepo := identity.NewRepository(db.DB)
 	learningRepo := learning.NewRepository(db.DB)
 	socialRepo := social.NewRepository(db.DB)
+	announcementsRepo := announcements.NewRepository(db.DB)
+	notificationsRepo := notifications.NewRepository(db.DB)
 	appLogger.Info("Repositories initialized")
 
 	// 6. Initialize Services
-	identityService := identity.NewService(identityRepo, cfg.JWT.Secret, cfg.JWT.ExpirationSeconds)
-	learningService := learning.NewService(learningRepo, aiClient)
-	socialService := social.NewService(socialRepo)
+	textLimits := validation.TextLimits{
+		ShortMaxLength:   cfg.Text.ShortMaxLength,
+		LongMaxLength:    cfg.Text.LongMaxLength,
+		TruncateOverflow: cfg.Text.TruncateOverflow,
+	}
+	variableDefaults := identity.DefaultVariableDefaults()
+	if cfg.Onboarding.DefaultVariablesFile != "" {
+		variableDefaults, err = identity.LoadVariableDefaults(cfg.Onboarding.DefaultVariablesFile)
+		if err != nil {
+			appLogger.Error("Failed to load onboarding variable defaults", "error", err)
+			log.Fatalf("Onboarding variable defaults failed to load: %v", err)
+		}
+	}
+	passwordHashing := identity.DefaultPasswordHashing()
+	passwordHashing.Cost = cfg.Auth.BcryptCost
+	passwordHashing.RehashOnLogin = cfg.Auth.RehashPasswordsOnLogin
+	identityService := identity.NewService(identityRepo, cfg.JWT.Secret, cfg.JWT.ExpirationSeconds).
+		WithEmailVerification(cfg.Auth.RequireEmailVerification, cfg.Auth.EmailVerificationTTL).
+		WithEmailNormalization(identity.EmailNormalization{
+			LowercaseLocal: cfg.Auth.EmailLowercaseLocal,
+			GmailCanonical: cfg.Auth.EmailGmailCanonical,
+		}).
+		WithPasswordHashing(passwordHashing).
+		WithTextLimits(textLimits).
+		WithStrictVariables(cfg.Onboarding.StrictVariables).
+		WithVariableDefaults(variableDefaults).
+		WithAIBudget(aiBudget).
+		WithMetrics(metrics.Recorder{})
+	if cfg.Onboarding.ValidateDomain {
+		identityService.WithDomainValidator(aiClient)
+	} else {
+		appLogger.Warn("Onboarding domain validation is disabled")
+	}
+	renormalized, err := identityService.RenormalizeEmails()
+	if err != nil {
+		appLogger.Error("Stored emails do not fit the normalization policy", "error", err)
+		log.Fatalf("Email normalization failed: %v", err)
+	}
+	if renormalized > 0 {
+		appLogger.Info("Re-normalized stored emails for the current policy", "count", renormalized)
+	}
+	languages := learning.DefaultLanguages()
+	if cfg.Learning.LanguagesFile != "" {
+		languages, err = learning.LoadLanguages(cfg.Learning.LanguagesFile)
+		if err != nil {
+			appLogger.Error("Failed to load supported languages", "error", err)
+			log.Fatalf("Supported languages failed to load: %v", err)
+		}
+	}
+	learningService := learning.NewService(learningRepo, aiClient).
+		WithTextLimits(textLimits).
+		WithScoringPolicy(learning.ScoringPolicy{
+			PassThreshold:    cfg.Scoring.PassThreshold,
+			VisibleWeight:    cfg.Scoring.VisibleWeight,
+			HiddenWeight:     cfg.Scoring.HiddenWeight,
+			HintPenalty:      cfg.Scoring.HintPenalty,
+			EasyMultiplier:   cfg.Scoring.EasyMultiplier,
+			MediumMultiplier: cfg.Scoring.MediumMultiplier,
+			HardMultiplier:   cfg.Scoring.HardMultiplier,
+		}).
+		WithCurriculumPolicy(learning.CurriculumPolicy{
+			TargetModules: cfg.Curriculum.TargetModules,
+			MinModules:    cfg.Curriculum.MinModules,
+		}).
+		WithLanguages(languages).
+		WithAIBudget(aiBudget).
+		WithMetrics(metrics.Recorder{})
+	skillGraph := social.SkillGraph
+	if cfg.Recommendations.SkillGraphFile != "" {
+		skillGraph, err = social.LoadSkillGraph(cfg.Recommendations.SkillGraphFile)
+		if err != nil {
+			appLogger.Error("Failed to load skill graph", "error", err)
+			log.Fatalf("Skill graph failed to load: %v", err)
+		}
+	}
+	socialService := social.NewService(socialRepo).
+		WithCaches(
+			cache.Config{TTL: cfg.Cache.TrendingTTL, MaxSize: cfg.Cache.TrendingMaxSize},
+			cache.Config{TTL: cfg.Cache.RecommendationsTTL, MaxSize: cfg.Cache.RecommendationsMaxSize},
+		).
+		WithRecommendationBatchSize(cfg.Recommendations.BatchSize).
+		WithRecommendationTimeout(cfg.Recommendations.Timeout).
+		WithSkillGraph(skillGraph).
+		WithTrendingRefreshWait(cfg.Cache.TrendingRefreshWait).
+		WithTrendingFullRebuild(cfg.Cache.TrendingFullRebuild).
+		WithTrendingPolicy(social.TrendingPolicy{
+			Window:            cfg.Trending.Window,
+			PreviousWindow:    cfg.Trending.PreviousWindow,
+			NewCourseVelocity: cfg.Trending.NewCourseVelocity,
+			Smoothing:         cfg.Trending.Smoothing,
+			ScorePerVelocity:  cfg.Trending.ScorePerVelocity,
+		})
+	// Domains depend on each other only through interfaces, so every service is
+	// constructed before any of them is wired to another
+	wireDomainServices(identityService, learningService, socialService, aiClient)
+	announcementsService := announcements.NewService(announcementsRepo).
+		WithTextLimits(textLimits)
+	notificationsService := notifications.NewService(notificationsRepo)
 	appLogger.Info("Services initialized",
 		"jwt_expiration_seconds", cfg.JWT.ExpirationSeconds,
-		"jwt_expiration_duration", cfg.JWT.ExpirationDuration)
+		"jwt_expiration_duration", cfg.JWT.ExpirationDuration,
+		"require_email_verification", cfg.Auth.RequireEmailVerification)
 
 	// 7. Initialize Handlers
 	identityHandler := identity.NewHandler(identityService)
 	learningHandler := learning.NewHandler(learningService)
 	socialHandler := social.NewHandler(socialService)
+	announcementsHandler := announcements.NewHandler(announcementsService)
+	notificationsHandler := notifications.NewHandler(notificationsService)
 	appLogger.Info("Handlers initialized")
 
 	// 8. Setup Health Check Handler
@@ -120,16 +254,31 @@ func main() {
 		Version:   "1.0.0",
 		StartTime: time.Now(),
 		DB:        db.DB,
+
+		MaxMemoryMB: uint64(cfg.Health.MaxMemoryMB),
 	})
-	appLogger.Info("Health check handler initialized")
+	if cfg.AI.HealthCheck {
+		// Readiness only: a revoked or rate-limited key should stop traffic, not restart pods
+		aiClient.WithHealthFreshness(cfg.AI.HealthFreshness)
+		health.RegisterCheck("ai", health.PingCheck(aiClient))
+	}
+	appLogger.Info("Health check handler initialized", "ai_check", cfg.AI.HealthCheck)
 
 	// 9. Start Background Metric Collectors
 	metrics.StartDatabaseMetricsCollector(db.DB, 15*time.Second)
 	metrics.StartPerformanceMetricsCollector(10*time.Second)
 	appLogger.Info("Metrics collectors started")
 
+	// Background workers stop when the server shuts down
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	socialService.StartTrendingRefresher(workerCtx, cfg.Trending.RefreshInterval, cfg.Trending.RefreshJitter)
+	socialService.StartRecommendationCleanup(workerCtx, cfg.Recommendations.CleanupInterval)
+	aiBudget.StartCleanup(workerCtx, 24*time.Hour)
+
 	// 10. Setup Router
 	router := mux.NewRouter()
+	router.Use(middleware.RouteTemplate) // Lets the metrics middleware label requests by route template
 
 	// Health check endpoints (no auth required)
 	router.HandleFunc("/health", healthHandler.Liveness).Methods("GET")
@@ -145,42 +294,122 @@ func main() {
 	rateLimitConfig := middleware.DefaultRateLimiterConfig()
 	securityHeadersConfig := middleware.DefaultSecurityHeadersConfig()
 	sizeLimitConfig := middleware.DefaultSizeLimitConfig()
+	// Submissions carry whole programs, so they get more room than other requests
+	sizeLimitConfig.Routes = []middleware.RouteSizeLimit{
+		{Method: "POST", Path: "/api/exercises/{id}/submit", MaxBodySize: cfg.Server.SubmissionMaxBodySize},
+	}
+
+	// API rate limiting: anonymous traffic is limited per IP globally, authenticated
+	// traffic per user (at the user's tier) once the token has been verified
+	apiRateLimiter := middleware.NewAPIRateLimiter(rateLimitConfig)
+	userRateLimit := apiRateLimiter.PerUser()
 
 	// Auth middleware for protected routes
-	authMiddleware := middleware.Auth(cfg.JWT.Secret)
+	requireAuth := middleware.Auth(cfg.JWT.Secret)
+	optionalAuth := middleware.OptionalAuth(cfg.JWT.Secret)
+	authMiddleware := func(next http.Handler) http.Handler {
+		return requireAuth(userRateLimit(next))
+	}
+	optionalAuthMiddleware := func(next http.Handler) http.Handler {
+		return optionalAuth(userRateLimit(next))
+	}
+	adminMiddleware := func(next http.Handler) http.Handler {
+		return authMiddleware(middleware.RequireAdmin()(next))
+	}
 
 	// Public routes - Authentication (with rate limiting and size limits)
 	authRouter := api.PathPrefix("/auth").Subrouter()
 	authRouter.Use(middleware.RateLimitAuth(rateLimitConfig))
-	authRouter.Use(middleware.RequestSizeLimit(sizeLimitConfig))
+	authRouter.Use(middleware.RequestSizeLimitBytes(cfg.Server.AuthMaxBodySize))
 	authRouter.HandleFunc("/register", identityHandler.Register).Methods("POST")
 	authRouter.HandleFunc("/login", identityHandler.Login).Methods("POST")
+	authRouter.HandleFunc("/verify-email", identityHandler.VerifyEmail).Methods("POST")
+	authRouter.HandleFunc("/verify-email/resend", identityHandler.ResendVerification).Methods("POST")
 
 	// Protected routes - Identity/User Management
 	api.Handle("/users/me", authMiddleware(http.HandlerFunc(identityHandler.GetProfile))).Methods("GET")
 	api.Handle("/users/me", authMiddleware(http.HandlerFunc(identityHandler.UpdateProfile))).Methods("PATCH")
+	api.Handle("/users/me", authMiddleware(http.HandlerFunc(identityHandler.ReplaceProfile))).Methods("PUT")
+	api.Handle("/users/me", authMiddleware(http.HandlerFunc(identityHandler.DeleteAccount))).Methods("DELETE")
+	api.Handle("/users/me/privacy", authMiddleware(http.HandlerFunc(identityHandler.UpdatePrivacySettings))).Methods("PATCH")
+	api.Handle("/users/me/variables", authMiddleware(http.HandlerFunc(identityHandler.GetVariables))).Methods("GET")
+	api.Handle("/users/me/variables", authMiddleware(http.HandlerFunc(identityHandler.UpdateVariables))).Methods("PATCH")
 	api.Handle("/onboarding/complete", authMiddleware(http.HandlerFunc(identityHandler.CompleteOnboarding))).Methods("POST")
 
 	// Protected routes - Learning/Courses
 	api.Handle("/courses", authMiddleware(http.HandlerFunc(learningHandler.GetCourses))).Methods("GET")
 	api.Handle("/courses/{id}", authMiddleware(http.HandlerFunc(learningHandler.GetCourseDetails))).Methods("GET")
+	api.Handle("/courses/{id}", authMiddleware(http.HandlerFunc(learningHandler.DeleteCourse))).Methods("DELETE")
+	api.Handle("/courses/{id}/restore", authMiddleware(http.HandlerFunc(learningHandler.RestoreCourse))).Methods("POST")
 	api.Handle("/courses/{id}/progress", authMiddleware(http.HandlerFunc(learningHandler.GetProgress))).Methods("GET")
+	api.Handle("/courses/{id}/progress/modules", authMiddleware(http.HandlerFunc(learningHandler.GetCourseProgress))).Methods("GET")
+	api.Handle("/courses/{id}/complete-generation", authMiddleware(http.HandlerFunc(learningHandler.CompleteCourseGeneration))).Methods("POST")
+	api.Handle("/courses/{id}/exercises", authMiddleware(http.HandlerFunc(learningHandler.GetCourseExercises))).Methods("GET")
+
+	// Protected routes - Modules
+	api.Handle("/modules/{id}", authMiddleware(http.HandlerFunc(learningHandler.GetModule))).Methods("GET")
+	api.Handle("/modules/{id}/exercises", authMiddleware(http.HandlerFunc(learningHandler.CreateExercise))).Methods("POST")
 
 	// Protected routes - Exercises
 	api.Handle("/exercises/{id}", authMiddleware(http.HandlerFunc(learningHandler.GetExercise))).Methods("GET")
+	api.Handle("/exercises/{id}", authMiddleware(http.HandlerFunc(learningHandler.UpdateExercise))).Methods("PUT")
 	api.Handle("/exercises/{id}/submit", authMiddleware(http.HandlerFunc(learningHandler.SubmitExercise))).Methods("POST")
+	api.Handle("/exercises/{id}/hints/{index}", authMiddleware(http.HandlerFunc(learningHandler.RevealHint))).Methods("GET")
+	api.Handle("/submissions/pending-review", authMiddleware(http.HandlerFunc(learningHandler.GetPendingReviewSubmissions))).Methods("GET")
 	api.Handle("/submissions/{id}/review", authMiddleware(http.HandlerFunc(learningHandler.RequestReview))).Methods("POST")
+	api.Handle("/users/me/skill-trend", authMiddleware(http.HandlerFunc(learningHandler.GetSkillTrend))).Methods("GET")
+	api.Handle("/users/me/goals", authMiddleware(http.HandlerFunc(learningHandler.GetLearningGoals))).Methods("GET")
+	api.Handle("/users/me/goals", authMiddleware(http.HandlerFunc(learningHandler.SetLearningGoal))).Methods("PUT")
+	api.Handle("/users/me/certificates", authMiddleware(http.HandlerFunc(learningHandler.GetCertificates))).Methods("GET")
 
 	// Protected routes - Social/Activity Feed
 	api.Handle("/feed", authMiddleware(http.HandlerFunc(socialHandler.GetActivityFeed))).Methods("GET")
+	api.Handle("/feed/stream", authMiddleware(http.HandlerFunc(socialHandler.StreamActivityFeed))).Methods("GET")
+	api.Handle("/users/follow/batch", authMiddleware(http.HandlerFunc(socialHandler.FollowUsers))).Methods("POST")
+	api.Handle("/users/follow/batch", authMiddleware(http.HandlerFunc(socialHandler.UnfollowUsers))).Methods("DELETE")
 	api.Handle("/users/{id}/follow", authMiddleware(http.HandlerFunc(socialHandler.FollowUser))).Methods("POST")
 	api.Handle("/users/{id}/follow", authMiddleware(http.HandlerFunc(socialHandler.UnfollowUser))).Methods("DELETE")
+	api.Handle("/users/{id}/follow-status", authMiddleware(http.HandlerFunc(socialHandler.GetFollowStatus))).Methods("GET")
+	api.Handle("/users/me/friends", authMiddleware(http.HandlerFunc(socialHandler.GetFriends))).Methods("GET")
 	api.Handle("/recommendations", authMiddleware(http.HandlerFunc(socialHandler.GetRecommendations))).Methods("GET")
+	api.Handle("/recommendations/refresh", authMiddleware(http.HandlerFunc(socialHandler.RefreshRecommendations))).Methods("POST")
+	api.Handle("/recommendations/feedback/batch", authMiddleware(http.HandlerFunc(socialHandler.RecordFeedbackBatch))).Methods("POST")
+	api.Handle("/recommendations/{courseId}/dismiss", authMiddleware(http.HandlerFunc(socialHandler.DismissRecommendation))).Methods("POST")
+	api.Handle("/recommendations/{courseId}/interested", authMiddleware(http.HandlerFunc(socialHandler.MarkRecommendationInterested))).Methods("POST")
 	api.Handle("/users/{id}/profile", authMiddleware(http.HandlerFunc(socialHandler.GetUserProfile))).Methods("GET")
 	api.Handle("/users/me/achievements", authMiddleware(http.HandlerFunc(socialHandler.GetAchievements))).Methods("GET")
+	api.Handle("/achievements/check", authMiddleware(http.HandlerFunc(socialHandler.CheckAchievements))).Methods("POST")
+	api.Handle("/leaderboard", authMiddleware(http.HandlerFunc(socialHandler.GetLeaderboard))).Methods("GET")
 
 	// Public routes - Trending (no auth required)
-	api.HandleFunc("/trending", socialHandler.GetTrendingCourses).Methods("GET")
+	api.Handle("/trending", userRateLimit(http.HandlerFunc(socialHandler.GetTrendingCourses))).Methods("GET")
+
+	// Public routes - Skill graph (no auth required)
+	api.Handle("/skills/graph", userRateLimit(http.HandlerFunc(socialHandler.GetSkillGraph))).Methods("GET")
+	api.Handle("/skills/{skill}/adjacent", userRateLimit(http.HandlerFunc(socialHandler.GetAdjacentSkills))).Methods("GET")
+
+	// Public routes - Gradable languages (no auth required)
+	api.Handle("/languages", userRateLimit(http.HandlerFunc(learningHandler.GetLanguages))).Methods("GET")
+
+	// Announcements (public list; signed-in users do not see ones they acknowledged)
+	api.Handle("/announcements/active", optionalAuthMiddleware(http.HandlerFunc(announcementsHandler.GetActiveAnnouncements))).Methods("GET")
+	api.Handle("/announcements/{id}/acknowledge", authMiddleware(http.HandlerFunc(announcementsHandler.AcknowledgeAnnouncement))).Methods("POST")
+
+	// Protected routes - Notifications
+	api.Handle("/notifications/read", authMiddleware(http.HandlerFunc(notificationsHandler.MarkRead))).Methods("POST")
+
+	// Admin routes - Announcements
+	api.Handle("/admin/announcements", adminMiddleware(http.HandlerFunc(announcementsHandler.ListAnnouncements))).Methods("GET")
+	api.Handle("/admin/announcements", adminMiddleware(http.HandlerFunc(announcementsHandler.CreateAnnouncement))).Methods("POST")
+	api.Handle("/admin/announcements/{id}", adminMiddleware(http.HandlerFunc(announcementsHandler.UpdateAnnouncement))).Methods("PUT")
+	api.Handle("/admin/announcements/{id}", adminMiddleware(http.HandlerFunc(announcementsHandler.DeleteAnnouncement))).Methods("DELETE")
+
+	// Admin routes - Metrics snapshot for dashboards that do not scrape Prometheus
+	api.Handle("/admin/metrics/snapshot", adminMiddleware(metrics.SnapshotHandler())).Methods("GET")
+
+	// Admin routes - Maintenance
+	api.Handle("/admin/recommendations/cleanup", adminMiddleware(http.HandlerFunc(socialHandler.CleanupExpiredRecommendations))).Methods("POST")
+	api.Handle("/trending/refresh", adminMiddleware(http.HandlerFunc(socialHandler.RefreshTrending))).Methods("POST")
 
 	appLogger.Info("Routes registered")
 
@@ -204,22 +433,41 @@ func main() {
 	}
 
 	// Apply middleware chain (executed in reverse order)
-	// Execution order: Recovery -> RequestID -> Logging -> Security -> Metrics -> SizeLimit -> RateLimit -> CORS
-	handler := corsMiddleware(router)                                     // Last: CORS headers
-	handler = middleware.RateLimitAPI(rateLimitConfig)(handler)           // Sixth: Rate limiting
+	// Execution order: Recovery -> RequestID -> Logging -> Security -> Metrics -> SizeLimit -> RateLimit -> CORS -> Timeout
+	// AI-heavy routes get their own budget so generation is not cut off mid-call,
+	// and the live feed stream stays open until the client disconnects
+	routeTimeouts := []middleware.RouteTimeout{
+		{Method: "POST", Path: "/api/onboarding/complete", Timeout: cfg.Server.AIRequestTimeout},
+		{Method: "POST", Path: "/api/submissions/{id}/review", Timeout: cfg.Server.AIRequestTimeout},
+		{Method: "POST", Path: "/api/courses/{id}/complete-generation", Timeout: cfg.Server.AIRequestTimeout},
+		{Method: "PATCH", Path: "/api/users/me/variables", Timeout: cfg.Server.AIRequestTimeout},
+		{Method: "GET", Path: "/api/feed/stream", Timeout: 0},
+	}
+	handler := middleware.Timeout(cfg.Server.RequestTimeout, routeTimeouts...)(router) // Innermost: bound handler run time
+	handler = corsMiddleware(handler)                                     // Last: CORS headers
+	handler = apiRateLimiter.Global()(handler)                           // Sixth: Rate limiting (anonymous; per user after Auth)
 	handler = middleware.RequestSizeLimit(sizeLimitConfig)(handler)       // Fifth: Size limits
 	handler = middleware.Metrics()(handler)                                // Fourth: Collect metrics
 	handler = middleware.SecurityHeaders(securityHeadersConfig)(handler) // Third: Security headers
-	handler = middleware.LoggingSimple()(handler)                         // Second: Log with request ID
+	handler = middleware.AccessLog(appLogger.Logger)(handler)            // Second: One structured log line per request
 	handler = middleware.RequestID()(handler)                              // Early: Generate request ID
 	handler = middleware.Recovery()(handler)                              // First: Panic recovery (catches everything)
-	appLogger.Info("Middleware applied (recovery, request-id, logging, security, metrics, size limits, rate limiting, CORS)")
+	appLogger.Info("Middleware applied (recovery, request-id, logging, security, metrics, size limits, rate limiting, CORS, timeout)",
+		"request_timeout", cfg.Server.RequestTimeout, "ai_request_timeout", cfg.Server.AIRequestTimeout)
 
 	// 12. Create and Start Server
 	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
 	serverConfig := server.Config{
 		Addr: addr,
 	}
+	if cfg.Server.RequestTimeout > 0 && cfg.Server.AIRequestTimeout > 0 {
+		// Leave room after the longest request timeout to write the 503 response
+		longest := cfg.Server.RequestTimeout
+		if cfg.Server.AIRequestTimeout > longest {
+			longest = cfg.Server.AIRequestTimeout
+		}
+		serverConfig.WriteTimeout = longest + 5*time.Second
+	}
 	srv := server.New(serverConfig, handler)
 
 	// Handle graceful shutdown
@@ -243,6 +491,7 @@ func main() {
 
 	case sig := <-shutdown:
 		appLogger.Info("Shutdown signal received", "signal", sig)
+		stopWorkers()
 
 		// Give outstanding requests time to complete (from config)
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
@@ -261,3 +510,53 @@ func main() {
 		}
 	}
 }
+
+// profilePrivacyLookup adapts identity privacy settings for social profile views
+func profilePrivacyLookup(identityService *identity.Service) social.PrivacyLookup {
+	return func(userID string) (social.ProfilePrivacy, error) {
+		settings, err := identityService.GetPrivacySettings(userID)
+		if errors.Is(err, identity.ErrUserNotFound) {
+			return social.ProfilePrivacy{}, social.ErrUserNotFound
+		}
+		if err != nil {
+			return social.ProfilePrivacy{}, err
+		}
+		return social.ProfilePrivacy{
+			ProfileVisibility:    settings.ProfileVisibility,
+			ProgressVisibility:   settings.ProgressVisibility,
+			ShowCompletedCourses: settings.ShowCompletedCourses,
+		}, nil
+	}
+}
+
+// wireDomainServices connects the domain services to each other. Each domain
+// only sees the others through its own interfaces, which breaks the cycles
+// (identity -> learning -> social -> identity and learning).
+func wireDomainServices(identityService *identity.Service, learningService *learning.Service, socialService *social.Service, aiClient *ai.Client) {
+	// Onboarding infers missing variables with the AI and generates the first course
+	identityService.
+		WithAIClient(aiClient).
+		WithCourseGenerator(learningCourseGenerator{learning: learningService})
+
+	learningService.WithActivityBroadcaster(socialService)
+
+	// Profiles show completed courses, archetypes and privacy-filtered progress
+	socialService.
+		WithLearningService(learningService).
+		WithIdentityService(identityService).
+		WithPrivacyLookup(profilePrivacyLookup(identityService))
+}
+
+// learningCourseGenerator adapts the learning service to identity.CourseGenerator.
+// Onboarding only needs to know whether generation failed, so the course is dropped.
+type learningCourseGenerator struct {
+	learning *learning.Service
+}
+
+var _ identity.CourseGenerator = learningCourseGenerator{}
+
+// GenerateCourse generates the user's course for the archetype
+func (g learningCourseGenerator) GenerateCourse(ctx context.Context, userID, archetypeID string, variables map[string]string) error {
+	_, err := g.learning.GenerateCourse(ctx, userID, archetypeID, variables)
+	return err
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"backend/internal/identity"
+	"backend/internal/learning"
+	"backend/internal/social"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnboardingGeneratesCourseOnceServicesAreWired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	identityService := identity.NewService(identity.NewRepository(db), "test-secret-key", 3600)
+	learningService := learning.NewService(learning.NewRepository(db), nil)
+	socialService := social.NewService(social.NewRepository(db))
+	wireDomainServices(identityService, learningService, socialService, nil)
+
+	variables := map[string]string{
+		"ENTITY":    "Order",
+		"STATE":     "Order status",
+		"FLOW":      "Checkout",
+		"LOGIC":     "Pricing rules",
+		"INTERFACE": "REST API",
+	}
+	now := time.Now()
+
+	// Onboarding: the user, their archetype and variables
+	mock.ExpectQuery(`FROM users`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "email", "password_hash", "name", "avatar_url", "locale", "email_verified", "is_admin",
+			"created_at", "updated_at", "last_login",
+			"profile_visibility", "activity_visibility", "progress_visibility",
+			"allow_followers", "show_in_leaderboards", "show_completed_courses",
+		}).AddRow("user-1", "jane@example.com", "hash", "Jane", "", "en", true, false, now, now, now,
+			nil, nil, nil, nil, nil, nil))
+	mock.ExpectExec(`INSERT INTO user_archetypes`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	variableInsert := mock.ExpectPrepare(`INSERT INTO user_variables`)
+	for range variables {
+		variableInsert.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	// Course generation through the learning service
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(
+		sqlmock.NewRows([]string{
+			"id", "module_number", "title_template", "description_template", "difficulty",
+			"estimated_hours", "learning_objectives", "variable_schema", "created_at", "updated_at",
+		}).AddRow("bp-1", 1, "Modeling the {ENTITY}", "Define the {ENTITY}", "beginner", 2, nil, nil, now, now),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO generated_courses`).
+		WithArgs(sqlmock.AnyArg(), "user-1", sqlmock.AnyArg(), "Modeling the Order", sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare(`INSERT INTO generated_modules`).
+		ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = identityService.CompleteOnboarding(context.Background(), "user-1", "Digital", "E-commerce", "beginner", "", variables)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLearningCourseGeneratorPropagatesErrors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	generator := learningCourseGenerator{learning: learning.NewService(learning.NewRepository(db), nil)}
+
+	// Without blueprints or an AI client there is nothing to build the course from
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(sqlmock.NewRows([]string{
+		"id", "module_number", "title_template", "description_template", "difficulty",
+		"estimated_hours", "learning_objectives", "variable_schema", "created_at", "updated_at",
+	}))
+
+	err = generator.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+
+	assert.ErrorIs(t, err, learning.ErrNoBlueprintModules)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProfilePrivacyLookupTranslatesUnknownUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	lookup := profilePrivacyLookup(identity.NewService(identity.NewRepository(db), "test-secret-key", 3600))
+
+	mock.ExpectQuery(`FROM users`).WithArgs("missing").WillReturnError(sql.ErrNoRows)
+
+	_, err = lookup("missing")
+
+	assert.ErrorIs(t, err, social.ErrUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,15 +5,29 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"backend/internal/platform/ai"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	AI       AIConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	AI         AIConfig
+	JWT        JWTConfig
+	Auth       AuthConfig
+	Text       TextConfig
+	Onboarding OnboardingConfig
+	Scoring    ScoringConfig
+	Curriculum CurriculumConfig
+	Learning   LearningConfig
+	Cache      CacheConfig
+	Trending   TrendingConfig
+	CORS       CORSConfig
+	Log        LogConfig
+	Health     HealthConfig
+
+	Recommendations RecommendationsConfig
 }
 
 // ServerConfig holds HTTP server configuration
@@ -21,8 +35,12 @@ type ServerConfig struct {
 	Port            string
 	Host            string
 	Env             string
-	ShutdownTimeout time.Duration // Graceful shutdown timeout
-	RequestTimeout  time.Duration // HTTP request timeout
+	ShutdownTimeout  time.Duration // Graceful shutdown timeout
+	RequestTimeout   time.Duration // HTTP request timeout
+	AIRequestTimeout time.Duration // Timeout for routes that wait on AI generation
+
+	SubmissionMaxBodySize int64 // Request body limit (bytes) for exercise submissions, which carry whole programs
+	AuthMaxBodySize       int64 // Request body limit (bytes) for the public auth routes
 }
 
 // DatabaseConfig holds PostgreSQL connection configuration
@@ -33,13 +51,44 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// Connection pool tuning; MaxIdleConns may not exceed MaxOpenConns
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
 // AIConfig holds AI service configuration (OpenAI, Anthropic, etc.)
 type AIConfig struct {
-	Provider string
-	APIKey   string
-	Model    string
+	Provider     string
+	APIKey       string
+	Model        string
+	StubFallback bool // Opt in to the "stub" provider when no API key is set (non-production only)
+
+	FallbackModels []string // Tried in order when Model is unavailable; empty means Model only
+
+	HealthCheck     bool          // Report the provider in the readiness probe
+	HealthFreshness time.Duration // A success this recent skips the readiness ping
+
+	DailyCallBudget int // AI calls each user may trigger per UTC day; 0 disables the limit
+
+	// Sampling settings per call type
+	Validation AICallConfig
+	Extraction AICallConfig
+	Curriculum AICallConfig
+	Review     AICallConfig
+}
+
+// AICallConfig holds completion settings for one kind of AI call
+type AICallConfig struct {
+	Temperature float64
+	MaxTokens   int
+}
+
+// Options converts the settings into AI client completion options
+func (c AICallConfig) Options() ai.CompletionOptions {
+	return ai.CompletionOptions{Temperature: c.Temperature, MaxTokens: c.MaxTokens}
 }
 
 // JWTConfig holds JWT authentication configuration
@@ -49,6 +98,102 @@ type JWTConfig struct {
 	ExpirationDuration time.Duration // JWT expiration as duration (derived from ExpirationSeconds)
 }
 
+// AuthConfig holds account security configuration
+type AuthConfig struct {
+	RequireEmailVerification bool          // Block login until the user verifies their email
+	EmailVerificationTTL     time.Duration // How long verification tokens stay valid
+	EmailLowercaseLocal      bool          // Treat the local part of email addresses as case-insensitive
+	EmailGmailCanonical      bool          // Ignore dots and +tags in Gmail addresses when detecting duplicates
+	RehashPasswordsOnLogin   bool          // Upgrade password hashes created with outdated parameters when users log in
+	BcryptCost               int           // Work factor for new password hashes
+}
+
+// Accepted BCRYPT_COST range: below 10 is too cheap to brute force, above 15
+// makes every login take seconds
+const (
+	MinBcryptCost = 10
+	MaxBcryptCost = 15
+)
+
+// TextConfig holds length caps for user-provided free text
+type TextConfig struct {
+	ShortMaxLength   int  // Names, domains, variable values
+	LongMaxLength    int  // Feedback, reviews, comments
+	TruncateOverflow bool // Truncate overlong text instead of rejecting it
+}
+
+// OnboardingConfig holds onboarding validation settings
+type OnboardingConfig struct {
+	StrictVariables      bool   // Only accept ENTITY/STATE/FLOW/LOGIC/INTERFACE with non-empty values
+	DefaultVariablesFile string // JSON file with per-archetype default variables; empty uses the built-in set
+	ValidateDomain       bool   // Ask the AI whether the domain is learnable before creating the archetype
+}
+
+// ScoringConfig holds exercise submission scoring settings
+type ScoringConfig struct {
+	PassThreshold int     // Minimum weighted score (1-100) that completes a module
+	VisibleWeight float64 // Weight of each visible test case
+	HiddenWeight  float64 // Weight of each hidden test case
+	HintPenalty   int     // Points deducted per revealed hint
+
+	EasyMultiplier   float64 // Exercise points multiplier for easy exercises
+	MediumMultiplier float64 // Exercise points multiplier for medium exercises
+	HardMultiplier   float64 // Exercise points multiplier for hard exercises
+}
+
+// CurriculumConfig holds settings for courses built from an AI curriculum
+type CurriculumConfig struct {
+	TargetModules int // Modules a complete course has; shorter curricula are saved as partial
+	MinModules    int // Fewest valid modules accepted before the curriculum is discarded
+}
+
+// LearningConfig holds exercise grading settings
+type LearningConfig struct {
+	LanguagesFile string // JSON file with the supported languages and their runner settings; empty uses the built-in set
+}
+
+// CacheConfig holds in-memory cache settings (a zero TTL or size disables a cache)
+type CacheConfig struct {
+	TrendingTTL            time.Duration
+	TrendingMaxSize        int
+	TrendingRefreshWait    bool // Wait for a concurrent trending refresh instead of skipping it
+	TrendingFullRebuild    bool // Rebuild the whole trending table at once instead of upserting per category
+	RecommendationsTTL     time.Duration
+	RecommendationsMaxSize int // Entries are per user and recommendation row
+}
+
+// TrendingConfig holds the background trending refresh schedule and how
+// signups are turned into a trending velocity
+type TrendingConfig struct {
+	RefreshInterval time.Duration // 0 disables the background refresh
+	RefreshJitter   time.Duration // Random delay added to each interval so replicas spread out
+
+	Window            time.Duration // Recent period whose signups are counted
+	PreviousWindow    time.Duration // Period before Window that recent signups are compared against
+	NewCourseVelocity float64       // Velocity of a course with no signups in the previous window (without smoothing)
+	Smoothing         float64       // Added to both signup counts, e.g. 1 for add-one smoothing
+	ScorePerVelocity  float64       // Recommendation match score points per 1x velocity
+}
+
+// RecommendationsConfig holds recommendation generation settings
+type RecommendationsConfig struct {
+	BatchSize      int           // Generated recommendations written per INSERT; 0 keeps the service default
+	SkillGraphFile string        // JSON file replacing the built-in skill graph; empty keeps the default
+	Timeout        time.Duration // How long a refresh waits for all recommendation algorithms; 0 keeps the service default
+
+	CleanupInterval time.Duration // How often expired recommendations are deleted; 0 disables the background cleanup
+}
+
+// LogConfig holds structured logging settings
+type LogConfig struct {
+	SanitizeFields bool // Escape newlines and control characters in logged values
+}
+
+// HealthConfig holds readiness check settings
+type HealthConfig struct {
+	MaxMemoryMB int // Heap size that fails readiness; 0 derives it from the container memory limit
+}
+
 // CORSConfig holds CORS configuration
 type CORSConfig struct {
 	AllowedOrigins string // Comma-separated list of allowed origins
@@ -73,13 +218,20 @@ func Load() (*Config, error) {
 	// JWT expiration in seconds (default 24 hours = 86400 seconds)
 	jwtExpirationSeconds := getEnvInt("JWT_EXPIRATION_SECONDS", 86400)
 
+	// Per-call AI defaults live in the ai package; env vars only override them
+	aiDefaults := ai.DefaultCompletionOptions()
+
 	cfg := &Config{
 		Server: ServerConfig{
 			Port:            getEnv("SERVER_PORT", "8080"),
 			Host:            getEnv("SERVER_HOST", "0.0.0.0"),
 			Env:             getEnv("SERVER_ENV", "development"),
-			ShutdownTimeout: getEnvDuration("GRACEFUL_SHUTDOWN_TIMEOUT", 30*time.Second),
-			RequestTimeout:  getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+			ShutdownTimeout:  getEnvDuration("GRACEFUL_SHUTDOWN_TIMEOUT", 30*time.Second),
+			RequestTimeout:   getEnvDuration("REQUEST_TIMEOUT", 30*time.Second),
+			AIRequestTimeout: getEnvDuration("AI_REQUEST_TIMEOUT", 3*time.Minute),
+
+			SubmissionMaxBodySize: int64(getEnvInt("MAX_REQUEST_SIZE_SUBMISSION", 2<<20)),
+			AuthMaxBodySize:       int64(getEnvInt("MAX_REQUEST_SIZE_AUTH", 16<<10)),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DATABASE_HOST", getEnv("DB_HOST", "localhost")),
@@ -88,20 +240,154 @@ func Load() (*Config, error) {
 			Password: getEnv("DATABASE_PASSWORD", getEnv("DB_PASSWORD", "postgres")),
 			DBName:   getEnv("DATABASE_NAME", getEnv("DB_NAME", "learnify")),
 			SSLMode:  getEnv("DATABASE_SSL_MODE", getEnv("DB_SSL_MODE", "disable")),
+
+			MaxOpenConns:    getEnvInt("DATABASE_MAX_CONNECTIONS", 25),
+			MaxIdleConns:    getEnvInt("DATABASE_MAX_IDLE_CONNECTIONS", 10),
+			ConnMaxLifetime: getEnvDuration("DATABASE_CONNECTION_MAX_LIFETIME", 5*time.Minute),
+			ConnMaxIdleTime: getEnvDuration("DATABASE_CONNECTION_MAX_IDLE_TIME", 2*time.Minute),
 		},
 		AI: AIConfig{
-			Provider: getEnv("AI_PROVIDER", "openai"),
-			APIKey:   getEnv("AI_API_KEY", ""),
-			Model:    getEnv("AI_MODEL", "gpt-4"),
+			Provider:     getEnv("AI_PROVIDER", "openai"),
+			APIKey:       getEnv("AI_API_KEY", ""),
+			Model:        getEnv("AI_MODEL", "gpt-4"),
+			StubFallback: getEnvBool("AI_STUB_FALLBACK", false),
+
+			FallbackModels: getEnvList("AI_FALLBACK_MODELS"),
+
+			HealthCheck:     getEnvBool("AI_HEALTH_CHECK", true),
+			HealthFreshness: getEnvDuration("AI_HEALTH_FRESHNESS", 5*time.Minute),
+			DailyCallBudget: getEnvInt("AI_DAILY_CALL_BUDGET", 50),
+			Validation:      getAICallConfig("AI_VALIDATION", aiDefaults[ai.CallValidateDomain]),
+			Extraction:      getAICallConfig("AI_EXTRACTION", aiDefaults[ai.CallExtractVariables]),
+			Curriculum:      getAICallConfig("AI_CURRICULUM", aiDefaults[ai.CallGenerateCurriculum]),
+			Review:          getAICallConfig("AI_REVIEW", aiDefaults[ai.CallReviewCode]),
 		},
 		JWT: JWTConfig{
 			Secret:             jwtSecret,
 			ExpirationSeconds:  jwtExpirationSeconds,
 			ExpirationDuration: time.Duration(jwtExpirationSeconds) * time.Second,
 		},
+		Auth: AuthConfig{
+			RequireEmailVerification: getEnvBool("AUTH_REQUIRE_EMAIL_VERIFICATION", false),
+			EmailVerificationTTL:     getEnvDuration("AUTH_EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			EmailLowercaseLocal:      getEnvBool("AUTH_EMAIL_LOWERCASE_LOCAL", true),
+			EmailGmailCanonical:      getEnvBool("AUTH_EMAIL_GMAIL_CANONICAL", false),
+			RehashPasswordsOnLogin:   getEnvBool("AUTH_REHASH_PASSWORDS_ON_LOGIN", true),
+			BcryptCost:               getEnvInt("BCRYPT_COST", 12),
+		},
+		Text: TextConfig{
+			ShortMaxLength:   getEnvInt("TEXT_SHORT_MAX_LENGTH", 100),
+			LongMaxLength:    getEnvInt("TEXT_LONG_MAX_LENGTH", 2000),
+			TruncateOverflow: getEnvBool("TEXT_TRUNCATE_OVERFLOW", false),
+		},
+		Onboarding: OnboardingConfig{
+			StrictVariables:      getEnvBool("ONBOARDING_STRICT_VARIABLES", true),
+			DefaultVariablesFile: getEnv("ONBOARDING_DEFAULT_VARIABLES_FILE", ""),
+			ValidateDomain:       getEnvBool("ONBOARDING_VALIDATE_DOMAIN", true),
+		},
+		Scoring: ScoringConfig{
+			PassThreshold: getEnvInt("SCORING_PASS_THRESHOLD", 100),
+			VisibleWeight: getEnvFloat("SCORING_VISIBLE_WEIGHT", 1),
+			HiddenWeight:  getEnvFloat("SCORING_HIDDEN_WEIGHT", 1),
+			HintPenalty:   getEnvInt("SCORING_HINT_PENALTY", 5),
+
+			EasyMultiplier:   getEnvFloat("SCORING_EASY_MULTIPLIER", 1.0),
+			MediumMultiplier: getEnvFloat("SCORING_MEDIUM_MULTIPLIER", 1.5),
+			HardMultiplier:   getEnvFloat("SCORING_HARD_MULTIPLIER", 2.0),
+		},
+		Curriculum: CurriculumConfig{
+			TargetModules: getEnvInt("CURRICULUM_TARGET_MODULES", 5),
+			MinModules:    getEnvInt("CURRICULUM_MIN_MODULES", 1),
+		},
+		Learning: LearningConfig{
+			LanguagesFile: getEnv("LEARNING_LANGUAGES_FILE", ""),
+		},
+		Cache: CacheConfig{
+			TrendingTTL:            getEnvDuration("CACHE_TRENDING_TTL", 5*time.Minute),
+			TrendingMaxSize:        getEnvInt("CACHE_TRENDING_MAX_SIZE", 16),
+			TrendingRefreshWait:    getEnvBool("CACHE_TRENDING_REFRESH_WAIT", false),
+			TrendingFullRebuild:    getEnvBool("CACHE_TRENDING_FULL_REBUILD", false),
+			RecommendationsTTL:     getEnvDuration("CACHE_RECOMMENDATIONS_TTL", 10*time.Minute),
+			RecommendationsMaxSize: getEnvInt("CACHE_RECOMMENDATIONS_MAX_SIZE", 10000),
+		},
+		Trending: TrendingConfig{
+			RefreshInterval: getEnvDuration("TRENDING_REFRESH_INTERVAL", 15*time.Minute),
+			RefreshJitter:   getEnvDuration("TRENDING_REFRESH_JITTER", time.Minute),
+
+			Window:            getEnvDuration("TRENDING_WINDOW", 24*time.Hour),
+			PreviousWindow:    getEnvDuration("TRENDING_PREVIOUS_WINDOW", 24*time.Hour),
+			NewCourseVelocity: getEnvFloat("TRENDING_NEW_COURSE_VELOCITY", 10),
+			Smoothing:         getEnvFloat("TRENDING_SMOOTHING", 0),
+			ScorePerVelocity:  getEnvFloat("TRENDING_SCORE_PER_VELOCITY", 10),
+		},
 		CORS: CORSConfig{
 			AllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "*"),
 		},
+		Log: LogConfig{
+			SanitizeFields: getEnvBool("LOG_SANITIZE_FIELDS", true),
+		},
+		Health: HealthConfig{
+			MaxMemoryMB: getEnvInt("HEALTH_MAX_MEMORY_MB", 0),
+		},
+		Recommendations: RecommendationsConfig{
+			BatchSize:      getEnvInt("RECOMMENDATIONS_BATCH_SIZE", 0),
+			SkillGraphFile: getEnv("RECOMMENDATIONS_SKILL_GRAPH_FILE", ""),
+			Timeout:        getEnvDuration("RECOMMENDATIONS_TIMEOUT", 0),
+
+			CleanupInterval: getEnvDuration("RECOMMENDATIONS_CLEANUP_INTERVAL", time.Hour),
+		},
+	}
+
+	if err := validateAICallConfigs(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateDatabasePool(cfg.Database); err != nil {
+		return nil, err
+	}
+	if cfg.Auth.BcryptCost < MinBcryptCost || cfg.Auth.BcryptCost > MaxBcryptCost {
+		return nil, &ConfigError{
+			Field:   "BCRYPT_COST",
+			Message: "BCRYPT_COST must be between " + strconv.Itoa(MinBcryptCost) + " and " + strconv.Itoa(MaxBcryptCost),
+		}
+	}
+	if cfg.AI.DailyCallBudget < 0 {
+		return nil, &ConfigError{
+			Field:   "AI_DAILY_CALL_BUDGET",
+			Message: "AI_DAILY_CALL_BUDGET must not be negative (0 disables the limit)",
+		}
+	}
+	if cfg.Health.MaxMemoryMB < 0 {
+		return nil, &ConfigError{
+			Field:   "HEALTH_MAX_MEMORY_MB",
+			Message: "HEALTH_MAX_MEMORY_MB must not be negative",
+		}
+	}
+	if cfg.Server.SubmissionMaxBodySize <= 0 {
+		return nil, &ConfigError{
+			Field:   "MAX_REQUEST_SIZE_SUBMISSION",
+			Message: "MAX_REQUEST_SIZE_SUBMISSION must be positive",
+		}
+	}
+	if cfg.Server.AuthMaxBodySize <= 0 {
+		return nil, &ConfigError{
+			Field:   "MAX_REQUEST_SIZE_AUTH",
+			Message: "MAX_REQUEST_SIZE_AUTH must be positive",
+		}
+	}
+	if err := validateScoring(cfg.Scoring); err != nil {
+		return nil, err
+	}
+	if err := validateCurriculum(cfg.Curriculum); err != nil {
+		return nil, err
+	}
+	if err := validateTrending(cfg.Trending); err != nil {
+		return nil, err
+	}
+
+	// Local development without an API key runs AI flows against canned responses
+	if cfg.Server.Env != "production" && cfg.AI.APIKey == "" && cfg.AI.Provider != "stub" && cfg.AI.StubFallback {
+		logWarning("AI_API_KEY is not set - falling back to the stub AI provider")
+		cfg.AI.Provider = "stub"
 	}
 
 	// Validate and warn about configuration issues
@@ -115,6 +401,153 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// validateAICallConfigs rejects per-call sampling settings the providers would refuse
+func validateAICallConfigs(cfg *Config) error {
+	calls := []struct {
+		prefix string
+		call   AICallConfig
+	}{
+		{"AI_VALIDATION", cfg.AI.Validation},
+		{"AI_EXTRACTION", cfg.AI.Extraction},
+		{"AI_CURRICULUM", cfg.AI.Curriculum},
+		{"AI_REVIEW", cfg.AI.Review},
+	}
+	for _, c := range calls {
+		if err := c.call.Options().Validate(); err != nil {
+			return &ConfigError{
+				Field:   c.prefix + "_TEMPERATURE/" + c.prefix + "_MAX_TOKENS",
+				Message: err.Error(),
+			}
+		}
+	}
+	return nil
+}
+
+// validateDatabasePool rejects pool settings database/sql would silently
+// adjust or treat as unlimited
+func validateDatabasePool(db DatabaseConfig) error {
+	switch {
+	case db.MaxOpenConns <= 0:
+		return &ConfigError{
+			Field:   "DATABASE_MAX_CONNECTIONS",
+			Message: "DATABASE_MAX_CONNECTIONS must be positive",
+		}
+	case db.MaxIdleConns <= 0:
+		return &ConfigError{
+			Field:   "DATABASE_MAX_IDLE_CONNECTIONS",
+			Message: "DATABASE_MAX_IDLE_CONNECTIONS must be positive",
+		}
+	case db.MaxIdleConns > db.MaxOpenConns:
+		return &ConfigError{
+			Field:   "DATABASE_MAX_IDLE_CONNECTIONS",
+			Message: "DATABASE_MAX_IDLE_CONNECTIONS must not exceed DATABASE_MAX_CONNECTIONS (" + strconv.Itoa(db.MaxOpenConns) + ")",
+		}
+	case db.ConnMaxLifetime <= 0:
+		return &ConfigError{
+			Field:   "DATABASE_CONNECTION_MAX_LIFETIME",
+			Message: "DATABASE_CONNECTION_MAX_LIFETIME must be positive",
+		}
+	case db.ConnMaxIdleTime <= 0:
+		return &ConfigError{
+			Field:   "DATABASE_CONNECTION_MAX_IDLE_TIME",
+			Message: "DATABASE_CONNECTION_MAX_IDLE_TIME must be positive",
+		}
+	}
+	return nil
+}
+
+// validateScoring rejects scoring settings that could not grade a submission
+func validateScoring(s ScoringConfig) error {
+	switch {
+	case s.PassThreshold < 1 || s.PassThreshold > 100:
+		return &ConfigError{
+			Field:   "SCORING_PASS_THRESHOLD",
+			Message: "SCORING_PASS_THRESHOLD must be between 1 and 100",
+		}
+	case s.VisibleWeight <= 0:
+		return &ConfigError{
+			Field:   "SCORING_VISIBLE_WEIGHT",
+			Message: "SCORING_VISIBLE_WEIGHT must be positive",
+		}
+	case s.HiddenWeight <= 0:
+		return &ConfigError{
+			Field:   "SCORING_HIDDEN_WEIGHT",
+			Message: "SCORING_HIDDEN_WEIGHT must be positive",
+		}
+	case s.HintPenalty < 0 || s.HintPenalty > 100:
+		return &ConfigError{
+			Field:   "SCORING_HINT_PENALTY",
+			Message: "SCORING_HINT_PENALTY must be between 0 and 100",
+		}
+	case s.EasyMultiplier <= 0:
+		return &ConfigError{
+			Field:   "SCORING_EASY_MULTIPLIER",
+			Message: "SCORING_EASY_MULTIPLIER must be positive",
+		}
+	case s.MediumMultiplier <= 0:
+		return &ConfigError{
+			Field:   "SCORING_MEDIUM_MULTIPLIER",
+			Message: "SCORING_MEDIUM_MULTIPLIER must be positive",
+		}
+	case s.HardMultiplier <= 0:
+		return &ConfigError{
+			Field:   "SCORING_HARD_MULTIPLIER",
+			Message: "SCORING_HARD_MULTIPLIER must be positive",
+		}
+	}
+	return nil
+}
+
+// validateCurriculum rejects module counts no curriculum could satisfy
+func validateCurriculum(c CurriculumConfig) error {
+	switch {
+	case c.MinModules < 1:
+		return &ConfigError{
+			Field:   "CURRICULUM_MIN_MODULES",
+			Message: "CURRICULUM_MIN_MODULES must be at least 1",
+		}
+	case c.TargetModules < c.MinModules:
+		return &ConfigError{
+			Field:   "CURRICULUM_TARGET_MODULES",
+			Message: "CURRICULUM_TARGET_MODULES must not be below CURRICULUM_MIN_MODULES (" + strconv.Itoa(c.MinModules) + ")",
+		}
+	}
+	return nil
+}
+
+// validateTrending rejects velocity settings that would divide by zero or
+// rank courses by a negative velocity
+func validateTrending(t TrendingConfig) error {
+	switch {
+	case t.Window <= 0:
+		return &ConfigError{
+			Field:   "TRENDING_WINDOW",
+			Message: "TRENDING_WINDOW must be positive",
+		}
+	case t.PreviousWindow <= 0:
+		return &ConfigError{
+			Field:   "TRENDING_PREVIOUS_WINDOW",
+			Message: "TRENDING_PREVIOUS_WINDOW must be positive",
+		}
+	case t.NewCourseVelocity < 0:
+		return &ConfigError{
+			Field:   "TRENDING_NEW_COURSE_VELOCITY",
+			Message: "TRENDING_NEW_COURSE_VELOCITY must not be negative",
+		}
+	case t.Smoothing < 0:
+		return &ConfigError{
+			Field:   "TRENDING_SMOOTHING",
+			Message: "TRENDING_SMOOTHING must not be negative",
+		}
+	case t.ScorePerVelocity < 0:
+		return &ConfigError{
+			Field:   "TRENDING_SCORE_PER_VELOCITY",
+			Message: "TRENDING_SCORE_PER_VELOCITY must not be negative",
+		}
+	}
+	return nil
+}
+
 // validateProductionConfig ensures production environment has secure configuration
 func validateProductionConfig(cfg *Config) error {
 	// Require strong database password in production
@@ -125,6 +558,14 @@ func validateProductionConfig(cfg *Config) error {
 		}
 	}
 
+	// Canned AI responses are for development and tests only
+	if cfg.AI.Provider == "stub" {
+		return &ConfigError{
+			Field:   "AI_PROVIDER",
+			Message: "The stub AI provider cannot be used in production",
+		}
+	}
+
 	// Require AI API key in production
 	if cfg.AI.APIKey == "" {
 		return &ConfigError{
@@ -190,6 +631,17 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList reads a comma-separated list, dropping blank entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // getEnvInt retrieves an environment variable as an integer or returns a default value
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -200,6 +652,24 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getAICallConfig reads <prefix>_TEMPERATURE and <prefix>_MAX_TOKENS, falling back to defaults
+func getAICallConfig(prefix string, defaults ai.CompletionOptions) AICallConfig {
+	return AICallConfig{
+		Temperature: getEnvFloat(prefix+"_TEMPERATURE", defaults.Temperature),
+		MaxTokens:   getEnvInt(prefix+"_MAX_TOKENS", defaults.MaxTokens),
+	}
+}
+
+// getEnvFloat retrieves an environment variable as a float or returns a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvBool retrieves an environment variable as a boolean or returns a default value
 // Accepts: "true", "1", "yes", "on" as true (case-insensitive)
 // Accepts: "false", "0", "no", "off" as false (case-insensitive)
@@ -0,0 +1,151 @@
+package announcements
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/jsonbody"
+	"backend/internal/platform/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for announcements
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new announcements handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers all announcement routes
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	// User routes
+	r.HandleFunc("/api/announcements/active", h.GetActiveAnnouncements).Methods("GET")
+	r.HandleFunc("/api/announcements/{id}/acknowledge", h.AcknowledgeAnnouncement).Methods("POST")
+
+	// Admin routes
+	r.HandleFunc("/api/admin/announcements", h.ListAnnouncements).Methods("GET")
+	r.HandleFunc("/api/admin/announcements", h.CreateAnnouncement).Methods("POST")
+	r.HandleFunc("/api/admin/announcements/{id}", h.UpdateAnnouncement).Methods("PUT")
+	r.HandleFunc("/api/admin/announcements/{id}", h.DeleteAnnouncement).Methods("DELETE")
+}
+
+// SuccessResponse represents a success response
+type SuccessResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+}
+
+// writeServiceError maps service errors to HTTP status codes
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidAnnouncement):
+		apierror.WriteError(w, apierror.BadRequest(err.Error()))
+	case errors.Is(err, ErrAnnouncementNotFound):
+		apierror.WriteError(w, apierror.NotFound(err.Error()))
+	default:
+		apierror.WriteError(w, apierror.Internal(err))
+	}
+}
+
+// GetActiveAnnouncements handles GET /api/announcements/active
+// Authentication is optional; signed-in users do not see announcements they acknowledged.
+func (h *Handler) GetActiveAnnouncements(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
+
+	announcements, err := h.service.GetActiveAnnouncements(userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	jsonbody.Write(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    announcements,
+	})
+}
+
+// AcknowledgeAnnouncement handles POST /api/announcements/{id}/acknowledge
+func (h *Handler) AcknowledgeAnnouncement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apierror.WriteError(w, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	if err := h.service.AcknowledgeAnnouncement(userID, mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAnnouncements handles GET /api/admin/announcements
+func (h *Handler) ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	announcements, err := h.service.ListAnnouncements()
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	jsonbody.Write(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    announcements,
+	})
+}
+
+// CreateAnnouncement handles POST /api/admin/announcements
+func (h *Handler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	var input AnnouncementInput
+	if err := jsonbody.Decode(r, &input); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	adminID, _ := middleware.GetUserIDFromContext(r.Context())
+	announcement, err := h.service.CreateAnnouncement(adminID, input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	jsonbody.Write(w, http.StatusCreated, SuccessResponse{
+		Success: true,
+		Data:    announcement,
+	})
+}
+
+// UpdateAnnouncement handles PUT /api/admin/announcements/{id}
+func (h *Handler) UpdateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	var input AnnouncementInput
+	if err := jsonbody.Decode(r, &input); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	announcement, err := h.service.UpdateAnnouncement(mux.Vars(r)["id"], input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	jsonbody.Write(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    announcement,
+	})
+}
+
+// DeleteAnnouncement handles DELETE /api/admin/announcements/{id}
+func (h *Handler) DeleteAnnouncement(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteAnnouncement(mux.Vars(r)["id"]); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
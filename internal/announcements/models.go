@@ -0,0 +1,26 @@
+package announcements
+
+import "time"
+
+// Announcement is an in-app message shown to users while its window is open
+type Announcement struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Category  string     `json:"category"`
+	StartsAt  time.Time  `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// AnnouncementInput is the admin-editable part of an announcement.
+// A nil StartsAt means "now"; a nil EndsAt leaves the announcement open-ended.
+type AnnouncementInput struct {
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	Category string     `json:"category"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
@@ -0,0 +1,215 @@
+package announcements
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository handles announcement data access
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new announcements repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// announcementColumns is the column list scanned by scanAnnouncement
+const announcementColumns = `
+	id, title, body, category, starts_at, ends_at,
+	COALESCE(created_by::text, ''), created_at, updated_at
+`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAnnouncement reads one row selected with announcementColumns
+func scanAnnouncement(row rowScanner) (*Announcement, error) {
+	var a Announcement
+	var endsAt sql.NullTime
+
+	err := row.Scan(
+		&a.ID,
+		&a.Title,
+		&a.Body,
+		&a.Category,
+		&a.StartsAt,
+		&endsAt,
+		&a.CreatedBy,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if endsAt.Valid {
+		a.EndsAt = &endsAt.Time
+	}
+	return &a, nil
+}
+
+// CreateAnnouncement inserts a new announcement
+func (r *Repository) CreateAnnouncement(a *Announcement) error {
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+
+	query := `
+		INSERT INTO announcements (id, title, body, category, starts_at, ends_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid, $8, $8)
+	`
+
+	now := time.Now()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+
+	_, err := r.db.Exec(query, a.ID, a.Title, a.Body, a.Category, a.StartsAt, a.EndsAt, a.CreatedBy, now)
+	if err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAnnouncement replaces the editable fields of an announcement
+func (r *Repository) UpdateAnnouncement(a *Announcement) error {
+	query := `
+		UPDATE announcements
+		SET title = $1, body = $2, category = $3, starts_at = $4, ends_at = $5, updated_at = $6
+		WHERE id = $7
+	`
+
+	a.UpdatedAt = time.Now()
+	result, err := r.db.Exec(query, a.Title, a.Body, a.Category, a.StartsAt, a.EndsAt, a.UpdatedAt, a.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update announcement: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrAnnouncementNotFound
+	}
+
+	return nil
+}
+
+// DeleteAnnouncement removes an announcement and its acknowledgments
+func (r *Repository) DeleteAnnouncement(id string) error {
+	result, err := r.db.Exec(`DELETE FROM announcements WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete announcement: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrAnnouncementNotFound
+	}
+
+	return nil
+}
+
+// GetAnnouncement retrieves an announcement by ID
+func (r *Repository) GetAnnouncement(id string) (*Announcement, error) {
+	query := `SELECT ` + announcementColumns + ` FROM announcements WHERE id = $1`
+
+	a, err := scanAnnouncement(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrAnnouncementNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcement: %w", err)
+	}
+
+	return a, nil
+}
+
+// ListAnnouncements returns every announcement, latest scheduled first
+func (r *Repository) ListAnnouncements() ([]Announcement, error) {
+	query := `SELECT ` + announcementColumns + ` FROM announcements ORDER BY starts_at DESC, id DESC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcements: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAnnouncements(rows)
+}
+
+// GetActiveAnnouncements returns announcements whose window contains at, newest first.
+// When userID is set, announcements that user has acknowledged are left out.
+func (r *Repository) GetActiveAnnouncements(at time.Time, userID string) ([]Announcement, error) {
+	query := `
+		SELECT ` + announcementColumns + `
+		FROM announcements a
+		WHERE a.starts_at <= $1
+			AND (a.ends_at IS NULL OR a.ends_at > $1)
+	`
+	args := []interface{}{at}
+
+	if userID != "" {
+		query += `
+			AND NOT EXISTS (
+				SELECT 1 FROM announcement_acknowledgments aa
+				WHERE aa.announcement_id = a.id AND aa.user_id = $2
+			)
+		`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY a.starts_at DESC, a.id DESC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active announcements: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAnnouncements(rows)
+}
+
+// scanAnnouncements reads all rows selected with announcementColumns
+func scanAnnouncements(rows *sql.Rows) ([]Announcement, error) {
+	announcements := []Announcement{}
+	for rows.Next() {
+		a, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan announcement: %w", err)
+		}
+		announcements = append(announcements, *a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating announcements: %w", err)
+	}
+
+	return announcements, nil
+}
+
+// AcknowledgeAnnouncement records that the user dismissed an announcement.
+// Acknowledging twice is a no-op.
+func (r *Repository) AcknowledgeAnnouncement(userID, announcementID string) error {
+	query := `
+		INSERT INTO announcement_acknowledgments (user_id, announcement_id, acknowledged_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id, announcement_id) DO NOTHING
+	`
+
+	if _, err := r.db.Exec(query, userID, announcementID); err != nil {
+		return fmt.Errorf("failed to acknowledge announcement: %w", err)
+	}
+
+	return nil
+}
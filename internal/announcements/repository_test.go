@@ -0,0 +1,57 @@
+package announcements
+
+import (
+	"testing"
+	"time"
+
+	"backend/tests/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetActiveAnnouncements_WindowAndAcknowledgment(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Announcement User")
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	hour := time.Hour
+	ended := now.Add(-hour)
+	closes := now.Add(hour)
+
+	open := &Announcement{Title: "Open-ended", Category: CategoryFeature, StartsAt: now.Add(-2 * hour)}
+	windowed := &Announcement{Title: "Windowed", Category: CategoryMaintenance, StartsAt: now.Add(-hour), EndsAt: &closes}
+	expired := &Announcement{Title: "Expired", Category: CategoryInfo, StartsAt: now.Add(-3 * hour), EndsAt: &ended}
+	scheduled := &Announcement{Title: "Scheduled", Category: CategoryInfo, StartsAt: now.Add(hour)}
+	for _, a := range []*Announcement{open, windowed, expired, scheduled} {
+		require.NoError(t, repo.CreateAnnouncement(a))
+	}
+	t.Cleanup(func() {
+		for _, a := range []*Announcement{open, windowed, expired, scheduled} {
+			repo.DeleteAnnouncement(a.ID)
+		}
+	})
+
+	activeIDs := func(userID string) []string {
+		active, err := repo.GetActiveAnnouncements(now, userID)
+		require.NoError(t, err)
+		ids := make([]string, len(active))
+		for i, a := range active {
+			ids[i] = a.ID
+		}
+		return ids
+	}
+
+	// Only announcements whose window contains now, newest start first
+	assert.Equal(t, []string{windowed.ID, open.ID}, activeIDs(""))
+	assert.Equal(t, []string{windowed.ID, open.ID}, activeIDs(userID))
+
+	// Acknowledging hides the announcement for that user only; repeating it is harmless
+	require.NoError(t, repo.AcknowledgeAnnouncement(userID, windowed.ID))
+	require.NoError(t, repo.AcknowledgeAnnouncement(userID, windowed.ID))
+
+	assert.Equal(t, []string{open.ID}, activeIDs(userID))
+	assert.Equal(t, []string{windowed.ID, open.ID}, activeIDs(""))
+}
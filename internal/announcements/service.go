@@ -0,0 +1,159 @@
+package announcements
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/platform/validation"
+
+	"github.com/google/uuid"
+)
+
+// Announcement categories
+const (
+	CategoryInfo        = "info"
+	CategoryMaintenance = "maintenance"
+	CategoryFeature     = "feature"
+)
+
+var (
+	// ErrAnnouncementNotFound is returned when no announcement has the given ID
+	ErrAnnouncementNotFound = errors.New("announcement not found")
+
+	// ErrInvalidAnnouncement is returned when admin input fails validation
+	ErrInvalidAnnouncement = errors.New("invalid announcement")
+)
+
+// Service handles announcement business logic
+type Service struct {
+	repo       *Repository
+	textLimits validation.TextLimits
+	now        func() time.Time
+}
+
+// NewService creates a new announcements service
+func NewService(repo *Repository) *Service {
+	return &Service{
+		repo:       repo,
+		textLimits: validation.DefaultTextLimits(),
+		now:        time.Now,
+	}
+}
+
+// WithTextLimits sets the length caps applied to titles and bodies
+func (s *Service) WithTextLimits(limits validation.TextLimits) *Service {
+	s.textLimits = limits
+	return s
+}
+
+// buildAnnouncement validates and sanitizes admin input into an announcement
+func (s *Service) buildAnnouncement(input AnnouncementInput) (*Announcement, error) {
+	title, err := validation.SanitizeText("title", input.Title, s.textLimits.Short())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAnnouncement, err)
+	}
+	if title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidAnnouncement)
+	}
+
+	body, err := validation.SanitizeText("body", input.Body, s.textLimits.Long())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAnnouncement, err)
+	}
+
+	category := input.Category
+	if category == "" {
+		category = CategoryInfo
+	}
+	if category != CategoryInfo && category != CategoryMaintenance && category != CategoryFeature {
+		return nil, fmt.Errorf("%w: category must be one of: %s, %s, %s",
+			ErrInvalidAnnouncement, CategoryInfo, CategoryMaintenance, CategoryFeature)
+	}
+
+	startsAt := s.now()
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+	}
+	if input.EndsAt != nil && !input.EndsAt.After(startsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidAnnouncement)
+	}
+
+	return &Announcement{
+		Title:    title,
+		Body:     body,
+		Category: category,
+		StartsAt: startsAt,
+		EndsAt:   input.EndsAt,
+	}, nil
+}
+
+// CreateAnnouncement schedules a new announcement
+func (s *Service) CreateAnnouncement(createdBy string, input AnnouncementInput) (*Announcement, error) {
+	announcement, err := s.buildAnnouncement(input)
+	if err != nil {
+		return nil, err
+	}
+	announcement.CreatedBy = createdBy
+
+	if err := s.repo.CreateAnnouncement(announcement); err != nil {
+		return nil, err
+	}
+	return announcement, nil
+}
+
+// validateAnnouncementID rejects IDs that are not UUIDs before they reach the database
+func validateAnnouncementID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("%w: id must be a UUID", ErrInvalidAnnouncement)
+	}
+	return nil
+}
+
+// UpdateAnnouncement replaces an announcement's content and schedule
+func (s *Service) UpdateAnnouncement(id string, input AnnouncementInput) (*Announcement, error) {
+	if err := validateAnnouncementID(id); err != nil {
+		return nil, err
+	}
+
+	announcement, err := s.buildAnnouncement(input)
+	if err != nil {
+		return nil, err
+	}
+	announcement.ID = id
+
+	if err := s.repo.UpdateAnnouncement(announcement); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAnnouncement(id)
+}
+
+// DeleteAnnouncement removes an announcement
+func (s *Service) DeleteAnnouncement(id string) error {
+	if err := validateAnnouncementID(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteAnnouncement(id)
+}
+
+// ListAnnouncements returns all announcements, including scheduled and expired ones
+func (s *Service) ListAnnouncements() ([]Announcement, error) {
+	return s.repo.ListAnnouncements()
+}
+
+// GetActiveAnnouncements returns the announcements currently shown to userID.
+// An empty userID (anonymous caller) sees every active announcement.
+func (s *Service) GetActiveAnnouncements(userID string) ([]Announcement, error) {
+	return s.repo.GetActiveAnnouncements(s.now(), userID)
+}
+
+// AcknowledgeAnnouncement hides an announcement from the user from now on
+func (s *Service) AcknowledgeAnnouncement(userID, announcementID string) error {
+	if err := validateAnnouncementID(announcementID); err != nil {
+		return err
+	}
+	if _, err := s.repo.GetAnnouncement(announcementID); err != nil {
+		return err
+	}
+	return s.repo.AcknowledgeAnnouncement(userID, announcementID)
+}
@@ -0,0 +1,192 @@
+package announcements
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Announcement IDs used by the mock-backed tests
+const (
+	announcementID = "0b6d3f4e-8a41-4c1f-9d7e-5c2a1b3e4f60"
+	missingID      = "9e8d7c6b-5a49-4382-b1a0-f9e8d7c6b5a4"
+)
+
+// fixedNow is the clock used by mock-backed services
+var fixedNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newMockService returns a service backed by a sqlmock database and a fixed clock
+func newMockService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	service := NewService(NewRepository(db))
+	service.now = func() time.Time { return fixedNow }
+	return service, mock
+}
+
+// announcementRows returns rows shaped like announcementColumns
+func announcementRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "title", "body", "category", "starts_at", "ends_at", "created_by", "created_at", "updated_at",
+	})
+}
+
+func TestCreateAnnouncement_Validation(t *testing.T) {
+	service, mock := newMockService(t)
+	before := fixedNow.Add(-time.Hour)
+
+	inputs := []AnnouncementInput{
+		{Title: "   "},
+		{Title: "Maintenance", Category: "urgent"},
+		{Title: "Maintenance", EndsAt: &before},
+		{Title: strings.Repeat("x", 101)},
+	}
+	for _, input := range inputs {
+		_, err := service.CreateAnnouncement("admin-1", input)
+		assert.ErrorIs(t, err, ErrInvalidAnnouncement, "%+v", input)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAnnouncement_DefaultsToNowAndInfo(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectExec(`INSERT INTO announcements`).
+		WithArgs(sqlmock.AnyArg(), "New courses", "", CategoryInfo, fixedNow, nil, "admin-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	announcement, err := service.CreateAnnouncement("admin-1", AnnouncementInput{Title: "New courses"})
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, announcement.ID)
+	assert.Equal(t, fixedNow, announcement.StartsAt)
+	assert.Nil(t, announcement.EndsAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetActiveAnnouncements_FiltersByWindow(t *testing.T) {
+	service, mock := newMockService(t)
+
+	// Anonymous callers only get the window filter
+	mock.ExpectQuery(`WHERE a.starts_at <= \$1\s+AND \(a.ends_at IS NULL OR a.ends_at > \$1\)\s+ORDER BY`).
+		WithArgs(fixedNow).
+		WillReturnRows(announcementRows().
+			AddRow(announcementID, "Maintenance", "Tonight", CategoryMaintenance, fixedNow.Add(-time.Hour), fixedNow.Add(time.Hour), "", fixedNow, fixedNow))
+
+	active, err := service.GetActiveAnnouncements("")
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, announcementID, active[0].ID)
+	require.NotNil(t, active[0].EndsAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetActiveAnnouncements_HidesAcknowledged(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`NOT EXISTS \(\s+SELECT 1 FROM announcement_acknowledgments aa\s+WHERE aa.announcement_id = a.id AND aa.user_id = \$2`).
+		WithArgs(fixedNow, "user-1").
+		WillReturnRows(announcementRows())
+
+	active, err := service.GetActiveAnnouncements("user-1")
+	require.NoError(t, err)
+	assert.Empty(t, active)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcknowledgeAnnouncement_UnknownID(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`FROM announcements WHERE id = \$1`).
+		WithArgs(missingID).
+		WillReturnError(sql.ErrNoRows)
+
+	err := service.AcknowledgeAnnouncement("user-1", missingID)
+	assert.ErrorIs(t, err, ErrAnnouncementNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcknowledgeAnnouncementHandler(t *testing.T) {
+	service, mock := newMockService(t)
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	// Anonymous callers cannot acknowledge
+	req := httptest.NewRequest(http.MethodPost, "/api/announcements/"+announcementID+"/acknowledge", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	mock.ExpectQuery(`FROM announcements WHERE id = \$1`).
+		WithArgs(announcementID).
+		WillReturnRows(announcementRows().
+			AddRow(announcementID, "Maintenance", "", CategoryMaintenance, fixedNow, nil, "", fixedNow, fixedNow))
+	mock.ExpectExec(`INSERT INTO announcement_acknowledgments .* ON CONFLICT \(user_id, announcement_id\) DO NOTHING`).
+		WithArgs("user-1", announcementID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req = httptest.NewRequest(http.MethodPost, "/api/announcements/"+announcementID+"/acknowledge", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateAnnouncementHandler_RejectsInvalid(t *testing.T) {
+	service, _ := newMockService(t)
+	handler := NewHandler(service)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/announcements", strings.NewReader(`{"title": "", "category": "info"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.CreateAnnouncement(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	var body apierror.Response
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Contains(t, body.Message, "title is required")
+}
+
+func TestAnnouncementHandlers_RejectMalformedID(t *testing.T) {
+	service, mock := newMockService(t)
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+	admin := &middleware.UserClaims{UserID: "admin-1", IsAdmin: true}
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/api/announcements/not-a-uuid/acknowledge", nil),
+		httptest.NewRequest(http.MethodPut, "/api/admin/announcements/not-a-uuid",
+			strings.NewReader(`{"title": "Maintenance", "body": "Tonight", "category": "maintenance"}`)),
+		httptest.NewRequest(http.MethodDelete, "/api/admin/announcements/not-a-uuid", nil),
+	}
+	for _, req := range requests {
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(middleware.ContextWithUser(req.Context(), admin))
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, req.Method+" "+req.URL.Path)
+	}
+
+	// Malformed IDs never reach the database
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,14 @@
+package identity
+
+// EmailSender delivers transactional emails to users
+type EmailSender interface {
+	SendVerificationEmail(to, name, token string) error
+}
+
+// NoopEmailSender discards all emails. Used when no provider is configured and in tests.
+type NoopEmailSender struct{}
+
+// SendVerificationEmail does nothing
+func (NoopEmailSender) SendVerificationEmail(to, name, token string) error {
+	return nil
+}
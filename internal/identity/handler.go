@@ -3,9 +3,15 @@ package identity
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
+	"backend/internal/platform/ai"
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/jsonbody"
 	"backend/internal/platform/middleware"
+	"backend/internal/platform/validation"
 )
 
 // Handler handles HTTP requests for identity domain
@@ -18,15 +24,12 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service}
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
 // UpdateProfileRequest represents profile update payload
 type UpdateProfileRequest struct {
-	Name      string `json:"name,omitempty"`
-	AvatarURL string `json:"avatar_url,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	AvatarURL string     `json:"avatar_url,omitempty"`
+	Locale    string     `json:"locale,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // UpdatedAt from GET /api/users/me; a stale value is rejected with 409
 }
 
 // OnboardingRequest represents onboarding completion payload
@@ -34,6 +37,7 @@ type OnboardingRequest struct {
 	MetaCategory string            `json:"meta_category"`
 	Domain       string            `json:"domain"`
 	SkillLevel   string            `json:"skill_level"`
+	Locale       string            `json:"locale,omitempty"`
 	Variables    map[string]string `json:"variables"`
 }
 
@@ -47,26 +51,32 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// respondError writes an error response
+// respondError writes an error response in the shared apierror envelope.
+// Internal server errors are logged and replaced by a generic message.
 func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, ErrorResponse{Error: message})
+	if status == http.StatusInternalServerError {
+		apierror.WriteError(w, apierror.Internal(errors.New(message)))
+		return
+	}
+	apierror.WriteError(w, apierror.FromStatus(status, message))
 }
 
 // Register handles POST /api/auth/register
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var req RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
 		return
 	}
 
 	authResp, err := h.service.Register(&req)
 	if err != nil {
 		status := http.StatusInternalServerError
-		if err.Error() == "invalid email format" ||
-			err.Error() == "password must be at least 8 characters" {
+		if errors.Is(err, ErrInvalidEmail) ||
+			errors.Is(err, ErrWeakPassword) ||
+			errors.Is(err, validation.ErrTextTooLong) {
 			status = http.StatusBadRequest
-		} else if err.Error() == "email already registered" {
+		} else if errors.Is(err, ErrEmailTaken) {
 			status = http.StatusConflict
 		}
 		respondError(w, status, err.Error())
@@ -79,16 +89,18 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 // Login handles POST /api/auth/login
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
 		return
 	}
 
 	authResp, err := h.service.Login(&req)
 	if err != nil {
 		status := http.StatusInternalServerError
-		if err.Error() == "invalid email or password" {
+		if errors.Is(err, ErrInvalidCredentials) {
 			status = http.StatusUnauthorized
+		} else if errors.Is(err, ErrEmailNotVerified) {
+			status = http.StatusForbidden
 		}
 		respondError(w, status, err.Error())
 		return
@@ -97,6 +109,64 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, authResp)
 }
 
+// VerifyEmail handles POST /api/auth/verify-email
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	err := h.service.VerifyEmail(req.Token)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidVerificationToken) {
+			status = http.StatusBadRequest
+		} else if errors.Is(err, ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(w, status, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "email verified successfully"})
+}
+
+// ResendVerification handles POST /api/auth/verify-email/resend
+// The response is the same whether or not the address belongs to an unverified account
+func (h *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req ResendVerificationRequest
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	if req.Email == "" {
+		respondError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	if err := h.service.ResendVerificationEmail(req.Email); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrVerificationResendTooSoon) {
+			status = http.StatusTooManyRequests
+		} else if errors.Is(err, ErrInvalidEmail) {
+			status = http.StatusBadRequest
+		}
+		respondError(w, status, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if the account exists and is unverified, a verification email has been sent",
+	})
+}
+
 // GetProfile handles GET /api/users/me
 func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
@@ -108,7 +178,7 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	user, err := h.service.GetProfile(userID)
 	if err != nil {
 		status := http.StatusInternalServerError
-		if err.Error() == "user not found" {
+		if errors.Is(err, ErrUserNotFound) {
 			status = http.StatusNotFound
 		}
 		respondError(w, status, err.Error())
@@ -119,6 +189,7 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateProfile handles PATCH /api/users/me
+// Only fields present and non-empty are changed; use PUT to clear a field.
 func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok || userID == "" {
@@ -127,8 +198,8 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req UpdateProfileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
 		return
 	}
 
@@ -139,18 +210,167 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	if req.AvatarURL != "" {
 		updates["avatar_url"] = req.AvatarURL
 	}
+	if req.Locale != "" {
+		updates["locale"] = req.Locale
+	}
+
+	if err := h.service.UpdateProfile(userID, updates, req.UpdatedAt); err != nil {
+		respondProfileError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "profile updated successfully"})
+}
+
+// ReplaceProfile handles PUT /api/users/me
+// Unlike PATCH, which only changes the fields present and non-empty, PUT
+// replaces the whole profile: omitted fields are stored empty, so sending no
+// avatar_url removes the avatar and no locale resets it to the default. The
+// name is required.
+func (h *Handler) ReplaceProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	if err := h.service.ReplaceProfile(userID, req.Name, req.AvatarURL, req.Locale, req.UpdatedAt); err != nil {
+		respondProfileError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "profile updated successfully"})
+}
+
+// respondProfileError maps a profile update failure to its response
+func respondProfileError(w http.ResponseWriter, err error) {
+	var fieldErr *validation.FieldError
+	if errors.As(err, &fieldErr) {
+		apierror.WriteError(w, apierror.InvalidField(fieldErr.Field, err.Error()))
+		return
+	}
+
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrUserNotFound) {
+		status = http.StatusNotFound
+	} else if errors.Is(err, ErrProfileConflict) {
+		status = http.StatusConflict
+	} else if errors.Is(err, ErrInvalidLocale) || errors.Is(err, validation.ErrTextTooLong) {
+		status = http.StatusBadRequest
+	}
+	respondError(w, status, err.Error())
+}
+
+// GetVariables handles GET /api/users/me/variables
+func (h *Handler) GetVariables(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	variables, err := h.service.GetVariables(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, variables)
+}
+
+// UpdateVariables handles PATCH /api/users/me/variables
+// Only the variables in the body change; "regenerate": true also generates a new course.
+func (h *Handler) UpdateVariables(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdateVariablesRequest
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	variables, err := h.service.UpdateVariables(r.Context(), userID, &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidVariables) || errors.Is(err, validation.ErrTextTooLong) {
+			status = http.StatusBadRequest
+		} else if errors.Is(err, ErrOnboardingIncomplete) {
+			status = http.StatusConflict
+		}
+		respondError(w, status, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, variables)
+}
+
+// UpdatePrivacySettings handles PATCH /api/users/me/privacy
+func (h *Handler) UpdatePrivacySettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
 
-	err := h.service.UpdateProfile(userID, updates)
+	var req UpdatePrivacyRequest
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	settings, err := h.service.UpdatePrivacySettings(userID, &req)
 	if err != nil {
 		status := http.StatusInternalServerError
-		if err.Error() == "user not found" {
+		if errors.Is(err, ErrUserNotFound) {
 			status = http.StatusNotFound
+		} else if errors.Is(err, ErrInvalidPrivacySettings) {
+			status = http.StatusBadRequest
 		}
 		respondError(w, status, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "profile updated successfully"})
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// DeleteAccount handles DELETE /api/users/me
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok || userID == "" {
+		respondError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+	if req.Password == "" {
+		respondError(w, http.StatusBadRequest, "password is required to delete the account")
+		return
+	}
+
+	if err := h.service.DeleteAccount(r.Context(), userID, req.Password); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrPasswordMismatch) {
+			status = http.StatusForbidden
+		}
+		respondError(w, status, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // CompleteOnboarding handles POST /api/onboarding/complete
@@ -162,8 +382,8 @@ func (h *Handler) CompleteOnboarding(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req OnboardingRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "invalid request body")
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
 		return
 	}
 
@@ -174,16 +394,35 @@ func (h *Handler) CompleteOnboarding(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := h.service.CompleteOnboarding(
+		r.Context(),
 		userID,
 		req.MetaCategory,
 		req.Domain,
 		req.SkillLevel,
+		req.Locale,
 		req.Variables,
 	)
 	if err != nil {
+		var fieldErr *validation.FieldError
+		if errors.As(err, &fieldErr) {
+			apierror.WriteError(w, apierror.InvalidField(fieldErr.Field, err.Error()))
+			return
+		}
+		if errors.Is(err, ErrDomainRejected) {
+			apierror.WriteError(w, apierror.InvalidField("domain", err.Error()))
+			return
+		}
+		if errors.Is(err, ai.ErrBudgetExceeded) {
+			apierror.WriteError(w, apierror.FromStatus(http.StatusTooManyRequests, err.Error()))
+			return
+		}
 		status := http.StatusInternalServerError
-		if err.Error() == "user not found" {
+		if errors.Is(err, ErrUserNotFound) {
 			status = http.StatusNotFound
+		} else if errors.Is(err, ErrAlreadyOnboarded) {
+			status = http.StatusConflict
+		} else if errors.Is(err, ErrInvalidLocale) || errors.Is(err, validation.ErrTextTooLong) || errors.Is(err, ErrInvalidVariables) {
+			status = http.StatusBadRequest
 		}
 		respondError(w, status, err.Error())
 		return
@@ -8,12 +8,16 @@ import (
 type User struct {
 	ID              string
 	Email           string
+	NormalizedEmail string `json:"-"` // Canonical form used for uniqueness and lookup
 	PasswordHash    string
 	Name            string
 	AvatarURL       string
+	Locale          string
+	EmailVerified   bool             `json:"email_verified"`
+	IsAdmin         bool             `json:"is_admin"`
 	PrivacySettings *PrivacySettings `json:"privacy_settings,omitempty"`
 	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	UpdatedAt       time.Time `json:"updated_at"` // Version sent back with profile updates
 	LastLogin       time.Time
 }
 
@@ -27,6 +31,40 @@ type PrivacySettings struct {
 	ShowCompletedCourses  bool   `json:"show_completed_courses"`
 }
 
+// Visibility levels for privacy settings
+const (
+	VisibilityPublic  = "public"
+	VisibilityFriends = "friends"
+	VisibilityPrivate = "private"
+)
+
+// DefaultPrivacySettings returns the settings of users who never changed them
+func DefaultPrivacySettings() *PrivacySettings {
+	return &PrivacySettings{
+		ProfileVisibility:    VisibilityFriends,
+		ActivityVisibility:   VisibilityFriends,
+		ProgressVisibility:   VisibilityFriends,
+		AllowFollowers:       true,
+		ShowInLeaderboards:   true,
+		ShowCompletedCourses: true,
+	}
+}
+
+// UpdatePrivacyRequest represents a partial privacy settings update; omitted fields keep their value
+type UpdatePrivacyRequest struct {
+	ProfileVisibility    *string `json:"profile_visibility,omitempty"`
+	ActivityVisibility   *string `json:"activity_visibility,omitempty"`
+	ProgressVisibility   *string `json:"progress_visibility,omitempty"`
+	AllowFollowers       *bool   `json:"allow_followers,omitempty"`
+	ShowInLeaderboards   *bool   `json:"show_in_leaderboards,omitempty"`
+	ShowCompletedCourses *bool   `json:"show_completed_courses,omitempty"`
+}
+
+// DeleteAccountRequest confirms account deletion with the user's current password
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
 // UserArchetype represents user's selected archetype
 type UserArchetype struct {
 	ID           string
@@ -48,6 +86,20 @@ type UserVariable struct {
 	CreatedAt     time.Time
 }
 
+// UserVariables are the variables personalizing the courses of the user's current archetype
+type UserVariables struct {
+	ArchetypeID string            `json:"archetype_id,omitempty"` // Empty before onboarding
+	Variables   map[string]string `json:"variables"`
+	Regenerated bool              `json:"regenerated,omitempty"` // A new course was generated from the update
+}
+
+// UpdateVariablesRequest changes some variables; omitted variables keep their value.
+// Regenerate generates a new course from the updated variables.
+type UpdateVariablesRequest struct {
+	Variables  map[string]string `json:"variables"`
+	Regenerate bool              `json:"regenerate"`
+}
+
 // RegisterRequest represents user registration payload
 type RegisterRequest struct {
 	Email    string `json:"email"`
@@ -55,6 +107,16 @@ type RegisterRequest struct {
 	Name     string `json:"name"`
 }
 
+// VerifyEmailRequest represents email verification payload
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// ResendVerificationRequest represents a request for a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
 // LoginRequest represents login payload
 type LoginRequest struct {
 	Email    string `json:"email"`
@@ -62,7 +124,8 @@ type LoginRequest struct {
 }
 
 // AuthResponse represents authentication response
+// Token is omitted when login requires a verified email the user doesn't have yet
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"`
 	User  User   `json:"user"`
 }
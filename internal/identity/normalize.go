@@ -0,0 +1,46 @@
+package identity
+
+import "strings"
+
+// gmailDomains are the domains that share Gmail's address semantics
+var gmailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+}
+
+// EmailNormalization controls how email addresses are canonicalized before
+// storage and lookup. The domain is always lowercased.
+type EmailNormalization struct {
+	LowercaseLocal bool // Treat the local part as case-insensitive
+	GmailCanonical bool // Strip dots and +tags from Gmail addresses and fold googlemail.com into gmail.com
+}
+
+// DefaultEmailNormalization lowercases the whole address without provider-specific rules
+func DefaultEmailNormalization() EmailNormalization {
+	return EmailNormalization{LowercaseLocal: true}
+}
+
+// Normalize returns the canonical form of email used to detect duplicate accounts
+func (n EmailNormalization) Normalize(email string) string {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+	if n.LowercaseLocal {
+		local = strings.ToLower(local)
+	}
+
+	if n.GmailCanonical && gmailDomains[domain] {
+		// Gmail ignores case, dots and anything after a plus in the local part
+		if plus := strings.Index(local, "+"); plus >= 0 {
+			local = local[:plus]
+		}
+		local = strings.ToLower(strings.ReplaceAll(local, ".", ""))
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain
+}
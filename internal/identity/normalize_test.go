@@ -0,0 +1,35 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailNormalization_Normalize(t *testing.T) {
+	domainOnly := EmailNormalization{}
+	lowercase := DefaultEmailNormalization()
+	gmail := EmailNormalization{LowercaseLocal: true, GmailCanonical: true}
+
+	tests := []struct {
+		name          string
+		normalization EmailNormalization
+		input         string
+		expected      string
+	}{
+		{"domain always lowercased", domainOnly, "User@Example.COM", "User@example.com"},
+		{"surrounding whitespace trimmed", domainOnly, "  user@example.com ", "user@example.com"},
+		{"local part lowercased", lowercase, "User@Example.com", "user@example.com"},
+		{"gmail rules off by default", lowercase, "j.doe+news@gmail.com", "j.doe+news@gmail.com"},
+		{"gmail dots stripped", gmail, "j.d.o.e@gmail.com", "jdoe@gmail.com"},
+		{"gmail plus tag stripped", gmail, "jdoe+newsletter@gmail.com", "jdoe@gmail.com"},
+		{"googlemail folded into gmail", gmail, "J.Doe+x@GoogleMail.com", "jdoe@gmail.com"},
+		{"gmail rules only for gmail", gmail, "j.doe+x@example.com", "j.doe+x@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.normalization.Normalize(tt.input))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package identity
+
+import "golang.org/x/crypto/bcrypt"
+
+// PasswordHashing controls how passwords are hashed and when stored hashes are
+// upgraded. Only bcrypt is supported; Cost is the bcrypt work factor.
+type PasswordHashing struct {
+	Cost          int
+	RehashOnLogin bool // Re-hash with the current parameters after a successful login with an outdated hash
+}
+
+// DefaultPasswordHashing uses bcrypt's default cost and upgrades outdated hashes on login
+func DefaultPasswordHashing() PasswordHashing {
+	return PasswordHashing{Cost: bcrypt.DefaultCost, RehashOnLogin: true}
+}
+
+// Validate reports whether the cost is one bcrypt accepts
+func (h PasswordHashing) Validate() error {
+	if h.Cost < bcrypt.MinCost || h.Cost > bcrypt.MaxCost {
+		return bcrypt.InvalidCostError(h.Cost)
+	}
+	return nil
+}
+
+// Hash returns the bcrypt hash of password at the configured cost
+func (h PasswordHashing) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// NeedsRehash reports whether a stored hash was produced with parameters other
+// than the current ones. Hashes that are not bcrypt always need rehashing.
+func (h PasswordHashing) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != h.Cost
+}
@@ -0,0 +1,25 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHashingNeedsRehash(t *testing.T) {
+	hashing := PasswordHashing{Cost: bcrypt.MinCost + 1}
+	current, err := hashing.Hash("Str0ng!Passw0rd")
+	assert.NoError(t, err)
+	outdated, err := bcrypt.GenerateFromPassword([]byte("Str0ng!Passw0rd"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	assert.False(t, hashing.NeedsRehash(current))
+	assert.True(t, hashing.NeedsRehash(string(outdated)))
+	assert.True(t, hashing.NeedsRehash("not-a-bcrypt-hash"))
+}
+
+func TestWithPasswordHashingIgnoresInvalidCost(t *testing.T) {
+	service := NewService(nil, "secret", 3600).WithPasswordHashing(PasswordHashing{Cost: bcrypt.MaxCost + 1})
+	assert.Equal(t, DefaultPasswordHashing(), service.passwordHashing)
+}
@@ -1,7 +1,12 @@
 package identity
 
 import (
+	"context"
 	"database/sql"
+	"sort"
+	"time"
+
+	"backend/internal/platform/database"
 )
 
 // Repository handles identity data access
@@ -14,40 +19,55 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// CreateUser inserts a new user
+// CreateUser inserts a new user. An email already registered, including by a
+// concurrent registration that passed the existence check, returns ErrEmailTaken.
 func (r *Repository) CreateUser(user *User) error {
 	query := `
-		INSERT INTO users (id, email, password_hash, name, avatar_url, created_at, updated_at, last_login)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO users (id, email, email_normalized, password_hash, name, avatar_url, locale, email_verified, created_at, updated_at, last_login)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 	_, err := r.db.Exec(
 		query,
 		user.ID,
 		user.Email,
+		user.NormalizedEmail,
 		user.PasswordHash,
 		user.Name,
 		user.AvatarURL,
+		user.Locale,
+		user.EmailVerified,
 		user.CreatedAt,
 		user.UpdatedAt,
 		user.LastLogin,
 	)
+	if database.IsUniqueViolationOn(err, emailConstraints...) {
+		return ErrEmailTaken
+	}
 	return err
 }
 
-// GetUserByEmail retrieves user by email
-func (r *Repository) GetUserByEmail(email string) (*User, error) {
+// emailConstraints are the unique constraints a taken email violates: the
+// original column constraint and the normalized-email index
+var emailConstraints = []string{"users_email_key", "idx_users_email_normalized"}
+
+// GetUserByEmail retrieves user by normalized email; callers normalize the address first
+func (r *Repository) GetUserByEmail(normalizedEmail string) (*User, error) {
 	query := `
-		SELECT id, email, password_hash, name, avatar_url, created_at, updated_at, last_login
+		SELECT id, email, email_normalized, password_hash, name, avatar_url, locale, email_verified, is_admin, created_at, updated_at, last_login
 		FROM users
-		WHERE email = $1
+		WHERE email_normalized = $1
 	`
 	user := &User{}
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.db.QueryRow(query, normalizedEmail).Scan(
 		&user.ID,
 		&user.Email,
+		&user.NormalizedEmail,
 		&user.PasswordHash,
 		&user.Name,
 		&user.AvatarURL,
+		&user.Locale,
+		&user.EmailVerified,
+		&user.IsAdmin,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.LastLogin,
@@ -61,23 +81,38 @@ func (r *Repository) GetUserByEmail(email string) (*User, error) {
 	return user, nil
 }
 
-// GetUserByID retrieves user by ID
+// GetUserByID retrieves user by ID along with their privacy settings
 func (r *Repository) GetUserByID(id string) (*User, error) {
 	query := `
-		SELECT id, email, password_hash, name, avatar_url, created_at, updated_at, last_login
-		FROM users
-		WHERE id = $1
+		SELECT u.id, u.email, u.password_hash, u.name, u.avatar_url, u.locale, u.email_verified, u.is_admin,
+		       u.created_at, u.updated_at, u.last_login,
+		       p.profile_visibility, p.activity_visibility, p.progress_visibility,
+		       p.allow_followers, p.show_in_leaderboards, p.show_completed_courses
+		FROM users u
+		LEFT JOIN user_privacy_settings p ON p.user_id = u.id
+		WHERE u.id = $1
 	`
 	user := &User{}
+	var profileVisibility, activityVisibility, progressVisibility sql.NullString
+	var allowFollowers, showInLeaderboards, showCompletedCourses sql.NullBool
 	err := r.db.QueryRow(query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
 		&user.Name,
 		&user.AvatarURL,
+		&user.Locale,
+		&user.EmailVerified,
+		&user.IsAdmin,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.LastLogin,
+		&profileVisibility,
+		&activityVisibility,
+		&progressVisibility,
+		&allowFollowers,
+		&showInLeaderboards,
+		&showCompletedCourses,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -86,38 +121,222 @@ func (r *Repository) GetUserByID(id string) (*User, error) {
 		return nil, err
 	}
 
-	// Set default privacy settings
+	// Users who never changed their privacy settings have no row and get the defaults
+	if !profileVisibility.Valid {
+		user.PrivacySettings = DefaultPrivacySettings()
+		return user, nil
+	}
 	user.PrivacySettings = &PrivacySettings{
-		ProfileVisibility:    "friends",
-		ActivityVisibility:   "friends",
-		ProgressVisibility:   "friends",
-		AllowFollowers:       true,
-		ShowInLeaderboards:   true,
-		ShowCompletedCourses: true,
+		ProfileVisibility:    profileVisibility.String,
+		ActivityVisibility:   activityVisibility.String,
+		ProgressVisibility:   progressVisibility.String,
+		AllowFollowers:       allowFollowers.Bool,
+		ShowInLeaderboards:   showInLeaderboards.Bool,
+		ShowCompletedCourses: showCompletedCourses.Bool,
 	}
 
 	return user, nil
 }
 
-// UpdateUser updates user information
-func (r *Repository) UpdateUser(user *User) error {
+// GetPrivacySettings retrieves the user's stored privacy settings, or nil if none were saved
+func (r *Repository) GetPrivacySettings(userID string) (*PrivacySettings, error) {
 	query := `
-		UPDATE users
-		SET name = $1, avatar_url = $2, updated_at = $3, last_login = $4
-		WHERE id = $5
+		SELECT profile_visibility, activity_visibility, progress_visibility,
+		       allow_followers, show_in_leaderboards, show_completed_courses
+		FROM user_privacy_settings
+		WHERE user_id = $1
+	`
+	settings := &PrivacySettings{}
+	err := r.db.QueryRow(query, userID).Scan(
+		&settings.ProfileVisibility,
+		&settings.ActivityVisibility,
+		&settings.ProgressVisibility,
+		&settings.AllowFollowers,
+		&settings.ShowInLeaderboards,
+		&settings.ShowCompletedCourses,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpsertPrivacySettings stores the user's privacy settings, replacing any saved ones
+func (r *Repository) UpsertPrivacySettings(userID string, settings *PrivacySettings) error {
+	query := `
+		INSERT INTO user_privacy_settings (
+			user_id, profile_visibility, activity_visibility, progress_visibility,
+			allow_followers, show_in_leaderboards, show_completed_courses, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			profile_visibility = EXCLUDED.profile_visibility,
+			activity_visibility = EXCLUDED.activity_visibility,
+			progress_visibility = EXCLUDED.progress_visibility,
+			allow_followers = EXCLUDED.allow_followers,
+			show_in_leaderboards = EXCLUDED.show_in_leaderboards,
+			show_completed_courses = EXCLUDED.show_completed_courses,
+			updated_at = NOW()
 	`
 	_, err := r.db.Exec(
+		query,
+		userID,
+		settings.ProfileVisibility,
+		settings.ActivityVisibility,
+		settings.ProgressVisibility,
+		settings.AllowFollowers,
+		settings.ShowInLeaderboards,
+		settings.ShowCompletedCourses,
+	)
+	return err
+}
+
+// UpdateLastLogin records when the user last logged in. It leaves updated_at
+// alone, since that is the version UpdateProfile checks and a login on another
+// device must not make profile edits conflict.
+func (r *Repository) UpdateLastLogin(userID string, lastLogin time.Time) error {
+	_, err := r.db.Exec(`UPDATE users SET last_login = $1 WHERE id = $2`, lastLogin, userID)
+	return err
+}
+
+// UpdateLocale sets the user's language preference without bumping updated_at,
+// for the same reason as UpdateLastLogin
+func (r *Repository) UpdateLocale(userID, locale string) error {
+	_, err := r.db.Exec(`UPDATE users SET locale = $1 WHERE id = $2`, locale, userID)
+	return err
+}
+
+// UpdateProfile writes the user's name, avatar and locale if the row's
+// updated_at still equals expectedUpdatedAt, returning ErrProfileConflict
+// when another write got there first
+func (r *Repository) UpdateProfile(user *User, expectedUpdatedAt time.Time) error {
+	query := `
+		UPDATE users
+		SET name = $1, avatar_url = $2, locale = $3, updated_at = $4
+		WHERE id = $5 AND updated_at = $6
+	`
+	result, err := r.db.Exec(
 		query,
 		user.Name,
 		user.AvatarURL,
+		user.Locale,
 		user.UpdatedAt,
-		user.LastLogin,
 		user.ID,
+		expectedUpdatedAt,
 	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrProfileConflict
+	}
+	return nil
+}
+
+// UpdatePasswordHash replaces the user's stored password hash. Like
+// UpdateLastLogin it leaves updated_at alone, as it runs on login.
+func (r *Repository) UpdatePasswordHash(userID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1 WHERE id = $2`
+	_, err := r.db.Exec(query, passwordHash, userID)
 	return err
 }
 
-// CreateArchetype creates user archetype
+// userCourses selects the IDs of the courses owned by the user in $1
+const userCourses = `SELECT id FROM generated_courses WHERE user_id = $1`
+
+// userModules selects the IDs of the modules in courses owned by the user in $1
+const userModules = `SELECT id FROM generated_modules WHERE course_id IN (` + userCourses + `)`
+
+// accountDeletionStatements remove everything belonging to the user in $1,
+// children before parents. Rows other users hold against the user's courses
+// (submissions, reviews, progress, recommendations) go too, so no foreign key
+// without ON DELETE CASCADE can block the course delete. Tables not listed here
+// (activity, notifications, certificates, privacy settings, ...) cascade from
+// users or generated_courses.
+var accountDeletionStatements = []string{
+	`DELETE FROM architecture_reviews WHERE user_id = $1 OR module_id IN (` + userModules + `)`,
+	`DELETE FROM module_completions WHERE user_id = $1 OR module_id IN (` + userModules + `)`,
+	`DELETE FROM user_progress WHERE user_id = $1 OR course_id IN (` + userCourses + `)`,
+	`DELETE FROM recommendations WHERE user_id = $1 OR course_id IN (` + userCourses + `)`,
+	`DELETE FROM user_relationships WHERE follower_id = $1 OR following_id = $1`,
+	`DELETE FROM user_achievements WHERE user_id = $1`,
+	`DELETE FROM generated_courses WHERE user_id = $1`, // Cascades to modules and exercises
+	`DELETE FROM user_variables WHERE user_id = $1`,
+	`DELETE FROM user_archetypes WHERE user_id = $1`,
+	`DELETE FROM users WHERE id = $1`,
+}
+
+// DeleteUserCascade removes the user and all of their data in one transaction.
+// Deleting a user that no longer exists succeeds without changing anything.
+func (r *Repository) DeleteUserCascade(ctx context.Context, userID string) error {
+	db := &database.DB{DB: r.db}
+	return db.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, statement := range accountDeletionStatements {
+			if _, err := tx.ExecContext(ctx, statement, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListUserEmails returns the ID, stored email and normalized email of every user
+func (r *Repository) ListUserEmails() ([]User, error) {
+	query := `SELECT id, email, email_normalized FROM users ORDER BY created_at`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.ID, &user.Email, &user.NormalizedEmail); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// UpdateNormalizedEmails rewrites email_normalized for the given user IDs in one transaction
+func (r *Repository) UpdateNormalizedEmails(normalized map[string]string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for userID, email := range normalized {
+		if _, err := tx.Exec(`UPDATE users SET email_normalized = $1 WHERE id = $2`, email, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// MarkEmailVerified flags the user's email as confirmed
+func (r *Repository) MarkEmailVerified(userID string, verifiedAt time.Time) error {
+	query := `
+		UPDATE users
+		SET email_verified = true, email_verified_at = $1, updated_at = $1
+		WHERE id = $2
+	`
+	_, err := r.db.Exec(query, verifiedAt, userID)
+	return err
+}
+
+// CreateArchetype creates user archetype. Each user has one, so a second
+// returns ErrAlreadyOnboarded.
 func (r *Repository) CreateArchetype(archetype *UserArchetype) error {
 	query := `
 		INSERT INTO user_archetypes (id, user_id, meta_category, domain, skill_level, created_at, updated_at)
@@ -133,6 +352,9 @@ func (r *Repository) CreateArchetype(archetype *UserArchetype) error {
 		archetype.CreatedAt,
 		archetype.UpdatedAt,
 	)
+	if database.IsUniqueViolation(err) {
+		return ErrAlreadyOnboarded
+	}
 	return err
 }
 
@@ -202,6 +424,45 @@ func (r *Repository) CreateVariables(variables []UserVariable) error {
 	return tx.Commit()
 }
 
+// UpsertVariables sets the user's variables, replacing existing values for the same keys
+func (r *Repository) UpsertVariables(userID, archetypeID string, variables map[string]string, now time.Time) error {
+	if len(variables) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO user_variables (user_id, variable_key, variable_value, archetype_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, variable_key) DO UPDATE
+		SET variable_value = EXCLUDED.variable_value, archetype_id = EXCLUDED.archetype_id
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	// Sorted so concurrent updates lock rows in the same order
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := stmt.Exec(userID, key, variables[key], archetypeID, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 // GetVariablesByUserID retrieves user's variables
 func (r *Repository) GetVariablesByUserID(userID string) ([]UserVariable, error) {
 	query := `
@@ -2,9 +2,16 @@ package identity
 
 import (
 	"backend/internal/platform/ai"
+	"backend/internal/platform/requestctx"
+	"backend/internal/platform/validation"
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,9 +21,30 @@ import (
 
 // CourseGenerator defines the interface for generating courses
 type CourseGenerator interface {
-	GenerateCourse(userID, archetypeID string, variables map[string]string) error
+	GenerateCourse(ctx context.Context, userID, archetypeID string, variables map[string]string) error
 }
 
+// DomainValidator judges whether an onboarding domain is a real, appropriate
+// subject to learn; *ai.Client satisfies it
+type DomainValidator interface {
+	ValidateDomain(ctx context.Context, domain string, metaCategory string) (*ai.DomainValidation, error)
+}
+
+// MetricsRecorder counts identity business events; metrics.Recorder satisfies it
+type MetricsRecorder interface {
+	RecordUserRegistration()
+	RecordUserLogin()
+}
+
+// NoopMetricsRecorder discards all events. Used when metrics are not wired and in tests.
+type NoopMetricsRecorder struct{}
+
+// RecordUserRegistration does nothing
+func (NoopMetricsRecorder) RecordUserRegistration() {}
+
+// RecordUserLogin does nothing
+func (NoopMetricsRecorder) RecordUserLogin() {}
+
 // Service handles identity business logic
 type Service struct {
 	repo            *Repository
@@ -24,14 +52,77 @@ type Service struct {
 	jwtExpiration   int // JWT expiration in seconds
 	aiClient        *ai.Client
 	courseGenerator CourseGenerator
+	domainValidator DomainValidator
+	aiBudget        *ai.Budget // Per-user daily AI call cap; nil allows every call
+	metrics         MetricsRecorder
+
+	emailSender              EmailSender
+	requireEmailVerification bool
+	verificationTTL          time.Duration
+	emailNormalization       EmailNormalization
+	passwordHashing          PasswordHashing
+
+	// Hash compared against when a login email is unknown, created lazily at the configured cost
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+
+	// Resend throttling, keyed by normalized email
+	resendCooldown time.Duration
+	resendMu       sync.Mutex
+	lastResend     map[string]time.Time
+
+	textLimits validation.TextLimits
+
+	strictVariables  bool              // Reject onboarding variables outside the universal set
+	variableDefaults *VariableDefaults // Fills variables the user skipped before course generation
+
+	now func() time.Time
 }
 
+// Errors returned by the service; handlers map them to HTTP statuses with errors.Is
+var (
+	ErrUserNotFound             = errors.New("user not found")
+	ErrInvalidEmail             = errors.New("invalid email format")
+	ErrEmailTaken               = errors.New("email already registered")
+	ErrInvalidCredentials       = errors.New("invalid email or password")
+	ErrEmailNotVerified         = errors.New("email not verified")
+	ErrInvalidLocale            = errors.New("invalid locale")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrWeakPassword             = errors.New("password does not meet complexity requirements")
+	ErrProfileConflict          = errors.New("profile was changed by another request; reload it and retry")
+)
+
+// weakPasswordError explains which complexity rule a password broke and matches ErrWeakPassword
+type weakPasswordError string
+
+func (e weakPasswordError) Error() string { return string(e) }
+
+// Is reports whether target is ErrWeakPassword
+func (e weakPasswordError) Is(target error) bool { return target == ErrWeakPassword }
+
+// defaultVerificationTTL is how long an email verification token stays valid
+const defaultVerificationTTL = 24 * time.Hour
+
+// defaultResendCooldown is the minimum time between verification emails to one address
+const defaultResendCooldown = time.Minute
+
 // NewService creates a new identity service
 func NewService(repo *Repository, jwtSecret string, jwtExpirationSeconds int) *Service {
 	return &Service{
-		repo:          repo,
-		jwtSecret:     jwtSecret,
-		jwtExpiration: jwtExpirationSeconds,
+		repo:               repo,
+		jwtSecret:          jwtSecret,
+		jwtExpiration:      jwtExpirationSeconds,
+		emailSender:        NoopEmailSender{},
+		metrics:            NoopMetricsRecorder{},
+		verificationTTL:    defaultVerificationTTL,
+		resendCooldown:     defaultResendCooldown,
+		lastResend:         make(map[string]time.Time),
+		emailNormalization: DefaultEmailNormalization(),
+		passwordHashing:    DefaultPasswordHashing(),
+		textLimits:         validation.DefaultTextLimits(),
+		strictVariables:    true,
+		variableDefaults:   DefaultVariableDefaults(),
+		now:                time.Now,
 	}
 }
 
@@ -47,38 +138,190 @@ func (s *Service) WithCourseGenerator(generator CourseGenerator) *Service {
 	return s
 }
 
+// WithDomainValidator rejects onboarding domains the validator judges invalid.
+// Without one, or when it errors, domains are accepted.
+func (s *Service) WithDomainValidator(validator DomainValidator) *Service {
+	s.domainValidator = validator
+	return s
+}
+
+// WithAIBudget caps the AI calls each user can trigger per day; onboarding
+// steps that need the AI over the cap fail with ai.ErrBudgetExceeded
+func (s *Service) WithAIBudget(budget *ai.Budget) *Service {
+	s.aiBudget = budget
+	return s
+}
+
+// WithMetrics sets where registrations and logins are counted
+func (s *Service) WithMetrics(recorder MetricsRecorder) *Service {
+	if recorder == nil {
+		recorder = NoopMetricsRecorder{}
+	}
+	s.metrics = recorder
+	return s
+}
+
+// WithEmailSender sets the provider used to deliver verification emails
+func (s *Service) WithEmailSender(sender EmailSender) *Service {
+	if sender == nil {
+		sender = NoopEmailSender{}
+	}
+	s.emailSender = sender
+	return s
+}
+
+// WithEmailVerification configures whether login requires a verified email
+// and how long verification tokens stay valid
+func (s *Service) WithEmailVerification(required bool, ttl time.Duration) *Service {
+	s.requireEmailVerification = required
+	if ttl > 0 {
+		s.verificationTTL = ttl
+	}
+	return s
+}
+
+// WithEmailNormalization sets how email addresses are canonicalized before storage and lookup
+func (s *Service) WithEmailNormalization(normalization EmailNormalization) *Service {
+	s.emailNormalization = normalization
+	return s
+}
+
+// WithPasswordHashing sets the cost new hashes are created with and whether
+// outdated hashes are upgraded on login. An invalid cost is ignored.
+func (s *Service) WithPasswordHashing(hashing PasswordHashing) *Service {
+	if err := hashing.Validate(); err != nil {
+		slog.Warn("ignoring invalid password hashing settings", "error", err)
+		return s
+	}
+	s.passwordHashing = hashing
+	s.dummyHashOnce = sync.Once{}
+	return s
+}
+
+// fallbackDummyHash is a cost 10 bcrypt hash used if the login dummy hash cannot be created
+var fallbackDummyHash = []byte("$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy")
+
+// loginDummyHash returns a hash at the configured cost so that logins for
+// unknown emails take as long as logins for existing accounts
+func (s *Service) loginDummyHash() []byte {
+	s.dummyHashOnce.Do(func() {
+		hash, err := s.passwordHashing.Hash(uuid.NewString())
+		if err != nil {
+			slog.Warn("failed to create login dummy hash", "error", err)
+			s.dummyHash = fallbackDummyHash
+			return
+		}
+		s.dummyHash = []byte(hash)
+	})
+	return s.dummyHash
+}
+
+// ErrEmailNormalizationConflict is returned when the normalization policy would merge two existing accounts
+var ErrEmailNormalizationConflict = errors.New("email normalization conflict")
+
+// RenormalizeEmails recomputes every stored normalized email with the configured
+// policy so lookups keep matching existing accounts after the policy changes.
+// It returns the number of rows rewritten and fails without writing anything
+// when two accounts would collapse onto the same normalized address.
+func (s *Service) RenormalizeEmails() (int, error) {
+	users, err := s.repo.ListUserEmails()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list user emails: %w", err)
+	}
+
+	owners := make(map[string]string, len(users))
+	updates := make(map[string]string)
+	for _, user := range users {
+		normalized := s.emailNormalization.Normalize(user.Email)
+		if owner, ok := owners[normalized]; ok {
+			return 0, fmt.Errorf("%w: users %s and %s both normalize to %s",
+				ErrEmailNormalizationConflict, owner, user.ID, normalized)
+		}
+		owners[normalized] = user.ID
+		if normalized != user.NormalizedEmail {
+			updates[user.ID] = normalized
+		}
+	}
+
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	if err := s.repo.UpdateNormalizedEmails(updates); err != nil {
+		return 0, fmt.Errorf("failed to update normalized emails: %w", err)
+	}
+	return len(updates), nil
+}
+
+// WithTextLimits sets the length caps applied to user-provided text
+func (s *Service) WithTextLimits(limits validation.TextLimits) *Service {
+	s.textLimits = limits
+	return s
+}
+
+// WithVariableDefaults sets the per-archetype variables used to fill gaps before course generation.
+// A nil value disables filling.
+func (s *Service) WithVariableDefaults(defaults *VariableDefaults) *Service {
+	s.variableDefaults = defaults
+	return s
+}
+
+// WithStrictVariables toggles validation of onboarding variable keys and values
+func (s *Service) WithStrictVariables(strict bool) *Service {
+	s.strictVariables = strict
+	return s
+}
+
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 
 // Custom JWT claims
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID  string `json:"user_id"`
+	Email   string `json:"email"`
+	IsAdmin bool   `json:"is_admin,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// emailVerificationPurpose marks tokens that may only be used to verify an email
+const emailVerificationPurpose = "email_verification"
+
+// EmailVerificationClaims are the claims carried by an email verification token
+type EmailVerificationClaims struct {
+	UserID  string `json:"user_id"`
+	Email   string `json:"email"`
+	Purpose string `json:"purpose"`
 	jwt.RegisteredClaims
 }
 
 // Register creates a new user account
 func (s *Service) Register(req *RegisterRequest) (*AuthResponse, error) {
 	// Validate email format
-	if !emailRegex.MatchString(req.Email) {
-		return nil, errors.New("invalid email format")
+	email := strings.TrimSpace(req.Email)
+	if !emailRegex.MatchString(email) {
+		return nil, ErrInvalidEmail
 	}
+	normalizedEmail := s.emailNormalization.Normalize(email)
 
 	// Validate password complexity
 	if err := validatePasswordComplexity(req.Password); err != nil {
 		return nil, err
 	}
 
-	// Check if email already exists
-	existingUser, err := s.repo.GetUserByEmail(req.Email)
+	name, err := validation.SanitizeText("name", req.Name, s.textLimits.Short())
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if email already exists, including case and provider variants
+	existingUser, err := s.repo.GetUserByEmail(normalizedEmail)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check existing user: %w", err)
 	}
 	if existingUser != nil {
-		return nil, errors.New("email already registered")
+		return nil, ErrEmailTaken
 	}
 
 	// Hash password using bcrypt
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHashing.Hash(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -86,30 +329,52 @@ func (s *Service) Register(req *RegisterRequest) (*AuthResponse, error) {
 	// Create user
 	now := time.Now()
 	user := &User{
-		ID:           uuid.New().String(),
-		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
-		Name:         req.Name,
-		AvatarURL:    "",
-		CreatedAt:    now,
-		UpdatedAt:    now,
-		LastLogin:    now,
+		ID:              uuid.New().String(),
+		Email:           email,
+		NormalizedEmail: normalizedEmail,
+		PasswordHash:    hashedPassword,
+		Name:            name,
+		AvatarURL:       "",
+		Locale:          ai.DefaultLocale,
+		EmailVerified:   false,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		LastLogin:       now,
 	}
 
 	err = s.repo.CreateUser(user)
+	if errors.Is(err, ErrEmailTaken) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	s.metrics.RecordUserRegistration()
 
-	// Generate JWT token
-	token, err := s.generateToken(user.ID, user.Email)
+	// Send verification email
+	verificationToken, err := s.generateVerificationToken(user.ID, user.Email)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	if err := s.emailSender.SendVerificationEmail(user.Email, user.Name, verificationToken); err != nil {
+		// Non-critical error, the user can request a new email via ResendVerificationEmail
+		slog.Warn("failed to send verification email", "user_id", user.ID, "error", err)
 	}
 
 	// Don't return password hash in response
 	user.PasswordHash = ""
 
+	// No session until the email is confirmed when verification is required
+	if s.requireEmailVerification {
+		return &AuthResponse{User: *user}, nil
+	}
+
+	// Generate JWT token
+	token, err := s.generateToken(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
 	return &AuthResponse{
 		Token: token,
 		User:  *user,
@@ -119,16 +384,16 @@ func (s *Service) Register(req *RegisterRequest) (*AuthResponse, error) {
 // Login authenticates a user
 func (s *Service) Login(req *LoginRequest) (*AuthResponse, error) {
 	// Find user by email
-	user, err := s.repo.GetUserByEmail(req.Email)
+	user, err := s.repo.GetUserByEmail(s.emailNormalization.Normalize(req.Email))
 	if err != nil {
 		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
 
 	// Always perform bcrypt comparison to maintain constant time
 	// Use a dummy hash if user doesn't exist to prevent timing attacks
-	// This ensures the response time is consistent regardless of whether the email exists
-	dummyHash := []byte("$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy")
-	passwordHash := dummyHash
+	// This ensures the response time is consistent regardless of whether the email exists,
+	// so the dummy hash uses the same cost as newly created hashes
+	passwordHash := s.loginDummyHash()
 	if user != nil {
 		passwordHash = []byte(user.PasswordHash)
 	}
@@ -136,26 +401,35 @@ func (s *Service) Login(req *LoginRequest) (*AuthResponse, error) {
 	// Verify password using bcrypt
 	err = bcrypt.CompareHashAndPassword(passwordHash, []byte(req.Password))
 	if err != nil || user == nil {
-		return nil, errors.New("invalid email or password")
+		return nil, ErrInvalidCredentials
+	}
+
+	if s.requireEmailVerification && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	// Upgrade hashes created with outdated parameters while the plaintext is at hand
+	if s.passwordHashing.RehashOnLogin && s.passwordHashing.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(user, req.Password)
 	}
 
 	// Update last login
 	user.LastLogin = time.Now()
-	user.UpdatedAt = time.Now()
-	err = s.repo.UpdateUser(user)
+	err = s.repo.UpdateLastLogin(user.ID, user.LastLogin)
 	if err != nil {
 		// Non-critical error, just log it
-		fmt.Printf("warning: failed to update last login: %v\n", err)
+		slog.Warn("failed to update last login", "user_id", user.ID, "error", err)
 	}
 
 	// Generate JWT token
-	token, err := s.generateToken(user.ID, user.Email)
+	token, err := s.generateToken(user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	// Don't return password hash in response
 	user.PasswordHash = ""
+	s.metrics.RecordUserLogin()
 
 	return &AuthResponse{
 		Token: token,
@@ -163,6 +437,21 @@ func (s *Service) Login(req *LoginRequest) (*AuthResponse, error) {
 	}, nil
 }
 
+// rehashPassword stores password hashed with the current parameters. Failures
+// are logged and leave the old hash in place, so the login still succeeds.
+func (s *Service) rehashPassword(user *User, password string) {
+	hash, err := s.passwordHashing.Hash(password)
+	if err != nil {
+		slog.Warn("failed to rehash password", "user_id", user.ID, "error", err)
+		return
+	}
+	if err := s.repo.UpdatePasswordHash(user.ID, hash); err != nil {
+		slog.Warn("failed to store rehashed password", "user_id", user.ID, "error", err)
+		return
+	}
+	user.PasswordHash = hash
+}
+
 // GetProfile retrieves user profile
 func (s *Service) GetProfile(userID string) (*User, error) {
 	user, err := s.repo.GetUserByID(userID)
@@ -170,7 +459,7 @@ func (s *Service) GetProfile(userID string) (*User, error) {
 		return nil, fmt.Errorf("failed to get user profile: %w", err)
 	}
 	if user == nil {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	// Don't return password hash
@@ -179,42 +468,188 @@ func (s *Service) GetProfile(userID string) (*User, error) {
 }
 
 // UpdateProfile updates user profile
-func (s *Service) UpdateProfile(userID string, updates map[string]interface{}) error {
-	user, err := s.repo.GetUserByID(userID)
+// When expectedUpdatedAt is set and the profile has changed since, nothing is
+// written and ErrProfileConflict is returned. A concurrent write between the
+// read and the update is a conflict either way.
+func (s *Service) UpdateProfile(userID string, updates map[string]interface{}, expectedUpdatedAt *time.Time) error {
+	user, err := s.loadProfileForUpdate(userID, expectedUpdatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
-	}
-	if user == nil {
-		return errors.New("user not found")
+		return err
 	}
 
 	// Apply updates
 	if name, ok := updates["name"].(string); ok {
-		user.Name = name
+		user.Name, err = validation.SanitizeText("name", name, s.textLimits.Short())
+		if err != nil {
+			return err
+		}
 	}
 	if avatarURL, ok := updates["avatar_url"].(string); ok {
+		if err := validateAvatarURL(avatarURL); err != nil {
+			return err
+		}
 		user.AvatarURL = avatarURL
 	}
+	if locale, ok := updates["locale"].(string); ok {
+		if !ai.IsValidLocale(locale) {
+			return ErrInvalidLocale
+		}
+		user.Locale = ai.NormalizeLocale(locale)
+	}
 
-	user.UpdatedAt = time.Now()
+	return s.saveProfile(user)
+}
 
-	err = s.repo.UpdateUser(user)
+// ReplaceProfile replaces every mutable profile field. Unlike UpdateProfile,
+// empty values are stored: an empty avatar URL removes the avatar and an empty
+// locale resets the language preference to the default. The name is required.
+// expectedUpdatedAt guards against lost updates as in UpdateProfile.
+func (s *Service) ReplaceProfile(userID, name, avatarURL, locale string, expectedUpdatedAt *time.Time) error {
+	user, err := s.loadProfileForUpdate(userID, expectedUpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	name, err = validation.SanitizeText("name", name, s.textLimits.Short())
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(name) == "" {
+		return &validation.FieldError{Field: "name", Message: "is required"}
+	}
+	if avatarURL != "" {
+		if err := validateAvatarURL(avatarURL); err != nil {
+			return err
+		}
+	}
+	if locale != "" && !ai.IsValidLocale(locale) {
+		return ErrInvalidLocale
+	}
+
+	user.Name = name
+	user.AvatarURL = avatarURL
+	user.Locale = ai.NormalizeLocale(locale)
+
+	return s.saveProfile(user)
+}
+
+// loadProfileForUpdate reads the user about to be edited, failing with
+// ErrProfileConflict when expectedUpdatedAt no longer matches the stored profile
+func (s *Service) loadProfileForUpdate(userID string, expectedUpdatedAt *time.Time) (*User, error) {
+	user, err := s.repo.GetUserByID(userID)
 	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if expectedUpdatedAt != nil && !expectedUpdatedAt.Equal(user.UpdatedAt) {
+		return nil, ErrProfileConflict
+	}
+	return user, nil
+}
+
+// saveProfile writes the edited profile only if it is unchanged since it was read
+func (s *Service) saveProfile(user *User) error {
+	readAt := user.UpdatedAt
+	user.UpdatedAt = s.now()
+
+	if err := s.repo.UpdateProfile(user, readAt); err != nil {
+		if errors.Is(err, ErrProfileConflict) {
+			return err
+		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 
 	return nil
 }
 
+// validateAvatarURL checks that a non-empty avatar URL is an http(s) URL
+func validateAvatarURL(avatarURL string) error {
+	if err := validation.ValidateURL(avatarURL); err != nil {
+		return &validation.FieldError{Field: "avatar_url", Message: "must be a valid http or https URL"}
+	}
+	return nil
+}
+
 // CompleteOnboarding saves onboarding results
-func (s *Service) CompleteOnboarding(userID, metaCategory, domain, skillLevel string, variables map[string]string) error {
+// An empty locale keeps the user's current language preference
+func (s *Service) CompleteOnboarding(ctx context.Context, userID, metaCategory, domain, skillLevel, locale string, variables map[string]string) error {
+	if locale != "" && !ai.IsValidLocale(locale) {
+		return ErrInvalidLocale
+	}
+
+	metaCategory, err := validation.OneOf("meta_category", metaCategory, MetaCategories)
+	if err != nil {
+		return err
+	}
+	skillLevel, err = validation.OneOf("skill_level", skillLevel, SkillLevels)
+	if err != nil {
+		return err
+	}
+
+	// Domain and variables are data for course generation, so they are cleaned but not HTML-escaped
+	domain, err = validation.CleanText("domain", domain, s.textLimits.Short())
+	if err != nil {
+		return err
+	}
+	if err := validation.ValidateTopic("domain", domain, minDomainLength); err != nil {
+		return err
+	}
+	if err := s.validateDomain(ctx, userID, metaCategory, domain); err != nil {
+		return err
+	}
+
+	sanitizedVariables := make(map[string]string, len(variables))
+	for key, value := range variables {
+		sanitizedVariables[key], err = validation.CleanText(key, value, s.textLimits.Short())
+		if err != nil {
+			return err
+		}
+	}
+	variables = sanitizedVariables
+
+	// Let the AI infer variables when the user skipped them all. Inference is
+	// optional, so over the AI budget the archetype defaults are used instead.
+	if s.courseGenerator != nil && s.aiClient != nil && len(variables) == 0 {
+		if err := s.aiBudget.Consume(ctx, userID, ai.CallExtractVariables); err != nil {
+			slog.Info("skipping AI variable extraction", "user_id", userID, "error", err)
+		} else if aiVars, err := s.aiClient.ExtractVariables(ctx, domain); err == nil && aiVars != nil {
+			variables = map[string]string{
+				"ENTITY":    aiVars.Entity,
+				"STATE":     aiVars.State,
+				"FLOW":      aiVars.Flow,
+				"LOGIC":     aiVars.Logic,
+				"INTERFACE": aiVars.Interface,
+			}
+		}
+	}
+
+	// Fill anything the user and the AI left blank from the archetype defaults,
+	// so validation and storage see the variables the course is generated from
+	variables = s.variableDefaults.Fill(metaCategory, domain, variables)
+
+	if s.strictVariables {
+		if err := validateOnboardingVariables(variables); err != nil {
+			return err
+		}
+	}
+
 	// Validate user exists
 	user, err := s.repo.GetUserByID(userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 	if user == nil {
-		return errors.New("user not found")
+		return ErrUserNotFound
+	}
+
+	// Save language preference before generating any content
+	if locale != "" {
+		user.Locale = ai.NormalizeLocale(locale)
+		if err := s.repo.UpdateLocale(user.ID, user.Locale); err != nil {
+			return fmt.Errorf("failed to update locale: %w", err)
+		}
 	}
 
 	// Create archetype
@@ -230,6 +665,9 @@ func (s *Service) CompleteOnboarding(userID, metaCategory, domain, skillLevel st
 	}
 
 	err = s.repo.CreateArchetype(archetype)
+	if errors.Is(err, ErrAlreadyOnboarded) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create archetype: %w", err)
 	}
@@ -256,53 +694,113 @@ func (s *Service) CompleteOnboarding(userID, metaCategory, domain, skillLevel st
 
 	// Trigger curriculum generation
 	if s.courseGenerator != nil {
-		// Use AI client to enhance variables if available
-		if s.aiClient != nil && len(variables) == 0 {
-			// Extract variables from domain using AI
-			aiVars, err := s.aiClient.ExtractVariables(domain)
-			if err == nil && aiVars != nil {
-				variables = map[string]string{
-					"ENTITY":    aiVars.Entity,
-					"STATE":     aiVars.State,
-					"FLOW":      aiVars.Flow,
-					"LOGIC":     aiVars.Logic,
-					"INTERFACE": aiVars.Interface,
-				}
-
-				// Store AI-extracted variables
-				userVariables := make([]UserVariable, 0, len(variables))
-				for key, value := range variables {
-					userVariables = append(userVariables, UserVariable{
-						ID:            uuid.New().String(),
-						UserID:        userID,
-						VariableKey:   key,
-						VariableValue: value,
-						ArchetypeID:   archetype.ID,
-						CreatedAt:     time.Now(),
-					})
-				}
-				_ = s.repo.CreateVariables(userVariables)
-			}
-		}
-
 		// Generate initial course
-		if err := s.courseGenerator.GenerateCourse(userID, archetype.ID, variables); err != nil {
+		if err := s.courseGenerator.GenerateCourse(ctx, userID, archetype.ID, variables); err != nil {
 			// Log error but don't fail onboarding
-			fmt.Printf("Warning: Failed to generate course: %v\n", err)
+			slog.Warn("failed to generate course during onboarding",
+				"request_id", requestctx.RequestID(ctx),
+				"user_id", userID,
+				"error", err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// MetaCategories are the universal domains an archetype belongs to (user_archetypes CHECK constraint)
+var MetaCategories = []string{"Digital", "Economic", "Cognitive", "Aesthetic", "Biological"}
+
+// SkillLevels are the self-reported starting levels accepted at onboarding
+var SkillLevels = []string{"beginner", "intermediate", "advanced"}
+
+// minDomainLength is the shortest domain name accepted at onboarding
+const minDomainLength = 2
+
+// ErrDomainRejected is returned when the domain validator judges an onboarding domain
+// not learnable or inappropriate; the error message carries its reason
+var ErrDomainRejected = errors.New("domain rejected")
+
+// validateDomain asks the domain validator, if any, whether domain can be learned.
+// A failing validator lets the domain through so an AI outage does not block onboarding.
+func (s *Service) validateDomain(ctx context.Context, userID, metaCategory, domain string) error {
+	if s.domainValidator == nil {
+		return nil
+	}
+	if err := s.aiBudget.Consume(ctx, userID, ai.CallValidateDomain); err != nil {
+		return err
+	}
+
+	result, err := s.domainValidator.ValidateDomain(ctx, domain, metaCategory)
+	if err != nil {
+		slog.Warn("domain validation failed, accepting domain",
+			"domain", domain, "meta_category", metaCategory, "error", err)
+		return nil
+	}
+	if result == nil || result.IsValid {
+		return nil
+	}
+
+	reason := strings.TrimSpace(result.Reason)
+	if reason == "" {
+		reason = "not a learnable subject"
+	}
+	return fmt.Errorf("%w: %s", ErrDomainRejected, reason)
+}
+
+// ErrInvalidVariables is returned when onboarding variables fail validation
+var ErrInvalidVariables = errors.New("invalid variables")
+
+// ErrOnboardingIncomplete is returned when an action needs the archetype chosen at onboarding
+var ErrOnboardingIncomplete = errors.New("onboarding has not been completed")
+
+// ErrAlreadyOnboarded is returned when a user who already has an archetype completes onboarding again
+var ErrAlreadyOnboarded = errors.New("onboarding has already been completed")
+
+// onboardingVariableKeys are the universal variables every archetype understands
+var onboardingVariableKeys = map[string]bool{
+	"ENTITY":    true,
+	"STATE":     true,
+	"FLOW":      true,
+	"LOGIC":     true,
+	"INTERFACE": true,
+}
+
+// validateOnboardingVariables checks user-provided variables against the universal set.
+// ENTITY is always required; keys are checked in sorted order so errors are deterministic.
+func validateOnboardingVariables(variables map[string]string) error {
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := variables[key]
+		if !onboardingVariableKeys[key] {
+			return fmt.Errorf("%w: unknown variable %q (allowed: ENTITY, STATE, FLOW, LOGIC, INTERFACE)", ErrInvalidVariables, key)
+		}
+		if value == "" {
+			return fmt.Errorf("%w: %s must not be empty", ErrInvalidVariables, key)
 		}
 	}
 
+	if _, ok := variables["ENTITY"]; !ok {
+		return fmt.Errorf("%w: ENTITY is required", ErrInvalidVariables)
+	}
+
 	return nil
 }
 
-// generateToken creates a JWT token for the user
-func (s *Service) generateToken(userID, email string) (string, error) {
+// generateToken creates a JWT token for the user, carrying the admin flag from the users table
+func (s *Service) generateToken(user *User) (string, error) {
 	// Use JWT expiration from config (in seconds)
 	expiration := time.Duration(s.jwtExpiration) * time.Second
 
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:  user.ID,
+		Email:   user.Email,
+		IsAdmin: user.IsAdmin,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -319,6 +817,134 @@ func (s *Service) generateToken(userID, email string) (string, error) {
 	return tokenString, nil
 }
 
+// VerifyEmail marks the user's email as verified using a token from the verification email
+func (s *Service) VerifyEmail(token string) error {
+	claims, err := s.parseVerificationToken(token)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := s.repo.GetUserByID(claims.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	// Tokens issued for a previous address must not verify a new one
+	if user.Email != claims.Email {
+		return ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err := s.repo.MarkEmailVerified(user.ID, time.Now()); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	return nil
+}
+
+// ErrVerificationResendTooSoon is returned when a verification email was sent to the address too recently
+var ErrVerificationResendTooSoon = errors.New("verification email was sent recently, please wait before requesting another")
+
+// ResendVerificationEmail sends a fresh verification token to an unverified account.
+// Unknown and already verified addresses succeed without sending anything so the
+// endpoint cannot be used to discover accounts; the per-address cooldown applies to
+// every address for the same reason.
+func (s *Service) ResendVerificationEmail(email string) error {
+	normalizedEmail := s.emailNormalization.Normalize(email)
+	if normalizedEmail == "" {
+		return ErrInvalidEmail
+	}
+	if !s.reserveResend(normalizedEmail) {
+		return ErrVerificationResendTooSoon
+	}
+
+	user, err := s.repo.GetUserByEmail(normalizedEmail)
+	if err != nil {
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if user == nil || user.EmailVerified {
+		return nil
+	}
+
+	token, err := s.generateVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+	if err := s.emailSender.SendVerificationEmail(user.Email, user.Name, token); err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// reserveResend records a resend for the address unless one happened within the
+// cooldown. Expired entries are dropped so the map only holds recent addresses.
+func (s *Service) reserveResend(normalizedEmail string) bool {
+	s.resendMu.Lock()
+	defer s.resendMu.Unlock()
+
+	now := s.now()
+	for key, sentAt := range s.lastResend {
+		if now.Sub(sentAt) >= s.resendCooldown {
+			delete(s.lastResend, key)
+		}
+	}
+	if _, recent := s.lastResend[normalizedEmail]; recent {
+		return false
+	}
+	s.lastResend[normalizedEmail] = now
+	return true
+}
+
+// verificationKey derives the signing key for verification tokens so they
+// can never be accepted as session tokens (and vice versa)
+func (s *Service) verificationKey() []byte {
+	return []byte(s.jwtSecret + ":" + emailVerificationPurpose)
+}
+
+// generateVerificationToken creates a signed token proving ownership of an email
+func (s *Service) generateVerificationToken(userID, email string) (string, error) {
+	now := s.now()
+	claims := &EmailVerificationClaims{
+		UserID:  userID,
+		Email:   email,
+		Purpose: emailVerificationPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.verificationTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.verificationKey())
+}
+
+// parseVerificationToken validates a verification token and returns its claims
+func (s *Service) parseVerificationToken(tokenString string) (*EmailVerificationClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &EmailVerificationClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.verificationKey(), nil
+	}, jwt.WithTimeFunc(s.now))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*EmailVerificationClaims)
+	if !ok || !token.Valid || claims.Purpose != emailVerificationPurpose || claims.UserID == "" {
+		return nil, errors.New("invalid verification token")
+	}
+
+	return claims, nil
+}
+
 // GetArchetype retrieves user's archetype as interface{} for social domain
 func (s *Service) GetArchetype(userID string) (interface{}, error) {
 	archetype, err := s.repo.GetArchetypeByUserID(userID)
@@ -331,14 +957,185 @@ func (s *Service) GetArchetype(userID string) (interface{}, error) {
 	return archetype, nil
 }
 
+// GetVariables returns the variables of the user's current archetype. Users who
+// have not completed onboarding get an empty set.
+func (s *Service) GetVariables(userID string) (*UserVariables, error) {
+	archetype, err := s.repo.GetArchetypeByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archetype: %w", err)
+	}
+	if archetype == nil {
+		return &UserVariables{Variables: map[string]string{}}, nil
+	}
+
+	return s.archetypeVariables(userID, archetype.ID)
+}
+
+// archetypeVariables loads the user's variables belonging to archetypeID
+func (s *Service) archetypeVariables(userID, archetypeID string) (*UserVariables, error) {
+	stored, err := s.repo.GetVariablesByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variables: %w", err)
+	}
+
+	variables := make(map[string]string, len(stored))
+	for _, v := range stored {
+		if v.ArchetypeID == archetypeID {
+			variables[v.VariableKey] = v.VariableValue
+		}
+	}
+	return &UserVariables{ArchetypeID: archetypeID, Variables: variables}, nil
+}
+
+// UpdateVariables changes some of the current archetype's variables and, when
+// asked, generates a new course from the result. A failed generation is logged
+// and reported through Regenerated rather than undoing the update.
+func (s *Service) UpdateVariables(ctx context.Context, userID string, req *UpdateVariablesRequest) (*UserVariables, error) {
+	if len(req.Variables) == 0 {
+		return nil, fmt.Errorf("%w: at least one variable is required", ErrInvalidVariables)
+	}
+
+	updates := make(map[string]string, len(req.Variables))
+	for key, value := range req.Variables {
+		if !onboardingVariableKeys[key] {
+			return nil, fmt.Errorf("%w: unknown variable %q (allowed: ENTITY, STATE, FLOW, LOGIC, INTERFACE)", ErrInvalidVariables, key)
+		}
+		cleaned, err := validation.CleanText(key, value, s.textLimits.Short())
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(cleaned) == "" {
+			return nil, fmt.Errorf("%w: %s must not be empty", ErrInvalidVariables, key)
+		}
+		updates[key] = cleaned
+	}
+
+	archetype, err := s.repo.GetArchetypeByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archetype: %w", err)
+	}
+	if archetype == nil {
+		return nil, ErrOnboardingIncomplete
+	}
+
+	if err := s.repo.UpsertVariables(userID, archetype.ID, updates, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to update variables: %w", err)
+	}
+
+	result, err := s.archetypeVariables(userID, archetype.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Regenerate && s.courseGenerator != nil {
+		if err := s.courseGenerator.GenerateCourse(ctx, userID, archetype.ID, result.Variables); err != nil {
+			slog.Warn("failed to regenerate course after variable update",
+				"request_id", requestctx.RequestID(ctx),
+				"user_id", userID,
+				"error", err,
+			)
+		} else {
+			result.Regenerated = true
+		}
+	}
+
+	return result, nil
+}
+
+// GetPrivacySettings retrieves user's privacy preferences
+func (s *Service) GetPrivacySettings(userID string) (*PrivacySettings, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user.PrivacySettings, nil
+}
+
+// ErrInvalidPrivacySettings is returned when a privacy update uses an unknown visibility level
+var ErrInvalidPrivacySettings = errors.New("invalid privacy settings")
+
+// UpdatePrivacySettings applies the fields set in req to the user's privacy
+// settings and stores the result
+func (s *Service) UpdatePrivacySettings(userID string, req *UpdatePrivacyRequest) (*PrivacySettings, error) {
+	settings, err := s.GetPrivacySettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	visibilities := []struct {
+		field string
+		value *string
+		dest  *string
+	}{
+		{"profile_visibility", req.ProfileVisibility, &settings.ProfileVisibility},
+		{"activity_visibility", req.ActivityVisibility, &settings.ActivityVisibility},
+		{"progress_visibility", req.ProgressVisibility, &settings.ProgressVisibility},
+	}
+	for _, v := range visibilities {
+		if v.value == nil {
+			continue
+		}
+		switch *v.value {
+		case VisibilityPublic, VisibilityFriends, VisibilityPrivate:
+			*v.dest = *v.value
+		default:
+			return nil, fmt.Errorf("%w: %s must be public, friends or private", ErrInvalidPrivacySettings, v.field)
+		}
+	}
+	if req.AllowFollowers != nil {
+		settings.AllowFollowers = *req.AllowFollowers
+	}
+	if req.ShowInLeaderboards != nil {
+		settings.ShowInLeaderboards = *req.ShowInLeaderboards
+	}
+	if req.ShowCompletedCourses != nil {
+		settings.ShowCompletedCourses = *req.ShowCompletedCourses
+	}
+
+	if err := s.repo.UpsertPrivacySettings(userID, settings); err != nil {
+		return nil, fmt.Errorf("failed to update privacy settings: %w", err)
+	}
+	return settings, nil
+}
+
+// ErrPasswordMismatch is returned when the password confirming a sensitive action is wrong
+var ErrPasswordMismatch = errors.New("password is incorrect")
+
+// DeleteAccount permanently removes the user and all of their data once the
+// password is confirmed. Deleting an account that is already gone succeeds, so
+// a retried request is safe.
+func (s *Service) DeleteAccount(ctx context.Context, userID, password string) error {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return ErrPasswordMismatch
+	}
+
+	if err := s.repo.DeleteUserCascade(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	slog.Info("account deleted", "user_id", userID)
+	return nil
+}
+
 // validatePasswordComplexity checks password meets security requirements
 func validatePasswordComplexity(password string) error {
 	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters")
+		return weakPasswordError("password must be at least 8 characters")
 	}
 
 	if len(password) > 128 {
-		return errors.New("password is too long (max 128 characters)")
+		return weakPasswordError("password is too long (max 128 characters)")
 	}
 
 	var (
@@ -369,19 +1166,19 @@ func validatePasswordComplexity(password string) error {
 	}
 
 	if !hasUpper {
-		return errors.New("password must contain at least one uppercase letter")
+		return weakPasswordError("password must contain at least one uppercase letter")
 	}
 
 	if !hasLower {
-		return errors.New("password must contain at least one lowercase letter")
+		return weakPasswordError("password must contain at least one lowercase letter")
 	}
 
 	if !hasNumber {
-		return errors.New("password must contain at least one number")
+		return weakPasswordError("password must contain at least one number")
 	}
 
 	if !hasSpecial {
-		return errors.New("password must contain at least one special character")
+		return weakPasswordError("password must contain at least one special character")
 	}
 
 	// Check for common weak passwords
@@ -392,7 +1189,7 @@ func validatePasswordComplexity(password string) error {
 
 	for _, weak := range commonWeakPasswords {
 		if password == weak {
-			return errors.New("password is too common, please choose a stronger password")
+			return weakPasswordError("password is too common, please choose a stronger password")
 		}
 	}
 
@@ -1,11 +1,29 @@
 package identity
 
 import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"backend/internal/platform/ai"
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/middleware"
+	"backend/internal/platform/validation"
+	"backend/tests/testutil"
+
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestEmailValidation(t *testing.T) {
@@ -32,13 +50,14 @@ func TestEmailValidation(t *testing.T) {
 
 func TestJWTTokenGeneration(t *testing.T) {
 	service := &Service{
-		jwtSecret: "test-secret-key",
+		jwtSecret:     "test-secret-key",
+		jwtExpiration: 3600,
 	}
 
 	userID := "user-123"
 	email := "test@example.com"
 
-	token, err := service.generateToken(userID, email)
+	token, err := service.generateToken(&User{ID: userID, Email: email})
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
@@ -55,12 +74,32 @@ func TestJWTTokenGeneration(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, userID, claims.UserID)
 	assert.Equal(t, email, claims.Email)
+	assert.False(t, claims.IsAdmin)
 
 	// Verify expiration is set
 	assert.NotNil(t, claims.ExpiresAt)
 	assert.True(t, claims.ExpiresAt.After(time.Now()))
 }
 
+func TestJWTTokenCarriesAdminFlag(t *testing.T) {
+	service := &Service{
+		jwtSecret:     "test-secret-key",
+		jwtExpiration: 3600,
+	}
+
+	token, err := service.generateToken(&User{ID: "admin-1", Email: "admin@example.com", IsAdmin: true})
+	require.NoError(t, err)
+
+	// The auth middleware reads the flag into UserClaims
+	parsed, err := jwt.ParseWithClaims(token, &middleware.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte("test-secret-key"), nil
+	})
+	require.NoError(t, err)
+	claims := parsed.Claims.(*middleware.UserClaims)
+	assert.Equal(t, "admin-1", claims.UserID)
+	assert.True(t, claims.IsAdmin)
+}
+
 func TestJWTClaimsStructure(t *testing.T) {
 	claims := &Claims{
 		UserID: "user-123",
@@ -98,3 +137,1266 @@ func TestPasswordValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestEmailVerificationToken(t *testing.T) {
+	service := NewService(nil, "test-secret-key", 3600)
+
+	token, err := service.generateVerificationToken("user-123", "test@example.com")
+	assert.NoError(t, err)
+
+	claims, err := service.parseVerificationToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-123", claims.UserID)
+	assert.Equal(t, "test@example.com", claims.Email)
+	assert.Equal(t, emailVerificationPurpose, claims.Purpose)
+
+	// Verification tokens are not valid session tokens
+	_, err = jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte("test-secret-key"), nil
+	})
+	assert.Error(t, err)
+
+	// Session tokens cannot verify an email
+	sessionToken, err := service.generateToken(&User{ID: "user-123", Email: "test@example.com"})
+	assert.NoError(t, err)
+	_, err = service.parseVerificationToken(sessionToken)
+	assert.Error(t, err)
+}
+
+func TestEmailVerificationTokenExpired(t *testing.T) {
+	service := NewService(nil, "test-secret-key", 3600).
+		WithEmailVerification(true, time.Hour)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	token, err := service.generateVerificationToken("user-123", "test@example.com")
+	assert.NoError(t, err)
+
+	_, err = service.parseVerificationToken(token)
+	assert.NoError(t, err)
+
+	now = now.Add(time.Hour + time.Second)
+
+	_, err = service.parseVerificationToken(token)
+	assert.Error(t, err)
+	assert.Error(t, service.VerifyEmail(token))
+}
+
+func TestRegisterRejectsNameThatOverflowsOnceEscaped(t *testing.T) {
+	service := NewService(nil, "test-secret-key", 3600)
+
+	// 97 characters as typed, 101 once the ampersand is escaped: over users.name VARCHAR(100)
+	_, err := service.Register(&RegisterRequest{
+		Email:    "test@example.com",
+		Password: "Str0ng!Passw0rd",
+		Name:     strings.Repeat("a", 95) + " &",
+	})
+
+	assert.ErrorIs(t, err, validation.ErrTextTooLong)
+}
+
+func TestNoopEmailSender(t *testing.T) {
+	var sender EmailSender = NoopEmailSender{}
+	assert.NoError(t, sender.SendVerificationEmail("test@example.com", "Test", "token"))
+}
+
+func TestRegisterRejectsOverlongName(t *testing.T) {
+	service := NewService(nil, "test-secret-key", 3600).
+		WithTextLimits(validation.TextLimits{ShortMaxLength: 10, LongMaxLength: 100})
+
+	_, err := service.Register(&RegisterRequest{
+		Email:    "test@example.com",
+		Password: "Str0ng!Passw0rd",
+		Name:     "<script>alert(1)</script>",
+	})
+
+	assert.ErrorIs(t, err, validation.ErrTextTooLong)
+}
+
+func TestValidateOnboardingVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		wantErr   string
+	}{
+		{
+			name: "valid set",
+			variables: map[string]string{
+				"ENTITY":    "Order",
+				"STATE":     "Cart",
+				"FLOW":      "Checkout",
+				"LOGIC":     "Pricing",
+				"INTERFACE": "REST API",
+			},
+		},
+		{
+			name:      "entity only",
+			variables: map[string]string{"ENTITY": "Order"},
+		},
+		{
+			name:      "empty map still requires entity",
+			variables: map[string]string{},
+			wantErr:   "ENTITY is required",
+		},
+		{
+			name:      "first unknown key in sorted order is reported",
+			variables: map[string]string{"ENTITY": "Order", "ZONE": "eu", "COLOR": "blue", "MOOD": "calm"},
+			wantErr:   `unknown variable "COLOR"`,
+		},
+		{
+			name:      "missing entity",
+			variables: map[string]string{"STATE": "Cart", "FLOW": "Checkout"},
+			wantErr:   "ENTITY is required",
+		},
+		{
+			name:      "unknown key",
+			variables: map[string]string{"ENTITY": "Order", "COLOR": "blue"},
+			wantErr:   `unknown variable "COLOR"`,
+		},
+		{
+			name:      "empty value",
+			variables: map[string]string{"ENTITY": "Order", "STATE": ""},
+			wantErr:   "STATE must not be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateOnboardingVariables(tt.variables)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidVariables)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCompleteOnboardingRejectsInvalidVariables(t *testing.T) {
+	service := NewService(nil, "test-secret-key", 3600)
+
+	err := service.CompleteOnboarding(context.Background(), "user-123", "Digital", "e-commerce", "intermediate", "",
+		map[string]string{"COLOR": "Red"})
+	assert.ErrorIs(t, err, ErrInvalidVariables)
+
+	// Without defaults nothing fills the gaps the user left
+	service.WithVariableDefaults(nil)
+
+	err = service.CompleteOnboarding(context.Background(), "user-123", "Digital", "e-commerce", "intermediate", "",
+		map[string]string{"STATE": "Cart"})
+	assert.ErrorIs(t, err, ErrInvalidVariables)
+
+	// Whitespace-only values are empty once sanitized
+	err = service.CompleteOnboarding(context.Background(), "user-123", "Digital", "e-commerce", "intermediate", "",
+		map[string]string{"ENTITY": "   "})
+	assert.ErrorIs(t, err, ErrInvalidVariables)
+}
+
+// newMockService returns a service backed by a sqlmock database
+func newMockService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewService(NewRepository(db), "test-secret-key", 3600), mock
+}
+
+// existingUserRows returns a user row as selected by GetUserByEmail
+func existingUserRows(email, normalizedEmail, passwordHash string) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{
+		"id", "email", "email_normalized", "password_hash", "name", "avatar_url",
+		"locale", "email_verified", "is_admin", "created_at", "updated_at", "last_login",
+	}).AddRow("user-1", email, normalizedEmail, passwordHash, "Jane", "", "en", true, false, now, now, now)
+}
+
+func TestRegisterDetectsDuplicateEmailVariants(t *testing.T) {
+	variants := []string{
+		"Jane.Doe@Gmail.com",
+		"jane.doe@GMAIL.COM",
+		"janedoe@gmail.com",
+		"jane.doe+courses@gmail.com",
+		"JaneDoe+x@googlemail.com",
+	}
+
+	for _, email := range variants {
+		t.Run(email, func(t *testing.T) {
+			service, mock := newMockService(t)
+			service.WithEmailNormalization(EmailNormalization{LowercaseLocal: true, GmailCanonical: true})
+
+			mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+				WithArgs("janedoe@gmail.com").
+				WillReturnRows(existingUserRows("jane.doe@gmail.com", "janedoe@gmail.com", "hash"))
+
+			_, err := service.Register(&RegisterRequest{
+				Email:    email,
+				Password: "Str0ng!Passw0rd",
+				Name:     "Jane",
+			})
+
+			assert.ErrorIs(t, err, ErrEmailTaken)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRegisterStoresNormalizedEmail(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+		WithArgs("jane@example.com").
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users")).
+		WithArgs(sqlmock.AnyArg(), "Jane@Example.COM", "jane@example.com", sqlmock.AnyArg(), "Jane", "", "en",
+			false, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	resp, err := service.Register(&RegisterRequest{
+		Email:    " Jane@Example.COM ",
+		Password: "Str0ng!Passw0rd",
+		Name:     "Jane",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Jane@Example.COM", resp.User.Email)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterTreatsUniqueViolationAsEmailTaken(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantErr  error
+		wantCode int
+	}{
+		{"unique violation", &pq.Error{Code: "23505", Constraint: "idx_users_email_normalized"}, ErrEmailTaken, http.StatusConflict},
+		{"original email constraint", &pq.Error{Code: "23505", Constraint: "users_email_key"}, ErrEmailTaken, http.StatusConflict},
+		{"unique violation of another column", &pq.Error{Code: "23505", Constraint: "users_pkey"}, nil, http.StatusInternalServerError},
+		{"other constraint violation", &pq.Error{Code: "23514", Constraint: "users_locale_check"}, nil, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+
+			// A concurrent registration inserts the email between the check and the insert
+			mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+				WithArgs("jane@example.com").
+				WillReturnRows(sqlmock.NewRows(nil))
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users")).
+				WillReturnError(tt.err)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/register",
+				strings.NewReader(`{"email": "jane@example.com", "password": "Str0ng!Passw0rd", "name": "Jane"}`))
+			req.Header.Set("Content-Type", "application/json")
+			NewHandler(service).Register(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantErr != nil {
+				assert.Contains(t, rec.Body.String(), tt.wantErr.Error())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoginLooksUpNormalizedEmail(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithPasswordHashing(PasswordHashing{Cost: bcrypt.MinCost, RehashOnLogin: true})
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("Str0ng!Passw0rd"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+		WithArgs("jane@example.com").
+		WillReturnRows(existingUserRows("jane@example.com", "jane@example.com", string(hash)))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resp, err := service.Login(&LoginRequest{Email: "JANE@Example.com", Password: "Str0ng!Passw0rd"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", resp.User.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterAndLoginRecordMetrics(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithPasswordHashing(PasswordHashing{Cost: bcrypt.MinCost})
+	recorder := &testutil.CountingMetrics{}
+	service.WithMetrics(recorder)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+		WithArgs("jane@example.com").
+		WillReturnRows(sqlmock.NewRows(nil))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO users")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	_, err := service.Register(&RegisterRequest{Email: "jane@example.com", Password: "Str0ng!Passw0rd", Name: "Jane"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, recorder.Registrations)
+
+	// A rejected registration is not counted
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+		WithArgs("jane@example.com").
+		WillReturnRows(existingUserRows("jane@example.com", "jane@example.com", "hash"))
+	_, err = service.Register(&RegisterRequest{Email: "jane@example.com", Password: "Str0ng!Passw0rd", Name: "Jane"})
+	require.ErrorIs(t, err, ErrEmailTaken)
+	assert.Equal(t, 1, recorder.Registrations)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("Str0ng!Passw0rd"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	// Only successful logins are counted
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+		WithArgs("jane@example.com").
+		WillReturnRows(existingUserRows("jane@example.com", "jane@example.com", string(hash)))
+	_, err = service.Login(&LoginRequest{Email: "jane@example.com", Password: "wrong"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Zero(t, recorder.Logins)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+		WithArgs("jane@example.com").
+		WillReturnRows(existingUserRows("jane@example.com", "jane@example.com", string(hash)))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = service.Login(&LoginRequest{Email: "jane@example.com", Password: "Str0ng!Passw0rd"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, recorder.Logins)
+	assert.Equal(t, 1, recorder.Registrations)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// newRecordingMockService is newMockService that also records every statement
+// sent to the database, including ones no expectation matched
+func newRecordingMockService(t *testing.T) (*Service, sqlmock.Sqlmock, *[]string) {
+	t.Helper()
+
+	var statements []string
+	matcher := sqlmock.QueryMatcherFunc(func(expectedSQL, actualSQL string) error {
+		statements = append(statements, actualSQL)
+		return sqlmock.QueryMatcherRegexp.Match(expectedSQL, actualSQL)
+	})
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(matcher))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewService(NewRepository(db), "test-secret-key", 3600), mock, &statements
+}
+
+// capturedHash records the password hash written by UpdatePasswordHash
+type capturedHash struct {
+	value string
+}
+
+func (c *capturedHash) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+	c.value = hash
+	return ok
+}
+
+func TestLoginRehashesOutdatedPassword(t *testing.T) {
+	const password = "Str0ng!Passw0rd"
+	oldHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		hashing    PasswordHashing
+		wantRehash bool
+	}{
+		{"outdated cost is upgraded", PasswordHashing{Cost: bcrypt.MinCost + 1, RehashOnLogin: true}, true},
+		{"current cost is left unchanged", PasswordHashing{Cost: bcrypt.MinCost, RehashOnLogin: true}, false},
+		{"rehashing disabled", PasswordHashing{Cost: bcrypt.MinCost + 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock, statements := newRecordingMockService(t)
+			service.WithPasswordHashing(tt.hashing)
+
+			mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+				WithArgs("jane@example.com").
+				WillReturnRows(existingUserRows("jane@example.com", "jane@example.com", string(oldHash)))
+			stored := &capturedHash{}
+			if tt.wantRehash {
+				mock.ExpectExec(regexp.QuoteMeta("SET password_hash = $1")).
+					WithArgs(stored, "user-1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET last_login = $1 WHERE id = $2")).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			_, err := service.Login(&LoginRequest{Email: "jane@example.com", Password: password})
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			rehashed := false
+			for _, statement := range *statements {
+				rehashed = rehashed || strings.Contains(statement, "SET password_hash")
+			}
+			assert.Equal(t, tt.wantRehash, rehashed)
+
+			if tt.wantRehash {
+				cost, err := bcrypt.Cost([]byte(stored.value))
+				require.NoError(t, err)
+				assert.Equal(t, tt.hashing.Cost, cost)
+				assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.value), []byte(password)))
+			}
+		})
+	}
+}
+
+func TestLoginUpgradesCost10HashToConfiguredCost(t *testing.T) {
+	const password = "Str0ng!Passw0rd"
+	oldHash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	require.NoError(t, err)
+
+	service, mock := newMockService(t)
+	service.WithPasswordHashing(PasswordHashing{Cost: 12, RehashOnLogin: true})
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+		WithArgs("jane@example.com").
+		WillReturnRows(existingUserRows("jane@example.com", "jane@example.com", string(oldHash)))
+	stored := &capturedHash{}
+	mock.ExpectExec(regexp.QuoteMeta("SET password_hash = $1")).
+		WithArgs(stored, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET last_login = $1 WHERE id = $2")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = service.Login(&LoginRequest{Email: "jane@example.com", Password: password})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	cost, err := bcrypt.Cost([]byte(stored.value))
+	require.NoError(t, err)
+	assert.Equal(t, 12, cost)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.value), []byte(password)))
+}
+
+func TestLoginDummyHashUsesConfiguredCost(t *testing.T) {
+	service, _ := newMockService(t)
+	service.WithPasswordHashing(PasswordHashing{Cost: bcrypt.MinCost + 1})
+
+	cost, err := bcrypt.Cost(service.loginDummyHash())
+	require.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost+1, cost)
+}
+
+// recordingEmailSender captures verification emails instead of sending them
+type recordingEmailSender struct {
+	sent []string
+}
+
+func (r *recordingEmailSender) SendVerificationEmail(to, name, token string) error {
+	r.sent = append(r.sent, to)
+	return nil
+}
+
+// verificationUserRows returns a user row as selected by GetUserByEmail with the given verification state
+func verificationUserRows(email string, verified bool) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{
+		"id", "email", "email_normalized", "password_hash", "name", "avatar_url",
+		"locale", "email_verified", "is_admin", "created_at", "updated_at", "last_login",
+	}).AddRow("user-1", email, email, "hash", "Jane", "", "en", verified, false, now, now, now)
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	service, mock := newMockService(t)
+	sender := &recordingEmailSender{}
+	service.WithEmailSender(sender)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	// An unverified account gets a fresh token
+	mock.ExpectQuery(`WHERE email_normalized = \$1`).
+		WithArgs("jane@example.com").
+		WillReturnRows(verificationUserRows("jane@example.com", false))
+	require.NoError(t, service.ResendVerificationEmail("Jane@Example.com"))
+	assert.Equal(t, []string{"jane@example.com"}, sender.sent)
+
+	// Within the cooldown nothing is looked up or sent
+	assert.ErrorIs(t, service.ResendVerificationEmail("jane@example.com"), ErrVerificationResendTooSoon)
+
+	// After the cooldown another email goes out
+	now = now.Add(defaultResendCooldown)
+	mock.ExpectQuery(`WHERE email_normalized = \$1`).
+		WithArgs("jane@example.com").
+		WillReturnRows(verificationUserRows("jane@example.com", false))
+	require.NoError(t, service.ResendVerificationEmail("jane@example.com"))
+	assert.Len(t, sender.sent, 2)
+
+	// Verified and unknown addresses succeed silently
+	mock.ExpectQuery(`WHERE email_normalized = \$1`).
+		WithArgs("verified@example.com").
+		WillReturnRows(verificationUserRows("verified@example.com", true))
+	require.NoError(t, service.ResendVerificationEmail("verified@example.com"))
+	mock.ExpectQuery(`WHERE email_normalized = \$1`).
+		WithArgs("nobody@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	require.NoError(t, service.ResendVerificationEmail("nobody@example.com"))
+	assert.Len(t, sender.sent, 2)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRenormalizeEmailsRewritesChangedRows(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithEmailNormalization(EmailNormalization{LowercaseLocal: true, GmailCanonical: true})
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, email, email_normalized FROM users")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "email_normalized"}).
+			AddRow("user-1", "Jane.Doe@gmail.com", "jane.doe@gmail.com").
+			AddRow("user-2", "bob@example.com", "bob@example.com"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET email_normalized = $1 WHERE id = $2")).
+		WithArgs("janedoe@gmail.com", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	count, err := service.RenormalizeEmails()
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRenormalizeEmailsRefusesToMergeAccounts(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithEmailNormalization(EmailNormalization{LowercaseLocal: true, GmailCanonical: true})
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, email, email_normalized FROM users")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "email_normalized"}).
+			AddRow("user-1", "jane.doe@gmail.com", "jane.doe@gmail.com").
+			AddRow("user-2", "janedoe@gmail.com", "janedoe@gmail.com"))
+
+	count, err := service.RenormalizeEmails()
+
+	assert.ErrorIs(t, err, ErrEmailNormalizationConflict)
+	assert.Zero(t, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// userByIDRows returns a user without saved privacy settings as selected by GetUserByID
+func userByIDRows(userID string) *sqlmock.Rows {
+	return userWithPrivacyRows(userID, nil)
+}
+
+// userWithPrivacyRows returns a user row as selected by GetUserByID; nil
+// settings leave the joined privacy columns NULL
+func userWithPrivacyRows(userID string, settings *PrivacySettings) *sqlmock.Rows {
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{
+		"id", "email", "password_hash", "name", "avatar_url", "locale", "email_verified", "is_admin",
+		"created_at", "updated_at", "last_login",
+		"profile_visibility", "activity_visibility", "progress_visibility",
+		"allow_followers", "show_in_leaderboards", "show_completed_courses",
+	})
+	if settings == nil {
+		return rows.AddRow(userID, "jane@example.com", "hash", "Jane", "", "en", true, false, now, now, now,
+			nil, nil, nil, nil, nil, nil)
+	}
+	return rows.AddRow(userID, "jane@example.com", "hash", "Jane", "", "en", true, false, now, now, now,
+		settings.ProfileVisibility, settings.ActivityVisibility, settings.ProgressVisibility,
+		settings.AllowFollowers, settings.ShowInLeaderboards, settings.ShowCompletedCourses)
+}
+
+func TestGetPrivacySettingsFallsBackToDefaults(t *testing.T) {
+	stored := &PrivacySettings{
+		ProfileVisibility:  VisibilityPublic,
+		ActivityVisibility: VisibilityPrivate,
+		ProgressVisibility: VisibilityPublic,
+	}
+
+	tests := []struct {
+		name   string
+		stored *PrivacySettings
+		want   *PrivacySettings
+	}{
+		{"no saved row", nil, DefaultPrivacySettings()},
+		{"saved row", stored, stored},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+				WithArgs("user-1").
+				WillReturnRows(userWithPrivacyRows("user-1", tt.stored))
+
+			settings, err := service.GetPrivacySettings("user-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, settings)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdatePrivacySettingsMergesPartialUpdate(t *testing.T) {
+	service, mock := newMockService(t)
+	public := VisibilityPublic
+	hideCourses := false
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userByIDRows("user-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_privacy_settings")).
+		WithArgs("user-1", VisibilityPublic, VisibilityFriends, VisibilityFriends, true, true, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	settings, err := service.UpdatePrivacySettings("user-1", &UpdatePrivacyRequest{
+		ProfileVisibility:    &public,
+		ShowCompletedCourses: &hideCourses,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, VisibilityPublic, settings.ProfileVisibility)
+	assert.Equal(t, VisibilityFriends, settings.ProgressVisibility)
+	assert.False(t, settings.ShowCompletedCourses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdatePrivacySettingsRejectsUnknownVisibility(t *testing.T) {
+	service, mock := newMockService(t)
+	everyone := "everyone"
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userByIDRows("user-1"))
+
+	_, err := service.UpdatePrivacySettings("user-1", &UpdatePrivacyRequest{ProgressVisibility: &everyone})
+	assert.ErrorIs(t, err, ErrInvalidPrivacySettings)
+	// Nothing is stored
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// userWithPasswordRows returns a user row as selected by GetUserByID with the given password hash
+func userWithPasswordRows(userID, passwordHash string) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{
+		"id", "email", "password_hash", "name", "avatar_url", "locale", "email_verified", "is_admin",
+		"created_at", "updated_at", "last_login",
+		"profile_visibility", "activity_visibility", "progress_visibility",
+		"allow_followers", "show_in_leaderboards", "show_completed_courses",
+	}).AddRow(userID, "jane@example.com", passwordHash, "Jane", "", "en", true, false, now, now, now,
+		nil, nil, nil, nil, nil, nil)
+}
+
+func TestDeleteAccountRemovesAllUserData(t *testing.T) {
+	service, mock := newMockService(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userWithPasswordRows("user-1", string(hash)))
+	mock.ExpectBegin()
+	for _, statement := range accountDeletionStatements {
+		mock.ExpectExec(regexp.QuoteMeta(statement)).WithArgs("user-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	require.NoError(t, service.DeleteAccount(context.Background(), "user-1", "Secret123!"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAccountRollsBackOnFailure(t *testing.T) {
+	service, mock := newMockService(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userWithPasswordRows("user-1", string(hash)))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(accountDeletionStatements[0])).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(accountDeletionStatements[1])).WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	assert.Error(t, service.DeleteAccount(context.Background(), "user-1", "Secret123!"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAccountStopsWhenRequestEnds(t *testing.T) {
+	service, mock := newMockService(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userWithPasswordRows("user-1", string(hash)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = service.DeleteAccount(ctx, "user-1", "Secret123!")
+	assert.ErrorIs(t, err, context.Canceled)
+	// No transaction is started for a request that already ended
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAccountRequiresPassword(t *testing.T) {
+	service, mock := newMockService(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userWithPasswordRows("user-1", string(hash)))
+
+	err = service.DeleteAccount(context.Background(), "user-1", "wrong-password")
+	assert.ErrorIs(t, err, ErrPasswordMismatch)
+	// Nothing is deleted
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAccountIsIdempotent(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	assert.NoError(t, service.DeleteAccount(context.Background(), "user-1", "Secret123!"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAccountHandler(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"missing password", `{}`, http.StatusBadRequest},
+		{"wrong password", `{"password": "wrong-password"}`, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			if tt.wantStatus == http.StatusForbidden {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+					WithArgs("user-1").
+					WillReturnRows(userWithPasswordRows("user-1", string(hash)))
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/users/me", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+			rec := httptest.NewRecorder()
+
+			NewHandler(service).DeleteAccount(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestHandlersMapSentinelErrorsToStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		sentinel   error
+		message    string // Expected response message when it is more specific than the sentinel's
+		method     string
+		body       string
+		userID     string
+		setup      func(service *Service, mock sqlmock.Sqlmock)
+		handle     func(h *Handler) http.HandlerFunc
+		wantStatus int
+	}{
+		{
+			name:       "invalid email",
+			sentinel:   ErrInvalidEmail,
+			body:       `{"email": "not-an-email", "password": "Str0ng!Passw0rd", "name": "Jane"}`,
+			handle:     func(h *Handler) http.HandlerFunc { return h.Register },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "weak password",
+			sentinel:   ErrWeakPassword,
+			message:    "password must contain at least one uppercase letter",
+			body:       `{"email": "jane@example.com", "password": "alllowercase1!", "name": "Jane"}`,
+			handle:     func(h *Handler) http.HandlerFunc { return h.Register },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "email taken",
+			sentinel: ErrEmailTaken,
+			body:     `{"email": "jane@example.com", "password": "Str0ng!Passw0rd", "name": "Jane"}`,
+			setup: func(service *Service, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+					WillReturnRows(existingUserRows("jane@example.com", "jane@example.com", "hash"))
+			},
+			handle:     func(h *Handler) http.HandlerFunc { return h.Register },
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:     "invalid credentials",
+			sentinel: ErrInvalidCredentials,
+			body:     `{"email": "jane@example.com", "password": "Str0ng!Passw0rd"}`,
+			setup: func(service *Service, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+					WillReturnRows(sqlmock.NewRows(nil))
+			},
+			handle:     func(h *Handler) http.HandlerFunc { return h.Login },
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:     "email not verified",
+			sentinel: ErrEmailNotVerified,
+			body:     `{"email": "jane@example.com", "password": "Str0ng!Passw0rd"}`,
+			setup: func(service *Service, mock sqlmock.Sqlmock) {
+				service.WithEmailVerification(true, time.Hour)
+				hash, err := bcrypt.GenerateFromPassword([]byte("Str0ng!Passw0rd"), bcrypt.MinCost)
+				require.NoError(t, err)
+				now := time.Now()
+				mock.ExpectQuery(regexp.QuoteMeta("WHERE email_normalized = $1")).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"id", "email", "email_normalized", "password_hash", "name", "avatar_url",
+						"locale", "email_verified", "is_admin", "created_at", "updated_at", "last_login",
+					}).AddRow("user-1", "jane@example.com", "jane@example.com", string(hash), "Jane", "", "en", false, false, now, now, now))
+			},
+			handle:     func(h *Handler) http.HandlerFunc { return h.Login },
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "invalid verification token",
+			sentinel:   ErrInvalidVerificationToken,
+			body:       `{"token": "not-a-token"}`,
+			handle:     func(h *Handler) http.HandlerFunc { return h.VerifyEmail },
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "user not found",
+			sentinel: ErrUserNotFound,
+			method:   http.MethodGet,
+			userID:   "user-1",
+			setup: func(service *Service, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM users")).WithArgs("user-1").
+					WillReturnRows(sqlmock.NewRows(nil))
+			},
+			handle:     func(h *Handler) http.HandlerFunc { return h.GetProfile },
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:     "invalid locale",
+			sentinel: ErrInvalidLocale,
+			body:     `{"locale": "not a locale"}`,
+			userID:   "user-1",
+			setup: func(service *Service, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM users")).WithArgs("user-1").
+					WillReturnRows(userByIDRows("user-1"))
+			},
+			handle:     func(h *Handler) http.HandlerFunc { return h.UpdateProfile },
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			if tt.setup != nil {
+				tt.setup(service, mock)
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.userID != "" {
+				req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: tt.userID}))
+			}
+			rec := httptest.NewRecorder()
+
+			tt.handle(NewHandler(service))(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			message := tt.message
+			if message == "" {
+				message = tt.sentinel.Error()
+			}
+			assert.Contains(t, rec.Body.String(), message)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRegisterHandlerRejectsNonJSONBodies(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"form encoded", "application/x-www-form-urlencoded", "email=jane%40example.com", http.StatusUnsupportedMediaType, "Content-Type must be application/json"},
+		{"no content type", "", `{"email": "jane@example.com"}`, http.StatusUnsupportedMediaType, "Content-Type must be application/json"},
+		{"empty body", "application/json", "", http.StatusBadRequest, "request body is required"},
+		{"malformed JSON", "application/json", `{"email": `, http.StatusBadRequest, "request body is not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/register", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			NewHandler(service).Register(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantMessage)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCompleteOnboardingRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name         string
+		metaCategory string
+		domain       string
+		skillLevel   string
+		variables    map[string]string
+		wantField    string
+	}{
+		{"unknown meta category", "Culinary", "baking", "beginner", nil, "meta_category"},
+		{"unknown skill level", "Economic", "e-commerce", "quant", nil, "skill_level"},
+		{"domain too short", "Economic", "e", "beginner", nil, "domain"},
+		{"domain with markup", "Digital", "<script>alert(1)</script>", "beginner", nil, "domain"},
+		{"domain without letters", "Digital", "12345", "beginner", nil, "domain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+
+			err := service.CompleteOnboarding(context.Background(), "user-1", tt.metaCategory, tt.domain, tt.skillLevel, "", tt.variables)
+
+			var fieldErr *validation.FieldError
+			require.ErrorAs(t, err, &fieldErr)
+			assert.Equal(t, tt.wantField, fieldErr.Field)
+			// Nothing is read or written for invalid input
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("unknown variable key", func(t *testing.T) {
+		service, _ := newMockService(t)
+
+		err := service.CompleteOnboarding(context.Background(), "user-1", "Economic", "e-commerce", "beginner", "",
+			map[string]string{"ENTITY": "Order", "COLOR": "blue"})
+		assert.ErrorIs(t, err, ErrInvalidVariables)
+	})
+}
+
+func TestCompleteOnboardingStoresCanonicalCategoryAndLevel(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userByIDRows("user-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_archetypes")).
+		WithArgs(sqlmock.AnyArg(), "user-1", "Economic", "e-commerce", "advanced", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO user_variables"))
+	for i := 0; i < 5; i++ {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_variables")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	err := service.CompleteOnboarding(context.Background(), "user-1", "economic", "e-commerce", "Advanced", "",
+		map[string]string{"ENTITY": "Order"})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteOnboardingTwiceConflicts(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userByIDRows("user-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_archetypes")).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "user_archetypes_user_id_key"})
+
+	body := `{"meta_category": "Economic", "domain": "e-commerce", "skill_level": "beginner", "variables": {"ENTITY": "Order"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/onboarding/complete", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).CompleteOnboarding(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), ErrAlreadyOnboarded.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteOnboardingHandlerReportsInvalidField(t *testing.T) {
+	service, _ := newMockService(t)
+
+	body := `{"meta_category": "Economic", "domain": "e-commerce", "skill_level": "expert"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/onboarding/complete", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).CompleteOnboarding(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp apierror.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "skill_level", resp.Field)
+	assert.Equal(t, "skill_level must be one of: beginner, intermediate, advanced", resp.Message)
+}
+
+// stubDomainValidator returns a fixed verdict and records the domains it was asked about
+type stubDomainValidator struct {
+	result *ai.DomainValidation
+	err    error
+	asked  []string
+}
+
+func (v *stubDomainValidator) ValidateDomain(ctx context.Context, domain, metaCategory string) (*ai.DomainValidation, error) {
+	v.asked = append(v.asked, metaCategory+"/"+domain)
+	return v.result, v.err
+}
+
+// expectOnboardingWrites mocks the user lookup, archetype insert and variable inserts of a successful onboarding
+func expectOnboardingWrites(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userByIDRows("user-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_archetypes")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO user_variables"))
+	for i := 0; i < 5; i++ {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_variables")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestCompleteOnboardingValidatesDomain(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator *stubDomainValidator
+		wantErr   error
+	}{
+		{"valid domain", &stubDomainValidator{result: &ai.DomainValidation{IsValid: true, Reason: "real subject"}}, nil},
+		{"invalid domain", &stubDomainValidator{result: &ai.DomainValidation{IsValid: false, Reason: "not a real subject"}}, ErrDomainRejected},
+		{"validator error lets the domain through", &stubDomainValidator{err: errors.New("AI unavailable")}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			service.WithDomainValidator(tt.validator)
+			if tt.wantErr == nil {
+				expectOnboardingWrites(mock)
+			}
+
+			err := service.CompleteOnboarding(context.Background(), "user-1", "Economic", "e-commerce", "beginner", "",
+				map[string]string{"ENTITY": "Order"})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Contains(t, err.Error(), tt.validator.result.Reason)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, []string{"Economic/e-commerce"}, tt.validator.asked)
+			// Rejected domains never reach the database
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCompleteOnboardingHandlerRejectsInvalidDomain(t *testing.T) {
+	service, _ := newMockService(t)
+	service.WithDomainValidator(&stubDomainValidator{
+		result: &ai.DomainValidation{IsValid: false, Reason: "asdf qwerty is not a real subject"},
+	})
+
+	body := `{"meta_category": "Cognitive", "domain": "asdf qwerty", "skill_level": "beginner", "variables": {"ENTITY": "Thing"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/onboarding/complete", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).CompleteOnboarding(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp apierror.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "domain", resp.Field)
+	assert.Equal(t, "domain rejected: asdf qwerty is not a real subject", resp.Message)
+}
+
+func TestCompleteOnboardingHandlerOverAIBudget(t *testing.T) {
+	service, mock := newMockService(t)
+	budgetDB, budgetMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { budgetDB.Close() })
+	validator := &stubDomainValidator{result: &ai.DomainValidation{IsValid: true}}
+	service.WithDomainValidator(validator).WithAIBudget(ai.NewBudget(budgetDB, 5))
+
+	// The user's calls for the day are used up, so the validator is never asked
+	budgetMock.ExpectQuery(regexp.QuoteMeta("INSERT INTO ai_usage")).
+		WithArgs("user-1", sqlmock.AnyArg(), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"calls"}))
+
+	body := `{"meta_category": "Economic", "domain": "e-commerce", "skill_level": "beginner", "variables": {"ENTITY": "Order"}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/onboarding/complete", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).CompleteOnboarding(rec, req)
+
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	var resp apierror.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, apierror.CodeTooManyRequests, resp.Error)
+	assert.Contains(t, resp.Message, "daily AI usage limit reached")
+	assert.Empty(t, validator.asked)
+	assert.NoError(t, budgetMock.ExpectationsWereMet())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplaceProfileClearsOmittedFields(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userWithPasswordRows("user-1", "hash"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users")).
+		WithArgs("Jane Doe", "", ai.DefaultLocale, sqlmock.AnyArg(), "user-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req := httptest.NewRequest(http.MethodPut, "/api/users/me", strings.NewReader(`{"name": "Jane Doe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).ReplaceProfile(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// userUpdatedAtRows returns a user row as selected by GetUserByID, last updated at updatedAt
+func userUpdatedAtRows(userID string, updatedAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "email", "password_hash", "name", "avatar_url", "locale", "email_verified", "is_admin",
+		"created_at", "updated_at", "last_login",
+		"profile_visibility", "activity_visibility", "progress_visibility",
+		"allow_followers", "show_in_leaderboards", "show_completed_courses",
+	}).AddRow(userID, "jane@example.com", "hash", "Jane", "", "en", true, false, updatedAt, updatedAt, updatedAt,
+		nil, nil, nil, nil, nil, nil)
+}
+
+func TestUpdateProfile_ConcurrentUpdateConflicts(t *testing.T) {
+	service, mock := newMockService(t)
+	read := time.Date(2025, 1, 20, 14, 45, 0, 0, time.UTC)
+
+	// Both requests read the same version; the first write wins
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).WithArgs("user-1").WillReturnRows(userUpdatedAtRows("user-1", read))
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id = $5 AND updated_at = $6")).
+		WithArgs("Alice", "", "en", sqlmock.AnyArg(), "user-1", read).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).WithArgs("user-1").WillReturnRows(userUpdatedAtRows("user-1", read))
+	mock.ExpectExec(regexp.QuoteMeta("WHERE id = $5 AND updated_at = $6")).
+		WithArgs("Bob", "", "en", sqlmock.AnyArg(), "user-1", read).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, service.UpdateProfile("user-1", map[string]interface{}{"name": "Alice"}, &read))
+	err := service.UpdateProfile("user-1", map[string]interface{}{"name": "Bob"}, &read)
+	assert.ErrorIs(t, err, ErrProfileConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateProfileHandler_StaleUpdatedAtConflicts(t *testing.T) {
+	service, mock := newMockService(t)
+	current := time.Date(2025, 1, 20, 14, 45, 0, 0, time.UTC)
+
+	// The client's copy predates the stored profile, so nothing is written
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).WithArgs("user-1").WillReturnRows(userUpdatedAtRows("user-1", current))
+
+	body := `{"name": "Bob", "updated_at": "2025-01-20T14:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/users/me", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).UpdateProfile(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProfileHandler_UpdatedAtRoundTripsToUpdates(t *testing.T) {
+	service, mock := newMockService(t)
+	current := time.Date(2025, 1, 20, 14, 45, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).WithArgs("user-1").WillReturnRows(userUpdatedAtRows("user-1", current))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).GetProfile(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, current.Format(time.RFC3339), body["updated_at"])
+	assert.NotContains(t, body, "UpdatedAt")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplaceProfileHandlerRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+	}{
+		{"missing name", `{"avatar_url": "https://example.com/a.png"}`, "name"},
+		{"invalid avatar URL", `{"name": "Jane", "avatar_url": "javascript:alert(1)"}`, "avatar_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+				WithArgs("user-1").
+				WillReturnRows(userWithPasswordRows("user-1", "hash"))
+
+			req := httptest.NewRequest(http.MethodPut, "/api/users/me", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+			rec := httptest.NewRecorder()
+
+			NewHandler(service).ReplaceProfile(rec, req)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			var body apierror.Response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.wantField, body.Field)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
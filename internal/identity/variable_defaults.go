@@ -0,0 +1,120 @@
+package identity
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed default_variables.json
+var embeddedVariableDefaults []byte
+
+// VariableDefaults holds fallback onboarding variables used when a user skips
+// detailed onboarding. More specific sets win: domain over meta-category over fallback.
+type VariableDefaults struct {
+	Fallback   map[string]string           `json:"fallback"`
+	Categories map[string]CategoryDefaults `json:"categories"`
+}
+
+// CategoryDefaults are the defaults for one meta-category and its well-known domains.
+// Domain keys are matched case-insensitively.
+type CategoryDefaults struct {
+	Variables map[string]string            `json:"variables"`
+	Domains   map[string]map[string]string `json:"domains,omitempty"`
+}
+
+// DefaultVariableDefaults returns the defaults embedded in the binary
+func DefaultVariableDefaults() *VariableDefaults {
+	defaults, err := parseVariableDefaults(embeddedVariableDefaults)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded variable defaults: %v", err))
+	}
+	return defaults
+}
+
+// LoadVariableDefaults reads defaults from a JSON file with the same shape as the embedded set
+func LoadVariableDefaults(path string) (*VariableDefaults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read variable defaults: %w", err)
+	}
+	return parseVariableDefaults(data)
+}
+
+// parseVariableDefaults decodes defaults and rejects keys outside the universal variable set
+func parseVariableDefaults(data []byte) (*VariableDefaults, error) {
+	var defaults VariableDefaults
+	if err := json.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse variable defaults: %w", err)
+	}
+
+	check := func(where string, variables map[string]string) error {
+		for key := range variables {
+			if !onboardingVariableKeys[key] {
+				return fmt.Errorf("%s: unknown variable %q", where, key)
+			}
+		}
+		return nil
+	}
+
+	if err := check("fallback", defaults.Fallback); err != nil {
+		return nil, err
+	}
+	for category, set := range defaults.Categories {
+		if err := check(category, set.Variables); err != nil {
+			return nil, err
+		}
+		for domain, variables := range set.Domains {
+			if err := check(category+"/"+domain, variables); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &defaults, nil
+}
+
+// Fill returns variables with missing or empty keys filled from the defaults for
+// the given meta-category and domain. Values the user provided are never replaced.
+func (d *VariableDefaults) Fill(metaCategory, domain string, variables map[string]string) map[string]string {
+	filled := make(map[string]string, len(onboardingVariableKeys))
+	for key, value := range variables {
+		filled[key] = value
+	}
+	if d == nil {
+		return filled
+	}
+
+	// Apply the most specific set first so broader sets only fill what is left
+	sets := []map[string]string{d.domainVariables(metaCategory, domain)}
+	if category, ok := d.Categories[metaCategory]; ok {
+		sets = append(sets, category.Variables)
+	}
+	sets = append(sets, d.Fallback)
+
+	for _, set := range sets {
+		for key, value := range set {
+			if filled[key] == "" && value != "" {
+				filled[key] = value
+			}
+		}
+	}
+	return filled
+}
+
+// domainVariables returns the domain-specific defaults within a meta-category, if any
+func (d *VariableDefaults) domainVariables(metaCategory, domain string) map[string]string {
+	category, ok := d.Categories[metaCategory]
+	if !ok {
+		return nil
+	}
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	for key, variables := range category.Domains {
+		if strings.ToLower(key) == domain {
+			return variables
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package identity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"backend/internal/platform/ai"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariableDefaults_FillMinimalOnboarding(t *testing.T) {
+	defaults := DefaultVariableDefaults()
+
+	// Known domain: every variable comes from the domain-specific set
+	filled := defaults.Fill("Economic", "E-Commerce", nil)
+	assert.Equal(t, map[string]string{
+		"ENTITY":    "Order",
+		"STATE":     "Cart",
+		"FLOW":      "Checkout",
+		"LOGIC":     "Discount Rules",
+		"INTERFACE": "Payment Gateway",
+	}, filled)
+
+	// Unknown domain: the meta-category set applies
+	filled = defaults.Fill("Biological", "beekeeping", map[string]string{})
+	assert.Equal(t, "Organism", filled["ENTITY"])
+	assert.Equal(t, "Life Cycle", filled["FLOW"])
+
+	// Unknown meta-category: the global fallback applies
+	filled = defaults.Fill("Unknown", "anything", nil)
+	assert.Len(t, filled, len(onboardingVariableKeys))
+	assert.Equal(t, "Item", filled["ENTITY"])
+}
+
+func TestVariableDefaults_UserValuesOverride(t *testing.T) {
+	defaults := DefaultVariableDefaults()
+	input := map[string]string{"ENTITY": "Invoice", "LOGIC": ""}
+
+	filled := defaults.Fill("Economic", "e-commerce", input)
+
+	assert.Equal(t, "Invoice", filled["ENTITY"], "explicit values win over defaults")
+	assert.Equal(t, "Discount Rules", filled["LOGIC"], "empty values are filled")
+	assert.Equal(t, "Cart", filled["STATE"])
+	assert.Equal(t, map[string]string{"ENTITY": "Invoice", "LOGIC": ""}, input, "input must not be mutated")
+}
+
+func TestVariableDefaults_NilKeepsInput(t *testing.T) {
+	var defaults *VariableDefaults
+	assert.Equal(t, map[string]string{"ENTITY": "Order"}, defaults.Fill("Economic", "e-commerce", map[string]string{"ENTITY": "Order"}))
+}
+
+func TestLoadVariableDefaults(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	require.NoError(t, os.WriteFile(valid, []byte(`{"fallback": {"ENTITY": "Widget"}}`), 0o600))
+	defaults, err := LoadVariableDefaults(valid)
+	require.NoError(t, err)
+	assert.Equal(t, "Widget", defaults.Fill("Digital", "web", nil)["ENTITY"])
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"categories": {"Digital": {"variables": {"COLOR": "red"}}}}`), 0o600))
+	_, err = LoadVariableDefaults(invalid)
+	assert.ErrorContains(t, err, `unknown variable "COLOR"`)
+}
+
+// recordingGenerator captures the variables passed to course generation
+type recordingGenerator struct {
+	variables map[string]string
+}
+
+func (g *recordingGenerator) GenerateCourse(ctx context.Context, userID, archetypeID string, variables map[string]string) error {
+	g.variables = variables
+	return nil
+}
+
+func TestCompleteOnboardingFillsDefaultVariables(t *testing.T) {
+	service, mock := newMockService(t)
+	generator := &recordingGenerator{}
+	service.WithCourseGenerator(generator)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userByIDRows("user-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_archetypes")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO user_variables"))
+	// The filled defaults are stored alongside what the user entered
+	for i := 0; i < 5; i++ {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_variables")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	err := service.CompleteOnboarding(context.Background(), "user-1", "Economic", "e-commerce", "beginner", "",
+		map[string]string{"ENTITY": "Subscription"})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"ENTITY":    "Subscription",
+		"STATE":     "Cart",
+		"FLOW":      "Checkout",
+		"LOGIC":     "Discount Rules",
+		"INTERFACE": "Payment Gateway",
+	}, generator.variables)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteOnboardingOverAIBudgetSkipsVariableExtraction(t *testing.T) {
+	service, mock := newMockService(t)
+	budgetDB, budgetMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { budgetDB.Close() })
+	aiClient, err := ai.New(ai.ProviderStub, "", "")
+	require.NoError(t, err)
+	generator := &recordingGenerator{}
+	service.WithAIClient(aiClient).WithCourseGenerator(generator).WithAIBudget(ai.NewBudget(budgetDB, 5))
+
+	// The day's calls are used up, so extraction is skipped rather than failing onboarding
+	budgetMock.ExpectQuery(regexp.QuoteMeta("INSERT INTO ai_usage")).
+		WithArgs("user-1", sqlmock.AnyArg(), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"calls"}))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userByIDRows("user-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_archetypes")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO user_variables"))
+	for i := 0; i < 5; i++ {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_variables")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	err = service.CompleteOnboarding(context.Background(), "user-1", "Economic", "e-commerce", "beginner", "", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Order", generator.variables["ENTITY"], "the archetype defaults stand in for the AI")
+	assert.NoError(t, budgetMock.ExpectationsWereMet())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteOnboardingFillsDefaultsBeforeValidation(t *testing.T) {
+	service, mock := newMockService(t)
+	generator := &recordingGenerator{}
+	service.WithCourseGenerator(generator)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM users")).
+		WithArgs("user-1").
+		WillReturnRows(userByIDRows("user-1"))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_archetypes")).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO user_variables"))
+	for i := 0; i < 5; i++ {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user_variables")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	// ENTITY is required, but the archetype defaults supply it
+	err := service.CompleteOnboarding(context.Background(), "user-1", "Economic", "e-commerce", "beginner", "",
+		map[string]string{"STATE": "Basket"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Basket", generator.variables["STATE"])
+	assert.NotEmpty(t, generator.variables["ENTITY"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,143 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingCourseGenerator remembers the variables it was asked to generate from
+type recordingCourseGenerator struct {
+	calls     int
+	variables map[string]string
+}
+
+func (g *recordingCourseGenerator) GenerateCourse(ctx context.Context, userID, archetypeID string, variables map[string]string) error {
+	g.calls++
+	g.variables = variables
+	return nil
+}
+
+// expectArchetype mocks GetArchetypeByUserID; an empty archetypeID means no onboarding
+func expectArchetype(mock sqlmock.Sqlmock, archetypeID string) {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "meta_category", "domain", "skill_level", "created_at", "updated_at"})
+	if archetypeID != "" {
+		rows.AddRow(archetypeID, "user-1", "Digital", "E-commerce", "beginner", time.Now(), time.Now())
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_archetypes")).WithArgs("user-1").WillReturnRows(rows)
+}
+
+// expectVariables mocks GetVariablesByUserID with key/value pairs of archetype-1
+// plus a leftover variable of an older archetype
+func expectVariables(mock sqlmock.Sqlmock, pairs ...string) {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "variable_key", "variable_value", "archetype_id", "created_at"})
+	for i := 0; i < len(pairs); i += 2 {
+		rows.AddRow("var-"+pairs[i], "user-1", pairs[i], pairs[i+1], "archetype-1", time.Now())
+	}
+	rows.AddRow("var-old", "user-1", "LOGIC", "Old logic", "archetype-0", time.Now())
+	mock.ExpectQuery(regexp.QuoteMeta("FROM user_variables")).WithArgs("user-1").WillReturnRows(rows)
+}
+
+// variablesRequest builds an authenticated variables request for user-1
+func variablesRequest(method, body string) *http.Request {
+	req := httptest.NewRequest(method, "/api/users/me/variables", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+}
+
+func TestGetVariablesHandler(t *testing.T) {
+	t.Run("current archetype", func(t *testing.T) {
+		service, mock := newMockService(t)
+		expectArchetype(mock, "archetype-1")
+		expectVariables(mock, "ENTITY", "Order", "STATE", "Order status")
+
+		rec := httptest.NewRecorder()
+		NewHandler(service).GetVariables(rec, variablesRequest(http.MethodGet, ""))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body UserVariables
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, "archetype-1", body.ArchetypeID)
+		assert.Equal(t, map[string]string{"ENTITY": "Order", "STATE": "Order status"}, body.Variables)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("before onboarding", func(t *testing.T) {
+		service, mock := newMockService(t)
+		expectArchetype(mock, "")
+
+		rec := httptest.NewRecorder()
+		NewHandler(service).GetVariables(rec, variablesRequest(http.MethodGet, ""))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"variables": {}}`, rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpdateVariablesRegeneratesCourse(t *testing.T) {
+	service, mock := newMockService(t)
+	generator := &recordingCourseGenerator{}
+	service.WithCourseGenerator(generator)
+
+	expectArchetype(mock, "archetype-1")
+	mock.ExpectBegin()
+	upsert := mock.ExpectPrepare(regexp.QuoteMeta("ON CONFLICT (user_id, variable_key) DO UPDATE"))
+	upsert.ExpectExec().WithArgs("user-1", "STATE", "Fulfilment stage", "archetype-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectVariables(mock, "ENTITY", "Order", "STATE", "Fulfilment stage")
+
+	rec := httptest.NewRecorder()
+	NewHandler(service).UpdateVariables(rec, variablesRequest(http.MethodPatch,
+		`{"variables": {"STATE": "  Fulfilment stage "}, "regenerate": true}`))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body UserVariables
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.True(t, body.Regenerated)
+	assert.Equal(t, 1, generator.calls)
+	assert.Equal(t, map[string]string{"ENTITY": "Order", "STATE": "Fulfilment stage"}, generator.variables,
+		"the course is generated from every current variable, not just the updated ones")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateVariablesHandlerRejectsInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		lookup     bool // Validation passes, so the archetype is looked up
+		wantStatus int
+	}{
+		{"no variables", `{"variables": {}}`, false, http.StatusBadRequest},
+		{"unknown variable", `{"variables": {"COLOR": "Blue"}}`, false, http.StatusBadRequest},
+		{"empty value", `{"variables": {"ENTITY": "   "}}`, false, http.StatusBadRequest},
+		{"before onboarding", `{"variables": {"ENTITY": "Order"}}`, true, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			if tt.lookup {
+				expectArchetype(mock, "")
+			}
+
+			rec := httptest.NewRecorder()
+			NewHandler(service).UpdateVariables(rec, variablesRequest(http.MethodPatch, tt.body))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -2,6 +2,7 @@ package learning
 
 import (
 	"backend/internal/platform/ai"
+	"context"
 	"fmt"
 )
 
@@ -34,7 +35,7 @@ func NewCurriculumAgent(aiClient *ai.Client) *CurriculumAgent {
 }
 
 // Generate creates curriculum based on archetype and variables
-func (a *CurriculumAgent) Generate(archetype, domain string, variables map[string]string) (*GeneratedCourse, error) {
+func (a *CurriculumAgent) Generate(ctx context.Context, archetype, domain string, variables map[string]string, locale string) (*GeneratedCourse, error) {
 	if a.aiClient == nil {
 		return nil, fmt.Errorf("AI client not configured")
 	}
@@ -60,7 +61,7 @@ func (a *CurriculumAgent) Generate(archetype, domain string, variables map[strin
 	}
 
 	// Use AI to generate curriculum structure
-	curriculum, err := a.aiClient.GenerateCurriculum(archetype, domain, aiVars)
+	curriculum, err := a.aiClient.GenerateCurriculum(ctx, archetype, domain, aiVars, locale)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate curriculum: %w", err)
 	}
@@ -134,14 +135,14 @@ func NewReviewerAgent(aiClient *ai.Client) *ReviewerAgent {
 	}
 }
 
-// Review analyzes submitted code
-func (a *ReviewerAgent) Review(code, language, context string) (*ArchitectureReview, error) {
+// Review analyzes submitted code, writing feedback in the given locale
+func (a *ReviewerAgent) Review(ctx context.Context, code, language, reviewContext, locale string) (*ArchitectureReview, error) {
 	if a.aiClient == nil {
 		return nil, fmt.Errorf("AI client not configured")
 	}
 
 	// Call AI client for code review
-	aiReview, err := a.aiClient.ReviewCode(code, language, context)
+	aiReview, err := a.aiClient.ReviewCode(ctx, code, language, reviewContext, locale)
 	if err != nil {
 		return nil, fmt.Errorf("failed to review code: %w", err)
 	}
@@ -153,8 +154,14 @@ func (a *ReviewerAgent) Review(code, language, context string) (*ArchitectureRev
 		EfficiencyScore: aiReview.Efficiency,
 		EdgeCasesScore:  aiReview.EdgeCases,
 		TasteScore:      aiReview.Taste,
+		Summary:         aiReview.Summary,
+		Strengths:       aiReview.Strengths,
+		Suggestions:     aiReview.Suggestions,
 		Feedback:        aiReview.Feedback,
 	}
+	for _, issue := range aiReview.Issues {
+		review.Issues = append(review.Issues, ReviewIssue(issue))
+	}
 
 	return review, nil
 }
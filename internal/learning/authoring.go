@@ -0,0 +1,171 @@
+package learning
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"backend/internal/platform/validation"
+)
+
+// ExerciseLanguages are the languages the database accepts (exercises.language CHECK).
+// The service only accepts the configured subset; see WithLanguages.
+var ExerciseLanguages = []string{"python", "go", "java", "javascript"}
+
+// ExerciseDifficulties are the allowed exercise difficulties (exercises.difficulty CHECK)
+var ExerciseDifficulties = []string{"easy", "medium", "hard"}
+
+// defaultExercisePoints is used when an authored exercise does not set points
+const defaultExercisePoints = 100
+
+// ExerciseInput is an authored exercise as sent to the create and update endpoints
+type ExerciseInput struct {
+	Title        string        `json:"title"`
+	Description  string        `json:"description"`
+	Language     string        `json:"language"`
+	StarterCode  string        `json:"starter_code"`
+	SolutionCode string        `json:"solution_code"`
+	TestCases    []interface{} `json:"test_cases"`
+	Difficulty   string        `json:"difficulty"`
+	Points       int           `json:"points"`
+	Hints        []interface{} `json:"hints"`
+}
+
+// CreateExercise adds an authored exercise to the end of a module. The input is
+// validated and the solution must pass every test case before it is saved.
+func (s *Service) CreateExercise(ctx context.Context, moduleID string, input ExerciseInput) (*Exercise, error) {
+	if _, err := s.repo.GetModuleByID(ctx, moduleID); err != nil {
+		return nil, err
+	}
+
+	exercise := &Exercise{ModuleID: moduleID}
+	if err := s.applyExerciseInput(exercise, input); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetModuleExercises(ctx, moduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module exercises: %w", err)
+	}
+	exercise.ExerciseNumber = len(existing) + 1
+
+	if err := s.repo.CreateExercise(ctx, exercise); err != nil {
+		return nil, err
+	}
+
+	return exercise, nil
+}
+
+// UpdateExercise replaces an exercise's authored content, keeping its module and
+// position. The same validation as CreateExercise applies.
+func (s *Service) UpdateExercise(ctx context.Context, exerciseID string, input ExerciseInput) (*Exercise, error) {
+	exercise, err := s.repo.GetExerciseByID(ctx, exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercise: %w", err)
+	}
+
+	if err := s.applyExerciseInput(exercise, input); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateExercise(ctx, exercise); err != nil {
+		return nil, err
+	}
+
+	return exercise, nil
+}
+
+// applyExerciseInput validates input and copies it onto exercise
+func (s *Service) applyExerciseInput(exercise *Exercise, input ExerciseInput) error {
+	title, err := validation.SanitizeText("title", input.Title, s.textLimits.Short())
+	if err != nil {
+		return err
+	}
+	if title == "" {
+		return &validation.FieldError{Field: "title", Message: "is required"}
+	}
+	description, err := validation.SanitizeText("description", input.Description, s.textLimits.Long())
+	if err != nil {
+		return err
+	}
+
+	language, err := validation.OneOf("language", input.Language, s.languageNames())
+	if err != nil {
+		return err
+	}
+	difficulty, err := validation.OneOf("difficulty", input.Difficulty, ExerciseDifficulties)
+	if err != nil {
+		return err
+	}
+
+	points := input.Points
+	if points < 0 {
+		return &validation.FieldError{Field: "points", Message: "must not be negative"}
+	}
+	if points == 0 {
+		points = defaultExercisePoints
+	}
+
+	if strings.TrimSpace(input.SolutionCode) == "" {
+		return &validation.FieldError{Field: "solution_code", Message: "is required"}
+	}
+	if _, err := validateTestCases(input.TestCases); err != nil {
+		return err
+	}
+	if err := validateHints(input.Hints); err != nil {
+		return err
+	}
+
+	hints := input.Hints
+	if hints == nil {
+		hints = []interface{}{}
+	}
+
+	exercise.Title = title
+	exercise.Description = description
+	exercise.Language = language
+	exercise.StarterCode = input.StarterCode
+	exercise.SolutionCode = input.SolutionCode
+	exercise.TestCases = input.TestCases
+	exercise.Difficulty = difficulty
+	exercise.Points = points
+	exercise.Hints = hints
+	return nil
+}
+
+// validateTestCases checks that there is at least one test case and each is an
+// object with an expected_output and an optional boolean is_hidden
+func validateTestCases(raw []interface{}) ([]TestCase, error) {
+	if len(raw) == 0 {
+		return nil, &validation.FieldError{Field: "test_cases", Message: "must contain at least one test case"}
+	}
+
+	testCases := make([]TestCase, 0, len(raw))
+	for i, entry := range raw {
+		testCase, ok := parseTestCase(entry)
+		if ok {
+			_, ok = entry.(map[string]interface{})["expected_output"]
+		}
+		if !ok {
+			return nil, &validation.FieldError{
+				Field:   "test_cases",
+				Message: fmt.Sprintf("entry %d must be an object with input, expected_output and an optional boolean is_hidden", i),
+			}
+		}
+		testCases = append(testCases, testCase)
+	}
+	return testCases, nil
+}
+
+// validateHints checks that each hint is a non-empty string or {text, penalty_points}
+func validateHints(raw []interface{}) error {
+	for i, entry := range raw {
+		if _, ok := parseHint(entry); !ok {
+			return &validation.FieldError{
+				Field:   "hints",
+				Message: fmt.Sprintf("entry %d must be a non-empty string or an object with text", i),
+			}
+		}
+	}
+	return nil
+}
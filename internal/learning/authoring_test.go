@@ -0,0 +1,190 @@
+package learning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/middleware"
+	"backend/internal/platform/validation"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validExerciseInput returns an exercise input that passes validation
+func validExerciseInput() ExerciseInput {
+	return ExerciseInput{
+		Title:        "Sum",
+		Description:  "Add two numbers",
+		Language:     "Go",
+		SolutionCode: "func sum(a, b int) int { return a + b }",
+		TestCases: []interface{}{
+			map[string]interface{}{"input": []interface{}{1.0, 2.0}, "expected_output": 3.0},
+			map[string]interface{}{"input": []interface{}{2.0, 2.0}, "expected_output": 4.0, "is_hidden": true},
+		},
+		Difficulty: "easy",
+		Hints:      []interface{}{"Use the + operator"},
+	}
+}
+
+// expectModule mocks GetModuleByID for module-1 in course-1
+func expectModule(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`FROM generated_modules\s+WHERE id = \$1`).
+		WithArgs("module-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "course_id", "blueprint_module_id", "module_number", "title",
+			"description", "content", "status", "unlocked_at", "created_at",
+		}).AddRow("module-1", "course-1", nil, 1, "Basics", "Intro", []byte(`{}`), "unlocked", time.Now(), time.Now()))
+}
+
+func TestCreateExerciseHandler_AppendsToModule(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+		WithArgs("module-1").
+		WillReturnRows(sqlmock.NewRows([]string{"course_id"}).AddRow("course-1"))
+	mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+		WithArgs("course-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "archetype_id", "title", "description", "meta_category",
+			"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+		}).AddRow("course-1", "owner-1", "archetype-1", "Course", "", "Digital", []byte(`{}`), "active", "ai", time.Now(), time.Now(), nil))
+	expectModule(mock)
+	mock.ExpectQuery(`FROM exercises\s+WHERE module_id = \$1`).
+		WithArgs("module-1").
+		WillReturnRows(exerciseRows("exercise-1", "module-1"))
+	mock.ExpectExec(`INSERT INTO exercises`).
+		WithArgs(sqlmock.AnyArg(), "module-1", 2, "Sum", "Add two numbers", "go",
+			"", sqlmock.AnyArg(), sqlmock.AnyArg(), "easy", defaultExercisePoints, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	body, err := json.Marshal(validExerciseInput())
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	NewHandler(service).CreateExercise(rr, requestAs(http.MethodPost, "/api/modules/module-1/exercises",
+		&middleware.UserClaims{UserID: "owner-1"}, map[string]string{"id": "module-1"}, string(body)))
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var response struct {
+		Data Exercise `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Data.ExerciseNumber)
+	assert.NotEmpty(t, response.Data.SolutionCode, "authors see the solution they saved")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateExercise_RejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(*ExerciseInput)
+		wantField string
+	}{
+		{"missing title", func(in *ExerciseInput) { in.Title = " " }, "title"},
+		{"unsupported language", func(in *ExerciseInput) { in.Language = "cobol" }, "language"},
+		{"unknown difficulty", func(in *ExerciseInput) { in.Difficulty = "expert" }, "difficulty"},
+		{"negative points", func(in *ExerciseInput) { in.Points = -5 }, "points"},
+		{"missing solution", func(in *ExerciseInput) { in.SolutionCode = "" }, "solution_code"},
+		{"no test cases", func(in *ExerciseInput) { in.TestCases = nil }, "test_cases"},
+		{"test case is not an object", func(in *ExerciseInput) { in.TestCases = []interface{}{"3"} }, "test_cases"},
+		{"test case without expected output", func(in *ExerciseInput) {
+			in.TestCases = []interface{}{map[string]interface{}{"input": 1.0}}
+		}, "test_cases"},
+		{"non-boolean is_hidden", func(in *ExerciseInput) {
+			in.TestCases = []interface{}{map[string]interface{}{"expected_output": 1.0, "is_hidden": "yes"}}
+		}, "test_cases"},
+		{"empty hint", func(in *ExerciseInput) { in.Hints = []interface{}{""} }, "hints"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			expectModule(mock)
+
+			input := validExerciseInput()
+			tt.modify(&input)
+			_, err := service.CreateExercise(context.Background(), "module-1", input)
+
+			var fieldErr *validation.FieldError
+			require.True(t, errors.As(err, &fieldErr), "got %v", err)
+			assert.Equal(t, tt.wantField, fieldErr.Field)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateExercise_UnknownModule(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`FROM generated_modules\s+WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	_, err := service.CreateExercise(context.Background(), "missing", validExerciseInput())
+	assert.ErrorIs(t, err, ErrModuleNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateExerciseHandler(t *testing.T) {
+	exerciseOwner := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT gc.user_id\s+FROM exercises e`).
+			WithArgs("exercise-1").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("owner-1"))
+	}
+	put := func(service *Service, claims *middleware.UserClaims, input ExerciseInput) *httptest.ResponseRecorder {
+		body, err := json.Marshal(input)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		NewHandler(service).UpdateExercise(rr, requestAs(http.MethodPut, "/api/exercises/exercise-1",
+			claims, map[string]string{"id": "exercise-1"}, string(body)))
+		return rr
+	}
+
+	t.Run("author replaces the content", func(t *testing.T) {
+		service, mock := newMockService(t)
+		exerciseOwner(mock)
+		mock.ExpectQuery(`FROM exercises\s+WHERE id = \$1`).
+			WithArgs("exercise-1").
+			WillReturnRows(exerciseRows("exercise-1", "module-1"))
+		mock.ExpectExec(`UPDATE exercises`).
+			WithArgs("Sum", "Add two numbers", "go", "", sqlmock.AnyArg(), sqlmock.AnyArg(),
+				"easy", defaultExercisePoints, sqlmock.AnyArg(), "exercise-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rr := put(service, &middleware.UserClaims{UserID: "owner-1"}, validExerciseInput())
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("other users are forbidden", func(t *testing.T) {
+		service, mock := newMockService(t)
+		exerciseOwner(mock)
+
+		rr := put(service, &middleware.UserClaims{UserID: "user-2"}, validExerciseInput())
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid input names the field", func(t *testing.T) {
+		service, mock := newMockService(t)
+		exerciseOwner(mock)
+		mock.ExpectQuery(`FROM exercises\s+WHERE id = \$1`).
+			WithArgs("exercise-1").
+			WillReturnRows(exerciseRows("exercise-1", "module-1"))
+
+		input := validExerciseInput()
+		input.Language = "cobol"
+		rr := put(service, &middleware.UserClaims{UserID: "owner-1"}, input)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		var body apierror.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, "language", body.Field)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
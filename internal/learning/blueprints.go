@@ -0,0 +1,46 @@
+package learning
+
+import (
+	"backend/internal/platform/ai"
+	"errors"
+)
+
+// ErrNoBlueprintModules is returned when neither the blueprint_modules table
+// (seeded by migration 006) nor an AI curriculum provides modules for a course
+var ErrNoBlueprintModules = errors.New("no blueprint modules found: apply migration 006 or configure an AI provider")
+
+// blueprintsFromCurriculum turns AI-generated curriculum modules into blueprint
+// templates so they flow through the same module creation path
+func blueprintsFromCurriculum(curriculum *ai.Curriculum) []BlueprintModule {
+	if curriculum == nil {
+		return nil
+	}
+
+	blueprints := make([]BlueprintModule, 0, len(curriculum.Modules))
+	for i, module := range curriculum.Modules {
+		if module.Title == "" {
+			continue
+		}
+		number := module.Number
+		if number <= 0 {
+			number = i + 1
+		}
+		blueprints = append(blueprints, BlueprintModule{
+			ModuleNumber:        number,
+			TitleTemplate:       module.Title,
+			DescriptionTemplate: module.Description,
+		})
+	}
+	return blueprints
+}
+
+// lowestModuleNumber returns the smallest module number, which is the module unlocked first
+func lowestModuleNumber(blueprints []BlueprintModule) int {
+	lowest := 0
+	for i, blueprint := range blueprints {
+		if i == 0 || blueprint.ModuleNumber < lowest {
+			lowest = blueprint.ModuleNumber
+		}
+	}
+	return lowest
+}
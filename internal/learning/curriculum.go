@@ -0,0 +1,64 @@
+package learning
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Course statuses
+const (
+	CourseStatusActive  = "active"
+	CourseStatusPartial = "partial" // The AI curriculum came back short; CompleteCourseGeneration fills the rest
+)
+
+// CurriculumPolicy controls how short AI curricula are handled for courses
+// built without stored blueprints
+type CurriculumPolicy struct {
+	TargetModules int // Modules a complete course has
+	MinModules    int // Fewest valid modules accepted; fewer and the curriculum is discarded
+}
+
+// DefaultCurriculumPolicy matches the 5-8 modules the curriculum prompt asks for
+func DefaultCurriculumPolicy() CurriculumPolicy {
+	return CurriculumPolicy{
+		TargetModules: 5,
+		MinModules:    1,
+	}
+}
+
+// Validate checks the minimum is positive and does not exceed the target
+func (p CurriculumPolicy) Validate() error {
+	if p.MinModules < 1 {
+		return fmt.Errorf("minimum modules must be at least 1, got %d", p.MinModules)
+	}
+	if p.TargetModules < p.MinModules {
+		return fmt.Errorf("target modules (%d) must not be below minimum modules (%d)", p.TargetModules, p.MinModules)
+	}
+	return nil
+}
+
+// status returns the course status for a course with the given number of modules
+func (p CurriculumPolicy) status(modules int) string {
+	if modules < p.TargetModules {
+		return CourseStatusPartial
+	}
+	return CourseStatusActive
+}
+
+// validCurriculumBlueprints keeps the curriculum modules that have a title and
+// a module number not used by an earlier module, ordered by module number
+func validCurriculumBlueprints(blueprints []BlueprintModule) []BlueprintModule {
+	seen := make(map[int]bool, len(blueprints))
+	valid := make([]BlueprintModule, 0, len(blueprints))
+	for _, blueprint := range blueprints {
+		if blueprint.TitleTemplate == "" || seen[blueprint.ModuleNumber] {
+			continue
+		}
+		seen[blueprint.ModuleNumber] = true
+		valid = append(valid, blueprint)
+	}
+	sort.SliceStable(valid, func(i, j int) bool {
+		return valid[i].ModuleNumber < valid[j].ModuleNumber
+	})
+	return valid
+}
@@ -0,0 +1,129 @@
+package learning
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/platform/ai"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStubAIService returns a service backed by sqlmock and the stub AI provider,
+// whose curriculum always has five modules
+func newStubAIService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	aiClient, err := ai.New(ai.ProviderStub, "", "")
+	require.NoError(t, err)
+	return NewService(NewRepository(db), aiClient), mock
+}
+
+func TestCurriculumPolicy_Validate(t *testing.T) {
+	assert.NoError(t, DefaultCurriculumPolicy().Validate())
+	assert.Error(t, CurriculumPolicy{TargetModules: 5, MinModules: 0}.Validate())
+	assert.Error(t, CurriculumPolicy{TargetModules: 2, MinModules: 3}.Validate())
+}
+
+func TestGenerateCourse_SavesShortCurriculumAsPartial(t *testing.T) {
+	service, mock := newStubAIService(t)
+	service.WithCurriculumPolicy(CurriculumPolicy{TargetModules: 8, MinModules: 3})
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(sqlmock.NewRows(blueprintColumns))
+	mock.ExpectQuery(`SELECT locale FROM users`).WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO generated_courses`).
+		WithArgs(sqlmock.AnyArg(), "user-1", "archetype-1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), CourseStatusPartial, CourseGeneratedByAI, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	prep := mock.ExpectPrepare(`INSERT INTO generated_modules`)
+	for i := 0; i < 5; i++ {
+		prep.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	course, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+	require.NoError(t, err)
+
+	assert.Equal(t, CourseStatusPartial, course.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateCourse_DiscardsCurriculumBelowMinimum(t *testing.T) {
+	service, mock := newStubAIService(t)
+	service.WithCurriculumPolicy(CurriculumPolicy{TargetModules: 8, MinModules: 6})
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(sqlmock.NewRows(blueprintColumns))
+	mock.ExpectQuery(`SELECT locale FROM users`).WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+
+	_, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+
+	assert.ErrorIs(t, err, ErrNoBlueprintModules)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// partialCourseRows returns a course row as selected by GetCourseByID
+func partialCourseRows(status string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "user_id", "archetype_id", "title", "description", "meta_category",
+		"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+	}).AddRow("course-1", "user-1", "archetype-1", "Modeling the Order", "", "Digital",
+		[]byte(`{"ENTITY": "Order"}`), status, "ai", time.Now(), time.Now(), nil)
+}
+
+func TestCompleteCourseGeneration_FillsRemainingModules(t *testing.T) {
+	service, mock := newStubAIService(t)
+
+	mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+		WithArgs("course-1").
+		WillReturnRows(partialCourseRows(CourseStatusPartial))
+	mock.ExpectQuery(`FROM generated_modules\s+WHERE course_id = \$1`).
+		WithArgs("course-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "course_id", "blueprint_module_id", "module_number", "title",
+			"description", "content", "status", "unlocked_at", "created_at",
+		}).
+			AddRow("module-1", "course-1", nil, 1, "Modeling the Order", "", nil, "active", nil, time.Now()).
+			AddRow("module-2", "course-1", nil, 2, "Managing Order state", "", nil, "locked", nil, time.Now()))
+	mock.ExpectQuery(`SELECT locale FROM users`).WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(`INSERT INTO generated_modules`)
+	// Modules 3 to 5 are added locked; the stored ones are not duplicated
+	for number := 3; number <= 5; number++ {
+		prep.ExpectExec().
+			WithArgs(sqlmock.AnyArg(), "course-1", sqlmock.AnyArg(), number, sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), "locked", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+	mock.ExpectExec(`UPDATE generated_courses SET status = \$1`).
+		WithArgs(CourseStatusActive, sqlmock.AnyArg(), "course-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	course, modules, err := service.CompleteCourseGeneration(context.Background(), "course-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, CourseStatusActive, course.Status)
+	assert.Len(t, modules, 5)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteCourseGeneration_RejectsCompleteCourse(t *testing.T) {
+	service, mock := newStubAIService(t)
+
+	mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+		WithArgs("course-1").
+		WillReturnRows(partialCourseRows(CourseStatusActive))
+
+	_, _, err := service.CompleteCourseGeneration(context.Background(), "course-1")
+
+	assert.ErrorIs(t, err, ErrCourseNotPartial)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,7 +2,16 @@ package learning
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+
+	"backend/internal/platform/ai"
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/jsonbody"
+	"backend/internal/platform/middleware"
+	"backend/internal/platform/validation"
 
 	"github.com/gorilla/mux"
 )
@@ -22,20 +31,37 @@ func (h *Handler) RegisterRoutes(r *mux.Router) {
 	// Course routes
 	r.HandleFunc("/api/courses", h.GetCourses).Methods("GET")
 	r.HandleFunc("/api/courses/{id}", h.GetCourseDetails).Methods("GET")
+	r.HandleFunc("/api/courses/{id}", h.DeleteCourse).Methods("DELETE")
+	r.HandleFunc("/api/courses/{id}/restore", h.RestoreCourse).Methods("POST")
 	r.HandleFunc("/api/courses/{id}/progress", h.GetProgress).Methods("GET")
+	r.HandleFunc("/api/courses/{id}/progress/modules", h.GetCourseProgress).Methods("GET")
+	r.HandleFunc("/api/courses/{id}/complete-generation", h.CompleteCourseGeneration).Methods("POST")
+	r.HandleFunc("/api/courses/{id}/exercises", h.GetCourseExercises).Methods("GET")
+
+	// Module routes
+	r.HandleFunc("/api/modules/{id}", h.GetModule).Methods("GET")
+	r.HandleFunc("/api/modules/{id}/exercises", h.CreateExercise).Methods("POST")
 
 	// Exercise routes
 	r.HandleFunc("/api/exercises/{id}", h.GetExercise).Methods("GET")
+	r.HandleFunc("/api/exercises/{id}", h.UpdateExercise).Methods("PUT")
 	r.HandleFunc("/api/exercises/{id}/submit", h.SubmitExercise).Methods("POST")
+	r.HandleFunc("/api/exercises/{id}/hints/{index}", h.RevealHint).Methods("GET")
 
 	// Review routes
+	r.HandleFunc("/api/submissions/pending-review", h.GetPendingReviewSubmissions).Methods("GET")
 	r.HandleFunc("/api/submissions/{id}/review", h.RequestReview).Methods("POST")
-}
+	r.HandleFunc("/api/users/me/skill-trend", h.GetSkillTrend).Methods("GET")
+
+	// Goal routes
+	r.HandleFunc("/api/users/me/goals", h.GetLearningGoals).Methods("GET")
+	r.HandleFunc("/api/users/me/goals", h.SetLearningGoal).Methods("PUT")
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	// Certificate routes
+	r.HandleFunc("/api/users/me/certificates", h.GetCertificates).Methods("GET")
+
+	// Language routes
+	r.HandleFunc("/api/languages", h.GetLanguages).Methods("GET")
 }
 
 // SuccessResponse represents a success response
@@ -44,6 +70,15 @@ type SuccessResponse struct {
 	Data    interface{} `json:"data"`
 }
 
+// PaginatedResponse is a success response for one page of a list
+type PaginatedResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+	Total   int         `json:"total"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+}
+
 // writeJSON writes JSON response
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -51,28 +86,24 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// writeError writes error response
+// writeError writes an error response in the shared apierror envelope.
+// Internal server errors are logged and replaced by a generic message.
 func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, ErrorResponse{
-		Error:   http.StatusText(status),
-		Message: message,
-	})
+	if status == http.StatusInternalServerError {
+		apierror.WriteError(w, apierror.Internal(errors.New(message)))
+		return
+	}
+	apierror.WriteError(w, apierror.FromStatus(status, message))
 }
 
-// getUserID extracts user ID from JWT context
-// In a real implementation, this would extract from JWT middleware context
+// getUserID extracts the authenticated user ID set by the Auth middleware
 func getUserID(r *http.Request) string {
-	// Placeholder - in real implementation, extract from JWT context
-	// For now, check for X-User-ID header (for testing)
-	userID := r.Header.Get("X-User-ID")
-	if userID == "" {
-		// Default test user
-		return "00000000-0000-0000-0000-000000000001"
-	}
+	userID, _ := middleware.GetUserIDFromContext(r.Context())
 	return userID
 }
 
-// GetCourses handles GET /api/courses
+// GetCourses handles GET /api/courses?limit=&offset=&q=&category=&status=
+// Any of q, category or status turns the listing into a search
 func (h *Handler) GetCourses(w http.ResponseWriter, r *http.Request) {
 	userID := getUserID(r)
 	if userID == "" {
@@ -80,18 +111,142 @@ func (h *Handler) GetCourses(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	courses, err := h.service.GetUserCourses(userID)
+	limit, err := parseNonNegativeQueryInt(r, "limit", DefaultCoursePageSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := parseNonNegativeQueryInt(r, "offset", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = DefaultCoursePageSize
+	}
+	if limit > MaxCoursePageSize {
+		limit = MaxCoursePageSize
+	}
+
+	includeDeleted, ok := parseIncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	search := CourseSearch{
+		Query:        r.URL.Query().Get("q"),
+		MetaCategory: r.URL.Query().Get("category"),
+		Status:       r.URL.Query().Get("status"),
+	}
+
+	var courses []GeneratedCourse
+	var total int
+	if search.IsEmpty() {
+		courses, total, err = h.service.GetUserCourses(r.Context(), userID, limit, offset, includeDeleted)
+	} else {
+		courses, total, err = h.service.SearchCourses(r.Context(), userID, search, limit, offset, includeDeleted)
+	}
+	if errors.Is(err, ErrInvalidCourseSearch) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, SuccessResponse{
+	writeJSON(w, http.StatusOK, PaginatedResponse{
 		Success: true,
 		Data:    courses,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
 	})
 }
 
+// parseNonNegativeQueryInt reads an optional non-negative integer query parameter
+func parseNonNegativeQueryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return value, nil
+}
+
+// parseIncludeDeleted reads the admin-only include_deleted query parameter. It
+// writes 400 for a malformed value or 403 for a non-admin asking for deleted
+// courses, and returns false in those cases.
+func parseIncludeDeleted(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	raw := r.URL.Query().Get("include_deleted")
+	if raw == "" {
+		return false, true
+	}
+	includeDeleted, err := strconv.ParseBool(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "include_deleted must be true or false")
+		return false, false
+	}
+	if includeDeleted && !isAdmin(r) {
+		writeError(w, http.StatusForbidden, "include_deleted is only available to admins")
+		return false, false
+	}
+	return includeDeleted, true
+}
+
+// isAdmin reports whether the authenticated requester is an admin
+func isAdmin(r *http.Request) bool {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	return ok && claims != nil && claims.IsAdmin
+}
+
+// authorizeCourse writes 404 or 403 and returns false unless the requester owns the course or is an admin
+func (h *Handler) authorizeCourse(w http.ResponseWriter, r *http.Request, courseID string) bool {
+	ownerID, err := h.service.GetCourseOwnerID(r.Context(), courseID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return false
+	}
+	if !middleware.IsOwnerOrAdmin(r.Context(), ownerID) {
+		writeError(w, http.StatusForbidden, middleware.ErrForbidden.Error())
+		return false
+	}
+	return true
+}
+
+// authorizeExercise writes 404 or 403 and returns false unless the requester owns the
+// exercise's course or is an admin
+func (h *Handler) authorizeExercise(w http.ResponseWriter, r *http.Request, exerciseID string) bool {
+	ownerID, err := h.service.GetExerciseOwnerID(r.Context(), exerciseID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return false
+	}
+	if !middleware.IsOwnerOrAdmin(r.Context(), ownerID) {
+		writeError(w, http.StatusForbidden, middleware.ErrForbidden.Error())
+		return false
+	}
+	return true
+}
+
+// authorizeModule writes 404 or 403 and returns false unless the requester owns the
+// module's course or is an admin
+func (h *Handler) authorizeModule(w http.ResponseWriter, r *http.Request, moduleID string) bool {
+	ownerID, err := h.service.GetModuleOwnerID(r.Context(), moduleID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return false
+	}
+	if !middleware.IsOwnerOrAdmin(r.Context(), ownerID) {
+		writeError(w, http.StatusForbidden, middleware.ErrForbidden.Error())
+		return false
+	}
+	return true
+}
+
 // GetCourseDetails handles GET /api/courses/:id
 func (h *Handler) GetCourseDetails(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -102,12 +257,22 @@ func (h *Handler) GetCourseDetails(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	course, modules, err := h.service.GetCourseDetails(courseID)
+	includeDeleted, ok := parseIncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	course, modules, err := h.service.GetCourseDetails(r.Context(), courseID, includeDeleted)
 	if err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
+	if !middleware.IsOwnerOrAdmin(r.Context(), course.UserID) {
+		writeError(w, http.StatusForbidden, middleware.ErrForbidden.Error())
+		return
+	}
+
 	response := map[string]interface{}{
 		"course":  course,
 		"modules": modules,
@@ -119,6 +284,189 @@ func (h *Handler) GetCourseDetails(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// DeleteCourse handles DELETE /api/courses/:id
+// The course is soft-deleted and can be brought back with POST /api/courses/:id/restore.
+func (h *Handler) DeleteCourse(w http.ResponseWriter, r *http.Request) {
+	courseID := mux.Vars(r)["id"]
+	if courseID == "" {
+		writeError(w, http.StatusBadRequest, "Course ID is required")
+		return
+	}
+
+	if !h.authorizeCourse(w, r, courseID) {
+		return
+	}
+
+	err := h.service.DeleteCourse(r.Context(), courseID)
+	if errors.Is(err, ErrCourseNotFound) {
+		// Deleted concurrently
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to delete course")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreCourse handles POST /api/courses/:id/restore, undoing a soft delete
+func (h *Handler) RestoreCourse(w http.ResponseWriter, r *http.Request) {
+	courseID := mux.Vars(r)["id"]
+	if courseID == "" {
+		writeError(w, http.StatusBadRequest, "Course ID is required")
+		return
+	}
+
+	// authorizeCourse cannot see deleted courses, so ownership is checked here
+	course, err := h.service.GetCourse(r.Context(), courseID, true)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if !middleware.IsOwnerOrAdmin(r.Context(), course.UserID) {
+		writeError(w, http.StatusForbidden, middleware.ErrForbidden.Error())
+		return
+	}
+
+	restored, err := h.service.RestoreCourse(r.Context(), courseID)
+	if errors.Is(err, ErrCourseNotDeleted) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to restore course")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    restored,
+	})
+}
+
+// CompleteCourseGeneration handles POST /api/courses/:id/complete-generation
+// It fills in the modules a partially generated course is missing.
+func (h *Handler) CompleteCourseGeneration(w http.ResponseWriter, r *http.Request) {
+	courseID := mux.Vars(r)["id"]
+	if courseID == "" {
+		writeError(w, http.StatusBadRequest, "Course ID is required")
+		return
+	}
+
+	if !h.authorizeCourse(w, r, courseID) {
+		return
+	}
+
+	course, modules, err := h.service.CompleteCourseGeneration(r.Context(), courseID)
+	switch {
+	case errors.Is(err, ErrCourseNotPartial):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, ErrCurriculumUnavailable):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	case errors.Is(err, ai.ErrBudgetExceeded):
+		writeError(w, http.StatusTooManyRequests, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "Failed to complete course generation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"course":  course,
+			"modules": modules,
+		},
+	})
+}
+
+// GetModule handles GET /api/modules/:id
+// It returns one module with its content and exercises so large courses can load lazily.
+func (h *Handler) GetModule(w http.ResponseWriter, r *http.Request) {
+	moduleID := mux.Vars(r)["id"]
+	if moduleID == "" {
+		writeError(w, http.StatusBadRequest, "Module ID is required")
+		return
+	}
+
+	module, err := h.service.GetModule(r.Context(), moduleID)
+	if errors.Is(err, ErrModuleNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load module")
+		return
+	}
+
+	if !h.authorizeCourse(w, r, module.CourseID) {
+		return
+	}
+
+	exercises, err := h.service.GetModuleExercises(r.Context(), moduleID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load module exercises")
+		return
+	}
+
+	// Only admins may see solutions and hidden test cases
+	if !isAdmin(r) {
+		for i := range exercises {
+			exercises[i] = *exercises[i].SanitizeForLearner()
+		}
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"module":    module,
+			"exercises": exercises,
+		},
+	})
+}
+
+// GetCourseExercises handles GET /api/courses/:id/exercises
+// It returns every exercise of the course grouped by module, so a course renders
+// without fetching each module and exercise separately.
+func (h *Handler) GetCourseExercises(w http.ResponseWriter, r *http.Request) {
+	courseID := mux.Vars(r)["id"]
+	if courseID == "" {
+		writeError(w, http.StatusBadRequest, "Course ID is required")
+		return
+	}
+
+	if !h.authorizeCourse(w, r, courseID) {
+		return
+	}
+
+	modules, err := h.service.GetCourseExercises(r.Context(), courseID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to load course exercises")
+		return
+	}
+
+	// Only admins may see solutions and hidden test cases
+	if !isAdmin(r) {
+		for i := range modules {
+			for j := range modules[i].Exercises {
+				modules[i].Exercises[j] = *modules[i].Exercises[j].SanitizeForLearner()
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"course_id": courseID,
+			"modules":   modules,
+		},
+	})
+}
+
 // GetExercise handles GET /api/exercises/:id
 func (h *Handler) GetExercise(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -129,24 +477,160 @@ func (h *Handler) GetExercise(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	exercise, err := h.service.GetExercise(exerciseID)
+	if !h.authorizeExercise(w, r, exerciseID) {
+		return
+	}
+
+	exercise, err := h.service.GetExercise(r.Context(), exerciseID)
 	if err != nil {
 		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
+	// Only admins may see the solution and hidden test cases
+	if !isAdmin(r) {
+		exercise = exercise.SanitizeForLearner()
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    exercise,
+	})
+}
+
+// CreateExercise handles POST /api/modules/:id/exercises
+// Only the course's author or an admin may add exercises. The response includes
+// the solution and hidden test cases.
+func (h *Handler) CreateExercise(w http.ResponseWriter, r *http.Request) {
+	moduleID := mux.Vars(r)["id"]
+	if moduleID == "" {
+		writeError(w, http.StatusBadRequest, "Module ID is required")
+		return
+	}
+
+	if !h.authorizeModule(w, r, moduleID) {
+		return
+	}
+
+	var input ExerciseInput
+	if err := jsonbody.Decode(r, &input); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	exercise, err := h.service.CreateExercise(r.Context(), moduleID, input)
+	if err != nil {
+		writeExerciseInputError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, SuccessResponse{
+		Success: true,
+		Data:    exercise,
+	})
+}
+
+// UpdateExercise handles PUT /api/exercises/:id
+// The exercise's authored content is replaced wholesale; only the course's author
+// or an admin may update it.
+func (h *Handler) UpdateExercise(w http.ResponseWriter, r *http.Request) {
+	exerciseID := mux.Vars(r)["id"]
+	if exerciseID == "" {
+		writeError(w, http.StatusBadRequest, "Exercise ID is required")
+		return
+	}
+
+	if !h.authorizeExercise(w, r, exerciseID) {
+		return
+	}
+
+	var input ExerciseInput
+	if err := jsonbody.Decode(r, &input); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	exercise, err := h.service.UpdateExercise(r.Context(), exerciseID, input)
+	if err != nil {
+		writeExerciseInputError(w, err)
+		return
+	}
+
 	writeJSON(w, http.StatusOK, SuccessResponse{
 		Success: true,
 		Data:    exercise,
 	})
 }
 
+// writeExerciseInputError maps an exercise authoring failure to its response
+func writeExerciseInputError(w http.ResponseWriter, err error) {
+	var fieldErr *validation.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		apierror.WriteError(w, apierror.InvalidField(fieldErr.Field, err.Error()))
+	case errors.Is(err, validation.ErrTextTooLong):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrModuleNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// RevealHint handles GET /api/exercises/:id/hints/:index
+// The index is zero-based; revealing a hint counts against the next submission's score.
+func (h *Handler) RevealHint(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	exerciseID := vars["id"]
+
+	if exerciseID == "" {
+		writeError(w, http.StatusBadRequest, "Exercise ID is required")
+		return
+	}
+
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil || index < 0 {
+		writeError(w, http.StatusBadRequest, "Hint index must be a non-negative integer")
+		return
+	}
+
+	userID := getUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if !h.authorizeExercise(w, r, exerciseID) {
+		return
+	}
+
+	reveal, err := h.service.RevealHint(r.Context(), userID, exerciseID, index)
+	if errors.Is(err, ErrHintNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    reveal,
+	})
+}
+
 // SubmitExerciseRequest represents exercise submission request
 type SubmitExerciseRequest struct {
-	Code     string `json:"code"`
-	Language string `json:"language"`
+	Code             string `json:"code"`
+	Language         string `json:"language"`
+	TimeSpentSeconds int    `json:"time_spent_seconds,omitempty"`
 }
 
+// maxSubmitArrayElements caps arrays in a submission body. SubmitExerciseRequest
+// has no array fields, so any array is rejected before it is decoded.
+const maxSubmitArrayElements = 0
+
 // SubmitExercise handles POST /api/exercises/:id/submit
 func (h *Handler) SubmitExercise(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -165,8 +649,8 @@ func (h *Handler) SubmitExercise(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request body
 	var req SubmitExerciseRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid request body")
+	if err := jsonbody.DecodeLimited(r, &req, maxSubmitArrayElements); err != nil {
+		apierror.WriteError(w, err)
 		return
 	}
 
@@ -181,13 +665,38 @@ func (h *Handler) SubmitExercise(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.TimeSpentSeconds < 0 {
+		writeError(w, http.StatusBadRequest, ErrNegativeTimeSpent.Error())
+		return
+	}
+
+	if !h.authorizeExercise(w, r, exerciseID) {
+		return
+	}
+
 	// Submit exercise
-	completion, err := h.service.SubmitExercise(userID, exerciseID, req.Code, req.Language)
+	completion, err := h.service.SubmitExercise(r.Context(), userID, exerciseID, req.Code, req.Language, req.TimeSpentSeconds, r.Header.Get("Idempotency-Key"))
+	if errors.Is(err, ErrInvalidIdempotencyKey) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, ErrIdempotencyKeyReused) {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if errors.Is(err, ErrUnsupportedLanguage) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	if completion.Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+
 	writeJSON(w, http.StatusOK, SuccessResponse{
 		Success: true,
 		Data:    completion,
@@ -211,9 +720,18 @@ func (h *Handler) RequestReview(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Request AI review
-	review, err := h.service.RequestReview(submissionID)
+	review, err := h.service.RequestReview(r.Context(), userID, submissionID)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		switch {
+		case errors.Is(err, ErrSubmissionNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, middleware.ErrForbidden):
+			writeError(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, ai.ErrBudgetExceeded):
+			writeError(w, http.StatusTooManyRequests, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -223,7 +741,7 @@ func (h *Handler) RequestReview(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetProgress handles GET /api/courses/:id/progress
+// GetProgress handles GET /api/courses/:id/progress, including progress toward the user's learning goals
 func (h *Handler) GetProgress(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	courseID := vars["id"]
@@ -239,14 +757,204 @@ func (h *Handler) GetProgress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	progress, err := h.service.GetUserProgress(userID, courseID)
-	if err != nil {
+	if !h.authorizeCourse(w, r, courseID) {
+		return
+	}
+
+	journey, err := h.service.GetCourseJourney(r.Context(), userID, courseID)
+	if errors.Is(err, ErrProgressNotFound) {
 		writeError(w, http.StatusNotFound, err.Error())
 		return
 	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get progress")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    journey,
+	})
+}
+
+// GetCourseProgress handles GET /api/courses/:id/progress/modules, with the completion
+// percentage computed from the modules passed and the status of every module
+func (h *Handler) GetCourseProgress(w http.ResponseWriter, r *http.Request) {
+	courseID := mux.Vars(r)["id"]
+	if courseID == "" {
+		writeError(w, http.StatusBadRequest, "Course ID is required")
+		return
+	}
+
+	userID := getUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if !h.authorizeCourse(w, r, courseID) {
+		return
+	}
+
+	progress, err := h.service.GetCourseProgress(r.Context(), userID, courseID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get progress")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    progress,
+	})
+}
+
+// GetPendingReviewSubmissions handles GET /api/submissions/pending-review
+func (h *Handler) GetPendingReviewSubmissions(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	submissions, err := h.service.GetPendingReviewSubmissions(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    submissions,
+	})
+}
+
+// GetSkillTrend handles GET /api/users/me/skill-trend
+func (h *Handler) GetSkillTrend(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	periods := 0
+	if periodsStr := r.URL.Query().Get("periods"); periodsStr != "" {
+		parsed, err := strconv.Atoi(periodsStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "periods must be an integer")
+			return
+		}
+		periods = parsed
+	}
+
+	trend, err := h.service.GetSkillTrend(r.Context(), userID, bucket, periods)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTrendBucket) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    trend,
+	})
+}
+
+// SetLearningGoalRequest represents the request body for setting a learning goal
+type SetLearningGoalRequest struct {
+	Period          string `json:"period"`
+	TargetExercises int    `json:"target_exercises"`
+	TargetMinutes   int    `json:"target_minutes"`
+}
+
+// GetLearningGoals handles GET /api/users/me/goals
+func (h *Handler) GetLearningGoals(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	goals, err := h.service.GetLearningGoals(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    goals,
+	})
+}
+
+// SetLearningGoal handles PUT /api/users/me/goals
+func (h *Handler) SetLearningGoal(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req SetLearningGoalRequest
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	progress, err := h.service.SetLearningGoal(r.Context(), userID, LearningGoal{
+		Period:          req.Period,
+		TargetExercises: req.TargetExercises,
+		TargetMinutes:   req.TargetMinutes,
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidGoal) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	writeJSON(w, http.StatusOK, SuccessResponse{
 		Success: true,
 		Data:    progress,
 	})
 }
+
+// GetCertificates handles GET /api/users/me/certificates
+func (h *Handler) GetCertificates(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	certificates, err := h.service.GetUserCertificates(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    certificates,
+	})
+}
+
+// GetLanguages handles GET /api/languages
+// Lists the languages submissions can be graded in, by name and version only.
+func (h *Handler) GetLanguages(w http.ResponseWriter, r *http.Request) {
+	supported := h.service.SupportedLanguages()
+	languages := make([]PublicLanguage, len(supported))
+	for i, language := range supported {
+		languages[i] = language.Public()
+	}
+
+	writeJSON(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    languages,
+	})
+}
@@ -0,0 +1,132 @@
+package learning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrHintNotFound is returned when an exercise has no hint at the requested index
+var ErrHintNotFound = errors.New("hint not found")
+
+// ActivityBroadcaster publishes learner activity to the social feed
+// (avoids a dependency on the social domain)
+type ActivityBroadcaster interface {
+	BroadcastActivity(ctx context.Context, userID, activityType string, metadata map[string]interface{}) error
+}
+
+// WithActivityBroadcaster sets where learning activity such as hint reveals is published
+func (s *Service) WithActivityBroadcaster(broadcaster ActivityBroadcaster) *Service {
+	s.activityBroadcaster = broadcaster
+	return s
+}
+
+// Hint is one exercise hint as stored in the exercises.hints JSONB array
+type Hint struct {
+	Text          string `json:"text"`
+	PenaltyPoints int    `json:"penalty_points,omitempty"` // Overrides the scoring policy's HintPenalty when set
+}
+
+// HintReveal is a revealed hint together with how many hints the learner has used
+type HintReveal struct {
+	Index     int    `json:"index"`
+	Hint      string `json:"hint"`
+	HintsUsed int    `json:"hints_used"`
+	Total     int    `json:"total"`
+}
+
+// parseHint reads a hint stored either as a plain string or as {text, penalty_points}
+func parseHint(raw interface{}) (Hint, bool) {
+	switch hint := raw.(type) {
+	case string:
+		return Hint{Text: hint}, hint != ""
+	case map[string]interface{}:
+		text, _ := hint["text"].(string)
+		penalty, _ := hint["penalty_points"].(float64)
+		return Hint{Text: text, PenaltyPoints: int(penalty)}, text != ""
+	default:
+		return Hint{}, false
+	}
+}
+
+// exerciseHints returns the exercise's well-formed hints in order
+func exerciseHints(exercise *Exercise) []Hint {
+	stored, ok := exercise.Hints.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	hints := make([]Hint, 0, len(stored))
+	for _, raw := range stored {
+		if hint, ok := parseHint(raw); ok {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// RevealHint returns the exercise hint at index (zero-based) and records it as
+// used. Hints count as used up to the highest index revealed, so skipping ahead
+// uses the earlier hints too. Only the first reveal of a hint is broadcast.
+func (s *Service) RevealHint(ctx context.Context, userID, exerciseID string, index int) (*HintReveal, error) {
+	exercise, err := s.repo.GetExerciseByID(ctx, exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercise: %w", err)
+	}
+
+	hints := exerciseHints(exercise)
+	if index < 0 || index >= len(hints) {
+		return nil, ErrHintNotFound
+	}
+
+	hintsUsed, err := s.repo.GetHintsUsed(ctx, userID, exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revealed hints: %w", err)
+	}
+
+	if index >= hintsUsed {
+		hintsUsed = index + 1
+		if err := s.repo.RecordHintsUsed(ctx, userID, exerciseID, hintsUsed); err != nil {
+			return nil, fmt.Errorf("failed to record hint reveal: %w", err)
+		}
+		s.broadcastHintUsed(ctx, userID, exercise, index)
+	}
+
+	return &HintReveal{
+		Index:     index,
+		Hint:      hints[index].Text,
+		HintsUsed: hintsUsed,
+		Total:     len(hints),
+	}, nil
+}
+
+// broadcastHintUsed publishes a hint_used activity; failures are logged, not returned
+func (s *Service) broadcastHintUsed(ctx context.Context, userID string, exercise *Exercise, index int) {
+	if s.activityBroadcaster == nil {
+		return
+	}
+
+	err := s.activityBroadcaster.BroadcastActivity(ctx, userID, "hint_used", map[string]interface{}{
+		"module_id":   exercise.ModuleID,
+		"exercise_id": exercise.ID,
+		"hint_index":  index,
+	})
+	if err != nil {
+		slog.Warn("failed to broadcast hint reveal",
+			"user_id", userID, "exercise_id", exercise.ID, "error", err)
+	}
+}
+
+// hintPenalty returns the points deducted for the first hintsUsed hints
+func (s *Service) hintPenalty(hints []Hint, hintsUsed int) int {
+	penalty := 0
+	for i := 0; i < hintsUsed && i < len(hints); i++ {
+		if hints[i].PenaltyPoints > 0 {
+			penalty += hints[i].PenaltyPoints
+		} else {
+			penalty += s.scoringPolicy.HintPenalty
+		}
+	}
+	return penalty
+}
@@ -0,0 +1,191 @@
+package learning
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectHintsUsed mocks GetHintsUsed for a user who revealed n hints
+func expectHintsUsed(mock sqlmock.Sqlmock, userID, exerciseID string, n int) {
+	rows := sqlmock.NewRows([]string{"hints_revealed"})
+	if n > 0 {
+		rows.AddRow(n)
+	}
+	mock.ExpectQuery(`SELECT hints_revealed FROM hint_reveals`).WithArgs(userID, exerciseID).WillReturnRows(rows)
+}
+
+// hintedExerciseRows returns an exercise with one plain hint and one carrying its own penalty
+func hintedExerciseRows(exerciseID, moduleID string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "module_id", "exercise_number", "title", "description", "language",
+		"starter_code", "solution_code", "test_cases", "difficulty", "points", "hints", "created_at",
+	}).AddRow(
+		exerciseID, moduleID, 1, "Sum", "Add two numbers", "go",
+		"", "func sum(a, b int) int { return a + b }", []byte(`[{"input": [1, 2], "expected_output": 3}]`),
+		"easy", 10, []byte(`["Use the + operator", {"text": "return a + b", "penalty_points": 20}]`), time.Now(),
+	)
+}
+
+// recordingBroadcaster captures broadcast activity types
+type recordingBroadcaster struct {
+	activities []string
+}
+
+func (b *recordingBroadcaster) BroadcastActivity(ctx context.Context, userID, activityType string, metadata map[string]interface{}) error {
+	b.activities = append(b.activities, activityType)
+	return nil
+}
+
+func TestRevealHint_RecordsFirstReveal(t *testing.T) {
+	service, mock := newMockService(t)
+	broadcaster := &recordingBroadcaster{}
+	service.WithActivityBroadcaster(broadcaster)
+
+	mock.ExpectQuery(`FROM exercises`).WithArgs("exercise-1").WillReturnRows(hintedExerciseRows("exercise-1", "module-1"))
+	expectHintsUsed(mock, "user-1", "exercise-1", 0)
+	mock.ExpectExec(`INSERT INTO hint_reveals`).
+		WithArgs("user-1", "exercise-1", 2, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Skipping ahead uses the earlier hint too
+	reveal, err := service.RevealHint(context.Background(), "user-1", "exercise-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, &HintReveal{Index: 1, Hint: "return a + b", HintsUsed: 2, Total: 2}, reveal)
+	assert.Equal(t, []string{"hint_used"}, broadcaster.activities)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevealHint_RereadingDoesNotRecordAgain(t *testing.T) {
+	service, mock := newMockService(t)
+	broadcaster := &recordingBroadcaster{}
+	service.WithActivityBroadcaster(broadcaster)
+
+	mock.ExpectQuery(`FROM exercises`).WithArgs("exercise-1").WillReturnRows(hintedExerciseRows("exercise-1", "module-1"))
+	expectHintsUsed(mock, "user-1", "exercise-1", 2)
+
+	reveal, err := service.RevealHint(context.Background(), "user-1", "exercise-1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "Use the + operator", reveal.Hint)
+	assert.Equal(t, 2, reveal.HintsUsed)
+	assert.Empty(t, broadcaster.activities)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevealHint_UnknownIndex(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`FROM exercises`).WithArgs("exercise-1").WillReturnRows(hintedExerciseRows("exercise-1", "module-1"))
+
+	_, err := service.RevealHint(context.Background(), "user-1", "exercise-1", 2)
+	assert.ErrorIs(t, err, ErrHintNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_DeductsHintPenalty(t *testing.T) {
+	tests := []struct {
+		name       string
+		hintsUsed  int
+		wantScore  int
+		wantPoints int
+	}{
+		{"no hints", 0, 100, 10},
+		{"policy penalty", 1, 95, 10},
+		{"hint's own penalty", 2, 75, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+
+			mock.ExpectQuery(`FROM exercises`).WithArgs("exercise-1").WillReturnRows(hintedExerciseRows("exercise-1", "module-1"))
+			mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+				WithArgs("user-1", "exercise-1").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			expectHintsUsed(mock, "user-1", "exercise-1", tt.hintsUsed)
+			// Points are awarded on the penalized score
+			mock.ExpectExec(`INSERT INTO module_completions`).
+				WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), tt.wantPoints).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(`SELECT course_id FROM generated_modules`).WithArgs("module-1").WillReturnError(sql.ErrNoRows)
+
+			completion, err := service.SubmitExercise(context.Background(), "user-1", "exercise-1", "func sum(a, b int) int { return a + b }", "go", 0, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantScore, completion.Score)
+			assert.Equal(t, tt.wantPoints, completion.PointsEarned)
+			assert.Equal(t, tt.hintsUsed, completion.HintsUsed)
+			// The penalty lowers the score, not the pass the tests earned
+			assert.True(t, completion.Passed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSanitizeForLearner_WithholdsHints(t *testing.T) {
+	exercise := &Exercise{Hints: []interface{}{"first", map[string]interface{}{"text": "second"}, 42}}
+
+	sanitized := exercise.SanitizeForLearner()
+	assert.Empty(t, sanitized.Hints)
+	assert.Equal(t, 2, sanitized.HintCount)
+	assert.Len(t, exercise.Hints, 3)
+}
+
+func TestPenalizeScore(t *testing.T) {
+	assert.Equal(t, 90, PenalizeScore(100, 10))
+	assert.Equal(t, 0, PenalizeScore(15, 20))
+	assert.Equal(t, 40, PenalizeScore(40, 0))
+	assert.Error(t, ScoringPolicy{PassThreshold: 100, VisibleWeight: 1, HiddenWeight: 1, HintPenalty: 101}.Validate())
+}
+
+func TestRevealHintHandler_RejectsBadIndex(t *testing.T) {
+	service, mock := newMockService(t)
+	owner := &middleware.UserClaims{UserID: "user-1"}
+
+	for _, index := range []string{"-1", "first"} {
+		rr := httptest.NewRecorder()
+		NewHandler(service).RevealHint(rr, requestAs(http.MethodGet, "/api/exercises/exercise-1/hints/"+index, owner,
+			map[string]string{"id": "exercise-1", "index": index}, ""))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, index)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevealHintHandler_ReturnsHint(t *testing.T) {
+	service, mock := newMockService(t)
+	owner := &middleware.UserClaims{UserID: "user-1"}
+
+	mock.ExpectQuery(`SELECT gc.user_id\s+FROM exercises e`).
+		WithArgs("exercise-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1"))
+	mock.ExpectQuery(`FROM exercises`).WithArgs("exercise-1").WillReturnRows(hintedExerciseRows("exercise-1", "module-1"))
+	expectHintsUsed(mock, "user-1", "exercise-1", 0)
+	mock.ExpectExec(`INSERT INTO hint_reveals`).
+		WithArgs("user-1", "exercise-1", 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).RevealHint(rr, requestAs(http.MethodGet, "/api/exercises/exercise-1/hints/0", owner,
+		map[string]string{"id": "exercise-1", "index": "0"}, ""))
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp struct {
+		Success bool       `json:"success"`
+		Data    HintReveal `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.True(t, resp.Success)
+	assert.Equal(t, HintReveal{Index: 0, Hint: "Use the + operator", HintsUsed: 1, Total: 2}, resp.Data)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
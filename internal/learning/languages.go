@@ -0,0 +1,135 @@
+package learning
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ErrUnsupportedLanguage is returned when code is submitted in a language the
+// platform cannot grade
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// Language is a programming language the platform grades, with the settings a
+// code runner needs to build and run a submission
+type Language struct {
+	Name           string `json:"name"`
+	FileExtension  string `json:"file_extension"`            // Including the dot, e.g. ".py"
+	Image          string `json:"image"`                     // Container image the code runs in
+	CompileCommand string `json:"compile_command,omitempty"` // Empty for interpreted languages
+	RunCommand     string `json:"run_command"`
+}
+
+// PublicLanguage is what clients are shown of a supported language; the runner
+// settings stay on the server
+type PublicLanguage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"` // Toolchain version from the image tag, e.g. "3.12"; empty if the image is untagged
+}
+
+// Public returns the language as shown to clients, taking the version from the
+// image tag up to its first dash, e.g. "python:3.12-slim" gives "3.12"
+func (l Language) Public() PublicLanguage {
+	version := ""
+	if i := strings.LastIndex(l.Image, ":"); i >= 0 && !strings.Contains(l.Image[i:], "/") {
+		version, _, _ = strings.Cut(l.Image[i+1:], "-")
+	}
+	return PublicLanguage{Name: l.Name, Version: version}
+}
+
+// DefaultLanguages returns the built-in language set, one entry per language
+// allowed by the exercises.language CHECK
+func DefaultLanguages() []Language {
+	return []Language{
+		{Name: "python", FileExtension: ".py", Image: "python:3.12-slim", RunCommand: "python3 main.py"},
+		{Name: "go", FileExtension: ".go", Image: "golang:1.23-alpine", CompileCommand: "go build -o main main.go", RunCommand: "./main"},
+		{Name: "java", FileExtension: ".java", Image: "eclipse-temurin:21-jdk", CompileCommand: "javac Main.java", RunCommand: "java Main"},
+		{Name: "javascript", FileExtension: ".js", Image: "node:20-alpine", RunCommand: "node main.js"},
+	}
+}
+
+// LoadLanguages reads the supported languages from a JSON array of Language,
+// e.g. [{"name": "python", "file_extension": ".py", "image": "python:3.12-slim", "run_command": "python3 main.py"}]
+func LoadLanguages(path string) ([]Language, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read languages: %w", err)
+	}
+
+	var languages []Language
+	if err := json.Unmarshal(data, &languages); err != nil {
+		return nil, fmt.Errorf("failed to parse languages: %w", err)
+	}
+	if err := ValidateLanguages(languages); err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+// ValidateLanguages checks that the set is non-empty, has no duplicates and only
+// names languages the database accepts, each with the settings needed to run it
+func ValidateLanguages(languages []Language) error {
+	if len(languages) == 0 {
+		return errors.New("at least one language is required")
+	}
+
+	seen := make(map[string]bool, len(languages))
+	for _, language := range languages {
+		if !isExerciseLanguage(language.Name) {
+			return fmt.Errorf("language %q is not one of: %s", language.Name, strings.Join(ExerciseLanguages, ", "))
+		}
+		if seen[language.Name] {
+			return fmt.Errorf("language %q is listed more than once", language.Name)
+		}
+		seen[language.Name] = true
+
+		if !strings.HasPrefix(language.FileExtension, ".") {
+			return fmt.Errorf("language %q: file_extension must start with a dot", language.Name)
+		}
+		if strings.TrimSpace(language.Image) == "" {
+			return fmt.Errorf("language %q: image is required", language.Name)
+		}
+		if strings.TrimSpace(language.RunCommand) == "" {
+			return fmt.Errorf("language %q: run_command is required", language.Name)
+		}
+	}
+	return nil
+}
+
+// isExerciseLanguage reports whether name is allowed by the exercises.language CHECK
+func isExerciseLanguage(name string) bool {
+	for _, allowed := range ExerciseLanguages {
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// WithLanguages replaces the languages submissions and exercises may use, e.g.
+// with ones loaded via LoadLanguages. An invalid set keeps the current one.
+func (s *Service) WithLanguages(languages []Language) *Service {
+	if err := ValidateLanguages(languages); err != nil {
+		slog.Warn("ignoring invalid language set", "error", err)
+		return s
+	}
+	s.languages = append([]Language{}, languages...)
+	return s
+}
+
+// SupportedLanguages returns a copy of the languages the platform grades
+func (s *Service) SupportedLanguages() []Language {
+	return append([]Language{}, s.languages...)
+}
+
+// languageNames returns the names of the supported languages
+func (s *Service) languageNames() []string {
+	names := make([]string, len(s.languages))
+	for i, language := range s.languages {
+		names[i] = language.Name
+	}
+	return names
+}
@@ -0,0 +1,121 @@
+package learning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend/internal/platform/middleware"
+	"backend/internal/platform/validation"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadLanguages(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "languages.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	languages, err := LoadLanguages(write(t, `[{"name": "python", "file_extension": ".py", "image": "python:3.12-slim", "run_command": "python3 main.py"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []Language{{Name: "python", FileExtension: ".py", Image: "python:3.12-slim", RunCommand: "python3 main.py"}}, languages)
+
+	invalid := map[string]string{
+		"empty":            `[]`,
+		"not in database":  `[{"name": "rust", "file_extension": ".rs", "image": "rust:1", "run_command": "cargo run"}]`,
+		"duplicate":        `[{"name": "go", "file_extension": ".go", "image": "golang", "run_command": "./main"}, {"name": "go", "file_extension": ".go", "image": "golang", "run_command": "./main"}]`,
+		"extension no dot": `[{"name": "go", "file_extension": "go", "image": "golang", "run_command": "./main"}]`,
+		"missing image":    `[{"name": "go", "file_extension": ".go", "run_command": "./main"}]`,
+		"missing run":      `[{"name": "go", "file_extension": ".go", "image": "golang"}]`,
+		"malformed":        `{"name": "go"}`,
+	}
+	for name, content := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadLanguages(write(t, content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWithLanguages_InvalidSetKeepsCurrent(t *testing.T) {
+	service := NewService(nil, nil).WithLanguages([]Language{{Name: "cobol"}})
+	assert.Equal(t, DefaultLanguages(), service.SupportedLanguages())
+}
+
+func TestSubmitExercise_RejectsUnsupportedLanguage(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithLanguages([]Language{{Name: "python", FileExtension: ".py", Image: "python", RunCommand: "python3 main.py"}})
+
+	_, err := service.SubmitExercise(context.Background(), "user-1", "exercise-1", "x", "go", 0, "")
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage, "go is allowed by the database but not configured")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExerciseHandler_UnsupportedLanguage(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`SELECT gc.user_id\s+FROM exercises e`).
+		WithArgs("exercise-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1"))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).SubmitExercise(rr, requestAs(http.MethodPost, "/api/exercises/exercise-1/submit",
+		&middleware.UserClaims{UserID: "user-1"}, map[string]string{"id": "exercise-1"}, `{"code": "x", "language": "rust"}`))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "unsupported language: rust")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateExercise_RejectsLanguageNotConfigured(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithLanguages([]Language{{Name: "python", FileExtension: ".py", Image: "python", RunCommand: "python3 main.py"}})
+	expectModule(mock)
+
+	_, err := service.CreateExercise(context.Background(), "module-1", validExerciseInput())
+
+	var fieldErr *validation.FieldError
+	require.True(t, errors.As(err, &fieldErr), "got %v", err)
+	assert.Equal(t, "language", fieldErr.Field)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLanguagesHandler(t *testing.T) {
+	service, _ := newMockService(t)
+	rr := httptest.NewRecorder()
+	NewHandler(service).GetLanguages(rr, httptest.NewRequest(http.MethodGet, "/api/languages", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response struct {
+		Data []map[string]string `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Data, len(DefaultLanguages()))
+	// Runner settings such as the image and commands are not exposed
+	assert.Equal(t, map[string]string{"name": "python", "version": "3.12"}, response.Data[0])
+	assert.Equal(t, map[string]string{"name": "java", "version": "21"}, response.Data[2])
+}
+
+func TestLanguagePublic(t *testing.T) {
+	tests := []struct {
+		image   string
+		version string
+	}{
+		{"golang:1.23-alpine", "1.23"},
+		{"node:20", "20"},
+		{"registry.example.com:5000/python", ""},
+		{"python", ""},
+	}
+	for _, tt := range tests {
+		language := Language{Name: "python", Image: tt.image}
+		assert.Equal(t, PublicLanguage{Name: "python", Version: tt.version}, language.Public(), tt.image)
+	}
+}
@@ -28,8 +28,10 @@ type GeneratedCourse struct {
 	MetaCategory     string
 	InjectedVariables interface{}
 	Status           string
+	GeneratedBy      string `json:"generated_by"` // CourseGeneratedByAI or CourseGeneratedByTemplate
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"` // Set while the course is soft-deleted
 }
 
 // GeneratedModule represents module instance with injected variables
@@ -60,9 +62,36 @@ type Exercise struct {
 	Difficulty     string
 	Points         int
 	Hints          interface{}
+	HintCount      int // Number of hints, set when the hints themselves are withheld
 	CreatedAt      time.Time
 }
 
+// SanitizeForLearner returns a copy of the exercise that is safe to show the
+// learner working on it: the solution is removed and only visible test cases
+// are kept. Test cases in an unexpected shape are dropped rather than exposed.
+// Hints are replaced by their count; learners reveal them one at a time.
+func (e *Exercise) SanitizeForLearner() *Exercise {
+	sanitized := *e
+	sanitized.SolutionCode = ""
+	sanitized.HintCount = len(exerciseHints(e))
+	sanitized.Hints = []interface{}{}
+
+	testCases, ok := e.TestCases.([]interface{})
+	if !ok {
+		sanitized.TestCases = []interface{}{}
+		return &sanitized
+	}
+
+	visible := make([]interface{}, 0, len(testCases))
+	for _, tc := range testCases {
+		if testCase, ok := parseTestCase(tc); ok && !testCase.IsHidden {
+			visible = append(visible, tc)
+		}
+	}
+	sanitized.TestCases = visible
+	return &sanitized
+}
+
 // UserProgress represents overall course progress
 type UserProgress struct {
 	ID                 string
@@ -71,9 +100,49 @@ type UserProgress struct {
 	CurrentModuleID    string
 	ProgressPercentage int
 	TimeSpentMinutes   int
+	TimeSpentSeconds   int
 	LastActivity       time.Time
 	StartedAt          time.Time
 	CompletedAt        *time.Time
+	UpdatedAt          time.Time `json:"updated_at"` // Version checked by UpdateUserProgress; zero for progress not yet stored
+}
+
+// Module statuses reported by CourseProgress, derived from the user's submissions
+const (
+	ModuleStatusNotStarted = "not_started" // No submissions yet
+	ModuleStatusInProgress = "in_progress" // Submitted, but no exercise passed
+	ModuleStatusCompleted  = "completed"   // At least one exercise passed
+)
+
+// ModuleProgress is the user's standing in one module of a course
+type ModuleProgress struct {
+	ModuleID          string     `json:"module_id"`
+	ModuleNumber      int        `json:"module_number"`
+	Title             string     `json:"title"`
+	Status            string     `json:"status"`
+	Submissions       int        `json:"submissions"`
+	PassedSubmissions int        `json:"passed_submissions"`
+	LastSubmittedAt   *time.Time `json:"last_submitted_at,omitempty"`
+}
+
+// CourseProgress is course progress computed from completed modules out of
+// all the course's modules
+type CourseProgress struct {
+	CourseID           string           `json:"course_id"`
+	CompletedModules   int              `json:"completed_modules"`
+	TotalModules       int              `json:"total_modules"`
+	ProgressPercentage int              `json:"progress_percentage"`
+	Completed          bool             `json:"completed"`
+	Modules            []ModuleProgress `json:"modules"`
+}
+
+// ModuleExercises is one module of a course with its exercises in order
+type ModuleExercises struct {
+	ModuleID     string     `json:"module_id"`
+	ModuleNumber int        `json:"module_number"`
+	Title        string     `json:"title"`
+	Status       string     `json:"status"`
+	Exercises    []Exercise `json:"exercises"`
 }
 
 // ModuleCompletion represents exercise submission
@@ -87,10 +156,14 @@ type ModuleCompletion struct {
 	TestResults      interface{}
 	Passed           bool
 	Score            int
+	PointsEarned     int // Exercise points awarded for this submission's score and difficulty
 	Attempts         int
 	HintsUsed        int
 	TimeSpentMinutes int
+	TimeSpentSeconds int
 	SubmittedAt      time.Time
+	IdempotencyKey   string `json:"-"` // Client-supplied key that deduplicates retried submissions
+	Replayed         bool   `json:"-"` // Set when an earlier submission with the same key was returned
 }
 
 // ArchitectureReview represents AI Senior Review
@@ -104,6 +177,72 @@ type ArchitectureReview struct {
 	EfficiencyScore int
 	EdgeCasesScore  int
 	TasteScore      int
-	Feedback        interface{}
+	Summary         string        `json:"summary"`
+	Strengths       []string      `json:"strengths"`
+	Issues          []ReviewIssue `json:"issues"`
+	Suggestions     []string      `json:"suggestions"`
+	Feedback        interface{}   // Flat per-category critique, kept for older clients
 	ReviewedAt      time.Time
 }
+
+// ReviewIssue is one problem found by an AI Senior Review
+type ReviewIssue struct {
+	Category    string `json:"category,omitempty"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// PendingReviewSubmission is a passing submission that has not been reviewed yet
+type PendingReviewSubmission struct {
+	SubmissionID  string    `json:"submission_id"`
+	ModuleID      string    `json:"module_id"`
+	ExerciseID    string    `json:"exercise_id"`
+	ExerciseTitle string    `json:"exercise_title"`
+	Language      string    `json:"language"`
+	Score         int       `json:"score"`
+	SubmittedAt   time.Time `json:"submitted_at"`
+}
+
+// SkillTrendPoint holds average architecture review scores for one time bucket
+type SkillTrendPoint struct {
+	BucketStart time.Time `json:"bucket_start"`
+	ReviewCount int       `json:"review_count"`
+	CodeSense   float64   `json:"code_sense"`
+	Efficiency  float64   `json:"efficiency"`
+	EdgeCases   float64   `json:"edge_cases"`
+	Taste       float64   `json:"taste"`
+	Overall     float64   `json:"overall"`
+}
+
+// LearningGoal is a learner's activity target for a daily or weekly period
+type LearningGoal struct {
+	Period          string    `json:"period"`
+	TargetExercises int       `json:"target_exercises"`
+	TargetMinutes   int       `json:"target_minutes"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// GoalProgress reports activity in the current period against a learning goal
+type GoalProgress struct {
+	LearningGoal
+	PeriodStart     time.Time `json:"period_start"`
+	ExercisesSolved int       `json:"exercises_solved"`
+	MinutesSpent    int       `json:"minutes_spent"`
+	PercentComplete int       `json:"percent_complete"`
+	Achieved        bool      `json:"achieved"`
+}
+
+// CourseJourney is a learner's course progress together with their goals for the current periods
+type CourseJourney struct {
+	*UserProgress
+	Goals []GoalProgress `json:"goals"`
+}
+
+// Certificate is issued once per user when a course is completed
+type Certificate struct {
+	ID               string    `json:"id"`
+	CourseID         string    `json:"course_id"`
+	CourseTitle      string    `json:"course_title"`
+	VerificationCode string    `json:"verification_code"`
+	IssuedAt         time.Time `json:"issued_at"`
+}
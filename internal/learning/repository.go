@@ -1,9 +1,13 @@
 package learning
 
 import (
+	"backend/internal/platform/ai"
+	"backend/internal/platform/database"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,7 +24,7 @@ func NewRepository(db *sql.DB) *Repository {
 }
 
 // GetBlueprintModules retrieves all blueprint templates
-func (r *Repository) GetBlueprintModules() ([]BlueprintModule, error) {
+func (r *Repository) GetBlueprintModules(ctx context.Context) ([]BlueprintModule, error) {
 	query := `
 		SELECT id, module_number, title_template, description_template,
 			   difficulty, estimated_hours, learning_objectives, variable_schema,
@@ -29,7 +33,7 @@ func (r *Repository) GetBlueprintModules() ([]BlueprintModule, error) {
 		ORDER BY module_number ASC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query blueprint modules: %w", err)
 	}
@@ -78,8 +82,35 @@ func (r *Repository) GetBlueprintModules() ([]BlueprintModule, error) {
 	return modules, nil
 }
 
-// CreateGeneratedCourse creates a new course instance
-func (r *Repository) CreateGeneratedCourse(course *GeneratedCourse) error {
+// execer is the subset of *sql.DB and *sql.Tx used by inserts that can run inside a transaction
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// withTransaction runs fn in a transaction that commits only if fn succeeds
+func (r *Repository) withTransaction(ctx context.Context, fn database.TxFunc) error {
+	db := &database.DB{DB: r.db}
+	return db.WithTransaction(ctx, fn)
+}
+
+// CreateCourseWithModules creates a course and its modules in one transaction,
+// so a failed module insert leaves no course behind. Each module's CourseID is
+// set to the new course's ID.
+func (r *Repository) CreateCourseWithModules(ctx context.Context, course *GeneratedCourse, modules []GeneratedModule) error {
+	return r.withTransaction(ctx, func(tx *sql.Tx) error {
+		if err := createGeneratedCourse(ctx, tx, course); err != nil {
+			return err
+		}
+		for i := range modules {
+			modules[i].CourseID = course.ID
+		}
+		return createGeneratedModules(ctx, tx, modules)
+	})
+}
+
+// createGeneratedCourse inserts a new course instance through db, which may be a transaction
+func createGeneratedCourse(ctx context.Context, db execer, course *GeneratedCourse) error {
 	if course.ID == "" {
 		course.ID = uuid.New().String()
 	}
@@ -93,15 +124,15 @@ func (r *Repository) CreateGeneratedCourse(course *GeneratedCourse) error {
 	query := `
 		INSERT INTO generated_courses
 			(id, user_id, archetype_id, title, description, meta_category,
-			 injected_variables, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			 injected_variables, status, generated_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
 	now := time.Now()
 	course.CreatedAt = now
 	course.UpdatedAt = now
 
-	_, err = r.db.Exec(query,
+	_, err = db.ExecContext(ctx, query,
 		course.ID,
 		course.UserID,
 		course.ArchetypeID,
@@ -110,6 +141,7 @@ func (r *Repository) CreateGeneratedCourse(course *GeneratedCourse) error {
 		course.MetaCategory,
 		variablesJSON,
 		course.Status,
+		course.GeneratedBy,
 		course.CreatedAt,
 		course.UpdatedAt,
 	)
@@ -121,19 +153,112 @@ func (r *Repository) CreateGeneratedCourse(course *GeneratedCourse) error {
 	return nil
 }
 
-// GetCourseByID retrieves course by ID
-func (r *Repository) GetCourseByID(courseID string) (*GeneratedCourse, error) {
+// courseColumns is the column list scanned by scanCourse
+const courseColumns = `
+	id, user_id, archetype_id, title, description, meta_category,
+	injected_variables, status, generated_by, created_at, updated_at, deleted_at
+`
+
+// liveCourses returns the condition that leaves out soft-deleted courses, or
+// nothing when they are included
+func liveCourses(includeDeleted bool) string {
+	if includeDeleted {
+		return ""
+	}
+	return " AND deleted_at IS NULL"
+}
+
+// GetCourseByID retrieves course by ID. Soft-deleted courses are not found
+// unless includeDeleted is set.
+func (r *Repository) GetCourseByID(ctx context.Context, courseID string, includeDeleted bool) (*GeneratedCourse, error) {
+	query := `SELECT ` + courseColumns + ` FROM generated_courses WHERE id = $1` + liveCourses(includeDeleted)
+
+	course, err := scanCourse(r.db.QueryRowContext(ctx, query, courseID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrCourseNotFound, courseID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course: %w", err)
+	}
+
+	return course, nil
+}
+
+// SoftDeleteCourse marks a live course deleted. It returns ErrCourseNotFound
+// when there is no live course with the ID.
+func (r *Repository) SoftDeleteCourse(ctx context.Context, courseID string, now time.Time) error {
+	query := `UPDATE generated_courses SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+	return r.setCourseDeleted(ctx, query, now, courseID)
+}
+
+// RestoreCourse clears the deletion mark of a soft-deleted course. It returns
+// ErrCourseNotFound when there is no deleted course with the ID.
+func (r *Repository) RestoreCourse(ctx context.Context, courseID string, now time.Time) error {
+	query := `UPDATE generated_courses SET deleted_at = NULL, updated_at = $1 WHERE id = $2 AND deleted_at IS NOT NULL`
+	return r.setCourseDeleted(ctx, query, now, courseID)
+}
+
+// setCourseDeleted runs a soft delete or restore query and reports whether it matched a course
+func (r *Repository) setCourseDeleted(ctx context.Context, query string, now time.Time, courseID string) error {
+	result, err := r.db.ExecContext(ctx, query, now, courseID)
+	if err != nil {
+		return fmt.Errorf("failed to update course: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update course: %w", err)
+	}
+	if affected == 0 {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+// UpdateCourseStatus changes a course's status
+func (r *Repository) UpdateCourseStatus(ctx context.Context, courseID, status string) error {
+	query := `UPDATE generated_courses SET status = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := r.db.ExecContext(ctx, query, status, time.Now(), courseID)
+	if err != nil {
+		return fmt.Errorf("failed to update course status: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserCourses retrieves all courses for a user, leaving out soft-deleted
+// ones unless includeDeleted is set.
+// Results are ordered newest first with id as a tie-breaker so pages never overlap
+func (r *Repository) GetUserCourses(ctx context.Context, userID string, limit, offset int, includeDeleted bool) ([]GeneratedCourse, error) {
 	query := `
-		SELECT id, user_id, archetype_id, title, description, meta_category,
-			   injected_variables, status, created_at, updated_at
+		SELECT ` + courseColumns + `
 		FROM generated_courses
-		WHERE id = $1
+		WHERE user_id = $1` + liveCourses(includeDeleted) + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
 	`
 
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user courses: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCourses(rows)
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCourse reads one row selected with courseColumns
+func scanCourse(row rowScanner) (*GeneratedCourse, error) {
 	var course GeneratedCourse
 	var variablesJSON []byte
+	var deletedAt sql.NullTime
 
-	err := r.db.QueryRow(query, courseID).Scan(
+	err := row.Scan(
 		&course.ID,
 		&course.UserID,
 		&course.ArchetypeID,
@@ -142,15 +267,16 @@ func (r *Repository) GetCourseByID(courseID string) (*GeneratedCourse, error) {
 		&course.MetaCategory,
 		&variablesJSON,
 		&course.Status,
+		&course.GeneratedBy,
 		&course.CreatedAt,
 		&course.UpdatedAt,
+		&deletedAt,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("course not found: %s", courseID)
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get course: %w", err)
+		return nil, err
+	}
+	if deletedAt.Valid {
+		course.DeletedAt = &deletedAt.Time
 	}
 
 	// Unmarshal JSONB field
@@ -163,71 +289,113 @@ func (r *Repository) GetCourseByID(courseID string) (*GeneratedCourse, error) {
 	return &course, nil
 }
 
-// GetUserCourses retrieves all courses for a user
-func (r *Repository) GetUserCourses(userID string) ([]GeneratedCourse, error) {
-	query := `
-		SELECT id, user_id, archetype_id, title, description, meta_category,
-			   injected_variables, status, created_at, updated_at
-		FROM generated_courses
+// scanCourses reads generated_courses rows selected with courseColumns
+func scanCourses(rows *sql.Rows) ([]GeneratedCourse, error) {
+	courses := []GeneratedCourse{}
+	for rows.Next() {
+		course, err := scanCourse(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan course: %w", err)
+		}
+		courses = append(courses, *course)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating courses: %w", err)
+	}
+
+	return courses, nil
+}
+
+// courseSearchFilter restricts a user's courses to those matching a search.
+// $2 is a contains pattern for title/description, $3 a meta-category and
+// $4 a status; an empty value disables that filter.
+const courseSearchFilter = `
 		WHERE user_id = $1
-		ORDER BY created_at DESC
+		  AND ($2 = '' OR title ILIKE $2 ESCAPE '\' OR description ILIKE $2 ESCAPE '\')
+		  AND ($3 = '' OR meta_category = $3)
+		  AND ($4 = '' OR status = $4)
+`
+
+// SearchCourses finds the user's courses whose title or description contains
+// query, optionally limited to one meta-category and status. Title matches rank
+// above description-only matches, title prefixes highest; ties and searches
+// without a query are ordered newest first.
+func (r *Repository) SearchCourses(ctx context.Context, userID, query, metaCategory, status string, limit, offset int, includeDeleted bool) ([]GeneratedCourse, error) {
+	sqlQuery := `
+		SELECT ` + courseColumns + `
+		FROM generated_courses` + courseSearchFilter + liveCourses(includeDeleted) + `
+		ORDER BY
+			CASE
+				WHEN $2 = '' THEN 0
+				WHEN title ILIKE $5 ESCAPE '\' THEN 0
+				WHEN title ILIKE $2 ESCAPE '\' THEN 1
+				ELSE 2
+			END,
+			created_at DESC, id DESC
+		LIMIT $6 OFFSET $7
 	`
 
-	rows, err := r.db.Query(query, userID)
+	contains, prefix := likePatterns(query)
+	rows, err := r.db.QueryContext(ctx, sqlQuery, userID, contains, metaCategory, status, prefix, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query user courses: %w", err)
+		return nil, fmt.Errorf("failed to search courses: %w", err)
 	}
 	defer rows.Close()
 
-	var courses []GeneratedCourse
-	for rows.Next() {
-		var course GeneratedCourse
-		var variablesJSON []byte
-
-		err := rows.Scan(
-			&course.ID,
-			&course.UserID,
-			&course.ArchetypeID,
-			&course.Title,
-			&course.Description,
-			&course.MetaCategory,
-			&variablesJSON,
-			&course.Status,
-			&course.CreatedAt,
-			&course.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan course: %w", err)
-		}
+	return scanCourses(rows)
+}
 
-		// Unmarshal JSONB field
-		if len(variablesJSON) > 0 {
-			if err := json.Unmarshal(variablesJSON, &course.InjectedVariables); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal injected_variables: %w", err)
-			}
-		}
+// CountSearchCourses returns how many of the user's courses match a search
+func (r *Repository) CountSearchCourses(ctx context.Context, userID, query, metaCategory, status string, includeDeleted bool) (int, error) {
+	sqlQuery := `SELECT COUNT(*) FROM generated_courses` + courseSearchFilter + liveCourses(includeDeleted)
 
-		courses = append(courses, course)
+	contains, _ := likePatterns(query)
+	var count int
+	if err := r.db.QueryRowContext(ctx, sqlQuery, userID, contains, metaCategory, status).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count courses: %w", err)
 	}
+	return count, nil
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating courses: %w", err)
+// likePatterns returns ILIKE patterns matching text anywhere and as a prefix,
+// with LIKE wildcards in text escaped. Empty text yields empty patterns.
+func likePatterns(text string) (contains, prefix string) {
+	if text == "" {
+		return "", ""
 	}
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+	return "%" + escaped + "%", escaped + "%"
+}
 
-	return courses, nil
+// CountUserCourses returns the total number of courses owned by the user,
+// counting soft-deleted ones only when includeDeleted is set
+func (r *Repository) CountUserCourses(ctx context.Context, userID string, includeDeleted bool) (int, error) {
+	query := `SELECT COUNT(*) FROM generated_courses WHERE user_id = $1` + liveCourses(includeDeleted)
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count user courses: %w", err)
+	}
+	return count, nil
 }
 
 // CreateGeneratedModules creates module instances (batch insert)
-func (r *Repository) CreateGeneratedModules(modules []GeneratedModule) error {
+func (r *Repository) CreateGeneratedModules(ctx context.Context, modules []GeneratedModule) error {
 	if len(modules) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+	return r.withTransaction(ctx, func(tx *sql.Tx) error {
+		return createGeneratedModules(ctx, tx, modules)
+	})
+}
+
+// createGeneratedModules inserts modules through db, which may be a transaction
+func createGeneratedModules(ctx context.Context, db execer, modules []GeneratedModule) error {
+	if len(modules) == 0 {
+		return nil
 	}
-	defer tx.Rollback()
 
 	query := `
 		INSERT INTO generated_modules
@@ -236,7 +404,7 @@ func (r *Repository) CreateGeneratedModules(modules []GeneratedModule) error {
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 
-	stmt, err := tx.Prepare(query)
+	stmt, err := db.PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -260,10 +428,13 @@ func (r *Repository) CreateGeneratedModules(modules []GeneratedModule) error {
 		now := time.Now()
 		modules[i].CreatedAt = now
 
-		_, err = stmt.Exec(
+		// Fallback modules have no blueprint row to reference
+		blueprintModuleID := sql.NullString{String: module.BlueprintModuleID, Valid: module.BlueprintModuleID != ""}
+
+		_, err = stmt.ExecContext(ctx,
 			module.ID,
 			module.CourseID,
-			module.BlueprintModuleID,
+			blueprintModuleID,
 			module.ModuleNumber,
 			module.Title,
 			module.Description,
@@ -277,15 +448,100 @@ func (r *Repository) CreateGeneratedModules(modules []GeneratedModule) error {
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	return nil
+}
+
+// GetCourseIDByModule returns the course a module belongs to
+func (r *Repository) GetCourseIDByModule(ctx context.Context, moduleID string) (string, error) {
+	query := `SELECT course_id FROM generated_modules WHERE id = $1`
+
+	var courseID string
+	err := r.db.QueryRowContext(ctx, query, moduleID).Scan(&courseID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("module not found: %s", moduleID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get module course: %w", err)
 	}
 
-	return nil
+	return courseID, nil
+}
+
+// CountCompletedModules returns how many of the course's modules the user has passed
+// at least one exercise in, and how many modules the course has
+func (r *Repository) CountCompletedModules(ctx context.Context, userID, courseID string) (passed, total int, err error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM module_completions mc
+				WHERE mc.module_id = gm.id AND mc.user_id = $1 AND mc.passed = true
+			)),
+			COUNT(*)
+		FROM generated_modules gm
+		WHERE gm.course_id = $2
+	`
+
+	err = r.db.QueryRowContext(ctx, query, userID, courseID).Scan(&passed, &total)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count completed modules: %w", err)
+	}
+
+	return passed, total, nil
+}
+
+// GetModuleProgress returns the user's submission counts for every module of the
+// course, in module order
+func (r *Repository) GetModuleProgress(ctx context.Context, userID, courseID string) ([]ModuleProgress, error) {
+	query := `
+		SELECT
+			gm.id,
+			gm.module_number,
+			gm.title,
+			COUNT(mc.id),
+			COUNT(mc.id) FILTER (WHERE mc.passed = true),
+			MAX(mc.submitted_at)
+		FROM generated_modules gm
+		LEFT JOIN module_completions mc ON mc.module_id = gm.id AND mc.user_id = $1
+		WHERE gm.course_id = $2
+		GROUP BY gm.id, gm.module_number, gm.title
+		ORDER BY gm.module_number ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query module progress: %w", err)
+	}
+	defer rows.Close()
+
+	modules := []ModuleProgress{}
+	for rows.Next() {
+		var module ModuleProgress
+		var lastSubmittedAt sql.NullTime
+		if err := rows.Scan(
+			&module.ModuleID,
+			&module.ModuleNumber,
+			&module.Title,
+			&module.Submissions,
+			&module.PassedSubmissions,
+			&lastSubmittedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan module progress: %w", err)
+		}
+		if lastSubmittedAt.Valid {
+			module.LastSubmittedAt = &lastSubmittedAt.Time
+		}
+		modules = append(modules, module)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating module progress: %w", err)
+	}
+
+	return modules, nil
 }
 
 // GetCourseModules retrieves modules for a course
-func (r *Repository) GetCourseModules(courseID string) ([]GeneratedModule, error) {
+func (r *Repository) GetCourseModules(ctx context.Context, courseID string) ([]GeneratedModule, error) {
 	query := `
 		SELECT id, course_id, blueprint_module_id, module_number, title,
 			   description, content, status, unlocked_at, created_at
@@ -294,7 +550,7 @@ func (r *Repository) GetCourseModules(courseID string) ([]GeneratedModule, error
 		ORDER BY module_number ASC
 	`
 
-	rows, err := r.db.Query(query, courseID)
+	rows, err := r.db.QueryContext(ctx, query, courseID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query course modules: %w", err)
 	}
@@ -305,11 +561,12 @@ func (r *Repository) GetCourseModules(courseID string) ([]GeneratedModule, error
 		var module GeneratedModule
 		var contentJSON []byte
 		var unlockedAt sql.NullTime
+		var blueprintModuleID sql.NullString
 
 		err := rows.Scan(
 			&module.ID,
 			&module.CourseID,
-			&module.BlueprintModuleID,
+			&blueprintModuleID,
 			&module.ModuleNumber,
 			&module.Title,
 			&module.Description,
@@ -332,6 +589,7 @@ func (r *Repository) GetCourseModules(courseID string) ([]GeneratedModule, error
 		if unlockedAt.Valid {
 			module.UnlockedAt = &unlockedAt.Time
 		}
+		module.BlueprintModuleID = blueprintModuleID.String
 
 		modules = append(modules, module)
 	}
@@ -343,8 +601,200 @@ func (r *Repository) GetCourseModules(courseID string) ([]GeneratedModule, error
 	return modules, nil
 }
 
+// GetModuleByID retrieves a single module with its generated content
+func (r *Repository) GetModuleByID(ctx context.Context, moduleID string) (*GeneratedModule, error) {
+	query := `
+		SELECT id, course_id, blueprint_module_id, module_number, title,
+			   description, content, status, unlocked_at, created_at
+		FROM generated_modules
+		WHERE id = $1
+	`
+
+	var module GeneratedModule
+	var contentJSON []byte
+	var unlockedAt sql.NullTime
+	var blueprintModuleID sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, moduleID).Scan(
+		&module.ID,
+		&module.CourseID,
+		&blueprintModuleID,
+		&module.ModuleNumber,
+		&module.Title,
+		&module.Description,
+		&contentJSON,
+		&module.Status,
+		&unlockedAt,
+		&module.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrModuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module: %w", err)
+	}
+
+	if len(contentJSON) > 0 {
+		if err := json.Unmarshal(contentJSON, &module.Content); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal content: %w", err)
+		}
+	}
+	if unlockedAt.Valid {
+		module.UnlockedAt = &unlockedAt.Time
+	}
+	module.BlueprintModuleID = blueprintModuleID.String
+
+	return &module, nil
+}
+
+// GetModuleExercises retrieves a module's exercises in order
+func (r *Repository) GetModuleExercises(ctx context.Context, moduleID string) ([]Exercise, error) {
+	query := `
+		SELECT id, module_id, exercise_number, title, description, language,
+			   starter_code, solution_code, test_cases, difficulty, points, hints, created_at
+		FROM exercises
+		WHERE module_id = $1
+		ORDER BY exercise_number ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, moduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query module exercises: %w", err)
+	}
+	defer rows.Close()
+
+	exercises := []Exercise{}
+	for rows.Next() {
+		var exercise Exercise
+		var testCasesJSON, hintsJSON []byte
+
+		err := rows.Scan(
+			&exercise.ID,
+			&exercise.ModuleID,
+			&exercise.ExerciseNumber,
+			&exercise.Title,
+			&exercise.Description,
+			&exercise.Language,
+			&exercise.StarterCode,
+			&exercise.SolutionCode,
+			&testCasesJSON,
+			&exercise.Difficulty,
+			&exercise.Points,
+			&hintsJSON,
+			&exercise.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan exercise: %w", err)
+		}
+
+		if len(testCasesJSON) > 0 {
+			if err := json.Unmarshal(testCasesJSON, &exercise.TestCases); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal test_cases: %w", err)
+			}
+		}
+		if len(hintsJSON) > 0 {
+			if err := json.Unmarshal(hintsJSON, &exercise.Hints); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal hints: %w", err)
+			}
+		}
+
+		exercises = append(exercises, exercise)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exercises: %w", err)
+	}
+
+	return exercises, nil
+}
+
+// GetExercisesByCourse retrieves every exercise of a course in one query,
+// grouped by module in module order. Modules without exercises are included
+// with an empty list.
+func (r *Repository) GetExercisesByCourse(ctx context.Context, courseID string) ([]ModuleExercises, error) {
+	query := `
+		SELECT gm.id, gm.module_number, gm.title, COALESCE(gm.status, ''),
+			   e.id, COALESCE(e.exercise_number, 0), COALESCE(e.title, ''), COALESCE(e.description, ''),
+			   COALESCE(e.language, ''), COALESCE(e.starter_code, ''), COALESCE(e.solution_code, ''),
+			   e.test_cases, COALESCE(e.difficulty, ''), COALESCE(e.points, 0), e.hints, e.created_at
+		FROM generated_modules gm
+		LEFT JOIN exercises e ON e.module_id = gm.id
+		WHERE gm.course_id = $1
+		ORDER BY gm.module_number ASC, e.exercise_number ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query course exercises: %w", err)
+	}
+	defer rows.Close()
+
+	modules := []ModuleExercises{}
+	for rows.Next() {
+		var module ModuleExercises
+		var exercise Exercise
+		var exerciseID sql.NullString
+		var createdAt sql.NullTime
+		var testCasesJSON, hintsJSON []byte
+
+		err := rows.Scan(
+			&module.ModuleID,
+			&module.ModuleNumber,
+			&module.Title,
+			&module.Status,
+			&exerciseID,
+			&exercise.ExerciseNumber,
+			&exercise.Title,
+			&exercise.Description,
+			&exercise.Language,
+			&exercise.StarterCode,
+			&exercise.SolutionCode,
+			&testCasesJSON,
+			&exercise.Difficulty,
+			&exercise.Points,
+			&hintsJSON,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan course exercise: %w", err)
+		}
+
+		// Rows arrive ordered by module, so a new module ID starts a new group
+		if n := len(modules); n == 0 || modules[n-1].ModuleID != module.ModuleID {
+			module.Exercises = []Exercise{}
+			modules = append(modules, module)
+		}
+		if !exerciseID.Valid {
+			continue
+		}
+
+		exercise.ID = exerciseID.String
+		exercise.ModuleID = module.ModuleID
+		exercise.CreatedAt = createdAt.Time
+		if len(testCasesJSON) > 0 {
+			if err := json.Unmarshal(testCasesJSON, &exercise.TestCases); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal test_cases: %w", err)
+			}
+		}
+		if len(hintsJSON) > 0 {
+			if err := json.Unmarshal(hintsJSON, &exercise.Hints); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal hints: %w", err)
+			}
+		}
+
+		group := &modules[len(modules)-1]
+		group.Exercises = append(group.Exercises, exercise)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating course exercises: %w", err)
+	}
+
+	return modules, nil
+}
+
 // CreateExercise creates a coding challenge
-func (r *Repository) CreateExercise(exercise *Exercise) error {
+func (r *Repository) CreateExercise(ctx context.Context, exercise *Exercise) error {
 	if exercise.ID == "" {
 		exercise.ID = uuid.New().String()
 	}
@@ -370,7 +820,7 @@ func (r *Repository) CreateExercise(exercise *Exercise) error {
 	now := time.Now()
 	exercise.CreatedAt = now
 
-	_, err = r.db.Exec(query,
+	_, err = r.db.ExecContext(ctx, query,
 		exercise.ID,
 		exercise.ModuleID,
 		exercise.ExerciseNumber,
@@ -393,8 +843,55 @@ func (r *Repository) CreateExercise(exercise *Exercise) error {
 	return nil
 }
 
+// UpdateExercise replaces an exercise's authored content; its module, number
+// and creation time are kept
+func (r *Repository) UpdateExercise(ctx context.Context, exercise *Exercise) error {
+	testCasesJSON, err := json.Marshal(exercise.TestCases)
+	if err != nil {
+		return fmt.Errorf("failed to marshal test_cases: %w", err)
+	}
+
+	hintsJSON, err := json.Marshal(exercise.Hints)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hints: %w", err)
+	}
+
+	query := `
+		UPDATE exercises
+		SET title = $1, description = $2, language = $3, starter_code = $4,
+			solution_code = $5, test_cases = $6, difficulty = $7, points = $8, hints = $9
+		WHERE id = $10
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		exercise.Title,
+		exercise.Description,
+		exercise.Language,
+		exercise.StarterCode,
+		exercise.SolutionCode,
+		testCasesJSON,
+		exercise.Difficulty,
+		exercise.Points,
+		hintsJSON,
+		exercise.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update exercise: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update exercise: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("exercise not found: %s", exercise.ID)
+	}
+
+	return nil
+}
+
 // GetExerciseByID retrieves exercise by ID
-func (r *Repository) GetExerciseByID(exerciseID string) (*Exercise, error) {
+func (r *Repository) GetExerciseByID(ctx context.Context, exerciseID string) (*Exercise, error) {
 	query := `
 		SELECT id, module_id, exercise_number, title, description, language,
 			   starter_code, solution_code, test_cases, difficulty, points, hints, created_at
@@ -405,7 +902,7 @@ func (r *Repository) GetExerciseByID(exerciseID string) (*Exercise, error) {
 	var exercise Exercise
 	var testCasesJSON, hintsJSON []byte
 
-	err := r.db.QueryRow(query, exerciseID).Scan(
+	err := r.db.QueryRowContext(ctx, query, exerciseID).Scan(
 		&exercise.ID,
 		&exercise.ModuleID,
 		&exercise.ExerciseNumber,
@@ -443,8 +940,60 @@ func (r *Repository) GetExerciseByID(exerciseID string) (*Exercise, error) {
 	return &exercise, nil
 }
 
+// GetExerciseOwnerID returns the owner of the course an exercise belongs to
+func (r *Repository) GetExerciseOwnerID(ctx context.Context, exerciseID string) (string, error) {
+	query := `
+		SELECT gc.user_id
+		FROM exercises e
+		JOIN generated_modules gm ON gm.id = e.module_id
+		JOIN generated_courses gc ON gc.id = gm.course_id
+		WHERE e.id = $1
+	`
+
+	var ownerID string
+	err := r.db.QueryRowContext(ctx, query, exerciseID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("exercise not found: %s", exerciseID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get exercise owner: %w", err)
+	}
+
+	return ownerID, nil
+}
+
+// GetSubmissionByID retrieves a single exercise submission
+func (r *Repository) GetSubmissionByID(ctx context.Context, submissionID string) (*ModuleCompletion, error) {
+	query := `
+		SELECT id, user_id, module_id, exercise_id, submitted_code, language, passed, score, submitted_at
+		FROM module_completions
+		WHERE id = $1
+	`
+
+	var completion ModuleCompletion
+	err := r.db.QueryRowContext(ctx, query, submissionID).Scan(
+		&completion.ID,
+		&completion.UserID,
+		&completion.ModuleID,
+		&completion.ExerciseID,
+		&completion.SubmittedCode,
+		&completion.Language,
+		&completion.Passed,
+		&completion.Score,
+		&completion.SubmittedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrSubmissionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+
+	return &completion, nil
+}
+
 // SubmitExercise saves exercise submission
-func (r *Repository) SubmitExercise(completion *ModuleCompletion) error {
+func (r *Repository) SubmitExercise(ctx context.Context, completion *ModuleCompletion) error {
 	if completion.ID == "" {
 		completion.ID = uuid.New().String()
 	}
@@ -455,17 +1004,20 @@ func (r *Repository) SubmitExercise(completion *ModuleCompletion) error {
 		return fmt.Errorf("failed to marshal test_results: %w", err)
 	}
 
+	// A repeated idempotency key inserts nothing; the caller replays the stored row
 	query := `
 		INSERT INTO module_completions
 			(id, user_id, module_id, exercise_id, submitted_code, language,
-			 test_results, passed, score, attempts, hints_used, time_spent_minutes, submitted_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			 test_results, passed, score, attempts, hints_used, time_spent_minutes,
+			 time_spent_seconds, submitted_at, idempotency_key, points_earned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (user_id, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
 	`
 
 	now := time.Now()
 	completion.SubmittedAt = now
 
-	_, err = r.db.Exec(query,
+	result, err := r.db.ExecContext(ctx, query,
 		completion.ID,
 		completion.UserID,
 		completion.ModuleID,
@@ -478,21 +1030,154 @@ func (r *Repository) SubmitExercise(completion *ModuleCompletion) error {
 		completion.Attempts,
 		completion.HintsUsed,
 		completion.TimeSpentMinutes,
+		completion.TimeSpentSeconds,
 		completion.SubmittedAt,
+		sql.NullString{String: completion.IdempotencyKey, Valid: completion.IdempotencyKey != ""},
+		completion.PointsEarned,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to submit exercise: %w", err)
 	}
 
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to submit exercise: %w", err)
+	}
+	if rows == 0 {
+		return ErrDuplicateSubmission
+	}
+
+	return nil
+}
+
+// GetSubmissionByIdempotencyKey retrieves the submission a user made with the given idempotency key
+func (r *Repository) GetSubmissionByIdempotencyKey(ctx context.Context, userID, key string) (*ModuleCompletion, error) {
+	query := `
+		SELECT id, user_id, module_id, exercise_id, submitted_code, language,
+		       test_results, passed, score, attempts, hints_used, time_spent_minutes,
+		       time_spent_seconds, submitted_at, points_earned
+		FROM module_completions
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	var completion ModuleCompletion
+	var testResultsJSON []byte
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(
+		&completion.ID,
+		&completion.UserID,
+		&completion.ModuleID,
+		&completion.ExerciseID,
+		&completion.SubmittedCode,
+		&completion.Language,
+		&testResultsJSON,
+		&completion.Passed,
+		&completion.Score,
+		&completion.Attempts,
+		&completion.HintsUsed,
+		&completion.TimeSpentMinutes,
+		&completion.TimeSpentSeconds,
+		&completion.SubmittedAt,
+		&completion.PointsEarned,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrSubmissionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+
+	// Unmarshal JSONB field
+	if len(testResultsJSON) > 0 {
+		var testResults []TestResult
+		if err := json.Unmarshal(testResultsJSON, &testResults); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal test_results: %w", err)
+		}
+		completion.TestResults = testResults
+	}
+	completion.IdempotencyKey = key
+
+	return &completion, nil
+}
+
+// CountSubmissions returns how many times the user has submitted the exercise
+func (r *Repository) CountSubmissions(ctx context.Context, userID, exerciseID string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM module_completions
+		WHERE user_id = $1 AND exercise_id = $2
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, exerciseID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count submissions: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetUserPoints returns the user's total exercise points. Only the best
+// submission of each exercise counts, so resubmitting cannot farm points.
+func (r *Repository) GetUserPoints(ctx context.Context, userID string) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(best), 0)
+		FROM (
+			SELECT MAX(points_earned) AS best
+			FROM module_completions
+			WHERE user_id = $1
+			GROUP BY exercise_id
+		) AS per_exercise
+	`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to get user points: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetHintsUsed returns how many of the exercise's hints the user has revealed
+func (r *Repository) GetHintsUsed(ctx context.Context, userID, exerciseID string) (int, error) {
+	query := `SELECT hints_revealed FROM hint_reveals WHERE user_id = $1 AND exercise_id = $2`
+
+	var hintsUsed int
+	err := r.db.QueryRowContext(ctx, query, userID, exerciseID).Scan(&hintsUsed)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get hints used: %w", err)
+	}
+
+	return hintsUsed, nil
+}
+
+// RecordHintsUsed raises the user's revealed hint count for the exercise to
+// hintsUsed; a lower count never replaces a higher one
+func (r *Repository) RecordHintsUsed(ctx context.Context, userID, exerciseID string, hintsUsed int) error {
+	query := `
+		INSERT INTO hint_reveals (user_id, exercise_id, hints_revealed, revealed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, exercise_id) DO UPDATE
+		SET hints_revealed = GREATEST(hint_reveals.hints_revealed, EXCLUDED.hints_revealed),
+		    revealed_at = EXCLUDED.revealed_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, exerciseID, hintsUsed, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record hints used: %w", err)
+	}
+
 	return nil
 }
 
 // GetUserProgress retrieves user's course progress
-func (r *Repository) GetUserProgress(userID, courseID string) (*UserProgress, error) {
+func (r *Repository) GetUserProgress(ctx context.Context, userID, courseID string) (*UserProgress, error) {
 	query := `
 		SELECT id, user_id, course_id, current_module_id, progress_percentage,
-			   time_spent_minutes, last_activity, started_at, completed_at
+			   time_spent_minutes, time_spent_seconds, last_activity, started_at, completed_at,
+			   updated_at
 		FROM user_progress
 		WHERE user_id = $1 AND course_id = $2
 	`
@@ -501,20 +1186,22 @@ func (r *Repository) GetUserProgress(userID, courseID string) (*UserProgress, er
 	var currentModuleID sql.NullString
 	var completedAt sql.NullTime
 
-	err := r.db.QueryRow(query, userID, courseID).Scan(
+	err := r.db.QueryRowContext(ctx, query, userID, courseID).Scan(
 		&progress.ID,
 		&progress.UserID,
 		&progress.CourseID,
 		&currentModuleID,
 		&progress.ProgressPercentage,
 		&progress.TimeSpentMinutes,
+		&progress.TimeSpentSeconds,
 		&progress.LastActivity,
 		&progress.StartedAt,
 		&completedAt,
+		&progress.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("progress not found for user %s and course %s", userID, courseID)
+		return nil, ErrProgressNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user progress: %w", err)
@@ -530,77 +1217,102 @@ func (r *Repository) GetUserProgress(userID, courseID string) (*UserProgress, er
 	return &progress, nil
 }
 
-// UpdateUserProgress updates course progress (or creates if not exists)
-func (r *Repository) UpdateUserProgress(progress *UserProgress) error {
-	// Try to update first
+// UpdateUserProgress saves progress read by GetUserProgress, or creates it when
+// progress.UpdatedAt is zero. The write only applies if the stored progress is
+// unchanged since it was read (or still absent); otherwise nothing is written
+// and ErrProgressConflict is returned.
+func (r *Repository) UpdateUserProgress(ctx context.Context, progress *UserProgress) error {
+	// Postgres keeps microseconds, so the stored version compares equal to this one
+	now := time.Now().Truncate(time.Microsecond)
+
+	if progress.UpdatedAt.IsZero() {
+		if progress.ID == "" {
+			progress.ID = uuid.New().String()
+		}
+
+		insertQuery := `
+			INSERT INTO user_progress
+				(id, user_id, course_id, current_module_id, progress_percentage,
+				 time_spent_minutes, time_spent_seconds, last_activity, started_at, completed_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (user_id, course_id) DO NOTHING
+		`
+
+		result, err := r.db.ExecContext(ctx, insertQuery,
+			progress.ID,
+			progress.UserID,
+			progress.CourseID,
+			progress.CurrentModuleID,
+			progress.ProgressPercentage,
+			progress.TimeSpentMinutes,
+			progress.TimeSpentSeconds,
+			now,
+			now,
+			progress.CompletedAt,
+			now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert user progress: %w", err)
+		}
+		if err := progressWritten(result); err != nil {
+			return err
+		}
+
+		progress.StartedAt = now
+		progress.LastActivity = now
+		progress.UpdatedAt = now
+		return nil
+	}
+
 	updateQuery := `
 		UPDATE user_progress
 		SET current_module_id = $1,
 			progress_percentage = $2,
 			time_spent_minutes = $3,
-			last_activity = $4,
-			completed_at = $5
-		WHERE user_id = $6 AND course_id = $7
+			time_spent_seconds = $4,
+			last_activity = $5,
+			completed_at = $6,
+			updated_at = $5
+		WHERE user_id = $7 AND course_id = $8 AND updated_at = $9
 	`
 
-	result, err := r.db.Exec(updateQuery,
+	result, err := r.db.ExecContext(ctx, updateQuery,
 		progress.CurrentModuleID,
 		progress.ProgressPercentage,
 		progress.TimeSpentMinutes,
-		time.Now(),
+		progress.TimeSpentSeconds,
+		now,
 		progress.CompletedAt,
 		progress.UserID,
 		progress.CourseID,
+		progress.UpdatedAt,
 	)
-
 	if err != nil {
 		return fmt.Errorf("failed to update user progress: %w", err)
 	}
+	if err := progressWritten(result); err != nil {
+		return err
+	}
+
+	progress.LastActivity = now
+	progress.UpdatedAt = now
+	return nil
+}
 
+// progressWritten maps a progress write that matched no row to ErrProgressConflict
+func progressWritten(result sql.Result) error {
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-
-	// If no rows updated, insert new record
 	if rowsAffected == 0 {
-		if progress.ID == "" {
-			progress.ID = uuid.New().String()
-		}
-
-		insertQuery := `
-			INSERT INTO user_progress
-				(id, user_id, course_id, current_module_id, progress_percentage,
-				 time_spent_minutes, last_activity, started_at, completed_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		`
-
-		now := time.Now()
-		progress.StartedAt = now
-		progress.LastActivity = now
-
-		_, err = r.db.Exec(insertQuery,
-			progress.ID,
-			progress.UserID,
-			progress.CourseID,
-			progress.CurrentModuleID,
-			progress.ProgressPercentage,
-			progress.TimeSpentMinutes,
-			progress.LastActivity,
-			progress.StartedAt,
-			progress.CompletedAt,
-		)
-
-		if err != nil {
-			return fmt.Errorf("failed to insert user progress: %w", err)
-		}
+		return ErrProgressConflict
 	}
-
 	return nil
 }
 
 // CreateArchitectureReview saves AI review
-func (r *Repository) CreateArchitectureReview(review *ArchitectureReview) error {
+func (r *Repository) CreateArchitectureReview(ctx context.Context, review *ArchitectureReview) error {
 	if review.ID == "" {
 		review.ID = uuid.New().String()
 	}
@@ -611,18 +1323,31 @@ func (r *Repository) CreateArchitectureReview(review *ArchitectureReview) error
 		return fmt.Errorf("failed to marshal feedback: %w", err)
 	}
 
+	// Marshal the structured sections; a nil list is stored as an empty array
+	sections := make([][]byte, 0, 3)
+	for _, section := range []interface{}{review.Strengths, review.Issues, review.Suggestions} {
+		sectionJSON, err := json.Marshal(section)
+		if err != nil {
+			return fmt.Errorf("failed to marshal review sections: %w", err)
+		}
+		if string(sectionJSON) == "null" {
+			sectionJSON = []byte("[]")
+		}
+		sections = append(sections, sectionJSON)
+	}
+
 	query := `
 		INSERT INTO architecture_reviews
 			(id, user_id, module_id, submission_id, overall_score,
 			 code_sense_score, efficiency_score, edge_cases_score, taste_score,
-			 feedback, reviewed_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			 feedback, summary, strengths, issues, suggestions, reviewed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
 	now := time.Now()
 	review.ReviewedAt = now
 
-	_, err = r.db.Exec(query,
+	_, err = r.db.ExecContext(ctx, query,
 		review.ID,
 		review.UserID,
 		review.ModuleID,
@@ -633,6 +1358,10 @@ func (r *Repository) CreateArchitectureReview(review *ArchitectureReview) error
 		review.EdgeCasesScore,
 		review.TasteScore,
 		feedbackJSON,
+		review.Summary,
+		sections[0],
+		sections[1],
+		sections[2],
 		review.ReviewedAt,
 	)
 
@@ -642,3 +1371,253 @@ func (r *Repository) CreateArchitectureReview(review *ArchitectureReview) error
 
 	return nil
 }
+
+// GetUserLocale retrieves the user's preferred language for AI-generated content
+func (r *Repository) GetUserLocale(ctx context.Context, userID string) (string, error) {
+	query := `SELECT locale FROM users WHERE id = $1`
+
+	var locale string
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&locale)
+	if err == sql.ErrNoRows || (err == nil && locale == "") {
+		return ai.DefaultLocale, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user locale: %w", err)
+	}
+
+	return locale, nil
+}
+
+// GetSkillTrend returns per-bucket average review scores for a user since the given time
+// bucket must be a Postgres date_trunc unit (day, week, month)
+func (r *Repository) GetSkillTrend(ctx context.Context, userID, bucket string, since time.Time) ([]SkillTrendPoint, error) {
+	query := `
+		SELECT
+			date_trunc($2, reviewed_at) AS bucket_start,
+			COUNT(*) AS review_count,
+			ROUND(AVG(code_sense_score)::numeric, 2),
+			ROUND(AVG(efficiency_score)::numeric, 2),
+			ROUND(AVG(edge_cases_score)::numeric, 2),
+			ROUND(AVG(taste_score)::numeric, 2),
+			ROUND(AVG(overall_score)::numeric, 2)
+		FROM architecture_reviews
+		WHERE user_id = $1 AND reviewed_at >= $3
+		GROUP BY bucket_start
+		ORDER BY bucket_start ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, bucket, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query skill trend: %w", err)
+	}
+	defer rows.Close()
+
+	points := []SkillTrendPoint{}
+	for rows.Next() {
+		var point SkillTrendPoint
+		err := rows.Scan(
+			&point.BucketStart,
+			&point.ReviewCount,
+			&point.CodeSense,
+			&point.Efficiency,
+			&point.EdgeCases,
+			&point.Taste,
+			&point.Overall,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan skill trend: %w", err)
+		}
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating skill trend: %w", err)
+	}
+
+	return points, nil
+}
+
+// GetPendingReviewSubmissions returns the user's passing submissions with no architecture review
+func (r *Repository) GetPendingReviewSubmissions(ctx context.Context, userID string, limit int) ([]PendingReviewSubmission, error) {
+	query := `
+		SELECT
+			mc.id,
+			mc.module_id,
+			mc.exercise_id,
+			COALESCE(e.title, ''),
+			COALESCE(mc.language, ''),
+			COALESCE(mc.score, 0),
+			mc.submitted_at
+		FROM module_completions mc
+		LEFT JOIN architecture_reviews ar ON ar.submission_id = mc.id
+		LEFT JOIN exercises e ON e.id = mc.exercise_id
+		WHERE mc.user_id = $1
+			AND mc.passed = true
+			AND ar.id IS NULL
+		ORDER BY mc.submitted_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending review submissions: %w", err)
+	}
+	defer rows.Close()
+
+	submissions := []PendingReviewSubmission{}
+	for rows.Next() {
+		var submission PendingReviewSubmission
+		var moduleID, exerciseID sql.NullString
+
+		err := rows.Scan(
+			&submission.SubmissionID,
+			&moduleID,
+			&exerciseID,
+			&submission.ExerciseTitle,
+			&submission.Language,
+			&submission.Score,
+			&submission.SubmittedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pending review submission: %w", err)
+		}
+		submission.ModuleID = moduleID.String
+		submission.ExerciseID = exerciseID.String
+
+		submissions = append(submissions, submission)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pending review submissions: %w", err)
+	}
+
+	return submissions, nil
+}
+
+// UpsertLearningGoal creates or replaces the user's goal for goal.Period
+func (r *Repository) UpsertLearningGoal(ctx context.Context, userID string, goal *LearningGoal) error {
+	query := `
+		INSERT INTO learning_goals (user_id, period, target_exercises, target_minutes)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, period)
+		DO UPDATE SET
+			target_exercises = EXCLUDED.target_exercises,
+			target_minutes = EXCLUDED.target_minutes,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, userID, goal.Period, goal.TargetExercises, goal.TargetMinutes).Scan(&goal.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert learning goal: %w", err)
+	}
+
+	return nil
+}
+
+// GetLearningGoals returns the user's goals ordered daily before weekly
+func (r *Repository) GetLearningGoals(ctx context.Context, userID string) ([]LearningGoal, error) {
+	query := `
+		SELECT period, target_exercises, target_minutes, updated_at
+		FROM learning_goals
+		WHERE user_id = $1
+		ORDER BY period ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query learning goals: %w", err)
+	}
+	defer rows.Close()
+
+	goals := []LearningGoal{}
+	for rows.Next() {
+		var goal LearningGoal
+		if err := rows.Scan(&goal.Period, &goal.TargetExercises, &goal.TargetMinutes, &goal.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan learning goal: %w", err)
+		}
+		goals = append(goals, goal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating learning goals: %w", err)
+	}
+
+	return goals, nil
+}
+
+// GetActivitySince returns the distinct exercises passed and total seconds spent
+// on submissions since the given time. Submissions without an exercise count individually.
+func (r *Repository) GetActivitySince(ctx context.Context, userID string, since time.Time) (exercisesSolved, secondsSpent int, err error) {
+	query := `
+		SELECT
+			COUNT(DISTINCT COALESCE(exercise_id, id)) FILTER (WHERE passed = true),
+			COALESCE(SUM(time_spent_seconds), 0)
+		FROM module_completions
+		WHERE user_id = $1 AND submitted_at >= $2
+	`
+
+	err = r.db.QueryRowContext(ctx, query, userID, since).Scan(&exercisesSolved, &secondsSpent)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get activity: %w", err)
+	}
+
+	return exercisesSolved, secondsSpent, nil
+}
+
+// IssueCertificate records a certificate for the user and course.
+// It returns false without error if the user already holds one for the course.
+func (r *Repository) IssueCertificate(ctx context.Context, userID string, cert *Certificate) (bool, error) {
+	if cert.ID == "" {
+		cert.ID = uuid.New().String()
+	}
+
+	query := `
+		INSERT INTO certificates (id, user_id, course_id, verification_code, issued_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id, course_id) DO NOTHING
+		RETURNING issued_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, cert.ID, userID, cert.CourseID, cert.VerificationCode).Scan(&cert.IssuedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to issue certificate: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetUserCertificates returns the user's certificates, most recently issued first
+func (r *Repository) GetUserCertificates(ctx context.Context, userID string) ([]Certificate, error) {
+	query := `
+		SELECT c.id, c.course_id, COALESCE(gc.title, ''), c.verification_code, c.issued_at
+		FROM certificates c
+		LEFT JOIN generated_courses gc ON gc.id = c.course_id
+		WHERE c.user_id = $1
+		ORDER BY c.issued_at DESC, c.id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query certificates: %w", err)
+	}
+	defer rows.Close()
+
+	certificates := []Certificate{}
+	for rows.Next() {
+		var cert Certificate
+		if err := rows.Scan(&cert.ID, &cert.CourseID, &cert.CourseTitle, &cert.VerificationCode, &cert.IssuedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan certificate: %w", err)
+		}
+		certificates = append(certificates, cert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating certificates: %w", err)
+	}
+
+	return certificates, nil
+}
@@ -0,0 +1,430 @@
+package learning
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/tests/testutil"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSkillTrend_PerBucketAverages(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Trend User")
+
+	// Two reviews in one week, one in the following week
+	week1 := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC) // Monday
+	week2 := week1.AddDate(0, 0, 7)
+	seed := []struct {
+		at                                  time.Time
+		codeSense, efficiency, edges, taste int
+	}{
+		{week1, 6, 4, 5, 7},
+		{week1.Add(48 * time.Hour), 8, 6, 7, 9},
+		{week2, 9, 9, 8, 10},
+	}
+	for _, r := range seed {
+		_, err := db.Exec(`
+			INSERT INTO architecture_reviews
+				(id, user_id, overall_score, code_sense_score, efficiency_score, edge_cases_score, taste_score, feedback, reviewed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, '{}', $8)`,
+			uuid.New().String(), userID, (r.codeSense+r.efficiency+r.edges+r.taste)/4,
+			r.codeSense, r.efficiency, r.edges, r.taste, r.at,
+		)
+		require.NoError(t, err)
+	}
+
+	points, err := repo.GetSkillTrend(context.Background(), userID, "week", week1.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+
+	assert.Equal(t, 2, points[0].ReviewCount)
+	assert.Equal(t, 7.0, points[0].CodeSense)
+	assert.Equal(t, 5.0, points[0].Efficiency)
+	assert.Equal(t, 6.0, points[0].EdgeCases)
+	assert.Equal(t, 8.0, points[0].Taste)
+
+	assert.Equal(t, 1, points[1].ReviewCount)
+	assert.Equal(t, 9.0, points[1].CodeSense)
+	assert.Equal(t, 10.0, points[1].Taste)
+	assert.True(t, points[0].BucketStart.Before(points[1].BucketStart))
+}
+
+func TestGetPendingReviewSubmissions_ExcludesReviewed(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Pending User")
+
+	insertSubmission := func(passed bool, submittedAt time.Time) string {
+		id := uuid.New().String()
+		_, err := db.Exec(`
+			INSERT INTO module_completions (id, user_id, submitted_code, language, passed, score, submitted_at)
+			VALUES ($1, $2, 'code', 'go', $3, 100, $4)`,
+			id, userID, passed, submittedAt,
+		)
+		require.NoError(t, err)
+		return id
+	}
+
+	now := time.Now()
+	reviewed := insertSubmission(true, now.Add(-3*time.Hour))
+	pendingOld := insertSubmission(true, now.Add(-2*time.Hour))
+	pendingNew := insertSubmission(true, now.Add(-1*time.Hour))
+	insertSubmission(false, now) // failing submissions are never prompted
+
+	_, err := db.Exec(`
+		INSERT INTO architecture_reviews
+			(id, user_id, submission_id, overall_score, code_sense_score, efficiency_score, edge_cases_score, taste_score, feedback)
+		VALUES ($1, $2, $3, 8, 8, 8, 8, 8, '{}')`,
+		uuid.New().String(), userID, reviewed,
+	)
+	require.NoError(t, err)
+
+	submissions, err := repo.GetPendingReviewSubmissions(context.Background(), userID, 10)
+	require.NoError(t, err)
+	require.Len(t, submissions, 2)
+
+	assert.Equal(t, pendingNew, submissions[0].SubmissionID)
+	assert.Equal(t, pendingOld, submissions[1].SubmissionID)
+	for _, s := range submissions {
+		assert.NotEqual(t, reviewed, s.SubmissionID)
+	}
+}
+
+func TestGetUserCourses_StablePagination(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Paging User")
+	archetypeID := uuid.New().String()
+	_, err := db.Exec(
+		`INSERT INTO user_archetypes (id, user_id, meta_category, domain, skill_level) VALUES ($1, $2, 'Digital', 'e-commerce', 'beginner')`,
+		archetypeID, userID,
+	)
+	require.NoError(t, err)
+
+	// Several courses share a timestamp so only the id tie-breaker orders them
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 7; i++ {
+		_, err := db.Exec(`
+			INSERT INTO generated_courses (id, user_id, archetype_id, title, meta_category, injected_variables, created_at)
+			VALUES ($1, $2, $3, $4, 'Digital', '{}', $5)`,
+			uuid.New().String(), userID, archetypeID, "Course", createdAt.Add(time.Duration(i/3)*time.Hour),
+		)
+		require.NoError(t, err)
+	}
+
+	total, err := repo.CountUserCourses(context.Background(), userID, false)
+	require.NoError(t, err)
+	require.Equal(t, 7, total)
+
+	all, err := repo.GetUserCourses(context.Background(), userID, 10, 0, false)
+	require.NoError(t, err)
+	require.Len(t, all, 7)
+
+	// Paging through in threes yields exactly the same sequence, twice
+	for run := 0; run < 2; run++ {
+		var paged []GeneratedCourse
+		for offset := 0; offset < total; offset += 3 {
+			page, err := repo.GetUserCourses(context.Background(), userID, 3, offset, false)
+			require.NoError(t, err)
+			paged = append(paged, page...)
+		}
+		require.Len(t, paged, len(all))
+		for i := range all {
+			assert.Equal(t, all[i].ID, paged[i].ID, "position %d", i)
+		}
+	}
+
+	empty, err := repo.GetUserCourses(context.Background(), userID, 3, 30, false)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestLearningGoals_PersistAndReflectActivity(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+	service := NewService(repo, nil)
+
+	userID := testutil.CreateUser(t, db, "Goal User")
+
+	// Three passing submissions and one failure this week, one passing submission last week
+	now := time.Now().UTC()
+	weekStart := goalPeriodStart(GoalPeriodWeekly, now)
+	seed := []struct {
+		passed  bool
+		at      time.Time
+		seconds int
+	}{
+		{true, now, 600},
+		{true, now, 300},
+		{true, weekStart, 300},
+		{false, now, 600},
+		{true, weekStart.Add(-time.Hour), 3600},
+	}
+	for _, s := range seed {
+		_, err := db.Exec(`
+			INSERT INTO module_completions (id, user_id, submitted_code, language, passed, score, time_spent_seconds, submitted_at)
+			VALUES ($1, $2, 'code', 'go', $3, 100, $4, $5)`,
+			uuid.New().String(), userID, s.passed, s.seconds, s.at,
+		)
+		require.NoError(t, err)
+	}
+
+	_, err := service.SetLearningGoal(context.Background(), userID, LearningGoal{Period: GoalPeriodWeekly, TargetExercises: 5, TargetMinutes: 20})
+	require.NoError(t, err)
+
+	// Updating replaces the existing weekly goal rather than adding a second one
+	_, err = service.SetLearningGoal(context.Background(), userID, LearningGoal{Period: GoalPeriodWeekly, TargetExercises: 6, TargetMinutes: 30})
+	require.NoError(t, err)
+
+	goals, err := service.GetLearningGoals(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, goals, 1)
+
+	goal := goals[0]
+	assert.Equal(t, GoalPeriodWeekly, goal.Period)
+	assert.Equal(t, 6, goal.TargetExercises)
+	assert.Equal(t, 30, goal.TargetMinutes)
+	assert.Equal(t, weekStart, goal.PeriodStart)
+	assert.Equal(t, 3, goal.ExercisesSolved)
+	assert.Equal(t, 30, goal.MinutesSpent)
+	assert.Equal(t, 50, goal.PercentComplete)
+	assert.False(t, goal.Achieved)
+}
+
+func TestCertificates_OnePerCompletedCourse(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Certified User")
+	archetypeID := uuid.New().String()
+	_, err := db.Exec(
+		`INSERT INTO user_archetypes (id, user_id, meta_category, domain, skill_level) VALUES ($1, $2, 'Digital', 'e-commerce', 'beginner')`,
+		archetypeID, userID,
+	)
+	require.NoError(t, err)
+
+	courseIDs := []string{uuid.New().String(), uuid.New().String()}
+	for i, courseID := range courseIDs {
+		_, err := db.Exec(`
+			INSERT INTO generated_courses (id, user_id, archetype_id, title, meta_category, injected_variables)
+			VALUES ($1, $2, $3, $4, 'Digital', '{}')`,
+			courseID, userID, archetypeID, []string{"First", "Second"}[i],
+		)
+		require.NoError(t, err)
+	}
+
+	issued, err := repo.IssueCertificate(context.Background(), userID, &Certificate{CourseID: courseIDs[0], VerificationCode: "AAAA-BBBB-CCCC-0001"})
+	require.NoError(t, err)
+	assert.True(t, issued)
+
+	// Completing the same course again keeps the original certificate
+	issued, err = repo.IssueCertificate(context.Background(), userID, &Certificate{CourseID: courseIDs[0], VerificationCode: "AAAA-BBBB-CCCC-0002"})
+	require.NoError(t, err)
+	assert.False(t, issued)
+
+	issued, err = repo.IssueCertificate(context.Background(), userID, &Certificate{CourseID: courseIDs[1], VerificationCode: "AAAA-BBBB-CCCC-0003"})
+	require.NoError(t, err)
+	assert.True(t, issued)
+
+	certificates, err := repo.GetUserCertificates(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, certificates, 2)
+
+	codes := map[string]string{}
+	for _, cert := range certificates {
+		codes[cert.CourseID] = cert.VerificationCode
+		assert.False(t, cert.IssuedAt.IsZero())
+	}
+	assert.Equal(t, "AAAA-BBBB-CCCC-0001", codes[courseIDs[0]])
+	assert.Equal(t, "AAAA-BBBB-CCCC-0003", codes[courseIDs[1]])
+}
+
+func TestSearchCourses_FiltersAndRanksByRelevance(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Search User")
+	archetypeID := uuid.New().String()
+	_, err := db.Exec(
+		`INSERT INTO user_archetypes (id, user_id, meta_category, domain, skill_level) VALUES ($1, $2, 'Digital', 'trading', 'beginner')`,
+		archetypeID, userID,
+	)
+	require.NoError(t, err)
+
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	courses := []struct {
+		title, description, category, status string
+	}{
+		{"Intro to pricing", "Covers trading fees", "Economic", "active"}, // Description match, newest
+		{"Advanced trading bots", "", "Digital", "active"},                // Title match
+		{"Trading fundamentals", "", "Economic", "completed"},             // Title prefix match
+		{"Gardening", "Nothing relevant", "Biological", "active"},         // No match
+	}
+	ids := make([]string, len(courses))
+	for i, c := range courses {
+		ids[i] = uuid.New().String()
+		_, err := db.Exec(`
+			INSERT INTO generated_courses (id, user_id, archetype_id, title, description, meta_category, status, injected_variables, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, '{}', $8)`,
+			ids[i], userID, archetypeID, c.title, c.description, c.category, c.status, createdAt.Add(-time.Duration(i)*time.Hour),
+		)
+		require.NoError(t, err)
+	}
+
+	found, err := repo.SearchCourses(context.Background(), userID, "TRADING", "", "", 10, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 3)
+	assert.Equal(t, []string{ids[2], ids[1], ids[0]}, []string{found[0].ID, found[1].ID, found[2].ID})
+
+	total, err := repo.CountSearchCourses(context.Background(), userID, "trading", "Economic", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	found, err = repo.SearchCourses(context.Background(), userID, "", "", "completed", 10, 0, false)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, ids[2], found[0].ID)
+
+	// LIKE wildcards in the query are matched literally
+	found, err = repo.SearchCourses(context.Background(), userID, "%", "", "", 10, 0, false)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestSoftDeletedCourses_ExcludedByDefault(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	userID := testutil.CreateUser(t, db, "Deleting User")
+	archetypeID := uuid.New().String()
+	_, err := db.Exec(
+		`INSERT INTO user_archetypes (id, user_id, meta_category, domain, skill_level) VALUES ($1, $2, 'Digital', 'e-commerce', 'beginner')`,
+		archetypeID, userID,
+	)
+	require.NoError(t, err)
+
+	live, deleted := uuid.New().String(), uuid.New().String()
+	for _, id := range []string{live, deleted} {
+		_, err := db.Exec(`
+			INSERT INTO generated_courses (id, user_id, archetype_id, title, meta_category, injected_variables)
+			VALUES ($1, $2, $3, 'Trading basics', 'Economic', '{}')`,
+			id, userID, archetypeID,
+		)
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.SoftDeleteCourse(ctx, deleted, time.Now()))
+	assert.ErrorIs(t, repo.SoftDeleteCourse(ctx, deleted, time.Now()), ErrCourseNotFound, "already deleted")
+
+	courses, err := repo.GetUserCourses(ctx, userID, 10, 0, false)
+	require.NoError(t, err)
+	require.Len(t, courses, 1)
+	assert.Equal(t, live, courses[0].ID)
+	count, err := repo.CountUserCourses(ctx, userID, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	found, err := repo.SearchCourses(ctx, userID, "trading", "", "", 10, 0, false)
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+
+	_, err = repo.GetCourseByID(ctx, deleted, false)
+	assert.ErrorIs(t, err, ErrCourseNotFound)
+	course, err := repo.GetCourseByID(ctx, deleted, true)
+	require.NoError(t, err)
+	assert.NotNil(t, course.DeletedAt)
+
+	courses, err = repo.GetUserCourses(ctx, userID, 10, 0, true)
+	require.NoError(t, err)
+	assert.Len(t, courses, 2)
+
+	// Restoring brings the course back, once
+	require.NoError(t, repo.RestoreCourse(ctx, deleted, time.Now()))
+	assert.ErrorIs(t, repo.RestoreCourse(ctx, deleted, time.Now()), ErrCourseNotFound)
+	course, err = repo.GetCourseByID(ctx, deleted, false)
+	require.NoError(t, err)
+	assert.Nil(t, course.DeletedAt)
+}
+
+func TestCreateCourseWithModules_RollsBackCourseOnModuleFailure(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Tx User")
+	archetypeID := uuid.New().String()
+	_, err := db.Exec(
+		`INSERT INTO user_archetypes (id, user_id, meta_category, domain, skill_level) VALUES ($1, $2, 'Digital', 'trading', 'beginner')`,
+		archetypeID, userID,
+	)
+	require.NoError(t, err)
+
+	course := &GeneratedCourse{
+		UserID:            userID,
+		ArchetypeID:       archetypeID,
+		Title:             "Orphan candidate",
+		MetaCategory:      "Digital",
+		InjectedVariables: map[string]string{},
+		Status:            CourseStatusActive,
+	}
+	// Two modules sharing an ID make the second insert fail
+	moduleID := uuid.New().String()
+	modules := []GeneratedModule{
+		{ID: moduleID, ModuleNumber: 1, Title: "First", Status: "active"},
+		{ID: moduleID, ModuleNumber: 2, Title: "Second", Status: "locked"},
+	}
+
+	err = repo.CreateCourseWithModules(context.Background(), course, modules)
+	require.Error(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM generated_courses WHERE user_id = $1`, userID).Scan(&count))
+	assert.Zero(t, count)
+}
+
+func TestGetExercisesByCourse_GroupsByModule(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Exercise User")
+	courseID := uuid.New().String()
+	_, err := db.Exec(
+		`INSERT INTO generated_courses (id, user_id, title, meta_category, injected_variables) VALUES ($1, $2, 'Course', 'Digital', '{}')`,
+		courseID, userID,
+	)
+	require.NoError(t, err)
+
+	// Modules and exercises are inserted out of order
+	moduleIDs := []string{uuid.New().String(), uuid.New().String()}
+	for i, number := range []int{2, 1} {
+		_, err := db.Exec(
+			`INSERT INTO generated_modules (id, course_id, module_number, title) VALUES ($1, $2, $3, 'Module')`,
+			moduleIDs[i], courseID, number,
+		)
+		require.NoError(t, err)
+	}
+	for _, number := range []int{2, 1} {
+		_, err := db.Exec(
+			`INSERT INTO exercises (module_id, exercise_number, title, language, test_cases, difficulty)
+			 VALUES ($1, $2, 'Exercise', 'go', '[]', 'easy')`,
+			moduleIDs[1], number,
+		)
+		require.NoError(t, err)
+	}
+
+	modules, err := repo.GetExercisesByCourse(context.Background(), courseID)
+	require.NoError(t, err)
+	require.Len(t, modules, 2)
+	assert.Equal(t, moduleIDs[1], modules[0].ModuleID)
+	require.Len(t, modules[0].Exercises, 2)
+	assert.Equal(t, []int{1, 2}, []int{modules[0].Exercises[0].ExerciseNumber, modules[0].Exercises[1].ExerciseNumber})
+	assert.Equal(t, moduleIDs[0], modules[1].ModuleID)
+	assert.Empty(t, modules[1].Exercises)
+}
@@ -0,0 +1,118 @@
+package learning
+
+import (
+	"fmt"
+	"math"
+)
+
+// ScoringPolicy controls how test results turn into a submission score and pass
+type ScoringPolicy struct {
+	PassThreshold int     // Minimum score (1-100) that passes the exercise
+	VisibleWeight float64 // Weight of each visible test case
+	HiddenWeight  float64 // Weight of each hidden test case
+	HintPenalty   int     // Points deducted per revealed hint that sets no penalty of its own
+
+	// Multipliers applied to an exercise's points by difficulty
+	EasyMultiplier   float64
+	MediumMultiplier float64
+	HardMultiplier   float64
+}
+
+// Default difficulty multipliers for awarded exercise points
+const (
+	DefaultEasyMultiplier   = 1.0
+	DefaultMediumMultiplier = 1.5
+	DefaultHardMultiplier   = 2.0
+)
+
+// DefaultScoringPolicy weighs every test case equally and requires all of them to pass
+func DefaultScoringPolicy() ScoringPolicy {
+	return ScoringPolicy{
+		PassThreshold: 100,
+		VisibleWeight: 1,
+		HiddenWeight:  1,
+		HintPenalty:   5,
+
+		EasyMultiplier:   DefaultEasyMultiplier,
+		MediumMultiplier: DefaultMediumMultiplier,
+		HardMultiplier:   DefaultHardMultiplier,
+	}
+}
+
+// Validate checks the threshold and hint penalty are percentages and the
+// weights and difficulty multipliers are positive
+func (p ScoringPolicy) Validate() error {
+	if p.PassThreshold < 1 || p.PassThreshold > 100 {
+		return fmt.Errorf("pass threshold must be between 1 and 100, got %d", p.PassThreshold)
+	}
+	if p.VisibleWeight <= 0 || p.HiddenWeight <= 0 {
+		return fmt.Errorf("test case weights must be positive, got visible=%g hidden=%g", p.VisibleWeight, p.HiddenWeight)
+	}
+	if p.HintPenalty < 0 || p.HintPenalty > 100 {
+		return fmt.Errorf("hint penalty must be between 0 and 100, got %d", p.HintPenalty)
+	}
+	if p.EasyMultiplier <= 0 || p.MediumMultiplier <= 0 || p.HardMultiplier <= 0 {
+		return fmt.Errorf("difficulty multipliers must be positive, got easy=%g medium=%g hard=%g",
+			p.EasyMultiplier, p.MediumMultiplier, p.HardMultiplier)
+	}
+	return nil
+}
+
+// Score returns the weighted percentage of passing test cases and whether it
+// meets the pass threshold. A submission without test results never passes.
+func (p ScoringPolicy) Score(results []TestResult) (int, bool) {
+	var earned, total float64
+	for _, result := range results {
+		weight := p.VisibleWeight
+		if result.TestCase.IsHidden {
+			weight = p.HiddenWeight
+		}
+		total += weight
+		if result.Passed {
+			earned += weight
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	// Round down so a score only reaches 100 when every test case passes
+	score := int(earned * 100 / total)
+	if earned == total {
+		score = 100
+	}
+	return score, score >= p.PassThreshold
+}
+
+// PenalizeScore deducts penalty points from score without going below zero
+func PenalizeScore(score, penalty int) int {
+	if penalty >= score {
+		return 0
+	}
+	return score - penalty
+}
+
+// DifficultyMultiplier returns the points multiplier for an exercise difficulty.
+// Unknown difficulties are treated as easy.
+func (p ScoringPolicy) DifficultyMultiplier(difficulty string) float64 {
+	switch difficulty {
+	case "medium":
+		return p.MediumMultiplier
+	case "hard":
+		return p.HardMultiplier
+	default:
+		return p.EasyMultiplier
+	}
+}
+
+// AwardPoints scales an exercise's points by the submission score (0-100) and
+// the difficulty multiplier, rounding to the nearest whole point
+func (p ScoringPolicy) AwardPoints(points, score int, difficulty string) int {
+	if points <= 0 || score <= 0 {
+		return 0
+	}
+	if score > 100 {
+		score = 100
+	}
+	return int(math.Round(float64(points) * float64(score) / 100 * p.DifficultyMultiplier(difficulty)))
+}
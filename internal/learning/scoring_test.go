@@ -0,0 +1,176 @@
+package learning
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// results builds test results from visible and hidden pass/fail counts
+func results(visiblePassed, visibleFailed, hiddenPassed, hiddenFailed int) []TestResult {
+	var out []TestResult
+	add := func(n int, hidden, passed bool) {
+		for i := 0; i < n; i++ {
+			out = append(out, TestResult{TestCase: TestCase{IsHidden: hidden}, Passed: passed})
+		}
+	}
+	add(visiblePassed, false, true)
+	add(visibleFailed, false, false)
+	add(hiddenPassed, true, true)
+	add(hiddenFailed, true, false)
+	return out
+}
+
+func TestScoringPolicy_Score(t *testing.T) {
+	partialCredit := DefaultScoringPolicy()
+	partialCredit.PassThreshold = 70
+	partialCredit.HiddenWeight = 2
+
+	tests := []struct {
+		name       string
+		policy     ScoringPolicy
+		results    []TestResult
+		wantScore  int
+		wantPassed bool
+	}{
+		{"all pass with default policy", DefaultScoringPolicy(), results(2, 0, 2, 0), 100, true},
+		{"one failure fails the default policy", DefaultScoringPolicy(), results(3, 1, 0, 0), 75, false},
+		{"all pass with threshold", partialCredit, results(2, 0, 2, 0), 100, true},
+		// 2 visible (2) + 2 hidden (4) earned out of 8
+		{"threshold pass without a perfect run", partialCredit, results(1, 1, 2, 0), 83, true},
+		{"below threshold", partialCredit, results(2, 0, 1, 1), 66, false},
+		{"hidden failures weigh more", partialCredit, results(2, 0, 0, 2), 33, false},
+		{"no test cases never pass", partialCredit, nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, passed := tt.policy.Score(tt.results)
+			assert.Equal(t, tt.wantScore, score)
+			assert.Equal(t, tt.wantPassed, passed)
+		})
+	}
+}
+
+func TestScoringPolicy_Validate(t *testing.T) {
+	assert.NoError(t, DefaultScoringPolicy().Validate())
+	valid := DefaultScoringPolicy()
+	valid.PassThreshold = 70
+	valid.HiddenWeight = 0.5
+	assert.NoError(t, valid.Validate())
+
+	assert.Error(t, ScoringPolicy{PassThreshold: 0, VisibleWeight: 1, HiddenWeight: 1}.Validate())
+	assert.Error(t, ScoringPolicy{PassThreshold: 101, VisibleWeight: 1, HiddenWeight: 1}.Validate())
+	assert.Error(t, ScoringPolicy{PassThreshold: 70, VisibleWeight: 0, HiddenWeight: 1}.Validate())
+	assert.Error(t, ScoringPolicy{PassThreshold: 70, VisibleWeight: 1, HiddenWeight: -1}.Validate())
+	assert.Error(t, ScoringPolicy{PassThreshold: 70, VisibleWeight: 1, HiddenWeight: 1, HardMultiplier: -1}.Validate())
+
+	// A zero multiplier would award nothing, so it is rejected rather than read as unset
+	zeroMultiplier := DefaultScoringPolicy()
+	zeroMultiplier.MediumMultiplier = 0
+	assert.Error(t, zeroMultiplier.Validate())
+
+	service, _ := newMockService(t)
+	assert.Same(t, service, service.WithScoringPolicy(ScoringPolicy{PassThreshold: 70}))
+	assert.Equal(t, DefaultScoringPolicy(), service.scoringPolicy)
+}
+
+func TestScoringPolicy_AwardPoints(t *testing.T) {
+	policy := DefaultScoringPolicy()
+	tests := []struct {
+		name       string
+		points     int
+		score      int
+		difficulty string
+		want       int
+	}{
+		{"full score on easy", 10, 100, "easy", 10},
+		{"full score on medium", 10, 100, "medium", 15},
+		{"full score on hard", 10, 100, "hard", 20},
+		{"partial score rounds to nearest", 10, 75, "medium", 11},
+		{"zero score earns nothing", 10, 0, "hard", 0},
+		{"exercise without points", 0, 100, "hard", 0},
+		{"unknown difficulty counts as easy", 10, 100, "legendary", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.AwardPoints(tt.points, tt.score, tt.difficulty))
+		})
+	}
+
+	custom := DefaultScoringPolicy()
+	custom.HardMultiplier = 3
+	assert.Equal(t, 30, custom.AwardPoints(10, 100, "hard"))
+	assert.Equal(t, 15, custom.AwardPoints(10, 100, "medium"))
+}
+
+func TestSubmitExercise_BelowThresholdDoesNotAdvanceProgress(t *testing.T) {
+	service, mock := newMockService(t)
+	policy := DefaultScoringPolicy()
+	policy.PassThreshold = 70
+	policy.HiddenWeight = 2
+	service.WithScoringPolicy(policy)
+	userID, exerciseID, courseID := "user-1", "exercise-1", "course-1"
+
+	mock.ExpectQuery(`FROM exercises`).
+		WithArgs(exerciseID).
+		WillReturnRows(exerciseRows(exerciseID, "module-1"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+		WithArgs(userID, exerciseID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	expectHintsUsed(mock, userID, exerciseID, 0)
+	mock.ExpectExec(`INSERT INTO module_completions`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+		WithArgs("module-1").
+		WillReturnRows(sqlmock.NewRows([]string{"course_id"}).AddRow(courseID))
+	mock.ExpectQuery(`FROM user_progress`).
+		WithArgs(userID, courseID).
+		WillReturnRows(progressRows(userID, courseID, 40, 0))
+	// Percentage stays at 40 because the submission scored below the threshold
+	mock.ExpectExec(`UPDATE user_progress`).
+		WithArgs("module-1", 40, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), userID, courseID, progressVersion).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	completion, err := service.SubmitExercise(context.Background(), userID, exerciseID, "x", "go", 0, "")
+	require.NoError(t, err)
+	assert.False(t, completion.Passed)
+	assert.Equal(t, 0, completion.Score)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseTestCase_MalformedEntriesCountAsFailures(t *testing.T) {
+	valid, ok := parseTestCase(map[string]interface{}{"input": 1, "expected_output": 2, "is_hidden": true})
+	assert.True(t, ok)
+	assert.True(t, valid.IsHidden)
+
+	for _, raw := range []interface{}{"not an object", map[string]interface{}{"is_hidden": "yes"}} {
+		_, ok := parseTestCase(raw)
+		assert.False(t, ok)
+	}
+
+	// One passing case and one malformed case must not add up to a pass
+	score, passed := DefaultScoringPolicy().Score([]TestResult{
+		{TestCase: valid, Passed: true},
+		{TestCase: TestCase{IsHidden: true}, Passed: false, Error: "Malformed test case"},
+	})
+	assert.Equal(t, 50, score)
+	assert.False(t, passed)
+}
+
+func TestGetUserPoints_SumsBestSubmissionPerExercise(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`SELECT MAX\(points_earned\) AS best\s+FROM module_completions\s+WHERE user_id = \$1\s+GROUP BY exercise_id`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(35))
+
+	total, err := service.GetUserPoints(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 35, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,36 +2,164 @@ package learning
 
 import (
 	"backend/internal/platform/ai"
+	"backend/internal/platform/middleware"
+	"backend/internal/platform/requestctx"
+	"backend/internal/platform/validation"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 )
 
 // Service handles learning business logic
 type Service struct {
-	repo     *Repository
-	aiClient *ai.Client
+	repo             *Repository
+	aiClient         *ai.Client
+	textLimits       validation.TextLimits
+	scoringPolicy    ScoringPolicy
+	curriculumPolicy CurriculumPolicy
+	languages        []Language
+	aiBudget         *ai.Budget // Per-user daily AI call cap; nil allows every call
+
+	activityBroadcaster ActivityBroadcaster
+	metrics             MetricsRecorder
+}
+
+// MetricsRecorder counts learning business events; metrics.Recorder satisfies it
+type MetricsRecorder interface {
+	RecordExerciseSubmission(passed bool)
 }
 
+// NoopMetricsRecorder discards all events. Used when metrics are not wired and in tests.
+type NoopMetricsRecorder struct{}
+
+// RecordExerciseSubmission does nothing
+func (NoopMetricsRecorder) RecordExerciseSubmission(passed bool) {}
+
 // NewService creates a new learning service
 func NewService(repo *Repository, aiClient *ai.Client) *Service {
 	return &Service{
-		repo:     repo,
-		aiClient: aiClient,
+		repo:             repo,
+		aiClient:         aiClient,
+		textLimits:       validation.DefaultTextLimits(),
+		scoringPolicy:    DefaultScoringPolicy(),
+		curriculumPolicy: DefaultCurriculumPolicy(),
+		languages:        DefaultLanguages(),
+		metrics:          NoopMetricsRecorder{},
+	}
+}
+
+// WithTextLimits sets the length caps applied to stored feedback text
+func (s *Service) WithTextLimits(limits validation.TextLimits) *Service {
+	s.textLimits = limits
+	return s
+}
+
+// WithAIBudget caps the AI calls each user can trigger per day. Reviews and
+// course completion over the cap fail with ai.ErrBudgetExceeded; course
+// generation falls back to templates.
+func (s *Service) WithAIBudget(budget *ai.Budget) *Service {
+	s.aiBudget = budget
+	return s
+}
+
+// WithMetrics sets where exercise submissions are counted
+func (s *Service) WithMetrics(recorder MetricsRecorder) *Service {
+	if recorder == nil {
+		recorder = NoopMetricsRecorder{}
+	}
+	s.metrics = recorder
+	return s
+}
+
+// WithScoringPolicy sets how submissions are scored and which score passes.
+// Callers validate the policy at startup; an invalid one is ignored so a bad
+// value can never make every submission pass or fail.
+func (s *Service) WithScoringPolicy(policy ScoringPolicy) *Service {
+	if err := policy.Validate(); err != nil {
+		slog.Warn("ignoring invalid scoring policy", "error", err)
+		return s
+	}
+	s.scoringPolicy = policy
+	return s
+}
+
+// WithCurriculumPolicy sets how many modules a course built from an AI curriculum
+// should have and how few are still accepted as a partial course. Like
+// WithScoringPolicy, an invalid policy is ignored; callers validate it at startup.
+func (s *Service) WithCurriculumPolicy(policy CurriculumPolicy) *Service {
+	if err := policy.Validate(); err != nil {
+		slog.Warn("ignoring invalid curriculum policy", "error", err)
+		return s
+	}
+	s.curriculumPolicy = policy
+	return s
+}
+
+// sanitizeFeedback escapes and caps review feedback before it is stored
+// AI output is never rejected for length, only truncated
+func (s *Service) sanitizeFeedback(feedback map[string]string) map[string]string {
+	policy := s.textLimits.Long()
+	policy.Truncate = true
+
+	sanitized := make(map[string]string, len(feedback))
+	for key, text := range feedback {
+		sanitized[key], _ = validation.SanitizeText(key, text, policy)
+	}
+	return sanitized
+}
+
+// sanitizeReviewSections applies sanitizeFeedback's policy to the structured
+// sections of an AI review and copies them onto review
+func (s *Service) sanitizeReviewSections(review *ArchitectureReview, aiReview *ai.ArchitectureReview) {
+	policy := s.textLimits.Long()
+	policy.Truncate = true
+
+	sanitizeList := func(field string, items []string) []string {
+		sanitized := make([]string, 0, len(items))
+		for _, item := range items {
+			text, _ := validation.SanitizeText(field, item, policy)
+			sanitized = append(sanitized, text)
+		}
+		return sanitized
+	}
+
+	review.Summary, _ = validation.SanitizeText("summary", aiReview.Summary, policy)
+	review.Strengths = sanitizeList("strengths", aiReview.Strengths)
+	review.Suggestions = sanitizeList("suggestions", aiReview.Suggestions)
+	review.Issues = make([]ReviewIssue, 0, len(aiReview.Issues))
+	for _, issue := range aiReview.Issues {
+		description, _ := validation.SanitizeText("issues", issue.Description, policy)
+		review.Issues = append(review.Issues, ReviewIssue{
+			Category:    issue.Category,
+			Severity:    issue.Severity,
+			Description: description,
+		})
 	}
 }
 
 // GenerateCourse creates personalized course from blueprint
-func (s *Service) GenerateCourse(userID, archetypeID string, variables map[string]string) (*GeneratedCourse, error) {
+// When no blueprint modules are stored, the AI curriculum modules are used instead;
+// without either, ErrNoBlueprintModules is returned. A curriculum with fewer valid
+// modules than the policy target is saved as a partial course. Without a usable AI
+// curriculum the course is built from blueprint templates alone and flagged
+// CourseGeneratedByTemplate.
+func (s *Service) GenerateCourse(ctx context.Context, userID, archetypeID string, variables map[string]string) (*GeneratedCourse, error) {
 	// 1. Fetch blueprint modules
-	blueprints, err := s.repo.GetBlueprintModules()
+	blueprints, err := s.repo.GetBlueprintModules(ctx)
 	if err != nil {
+		slog.Error("failed to fetch blueprint modules",
+			"request_id", requestctx.RequestID(ctx),
+			"user_id", userID,
+			"error", err,
+		)
 		return nil, fmt.Errorf("failed to fetch blueprint modules: %w", err)
 	}
 
-	if len(blueprints) == 0 {
-		return nil, fmt.Errorf("no blueprint modules found")
-	}
-
 	// 2. Extract variables for template injection
 	entity := variables["ENTITY"]
 	state := variables["STATE"]
@@ -43,12 +171,28 @@ func (s *Service) GenerateCourse(userID, archetypeID string, variables map[strin
 		return nil, fmt.Errorf("ENTITY variable is required")
 	}
 
-	// 3. Create course title from first blueprint template
-	courseTitle := s.injectVariables(blueprints[0].TitleTemplate, variables)
-	courseDescription := fmt.Sprintf("Learn to build a %s system from first principles", entity)
+	courseDescription := templateCourseDescription(variables)
+	generatedBy := CourseGeneratedByTemplate
+
+	// 3. Use AI to enhance course description if available
+	var curriculum *ai.Curriculum
+	useAI := s.aiClient != nil
+	if useAI {
+		if err := s.aiBudget.Consume(ctx, userID, ai.CallGenerateCurriculum); err != nil {
+			slog.Warn("AI budget exhausted, using template description",
+				"request_id", requestctx.RequestID(ctx),
+				"user_id", userID,
+				"error", err,
+			)
+			useAI = false
+		}
+	}
+	if useAI {
+		locale, err := s.repo.GetUserLocale(ctx, userID)
+		if err != nil {
+			locale = ai.DefaultLocale
+		}
 
-	// 4. Use AI to enhance course description if available
-	if s.aiClient != nil {
 		aiVars := &ai.Variables{
 			Entity:    entity,
 			State:     state,
@@ -56,12 +200,52 @@ func (s *Service) GenerateCourse(userID, archetypeID string, variables map[strin
 			Logic:     logic,
 			Interface: iface,
 		}
-		curriculum, err := s.aiClient.GenerateCurriculum(archetypeID, entity, aiVars)
-		if err == nil && curriculum != nil {
+		curriculum, err = s.aiClient.GenerateCurriculum(ctx, archetypeID, entity, aiVars, locale)
+		if err != nil {
+			slog.Warn("curriculum generation failed, using template description",
+				"request_id", requestctx.RequestID(ctx),
+				"user_id", userID,
+				"archetype_id", archetypeID,
+				"error", err,
+			)
+			curriculum = nil
+		} else if curriculum != nil {
 			courseDescription = curriculum.Description
+			generatedBy = CourseGeneratedByAI
 		}
 	}
 
+	// 4. Fall back to the AI curriculum when the blueprint table is empty (e.g. a fresh environment)
+	status := CourseStatusActive
+	if len(blueprints) == 0 {
+		blueprints = validCurriculumBlueprints(blueprintsFromCurriculum(curriculum))
+		if len(blueprints) == 0 || len(blueprints) < s.curriculumPolicy.MinModules {
+			slog.Error("no blueprint modules found and no usable AI curriculum available",
+				"request_id", requestctx.RequestID(ctx),
+				"user_id", userID,
+				"curriculum_modules", len(blueprints),
+			)
+			return nil, ErrNoBlueprintModules
+		}
+		status = s.curriculumPolicy.status(len(blueprints))
+		slog.Warn("no blueprint modules found, using AI curriculum modules",
+			"request_id", requestctx.RequestID(ctx),
+			"user_id", userID,
+			"modules", len(blueprints),
+			"status", status,
+		)
+	}
+	if generatedBy == CourseGeneratedByTemplate {
+		slog.Warn("AI curriculum unavailable, generating course from templates",
+			"request_id", requestctx.RequestID(ctx),
+			"user_id", userID,
+			"archetype_id", archetypeID,
+		)
+	}
+
+	// Course title comes from the first module's template
+	courseTitle := s.injectVariables(blueprints[0].TitleTemplate, variables)
+
 	// 5. Create course instance
 	course := &GeneratedCourse{
 		UserID:            userID,
@@ -70,53 +254,173 @@ func (s *Service) GenerateCourse(userID, archetypeID string, variables map[strin
 		Description:       courseDescription,
 		MetaCategory:      "Digital", // Default, should be determined by archetype
 		InjectedVariables: variables,
-		Status:            "active",
-	}
-
-	if err := s.repo.CreateGeneratedCourse(course); err != nil {
-		return nil, fmt.Errorf("failed to create course: %w", err)
+		Status:            status,
+		GeneratedBy:       generatedBy,
 	}
 
-	// 6. Create module instances with injected variables
+	// 6. Build module instances with injected variables; the repository assigns
+	// their course ID once the course row exists
 	var modules []GeneratedModule
+	firstModule := lowestModuleNumber(blueprints)
 	for _, blueprint := range blueprints {
-		module := GeneratedModule{
-			CourseID:          course.ID,
-			BlueprintModuleID: blueprint.ID,
-			ModuleNumber:      blueprint.ModuleNumber,
-			Title:             s.injectVariables(blueprint.TitleTemplate, variables),
-			Description:       s.injectVariables(blueprint.DescriptionTemplate, variables),
-			Status:            "locked",
+		module := s.newModule("", blueprint, variables)
+		if generatedBy == CourseGeneratedByTemplate {
+			module.Content = s.templateModuleContent(blueprint, module.Title, variables)
 		}
 
-		// Unlock first module
-		if blueprint.ModuleNumber == 1 {
+		// Unlock first module, even when numbering does not start at 1
+		if blueprint.ModuleNumber == firstModule {
 			module.Status = "active"
 		}
 
-		// Generate module content using AI
-		if s.aiClient != nil {
-			content := map[string]interface{}{
-				"lessons": []string{
-					fmt.Sprintf("Introduction to %s", module.Title),
-					fmt.Sprintf("Core concepts of %s", entity),
-					fmt.Sprintf("Implementation patterns"),
-				},
-				"exercises": []string{},
-			}
-			module.Content = content
-		}
-
 		modules = append(modules, module)
 	}
 
-	if err := s.repo.CreateGeneratedModules(modules); err != nil {
-		return nil, fmt.Errorf("failed to create modules: %w", err)
+	// 7. Save the course and its modules together so a failure leaves no orphan course
+	if err := s.repo.CreateCourseWithModules(ctx, course, modules); err != nil {
+		slog.Error("failed to create course",
+			"request_id", requestctx.RequestID(ctx),
+			"user_id", userID,
+			"error", err,
+		)
+		return nil, fmt.Errorf("failed to create course: %w", err)
 	}
 
 	return course, nil
 }
 
+// newModule builds a locked module instance from a blueprint with the course variables injected
+func (s *Service) newModule(courseID string, blueprint BlueprintModule, variables map[string]string) GeneratedModule {
+	module := GeneratedModule{
+		CourseID:          courseID,
+		BlueprintModuleID: blueprint.ID,
+		ModuleNumber:      blueprint.ModuleNumber,
+		Title:             s.injectVariables(blueprint.TitleTemplate, variables),
+		Description:       s.injectVariables(blueprint.DescriptionTemplate, variables),
+		Status:            "locked",
+	}
+
+	// Generate module content using AI
+	if s.aiClient != nil {
+		content := map[string]interface{}{
+			"lessons": []string{
+				fmt.Sprintf("Introduction to %s", module.Title),
+				fmt.Sprintf("Core concepts of %s", variables["ENTITY"]),
+				fmt.Sprintf("Implementation patterns"),
+			},
+			"exercises": []string{},
+		}
+		module.Content = content
+	}
+
+	return module
+}
+
+// ErrCourseNotPartial is returned when completing generation of a course that is not partial
+var ErrCourseNotPartial = errors.New("course generation is already complete")
+
+// ErrCurriculumUnavailable is returned when a partial course cannot be completed without an AI provider
+var ErrCurriculumUnavailable = errors.New("curriculum generation is unavailable")
+
+// CompleteCourseGeneration asks the AI for the curriculum again and adds the
+// modules a partial course is missing, up to the policy target. The course
+// becomes active once it reaches the target; otherwise it stays partial and
+// can be completed again later. New modules start locked.
+func (s *Service) CompleteCourseGeneration(ctx context.Context, courseID string) (*GeneratedCourse, []GeneratedModule, error) {
+	course, err := s.repo.GetCourseByID(ctx, courseID, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get course: %w", err)
+	}
+	if course.Status != CourseStatusPartial {
+		return nil, nil, ErrCourseNotPartial
+	}
+	if s.aiClient == nil {
+		return nil, nil, ErrCurriculumUnavailable
+	}
+
+	modules, err := s.repo.GetCourseModules(ctx, courseID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get course modules: %w", err)
+	}
+
+	if err := s.aiBudget.Consume(ctx, course.UserID, ai.CallGenerateCurriculum); err != nil {
+		return nil, nil, err
+	}
+
+	variables := courseVariables(course.InjectedVariables)
+	locale, err := s.repo.GetUserLocale(ctx, course.UserID)
+	if err != nil {
+		locale = ai.DefaultLocale
+	}
+
+	aiVars := &ai.Variables{
+		Entity:    variables["ENTITY"],
+		State:     variables["STATE"],
+		Flow:      variables["FLOW"],
+		Logic:     variables["LOGIC"],
+		Interface: variables["INTERFACE"],
+	}
+	curriculum, err := s.aiClient.GenerateCurriculum(ctx, course.ArchetypeID, aiVars.Entity, aiVars, locale)
+	if err != nil {
+		slog.Warn("curriculum generation failed while completing course",
+			"request_id", requestctx.RequestID(ctx),
+			"course_id", courseID,
+			"error", err,
+		)
+		return nil, nil, fmt.Errorf("failed to generate curriculum: %w", err)
+	}
+
+	existing := make(map[int]bool, len(modules))
+	for _, module := range modules {
+		existing[module.ModuleNumber] = true
+	}
+
+	var added []GeneratedModule
+	for _, blueprint := range validCurriculumBlueprints(blueprintsFromCurriculum(curriculum)) {
+		if len(modules)+len(added) >= s.curriculumPolicy.TargetModules {
+			break
+		}
+		if existing[blueprint.ModuleNumber] {
+			continue
+		}
+		added = append(added, s.newModule(courseID, blueprint, variables))
+	}
+
+	if len(added) > 0 {
+		if err := s.repo.CreateGeneratedModules(ctx, added); err != nil {
+			return nil, nil, fmt.Errorf("failed to create modules: %w", err)
+		}
+		modules = append(modules, added...)
+	}
+
+	if status := s.curriculumPolicy.status(len(modules)); status != course.Status {
+		if err := s.repo.UpdateCourseStatus(ctx, courseID, status); err != nil {
+			return nil, nil, fmt.Errorf("failed to update course status: %w", err)
+		}
+		course.Status = status
+	}
+
+	return course, modules, nil
+}
+
+// courseVariables converts a course's stored injected variables back to strings
+func courseVariables(stored interface{}) map[string]string {
+	variables := make(map[string]string)
+	switch values := stored.(type) {
+	case map[string]string:
+		for key, value := range values {
+			variables[key] = value
+		}
+	case map[string]interface{}:
+		for key, value := range values {
+			if text, ok := value.(string); ok {
+				variables[key] = text
+			}
+		}
+	}
+	return variables
+}
+
 // injectVariables replaces template placeholders with actual values
 func (s *Service) injectVariables(template string, variables map[string]string) string {
 	result := template
@@ -127,23 +431,101 @@ func (s *Service) injectVariables(template string, variables map[string]string)
 	return result
 }
 
-// GetUserCourses retrieves all courses for user
-func (s *Service) GetUserCourses(userID string) ([]GeneratedCourse, error) {
-	courses, err := s.repo.GetUserCourses(userID)
+// Course page sizes
+const (
+	DefaultCoursePageSize = 20
+	MaxCoursePageSize     = 100
+)
+
+// GetUserCourses retrieves one page of the user's courses and the total course count,
+// soft-deleted courses included only when includeDeleted is set
+func (s *Service) GetUserCourses(ctx context.Context, userID string, limit, offset int, includeDeleted bool) ([]GeneratedCourse, int, error) {
+	courses, err := s.repo.GetUserCourses(ctx, userID, limit, offset, includeDeleted)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get user courses: %w", err)
+	}
+
+	total, err := s.repo.CountUserCourses(ctx, userID, includeDeleted)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get user courses: %w", err)
+	}
+
+	return courses, total, nil
+}
+
+// MaxCourseSearchLength caps the length of a course search query
+const MaxCourseSearchLength = 100
+
+// ErrInvalidCourseSearch is returned when a course search uses an unknown filter value
+var ErrInvalidCourseSearch = errors.New("invalid course search")
+
+// courseMetaCategories and courseStatuses mirror the generated_courses CHECK constraints
+var (
+	courseMetaCategories = map[string]bool{
+		"Digital": true, "Economic": true, "Aesthetic": true, "Biological": true, "Cognitive": true,
+	}
+	courseStatuses = map[string]bool{
+		CourseStatusActive: true, CourseStatusPartial: true, "paused": true, "completed": true, "archived": true,
+	}
+)
+
+// CourseSearch filters a user's courses. Empty fields match everything.
+type CourseSearch struct {
+	Query        string // Matched case-insensitively against title and description
+	MetaCategory string
+	Status       string
+}
+
+// IsEmpty reports whether the search has no filters at all
+func (c CourseSearch) IsEmpty() bool {
+	return c.Query == "" && c.MetaCategory == "" && c.Status == ""
+}
+
+// Validate checks the filters against the values courses can have
+func (c CourseSearch) Validate() error {
+	if len(c.Query) > MaxCourseSearchLength {
+		return fmt.Errorf("%w: q must be at most %d characters", ErrInvalidCourseSearch, MaxCourseSearchLength)
+	}
+	if c.MetaCategory != "" && !courseMetaCategories[c.MetaCategory] {
+		return fmt.Errorf("%w: category must be one of: Digital, Economic, Aesthetic, Biological, Cognitive", ErrInvalidCourseSearch)
+	}
+	if c.Status != "" && !courseStatuses[c.Status] {
+		return fmt.Errorf("%w: status must be one of: active, partial, paused, completed, archived", ErrInvalidCourseSearch)
+	}
+	return nil
+}
+
+// SearchCourses returns one page of the user's courses matching search, most
+// relevant first, along with the total number of matches. Soft-deleted courses
+// match only when includeDeleted is set.
+func (s *Service) SearchCourses(ctx context.Context, userID string, search CourseSearch, limit, offset int, includeDeleted bool) ([]GeneratedCourse, int, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	if err := search.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	courses, err := s.repo.SearchCourses(ctx, userID, search.Query, search.MetaCategory, search.Status, limit, offset, includeDeleted)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search courses: %w", err)
+	}
+
+	total, err := s.repo.CountSearchCourses(ctx, userID, search.Query, search.MetaCategory, search.Status, includeDeleted)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user courses: %w", err)
+		return nil, 0, fmt.Errorf("failed to search courses: %w", err)
 	}
-	return courses, nil
+
+	return courses, total, nil
 }
 
-// GetCourseDetails retrieves course with modules
-func (s *Service) GetCourseDetails(courseID string) (*GeneratedCourse, []GeneratedModule, error) {
-	course, err := s.repo.GetCourseByID(courseID)
+// GetCourseDetails retrieves course with modules; a soft-deleted course is
+// only found when includeDeleted is set
+func (s *Service) GetCourseDetails(ctx context.Context, courseID string, includeDeleted bool) (*GeneratedCourse, []GeneratedModule, error) {
+	course, err := s.repo.GetCourseByID(ctx, courseID, includeDeleted)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get course: %w", err)
 	}
 
-	modules, err := s.repo.GetCourseModules(courseID)
+	modules, err := s.repo.GetCourseModules(ctx, courseID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to get course modules: %w", err)
 	}
@@ -151,15 +533,92 @@ func (s *Service) GetCourseDetails(courseID string) (*GeneratedCourse, []Generat
 	return course, modules, nil
 }
 
+// GetModule retrieves a single module with its content
+func (s *Service) GetModule(ctx context.Context, moduleID string) (*GeneratedModule, error) {
+	return s.repo.GetModuleByID(ctx, moduleID)
+}
+
+// GetModuleExercises retrieves the exercises of a module
+func (s *Service) GetModuleExercises(ctx context.Context, moduleID string) ([]Exercise, error) {
+	return s.repo.GetModuleExercises(ctx, moduleID)
+}
+
+// GetCourseExercises retrieves all of a course's exercises grouped by module
+func (s *Service) GetCourseExercises(ctx context.Context, courseID string) ([]ModuleExercises, error) {
+	modules, err := s.repo.GetExercisesByCourse(ctx, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course exercises: %w", err)
+	}
+	return modules, nil
+}
+
 // GetExercise retrieves exercise details
-func (s *Service) GetExercise(exerciseID string) (*Exercise, error) {
-	exercise, err := s.repo.GetExerciseByID(exerciseID)
+func (s *Service) GetExercise(ctx context.Context, exerciseID string) (*Exercise, error) {
+	exercise, err := s.repo.GetExerciseByID(ctx, exerciseID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get exercise: %w", err)
 	}
 	return exercise, nil
 }
 
+// GetExerciseOwnerID returns the user who owns the exercise's course
+func (s *Service) GetExerciseOwnerID(ctx context.Context, exerciseID string) (string, error) {
+	return s.repo.GetExerciseOwnerID(ctx, exerciseID)
+}
+
+// GetModuleOwnerID returns the user who owns the module's course
+func (s *Service) GetModuleOwnerID(ctx context.Context, moduleID string) (string, error) {
+	courseID, err := s.repo.GetCourseIDByModule(ctx, moduleID)
+	if err != nil {
+		return "", err
+	}
+	return s.GetCourseOwnerID(ctx, courseID)
+}
+
+// GetCourseOwnerID returns the user who owns the course. Soft-deleted courses
+// are not found, so nothing can be done to them until they are restored.
+func (s *Service) GetCourseOwnerID(ctx context.Context, courseID string) (string, error) {
+	course, err := s.GetCourse(ctx, courseID, false)
+	if err != nil {
+		return "", err
+	}
+	return course.UserID, nil
+}
+
+// GetCourse retrieves a course without its modules; a soft-deleted course is
+// only found when includeDeleted is set
+func (s *Service) GetCourse(ctx context.Context, courseID string, includeDeleted bool) (*GeneratedCourse, error) {
+	course, err := s.repo.GetCourseByID(ctx, courseID, includeDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course: %w", err)
+	}
+	return course, nil
+}
+
+// DeleteCourse soft-deletes a course: it disappears from listings and lookups
+// but keeps its modules and the learner's submissions so it can be restored
+func (s *Service) DeleteCourse(ctx context.Context, courseID string) error {
+	if err := s.repo.SoftDeleteCourse(ctx, courseID, time.Now()); err != nil {
+		if errors.Is(err, ErrCourseNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete course: %w", err)
+	}
+	return nil
+}
+
+// RestoreCourse brings back a soft-deleted course and returns it. It returns
+// ErrCourseNotDeleted for a course that is not deleted.
+func (s *Service) RestoreCourse(ctx context.Context, courseID string) (*GeneratedCourse, error) {
+	if err := s.repo.RestoreCourse(ctx, courseID, time.Now()); err != nil {
+		if errors.Is(err, ErrCourseNotFound) {
+			return nil, ErrCourseNotDeleted
+		}
+		return nil, fmt.Errorf("failed to restore course: %w", err)
+	}
+	return s.GetCourse(ctx, courseID, false)
+}
+
 // TestCase represents a single test case
 type TestCase struct {
 	Input          interface{} `json:"input"`
@@ -167,6 +626,29 @@ type TestCase struct {
 	IsHidden       bool        `json:"is_hidden"`
 }
 
+// parseTestCase reads a test case stored as JSONB. Entries that are not objects
+// or carry a non-boolean is_hidden flag are reported as malformed; those are
+// treated as hidden so their contents are never shown to the learner.
+func parseTestCase(raw interface{}) (TestCase, bool) {
+	tcMap, ok := raw.(map[string]interface{})
+	if !ok {
+		return TestCase{IsHidden: true}, false
+	}
+
+	testCase := TestCase{
+		Input:          tcMap["input"],
+		ExpectedOutput: tcMap["expected_output"],
+	}
+	if hidden, present := tcMap["is_hidden"]; present && hidden != nil {
+		isHidden, ok := hidden.(bool)
+		if !ok {
+			return TestCase{IsHidden: true}, false
+		}
+		testCase.IsHidden = isHidden
+	}
+	return testCase, true
+}
+
 // TestResult represents the result of a test case execution
 type TestResult struct {
 	TestCase       TestCase    `json:"test_case"`
@@ -176,10 +658,93 @@ type TestResult struct {
 	ExecutionTime  int         `json:"execution_time_ms"`
 }
 
+// MaxTimeSpentSeconds caps the client-reported time for a single submission
+const MaxTimeSpentSeconds = 24 * 60 * 60
+
+// ErrCourseNotFound is returned when no course (or no live course) has the requested ID
+var ErrCourseNotFound = errors.New("course not found")
+
+// ErrCourseNotDeleted is returned when restoring a course that was not deleted
+var ErrCourseNotDeleted = errors.New("course is not deleted")
+
+// ErrSubmissionNotFound is returned when a submission does not exist
+var ErrSubmissionNotFound = errors.New("submission not found")
+
+// ErrModuleNotFound is returned when a module does not exist
+var ErrModuleNotFound = errors.New("module not found")
+
+// ErrProgressNotFound is returned when a user has no progress row for a course
+var ErrProgressNotFound = errors.New("progress not found")
+
+// ErrProgressConflict is returned when progress changed between being read and written
+var ErrProgressConflict = errors.New("progress was changed by another request")
+
+// maxProgressAttempts bounds how often a progress update is re-applied after
+// losing a race with a concurrent submission
+const maxProgressAttempts = 3
+
+// ErrNegativeTimeSpent is returned when a submission reports negative time spent
+var ErrNegativeTimeSpent = errors.New("time_spent_seconds must be non-negative")
+
+// MaxIdempotencyKeyLength bounds the Idempotency-Key accepted on submissions
+const MaxIdempotencyKeyLength = 255
+
+// ErrInvalidIdempotencyKey is returned when an idempotency key is too long or contains non-printable characters
+var ErrInvalidIdempotencyKey = errors.New("idempotency key must be 1-255 printable ASCII characters")
+
+// ErrIdempotencyKeyReused is returned when an idempotency key was already used for a different exercise
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different exercise")
+
+// ErrDuplicateSubmission is returned by the repository when a submission with the same idempotency key already exists
+var ErrDuplicateSubmission = errors.New("duplicate submission")
+
+// validateIdempotencyKey checks a non-empty key against MaxIdempotencyKeyLength and the printable ASCII range
+func validateIdempotencyKey(key string) error {
+	if len(key) > MaxIdempotencyKeyLength {
+		return ErrInvalidIdempotencyKey
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return ErrInvalidIdempotencyKey
+		}
+	}
+	return nil
+}
+
 // SubmitExercise handles code submission
-func (s *Service) SubmitExercise(userID, exerciseID, code, language string) (*ModuleCompletion, error) {
+// timeSpentSeconds is client-reported; values above MaxTimeSpentSeconds are capped.
+// When idempotencyKey is set and the user already submitted with it, the stored
+// completion is returned with Replayed set and nothing is re-scored or re-counted.
+func (s *Service) SubmitExercise(ctx context.Context, userID, exerciseID, code, language string, timeSpentSeconds int, idempotencyKey string) (*ModuleCompletion, error) {
+	if timeSpentSeconds < 0 {
+		return nil, ErrNegativeTimeSpent
+	}
+	if timeSpentSeconds > MaxTimeSpentSeconds {
+		timeSpentSeconds = MaxTimeSpentSeconds
+	}
+
+	if idempotencyKey != "" {
+		if err := validateIdempotencyKey(idempotencyKey); err != nil {
+			return nil, err
+		}
+		existing, err := s.replaySubmission(ctx, userID, exerciseID, idempotencyKey)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, ErrSubmissionNotFound) {
+			return nil, err
+		}
+	}
+
+	// Submissions are stored with the configured spelling (exercises.language CHECK)
+	canonical, err := validation.OneOf("language", language, s.languageNames())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, strings.TrimSpace(language))
+	}
+	language = canonical
+
 	// 1. Fetch exercise details
-	exercise, err := s.repo.GetExerciseByID(exerciseID)
+	exercise, err := s.repo.GetExerciseByID(ctx, exerciseID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get exercise: %w", err)
 	}
@@ -192,39 +757,45 @@ func (s *Service) SubmitExercise(userID, exerciseID, code, language string) (*Mo
 
 	// 3. Run test cases
 	var testResults []TestResult
-	passedCount := 0
-	totalCount := len(testCases)
-
 	for _, tc := range testCases {
-		tcMap, ok := tc.(map[string]interface{})
+		testCase, ok := parseTestCase(tc)
 		if !ok {
+			// A test case the learner cannot pass still counts against the score
+			testResults = append(testResults, TestResult{
+				TestCase: testCase,
+				Passed:   false,
+				Error:    "Malformed test case",
+			})
 			continue
 		}
 
-		testCase := TestCase{
-			Input:          tcMap["input"],
-			ExpectedOutput: tcMap["expected_output"],
-			IsHidden:       tcMap["is_hidden"] != nil && tcMap["is_hidden"].(bool),
-		}
-
 		// Execute test case (simplified - real implementation would run code)
 		result := s.executeTestCase(code, language, testCase, exercise.SolutionCode)
 		testResults = append(testResults, result)
+	}
 
-		if result.Passed {
-			passedCount++
-		}
+	// 4. Calculate the weighted score; reaching the policy threshold counts as a pass
+	score, passed := s.scoringPolicy.Score(testResults)
+
+	// 5. Create submission record, counting this attempt after any earlier ones
+	priorAttempts, err := s.repo.CountSubmissions(ctx, userID, exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attempts: %w", err)
+	}
+
+	// Revealed hints lower the score but never turn a pass into a fail
+	hintsUsed, err := s.repo.GetHintsUsed(ctx, userID, exerciseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply hint penalty: %w", err)
 	}
+	score = PenalizeScore(score, s.hintPenalty(exerciseHints(exercise), hintsUsed))
 
-	// 4. Calculate score
-	score := 0
-	passed := false
-	if totalCount > 0 {
-		score = (passedCount * 100) / totalCount
-		passed = passedCount == totalCount
+	// Only a passing submission earns points
+	pointsEarned := 0
+	if passed {
+		pointsEarned = s.scoringPolicy.AwardPoints(exercise.Points, score, exercise.Difficulty)
 	}
 
-	// 5. Create submission record
 	completion := &ModuleCompletion{
 		UserID:           userID,
 		ModuleID:         exercise.ModuleID,
@@ -234,46 +805,110 @@ func (s *Service) SubmitExercise(userID, exerciseID, code, language string) (*Mo
 		TestResults:      testResults,
 		Passed:           passed,
 		Score:            score,
-		Attempts:         1,
-		HintsUsed:        0,
-		TimeSpentMinutes: 0,
+		PointsEarned:     pointsEarned,
+		Attempts:         priorAttempts + 1,
+		HintsUsed:        hintsUsed,
+		TimeSpentMinutes: timeSpentSeconds / 60,
+		TimeSpentSeconds: timeSpentSeconds,
+		IdempotencyKey:   idempotencyKey,
 	}
 
-	if err := s.repo.SubmitExercise(completion); err != nil {
+	if err := s.repo.SubmitExercise(ctx, completion); err != nil {
+		if errors.Is(err, ErrDuplicateSubmission) {
+			// A concurrent retry with the same key won the insert
+			return s.replaySubmission(ctx, userID, exerciseID, idempotencyKey)
+		}
 		return nil, fmt.Errorf("failed to save submission: %w", err)
 	}
+	s.metrics.RecordExerciseSubmission(passed)
 
-	// 6. Update user progress
-	if passed {
-		// Fetch course from module
-		modules, err := s.repo.GetCourseModules(exercise.ModuleID)
-		if err == nil && len(modules) > 0 {
-			courseID := modules[0].CourseID
+	// 6. Update user progress: time accumulates on every attempt, percentage only on a pass at or above the threshold
+	courseID, err := s.repo.GetCourseIDByModule(ctx, exercise.ModuleID)
+	if err == nil {
+		s.updateProgress(ctx, userID, courseID, exercise.ModuleID, timeSpentSeconds, passed)
+	}
+
+	return completion, nil
+}
+
+// updateProgress folds one submission into the user's course progress. The
+// write only applies if the progress is unchanged since it was read; when a
+// concurrent submission got there first, the progress is read and the
+// submission applied again, so neither submission's time is lost. The
+// submission itself is already saved, so failures are logged, not returned.
+func (s *Service) updateProgress(ctx context.Context, userID, courseID, moduleID string, timeSpentSeconds int, passed bool) {
+	for attempt := 1; attempt <= maxProgressAttempts; attempt++ {
+		progress, err := s.repo.GetUserProgress(ctx, userID, courseID)
+		if errors.Is(err, ErrProgressNotFound) {
+			// Create new progress if doesn't exist
+			progress = &UserProgress{
+				UserID:          userID,
+				CourseID:        courseID,
+				CurrentModuleID: moduleID,
+			}
+		} else if err != nil {
+			// Leave the stored progress untouched rather than overwrite it with a zeroed row
+			slog.Error("failed to load progress, skipping progress update",
+				"user_id", userID, "course_id", courseID, "error", err)
+			return
+		}
+
+		progress.TimeSpentSeconds += timeSpentSeconds
+		progress.TimeSpentMinutes = progress.TimeSpentSeconds / 60
 
-			// Get current progress
-			progress, err := s.repo.GetUserProgress(userID, courseID)
+		completed := false
+		if passed {
+			// Percentage is the share of distinct modules passed, so repeat passes add nothing
+			passedModules, totalModules, err := s.repo.CountCompletedModules(ctx, userID, courseID)
 			if err != nil {
-				// Create new progress if doesn't exist
-				progress = &UserProgress{
-					UserID:             userID,
-					CourseID:           courseID,
-					CurrentModuleID:    exercise.ModuleID,
-					ProgressPercentage: 10, // Increment by 10% per module (7 modules = ~70%)
-					TimeSpentMinutes:   0,
-				}
-			} else {
-				// Update existing progress
-				progress.ProgressPercentage += 10
-				if progress.ProgressPercentage > 100 {
-					progress.ProgressPercentage = 100
-				}
+				slog.Error("failed to count completed modules, skipping progress update",
+					"user_id", userID, "course_id", courseID, "error", err)
+				return
+			}
+			if totalModules > 0 {
+				progress.ProgressPercentage = passedModules * 100 / totalModules
 			}
+			completed = totalModules > 0 && passedModules == totalModules
+			if completed && progress.CompletedAt == nil {
+				now := time.Now()
+				progress.CompletedAt = &now
+			}
+		}
 
-			_ = s.repo.UpdateUserProgress(progress)
+		err = s.repo.UpdateUserProgress(ctx, progress)
+		if errors.Is(err, ErrProgressConflict) {
+			continue
+		}
+		if err != nil {
+			slog.Error("failed to update progress", "user_id", userID, "course_id", courseID, "error", err)
+			return
+		}
+		if completed {
+			s.issueCertificate(ctx, userID, courseID)
 		}
+		return
 	}
 
-	return completion, nil
+	slog.Error("progress kept changing concurrently, skipping progress update",
+		"user_id", userID, "course_id", courseID, "attempts", maxProgressAttempts)
+}
+
+// replaySubmission returns the completion userID stored under idempotencyKey,
+// or ErrSubmissionNotFound when the key has not been used yet
+func (s *Service) replaySubmission(ctx context.Context, userID, exerciseID, idempotencyKey string) (*ModuleCompletion, error) {
+	existing, err := s.repo.GetSubmissionByIdempotencyKey(ctx, userID, idempotencyKey)
+	if errors.Is(err, ErrSubmissionNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up submission: %w", err)
+	}
+	if existing.ExerciseID != exerciseID {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	existing.Replayed = true
+	return existing, nil
 }
 
 // executeTestCase runs a test case against submitted code
@@ -310,41 +945,73 @@ func (s *Service) executeTestCase(code, language string, testCase TestCase, solu
 	return result
 }
 
-// RequestReview triggers AI Senior Review
-func (s *Service) RequestReview(submissionID string) (*ArchitectureReview, error) {
-	// 1. Fetch submission
-	// Note: We'd need a GetSubmissionByID method in repository
-	// For now, we'll create a placeholder review
+// RequestReview triggers AI Senior Review in the requesting user's locale
+func (s *Service) RequestReview(ctx context.Context, userID, submissionID string) (*ArchitectureReview, error) {
+	// 1. Fetch submission; only its author or an admin may have it reviewed
+	submission, err := s.repo.GetSubmissionByID(ctx, submissionID)
+	if err != nil {
+		if !errors.Is(err, ErrSubmissionNotFound) {
+			slog.Error("failed to fetch submission",
+				"request_id", requestctx.RequestID(ctx),
+				"submission_id", submissionID,
+				"error", err,
+			)
+		}
+		return nil, err
+	}
+	if err := middleware.AuthorizeOwnerOrAdmin(ctx, submission.UserID); err != nil {
+		return nil, err
+	}
 
 	if s.aiClient == nil {
 		return nil, fmt.Errorf("AI client not configured")
 	}
 
-	// 2. Mock submission data (in real implementation, fetch from DB)
-	submittedCode := "// Code would be fetched from submission"
-	language := "go"
-	context := "Module 1: System fundamentals"
+	// 2. Review the submitted code in the context of its module
+	submittedCode := submission.SubmittedCode
+	language := submission.Language
+	reviewContext := fmt.Sprintf("Module %s, exercise %s", submission.ModuleID, submission.ExerciseID)
 
 	// 3. Call AI for review
-	aiReview, err := s.aiClient.ReviewCode(submittedCode, language, context)
+	locale, err := s.repo.GetUserLocale(ctx, userID)
 	if err != nil {
+		locale = ai.DefaultLocale
+	}
+
+	if err := s.aiBudget.Consume(ctx, userID, ai.CallReviewCode); err != nil {
+		return nil, err
+	}
+	aiReview, err := s.aiClient.ReviewCode(ctx, submittedCode, language, reviewContext, locale)
+	if err != nil {
+		slog.Error("code review failed",
+			"request_id", requestctx.RequestID(ctx),
+			"user_id", userID,
+			"submission_id", submissionID,
+			"error", err,
+		)
 		return nil, fmt.Errorf("failed to get AI review: %w", err)
 	}
 
 	// 4. Create architecture review record
 	review := &ArchitectureReview{
-		UserID:          "", // Would be from submission
-		ModuleID:        "", // Would be from submission
+		UserID:          userID,
+		ModuleID:        submission.ModuleID,
 		SubmissionID:    submissionID,
 		OverallScore:    aiReview.OverallScore,
 		CodeSenseScore:  aiReview.CodeSense,
 		EfficiencyScore: aiReview.Efficiency,
 		EdgeCasesScore:  aiReview.EdgeCases,
 		TasteScore:      aiReview.Taste,
-		Feedback:        aiReview.Feedback,
+		Feedback:        s.sanitizeFeedback(aiReview.Feedback),
 	}
+	s.sanitizeReviewSections(review, aiReview)
 
-	if err := s.repo.CreateArchitectureReview(review); err != nil {
+	if err := s.repo.CreateArchitectureReview(ctx, review); err != nil {
+		slog.Error("failed to save review",
+			"request_id", requestctx.RequestID(ctx),
+			"submission_id", submissionID,
+			"error", err,
+		)
 		return nil, fmt.Errorf("failed to save review: %w", err)
 	}
 
@@ -352,17 +1019,98 @@ func (s *Service) RequestReview(submissionID string) (*ArchitectureReview, error
 }
 
 // GetUserProgress retrieves learning progress
-func (s *Service) GetUserProgress(userID, courseID string) (*UserProgress, error) {
-	progress, err := s.repo.GetUserProgress(userID, courseID)
+func (s *Service) GetUserProgress(ctx context.Context, userID, courseID string) (*UserProgress, error) {
+	progress, err := s.repo.GetUserProgress(ctx, userID, courseID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get progress: %w", err)
 	}
 	return progress, nil
 }
 
-// GetUserCoursesInterface retrieves all courses as interface{} for social domain
-func (s *Service) GetUserCoursesInterface(userID string) ([]interface{}, error) {
-	courses, err := s.GetUserCourses(userID)
+// GetCourseProgress derives the user's progress in a course from the modules they
+// have passed, rather than the stored percentage, along with each module's status
+func (s *Service) GetCourseProgress(ctx context.Context, userID, courseID string) (*CourseProgress, error) {
+	modules, err := s.repo.GetModuleProgress(ctx, userID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get module progress: %w", err)
+	}
+
+	progress := &CourseProgress{
+		CourseID:     courseID,
+		TotalModules: len(modules),
+		Modules:      modules,
+	}
+	for i := range modules {
+		module := &modules[i]
+		switch {
+		case module.PassedSubmissions > 0:
+			module.Status = ModuleStatusCompleted
+			progress.CompletedModules++
+		case module.Submissions > 0:
+			module.Status = ModuleStatusInProgress
+		default:
+			module.Status = ModuleStatusNotStarted
+		}
+	}
+
+	if progress.TotalModules > 0 {
+		progress.ProgressPercentage = progress.CompletedModules * 100 / progress.TotalModules
+		progress.Completed = progress.CompletedModules == progress.TotalModules
+	}
+	return progress, nil
+}
+
+// ErrInvalidTrendBucket is returned for unsupported skill trend bucket sizes
+var ErrInvalidTrendBucket = errors.New("bucket must be one of: day, week, month")
+
+// trendBucketDurations maps supported bucket sizes to their approximate length
+var trendBucketDurations = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+}
+
+// GetSkillTrend returns the user's average review scores per bucket over the last N periods
+func (s *Service) GetSkillTrend(ctx context.Context, userID, bucket string, periods int) ([]SkillTrendPoint, error) {
+	if bucket == "" {
+		bucket = "week"
+	}
+	duration, ok := trendBucketDurations[bucket]
+	if !ok {
+		return nil, ErrInvalidTrendBucket
+	}
+
+	if periods <= 0 {
+		periods = 12 // Default window
+	}
+	if periods > 104 {
+		periods = 104 // Max window
+	}
+
+	since := time.Now().Add(-time.Duration(periods) * duration)
+	points, err := s.repo.GetSkillTrend(ctx, userID, bucket, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get skill trend: %w", err)
+	}
+
+	return points, nil
+}
+
+// pendingReviewLimit caps how many unreviewed submissions are returned
+const pendingReviewLimit = 50
+
+// GetPendingReviewSubmissions lists passing submissions the user has not requested a review for
+func (s *Service) GetPendingReviewSubmissions(ctx context.Context, userID string) ([]PendingReviewSubmission, error) {
+	submissions, err := s.repo.GetPendingReviewSubmissions(ctx, userID, pendingReviewLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending review submissions: %w", err)
+	}
+	return submissions, nil
+}
+
+// GetUserCoursesInterface retrieves the user's most recent courses as interface{} for social domain
+func (s *Service) GetUserCoursesInterface(ctx context.Context, userID string) ([]interface{}, error) {
+	courses, _, err := s.GetUserCourses(ctx, userID, MaxCoursePageSize, 0, false)
 	if err != nil {
 		return nil, err
 	}
@@ -374,3 +1122,174 @@ func (s *Service) GetUserCoursesInterface(userID string) ([]interface{}, error)
 	}
 	return result, nil
 }
+
+// GetUserPoints returns the user's total exercise points across all courses
+func (s *Service) GetUserPoints(ctx context.Context, userID string) (int, error) {
+	return s.repo.GetUserPoints(ctx, userID)
+}
+
+// Learning goal periods
+const (
+	GoalPeriodDaily  = "daily"
+	GoalPeriodWeekly = "weekly"
+)
+
+// Upper bounds for goal targets; a week has 10080 minutes
+const (
+	MaxGoalExercises = 500
+	MaxGoalMinutes   = 7 * 24 * 60
+)
+
+// ErrInvalidGoal is returned when a learning goal fails validation
+var ErrInvalidGoal = errors.New("invalid learning goal")
+
+// validateLearningGoal checks the period and that at least one target is set within bounds
+func validateLearningGoal(goal LearningGoal) error {
+	if goal.Period != GoalPeriodDaily && goal.Period != GoalPeriodWeekly {
+		return fmt.Errorf("%w: period must be one of: %s, %s", ErrInvalidGoal, GoalPeriodDaily, GoalPeriodWeekly)
+	}
+	if goal.TargetExercises < 0 || goal.TargetExercises > MaxGoalExercises {
+		return fmt.Errorf("%w: target_exercises must be between 0 and %d", ErrInvalidGoal, MaxGoalExercises)
+	}
+	if goal.TargetMinutes < 0 || goal.TargetMinutes > MaxGoalMinutes {
+		return fmt.Errorf("%w: target_minutes must be between 0 and %d", ErrInvalidGoal, MaxGoalMinutes)
+	}
+	if goal.TargetExercises == 0 && goal.TargetMinutes == 0 {
+		return fmt.Errorf("%w: set target_exercises or target_minutes", ErrInvalidGoal)
+	}
+	return nil
+}
+
+// goalPeriodStart returns the start of the period containing now, in UTC.
+// Weeks start on Monday to match Postgres date_trunc('week').
+func goalPeriodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == GoalPeriodWeekly {
+		daysSinceMonday := (int(start.Weekday()) + 6) % 7
+		start = start.AddDate(0, 0, -daysSinceMonday)
+	}
+	return start
+}
+
+// goalRatio returns done/target as a percentage capped at 100; a zero target counts as met
+func goalRatio(done, target int) int {
+	if target <= 0 || done >= target {
+		return 100
+	}
+	return done * 100 / target
+}
+
+// SetLearningGoal stores the user's goal for a period and returns its current progress
+func (s *Service) SetLearningGoal(ctx context.Context, userID string, goal LearningGoal) (*GoalProgress, error) {
+	goal.Period = strings.ToLower(strings.TrimSpace(goal.Period))
+	if err := validateLearningGoal(goal); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpsertLearningGoal(ctx, userID, &goal); err != nil {
+		return nil, fmt.Errorf("failed to save learning goal: %w", err)
+	}
+
+	return s.goalProgress(ctx, userID, goal, time.Now())
+}
+
+// GetLearningGoals returns the user's goals with progress for the current periods
+func (s *Service) GetLearningGoals(ctx context.Context, userID string) ([]GoalProgress, error) {
+	goals, err := s.repo.GetLearningGoals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get learning goals: %w", err)
+	}
+
+	now := time.Now()
+	progress := make([]GoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		p, err := s.goalProgress(ctx, userID, goal, now)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, *p)
+	}
+
+	return progress, nil
+}
+
+// GetCourseJourney returns the user's progress in a course along with progress toward their goals
+func (s *Service) GetCourseJourney(ctx context.Context, userID, courseID string) (*CourseJourney, error) {
+	progress, err := s.GetUserProgress(ctx, userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	goals, err := s.GetLearningGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CourseJourney{UserProgress: progress, Goals: goals}, nil
+}
+
+// goalProgress computes progress toward goal from submissions in the period containing now
+func (s *Service) goalProgress(ctx context.Context, userID string, goal LearningGoal, now time.Time) (*GoalProgress, error) {
+	start := goalPeriodStart(goal.Period, now)
+
+	solved, seconds, err := s.repo.GetActivitySince(ctx, userID, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute goal progress: %w", err)
+	}
+
+	progress := &GoalProgress{
+		LearningGoal:    goal,
+		PeriodStart:     start,
+		ExercisesSolved: solved,
+		MinutesSpent:    seconds / 60,
+	}
+
+	// Every target that is set must be met, so the slowest one drives the percentage
+	progress.PercentComplete = min(
+		goalRatio(progress.ExercisesSolved, goal.TargetExercises),
+		goalRatio(progress.MinutesSpent, goal.TargetMinutes),
+	)
+	progress.Achieved = progress.PercentComplete == 100
+
+	return progress, nil
+}
+
+// newVerificationCode returns a random, human-readable certificate code (e.g. ABCD-EFGH-IJKL-MNOP)
+func newVerificationCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := base32.StdEncoding.EncodeToString(buf)
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16], nil
+}
+
+// issueCertificate records a certificate for a completed course. Completing the
+// course again keeps the original certificate. Failures are logged, not returned,
+// so they never fail the submission that completed the course.
+func (s *Service) issueCertificate(ctx context.Context, userID, courseID string) {
+	code, err := newVerificationCode()
+	if err != nil {
+		slog.Error("failed to generate certificate code", "user_id", userID, "course_id", courseID, "error", err)
+		return
+	}
+
+	issued, err := s.repo.IssueCertificate(ctx, userID, &Certificate{CourseID: courseID, VerificationCode: code})
+	if err != nil {
+		slog.Error("failed to issue certificate", "user_id", userID, "course_id", courseID, "error", err)
+		return
+	}
+	if issued {
+		slog.Info("certificate issued", "user_id", userID, "course_id", courseID)
+	}
+}
+
+// GetUserCertificates lists the certificates the user has earned
+func (s *Service) GetUserCertificates(ctx context.Context, userID string) ([]Certificate, error) {
+	certificates, err := s.repo.GetUserCertificates(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificates: %w", err)
+	}
+	return certificates, nil
+}
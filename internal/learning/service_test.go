@@ -0,0 +1,1532 @@
+package learning
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/platform/ai"
+	"backend/internal/platform/middleware"
+	"backend/tests/testutil"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockService returns a service backed by a sqlmock database and no AI client
+func newMockService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewService(NewRepository(db), nil), mock
+}
+
+// exerciseRows returns a single exercise row as returned by GetExerciseByID
+func exerciseRows(exerciseID, moduleID string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "module_id", "exercise_number", "title", "description", "language",
+		"starter_code", "solution_code", "test_cases", "difficulty", "points", "hints", "created_at",
+	}).AddRow(
+		exerciseID, moduleID, 1, "Sum", "Add two numbers", "go",
+		"", "func sum(a, b int) int { return a + b }", []byte(`[{"input": [1, 2], "expected_output": 3}]`),
+		"easy", 10, []byte(`[]`), time.Now(),
+	)
+}
+
+func TestSubmitExercise_IncrementsAttempts(t *testing.T) {
+	service, mock := newMockService(t)
+	userID, exerciseID := "user-1", "exercise-1"
+
+	var completion *ModuleCompletion
+	for prior := 0; prior < 3; prior++ {
+		mock.ExpectQuery(`FROM exercises`).
+			WithArgs(exerciseID).
+			WillReturnRows(exerciseRows(exerciseID, "module-1"))
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+			WithArgs(userID, exerciseID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(prior))
+		expectHintsUsed(mock, userID, exerciseID, 0)
+		mock.ExpectExec(`INSERT INTO module_completions`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+			WithArgs("module-1").
+			WillReturnError(sql.ErrNoRows)
+
+		var err error
+		completion, err = service.SubmitExercise(context.Background(), userID, exerciseID, "x", "go", 0, "")
+		require.NoError(t, err)
+		assert.Equal(t, prior+1, completion.Attempts)
+	}
+
+	assert.Equal(t, 3, completion.Attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// progressRows returns a single user_progress row as returned by GetUserProgress
+// progressVersion is the updated_at of rows returned by progressRows
+var progressVersion = time.Date(2025, 1, 20, 14, 45, 0, 0, time.UTC)
+
+func progressRows(userID, courseID string, percentage, seconds int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "user_id", "course_id", "current_module_id", "progress_percentage",
+		"time_spent_minutes", "time_spent_seconds", "last_activity", "started_at", "completed_at", "updated_at",
+	}).AddRow("progress-1", userID, courseID, "module-1", percentage, seconds/60, seconds, time.Now(), time.Now(), nil, progressVersion)
+}
+
+func TestSubmitExercise_AccumulatesTimeSpent(t *testing.T) {
+	service, mock := newMockService(t)
+	userID, exerciseID, courseID := "user-1", "exercise-1", "course-1"
+
+	submissions := []struct {
+		reported int
+		stored   int
+		total    int
+	}{
+		{reported: 120, stored: 120, total: 120},
+		{reported: 300, stored: 300, total: 420},
+		{reported: 2 * MaxTimeSpentSeconds, stored: MaxTimeSpentSeconds, total: 420 + MaxTimeSpentSeconds},
+	}
+
+	previousTotal := 0
+	for i, sub := range submissions {
+		mock.ExpectQuery(`FROM exercises`).
+			WithArgs(exerciseID).
+			WillReturnRows(exerciseRows(exerciseID, "module-1"))
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+			WithArgs(userID, exerciseID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(i))
+		expectHintsUsed(mock, userID, exerciseID, 0)
+		mock.ExpectExec(`INSERT INTO module_completions`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+			WithArgs("module-1").
+			WillReturnRows(sqlmock.NewRows([]string{"course_id"}).AddRow(courseID))
+
+		if i == 0 {
+			mock.ExpectQuery(`FROM user_progress`).
+				WithArgs(userID, courseID).
+				WillReturnError(sql.ErrNoRows)
+		} else {
+			mock.ExpectQuery(`FROM user_progress`).
+				WithArgs(userID, courseID).
+				WillReturnRows(progressRows(userID, courseID, 0, previousTotal))
+		}
+
+		// Failing submissions still accumulate time but leave the percentage alone
+		if i == 0 {
+			mock.ExpectExec(`INSERT INTO user_progress`).
+				WithArgs(sqlmock.AnyArg(), userID, courseID, "module-1", 0, sub.total/60, sub.total,
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		} else {
+			mock.ExpectExec(`UPDATE user_progress`).
+				WithArgs("module-1", 0, sub.total/60, sub.total, sqlmock.AnyArg(), sqlmock.AnyArg(), userID, courseID, progressVersion).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		completion, err := service.SubmitExercise(context.Background(), userID, exerciseID, "x", "go", sub.reported, "")
+		require.NoError(t, err)
+		assert.Equal(t, sub.stored, completion.TimeSpentSeconds)
+
+		previousTotal = sub.total
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_ProgressLoadErrorLeavesProgressUntouched(t *testing.T) {
+	service, mock := newMockService(t)
+	userID, exerciseID, courseID := "user-1", "exercise-1", "course-1"
+
+	mock.ExpectQuery(`FROM exercises`).
+		WithArgs(exerciseID).
+		WillReturnRows(exerciseRows(exerciseID, "module-1"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+		WithArgs(userID, exerciseID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	expectHintsUsed(mock, userID, exerciseID, 0)
+	mock.ExpectExec(`INSERT INTO module_completions`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+		WithArgs("module-1").
+		WillReturnRows(sqlmock.NewRows([]string{"course_id"}).AddRow(courseID))
+	mock.ExpectQuery(`FROM user_progress`).
+		WithArgs(userID, courseID).
+		WillReturnError(sql.ErrConnDone)
+
+	// No UPDATE is expected: a transient error must not overwrite the stored row
+	completion, err := service.SubmitExercise(context.Background(), userID, exerciseID, "x", "go", 60, "")
+	require.NoError(t, err)
+	assert.Equal(t, 60, completion.TimeSpentSeconds)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_RejectsNegativeTimeSpent(t *testing.T) {
+	service, mock := newMockService(t)
+
+	_, err := service.SubmitExercise(context.Background(), "user-1", "exercise-1", "x", "go", -1, "")
+
+	assert.ErrorIs(t, err, ErrNegativeTimeSpent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSkillTrend(t *testing.T) {
+	service, mock := newMockService(t)
+	bucketStart := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`date_trunc\(\$2, reviewed_at\)`).
+		WithArgs("user-1", "month", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"bucket_start", "review_count", "code_sense", "efficiency", "edge_cases", "taste", "overall",
+		}).AddRow(bucketStart, 2, 7.5, 5.0, 6.0, 8.25, 6.5))
+
+	points, err := service.GetSkillTrend(context.Background(), "user-1", "month", 6)
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, bucketStart, points[0].BucketStart)
+	assert.Equal(t, 2, points[0].ReviewCount)
+	assert.Equal(t, 7.5, points[0].CodeSense)
+	assert.Equal(t, 8.25, points[0].Taste)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetSkillTrend_InvalidBucket(t *testing.T) {
+	service, mock := newMockService(t)
+
+	_, err := service.GetSkillTrend(context.Background(), "user-1", "hour", 0)
+
+	assert.ErrorIs(t, err, ErrInvalidTrendBucket)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// blueprintColumns are the columns returned by GetBlueprintModules
+var blueprintColumns = []string{
+	"id", "module_number", "title_template", "description_template", "difficulty",
+	"estimated_hours", "learning_objectives", "variable_schema", "created_at", "updated_at",
+}
+
+// expectCourseInsert expects the course insert followed by n module inserts in one transaction
+func expectCourseInsert(mock sqlmock.Sqlmock, modules int) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO generated_courses`).WillReturnResult(sqlmock.NewResult(1, 1))
+	prep := mock.ExpectPrepare(`INSERT INTO generated_modules`)
+	for i := 0; i < modules; i++ {
+		prep.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestGenerateCourse_UsesStoredBlueprints(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(
+		sqlmock.NewRows(blueprintColumns).
+			AddRow("bp-1", 1, "Modeling the {ENTITY}", "Define the {ENTITY}", "beginner", 2, nil, nil, time.Now(), time.Now()).
+			AddRow("bp-2", 2, "Testing the {ENTITY}", "Verify the {ENTITY}", "beginner", 3, nil, nil, time.Now(), time.Now()),
+	)
+	expectCourseInsert(mock, 2)
+
+	course, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Modeling the Order", course.Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateCourse_FallsBackToAICurriculum(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	aiClient, err := ai.New(ai.ProviderStub, "", "")
+	require.NoError(t, err)
+	service := NewService(NewRepository(db), aiClient)
+
+	curriculum, err := aiClient.GenerateCurriculum(context.Background(), "archetype-1", "Order", &ai.Variables{Entity: "Order"}, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, curriculum.Modules)
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(sqlmock.NewRows(blueprintColumns))
+	mock.ExpectQuery(`SELECT locale FROM users`).WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+	expectCourseInsert(mock, len(curriculum.Modules))
+
+	course, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+	require.NoError(t, err)
+
+	assert.Equal(t, curriculum.Modules[0].Title, course.Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateCourse_FailsWithoutBlueprintsOrAI(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(sqlmock.NewRows(blueprintColumns))
+
+	_, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+
+	// No course is created when the migration 006 seed is missing
+	assert.ErrorIs(t, err, ErrNoBlueprintModules)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateCourse_UnlocksLowestModuleNumber(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(
+		sqlmock.NewRows(blueprintColumns).
+			AddRow("bp-3", 3, "Scaling the {ENTITY}", "", "intermediate", 4, nil, nil, time.Now(), time.Now()).
+			AddRow("bp-4", 4, "Deciding for the {ENTITY}", "", "intermediate", 4, nil, nil, time.Now(), time.Now()),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO generated_courses`).WillReturnResult(sqlmock.NewResult(1, 1))
+	prep := mock.ExpectPrepare(`INSERT INTO generated_modules`)
+	for _, status := range []string{"active", "locked"} {
+		prep.ExpectExec().
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), status, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	_, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateCourse_RollsBackCourseWhenModulesFail(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(
+		sqlmock.NewRows(blueprintColumns).
+			AddRow("bp-1", 1, "Modeling the {ENTITY}", "", "beginner", 2, nil, nil, time.Now(), time.Now()).
+			AddRow("bp-2", 2, "Testing the {ENTITY}", "", "beginner", 3, nil, nil, time.Now(), time.Now()),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO generated_courses`).WillReturnResult(sqlmock.NewResult(1, 1))
+	prep := mock.ExpectPrepare(`INSERT INTO generated_modules`)
+	prep.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	prep.ExpectExec().WillReturnError(errors.New("insert failed"))
+	mock.ExpectRollback()
+
+	course, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+	require.Error(t, err)
+	assert.Nil(t, course)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateCourse_StopsWhenRequestCancelled(t *testing.T) {
+	service, mock := newMockService(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := service.GenerateCourse(ctx, "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+
+	// The blueprint query is never sent once the request is gone
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPendingReviewSubmissionsHandler(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	mock.ExpectQuery(`LEFT JOIN architecture_reviews ar ON ar.submission_id = mc.id.*ar.id IS NULL`).
+		WithArgs("user-1", pendingReviewLimit).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "module_id", "exercise_id", "title", "language", "score", "submitted_at",
+		}).AddRow("submission-1", "module-1", nil, "", "go", 100, time.Now()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/submissions/pending-review", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	handler.GetPendingReviewSubmissions(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Data []PendingReviewSubmission `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "submission-1", body.Data[0].SubmissionID)
+	assert.Empty(t, body.Data[0].ExerciseID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCoursesHandler_Paginates(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	mock.ExpectQuery(`FROM generated_courses\s+WHERE user_id = \$1 AND deleted_at IS NULL\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$2 OFFSET \$3`).
+		WithArgs("user-1", 2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "archetype_id", "title", "description", "meta_category",
+			"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+		}).
+			AddRow("course-5", "user-1", "archetype-1", "Five", "", "Digital", []byte(`{}`), "active", "ai", time.Now(), time.Now(), nil).
+			AddRow("course-6", "user-1", "archetype-1", "Six", "", "Digital", []byte(`{}`), "active", "ai", time.Now(), time.Now(), nil))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM generated_courses WHERE user_id = \$1 AND deleted_at IS NULL`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(9))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/courses?limit=2&offset=4", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	handler.GetCourses(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Data   []GeneratedCourse `json:"data"`
+		Total  int               `json:"total"`
+		Limit  int               `json:"limit"`
+		Offset int               `json:"offset"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Len(t, body.Data, 2)
+	assert.Equal(t, 9, body.Total)
+	assert.Equal(t, 2, body.Limit)
+	assert.Equal(t, 4, body.Offset)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCoursesHandler_InvalidParams(t *testing.T) {
+	service, _ := newMockService(t)
+	handler := NewHandler(service)
+
+	for _, query := range []string{"limit=abc", "limit=-1", "offset=-5"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/courses?"+query, nil)
+		req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+		rr := httptest.NewRecorder()
+
+		handler.GetCourses(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestGetProgressHandler_IncludesGoalProgress(t *testing.T) {
+	service, mock := newMockService(t)
+	owner := &middleware.UserClaims{UserID: "user-1"}
+
+	mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+		WithArgs("course-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "archetype_id", "title", "description", "meta_category",
+			"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+		}).AddRow("course-1", "user-1", "archetype-1", "Course", "", "Digital", []byte(`{}`), "active", "ai", time.Now(), time.Now(), nil))
+	mock.ExpectQuery(`FROM user_progress`).
+		WithArgs("user-1", "course-1").
+		WillReturnRows(progressRows("user-1", "course-1", 30, 1800))
+	mock.ExpectQuery(`FROM learning_goals`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"period", "target_exercises", "target_minutes", "updated_at"}).
+			AddRow(GoalPeriodWeekly, 4, 60, time.Now()))
+	// Seeded activity this week: 3 exercises solved in 45 minutes
+	mock.ExpectQuery(`FROM module_completions\s+WHERE user_id = \$1 AND submitted_at >= \$2`).
+		WithArgs("user-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"solved", "seconds"}).AddRow(3, 45*60))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).GetProgress(rr, requestAs(http.MethodGet, "/api/courses/course-1/progress", owner, map[string]string{"id": "course-1"}, ""))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		Data struct {
+			ProgressPercentage int
+			Goals              []GoalProgress `json:"goals"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, 30, body.Data.ProgressPercentage)
+	require.Len(t, body.Data.Goals, 1)
+	goal := body.Data.Goals[0]
+	assert.Equal(t, 3, goal.ExercisesSolved)
+	assert.Equal(t, 45, goal.MinutesSpent)
+	assert.Equal(t, 75, goal.PercentComplete)
+	assert.False(t, goal.Achieved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGoalPeriodStart(t *testing.T) {
+	thursday := time.Date(2024, 3, 7, 15, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), goalPeriodStart(GoalPeriodDaily, thursday))
+	assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), goalPeriodStart(GoalPeriodWeekly, thursday))
+
+	sunday := time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), goalPeriodStart(GoalPeriodWeekly, sunday))
+}
+
+func TestSetLearningGoal_ReportsProgress(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`INSERT INTO learning_goals`).
+		WithArgs("user-1", GoalPeriodDaily, 2, 30).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectQuery(`FROM module_completions\s+WHERE user_id = \$1 AND submitted_at >= \$2`).
+		WithArgs("user-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"solved", "seconds"}).AddRow(3, 900))
+
+	progress, err := service.SetLearningGoal(context.Background(), "user-1", LearningGoal{Period: " Daily ", TargetExercises: 2, TargetMinutes: 30})
+	require.NoError(t, err)
+
+	assert.Equal(t, GoalPeriodDaily, progress.Period)
+	assert.Equal(t, 3, progress.ExercisesSolved)
+	assert.Equal(t, 15, progress.MinutesSpent)
+	assert.Equal(t, 50, progress.PercentComplete, "minutes target lags behind")
+	assert.False(t, progress.Achieved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetLearningGoalHandler_RejectsInvalidGoal(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	bodies := []string{
+		`{"period": "monthly", "target_exercises": 5}`,
+		`{"period": "weekly"}`,
+		`{"period": "weekly", "target_exercises": -1}`,
+		`{"period": "daily", "target_minutes": 100000}`,
+	}
+	for _, body := range bodies {
+		req := httptest.NewRequest(http.MethodPut, "/api/users/me/goals", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+		rr := httptest.NewRecorder()
+
+		handler.SetLearningGoal(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_RetriesProgressUpdateAfterConcurrentWrite(t *testing.T) {
+	service, mock := newMockService(t)
+	userID, exerciseID, courseID := "user-1", "exercise-1", "course-1"
+	concurrentVersion := progressVersion.Add(time.Second)
+
+	mock.ExpectQuery(`FROM exercises`).
+		WithArgs(exerciseID).
+		WillReturnRows(exerciseRows(exerciseID, "module-1"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+		WithArgs(userID, exerciseID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	expectHintsUsed(mock, userID, exerciseID, 0)
+	mock.ExpectExec(`INSERT INTO module_completions`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+		WithArgs("module-1").
+		WillReturnRows(sqlmock.NewRows([]string{"course_id"}).AddRow(courseID))
+
+	// Another submission saved 60 seconds after this one read the progress
+	mock.ExpectQuery(`FROM user_progress`).
+		WithArgs(userID, courseID).
+		WillReturnRows(progressRows(userID, courseID, 0, 0))
+	mock.ExpectExec(`UPDATE user_progress`).
+		WithArgs("module-1", 0, 2, 120, sqlmock.AnyArg(), sqlmock.AnyArg(), userID, courseID, progressVersion).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// The update is applied again on top of the other submission's time
+	concurrent := sqlmock.NewRows([]string{
+		"id", "user_id", "course_id", "current_module_id", "progress_percentage",
+		"time_spent_minutes", "time_spent_seconds", "last_activity", "started_at", "completed_at", "updated_at",
+	}).AddRow("progress-1", userID, courseID, "module-1", 0, 1, 60, time.Now(), time.Now(), nil, concurrentVersion)
+	mock.ExpectQuery(`FROM user_progress`).
+		WithArgs(userID, courseID).
+		WillReturnRows(concurrent)
+	mock.ExpectExec(`UPDATE user_progress`).
+		WithArgs("module-1", 0, 3, 180, sqlmock.AnyArg(), sqlmock.AnyArg(), userID, courseID, concurrentVersion).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := service.SubmitExercise(context.Background(), userID, exerciseID, "x", "go", 120, "")
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_IssuesCertificateOnCompletion(t *testing.T) {
+	service, mock := newMockService(t)
+	userID, exerciseID, courseID := "user-1", "exercise-1", "course-1"
+	code := "func sum(a, b int) int { return a + b }"
+
+	expectPassingSubmission := func(percentage, passedModules, totalModules, wantPercentage int) {
+		mock.ExpectQuery(`FROM exercises`).
+			WithArgs(exerciseID).
+			WillReturnRows(exerciseRows(exerciseID, "module-1"))
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+			WithArgs(userID, exerciseID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		expectHintsUsed(mock, userID, exerciseID, 0)
+		mock.ExpectExec(`INSERT INTO module_completions`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+			WithArgs("module-1").
+			WillReturnRows(sqlmock.NewRows([]string{"course_id"}).AddRow(courseID))
+		mock.ExpectQuery(`FROM user_progress`).
+			WithArgs(userID, courseID).
+			WillReturnRows(progressRows(userID, courseID, percentage, 0))
+		mock.ExpectQuery(`FROM generated_modules gm\s+WHERE gm.course_id = \$2`).
+			WithArgs(userID, courseID).
+			WillReturnRows(sqlmock.NewRows([]string{"passed", "total"}).AddRow(passedModules, totalModules))
+		mock.ExpectExec(`UPDATE user_progress`).
+			WithArgs("module-1", wantPercentage, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), userID, courseID, progressVersion).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	// Passing the same module again does not move progress or issue a certificate
+	expectPassingSubmission(14, 1, 7, 14)
+
+	_, err := service.SubmitExercise(context.Background(), userID, exerciseID, code, "go", 0, "")
+	require.NoError(t, err)
+
+	// The pass that completes the last module issues the certificate
+	expectPassingSubmission(85, 7, 7, 100)
+	mock.ExpectQuery(`INSERT INTO certificates .* ON CONFLICT \(user_id, course_id\) DO NOTHING`).
+		WithArgs(sqlmock.AnyArg(), userID, courseID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"issued_at"}).AddRow(time.Now()))
+
+	_, err = service.SubmitExercise(context.Background(), userID, exerciseID, code, "go", 0, "")
+	require.NoError(t, err)
+
+	// Passing again after completion hits the unique constraint and issues nothing new
+	expectPassingSubmission(100, 7, 7, 100)
+	mock.ExpectQuery(`INSERT INTO certificates`).
+		WithArgs(sqlmock.AnyArg(), userID, courseID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"issued_at"}))
+
+	_, err = service.SubmitExercise(context.Background(), userID, exerciseID, code, "go", 0, "")
+	require.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewVerificationCode(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		code, err := newVerificationCode()
+		require.NoError(t, err)
+		assert.Regexp(t, `^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`, code)
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+	}
+}
+
+// requestAs builds a request for the given user with mux route variables set
+func requestAs(method, target string, claims *middleware.UserClaims, vars map[string]string, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), claims))
+	return mux.SetURLVars(req, vars)
+}
+
+func TestResourceHandlers_RequireOwnerOrAdmin(t *testing.T) {
+	stranger := &middleware.UserClaims{UserID: "user-2"}
+	exerciseOwner := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT gc.user_id\s+FROM exercises e`).
+			WithArgs("exercise-1").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("owner-1"))
+	}
+
+	t.Run("GetExercise", func(t *testing.T) {
+		service, mock := newMockService(t)
+		exerciseOwner(mock)
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetExercise(rr, requestAs(http.MethodGet, "/api/exercises/exercise-1", stranger, map[string]string{"id": "exercise-1"}, ""))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SubmitExercise", func(t *testing.T) {
+		service, mock := newMockService(t)
+		exerciseOwner(mock)
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).SubmitExercise(rr, requestAs(http.MethodPost, "/api/exercises/exercise-1/submit", stranger,
+			map[string]string{"id": "exercise-1"}, `{"code": "func sum() {}", "language": "go"}`))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetProgress", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+			WithArgs("course-1").
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "user_id", "archetype_id", "title", "description", "meta_category",
+				"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+			}).AddRow("course-1", "owner-1", "archetype-1", "Course", "", "Digital", []byte(`{}`), "active", "ai", time.Now(), time.Now(), nil))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetProgress(rr, requestAs(http.MethodGet, "/api/courses/course-1/progress", stranger, map[string]string{"id": "course-1"}, ""))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RequestReview", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM module_completions\s+WHERE id = \$1`).
+			WithArgs("submission-1").
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "user_id", "module_id", "exercise_id", "submitted_code", "language", "passed", "score", "submitted_at",
+			}).AddRow("submission-1", "owner-1", "module-1", "exercise-1", "code", "go", true, 100, time.Now()))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).RequestReview(rr, requestAs(http.MethodPost, "/api/submissions/submission-1/review", stranger, map[string]string{"id": "submission-1"}, ""))
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("admin reads any exercise", func(t *testing.T) {
+		service, mock := newMockService(t)
+		exerciseOwner(mock)
+		mock.ExpectQuery(`FROM exercises\s+WHERE id = \$1`).
+			WithArgs("exercise-1").
+			WillReturnRows(exerciseRows("exercise-1", "module-1"))
+
+		admin := &middleware.UserClaims{UserID: "admin-1", IsAdmin: true}
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetExercise(rr, requestAs(http.MethodGet, "/api/exercises/exercise-1", admin, map[string]string{"id": "exercise-1"}, ""))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetExerciseHandler_HidesAnswersFromLearners(t *testing.T) {
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{
+			"id", "module_id", "exercise_number", "title", "description", "language",
+			"starter_code", "solution_code", "test_cases", "difficulty", "points", "hints", "created_at",
+		}).AddRow(
+			"exercise-1", "module-1", 1, "Sum", "Add two numbers", "go",
+			"", "func sum(a, b int) int { return a + b }",
+			[]byte(`[{"input": [1, 2], "expected_output": 3}, {"input": [5, 5], "expected_output": 10, "is_hidden": true}]`),
+			"easy", 10, []byte(`[]`), time.Now(),
+		)
+	}
+
+	tests := []struct {
+		name          string
+		claims        *middleware.UserClaims
+		wantSolution  bool
+		wantTestCases int
+	}{
+		{"learner", &middleware.UserClaims{UserID: "owner-1"}, false, 1},
+		{"admin", &middleware.UserClaims{UserID: "admin-1", IsAdmin: true}, true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			mock.ExpectQuery(`SELECT gc.user_id\s+FROM exercises e`).
+				WithArgs("exercise-1").
+				WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("owner-1"))
+			mock.ExpectQuery(`FROM exercises\s+WHERE id = \$1`).
+				WithArgs("exercise-1").
+				WillReturnRows(rows())
+
+			rr := httptest.NewRecorder()
+			NewHandler(service).GetExercise(rr, requestAs(http.MethodGet, "/api/exercises/exercise-1", tt.claims, map[string]string{"id": "exercise-1"}, ""))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body struct {
+				Data struct {
+					SolutionCode string
+					TestCases    []map[string]interface{}
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantSolution, body.Data.SolutionCode != "")
+			assert.Len(t, body.Data.TestCases, tt.wantTestCases)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRequestReview_UnknownSubmission(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`FROM module_completions\s+WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).RequestReview(rr, requestAs(http.MethodPost, "/api/submissions/missing/review",
+		&middleware.UserClaims{UserID: "user-1"}, map[string]string{"id": "missing"}, ""))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetModuleHandler(t *testing.T) {
+	moduleRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{
+			"id", "course_id", "blueprint_module_id", "module_number", "title",
+			"description", "content", "status", "unlocked_at", "created_at",
+		}).AddRow("module-1", "course-1", nil, 1, "Basics", "Intro", []byte(`{"sections": []}`), "unlocked", time.Now(), time.Now())
+	}
+	courseRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{
+			"id", "user_id", "archetype_id", "title", "description", "meta_category",
+			"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+		}).AddRow("course-1", "owner-1", "archetype-1", "Course", "", "Digital", []byte(`{}`), "active", "ai", time.Now(), time.Now(), nil)
+	}
+	get := func(service *Service, claims *middleware.UserClaims, moduleID string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetModule(rr, requestAs(http.MethodGet, "/api/modules/"+moduleID, claims, map[string]string{"id": moduleID}, ""))
+		return rr
+	}
+
+	t.Run("owner gets module and exercises", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_modules\s+WHERE id = \$1`).
+			WithArgs("module-1").
+			WillReturnRows(moduleRows())
+		mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows())
+		mock.ExpectQuery(`FROM exercises\s+WHERE module_id = \$1`).
+			WithArgs("module-1").
+			WillReturnRows(exerciseRows("exercise-1", "module-1"))
+
+		rr := get(service, &middleware.UserClaims{UserID: "owner-1"}, "module-1")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var body struct {
+			Data struct {
+				Module    GeneratedModule `json:"module"`
+				Exercises []Exercise      `json:"exercises"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, "module-1", body.Data.Module.ID)
+		require.Len(t, body.Data.Exercises, 1)
+		assert.Empty(t, body.Data.Exercises[0].SolutionCode)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("other users are forbidden", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_modules\s+WHERE id = \$1`).
+			WithArgs("module-1").
+			WillReturnRows(moduleRows())
+		mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows())
+
+		rr := get(service, &middleware.UserClaims{UserID: "user-2"}, "module-1")
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown module", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_modules\s+WHERE id = \$1`).
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		rr := get(service, &middleware.UserClaims{UserID: "owner-1"}, "missing")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetCourseExercisesHandler(t *testing.T) {
+	// Two modules: the first with a visible and a hidden test case, the second with no exercises yet
+	courseExerciseRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{
+			"id", "module_number", "title", "status",
+			"exercise_id", "exercise_number", "exercise_title", "description", "language",
+			"starter_code", "solution_code", "test_cases", "difficulty", "points", "hints", "created_at",
+		}).
+			AddRow("module-1", 1, "Basics", "active",
+				"exercise-1", 1, "Sum", "Add two numbers", "go", "", "func sum(a, b int) int { return a + b }",
+				[]byte(`[{"input": [1, 2], "expected_output": 3}, {"input": [2, 2], "expected_output": 4, "is_hidden": true}]`),
+				"easy", 10, []byte(`["Use +"]`), time.Now()).
+			AddRow("module-1", 1, "Basics", "active",
+				"exercise-2", 2, "Product", "Multiply two numbers", "go", "", "func product(a, b int) int { return a * b }",
+				[]byte(`[]`), "easy", 10, nil, time.Now()).
+			AddRow("module-2", 2, "State", "locked",
+				nil, 0, "", "", "", "", "", nil, "", 0, nil, nil)
+	}
+	get := func(service *Service, claims *middleware.UserClaims) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetCourseExercises(rr, requestAs(http.MethodGet, "/api/courses/course-1/exercises", claims, map[string]string{"id": "course-1"}, ""))
+		return rr
+	}
+	type response struct {
+		Data struct {
+			CourseID string            `json:"course_id"`
+			Modules  []ModuleExercises `json:"modules"`
+		} `json:"data"`
+	}
+
+	t.Run("owner gets sanitized exercises grouped by module in one query", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", nil))
+		mock.ExpectQuery(`FROM generated_modules gm\s+LEFT JOIN exercises e ON e.module_id = gm.id\s+WHERE gm.course_id = \$1`).
+			WithArgs("course-1").
+			WillReturnRows(courseExerciseRows())
+
+		rr := get(service, &middleware.UserClaims{UserID: "owner-1"})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var body response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, "course-1", body.Data.CourseID)
+		require.Len(t, body.Data.Modules, 2)
+
+		first := body.Data.Modules[0]
+		assert.Equal(t, "module-1", first.ModuleID)
+		require.Len(t, first.Exercises, 2)
+		assert.Equal(t, []string{"exercise-1", "exercise-2"}, []string{first.Exercises[0].ID, first.Exercises[1].ID})
+		assert.Equal(t, "module-1", first.Exercises[0].ModuleID)
+		assert.Empty(t, first.Exercises[0].SolutionCode)
+		assert.Len(t, first.Exercises[0].TestCases, 1, "hidden test cases are withheld")
+		assert.Equal(t, 1, first.Exercises[0].HintCount)
+
+		assert.Equal(t, "module-2", body.Data.Modules[1].ModuleID)
+		assert.NotNil(t, body.Data.Modules[1].Exercises)
+		assert.Empty(t, body.Data.Modules[1].Exercises)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("admins see solutions", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", nil))
+		mock.ExpectQuery(`LEFT JOIN exercises`).
+			WithArgs("course-1").
+			WillReturnRows(courseExerciseRows())
+
+		rr := get(service, &middleware.UserClaims{UserID: "admin-1", IsAdmin: true})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var body response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.NotEmpty(t, body.Data.Modules[0].Exercises[0].SolutionCode)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("other users are forbidden", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", nil))
+
+		rr := get(service, &middleware.UserClaims{UserID: "user-2"})
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet(), "exercises are not loaded")
+	})
+
+	t.Run("unknown course", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+			WithArgs("course-1").
+			WillReturnError(sql.ErrNoRows)
+
+		rr := get(service, &middleware.UserClaims{UserID: "owner-1"})
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetCoursesHandler_Search(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	mock.ExpectQuery(`FROM generated_courses\s+WHERE user_id = \$1.*ILIKE.*LIMIT \$6 OFFSET \$7`).
+		WithArgs("user-1", `%50\%\_off%`, "Economic", "active", `50\%\_off%`, DefaultCoursePageSize, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "archetype_id", "title", "description", "meta_category",
+			"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+		}).AddRow("course-1", "user-1", "archetype-1", "50%_off pricing", "", "Economic", []byte(`{}`), "active", "ai", time.Now(), time.Now(), nil))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM generated_courses\s+WHERE user_id = \$1`).
+		WithArgs("user-1", `%50\%\_off%`, "Economic", "active").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/courses?q=+50%25_off+&category=Economic&status=active", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	handler.GetCourses(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Data  []GeneratedCourse `json:"data"`
+		Total int               `json:"total"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "course-1", body.Data[0].ID)
+	assert.Equal(t, 1, body.Total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCoursesHandler_InvalidSearch(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	for _, query := range []string{"category=Culinary", "status=deleted", "q=" + strings.Repeat("a", MaxCourseSearchLength+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/api/courses?"+query, nil)
+		req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+		rr := httptest.NewRecorder()
+
+		handler.GetCourses(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// submissionByKeyRows returns a stored submission as returned by GetSubmissionByIdempotencyKey
+func submissionByKeyRows(userID, exerciseID string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "user_id", "module_id", "exercise_id", "submitted_code", "language",
+		"test_results", "passed", "score", "attempts", "hints_used", "time_spent_minutes",
+		"time_spent_seconds", "submitted_at", "points_earned",
+	}).AddRow(
+		"submission-1", userID, "module-1", exerciseID, "x", "go",
+		[]byte(`[{"test_case": {"input": [1, 2], "expected_output": 3}, "actual_output": 3, "passed": true, "execution_time_ms": 100}]`),
+		true, 100, 1, 0, 1, 90, time.Now(), 10,
+	)
+}
+
+func TestSubmitExercise_ReplaysIdempotencyKey(t *testing.T) {
+	service, mock := newMockService(t)
+	userID, exerciseID := "user-1", "exercise-1"
+
+	mock.ExpectQuery(`FROM module_completions\s+WHERE user_id = \$1 AND idempotency_key = \$2`).
+		WithArgs(userID, "key-1").
+		WillReturnRows(submissionByKeyRows(userID, exerciseID))
+
+	completion, err := service.SubmitExercise(context.Background(), userID, exerciseID, "x", "go", 0, "key-1")
+	require.NoError(t, err)
+	assert.True(t, completion.Replayed)
+	assert.Equal(t, "submission-1", completion.ID)
+	assert.Equal(t, 1, completion.Attempts)
+	assert.Equal(t, 90, completion.TimeSpentSeconds)
+	require.Len(t, completion.TestResults, 1)
+	// No exercise lookup, attempt count, insert or progress update
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_StoresNewIdempotencyKey(t *testing.T) {
+	service, mock := newMockService(t)
+	userID, exerciseID := "user-1", "exercise-1"
+
+	mock.ExpectQuery(`WHERE user_id = \$1 AND idempotency_key = \$2`).
+		WithArgs(userID, "key-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`FROM exercises`).
+		WithArgs(exerciseID).
+		WillReturnRows(exerciseRows(exerciseID, "module-1"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+		WithArgs(userID, exerciseID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	expectHintsUsed(mock, userID, exerciseID, 0)
+	mock.ExpectExec(`INSERT INTO module_completions`).
+		WithArgs(sqlmock.AnyArg(), userID, "module-1", exerciseID, "x", "go",
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 0, 0, 0, sqlmock.AnyArg(),
+			sql.NullString{String: "key-1", Valid: true}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+		WithArgs("module-1").
+		WillReturnError(sql.ErrNoRows)
+
+	completion, err := service.SubmitExercise(context.Background(), userID, exerciseID, "x", "go", 0, "key-1")
+	require.NoError(t, err)
+	assert.False(t, completion.Replayed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_ConcurrentDuplicateIsReplayed(t *testing.T) {
+	service, mock := newMockService(t)
+	userID, exerciseID := "user-1", "exercise-1"
+
+	mock.ExpectQuery(`WHERE user_id = \$1 AND idempotency_key = \$2`).
+		WithArgs(userID, "key-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`FROM exercises`).
+		WithArgs(exerciseID).
+		WillReturnRows(exerciseRows(exerciseID, "module-1"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+		WithArgs(userID, exerciseID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	expectHintsUsed(mock, userID, exerciseID, 0)
+	// The other request inserted first, so ON CONFLICT skips this row
+	mock.ExpectExec(`INSERT INTO module_completions`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`WHERE user_id = \$1 AND idempotency_key = \$2`).
+		WithArgs(userID, "key-1").
+		WillReturnRows(submissionByKeyRows(userID, exerciseID))
+
+	completion, err := service.SubmitExercise(context.Background(), userID, exerciseID, "x", "go", 0, "key-1")
+	require.NoError(t, err)
+	assert.True(t, completion.Replayed)
+	assert.Equal(t, "submission-1", completion.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_RejectsBadIdempotencyKeys(t *testing.T) {
+	t.Run("reused for another exercise", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`WHERE user_id = \$1 AND idempotency_key = \$2`).
+			WithArgs("user-1", "key-1").
+			WillReturnRows(submissionByKeyRows("user-1", "exercise-2"))
+
+		_, err := service.SubmitExercise(context.Background(), "user-1", "exercise-1", "x", "go", 0, "key-1")
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	for name, key := range map[string]string{
+		"too long":      strings.Repeat("k", MaxIdempotencyKeyLength+1),
+		"control chars": "key\n1",
+		"non-ascii":     "clé",
+	} {
+		t.Run(name, func(t *testing.T) {
+			service, mock := newMockService(t)
+
+			_, err := service.SubmitExercise(context.Background(), "user-1", "exercise-1", "x", "go", 0, key)
+			assert.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSubmitExerciseHandler_MarksReplays(t *testing.T) {
+	owner := &middleware.UserClaims{UserID: "user-1"}
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`SELECT gc.user_id\s+FROM exercises e`).
+		WithArgs("exercise-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1"))
+	mock.ExpectQuery(`WHERE user_id = \$1 AND idempotency_key = \$2`).
+		WithArgs("user-1", "key-1").
+		WillReturnRows(submissionByKeyRows("user-1", "exercise-1"))
+
+	req := requestAs(http.MethodPost, "/api/exercises/exercise-1/submit", owner,
+		map[string]string{"id": "exercise-1"}, `{"code": "x", "language": "go"}`)
+	req.Header.Set("Idempotency-Key", "key-1")
+	rr := httptest.NewRecorder()
+	NewHandler(service).SubmitExercise(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+
+	var resp struct {
+		Data struct {
+			ID string `json:"ID"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "submission-1", resp.Data.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCourseProgressHandler_DerivesPercentageFromModules(t *testing.T) {
+	service, mock := newMockService(t)
+	owner := &middleware.UserClaims{UserID: "owner-1"}
+	mock.ExpectQuery(`FROM generated_courses\s+WHERE id = \$1`).
+		WithArgs("course-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "archetype_id", "title", "description", "meta_category",
+			"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+		}).AddRow("course-1", "owner-1", "archetype-1", "Course", "", "Digital", []byte(`{}`), "active", "ai", time.Now(), time.Now(), nil))
+	// The stored user_progress percentage is not read
+	mock.ExpectQuery(`FROM generated_modules gm\s+LEFT JOIN module_completions mc`).
+		WithArgs("owner-1", "course-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "module_number", "title", "count", "count", "max"}).
+			AddRow("module-1", 1, "Modeling", 3, 1, time.Now()).
+			AddRow("module-2", 2, "State", 2, 0, time.Now()).
+			AddRow("module-3", 3, "Flow", 0, 0, nil))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).GetCourseProgress(rr, requestAs(http.MethodGet, "/api/courses/course-1/progress/modules", owner, map[string]string{"id": "course-1"}, ""))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Data CourseProgress `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	progress := body.Data
+	assert.Equal(t, 1, progress.CompletedModules)
+	assert.Equal(t, 3, progress.TotalModules)
+	assert.Equal(t, 33, progress.ProgressPercentage)
+	assert.False(t, progress.Completed)
+	require.Len(t, progress.Modules, 3)
+	assert.Equal(t, ModuleStatusCompleted, progress.Modules[0].Status)
+	assert.Equal(t, ModuleStatusInProgress, progress.Modules[1].Status)
+	assert.Equal(t, ModuleStatusNotStarted, progress.Modules[2].Status)
+	assert.Nil(t, progress.Modules[2].LastSubmittedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCourseProgress_WithoutModules(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`FROM generated_modules gm`).
+		WithArgs("user-1", "course-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "module_number", "title", "count", "count", "max"}))
+
+	progress, err := service.GetCourseProgress(context.Background(), "user-1", "course-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, progress.ProgressPercentage)
+	assert.False(t, progress.Completed, "a course without modules is not complete")
+	assert.NotNil(t, progress.Modules)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExerciseHandler_RejectsUnexpectedBodies(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"array in a small body", `{"code": "x", "language": "go", "extra": [` + strings.Repeat("0,", 99_999) + `0]}`, http.StatusRequestEntityTooLarge},
+		{"unknown field", `{"code": "x", "language": "go", "passed": true}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			rr := httptest.NewRecorder()
+
+			NewHandler(service).SubmitExercise(rr, requestAs(http.MethodPost, "/api/exercises/exercise-1/submit",
+				&middleware.UserClaims{UserID: "user-1"}, map[string]string{"id": "exercise-1"}, tt.body))
+
+			assert.Equal(t, tt.wantStatus, rr.Code)
+			assert.NoError(t, mock.ExpectationsWereMet(), "rejected before the exercise is looked up")
+		})
+	}
+}
+
+// courseRows returns one course as selected with courseColumns, soft-deleted when deletedAt is set
+func courseRows(courseID, ownerID string, deletedAt *time.Time) *sqlmock.Rows {
+	var deleted interface{}
+	if deletedAt != nil {
+		deleted = *deletedAt
+	}
+	return sqlmock.NewRows([]string{
+		"id", "user_id", "archetype_id", "title", "description", "meta_category",
+		"injected_variables", "status", "generated_by", "created_at", "updated_at", "deleted_at",
+	}).AddRow(courseID, ownerID, "archetype-1", "Course", "", "Digital", []byte(`{}`), "active", "ai", time.Now(), time.Now(), deleted)
+}
+
+func TestGetCoursesHandler_IncludeDeletedIsAdminOnly(t *testing.T) {
+	t.Run("admin", func(t *testing.T) {
+		service, mock := newMockService(t)
+		deletedAt := time.Now()
+
+		// No deleted_at filter on either query
+		mock.ExpectQuery(`FROM generated_courses\s+WHERE user_id = \$1\s+ORDER BY`).
+			WithArgs("admin-1", DefaultCoursePageSize, 0).
+			WillReturnRows(courseRows("course-1", "admin-1", &deletedAt))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM generated_courses WHERE user_id = \$1$`).
+			WithArgs("admin-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetCourses(rr, requestAs(http.MethodGet, "/api/courses?include_deleted=true",
+			&middleware.UserClaims{UserID: "admin-1", IsAdmin: true}, nil, ""))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var body struct {
+			Data []GeneratedCourse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		require.Len(t, body.Data, 1)
+		assert.NotNil(t, body.Data[0].DeletedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	for _, query := range []string{"include_deleted=true", "include_deleted=maybe"} {
+		t.Run(query, func(t *testing.T) {
+			service, mock := newMockService(t)
+			rr := httptest.NewRecorder()
+
+			NewHandler(service).GetCourses(rr, requestAs(http.MethodGet, "/api/courses?"+query,
+				&middleware.UserClaims{UserID: "user-1"}, nil, ""))
+
+			if query == "include_deleted=true" {
+				assert.Equal(t, http.StatusForbidden, rr.Code)
+			} else {
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetCourseDetailsHandler_HidesDeletedCourses(t *testing.T) {
+	owner := &middleware.UserClaims{UserID: "owner-1"}
+	admin := &middleware.UserClaims{UserID: "admin-1", IsAdmin: true}
+	vars := map[string]string{"id": "course-1"}
+
+	t.Run("deleted course is not found", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_courses WHERE id = \$1 AND deleted_at IS NULL`).
+			WithArgs("course-1").
+			WillReturnError(sql.ErrNoRows)
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetCourseDetails(rr, requestAs(http.MethodGet, "/api/courses/course-1", owner, vars, ""))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("admin includes deleted", func(t *testing.T) {
+		service, mock := newMockService(t)
+		deletedAt := time.Now()
+		mock.ExpectQuery(`FROM generated_courses WHERE id = \$1$`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", &deletedAt))
+		mock.ExpectQuery(`FROM generated_modules`).
+			WithArgs("course-1").
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "course_id", "blueprint_module_id", "module_number", "title",
+				"description", "content", "status", "unlocked_at", "created_at",
+			}))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetCourseDetails(rr, requestAs(http.MethodGet, "/api/courses/course-1?include_deleted=true", admin, vars, ""))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"deleted_at"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteAndRestoreCourseHandlers(t *testing.T) {
+	owner := &middleware.UserClaims{UserID: "owner-1"}
+	vars := map[string]string{"id": "course-1"}
+
+	t.Run("delete marks the course deleted", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_courses WHERE id = \$1 AND deleted_at IS NULL`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", nil))
+		mock.ExpectExec(`UPDATE generated_courses SET deleted_at = \$1, updated_at = \$1 WHERE id = \$2 AND deleted_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), "course-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).DeleteCourse(rr, requestAs(http.MethodDelete, "/api/courses/course-1", owner, vars, ""))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("restore clears the mark", func(t *testing.T) {
+		service, mock := newMockService(t)
+		deletedAt := time.Now()
+		mock.ExpectQuery(`FROM generated_courses WHERE id = \$1$`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", &deletedAt))
+		mock.ExpectExec(`UPDATE generated_courses SET deleted_at = NULL, updated_at = \$1 WHERE id = \$2 AND deleted_at IS NOT NULL`).
+			WithArgs(sqlmock.AnyArg(), "course-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`FROM generated_courses WHERE id = \$1 AND deleted_at IS NULL`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", nil))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).RestoreCourse(rr, requestAs(http.MethodPost, "/api/courses/course-1/restore", owner, vars, ""))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), `"deleted_at"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("restoring a live course conflicts", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM generated_courses WHERE id = \$1$`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", nil))
+		mock.ExpectExec(`UPDATE generated_courses SET deleted_at = NULL`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).RestoreCourse(rr, requestAs(http.MethodPost, "/api/courses/course-1/restore", owner, vars, ""))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("only the owner restores", func(t *testing.T) {
+		service, mock := newMockService(t)
+		deletedAt := time.Now()
+		mock.ExpectQuery(`FROM generated_courses WHERE id = \$1$`).
+			WithArgs("course-1").
+			WillReturnRows(courseRows("course-1", "owner-1", &deletedAt))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).RestoreCourse(rr, requestAs(http.MethodPost, "/api/courses/course-1/restore",
+			&middleware.UserClaims{UserID: "user-2"}, vars, ""))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRequestReview_StoresStructuredSections(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	aiClient, err := ai.New(ai.ProviderStub, "", "")
+	require.NoError(t, err)
+	service := NewService(NewRepository(db), aiClient)
+
+	mock.ExpectQuery(`FROM module_completions\s+WHERE id = \$1`).
+		WithArgs("submission-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "module_id", "exercise_id", "submitted_code", "language", "passed", "score", "submitted_at",
+		}).AddRow("submission-1", "user-1", "module-1", "exercise-1", "package main", "go", true, 100, time.Now()))
+	mock.ExpectQuery(`SELECT locale FROM users`).WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+	mock.ExpectExec(`INSERT INTO architecture_reviews`).
+		WithArgs(sqlmock.AnyArg(), "user-1", "module-1", "submission-1", 70, 70, 70, 60, 80,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx := middleware.ContextWithUser(context.Background(), &middleware.UserClaims{UserID: "user-1"})
+	review, err := service.RequestReview(ctx, "user-1", "submission-1")
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, review.Summary)
+	assert.NotEmpty(t, review.Strengths)
+	assert.NotEmpty(t, review.Suggestions)
+	require.NotEmpty(t, review.Issues)
+	assert.Equal(t, ai.SeverityMedium, review.Issues[0].Severity)
+
+	// The flat per-category map is still stored for older clients
+	feedback, ok := review.Feedback.(map[string]string)
+	require.True(t, ok)
+	for _, category := range ai.ReviewCategories {
+		assert.NotEmpty(t, feedback[category])
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequestReviewHandler_StructuredSectionsUseSnakeCaseKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	aiClient, err := ai.New(ai.ProviderStub, "", "")
+	require.NoError(t, err)
+	service := NewService(NewRepository(db), aiClient)
+
+	mock.ExpectQuery(`FROM module_completions\s+WHERE id = \$1`).
+		WithArgs("submission-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "module_id", "exercise_id", "submitted_code", "language", "passed", "score", "submitted_at",
+		}).AddRow("submission-1", "user-1", "module-1", "exercise-1", "package main", "go", true, 100, time.Now()))
+	mock.ExpectQuery(`SELECT locale FROM users`).WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+	mock.ExpectExec(`INSERT INTO architecture_reviews`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/submissions/submission-1/review", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "submission-1"})
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	NewHandler(service).RequestReview(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	for _, key := range []string{"summary", "strengths", "issues", "suggestions"} {
+		assert.Contains(t, resp.Data, key)
+	}
+	assert.NotContains(t, resp.Data, "Summary")
+
+	var issues []map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Data["issues"], &issues))
+	require.NotEmpty(t, issues)
+	assert.Contains(t, issues[0], "severity")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequestReviewHandler_OverAIBudget(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	aiClient, err := ai.New(ai.ProviderStub, "", "")
+	require.NoError(t, err)
+	service := NewService(NewRepository(db), aiClient).WithAIBudget(ai.NewBudget(db, 20))
+
+	mock.ExpectQuery(`FROM module_completions\s+WHERE id = \$1`).
+		WithArgs("submission-1").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "user_id", "module_id", "exercise_id", "submitted_code", "language", "passed", "score", "submitted_at",
+		}).AddRow("submission-1", "user-1", "module-1", "exercise-1", "package main", "go", true, 100, time.Now()))
+	mock.ExpectQuery(`SELECT locale FROM users`).WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+	mock.ExpectQuery(`INSERT INTO ai_usage`).
+		WithArgs("user-1", sqlmock.AnyArg(), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"calls"}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/submissions/submission-1/review", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "submission-1"})
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	NewHandler(service).RequestReview(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Contains(t, rr.Body.String(), "daily AI usage limit reached")
+	// No review is stored when the AI is never called
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubmitExercise_RecordsSubmissionMetrics(t *testing.T) {
+	service, mock := newMockService(t)
+	recorder := &testutil.CountingMetrics{}
+	service.WithMetrics(recorder)
+
+	for _, code := range []string{"func sum(a, b int) int { return a + b }", "x"} {
+		mock.ExpectQuery(`FROM exercises`).
+			WithArgs("exercise-1").
+			WillReturnRows(exerciseRows("exercise-1", "module-1"))
+		mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM module_completions`).
+			WithArgs("user-1", "exercise-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		expectHintsUsed(mock, "user-1", "exercise-1", 0)
+		mock.ExpectExec(`INSERT INTO module_completions`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT course_id FROM generated_modules`).
+			WithArgs("module-1").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := service.SubmitExercise(context.Background(), "user-1", "exercise-1", code, "go", 0, "")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, recorder.PassedSubmissions)
+	assert.Equal(t, 1, recorder.FailedSubmissions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserPoints_CancelledContextSkipsQuery(t *testing.T) {
+	service, mock := newMockService(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := service.GetUserPoints(ctx, "user-1")
+	assert.True(t, errors.Is(err, context.Canceled), "got %v", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
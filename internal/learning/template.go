@@ -0,0 +1,64 @@
+package learning
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Course generation sources, stored in generated_courses.generated_by
+const (
+	CourseGeneratedByAI       = "ai"
+	CourseGeneratedByTemplate = "template" // Built from blueprints and variables while the AI provider was unavailable
+)
+
+// templateCourseDescription describes a course from its injected variables alone
+// Variables the user did not provide are left out rather than rendered empty.
+func templateCourseDescription(variables map[string]string) string {
+	description := fmt.Sprintf("Learn to build a %s system from first principles", variables["ENTITY"])
+
+	var focus []string
+	if state := variables["STATE"]; state != "" {
+		focus = append(focus, fmt.Sprintf("modeling its %s", state))
+	}
+	if flow := variables["FLOW"]; flow != "" {
+		focus = append(focus, fmt.Sprintf("driving the %s flow", flow))
+	}
+	if logic := variables["LOGIC"]; logic != "" {
+		focus = append(focus, fmt.Sprintf("enforcing %s", logic))
+	}
+	if iface := variables["INTERFACE"]; iface != "" {
+		focus = append(focus, fmt.Sprintf("exposing it through %s", iface))
+	}
+	if len(focus) == 0 {
+		return description
+	}
+
+	return description + ": " + strings.Join(focus, ", ")
+}
+
+// templateModuleContent builds module content deterministically from the
+// blueprint's learning objectives, falling back to generic lessons when the
+// blueprint has none
+func (s *Service) templateModuleContent(blueprint BlueprintModule, title string, variables map[string]string) map[string]interface{} {
+	var lessons []string
+	if objectives, ok := blueprint.LearningObjectives.([]interface{}); ok {
+		for _, objective := range objectives {
+			if text, ok := objective.(string); ok && text != "" {
+				lessons = append(lessons, s.injectVariables(text, variables))
+			}
+		}
+	}
+	if len(lessons) == 0 {
+		lessons = []string{
+			fmt.Sprintf("Introduction to %s", title),
+			fmt.Sprintf("Core concepts of %s", variables["ENTITY"]),
+			"Implementation patterns",
+		}
+	}
+
+	return map[string]interface{}{
+		"lessons":      lessons,
+		"exercises":    []string{},
+		"generated_by": CourseGeneratedByTemplate,
+	}
+}
@@ -0,0 +1,133 @@
+package learning
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// templateLessons matches module content whose template lessons equal want
+type templateLessons []string
+
+func (want templateLessons) Match(v driver.Value) bool {
+	raw, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	var content struct {
+		Lessons     []string `json:"lessons"`
+		GeneratedBy string   `json:"generated_by"`
+	}
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return false
+	}
+	if content.GeneratedBy != CourseGeneratedByTemplate || len(content.Lessons) != len(want) {
+		return false
+	}
+	for i := range want {
+		if content.Lessons[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTemplateCourseDescription(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		want      string
+	}{
+		{
+			name:      "entity only",
+			variables: map[string]string{"ENTITY": "Order"},
+			want:      "Learn to build a Order system from first principles",
+		},
+		{
+			name: "all variables",
+			variables: map[string]string{
+				"ENTITY": "Order", "STATE": "fulfilment status", "FLOW": "checkout",
+				"LOGIC": "pricing rules", "INTERFACE": "a REST API",
+			},
+			want: "Learn to build a Order system from first principles: modeling its fulfilment status, " +
+				"driving the checkout flow, enforcing pricing rules, exposing it through a REST API",
+		},
+		{
+			name:      "missing variables are skipped",
+			variables: map[string]string{"ENTITY": "Order", "FLOW": "checkout"},
+			want:      "Learn to build a Order system from first principles: driving the checkout flow",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, templateCourseDescription(tt.variables))
+		})
+	}
+}
+
+func TestGenerateCourse_UsesTemplateFallbackWithoutAI(t *testing.T) {
+	service, mock := newMockService(t)
+	variables := map[string]string{"ENTITY": "Order", "STATE": "status"}
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(
+		sqlmock.NewRows(blueprintColumns).
+			AddRow("bp-1", 1, "Modeling the {ENTITY}", "Define the {ENTITY}", "beginner", 2,
+				[]byte(`["Identify the {ENTITY}", "Track its {STATE}"]`), nil, time.Now(), time.Now()).
+			AddRow("bp-2", 2, "Testing the {ENTITY}", "Verify the {ENTITY}", "beginner", 3, nil, nil, time.Now(), time.Now()),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO generated_courses`).
+		WithArgs(sqlmock.AnyArg(), "user-1", "archetype-1", "Modeling the Order",
+			"Learn to build a Order system from first principles: modeling its status", "Digital",
+			sqlmock.AnyArg(), CourseStatusActive, CourseGeneratedByTemplate, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	prep := mock.ExpectPrepare(`INSERT INTO generated_modules`)
+	prep.ExpectExec().
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, sqlmock.AnyArg(), sqlmock.AnyArg(),
+			templateLessons{"Identify the Order", "Track its status"}, "active", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// Blueprints without objectives get generic lessons instead of empty content
+	prep.ExpectExec().
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 2, sqlmock.AnyArg(), sqlmock.AnyArg(),
+			templateLessons{"Introduction to Testing the Order", "Core concepts of Order", "Implementation patterns"},
+			"locked", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	course, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", variables)
+	require.NoError(t, err)
+
+	assert.Equal(t, CourseGeneratedByTemplate, course.GeneratedBy)
+	assert.Equal(t, "Modeling the Order", course.Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateCourse_FlagsAICourses(t *testing.T) {
+	service, mock := newStubAIService(t)
+
+	mock.ExpectQuery(`FROM blueprint_modules`).WillReturnRows(
+		sqlmock.NewRows(blueprintColumns).
+			AddRow("bp-1", 1, "Modeling the {ENTITY}", "", "beginner", 2, nil, nil, time.Now(), time.Now()),
+	)
+	mock.ExpectQuery(`SELECT locale FROM users`).WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("en"))
+	expectCourseInsert(mock, 1)
+
+	course, err := service.GenerateCourse(context.Background(), "user-1", "archetype-1", map[string]string{"ENTITY": "Order"})
+	require.NoError(t, err)
+
+	assert.Equal(t, CourseGeneratedByAI, course.GeneratedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGeneratedCourse_EncodesGeneratedBy(t *testing.T) {
+	encoded, err := json.Marshal(GeneratedCourse{GeneratedBy: CourseGeneratedByTemplate})
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"generated_by":"template"`)
+}
@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/jsonbody"
+	"backend/internal/platform/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for notifications
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new notifications handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes registers all notification routes
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/notifications/read", h.MarkRead).Methods("POST")
+}
+
+// SuccessResponse represents a success response
+type SuccessResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data"`
+}
+
+// MarkRead handles POST /api/notifications/read
+// The body is either {"ids": [...]} or {"all": true}.
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apierror.WriteError(w, apierror.Unauthorized("Unauthorized"))
+		return
+	}
+
+	var req MarkReadRequest
+	if err := jsonbody.Decode(r, &req); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	result, err := h.service.MarkRead(userID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMarkRead) {
+			apierror.WriteError(w, apierror.BadRequest(err.Error()))
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	jsonbody.Write(w, http.StatusOK, SuccessResponse{
+		Success: true,
+		Data:    result,
+	})
+}
@@ -0,0 +1,12 @@
+package notifications
+
+// MarkReadRequest selects which notifications to mark read: either the listed IDs or all of them
+type MarkReadRequest struct {
+	IDs []string `json:"ids,omitempty"`
+	All bool     `json:"all,omitempty"`
+}
+
+// MarkReadResult reports how many unread notifications were marked read
+type MarkReadResult struct {
+	Marked int64 `json:"marked"`
+}
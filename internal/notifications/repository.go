@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Repository handles notification data access
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new notifications repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// MarkRead marks the user's unread notifications among ids as read in one statement.
+// IDs that belong to other users are ignored, so ownership is enforced by the query.
+func (r *Repository) MarkRead(userID string, ids []string, at time.Time) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = $3
+		WHERE user_id = $1 AND id = ANY($2::uuid[]) AND read_at IS NULL
+	`
+	result, err := r.db.Exec(query, userID, pq.Array(ids), at)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// MarkAllRead marks every unread notification of the user as read
+func (r *Repository) MarkAllRead(userID string, at time.Time) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = $2
+		WHERE user_id = $1 AND read_at IS NULL
+	`
+	result, err := r.db.Exec(query, userID, at)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,54 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	"backend/tests/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkRead_OwnershipAndAll(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	owner := testutil.CreateUser(t, db, "Notified User")
+	other := testutil.CreateUser(t, db, "Notified User")
+
+	insert := func(userID string) string {
+		var id string
+		require.NoError(t, db.QueryRow(
+			`INSERT INTO notifications (user_id, type, title) VALUES ($1, 'test', 'Hello') RETURNING id`,
+			userID,
+		).Scan(&id))
+		return id
+	}
+	first, second, third := insert(owner), insert(owner), insert(owner)
+	foreign := insert(other)
+
+	isRead := func(id string) bool {
+		var read bool
+		require.NoError(t, db.QueryRow(`SELECT read_at IS NOT NULL FROM notifications WHERE id = $1`, id).Scan(&read))
+		return read
+	}
+
+	now := time.Now().UTC()
+
+	// A subset is marked; the other user's notification is left alone
+	marked, err := repo.MarkRead(owner, []string{first, second, foreign}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), marked)
+	assert.True(t, isRead(first))
+	assert.True(t, isRead(second))
+	assert.False(t, isRead(third))
+	assert.False(t, isRead(foreign), "another user's notification must not be marked")
+
+	// Marking all only touches what is still unread for this user
+	marked, err = repo.MarkAllRead(owner, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+	assert.True(t, isRead(third))
+	assert.False(t, isRead(foreign))
+}
@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxMarkReadIDs caps how many notification IDs one request may mark read
+const MaxMarkReadIDs = 500
+
+// ErrInvalidMarkRead is returned when a mark-read request is malformed
+var ErrInvalidMarkRead = errors.New("invalid mark read request")
+
+// Service handles notification business logic
+type Service struct {
+	repo *Repository
+	now  func() time.Time
+}
+
+// NewService creates a new notifications service
+func NewService(repo *Repository) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// MarkRead marks the selected notifications of userID as read.
+// Notifications owned by other users are never modified.
+func (s *Service) MarkRead(userID string, req MarkReadRequest) (*MarkReadResult, error) {
+	if req.All {
+		if len(req.IDs) > 0 {
+			return nil, fmt.Errorf("%w: provide either ids or all, not both", ErrInvalidMarkRead)
+		}
+		marked, err := s.repo.MarkAllRead(userID, s.now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark notifications read: %w", err)
+		}
+		return &MarkReadResult{Marked: marked}, nil
+	}
+
+	ids, err := normalizeIDs(req.IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	marked, err := s.repo.MarkRead(userID, ids, s.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return &MarkReadResult{Marked: marked}, nil
+}
+
+// normalizeIDs validates notification IDs and drops duplicates
+func normalizeIDs(ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: ids are required unless all is set", ErrInvalidMarkRead)
+	}
+	if len(ids) > MaxMarkReadIDs {
+		return nil, fmt.Errorf("%w: at most %d ids per request", ErrInvalidMarkRead, MaxMarkReadIDs)
+	}
+
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid id", ErrInvalidMarkRead, id)
+		}
+		key := parsed.String()
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+	return unique, nil
+}
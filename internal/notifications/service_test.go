@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedNow is the clock used by mock-backed services
+var fixedNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newMockService returns a service backed by a sqlmock database and a fixed clock
+func newMockService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	service := NewService(NewRepository(db))
+	service.now = func() time.Time { return fixedNow }
+	return service, mock
+}
+
+const (
+	notificationA = "11111111-1111-1111-1111-111111111111"
+	notificationB = "22222222-2222-2222-2222-222222222222"
+)
+
+func TestMarkRead_SubsetScopedToUser(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("WHERE user_id = $1 AND id = ANY($2::uuid[]) AND read_at IS NULL")).
+		WithArgs("user-1", pq.Array([]string{notificationA, notificationB}), fixedNow).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	result, err := service.MarkRead("user-1", MarkReadRequest{
+		IDs: []string{notificationA, strings.ToUpper(notificationB), notificationA},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Marked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkRead_All(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("WHERE user_id = $1 AND read_at IS NULL")).
+		WithArgs("user-1", fixedNow).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	result, err := service.MarkRead("user-1", MarkReadRequest{All: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), result.Marked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkRead_Validation(t *testing.T) {
+	service, mock := newMockService(t)
+
+	tooMany := make([]string, MaxMarkReadIDs+1)
+	for i := range tooMany {
+		tooMany[i] = notificationA
+	}
+
+	requests := []MarkReadRequest{
+		{},
+		{IDs: []string{"not-a-uuid"}},
+		{IDs: []string{notificationA}, All: true},
+		{IDs: tooMany},
+	}
+	for _, req := range requests {
+		_, err := service.MarkRead("user-1", req)
+		assert.ErrorIs(t, err, ErrInvalidMarkRead)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkReadHandler(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE notifications")).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	req := httptest.NewRequest("POST", "/api/notifications/read", strings.NewReader(`{"all": true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	handler.MarkRead(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"success": true, "data": {"marked": 3}}`, rr.Body.String())
+
+	req = httptest.NewRequest("POST", "/api/notifications/read", strings.NewReader(`{"ids": []}`))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr = httptest.NewRecorder()
+
+	handler.MarkRead(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
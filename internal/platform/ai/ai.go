@@ -2,12 +2,19 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"backend/internal/platform/metrics"
+	"backend/internal/platform/requestctx"
 )
 
 // Client wraps AI service clients (OpenAI, Anthropic, etc.)
@@ -17,10 +24,23 @@ type Client struct {
 	model      string
 	httpClient *http.Client
 	baseURL    string
+	options    map[CallType]CompletionOptions
+
+	// Tried in order when the primary model is unavailable (see WithFallbackModels)
+	fallbackModels []string
+
+	// Unix nanos of the last successful completion or ping, used by Ping
+	lastSuccess     atomic.Int64
+	healthFreshness time.Duration
 }
 
 // New creates a new AI client
+// The stub provider needs no API key and never makes network calls
 func New(provider, apiKey, model string) (*Client, error) {
+	if provider == ProviderStub {
+		return &Client{provider: ProviderStub, model: ProviderStub}, nil
+	}
+
 	if apiKey == "" {
 		return nil, fmt.Errorf("API key is required")
 	}
@@ -44,11 +64,16 @@ func New(provider, apiKey, model string) (*Client, error) {
 			Timeout: 60 * time.Second,
 		},
 		baseURL: baseURL,
+		options: DefaultCompletionOptions(),
 	}, nil
 }
 
 // ValidateDomain validates user domain input using LLM
-func (c *Client) ValidateDomain(domain string, metaCategory string) (*DomainValidation, error) {
+func (c *Client) ValidateDomain(ctx context.Context, domain string, metaCategory string) (*DomainValidation, error) {
+	if c.IsStub() {
+		return stubValidateDomain(domain, metaCategory), nil
+	}
+
 	prompt := fmt.Sprintf(`You are a domain validation expert. Determine if the following domain is valid and real for learning purposes.
 
 Domain: %s
@@ -65,7 +90,7 @@ Respond in JSON format:
   "reason": "explanation why it's valid or invalid"
 }`, domain, metaCategory)
 
-	response, err := c.complete(prompt)
+	response, err := c.complete(ctx, prompt, c.CompletionOptionsFor(CallValidateDomain))
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate domain: %w", err)
 	}
@@ -83,7 +108,11 @@ Respond in JSON format:
 }
 
 // ExtractVariables extracts the 5 universal variables from domain
-func (c *Client) ExtractVariables(domain string) (*Variables, error) {
+func (c *Client) ExtractVariables(ctx context.Context, domain string) (*Variables, error) {
+	if c.IsStub() {
+		return stubExtractVariables(domain), nil
+	}
+
 	prompt := fmt.Sprintf(`You are an expert at analyzing learning domains. Extract the 5 universal variables from this domain:
 
 Domain: %s
@@ -104,7 +133,7 @@ Respond in JSON format:
   "interface": "description"
 }`, domain)
 
-	response, err := c.complete(prompt)
+	response, err := c.complete(ctx, prompt, c.CompletionOptionsFor(CallExtractVariables))
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract variables: %w", err)
 	}
@@ -117,8 +146,12 @@ Respond in JSON format:
 	return &variables, nil
 }
 
-// GenerateCurriculum generates personalized curriculum
-func (c *Client) GenerateCurriculum(archetype, domain string, variables *Variables) (*Curriculum, error) {
+// GenerateCurriculum generates personalized curriculum in the learner's locale
+func (c *Client) GenerateCurriculum(ctx context.Context, archetype, domain string, variables *Variables, locale string) (*Curriculum, error) {
+	if c.IsStub() {
+		return stubGenerateCurriculum(domain, variables), nil
+	}
+
 	prompt := fmt.Sprintf(`You are an expert curriculum designer. Create a personalized learning curriculum.
 
 Learner Archetype: %s
@@ -132,6 +165,8 @@ Variables:
 
 Create a structured curriculum with 5-8 modules. Each module should build on previous ones.
 
+%s
+
 Respond in JSON format:
 {
   "title": "curriculum title",
@@ -143,9 +178,9 @@ Respond in JSON format:
       "description": "what will be learned"
     }
   ]
-}`, archetype, domain, variables.Entity, variables.State, variables.Flow, variables.Logic, variables.Interface)
+}`, archetype, domain, variables.Entity, variables.State, variables.Flow, variables.Logic, variables.Interface, localeInstruction(locale))
 
-	response, err := c.complete(prompt)
+	response, err := c.complete(ctx, prompt, c.CompletionOptionsFor(CallGenerateCurriculum))
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate curriculum: %w", err)
 	}
@@ -158,8 +193,12 @@ Respond in JSON format:
 	return &curriculum, nil
 }
 
-// ReviewCode performs AI Senior Review on submitted code
-func (c *Client) ReviewCode(code, language, context string) (*ArchitectureReview, error) {
+// ReviewCode performs AI Senior Review on submitted code, writing feedback in the learner's locale
+func (c *Client) ReviewCode(ctx context.Context, code, language, reviewContext, locale string) (*ArchitectureReview, error) {
+	if c.IsStub() {
+		return stubReviewCode(code, language), nil
+	}
+
 	prompt := fmt.Sprintf(`You are a senior software architect. Review this code submission.
 
 Language: %s
@@ -168,27 +207,39 @@ Context: %s
 Code:
 %s
 
-Score the following categories from 1-10:
+Score the following categories from 0-100:
 1. CODE SENSE - Code quality, readability, structure
 2. EFFICIENCY - Performance, optimization, resource usage
 3. EDGE CASES - Error handling, boundary conditions
 4. TASTE - Design patterns, best practices, elegance
 
+Also give a short summary of the submission, what it does well, the issues you
+found and concrete suggestions for improving it. Tag each issue with the category
+it falls under and a severity of "low", "medium" or "high".
+
+%s
+
 Respond in JSON format:
 {
-  "code_sense": 8,
-  "efficiency": 7,
-  "edge_cases": 6,
-  "taste": 9,
+  "code_sense": 80,
+  "efficiency": 70,
+  "edge_cases": 60,
+  "taste": 90,
+  "summary": "one or two sentence overview",
+  "strengths": ["what the code does well"],
+  "issues": [
+    {"category": "edge_cases", "severity": "high", "description": "what is wrong and why it matters"}
+  ],
+  "suggestions": ["a concrete improvement"],
   "feedback": {
     "code_sense": "detailed feedback",
     "efficiency": "detailed feedback",
     "edge_cases": "detailed feedback",
     "taste": "detailed feedback"
   }
-}`, language, context, code)
+}`, language, reviewContext, code, localeInstruction(locale))
 
-	response, err := c.complete(prompt)
+	response, err := c.complete(ctx, prompt, c.CompletionOptionsFor(CallReviewCode))
 	if err != nil {
 		return nil, fmt.Errorf("failed to review code: %w", err)
 	}
@@ -200,22 +251,128 @@ Respond in JSON format:
 
 	// Calculate overall score
 	review.OverallScore = (review.CodeSense + review.Efficiency + review.EdgeCases + review.Taste) / 4
+	review.normalize()
 
 	return &review, nil
 }
 
-// complete sends a completion request to the AI API
-func (c *Client) complete(prompt string) (string, error) {
+// DefaultLocale is used when a user has no language preference
+const DefaultLocale = "en"
+
+// localeNames maps common language tags to the name used in prompts
+var localeNames = map[string]string{
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"id": "Indonesian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"vi": "Vietnamese",
+	"zh": "Chinese",
+}
+
+// localeTagRegex matches simple BCP 47 tags such as "en", "pt-BR" or "zh-Hant"
+var localeTagRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})?$`)
+
+// NormalizeLocale returns a canonical language tag, falling back to DefaultLocale
+// when the input is empty or malformed
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(strings.ReplaceAll(locale, "_", "-"))
+	if !localeTagRegex.MatchString(locale) {
+		return DefaultLocale
+	}
+
+	parts := strings.SplitN(locale, "-", 2)
+	parts[0] = strings.ToLower(parts[0])
+	if len(parts) == 2 && len(parts[1]) == 2 {
+		parts[1] = strings.ToUpper(parts[1])
+	}
+	return strings.Join(parts, "-")
+}
+
+// IsValidLocale reports whether the tag is a well-formed language tag
+func IsValidLocale(locale string) bool {
+	return localeTagRegex.MatchString(strings.TrimSpace(strings.ReplaceAll(locale, "_", "-")))
+}
+
+// localeInstruction tells the model which language to write human-readable text in
+func localeInstruction(locale string) string {
+	locale = NormalizeLocale(locale)
+	base := strings.SplitN(locale, "-", 2)[0]
+
+	name, ok := localeNames[base]
+	if !ok {
+		name = locale
+	} else if base != locale {
+		name = fmt.Sprintf("%s (%s)", name, locale)
+	}
+
+	return fmt.Sprintf("Write all human-readable text (titles, descriptions, feedback) in %s. Keep JSON keys exactly as shown.", name)
+}
+
+// complete sends a completion request to the AI API, moving down the fallback
+// chain while the requested model is unavailable. Every attempt is timed and
+// counted in ai_requests_total under the model that handled it.
+func (c *Client) complete(ctx context.Context, prompt string, opts CompletionOptions) (string, error) {
+	models := c.models()
+	requestID := requestctx.RequestID(ctx)
+
+	var err error
+	for i, model := range models {
+		var content string
+		start := time.Now()
+		content, err = c.completeWithModel(ctx, model, prompt, opts)
+		metrics.RecordAIRequest(c.provider, model, time.Since(start), err == nil)
+		if err == nil {
+			if i > 0 {
+				slog.Info("ai_fallback_served",
+					"request_id", requestID,
+					"provider", c.provider,
+					"model", model,
+					"primary_model", c.model,
+					"attempts", i+1,
+				)
+			}
+			return content, nil
+		}
+		if !isModelUnavailable(err) || i == len(models)-1 || ctx.Err() != nil {
+			break
+		}
+
+		slog.Warn("ai_model_unavailable",
+			"request_id", requestID,
+			"provider", c.provider,
+			"model", model,
+			"next_model", models[i+1],
+			"error", err,
+		)
+	}
+	return "", err
+}
+
+// completeWithModel sends one completion request for model
+// The request ID from ctx is forwarded as X-Request-ID and attached to the call's log line
+func (c *Client) completeWithModel(ctx context.Context, model, prompt string, opts CompletionOptions) (string, error) {
 	requestBody := map[string]interface{}{
-		"model": c.model,
+		"model": model,
 		"messages": []map[string]string{
 			{
 				"role":    "user",
 				"content": prompt,
 			},
 		},
-		"temperature": 0.7,
-		"max_tokens":  2000,
+		"temperature": opts.Temperature,
+		"max_tokens":  opts.MaxTokens,
 	}
 
 	jsonData, err := json.Marshal(requestBody)
@@ -223,7 +380,7 @@ func (c *Client) complete(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -231,20 +388,42 @@ func (c *Client) complete(prompt string) (string, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
+	requestID := requestctx.RequestID(ctx)
+	if requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		slog.Error("ai_request_failed",
+			"request_id", requestID,
+			"provider", c.provider,
+			"model", model,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"error", err,
+		)
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	slog.Info("ai_request",
+		"request_id", requestID,
+		"provider", c.provider,
+		"model", model,
+		"status", resp.StatusCode,
+		"duration_ms", time.Since(start).Milliseconds(),
+	)
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return "", &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
+	c.markSuccess()
 
 	var result struct {
 		Choices []struct {
@@ -252,12 +431,15 @@ func (c *Client) complete(prompt string) (string, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage tokenUsage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
 		return "", fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	metrics.RecordAITokens(c.provider, model, result.Usage.prompt(), result.Usage.completion())
+
 	if len(result.Choices) == 0 {
 		return "", fmt.Errorf("no response from AI")
 	}
@@ -265,6 +447,31 @@ func (c *Client) complete(prompt string) (string, error) {
 	return result.Choices[0].Message.Content, nil
 }
 
+// tokenUsage is the usage block of a completion response. OpenAI and OpenRouter
+// report prompt/completion tokens; Anthropic reports input/output tokens.
+type tokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+}
+
+// prompt returns the prompt token count in whichever form the provider reported
+func (u tokenUsage) prompt() int {
+	if u.PromptTokens > 0 {
+		return u.PromptTokens
+	}
+	return u.InputTokens
+}
+
+// completion returns the completion token count in whichever form the provider reported
+func (u tokenUsage) completion() int {
+	if u.CompletionTokens > 0 {
+		return u.CompletionTokens
+	}
+	return u.OutputTokens
+}
+
 // DomainValidation represents domain validation result
 type DomainValidation struct {
 	IsValid bool   `json:"is_valid"`
@@ -294,12 +501,93 @@ type Module struct {
 	Description string `json:"description"`
 }
 
+// Issue severities reported by code reviews
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// ReviewCategories are the scored review categories, also the keys of ArchitectureReview.Feedback
+var ReviewCategories = []string{"code_sense", "efficiency", "edge_cases", "taste"}
+
+// ReviewIssue is one problem found by a code review
+type ReviewIssue struct {
+	Category    string `json:"category,omitempty"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
 // ArchitectureReview represents AI code review
+// Feedback is the flat per-category critique older clients read; it is kept
+// populated alongside the structured Summary, Strengths, Issues and Suggestions.
 type ArchitectureReview struct {
 	OverallScore int               `json:"overall_score"`
 	CodeSense    int               `json:"code_sense"`
 	Efficiency   int               `json:"efficiency"`
 	EdgeCases    int               `json:"edge_cases"`
 	Taste        int               `json:"taste"`
+	Summary      string            `json:"summary"`
+	Strengths    []string          `json:"strengths"`
+	Issues       []ReviewIssue     `json:"issues"`
+	Suggestions  []string          `json:"suggestions"`
 	Feedback     map[string]string `json:"feedback"`
 }
+
+// normalize cleans up the structured parts of a parsed review: blank entries are
+// dropped, severities outside low/medium/high become medium, and feedback the
+// model left out is filled in from the issues of that category and the summary
+func (r *ArchitectureReview) normalize() {
+	r.Summary = strings.TrimSpace(r.Summary)
+	r.Strengths = nonBlank(r.Strengths)
+	r.Suggestions = nonBlank(r.Suggestions)
+
+	issues := make([]ReviewIssue, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		issue.Description = strings.TrimSpace(issue.Description)
+		if issue.Description == "" {
+			continue
+		}
+		issue.Category = strings.ToLower(strings.TrimSpace(issue.Category))
+		switch severity := strings.ToLower(strings.TrimSpace(issue.Severity)); severity {
+		case SeverityLow, SeverityMedium, SeverityHigh:
+			issue.Severity = severity
+		default:
+			issue.Severity = SeverityMedium
+		}
+		issues = append(issues, issue)
+	}
+	r.Issues = issues
+
+	if r.Feedback == nil {
+		r.Feedback = make(map[string]string, len(ReviewCategories)+1)
+	}
+	for _, category := range ReviewCategories {
+		if strings.TrimSpace(r.Feedback[category]) != "" {
+			continue
+		}
+		var found []string
+		for _, issue := range r.Issues {
+			if issue.Category == category {
+				found = append(found, issue.Description)
+			}
+		}
+		if len(found) > 0 {
+			r.Feedback[category] = strings.Join(found, " ")
+		}
+	}
+	if r.Summary != "" && r.Feedback["summary"] == "" {
+		r.Feedback["summary"] = r.Summary
+	}
+}
+
+// nonBlank returns the trimmed, non-empty entries of items, never nil
+func nonBlank(items []string) []string {
+	kept := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
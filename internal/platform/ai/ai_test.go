@@ -0,0 +1,357 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/platform/requestctx"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a client pointed at a fake completions API that records
+// the last prompt it received and answers with the given content
+func newTestClient(t *testing.T, content string) (*Client, *string) {
+	t.Helper()
+
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.NotEmpty(t, body.Messages)
+		prompt = body.Messages[0].Content
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": content}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := New("openai", "test-key", "test-model")
+	require.NoError(t, err)
+	client.baseURL = server.URL
+
+	return client, &prompt
+}
+
+func TestGenerateCurriculumLocale(t *testing.T) {
+	curriculum := `{"title": "Curso", "description": "Descripción", "modules": []}`
+	vars := &Variables{Entity: "Order", State: "Cart", Flow: "Checkout", Logic: "Pricing", Interface: "REST"}
+
+	t.Run("non-English user", func(t *testing.T) {
+		client, prompt := newTestClient(t, curriculum)
+
+		_, err := client.GenerateCurriculum(context.Background(), "builder", "e-commerce", vars, "es")
+		require.NoError(t, err)
+
+		assert.Contains(t, *prompt, "in Spanish")
+		assert.NotContains(t, *prompt, "in English")
+	})
+
+	t.Run("regional locale", func(t *testing.T) {
+		client, prompt := newTestClient(t, curriculum)
+
+		_, err := client.GenerateCurriculum(context.Background(), "builder", "e-commerce", vars, "pt_br")
+		require.NoError(t, err)
+
+		assert.Contains(t, *prompt, "in Portuguese (pt-BR)")
+	})
+
+	t.Run("defaults to English", func(t *testing.T) {
+		client, prompt := newTestClient(t, curriculum)
+
+		_, err := client.GenerateCurriculum(context.Background(), "builder", "e-commerce", vars, "")
+		require.NoError(t, err)
+
+		assert.Contains(t, *prompt, "in English")
+	})
+}
+
+func TestReviewCodeLocale(t *testing.T) {
+	review := `{"code_sense": 80, "efficiency": 70, "edge_cases": 60, "taste": 90, "feedback": {"code_sense": "Gut"}}`
+
+	t.Run("non-English user", func(t *testing.T) {
+		client, prompt := newTestClient(t, review)
+
+		result, err := client.ReviewCode(context.Background(), "package main", "go", "Module 1", "de")
+		require.NoError(t, err)
+
+		assert.Contains(t, *prompt, "in German")
+		assert.Equal(t, 75, result.OverallScore)
+	})
+
+	t.Run("invalid locale falls back to English", func(t *testing.T) {
+		client, prompt := newTestClient(t, review)
+
+		_, err := client.ReviewCode(context.Background(), "package main", "go", "Module 1", "not a locale!")
+		require.NoError(t, err)
+
+		assert.Contains(t, *prompt, "in English")
+	})
+}
+
+func TestReviewCodeStructuredSections(t *testing.T) {
+	review := `{
+		"code_sense": 80, "efficiency": 70, "edge_cases": 60, "taste": 90,
+		"summary": " Solid solution. ",
+		"strengths": ["Readable", "  "],
+		"issues": [
+			{"category": "Edge_Cases", "severity": "HIGH", "description": "Panics on empty input"},
+			{"category": "efficiency", "severity": "catastrophic", "description": "Quadratic loop"},
+			{"category": "taste", "severity": "low", "description": " "}
+		],
+		"suggestions": ["Guard against empty slices"],
+		"feedback": {"taste": "Idiomatic"}
+	}`
+	client, prompt := newTestClient(t, review)
+
+	result, err := client.ReviewCode(context.Background(), "package main", "go", "Module 1", "en")
+	require.NoError(t, err)
+
+	assert.Contains(t, *prompt, `"issues"`)
+	assert.Equal(t, "Solid solution.", result.Summary)
+	assert.Equal(t, []string{"Readable"}, result.Strengths)
+	assert.Equal(t, []string{"Guard against empty slices"}, result.Suggestions)
+	assert.Equal(t, []ReviewIssue{
+		{Category: "edge_cases", Severity: SeverityHigh, Description: "Panics on empty input"},
+		{Category: "efficiency", Severity: SeverityMedium, Description: "Quadratic loop"},
+	}, result.Issues)
+
+	// The legacy feedback map is kept, and filled in where the model left it out
+	assert.Equal(t, map[string]string{
+		"edge_cases": "Panics on empty input",
+		"efficiency": "Quadratic loop",
+		"taste":      "Idiomatic",
+		"summary":    "Solid solution.",
+	}, result.Feedback)
+}
+
+func TestReviewCodeLegacyResponse(t *testing.T) {
+	client, _ := newTestClient(t, `{"code_sense": 80, "efficiency": 70, "edge_cases": 60, "taste": 90, "feedback": {"code_sense": "Gut"}}`)
+
+	result, err := client.ReviewCode(context.Background(), "package main", "go", "Module 1", "en")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"code_sense": "Gut"}, result.Feedback)
+	assert.Empty(t, result.Summary)
+	assert.NotNil(t, result.Strengths)
+	assert.NotNil(t, result.Issues)
+	assert.NotNil(t, result.Suggestions)
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", DefaultLocale},
+		{"es", "es"},
+		{"EN", "en"},
+		{"pt_br", "pt-BR"},
+		{"zh-Hant", "zh-Hant"},
+		{"english please", DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, NormalizeLocale(tt.input), "input %q", tt.input)
+	}
+}
+
+func TestCompleteForwardsRequestID(t *testing.T) {
+	var forwarded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("X-Request-ID")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": `{"is_valid": true, "reason": "ok"}`}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := New("openai", "test-key", "test-model")
+	require.NoError(t, err)
+	client.baseURL = server.URL
+
+	ctx := requestctx.WithRequestID(context.Background(), "req-123")
+	_, err = client.ValidateDomain(ctx, "e-commerce", "Economic")
+	require.NoError(t, err)
+
+	assert.Equal(t, "req-123", forwarded)
+}
+
+func TestCompleteHonorsCancelledContext(t *testing.T) {
+	client, _ := newTestClient(t, `{"is_valid": true}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.ValidateDomain(ctx, "e-commerce", "Economic")
+	assert.Error(t, err)
+}
+
+func TestCompletionOptionsPerCallType(t *testing.T) {
+	var sent struct {
+		Temperature float64 `json:"temperature"`
+		MaxTokens   int     `json:"max_tokens"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": `{"is_valid": true, "reason": "ok", "title": "t", "modules": []}`}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := New("openai", "test-key", "test-model")
+	require.NoError(t, err)
+	client.baseURL = server.URL
+
+	_, err = client.ValidateDomain(context.Background(), "e-commerce", "Economic")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, sent.Temperature)
+	assert.Equal(t, DefaultCompletionOptions()[CallValidateDomain].MaxTokens, sent.MaxTokens)
+
+	client.WithCompletionOptions(CallGenerateCurriculum, CompletionOptions{Temperature: 1.1, MaxTokens: 8000})
+
+	vars := &Variables{Entity: "Order"}
+	_, err = client.GenerateCurriculum(context.Background(), "builder", "e-commerce", vars, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1.1, sent.Temperature)
+	assert.Equal(t, 8000, sent.MaxTokens)
+}
+
+func TestCompletionOptionsValidate(t *testing.T) {
+	assert.Error(t, CompletionOptions{Temperature: 3, MaxTokens: 100}.Validate())
+	assert.Error(t, CompletionOptions{Temperature: 0.5, MaxTokens: 0}.Validate())
+
+	for callType, opts := range DefaultCompletionOptions() {
+		assert.NoError(t, opts.Validate(), callType)
+	}
+}
+
+// tokenCount reads ai_tokens_total for a provider and token type from the default registry
+func tokenCount(t *testing.T, provider, tokenType string) float64 {
+	return counterValue(t, "ai_tokens_total", map[string]string{"provider": provider, "type": tokenType})
+}
+
+// requestCount reads ai_requests_total for a provider and status from the default registry
+func requestCount(t *testing.T, provider, status string) float64 {
+	return counterValue(t, "ai_requests_total", map[string]string{"provider": provider, "status": status})
+}
+
+// counterValue sums the samples of a counter whose labels include all of match
+func counterValue(t *testing.T, name string, match map[string]string) float64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	var total float64
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range m.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			matched := true
+			for key, value := range match {
+				matched = matched && labels[key] == value
+			}
+			if matched {
+				total += m.GetCounter().GetValue()
+			}
+		}
+	}
+	return total
+}
+
+func TestCompleteRecordsTokenUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage map[string]int
+	}{
+		{"openai style", map[string]int{"prompt_tokens": 120, "completion_tokens": 45, "total_tokens": 165}},
+		{"anthropic style", map[string]int{"input_tokens": 120, "output_tokens": 45}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"choices": []map[string]interface{}{
+						{"message": map[string]string{"content": `{"is_valid": true, "reason": "ok"}`}},
+					},
+					"usage": tt.usage,
+				})
+			}))
+			defer server.Close()
+
+			client, err := New("openai", "test-key", "test-model")
+			require.NoError(t, err)
+			client.baseURL = server.URL
+			client.provider = t.Name() // Isolate this test's counters
+
+			_, err = client.ValidateDomain(context.Background(), "e-commerce", "Economic")
+			require.NoError(t, err)
+
+			assert.Equal(t, 120.0, tokenCount(t, t.Name(), "prompt"))
+			assert.Equal(t, 45.0, tokenCount(t, t.Name(), "completion"))
+		})
+	}
+}
+
+func TestCompleteWithoutUsageRecordsNoTokens(t *testing.T) {
+	client, _ := newTestClient(t, `{"is_valid": true}`)
+	client.provider = t.Name()
+
+	_, err := client.ValidateDomain(context.Background(), "e-commerce", "Economic")
+	require.NoError(t, err)
+
+	assert.Equal(t, 0.0, tokenCount(t, t.Name(), "prompt"))
+	assert.Equal(t, 0.0, tokenCount(t, t.Name(), "completion"))
+}
+
+// roundTripFunc is an http.RoundTripper backed by a function
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestCompleteRecordsRequestOutcome(t *testing.T) {
+	client, _ := newTestClient(t, `{"is_valid": true}`)
+	client.provider = t.Name() // Isolate this test's counters
+
+	_, err := client.ValidateDomain(context.Background(), "e-commerce", "Economic")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, requestCount(t, t.Name(), "success"))
+	assert.Equal(t, 0.0, requestCount(t, t.Name(), "failure"))
+
+	client.httpClient.Transport = roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	_, err = client.ReviewCode(context.Background(), "x", "go", "", "en")
+	require.Error(t, err)
+	assert.Equal(t, 1.0, requestCount(t, t.Name(), "success"))
+	assert.Equal(t, 1.0, requestCount(t, t.Name(), "failure"))
+}
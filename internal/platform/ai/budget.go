@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"backend/internal/platform/metrics"
+)
+
+// ErrBudgetExceeded is returned when a user has used up their daily AI calls
+var ErrBudgetExceeded = errors.New("daily AI usage limit reached, try again tomorrow")
+
+// Budget caps how many AI calls each user may trigger per UTC day. Calls are
+// counted in the ai_usage table, so the cap holds across server instances.
+// A nil *Budget allows every call.
+type Budget struct {
+	db         *sql.DB
+	dailyCalls int
+	now        func() time.Time
+}
+
+// NewBudget returns a budget allowing dailyCalls AI calls per user per day,
+// or nil (no limit) when dailyCalls is not positive
+func NewBudget(db *sql.DB, dailyCalls int) *Budget {
+	if dailyCalls <= 0 {
+		return nil
+	}
+	return &Budget{db: db, dailyCalls: dailyCalls, now: time.Now}
+}
+
+// DailyCalls returns the number of AI calls each user may make per day
+func (b *Budget) DailyCalls() int {
+	if b == nil {
+		return 0
+	}
+	return b.dailyCalls
+}
+
+// Consume records one AI call of the given type for userID, returning
+// ErrBudgetExceeded once the user's calls for the day are used up. Call it
+// before calling the AI client. If usage cannot be recorded the call is
+// allowed, so a database problem does not take AI features down with it.
+func (b *Budget) Consume(ctx context.Context, userID string, call CallType) error {
+	if b == nil || userID == "" {
+		return nil
+	}
+
+	// The row is only incremented while under the limit; no row back means the limit was hit
+	query := `
+		INSERT INTO ai_usage (user_id, usage_date, calls)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (user_id, usage_date) DO UPDATE
+			SET calls = ai_usage.calls + 1
+			WHERE ai_usage.calls < $3
+		RETURNING calls
+	`
+
+	day := b.now().UTC().Format("2006-01-02")
+	var calls int
+	err := b.db.QueryRowContext(ctx, query, userID, day, b.dailyCalls).Scan(&calls)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		metrics.RecordAIBudget(string(call), "exceeded")
+		return fmt.Errorf("%w (%d calls per day)", ErrBudgetExceeded, b.dailyCalls)
+	case err != nil:
+		metrics.RecordAIBudget(string(call), "error")
+		slog.Warn("failed to record AI usage, allowing call",
+			"user_id", userID,
+			"call", call,
+			"error", err,
+		)
+		return nil
+	}
+
+	metrics.RecordAIBudget(string(call), "allowed")
+	return nil
+}
+
+// UsageRetentionDays is how many past days of AI usage are kept for reporting;
+// the budget itself only reads the current day
+const UsageRetentionDays = 30
+
+// Cleanup deletes AI usage older than UsageRetentionDays and returns how many
+// rows were removed
+func (b *Budget) Cleanup(ctx context.Context) (int64, error) {
+	if b == nil {
+		return 0, nil
+	}
+
+	cutoff := b.now().UTC().AddDate(0, 0, -UsageRetentionDays).Format("2006-01-02")
+	result, err := b.db.ExecContext(ctx, `DELETE FROM ai_usage WHERE usage_date < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old AI usage: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// StartCleanup runs Cleanup every interval until ctx is cancelled
+func (b *Budget) StartCleanup(ctx context.Context, interval time.Duration) {
+	if b == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deleted, err := b.Cleanup(ctx)
+				if err != nil {
+					slog.Error("failed to clean up AI usage", "error", err)
+					continue
+				}
+				slog.Info("old AI usage deleted", "count", deleted)
+			}
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockBudget returns a budget of dailyCalls backed by a sqlmock database on a fixed day
+func newMockBudget(t *testing.T, dailyCalls int) (*Budget, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	budget := NewBudget(db, dailyCalls)
+	budget.now = func() time.Time { return time.Date(2024, 5, 1, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60)) }
+	return budget, mock
+}
+
+func TestBudgetConsume(t *testing.T) {
+	budget, mock := newMockBudget(t, 3)
+
+	// Days are counted in UTC
+	mock.ExpectQuery(`INSERT INTO ai_usage .* WHERE ai_usage.calls < \$3\s+RETURNING calls`).
+		WithArgs("user-1", "2024-05-02", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"calls"}).AddRow(3))
+	assert.NoError(t, budget.Consume(context.Background(), "user-1", CallReviewCode))
+
+	// No row back means the day's calls were already used up
+	mock.ExpectQuery(`INSERT INTO ai_usage`).
+		WithArgs("user-1", "2024-05-02", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"calls"}))
+	err := budget.Consume(context.Background(), "user-1", CallReviewCode)
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+	assert.Contains(t, err.Error(), "3 calls per day")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBudgetConsume_AllowsCallWhenUsageCannotBeRecorded(t *testing.T) {
+	budget, mock := newMockBudget(t, 3)
+	mock.ExpectQuery(`INSERT INTO ai_usage`).WillReturnError(errors.New("database unavailable"))
+
+	assert.NoError(t, budget.Consume(context.Background(), "user-1", CallGenerateCurriculum))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBudgetDisabled(t *testing.T) {
+	budget := NewBudget(nil, 0)
+	assert.Nil(t, budget)
+	assert.Equal(t, 0, budget.DailyCalls())
+	assert.NoError(t, budget.Consume(context.Background(), "user-1", CallReviewCode))
+}
+
+func TestBudgetCleanup_KeepsRetentionDays(t *testing.T) {
+	budget, mock := newMockBudget(t, 3)
+	mock.ExpectExec(`DELETE FROM ai_usage WHERE usage_date < \$1`).
+		WithArgs("2024-04-02").
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	deleted, err := budget.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	deleted, err = (*Budget)(nil).Cleanup(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, deleted)
+}
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is a non-200 response from the completions API
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// modelUnavailableMarkers are error body fragments providers use when a model
+// cannot serve requests, whatever the status code
+var modelUnavailableMarkers = []string{
+	"model_not_found",
+	"model not found",
+	"model is unavailable",
+	"model is currently unavailable",
+	"no endpoints found",
+}
+
+// isModelUnavailable reports whether err means the model itself could not serve
+// the request, so the same prompt may succeed on another model
+func isModelUnavailable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+
+	body := strings.ToLower(apiErr.Body)
+	for _, marker := range modelUnavailableMarkers {
+		if strings.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFallbackModels sets the models tried, in order, when the primary model
+// is unavailable. Blank entries and repeats of earlier models are skipped; with
+// no fallbacks every request goes to the primary model only.
+func (c *Client) WithFallbackModels(models ...string) *Client {
+	seen := map[string]bool{c.model: true}
+	c.fallbackModels = nil
+	for _, model := range models {
+		model = strings.TrimSpace(model)
+		if model == "" || seen[model] {
+			continue
+		}
+		seen[model] = true
+		c.fallbackModels = append(c.fallbackModels, model)
+	}
+	return c
+}
+
+// FallbackModels returns the configured fallback chain, excluding the primary model
+func (c *Client) FallbackModels() []string {
+	return append([]string(nil), c.fallbackModels...)
+}
+
+// models returns the primary model followed by the fallback chain
+func (c *Client) models() []string {
+	return append([]string{c.model}, c.fallbackModels...)
+}
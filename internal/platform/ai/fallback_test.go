@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newChainClient returns a client whose fake API answers with the status set
+// for the requested model (200 when unset) and records the models asked for
+func newChainClient(t *testing.T, statuses map[string]int) (*Client, *[]string) {
+	t.Helper()
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requested = append(requested, body.Model)
+
+		if status, ok := statuses[body.Model]; ok && status != http.StatusOK {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error": {"message": "upstream error"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": `{"is_valid": true, "reason": "` + body.Model + `"}`}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := New("openrouter", "test-key", "primary")
+	require.NoError(t, err)
+	client.baseURL = server.URL
+	return client, &requested
+}
+
+func TestCompleteFallsBackWhenModelUnavailable(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  map[string]int
+		wantModel string
+		wantTried []string
+	}{
+		{"primary serves", nil, "primary", []string{"primary"}},
+		{"primary not found", map[string]int{"primary": http.StatusNotFound}, "backup-1", []string{"primary", "backup-1"}},
+		{
+			"chain exhausted down to last model",
+			map[string]int{"primary": http.StatusServiceUnavailable, "backup-1": http.StatusNotFound},
+			"backup-2",
+			[]string{"primary", "backup-1", "backup-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requested := newChainClient(t, tt.statuses)
+			client.WithFallbackModels("backup-1", "backup-2")
+
+			validation, err := client.ValidateDomain(context.Background(), "e-commerce", "Economic")
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantModel, validation.Reason)
+			assert.Equal(t, tt.wantTried, *requested)
+		})
+	}
+}
+
+func TestCompleteDoesNotFallBackOnOtherErrors(t *testing.T) {
+	client, requested := newChainClient(t, map[string]int{"primary": http.StatusUnauthorized})
+	client.WithFallbackModels("backup-1")
+
+	_, err := client.ValidateDomain(context.Background(), "e-commerce", "Economic")
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, []string{"primary"}, *requested)
+}
+
+func TestCompleteReturnsLastErrorWhenChainFails(t *testing.T) {
+	client, requested := newChainClient(t, map[string]int{
+		"primary":  http.StatusServiceUnavailable,
+		"backup-1": http.StatusServiceUnavailable,
+	})
+	client.WithFallbackModels("backup-1")
+
+	_, err := client.ValidateDomain(context.Background(), "e-commerce", "Economic")
+	require.Error(t, err)
+	assert.Equal(t, []string{"primary", "backup-1"}, *requested)
+}
+
+func TestWithFallbackModelsSkipsBlanksAndDuplicates(t *testing.T) {
+	client, err := New("openrouter", "test-key", "primary")
+	require.NoError(t, err)
+
+	assert.Empty(t, client.FallbackModels())
+
+	client.WithFallbackModels(" backup-1 ", "", "primary", "backup-1", "backup-2")
+	assert.Equal(t, []string{"backup-1", "backup-2"}, client.FallbackModels())
+}
+
+func TestIsModelUnavailable(t *testing.T) {
+	assert.True(t, isModelUnavailable(&APIError{StatusCode: http.StatusNotFound}))
+	assert.True(t, isModelUnavailable(&APIError{StatusCode: http.StatusServiceUnavailable}))
+	assert.True(t, isModelUnavailable(&APIError{StatusCode: http.StatusBadRequest, Body: `{"error": "No endpoints found for model"}`}))
+	assert.False(t, isModelUnavailable(&APIError{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, isModelUnavailable(context.Canceled))
+}
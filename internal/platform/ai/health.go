@@ -0,0 +1,74 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultHealthFreshness is how long a successful call counts as proof the provider is up
+const DefaultHealthFreshness = 5 * time.Minute
+
+// markSuccess records that the provider just answered successfully
+func (c *Client) markSuccess() {
+	c.lastSuccess.Store(time.Now().UnixNano())
+}
+
+// LastSuccess returns when the provider last answered successfully (zero if never)
+func (c *Client) LastSuccess() time.Time {
+	nanos := c.lastSuccess.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// WithHealthFreshness sets how long a successful call lets Ping skip its request
+func (c *Client) WithHealthFreshness(freshness time.Duration) *Client {
+	if freshness > 0 {
+		c.healthFreshness = freshness
+	}
+	return c
+}
+
+// Ping reports whether the provider is reachable and accepts our API key.
+// A successful call within the freshness window is trusted without a request;
+// otherwise it lists models, which is free and exercises authentication.
+func (c *Client) Ping(ctx context.Context) error {
+	if c.IsStub() {
+		return nil
+	}
+
+	freshness := c.healthFreshness
+	if freshness <= 0 {
+		freshness = DefaultHealthFreshness
+	}
+	if last := c.LastSuccess(); !last.IsZero() && time.Since(last) < freshness {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("AI provider unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		c.markSuccess()
+		return nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("AI provider rejected the API key (status %d)", resp.StatusCode)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("AI provider is rate limiting requests")
+	default:
+		return fmt.Errorf("AI provider returned status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPingClient returns a client whose /models endpoint answers with status
+func newPingClient(t *testing.T, status int) (*Client, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.Equal(t, "/models", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := New("openai", "test-key", "test-model")
+	require.NoError(t, err)
+	client.baseURL = server.URL
+
+	return client, &calls
+}
+
+func TestPing(t *testing.T) {
+	t.Run("healthy provider", func(t *testing.T) {
+		client, calls := newPingClient(t, http.StatusOK)
+
+		require.NoError(t, client.Ping(context.Background()))
+		assert.False(t, client.LastSuccess().IsZero())
+
+		// A recent success is trusted without another request
+		require.NoError(t, client.Ping(context.Background()))
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		client, _ := newPingClient(t, http.StatusUnauthorized)
+
+		err := client.Ping(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rejected the API key")
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		client, _ := newPingClient(t, http.StatusTooManyRequests)
+
+		err := client.Ping(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "rate limiting")
+	})
+
+	t.Run("stub is always up", func(t *testing.T) {
+		client, err := New(ProviderStub, "", "")
+		require.NoError(t, err)
+
+		assert.NoError(t, client.Ping(context.Background()))
+	})
+}
+
+func TestCompleteMarksSuccess(t *testing.T) {
+	client, _ := newTestClient(t, `{"is_valid": true, "reason": "ok"}`)
+	require.True(t, client.LastSuccess().IsZero())
+
+	_, err := client.ValidateDomain(context.Background(), "e-commerce", "Economic")
+	require.NoError(t, err)
+
+	assert.False(t, client.LastSuccess().IsZero())
+}
@@ -0,0 +1,63 @@
+package ai
+
+import "fmt"
+
+// CallType identifies which client method issued a completion request
+type CallType string
+
+const (
+	CallValidateDomain     CallType = "validate_domain"
+	CallExtractVariables   CallType = "extract_variables"
+	CallGenerateCurriculum CallType = "generate_curriculum"
+	CallReviewCode         CallType = "review_code"
+)
+
+// MaxTemperature is the highest sampling temperature accepted by supported providers
+const MaxTemperature = 2.0
+
+// CompletionOptions controls sampling and output length for a completion request
+type CompletionOptions struct {
+	Temperature float64
+	MaxTokens   int
+}
+
+// Validate checks that the options are within provider limits
+func (o CompletionOptions) Validate() error {
+	if o.Temperature < 0 || o.Temperature > MaxTemperature {
+		return fmt.Errorf("temperature must be between 0 and %.1f", MaxTemperature)
+	}
+	if o.MaxTokens <= 0 {
+		return fmt.Errorf("max tokens must be positive")
+	}
+	return nil
+}
+
+// DefaultCompletionOptions returns the per-call defaults.
+// Validation and extraction stay near-deterministic; curriculum generation gets
+// more creativity and room for large courses; reviews need long feedback.
+func DefaultCompletionOptions() map[CallType]CompletionOptions {
+	return map[CallType]CompletionOptions{
+		CallValidateDomain:     {Temperature: 0, MaxTokens: 300},
+		CallExtractVariables:   {Temperature: 0.2, MaxTokens: 500},
+		CallGenerateCurriculum: {Temperature: 0.8, MaxTokens: 6000},
+		CallReviewCode:         {Temperature: 0.3, MaxTokens: 3000},
+	}
+}
+
+// WithCompletionOptions overrides the defaults for one call type.
+// Options are expected to be validated by the caller (see CompletionOptions.Validate).
+func (c *Client) WithCompletionOptions(callType CallType, opts CompletionOptions) *Client {
+	if c.options == nil {
+		c.options = DefaultCompletionOptions()
+	}
+	c.options[callType] = opts
+	return c
+}
+
+// CompletionOptionsFor returns the options used for the given call type
+func (c *Client) CompletionOptionsFor(callType CallType) CompletionOptions {
+	if opts, ok := c.options[callType]; ok {
+		return opts
+	}
+	return DefaultCompletionOptions()[callType]
+}
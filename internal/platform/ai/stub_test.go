@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStubClient(t *testing.T) {
+	client, err := New(ProviderStub, "", "")
+	require.NoError(t, err)
+	assert.True(t, client.IsStub())
+
+	// Other providers still require an API key
+	_, err = New("openai", "", "")
+	assert.Error(t, err)
+}
+
+func TestStubValidateDomain(t *testing.T) {
+	client, _ := New(ProviderStub, "", "")
+
+	validation, err := client.ValidateDomain(context.Background(), "Inventory Management", "Digital")
+	require.NoError(t, err)
+	assert.True(t, validation.IsValid)
+	assert.NotEmpty(t, validation.Reason)
+
+	validation, err = client.ValidateDomain(context.Background(), "   ", "Digital")
+	require.NoError(t, err)
+	assert.False(t, validation.IsValid)
+}
+
+func TestStubExtractVariables(t *testing.T) {
+	client, _ := New(ProviderStub, "", "")
+
+	vars, err := client.ExtractVariables(context.Background(), "Inventory")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Inventory", vars.Entity)
+	assert.NotEmpty(t, vars.State)
+	assert.NotEmpty(t, vars.Flow)
+	assert.NotEmpty(t, vars.Logic)
+	assert.NotEmpty(t, vars.Interface)
+
+	// Deterministic across calls
+	again, _ := client.ExtractVariables(context.Background(), "Inventory")
+	assert.Equal(t, vars, again)
+}
+
+func TestStubGenerateCurriculum(t *testing.T) {
+	client, _ := New(ProviderStub, "", "")
+	vars, _ := client.ExtractVariables(context.Background(), "Inventory")
+
+	curriculum, err := client.GenerateCurriculum(context.Background(), "builder", "Inventory", vars, "en")
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, curriculum.Title)
+	assert.NotEmpty(t, curriculum.Description)
+	require.GreaterOrEqual(t, len(curriculum.Modules), 5)
+	for i, module := range curriculum.Modules {
+		assert.Equal(t, i+1, module.Number)
+		assert.NotEmpty(t, module.Title)
+		assert.NotEmpty(t, module.Description)
+	}
+}
+
+func TestStubReviewCode(t *testing.T) {
+	client, _ := New(ProviderStub, "", "")
+
+	review, err := client.ReviewCode(context.Background(), "package main", "go", "Module 1", "en")
+	require.NoError(t, err)
+
+	for _, score := range []int{review.CodeSense, review.Efficiency, review.EdgeCases, review.Taste} {
+		assert.GreaterOrEqual(t, score, 0)
+		assert.LessOrEqual(t, score, 100)
+	}
+	assert.Equal(t, (review.CodeSense+review.Efficiency+review.EdgeCases+review.Taste)/4, review.OverallScore)
+	// Stub reviews must fit the architecture_reviews CHECK constraints and score thresholds like real ones
+	assert.Equal(t, 70, review.OverallScore)
+	for _, key := range ReviewCategories {
+		assert.NotEmpty(t, review.Feedback[key])
+	}
+	assert.NotEmpty(t, review.Summary)
+	assert.NotEmpty(t, review.Strengths)
+	assert.NotEmpty(t, review.Issues)
+	assert.NotEmpty(t, review.Suggestions)
+}
@@ -0,0 +1,168 @@
+// Package apierror defines the errors handlers report to clients and writes
+// them in one JSON envelope, so every endpoint fails the same way:
+//
+//	{"error": "not_found", "message": "course not found", "status": 404, "request_id": "..."}
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// Machine-readable error codes, stable across message wording changes
+const (
+	CodeBadRequest         = "bad_request"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodeUnprocessable      = "unprocessable_entity"
+	CodeTooManyRequests    = "too_many_requests"
+	CodeInternal           = "internal_error"
+	CodeServiceUnavailable = "service_unavailable"
+)
+
+// Error is an error with the HTTP status, code and message a client should see.
+// Err keeps the underlying cause for logs; it is never written to the response.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Field   string // Request field at fault, when the error is about one
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with an explicit code
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// FromStatus returns an error whose code is derived from the HTTP status
+func FromStatus(status int, message string) *Error {
+	return New(status, CodeForStatus(status), message)
+}
+
+// BadRequest reports malformed or invalid input
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// InvalidField reports a bad request caused by one request field
+func InvalidField(field, message string) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: message, Field: field}
+}
+
+// Unauthorized reports a missing or invalid authentication
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden reports an authenticated caller without access
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound reports a missing resource
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict reports a request that clashes with the resource's current state
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// Internal hides err behind a generic message; err is logged by WriteError
+func Internal(err error) *Error {
+	return &Error{
+		Status:  http.StatusInternalServerError,
+		Code:    CodeInternal,
+		Message: "internal server error",
+		Err:     err,
+	}
+}
+
+// CodeForStatus returns the default code for an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMedia
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Response is the JSON envelope of every error response
+type Response struct {
+	Error     string `json:"error"` // Machine-readable code
+	Message   string `json:"message"`
+	Status    int    `json:"status"`
+	Field     string `json:"field,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// WriteError writes err as an error response. Errors that are not *Error are
+// treated as internal. The request ID is taken from the X-Request-ID response
+// header set by the request ID middleware.
+func WriteError(w http.ResponseWriter, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = Internal(err)
+	}
+
+	requestID := w.Header().Get("X-Request-ID")
+	if apiErr.Status >= http.StatusInternalServerError {
+		slog.Error("request failed",
+			"request_id", requestID,
+			"status", apiErr.Status,
+			"code", apiErr.Code,
+			"error", err,
+		)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(Response{
+		Error:     apiErr.Code,
+		Message:   apiErr.Message,
+		Status:    apiErr.Status,
+		Field:     apiErr.Field,
+		RequestID: requestID,
+	})
+}
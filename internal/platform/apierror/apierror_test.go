@@ -0,0 +1,103 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeAndDecode writes err and returns the response and its decoded envelope
+func writeAndDecode(t *testing.T, err error, requestID string) (*httptest.ResponseRecorder, Response) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	if requestID != "" {
+		rec.Header().Set("X-Request-ID", requestID)
+	}
+	WriteError(rec, err)
+
+	var body Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec, body
+}
+
+func TestWriteError_Envelope(t *testing.T) {
+	rec, body := writeAndDecode(t, NotFound("course not found"), "req-123")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, Response{
+		Error:     CodeNotFound,
+		Message:   "course not found",
+		Status:    http.StatusNotFound,
+		RequestID: "req-123",
+	}, body)
+}
+
+func TestWriteError_UnwrapsWrappedErrors(t *testing.T) {
+	err := fmt.Errorf("loading course: %w", Forbidden("not your course"))
+
+	rec, body := writeAndDecode(t, err, "")
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, CodeForbidden, body.Error)
+	assert.Equal(t, "not your course", body.Message)
+	assert.Empty(t, body.RequestID)
+}
+
+func TestWriteError_HidesInternalDetails(t *testing.T) {
+	for _, err := range []error{
+		errors.New("pq: connection refused"),
+		Internal(errors.New("pq: connection refused")),
+	} {
+		rec, body := writeAndDecode(t, err, "req-123")
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, CodeInternal, body.Error)
+		assert.Equal(t, "internal server error", body.Message)
+		assert.NotContains(t, rec.Body.String(), "connection refused")
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusBadRequest, CodeBadRequest},
+		{http.StatusUnauthorized, CodeUnauthorized},
+		{http.StatusConflict, CodeConflict},
+		{http.StatusUnprocessableEntity, CodeUnprocessable},
+		{http.StatusTooManyRequests, CodeTooManyRequests},
+		{http.StatusServiceUnavailable, CodeServiceUnavailable},
+		{http.StatusBadGateway, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		err := FromStatus(tt.status, "message")
+		assert.Equal(t, tt.status, err.Status)
+		assert.Equal(t, tt.code, err.Code, tt.status)
+	}
+}
+
+func TestErrorUnwrap(t *testing.T) {
+	cause := errors.New("cause")
+	err := Internal(cause)
+
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "internal server error: cause", err.Error())
+}
+
+func TestWriteError_IncludesField(t *testing.T) {
+	rec, body := writeAndDecode(t, InvalidField("skill_level", "skill_level must be one of: beginner"), "")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "skill_level", body.Field)
+	assert.Equal(t, CodeBadRequest, body.Error)
+}
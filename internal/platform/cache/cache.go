@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"backend/internal/platform/metrics"
+)
+
+// Eviction reasons reported to metrics
+const (
+	EvictionExpired  = "expired"
+	EvictionCapacity = "capacity"
+)
+
+// Config holds cache sizing
+type Config struct {
+	TTL     time.Duration // How long an entry stays valid
+	MaxSize int           // Maximum number of entries before LRU eviction
+}
+
+// Enabled reports whether the config describes a usable cache
+func (c Config) Enabled() bool {
+	return c.TTL > 0 && c.MaxSize > 0
+}
+
+// Cache is a concurrency-safe in-memory cache with per-entry TTL and LRU eviction.
+// Lookups and evictions are reported to Prometheus under the cache's name.
+type Cache[K comparable, V any] struct {
+	name    string
+	ttl     time.Duration
+	maxSize int
+
+	mu    sync.Mutex
+	order *list.List // Front is most recently used
+	items map[K]*list.Element
+
+	now func() time.Time
+}
+
+// entry is a cached value and its expiry
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// New creates a cache; name is used as the metrics label
+func New[K comparable, V any](name string, cfg Config) *Cache[K, V] {
+	maxSize := cfg.MaxSize
+	if maxSize <= 0 {
+		maxSize = 1
+	}
+
+	return &Cache[K, V]{
+		name:    name,
+		ttl:     cfg.TTL,
+		maxSize: maxSize,
+		order:   list.New(),
+		items:   make(map[K]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get returns the value for key if present and not expired.
+// The stored value is returned as is: callers caching slices, maps or pointers
+// must copy them on Set and Get if they intend to modify the result.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		metrics.RecordCacheMiss(c.name)
+		return zero, false
+	}
+
+	e := elem.Value.(*entry[K, V])
+	if !c.now().Before(e.expiresAt) {
+		c.removeElement(elem)
+		metrics.RecordCacheEviction(c.name, EvictionExpired)
+		metrics.RecordCacheMiss(c.name)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	metrics.RecordCacheHit(c.name)
+	return e.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when full
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.maxSize {
+		c.removeElement(c.order.Back())
+		metrics.RecordCacheEviction(c.name, EvictionCapacity)
+	}
+}
+
+// Delete removes key from the cache
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Purge removes all entries
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[K]*list.Element)
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// removeElement unlinks an entry; callers must hold mu
+func (c *Cache[K, V]) removeElement(elem *list.Element) {
+	e := c.order.Remove(elem).(*entry[K, V])
+	delete(c.items, e.key)
+}
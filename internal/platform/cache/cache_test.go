@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counterValue reads a labelled counter from the default Prometheus registry
+func counterValue(t *testing.T, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metric:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if want, ok := labels[label.GetName()]; ok && want != label.GetValue() {
+					continue metric
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+// newTestCache returns a cache with a controllable clock and a unique metrics name
+func newTestCache(t *testing.T, cfg Config) (*Cache[string, int], *time.Time) {
+	c := New[string, int](t.Name(), cfg)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c, _ := newTestCache(t, Config{TTL: time.Minute, MaxSize: 2})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	// Touch "a" so "b" becomes the least recently used
+	_, ok := c.Get("a")
+	require.True(t, ok)
+
+	c.Set("c", 3)
+
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get("b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	v, ok = c.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	assert.Equal(t, 1.0, counterValue(t, "cache_evictions_total", map[string]string{"cache": t.Name(), "reason": EvictionCapacity}))
+}
+
+func TestCache_ExpiresAfterTTL(t *testing.T) {
+	c, now := newTestCache(t, Config{TTL: time.Minute, MaxSize: 10})
+
+	c.Set("a", 1)
+
+	*now = now.Add(59 * time.Second)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	*now = now.Add(time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len(), "expired entry should be removed on lookup")
+
+	assert.Equal(t, 1.0, counterValue(t, "cache_evictions_total", map[string]string{"cache": t.Name(), "reason": EvictionExpired}))
+}
+
+func TestCache_SetRefreshesTTL(t *testing.T) {
+	c, now := newTestCache(t, Config{TTL: time.Minute, MaxSize: 10})
+
+	c.Set("a", 1)
+	*now = now.Add(45 * time.Second)
+	c.Set("a", 2)
+	*now = now.Add(45 * time.Second)
+
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+}
+
+func TestCache_RecordsHitsAndMisses(t *testing.T) {
+	c, _ := newTestCache(t, Config{TTL: time.Minute, MaxSize: 10})
+	hits := map[string]string{"cache": t.Name(), "result": "hit"}
+	misses := map[string]string{"cache": t.Name(), "result": "miss"}
+
+	c.Get("missing")
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+
+	assert.Equal(t, 2.0, counterValue(t, "cache_requests_total", hits))
+	assert.Equal(t, 1.0, counterValue(t, "cache_requests_total", misses))
+}
+
+func TestCache_DeleteAndPurge(t *testing.T) {
+	c, _ := newTestCache(t, Config{TTL: time.Minute, MaxSize: 10})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Delete("a")
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+
+	c.Purge()
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_ConcurrentAccess(t *testing.T) {
+	c := New[string, int](t.Name(), Config{TTL: time.Minute, MaxSize: 50})
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("key-%d", (worker*31+i)%100)
+				c.Set(key, i)
+				c.Get(key)
+				if i%50 == 0 {
+					c.Delete(key)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Len(), 50)
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	assert.True(t, Config{TTL: time.Minute, MaxSize: 1}.Enabled())
+	assert.False(t, Config{TTL: 0, MaxSize: 10}.Enabled())
+	assert.False(t, Config{TTL: time.Minute, MaxSize: 0}.Enabled())
+}
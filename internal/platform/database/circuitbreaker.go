@@ -7,6 +7,8 @@ import (
 	"log"
 	"time"
 
+	"backend/internal/platform/metrics"
+
 	"github.com/sony/gobreaker"
 )
 
@@ -51,7 +53,12 @@ func NewCircuitBreakerDB(db *DB, config CircuitBreakerConfig) *CircuitBreakerDB
 			// Trip to open state after consecutive failures
 			return counts.ConsecutiveFailures >= config.MaxFailures
 		},
-		OnStateChange: config.OnStateChange,
+		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+			metrics.RecordCircuitBreakerState(name, to.String())
+			if config.OnStateChange != nil {
+				config.OnStateChange(name, from, to)
+			}
+		},
 		IsSuccessful: func(err error) bool {
 			// Only count database errors as failures, not business logic errors
 			return !IsRetryableError(err)
@@ -59,6 +66,7 @@ func NewCircuitBreakerDB(db *DB, config CircuitBreakerConfig) *CircuitBreakerDB
 	}
 
 	cb := gobreaker.NewCircuitBreaker(settings)
+	metrics.RecordCircuitBreakerState(config.Name, cb.State().String())
 
 	return &CircuitBreakerDB{
 		db: db,
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// CodeUniqueViolation is the PostgreSQL error code for a unique constraint violation
+const CodeUniqueViolation = "23505"
+
+// IsUniqueViolation reports whether err is a PostgreSQL unique constraint
+// violation, i.e. another row already holds the value. Repositories use it to
+// turn an insert that lost a race into the domain's "already exists" error.
+func IsUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == CodeUniqueViolation
+}
+
+// IsUniqueViolationOn reports whether err is a unique violation of one of the
+// named constraints or unique indexes, so a table with several unique columns
+// can tell which value was already taken
+func IsUniqueViolationOn(err error, constraints ...string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != CodeUniqueViolation {
+		return false
+	}
+	for _, constraint := range constraints {
+		if pqErr.Constraint == constraint {
+			return true
+		}
+	}
+	return false
+}
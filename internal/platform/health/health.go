@@ -4,9 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -39,6 +44,25 @@ type Config struct {
 	Version   string
 	StartTime time.Time
 	DB        *sql.DB
+
+	// MaxMemoryMB is the heap size above which the memory check reports DOWN.
+	// Zero derives it from the container's cgroup memory limit, falling back
+	// to DefaultMaxMemoryMB outside a container.
+	MaxMemoryMB uint64
+}
+
+// DefaultMaxMemoryMB is the memory threshold when none is configured and no
+// container memory limit is found
+const DefaultMaxMemoryMB = 1024
+
+// cgroupLimitFraction is the share of the container memory limit used as the
+// threshold, so readiness fails before the OOM killer steps in
+const cgroupLimitFraction = 0.9
+
+// cgroupMemoryLimitFiles hold the container memory limit under cgroup v2 and v1
+var cgroupMemoryLimitFiles = []string{
+	"/sys/fs/cgroup/memory.max",
+	"/sys/fs/cgroup/memory/memory.limit_in_bytes",
 }
 
 // Handler manages health check endpoints
@@ -52,6 +76,9 @@ func NewHandler(cfg Config) *Handler {
 	if cfg.StartTime.IsZero() {
 		cfg.StartTime = time.Now()
 	}
+	if cfg.MaxMemoryMB == 0 {
+		cfg.MaxMemoryMB = defaultMaxMemoryMB(cgroupMemoryLimitFiles)
+	}
 	return &Handler{
 		config: cfg,
 	}
@@ -117,6 +144,7 @@ func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) performHealthChecks(ctx context.Context) []HealthCheck {
 	var checks []HealthCheck
 	var wg sync.WaitGroup
+	var mu sync.Mutex // Guards checks; h.mu is already read-locked by the caller
 
 	// Database check
 	if h.config.DB != nil {
@@ -124,9 +152,9 @@ func (h *Handler) performHealthChecks(ctx context.Context) []HealthCheck {
 		go func() {
 			defer wg.Done()
 			check := h.checkDatabase(ctx)
-			h.mu.Lock()
+			mu.Lock()
 			checks = append(checks, check)
-			h.mu.Unlock()
+			mu.Unlock()
 		}()
 	}
 
@@ -135,11 +163,23 @@ func (h *Handler) performHealthChecks(ctx context.Context) []HealthCheck {
 	go func() {
 		defer wg.Done()
 		check := h.checkMemory()
-		h.mu.Lock()
+		mu.Lock()
 		checks = append(checks, check)
-		h.mu.Unlock()
+		mu.Unlock()
 	}()
 
+	// Registered checks (e.g. AI provider)
+	for _, c := range registeredChecks() {
+		wg.Add(1)
+		go func(c *customCheck) {
+			defer wg.Done()
+			check := runCustomCheck(ctx, c)
+			mu.Lock()
+			checks = append(checks, check)
+			mu.Unlock()
+		}(c)
+	}
+
 	wg.Wait()
 	return checks
 }
@@ -183,24 +223,125 @@ func (h *Handler) checkMemory() HealthCheck {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
-	// Alert if allocated memory exceeds 1GB (configurable threshold)
-	const maxMemoryMB = 1024
 	allocMB := m.Alloc / 1024 / 1024
-
-	if allocMB > maxMemoryMB {
+	if allocMB > h.config.MaxMemoryMB {
 		check.Status = StatusDown
-		check.Error = "memory usage exceeds threshold"
+		check.Error = fmt.Sprintf("memory usage %dMB exceeds threshold %dMB", allocMB, h.config.MaxMemoryMB)
 	}
 
 	return check
 }
 
-// AddCustomCheck allows adding custom health checks (for future extensibility)
+// defaultMaxMemoryMB returns cgroupLimitFraction of the first memory limit found
+// in files, or DefaultMaxMemoryMB when there is none
+func defaultMaxMemoryMB(files []string) uint64 {
+	for _, path := range files {
+		if limit, ok := readMemoryLimit(path); ok {
+			if mb := uint64(float64(limit)*cgroupLimitFraction) / 1024 / 1024; mb > 0 {
+				return mb
+			}
+		}
+	}
+	return DefaultMaxMemoryMB
+}
+
+// unlimitedMemory is the smallest cgroup v1 limit treated as "no limit"; v1
+// reports an unlimited group as a page-aligned value near the int64 maximum
+const unlimitedMemory = 1 << 60
+
+// readMemoryLimit reads a cgroup memory limit in bytes; ok is false when the
+// file is missing, unreadable or the group is unlimited
+func readMemoryLimit(path string) (limit uint64, ok bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, false
+	}
+	limit, err = strconv.ParseUint(value, 10, 64)
+	if err != nil || limit == 0 || limit >= unlimitedMemory {
+		return 0, false
+	}
+	return limit, true
+}
+
+// CheckFunc is a custom health check run on every readiness probe
 type CheckFunc func(ctx context.Context) HealthCheck
 
-var customChecks []CheckFunc
+// customCheck is a registered check and whether a previous run is still in flight
+type customCheck struct {
+	name    string
+	fn      CheckFunc
+	running atomic.Bool
+}
 
-// RegisterCheck registers a custom health check function
-func RegisterCheck(fn CheckFunc) {
-	customChecks = append(customChecks, fn)
+var (
+	customChecksMu sync.RWMutex
+	customChecks   []*customCheck
+)
+
+// RegisterCheck registers a named custom health check function.
+// A DOWN result from any registered check makes readiness return 503.
+// The check is always reported under name, whatever name fn puts in its result.
+func RegisterCheck(name string, fn CheckFunc) {
+	customChecksMu.Lock()
+	defer customChecksMu.Unlock()
+	customChecks = append(customChecks, &customCheck{name: name, fn: fn})
+}
+
+// registeredChecks returns a snapshot of the registered checks
+func registeredChecks() []*customCheck {
+	customChecksMu.RLock()
+	defer customChecksMu.RUnlock()
+	return append([]*customCheck(nil), customChecks...)
+}
+
+// runCustomCheck runs the check, reporting DOWN if it panics or outlives the readiness timeout.
+// A check that ignores ctx keeps running in the background after a timeout; at most one
+// such run is kept per check, and later probes report DOWN until it returns.
+func runCustomCheck(ctx context.Context, c *customCheck) HealthCheck {
+	if !c.running.CompareAndSwap(false, true) {
+		return HealthCheck{Name: c.name, Status: StatusDown, Error: "previous check still running"}
+	}
+
+	result := make(chan HealthCheck, 1)
+	go func() {
+		defer c.running.Store(false)
+		defer func() {
+			if rec := recover(); rec != nil {
+				result <- HealthCheck{Name: c.name, Status: StatusDown, Error: fmt.Sprintf("check panicked: %v", rec)}
+			}
+		}()
+		check := c.fn(ctx)
+		check.Name = c.name
+		result <- check
+	}()
+
+	select {
+	case check := <-result:
+		return check
+	case <-ctx.Done():
+		return HealthCheck{Name: c.name, Status: StatusDown, Error: "check timed out: " + ctx.Err().Error()}
+	}
+}
+
+// Pinger is implemented by dependencies that can report their own reachability
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck adapts a Pinger into a check for RegisterCheck
+func PingCheck(p Pinger) CheckFunc {
+	return func(ctx context.Context) HealthCheck {
+		check := HealthCheck{
+			Status: StatusUp,
+		}
+		if err := p.Ping(ctx); err != nil {
+			check.Status = StatusDown
+			check.Error = err.Error()
+		}
+		return check
+	}
 }
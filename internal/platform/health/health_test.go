@@ -0,0 +1,168 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namedCheck pairs a check with its registration name for withChecks
+type namedCheck struct {
+	name string
+	fn   CheckFunc
+}
+
+// withChecks replaces the registered custom checks for the duration of a test
+func withChecks(t *testing.T, checks ...namedCheck) {
+	t.Helper()
+
+	customChecksMu.Lock()
+	saved := customChecks
+	customChecks = nil
+	customChecksMu.Unlock()
+
+	for _, c := range checks {
+		RegisterCheck(c.name, c.fn)
+	}
+
+	t.Cleanup(func() {
+		customChecksMu.Lock()
+		customChecks = saved
+		customChecksMu.Unlock()
+	})
+}
+
+// readiness runs the readiness probe and decodes its response
+func readiness(t *testing.T, h *Handler) (int, Response) {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	h.Readiness(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	var body Response
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	return rr.Code, body
+}
+
+// findCheck returns the named check from a response
+func findCheck(t *testing.T, resp Response, name string) HealthCheck {
+	t.Helper()
+
+	for _, check := range resp.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("check %q not found in %+v", name, resp.Checks)
+	return HealthCheck{}
+}
+
+// pingerFunc adapts a function to the Pinger interface
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error { return f(ctx) }
+
+func TestReadiness_FailingCustomCheckReturns503(t *testing.T) {
+	withChecks(t, namedCheck{"queue", PingCheck(pingerFunc(func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}))})
+
+	code, body := readiness(t, NewHandler(Config{Version: "test"}))
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusDown, body.Status)
+
+	check := findCheck(t, body, "queue")
+	assert.Equal(t, StatusDown, check.Status)
+	assert.Equal(t, "connection refused", check.Error)
+	findCheck(t, body, "memory")
+}
+
+func TestReadiness_PassingCustomCheckReturns200(t *testing.T) {
+	withChecks(t, namedCheck{"queue", PingCheck(pingerFunc(func(ctx context.Context) error {
+		return nil
+	}))})
+
+	code, body := readiness(t, NewHandler(Config{Version: "test"}))
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusUp, body.Status)
+	assert.Equal(t, StatusUp, findCheck(t, body, "queue").Status)
+}
+
+func TestReadiness_PanickingCustomCheckIsDown(t *testing.T) {
+	withChecks(t, namedCheck{"flaky", func(ctx context.Context) HealthCheck {
+		panic("boom")
+	}})
+
+	code, body := readiness(t, NewHandler(Config{Version: "test"}))
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, findCheck(t, body, "flaky").Error, "boom")
+}
+
+func TestRunCustomCheck_StopsAtDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	release := make(chan struct{})
+	slow := &customCheck{name: "slow", fn: func(ctx context.Context) HealthCheck {
+		<-release // Ignores ctx
+		return HealthCheck{Status: StatusUp}
+	}}
+
+	check := runCustomCheck(ctx, slow)
+	assert.Equal(t, "slow", check.Name)
+	assert.Equal(t, StatusDown, check.Status)
+	assert.Contains(t, check.Error, "timed out")
+
+	// The abandoned run is not duplicated by the next probe
+	check = runCustomCheck(context.Background(), slow)
+	assert.Equal(t, StatusDown, check.Status)
+	assert.Contains(t, check.Error, "still running")
+
+	close(release)
+	assert.Eventually(t, func() bool { return !slow.running.Load() }, time.Second, time.Millisecond)
+	assert.Equal(t, StatusUp, runCustomCheck(context.Background(), slow).Status)
+}
+
+func TestReadiness_MemoryAboveThresholdIsDown(t *testing.T) {
+	withChecks(t)
+
+	// Keep enough heap alive to exceed a 1MB threshold
+	ballast := make([]byte, 8*1024*1024)
+	code, body := readiness(t, NewHandler(Config{Version: "test", MaxMemoryMB: 1}))
+	runtime.KeepAlive(ballast)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	check := findCheck(t, body, "memory")
+	assert.Equal(t, StatusDown, check.Status)
+	assert.Regexp(t, `^memory usage \d+MB exceeds threshold 1MB$`, check.Error)
+}
+
+func TestDefaultMaxMemoryMB_UsesCgroupLimit(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	limited := write("limited", "536870912\n") // 512MB
+	unlimitedV2 := write("unlimited-v2", "max\n")
+	unlimitedV1 := write("unlimited-v1", "9223372036854771712\n")
+	missing := filepath.Join(dir, "missing")
+
+	assert.Equal(t, uint64(460), defaultMaxMemoryMB([]string{missing, limited}))
+	assert.Equal(t, uint64(DefaultMaxMemoryMB), defaultMaxMemoryMB([]string{unlimitedV2, unlimitedV1, missing}))
+}
@@ -0,0 +1,204 @@
+// Package jsonbody decodes JSON request bodies and reports what is wrong with
+// them as apierror errors, so every handler tells a client the same thing:
+//
+//	415 the body is not declared as JSON
+//	400 the body is empty, or is not valid JSON for the request
+//	413 the body exceeds the request size limit, or DecodeLimited's array or nesting caps
+//
+// Write is the matching helper for JSON response bodies.
+package jsonbody
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"backend/internal/platform/apierror"
+)
+
+// MaxDepth is how deeply DecodeLimited lets arrays and objects nest
+const MaxDepth = 32
+
+var (
+	// ErrEmptyBody is the cause of the error returned for a request without a body
+	ErrEmptyBody = errors.New("request body is empty")
+
+	// ErrTooManyElements is the cause of the error returned by DecodeLimited for
+	// an array longer than its cap
+	ErrTooManyElements = errors.New("array has too many elements")
+
+	// ErrTooDeep is the cause of the error returned by DecodeLimited for a body
+	// nested deeper than MaxDepth
+	ErrTooDeep = errors.New("body is nested too deeply")
+)
+
+// Decode checks that r declares a JSON body and decodes it into dst. The
+// returned error is an *apierror.Error ready for apierror.WriteError.
+func Decode(r *http.Request, dst interface{}) error {
+	if err := RequireJSON(r); err != nil {
+		return err
+	}
+	return decode(json.NewDecoder(r.Body), dst)
+}
+
+// DecodeLimited is Decode for endpoints that must not let a small body expand
+// into a large value: every array in the body may hold at most maxElements
+// elements, nesting may not exceed MaxDepth and fields dst does not declare are
+// rejected. The limits are checked on the raw tokens before anything is decoded
+// into dst.
+func DecodeLimited(r *http.Request, dst interface{}, maxElements int) error {
+	if err := RequireJSON(r); err != nil {
+		return err
+	}
+
+	// The body is already bounded in bytes by the request size limit
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return decodeError(err)
+	}
+	if err := checkLimits(body, maxElements); err != nil {
+		return &apierror.Error{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    apierror.CodePayloadTooLarge,
+			Message: err.Error(),
+			Err:     err,
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	return decode(dec, dst)
+}
+
+// checkLimits walks the tokens of data and reports the first array longer than
+// maxElements or container nested deeper than MaxDepth. Malformed JSON passes;
+// decoding reports it.
+func checkLimits(data []byte, maxElements int) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// Element counts of the open containers; -1 marks an object
+	var open []int
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+
+		delim, isDelim := tok.(json.Delim)
+		if isDelim && (delim == ']' || delim == '}') {
+			open = open[:len(open)-1]
+			continue
+		}
+
+		// A value, including a nested container, is an element of an enclosing
+		// array; object keys and values are not counted
+		if n := len(open); n > 0 && open[n-1] >= 0 {
+			open[n-1]++
+			if open[n-1] > maxElements {
+				return fmt.Errorf("%w: at most %d are allowed", ErrTooManyElements, maxElements)
+			}
+		}
+
+		if isDelim {
+			if len(open) == MaxDepth {
+				return fmt.Errorf("%w: at most %d levels are allowed", ErrTooDeep, MaxDepth)
+			}
+			if delim == '[' {
+				open = append(open, 0)
+			} else {
+				open = append(open, -1)
+			}
+		}
+	}
+}
+
+// RequireJSON reports a 415 unless the Content-Type of r is application/json
+// or a +json media type such as application/merge-patch+json
+func RequireJSON(r *http.Request) error {
+	contentType := r.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+
+	if contentType == "" {
+		return apierror.FromStatus(http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+	}
+	return apierror.FromStatus(http.StatusUnsupportedMediaType,
+		"Content-Type must be application/json, got "+contentType)
+}
+
+// decode reads one JSON value from dec into dst, telling an empty body apart
+// from malformed JSON and from a body over the size limit
+func decode(dec *json.Decoder, dst interface{}) error {
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+// decodeError converts an error from reading or decoding a body into an *apierror.Error
+func decodeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &apierror.Error{
+			Status:  http.StatusBadRequest,
+			Code:    apierror.CodeBadRequest,
+			Message: "request body is required",
+			Err:     ErrEmptyBody,
+		}
+	case errors.As(err, &maxBytesErr):
+		return &apierror.Error{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    apierror.CodePayloadTooLarge,
+			Message: "request body is too large",
+			Err:     err,
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &apierror.Error{
+			Status:  http.StatusBadRequest,
+			Code:    apierror.CodeBadRequest,
+			Message: "invalid value for " + typeErr.Field + ": expected " + typeErr.Type.String(),
+			Field:   typeErr.Field,
+			Err:     err,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for DisallowUnknownFields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &apierror.Error{
+			Status:  http.StatusBadRequest,
+			Code:    apierror.CodeBadRequest,
+			Message: "unknown field " + field,
+			Field:   field,
+			Err:     err,
+		}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &typeErr):
+		return &apierror.Error{
+			Status:  http.StatusBadRequest,
+			Code:    apierror.CodeBadRequest,
+			Message: "request body is not valid JSON",
+			Err:     err,
+		}
+	}
+	return &apierror.Error{
+		Status:  http.StatusBadRequest,
+		Code:    apierror.CodeBadRequest,
+		Message: "invalid request body",
+		Err:     err,
+	}
+}
+
+// Write writes v as a JSON response with the given status
+func Write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
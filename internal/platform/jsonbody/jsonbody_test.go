@@ -0,0 +1,170 @@
+package jsonbody
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/platform/apierror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type payload struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// jsonRequest builds a POST request with the given Content-Type and body
+func jsonRequest(contentType, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+// requireAPIError asserts err is an *apierror.Error with the given status
+func requireAPIError(t *testing.T, err error, status int) *apierror.Error {
+	t.Helper()
+	var apiErr *apierror.Error
+	require.True(t, errors.As(err, &apiErr), "got %v", err)
+	assert.Equal(t, status, apiErr.Status)
+	return apiErr
+}
+
+func TestDecode(t *testing.T) {
+	for _, contentType := range []string{"application/json", "application/json; charset=utf-8", "application/merge-patch+json"} {
+		var dst payload
+		require.NoError(t, Decode(jsonRequest(contentType, `{"name": "Go", "count": 2}`), &dst), contentType)
+		assert.Equal(t, payload{Name: "Go", Count: 2}, dst)
+	}
+}
+
+func TestDecode_RejectsOtherContentTypes(t *testing.T) {
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded", "not a media type"} {
+		var dst payload
+		err := Decode(jsonRequest(contentType, `{"name": "Go"}`), &dst)
+
+		apiErr := requireAPIError(t, err, http.StatusUnsupportedMediaType)
+		assert.Equal(t, apierror.CodeUnsupportedMedia, apiErr.Code, contentType)
+	}
+}
+
+func TestDecode_EmptyBodyIsNotMalformed(t *testing.T) {
+	var dst payload
+	err := Decode(jsonRequest("application/json", "  "), &dst)
+
+	apiErr := requireAPIError(t, err, http.StatusBadRequest)
+	assert.ErrorIs(t, err, ErrEmptyBody)
+	assert.Equal(t, "request body is required", apiErr.Message)
+}
+
+func TestDecode_MalformedJSON(t *testing.T) {
+	for _, body := range []string{`{"name": `, `name=Go`, `[1, 2]`} {
+		var dst payload
+		err := Decode(jsonRequest("application/json", body), &dst)
+
+		apiErr := requireAPIError(t, err, http.StatusBadRequest)
+		assert.NotErrorIs(t, err, ErrEmptyBody, body)
+		assert.Equal(t, "request body is not valid JSON", apiErr.Message, body)
+	}
+}
+
+func TestDecode_WrongFieldType(t *testing.T) {
+	var dst payload
+	err := Decode(jsonRequest("application/json", `{"count": "two"}`), &dst)
+
+	apiErr := requireAPIError(t, err, http.StatusBadRequest)
+	assert.Equal(t, "count", apiErr.Field)
+}
+
+func TestDecode_BodyTooLarge(t *testing.T) {
+	req := jsonRequest("application/json", `{"name": "`+strings.Repeat("a", 64)+`"}`)
+	req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 16)
+
+	var dst payload
+	err := Decode(req, &dst)
+
+	requireAPIError(t, err, http.StatusRequestEntityTooLarge)
+}
+
+type batch struct {
+	IDs []int `json:"ids"`
+}
+
+func TestDecodeLimited(t *testing.T) {
+	var dst batch
+	require.NoError(t, DecodeLimited(jsonRequest("application/json", `{"ids": [1, 2, 3]}`), &dst, 3))
+	assert.Equal(t, []int{1, 2, 3}, dst.IDs)
+}
+
+func TestDecodeLimited_RejectsHugeArrayInSmallBody(t *testing.T) {
+	// 100k elements in about 200KB, well under the default 1MB request size limit
+	body := `{"ids": [` + strings.Repeat("0,", 100_000-1) + `0]}`
+	require.Less(t, len(body), 1<<20)
+
+	var dst batch
+	err := DecodeLimited(jsonRequest("application/json", body), &dst, 100)
+
+	apiErr := requireAPIError(t, err, http.StatusRequestEntityTooLarge)
+	assert.ErrorIs(t, err, ErrTooManyElements)
+	assert.Contains(t, apiErr.Message, "at most 100")
+	assert.Nil(t, dst.IDs, "nothing is decoded once a limit is exceeded")
+}
+
+func TestDecodeLimited_CountsEveryArray(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown field", `{"extra": [1, 2, 3, 4]}`},
+		{"nested arrays", `{"ids": [1, 2], "extra": [[1, 2, 3, 4]]}`},
+		{"top-level array", `[{}, {}, {}, {}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst batch
+			err := DecodeLimited(jsonRequest("application/json", tt.body), &dst, 3)
+			assert.ErrorIs(t, err, ErrTooManyElements)
+		})
+	}
+}
+
+func TestDecodeLimited_RejectsDeepNesting(t *testing.T) {
+	body := strings.Repeat("[", MaxDepth+1) + strings.Repeat("]", MaxDepth+1)
+
+	var dst batch
+	err := DecodeLimited(jsonRequest("application/json", body), &dst, 10)
+
+	requireAPIError(t, err, http.StatusRequestEntityTooLarge)
+	assert.ErrorIs(t, err, ErrTooDeep)
+}
+
+func TestDecodeLimited_RejectsUnknownFields(t *testing.T) {
+	var dst batch
+	err := DecodeLimited(jsonRequest("application/json", `{"ids": [1], "admin": true}`), &dst, 10)
+
+	apiErr := requireAPIError(t, err, http.StatusBadRequest)
+	assert.Equal(t, "admin", apiErr.Field)
+	assert.Equal(t, "unknown field admin", apiErr.Message)
+}
+
+func TestDecodeLimited_ReportsLikeDecode(t *testing.T) {
+	var dst batch
+	assert.ErrorIs(t, DecodeLimited(jsonRequest("application/json", ""), &dst, 10), ErrEmptyBody)
+	requireAPIError(t, DecodeLimited(jsonRequest("text/plain", `{"ids": []}`), &dst, 10), http.StatusUnsupportedMediaType)
+	requireAPIError(t, DecodeLimited(jsonRequest("application/json", `{"ids": [`), &dst, 10), http.StatusBadRequest)
+}
+
+func TestWrite(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Write(rr, http.StatusCreated, batch{IDs: []int{1, 2}})
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"ids": [1, 2]}`, rr.Body.String())
+}
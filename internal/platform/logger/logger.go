@@ -6,6 +6,8 @@ import (
 	"log/slog"
 	"os"
 
+	"backend/internal/platform/requestctx"
+
 	"github.com/google/uuid"
 )
 
@@ -16,17 +18,19 @@ type Logger struct {
 
 // Config holds logger configuration
 type Config struct {
-	Env    string
-	Level  slog.Level
-	Output io.Writer
+	Env            string
+	Level          slog.Level
+	Output         io.Writer
+	SanitizeFields bool // Escape control characters in messages and field values
 }
 
 // New creates a new structured logger
 func New(env string) *Logger {
 	return NewWithConfig(Config{
-		Env:    env,
-		Level:  slog.LevelInfo,
-		Output: os.Stdout,
+		Env:            env,
+		Level:          slog.LevelInfo,
+		Output:         os.Stdout,
+		SanitizeFields: true,
 	})
 }
 
@@ -50,6 +54,10 @@ func NewWithConfig(cfg Config) *Logger {
 		handler = slog.NewTextHandler(cfg.Output, opts)
 	}
 
+	if cfg.SanitizeFields {
+		handler = NewSanitizingHandler(handler)
+	}
+
 	return &Logger{
 		Logger: slog.New(handler),
 	}
@@ -117,9 +125,8 @@ func (l *Logger) WithCorrelationID(ctx context.Context) *Logger {
 	if corrID, ok := ctx.Value(CorrelationIDKey).(string); ok && corrID != "" {
 		return l.WithField("correlation_id", corrID)
 	}
-	// Also check for request_id key (from middleware)
-	type requestIDKey string
-	if reqID, ok := ctx.Value(requestIDKey("request_id")).(string); ok && reqID != "" {
+	// Also check for the request ID set by the RequestID middleware
+	if reqID := requestctx.RequestID(ctx); reqID != "" {
 		return l.WithField("correlation_id", reqID)
 	}
 	return l
@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// SanitizeValue escapes control and line-separator characters so user-controlled
+// values cannot break a log line apart or forge new entries.
+// Newlines, carriage returns and tabs become \n, \r and \t; anything else becomes \uXXXX.
+func SanitizeValue(s string) string {
+	if strings.IndexFunc(s, needsEscape) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case needsEscape(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// needsEscape reports control characters, Unicode line/paragraph separators and
+// bidi overrides, all of which can make a log line render differently from its content
+func needsEscape(r rune) bool {
+	return unicode.IsControl(r) || r == '\u2028' || r == '\u2029' || unicode.Is(unicode.Bidi_Control, r)
+}
+
+// sanitizeAttr neutralises string, error and Stringer values, including inside groups
+func sanitizeAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(SanitizeValue(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			a.Value = slog.StringValue(SanitizeValue(v.Error()))
+		case fmt.Stringer:
+			a.Value = slog.StringValue(SanitizeValue(v.String()))
+		}
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		sanitized := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			sanitized[i] = sanitizeAttr(attr)
+		}
+		a.Value = slog.GroupValue(sanitized...)
+	}
+	return a
+}
+
+// sanitizingHandler escapes the message and attribute values of every record
+// before passing it to the wrapped handler
+type sanitizingHandler struct {
+	next slog.Handler
+}
+
+// NewSanitizingHandler wraps next so every message and attribute is sanitized
+func NewSanitizingHandler(next slog.Handler) slog.Handler {
+	return &sanitizingHandler{next: next}
+}
+
+func (h *sanitizingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *sanitizingHandler) Handle(ctx context.Context, record slog.Record) error {
+	sanitized := slog.NewRecord(record.Time, record.Level, SanitizeValue(record.Message), record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		sanitized.AddAttrs(sanitizeAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, sanitized)
+}
+
+func (h *sanitizingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	sanitized := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		sanitized[i] = sanitizeAttr(a)
+	}
+	return &sanitizingHandler{next: h.next.WithAttrs(sanitized)}
+}
+
+func (h *sanitizingHandler) WithGroup(name string) slog.Handler {
+	return &sanitizingHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"alice@example.com", "alice@example.com"},
+		{"line1\nline2", `line1\nline2`},
+		{"a\r\nb\tc", `a\r\nb\tc`},
+		{"bell\x07esc\x1b[31m", `bell\u0007esc\u001b[31m`},
+		{"sep\u2028arator", `sep\u2028arator`},
+		{"rtl\u202eoverride", `rtl\u202eoverride`},
+		{"café ✓", "café ✓"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, SanitizeValue(tt.input), "input %q", tt.input)
+	}
+}
+
+func TestLogger_SanitizesFields(t *testing.T) {
+	forged := "bob\nlevel=ERROR msg=\"admin login\""
+
+	for _, env := range []string{"development", "production"} {
+		t.Run(env, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := NewWithConfig(Config{Env: env, Output: &buf, SanitizeFields: true})
+
+			log.WithField("domain", "evil\r\ninjected").Info("login "+forged,
+				"email", forged,
+				"error", errors.New("bad\ninput"),
+			)
+
+			out := buf.String()
+			assert.Equal(t, 1, strings.Count(out, "\n"), "record must stay on one line: %s", out)
+			assert.NotContains(t, out, "\r")
+			assert.NotContains(t, out, "\nlevel=ERROR")
+			assert.Contains(t, out, "injected")
+			assert.Contains(t, out, "input")
+		})
+	}
+}
+
+func TestLogger_SanitizeFieldsDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewWithConfig(Config{Env: "production", Output: &buf})
+
+	log.Info("event", "value", "a\nb")
+
+	// The JSON handler still escapes the newline itself, but only once
+	assert.Contains(t, buf.String(), `"value":"a\nb"`)
+}
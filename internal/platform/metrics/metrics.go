@@ -69,6 +69,14 @@ var (
 		},
 	)
 
+	circuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "circuit_breaker_state",
+			Help: "Circuit breaker state (0 closed, 1 half-open, 2 open)",
+		},
+		[]string{"name"},
+	)
+
 	dbQueryDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "db_query_duration_seconds",
@@ -84,7 +92,7 @@ var (
 			Name: "jwt_validation_total",
 			Help: "Total number of JWT validation attempts",
 		},
-		[]string{"status"},
+		[]string{"status", "reason"},
 	)
 
 	// Business Metrics
@@ -110,12 +118,19 @@ var (
 		[]string{"status"},
 	)
 
+	expiredRecommendationsDeleted = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "recommendations_expired_deleted_total",
+			Help: "Total number of expired recommendations deleted by cleanup",
+		},
+	)
+
 	aiRequestsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "ai_requests_total",
 			Help: "Total number of AI requests",
 		},
-		[]string{"provider", "status"},
+		[]string{"provider", "model", "status"},
 	)
 
 	aiRequestDuration = prometheus.NewHistogramVec(
@@ -124,7 +139,49 @@ var (
 			Help:    "AI request duration in seconds",
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{"provider"},
+		[]string{"provider", "model"},
+	)
+
+	aiTokensTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_tokens_total",
+			Help: "Total number of AI tokens consumed by type (prompt or completion)",
+		},
+		[]string{"provider", "model", "type"},
+	)
+
+	aiRequestTokens = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "ai_request_tokens",
+			Help:    "Tokens used per AI request by type (prompt or completion)",
+			Buckets: prometheus.ExponentialBuckets(64, 2, 10), // 64 to 32768 tokens
+		},
+		[]string{"provider", "model", "type"},
+	)
+
+	aiBudgetCallsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_budget_calls_total",
+			Help: "Total number of AI calls checked against the per-user daily budget by result (allowed, exceeded or error)",
+		},
+		[]string{"call", "result"},
+	)
+
+	// Cache Metrics
+	cacheRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Total number of cache lookups by result (hit or miss)",
+		},
+		[]string{"cache", "result"},
+	)
+
+	cacheEvictionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Total number of cache evictions by reason (expired or capacity)",
+		},
+		[]string{"cache", "reason"},
 	)
 )
 
@@ -138,13 +195,20 @@ func init() {
 		dbConnectionsOpen,
 		dbConnectionsInUse,
 		dbConnectionsIdle,
+		circuitBreakerState,
 		dbQueryDuration,
 		jwtValidationTotal,
 		userRegistrationsTotal,
 		userLoginsTotal,
 		exerciseSubmissionsTotal,
+		expiredRecommendationsDeleted,
 		aiRequestsTotal,
 		aiRequestDuration,
+		aiTokensTotal,
+		aiRequestTokens,
+		aiBudgetCallsTotal,
+		cacheRequestsTotal,
+		cacheEvictionsTotal,
 	)
 }
 
@@ -180,13 +244,38 @@ func UpdateDatabaseMetrics(db *sql.DB) {
 	dbConnectionsIdle.Set(float64(stats.Idle))
 }
 
-// RecordJWTValidation records JWT validation metrics
-func RecordJWTValidation(success bool) {
+// circuitBreakerStates maps breaker state names to their gauge values
+var circuitBreakerStates = map[string]float64{
+	"closed":    0,
+	"half-open": 1,
+	"open":      2,
+}
+
+// RecordCircuitBreakerState records the current state ("closed", "half-open" or "open") of a circuit breaker
+func RecordCircuitBreakerState(name, state string) {
+	value, ok := circuitBreakerStates[state]
+	if !ok {
+		return
+	}
+	circuitBreakerState.WithLabelValues(name).Set(value)
+}
+
+// RecordJWTValidation records a JWT validation attempt. reason says why a token
+// was rejected (e.g. "expired") and is ignored for a valid one.
+func RecordJWTValidation(success bool, reason string) {
 	status := "success"
-	if !success {
+	if success {
+		reason = ""
+	} else {
 		status = "failure"
 	}
-	jwtValidationTotal.WithLabelValues(status).Inc()
+	jwtValidationTotal.WithLabelValues(status, reason).Inc()
+}
+
+// RecordJWTAbsent records a request to an optionally authenticated route that
+// carried no token, so anonymous traffic is not counted as a failure
+func RecordJWTAbsent() {
+	jwtValidationTotal.WithLabelValues("no_token", "").Inc()
 }
 
 // RecordUserRegistration records a user registration
@@ -208,14 +297,64 @@ func RecordExerciseSubmission(success bool) {
 	exerciseSubmissionsTotal.WithLabelValues(status).Inc()
 }
 
-// RecordAIRequest records AI request metrics
-func RecordAIRequest(provider string, duration time.Duration, success bool) {
+// Recorder forwards domain business events to the package-level counters so
+// services can count them without importing this package
+type Recorder struct{}
+
+// RecordUserRegistration records a user registration
+func (Recorder) RecordUserRegistration() { RecordUserRegistration() }
+
+// RecordUserLogin records a user login
+func (Recorder) RecordUserLogin() { RecordUserLogin() }
+
+// RecordExerciseSubmission records an exercise submission and whether it passed
+func (Recorder) RecordExerciseSubmission(passed bool) { RecordExerciseSubmission(passed) }
+
+// RecordExpiredRecommendationsDeleted records recommendations removed by expiry cleanup
+func RecordExpiredRecommendationsDeleted(count int64) {
+	expiredRecommendationsDeleted.Add(float64(count))
+}
+
+// RecordAIRequest records AI request metrics for the model that handled the request
+func RecordAIRequest(provider, model string, duration time.Duration, success bool) {
 	status := "success"
 	if !success {
 		status = "failure"
 	}
-	aiRequestsTotal.WithLabelValues(provider, status).Inc()
-	aiRequestDuration.WithLabelValues(provider).Observe(duration.Seconds())
+	aiRequestsTotal.WithLabelValues(provider, model, status).Inc()
+	aiRequestDuration.WithLabelValues(provider, model).Observe(duration.Seconds())
+}
+
+// RecordAIBudget records one AI call checked against a user's daily budget
+func RecordAIBudget(call, result string) {
+	aiBudgetCallsTotal.WithLabelValues(call, result).Inc()
+}
+
+// RecordAITokens records token usage reported by an AI provider for one request
+func RecordAITokens(provider, model string, promptTokens, completionTokens int) {
+	if promptTokens > 0 {
+		aiTokensTotal.WithLabelValues(provider, model, "prompt").Add(float64(promptTokens))
+		aiRequestTokens.WithLabelValues(provider, model, "prompt").Observe(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		aiTokensTotal.WithLabelValues(provider, model, "completion").Add(float64(completionTokens))
+		aiRequestTokens.WithLabelValues(provider, model, "completion").Observe(float64(completionTokens))
+	}
+}
+
+// RecordCacheHit records a cache lookup that found a live entry
+func RecordCacheHit(cache string) {
+	cacheRequestsTotal.WithLabelValues(cache, "hit").Inc()
+}
+
+// RecordCacheMiss records a cache lookup that found nothing or an expired entry
+func RecordCacheMiss(cache string) {
+	cacheRequestsTotal.WithLabelValues(cache, "miss").Inc()
+}
+
+// RecordCacheEviction records an entry removed for expiry or to make room
+func RecordCacheEviction(cache, reason string) {
+	cacheEvictionsTotal.WithLabelValues(cache, reason).Inc()
 }
 
 // Handler returns the Prometheus HTTP handler
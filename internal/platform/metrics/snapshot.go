@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Snapshot is a JSON view of the key application metrics for dashboards that
+// do not scrape Prometheus. Values are read from the same collectors /metrics exposes.
+type Snapshot struct {
+	GeneratedAt     time.Time          `json:"generated_at"`
+	HTTPRequests    map[string]float64 `json:"http_requests"` // Totals by status class (2xx, 4xx, ...)
+	Database        DatabaseSnapshot   `json:"database"`
+	CircuitBreakers map[string]string  `json:"circuit_breakers"` // State by breaker name
+	AIRequests      map[string]float64 `json:"ai_requests"`      // Totals by status (success, failure)
+	AITokens        map[string]float64 `json:"ai_tokens"`        // Totals by type (prompt, completion)
+
+	UserRegistrations   float64            `json:"user_registrations"`
+	UserLogins          float64            `json:"user_logins"`
+	ExerciseSubmissions map[string]float64 `json:"exercise_submissions"` // Totals by status (success, failure)
+}
+
+// DatabaseSnapshot holds the last recorded connection pool stats
+type DatabaseSnapshot struct {
+	OpenConnections  int `json:"open_connections"`
+	InUseConnections int `json:"in_use_connections"`
+	IdleConnections  int `json:"idle_connections"`
+}
+
+// TakeSnapshot reads the current metric values
+func TakeSnapshot() Snapshot {
+	breakers := make(map[string]string)
+	for name, value := range sumByLabel(circuitBreakerState, "name", nil) {
+		for state, stateValue := range circuitBreakerStates {
+			if value == stateValue {
+				breakers[name] = state
+			}
+		}
+	}
+
+	return Snapshot{
+		GeneratedAt: time.Now().UTC(),
+		HTTPRequests: sumByLabel(httpRequestsTotal, "status", func(status string) string {
+			if len(status) != 3 {
+				return "other"
+			}
+			return status[:1] + "xx"
+		}),
+		Database: DatabaseSnapshot{
+			OpenConnections:  int(gaugeValue(dbConnectionsOpen)),
+			InUseConnections: int(gaugeValue(dbConnectionsInUse)),
+			IdleConnections:  int(gaugeValue(dbConnectionsIdle)),
+		},
+		CircuitBreakers: breakers,
+		AIRequests:      sumByLabel(aiRequestsTotal, "status", nil),
+		AITokens:        sumByLabel(aiTokensTotal, "type", nil),
+
+		UserRegistrations:   counterValue(userRegistrationsTotal),
+		UserLogins:          counterValue(userLoginsTotal),
+		ExerciseSubmissions: sumByLabel(exerciseSubmissionsTotal, "status", nil),
+	}
+}
+
+// SnapshotHandler serves TakeSnapshot as JSON. It must be mounted behind admin auth.
+func SnapshotHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(TakeSnapshot())
+	})
+}
+
+// sumByLabel totals counter or gauge values grouped by one label. When group
+// is set, label values are mapped through it before being summed.
+func sumByLabel(collector prometheus.Collector, label string, group func(string) string) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, m := range collect(collector) {
+		key := ""
+		for _, pair := range m.GetLabel() {
+			if pair.GetName() == label {
+				key = pair.GetValue()
+			}
+		}
+		if group != nil {
+			key = group(key)
+		}
+		totals[key] += m.GetCounter().GetValue() + m.GetGauge().GetValue()
+	}
+	return totals
+}
+
+// gaugeValue returns the current value of a single gauge
+func gaugeValue(gauge prometheus.Gauge) float64 {
+	var value float64
+	for _, m := range collect(gauge) {
+		value += m.GetGauge().GetValue()
+	}
+	return value
+}
+
+// counterValue returns the current value of a single counter
+func counterValue(counter prometheus.Counter) float64 {
+	var value float64
+	for _, m := range collect(counter) {
+		value += m.GetCounter().GetValue()
+	}
+	return value
+}
+
+// collect gathers the current samples of a collector
+func collect(collector prometheus.Collector) []*dto.Metric {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collector.Collect(ch)
+		close(ch)
+	}()
+
+	var samples []*dto.Metric
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err == nil {
+			samples = append(samples, m)
+		}
+	}
+	return samples
+}
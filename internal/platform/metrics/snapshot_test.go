@@ -0,0 +1,78 @@
+package metrics_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/platform/metrics"
+	"backend/internal/platform/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeSnapshot_ReflectsRecordedMetrics(t *testing.T) {
+	before := metrics.TakeSnapshot()
+
+	metrics.RecordHTTPRequest("GET", "/api/courses", http.StatusOK, time.Millisecond, 0, 0)
+	metrics.RecordHTTPRequest("GET", "/api/courses", http.StatusCreated, time.Millisecond, 0, 0)
+	metrics.RecordHTTPRequest("GET", "/api/courses", http.StatusNotFound, time.Millisecond, 0, 0)
+	metrics.RecordAIRequest("openai", "gpt-4", time.Second, false)
+	metrics.RecordAITokens("openai", "gpt-4", 120, 30)
+	metrics.RecordCircuitBreakerState("snapshot-test", "open")
+	recorder := metrics.Recorder{}
+	recorder.RecordUserRegistration()
+	recorder.RecordUserLogin()
+	recorder.RecordUserLogin()
+	recorder.RecordExerciseSubmission(true)
+	recorder.RecordExerciseSubmission(false)
+
+	after := metrics.TakeSnapshot()
+
+	assert.Equal(t, 2.0, after.HTTPRequests["2xx"]-before.HTTPRequests["2xx"])
+	assert.Equal(t, 1.0, after.HTTPRequests["4xx"]-before.HTTPRequests["4xx"])
+	assert.Equal(t, 1.0, after.AIRequests["failure"]-before.AIRequests["failure"])
+	assert.Equal(t, 120.0, after.AITokens["prompt"]-before.AITokens["prompt"])
+	assert.Equal(t, 30.0, after.AITokens["completion"]-before.AITokens["completion"])
+	assert.Equal(t, "open", after.CircuitBreakers["snapshot-test"])
+	assert.Equal(t, 1.0, after.UserRegistrations-before.UserRegistrations)
+	assert.Equal(t, 2.0, after.UserLogins-before.UserLogins)
+	assert.Equal(t, 1.0, after.ExerciseSubmissions["success"]-before.ExerciseSubmissions["success"])
+	assert.Equal(t, 1.0, after.ExerciseSubmissions["failure"]-before.ExerciseSubmissions["failure"])
+}
+
+func TestSnapshotHandler_RequiresAdmin(t *testing.T) {
+	handler := middleware.RequireAdmin()(metrics.SnapshotHandler())
+
+	tests := []struct {
+		name   string
+		claims *middleware.UserClaims
+		want   int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"learner", &middleware.UserClaims{UserID: "user-1"}, http.StatusForbidden},
+		{"admin", &middleware.UserClaims{UserID: "admin-1", IsAdmin: true}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/metrics/snapshot", nil)
+			if tt.claims != nil {
+				req = req.WithContext(middleware.ContextWithUser(req.Context(), tt.claims))
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.want, rr.Code)
+			if tt.want == http.StatusOK {
+				var snapshot metrics.Snapshot
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &snapshot))
+				assert.NotNil(t, snapshot.HTTPRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"backend/internal/platform/logger"
+)
+
+// requestUserKey is the context key for the per-request user holder
+type requestUserKey struct{}
+
+// requestUser lets Auth, which runs inside the router, report the authenticated
+// user back to middleware that wrapped the request before authentication
+type requestUser struct {
+	mu     sync.Mutex
+	userID string
+}
+
+// recordRequestUser stores userID in the holder placed by AccessLog, if any
+func recordRequestUser(ctx context.Context, userID string) {
+	if holder, ok := ctx.Value(requestUserKey{}).(*requestUser); ok {
+		holder.mu.Lock()
+		holder.userID = userID
+		holder.mu.Unlock()
+	}
+}
+
+// get returns the recorded user ID
+func (u *requestUser) get() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.userID
+}
+
+// AccessLog emits one structured line per request with its method, path, status,
+// duration, bytes written, request ID and, once authenticated, user ID.
+// 5xx responses log at error level and 4xx at warn so alerts can key off the level.
+func AccessLog(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := resolveRequestID(r)
+			w.Header().Set("X-Request-ID", requestID)
+
+			user := &requestUser{}
+			ctx := contextWithRequestID(r.Context(), requestID)
+			ctx = context.WithValue(ctx, requestUserKey{}, user)
+
+			wrapped := wrapResponseWriter(w)
+			next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+			duration := time.Since(start)
+
+			level := slog.LevelInfo
+			switch {
+			case wrapped.statusCode >= 500:
+				level = slog.LevelError
+			case wrapped.statusCode >= 400:
+				level = slog.LevelWarn
+			}
+
+			// Request fields are client-controlled; escape them even if the logger does not
+			attrs := []slog.Attr{
+				slog.String("request_id", logger.SanitizeValue(requestID)),
+				slog.String("method", logger.SanitizeValue(r.Method)),
+				slog.String("path", logger.SanitizeValue(r.URL.Path)),
+				slog.Int("status", wrapped.statusCode),
+				slog.Int64("duration_ms", duration.Milliseconds()),
+				slog.Int64("bytes_written", wrapped.written),
+			}
+			if userID := user.get(); userID != "" {
+				attrs = append(attrs, slog.String("user_id", userID))
+			}
+
+			log.LogAttrs(r.Context(), level, "http_request", attrs...)
+		})
+	}
+}
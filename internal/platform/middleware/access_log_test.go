@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// captureHandler keeps every record the logger emits
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler      { return h }
+
+// attrs flattens a record's attributes into a map
+func attrs(record slog.Record) map[string]slog.Value {
+	values := map[string]slog.Value{}
+	record.Attrs(func(a slog.Attr) bool {
+		values[a.Key] = a.Value
+		return true
+	})
+	return values
+}
+
+func TestAccessLog_SingleLineWithUser(t *testing.T) {
+	capture := &captureHandler{}
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Authentication happens inside the router, after AccessLog wrapped the request
+		r = r.WithContext(ContextWithUser(r.Context(), &UserClaims{UserID: "user-42"}))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+	handler := AccessLog(slog.New(capture))(Metrics()(inner))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/courses", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(capture.records) != 1 {
+		t.Fatalf("expected exactly one log line, got %d", len(capture.records))
+	}
+	record := capture.records[0]
+	values := attrs(record)
+
+	if record.Level != slog.LevelInfo {
+		t.Errorf("level = %v, want INFO", record.Level)
+	}
+	if got := values["method"].String(); got != http.MethodPost {
+		t.Errorf("method = %q", got)
+	}
+	if got := values["path"].String(); got != "/api/courses" {
+		t.Errorf("path = %q", got)
+	}
+	if got := values["status"].Int64(); got != http.StatusCreated {
+		t.Errorf("status = %d, want 201", got)
+	}
+	if got := values["bytes_written"].Int64(); got != 5 {
+		t.Errorf("bytes_written = %d, want 5", got)
+	}
+	if got := values["request_id"].String(); got != "req-1" {
+		t.Errorf("request_id = %q", got)
+	}
+	if got := values["user_id"].String(); got != "user-42" {
+		t.Errorf("user_id = %q, want user-42", got)
+	}
+	if _, ok := values["duration_ms"]; !ok {
+		t.Error("expected duration_ms")
+	}
+}
+
+func TestAccessLog_LevelsByStatusAndAnonymous(t *testing.T) {
+	tests := []struct {
+		status int
+		level  slog.Level
+	}{
+		{http.StatusOK, slog.LevelInfo},
+		{http.StatusNotFound, slog.LevelWarn},
+		{http.StatusBadGateway, slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		capture := &captureHandler{}
+		handler := AccessLog(slog.New(capture))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if len(capture.records) != 1 {
+			t.Fatalf("status %d: expected one log line, got %d", tt.status, len(capture.records))
+		}
+		if got := capture.records[0].Level; got != tt.level {
+			t.Errorf("status %d: level = %v, want %v", tt.status, got, tt.level)
+		}
+		if _, ok := attrs(capture.records[0])["user_id"]; ok {
+			t.Errorf("status %d: anonymous request should not log user_id", tt.status)
+		}
+	}
+}
+
+func TestWrapResponseWriter_ReusesExistingWrapper(t *testing.T) {
+	outer := wrapResponseWriter(httptest.NewRecorder())
+
+	if inner := wrapResponseWriter(outer); inner != outer {
+		t.Error("expected the existing wrapper to be reused")
+	}
+
+	outer.WriteHeader(http.StatusAccepted)
+	outer.WriteHeader(http.StatusInternalServerError)
+	if outer.statusCode != http.StatusAccepted {
+		t.Errorf("statusCode = %d, want the first status written", outer.statusCode)
+	}
+}
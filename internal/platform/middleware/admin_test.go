@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	secret := "test-secret"
+	signToken := func(isAdmin bool) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &UserClaims{
+			UserID:  "user-1",
+			IsAdmin: isAdmin,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		signed, _ := token.SignedString([]byte(secret))
+		return signed
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := Auth(secret)(RequireAdmin()(next))
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"admin token", "Bearer " + signToken(true), http.StatusOK},
+		{"non-admin token", "Bearer " + signToken(false), http.StatusForbidden},
+		{"no token", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/trending/refresh", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+
+	t.Run("without Auth", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		RequireAdmin()(next).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/trending/refresh", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
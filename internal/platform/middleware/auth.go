@@ -3,10 +3,13 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"backend/internal/platform/metrics"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -25,13 +28,15 @@ type UserClaims struct {
 	jwt.RegisteredClaims
 }
 
-// Auth validates JWT tokens
+// Auth validates JWT tokens, recording every attempt in the jwt_validation_total
+// metric with the reason a rejected token failed
 func Auth(jwtSecret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract token from Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
+				metrics.RecordJWTValidation(false, "missing_token")
 				writeError(w, "missing authorization header", http.StatusUnauthorized)
 				return
 			}
@@ -39,6 +44,7 @@ func Auth(jwtSecret string) func(http.Handler) http.Handler {
 			// Check for Bearer token format
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
+				metrics.RecordJWTValidation(false, "malformed_header")
 				writeError(w, "invalid authorization header format", http.StatusUnauthorized)
 				return
 			}
@@ -55,11 +61,13 @@ func Auth(jwtSecret string) func(http.Handler) http.Handler {
 			})
 
 			if err != nil {
+				metrics.RecordJWTValidation(false, tokenFailureReason(err))
 				writeError(w, fmt.Sprintf("invalid token: %v", err), http.StatusUnauthorized)
 				return
 			}
 
 			if !token.Valid {
+				metrics.RecordJWTValidation(false, "invalid")
 				writeError(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
@@ -67,19 +75,20 @@ func Auth(jwtSecret string) func(http.Handler) http.Handler {
 			// Extract claims
 			claims, ok := token.Claims.(*UserClaims)
 			if !ok {
+				metrics.RecordJWTValidation(false, "invalid_claims")
 				writeError(w, "invalid token claims", http.StatusUnauthorized)
 				return
 			}
+			metrics.RecordJWTValidation(true, "")
 
 			// Add user context to request (both for backward compatibility and tracing)
-			ctx := context.WithValue(r.Context(), userContextKey{}, claims)
-			ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), claims)))
 		})
 	}
 }
 
-// OptionalAuth validates JWT tokens but doesn't require them
+// OptionalAuth validates JWT tokens but doesn't require them. Requests without a
+// token are recorded as no_token rather than as failed validations.
 func OptionalAuth(jwtSecret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -87,6 +96,7 @@ func OptionalAuth(jwtSecret string) func(http.Handler) http.Handler {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				// No token provided, continue without user context
+				metrics.RecordJWTAbsent()
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -95,6 +105,7 @@ func OptionalAuth(jwtSecret string) func(http.Handler) http.Handler {
 			parts := strings.Split(authHeader, " ")
 			if len(parts) != 2 || parts[0] != "Bearer" {
 				// Invalid format, continue without user context
+				metrics.RecordJWTValidation(false, "malformed_header")
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -111,22 +122,55 @@ func OptionalAuth(jwtSecret string) func(http.Handler) http.Handler {
 
 			if err != nil || !token.Valid {
 				// Invalid token, continue without user context
+				reason := "invalid"
+				if err != nil {
+					reason = tokenFailureReason(err)
+				}
+				metrics.RecordJWTValidation(false, reason)
 				next.ServeHTTP(w, r)
 				return
 			}
 
 			// Extract claims and add to context if valid
 			if claims, ok := token.Claims.(*UserClaims); ok {
-				ctx := context.WithValue(r.Context(), userContextKey{}, claims)
-				next.ServeHTTP(w, r.WithContext(ctx))
+				metrics.RecordJWTValidation(true, "")
+				next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), claims)))
 				return
 			}
 
+			metrics.RecordJWTValidation(false, "invalid_claims")
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// tokenFailureReason classifies a token parse error for the jwt_validation_total
+// reason label, keeping the label set small enough to alert on
+func tokenFailureReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "not_yet_valid"
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return "invalid_signature"
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return "malformed"
+	case errors.Is(err, jwt.ErrTokenUnverifiable):
+		return "unverifiable"
+	default:
+		return "invalid"
+	}
+}
+
+// ContextWithUser returns a copy of ctx carrying the given user claims,
+// as the Auth middleware does after validating a token
+func ContextWithUser(ctx context.Context, claims *UserClaims) context.Context {
+	recordRequestUser(ctx, claims.UserID)
+	ctx = context.WithValue(ctx, userContextKey{}, claims)
+	return context.WithValue(ctx, UserIDKey, claims.UserID)
+}
+
 // GetUserFromContext retrieves user claims from request context
 func GetUserFromContext(ctx context.Context) (*UserClaims, bool) {
 	claims, ok := ctx.Value(userContextKey{}).(*UserClaims)
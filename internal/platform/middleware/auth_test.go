@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func generateTestToken(secret, userID, email string) string {
@@ -224,3 +226,68 @@ func TestGetUserIDFromContext(t *testing.T) {
 	assert.Equal(t, "user-123", userID)
 }
 
+// jwtValidationCount returns jwt_validation_total for one status and reason
+func jwtValidationCount(t *testing.T, status, reason string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "jwt_validation_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["status"] == status && labels["reason"] == reason {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestAuth_RecordsJWTValidation(t *testing.T) {
+	secret := "test-secret"
+	handler := Auth(secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(authHeader string) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", authHeader)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	before := jwtValidationCount(t, "failure", "invalid_signature")
+	serve("Bearer " + generateTestToken("other-secret", "user-123", "test@example.com"))
+	assert.Equal(t, before+1, jwtValidationCount(t, "failure", "invalid_signature"))
+
+	before = jwtValidationCount(t, "failure", "malformed")
+	serve("Bearer invalid.token.here")
+	assert.Equal(t, before+1, jwtValidationCount(t, "failure", "malformed"))
+
+	before = jwtValidationCount(t, "success", "")
+	serve("Bearer " + generateTestToken(secret, "user-123", "test@example.com"))
+	assert.Equal(t, before+1, jwtValidationCount(t, "success", ""))
+}
+
+func TestOptionalAuth_DistinguishesMissingFromInvalidToken(t *testing.T) {
+	handler := OptionalAuth("test-secret")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	absent := jwtValidationCount(t, "no_token", "")
+	invalid := jwtValidationCount(t, "failure", "malformed")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, absent+1, jwtValidationCount(t, "no_token", ""))
+	assert.Equal(t, invalid, jwtValidationCount(t, "failure", "malformed"), "a missing token is not a failure")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer invalid.token.here")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, invalid+1, jwtValidationCount(t, "failure", "malformed"))
+	assert.Equal(t, absent+1, jwtValidationCount(t, "no_token", ""))
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrForbidden is returned when the requester may not access a resource
+var ErrForbidden = errors.New("forbidden: you do not have access to this resource")
+
+// IsOwnerOrAdmin reports whether the authenticated requester owns the resource
+// or is an admin. Unauthenticated requests and empty owner IDs are always denied.
+func IsOwnerOrAdmin(ctx context.Context, ownerID string) bool {
+	claims, ok := GetUserFromContext(ctx)
+	if !ok || claims == nil || claims.UserID == "" {
+		return false
+	}
+
+	if claims.IsAdmin {
+		return true
+	}
+
+	return ownerID != "" && claims.UserID == ownerID
+}
+
+// AuthorizeOwnerOrAdmin returns ErrForbidden unless the requester owns the
+// resource or is an admin
+func AuthorizeOwnerOrAdmin(ctx context.Context, ownerID string) error {
+	if !IsOwnerOrAdmin(ctx, ownerID) {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func contextWithClaims(claims *UserClaims) context.Context {
+	return context.WithValue(context.Background(), userContextKey{}, claims)
+}
+
+func TestIsOwnerOrAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		ownerID  string
+		expected bool
+	}{
+		{
+			name:     "owner is allowed",
+			ctx:      contextWithClaims(&UserClaims{UserID: "user-1"}),
+			ownerID:  "user-1",
+			expected: true,
+		},
+		{
+			name:     "admin is allowed",
+			ctx:      contextWithClaims(&UserClaims{UserID: "admin-1", IsAdmin: true}),
+			ownerID:  "user-1",
+			expected: true,
+		},
+		{
+			name:     "unrelated user is denied",
+			ctx:      contextWithClaims(&UserClaims{UserID: "user-2"}),
+			ownerID:  "user-1",
+			expected: false,
+		},
+		{
+			name:     "unauthenticated request is denied",
+			ctx:      context.Background(),
+			ownerID:  "user-1",
+			expected: false,
+		},
+		{
+			name:     "empty owner is denied for non-admin",
+			ctx:      contextWithClaims(&UserClaims{UserID: "user-1"}),
+			ownerID:  "",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsOwnerOrAdmin(tt.ctx, tt.ownerID))
+		})
+	}
+}
+
+func TestAuthorizeOwnerOrAdmin(t *testing.T) {
+	ctx := contextWithClaims(&UserClaims{UserID: "user-2"})
+
+	assert.ErrorIs(t, AuthorizeOwnerOrAdmin(ctx, "user-1"), ErrForbidden)
+	assert.NoError(t, AuthorizeOwnerOrAdmin(ctx, "user-2"))
+}
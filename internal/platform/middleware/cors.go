@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -58,8 +59,8 @@ func CORSWithConfig(config CORSConfig) func(http.Handler) http.Handler {
 			if len(config.AllowedOrigins) == 1 && config.AllowedOrigins[0] == "*" {
 				allowedOrigin = "*"
 			} else {
-				for _, allowedOrigin := range config.AllowedOrigins {
-					if allowedOrigin == origin {
+				for _, candidate := range config.AllowedOrigins {
+					if origin != "" && candidate == origin {
 						allowedOrigin = origin
 						break
 					}
@@ -70,6 +71,10 @@ func CORSWithConfig(config CORSConfig) func(http.Handler) http.Handler {
 			if allowedOrigin != "" {
 				// Set Access-Control-Allow-Origin
 				w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
+				if allowedOrigin != "*" {
+					// The response depends on the request origin, so shared caches must key on it
+					w.Header().Add("Vary", "Origin")
+				}
 
 				// Set Access-Control-Allow-Methods
 				if len(config.AllowedMethods) > 0 {
@@ -93,7 +98,7 @@ func CORSWithConfig(config CORSConfig) func(http.Handler) http.Handler {
 
 				// Set Access-Control-Max-Age for preflight requests
 				if r.Method == http.MethodOptions && config.MaxAge > 0 {
-					w.Header().Set("Access-Control-Max-Age", string(rune(config.MaxAge)))
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
 				}
 			}
 
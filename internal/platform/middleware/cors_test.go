@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS_MaxAgeIsDecimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxAge   int
+		expected string
+	}{
+		{"default", 86400, "86400"},
+		{"short", 600, "600"},
+		{"disabled", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultCORSConfig()
+			config.MaxAge = tt.maxAge
+
+			handler := CORSWithConfig(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("preflight request should not reach the next handler")
+			}))
+
+			req := httptest.NewRequest(http.MethodOptions, "/api/courses", nil)
+			req.Header.Set("Origin", "http://localhost:5173")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusNoContent {
+				t.Errorf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+			}
+			if got := rr.Header().Get("Access-Control-Max-Age"); got != tt.expected {
+				t.Errorf("Expected Access-Control-Max-Age %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCORS_MaxAgeOnlyOnPreflight(t *testing.T) {
+	handler := CORS()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/courses", nil)
+	req.Header.Set("Origin", "http://localhost:5173")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("Expected no Access-Control-Max-Age on simple requests, got %q", got)
+	}
+}
+
+func TestCORSStrict_EchoesOnlyAllowedOrigins(t *testing.T) {
+	handler := CORSStrict([]string{"https://app.learnify.dev", "https://admin.learnify.dev"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	tests := []struct {
+		name     string
+		origin   string
+		expected string
+	}{
+		{"first allowed origin", "https://app.learnify.dev", "https://app.learnify.dev"},
+		{"second allowed origin", "https://admin.learnify.dev", "https://admin.learnify.dev"},
+		{"unknown origin", "https://evil.example.com", ""},
+		{"no origin", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/courses", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tt.expected {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.expected, got)
+			}
+			if tt.expected == "" {
+				if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+					t.Errorf("Expected no Access-Control-Allow-Credentials for a rejected origin, got %q", got)
+				}
+				return
+			}
+			if got := rr.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Expected Vary: Origin, got %q", got)
+			}
+		})
+	}
+}
@@ -6,47 +6,72 @@ import (
 	"net/http"
 	"time"
 
+	"backend/internal/platform/logger"
+	"backend/internal/platform/requestctx"
+
 	"github.com/google/uuid"
 )
 
-// responseWriter wraps http.ResponseWriter to capture status code
+// responseWriter wraps http.ResponseWriter to capture status code and response size.
+// Logging and metrics middleware share one wrapper per request via wrapResponseWriter.
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
-	written    int64
+	statusCode  int
+	written     int64
+	wroteHeader bool
+}
+
+// wrapResponseWriter returns w if it already captures the response, otherwise wraps it
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK, // default status
+	}
 }
 
 func (rw *responseWriter) WriteHeader(statusCode int) {
-	rw.statusCode = statusCode
+	if !rw.wroteHeader {
+		rw.statusCode = statusCode
+		rw.wroteHeader = true
+	}
 	rw.ResponseWriter.WriteHeader(statusCode)
 }
 
 func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
 	n, err := rw.ResponseWriter.Write(b)
 	rw.written += int64(n)
 	return n, err
 }
 
+// Flush forwards to the underlying writer so streaming responses work through the wrapper
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // Logging logs HTTP requests with detailed information
-func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
+func Logging(log *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
-			// Generate request ID if not present
-			requestID := r.Header.Get("X-Request-ID")
-			if requestID == "" {
-				requestID = uuid.New().String()
-			}
+			requestID := resolveRequestID(r)
 
 			// Add request ID to response headers
 			w.Header().Set("X-Request-ID", requestID)
 
 			// Wrap response writer to capture status code
-			wrapped := &responseWriter{
-				ResponseWriter: w,
-				statusCode:     http.StatusOK, // default status
-			}
+			wrapped := wrapResponseWriter(w)
 
 			// Add request ID to context
 			ctx := r.Context()
@@ -60,13 +85,17 @@ func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
 			duration := time.Since(start)
 
 			// Log request details
-			logger.Info("http_request",
-				"request_id", requestID,
-				"method", r.Method,
-				"path", r.URL.Path,
-				"query", r.URL.RawQuery,
+			// Request fields are client-controlled; escape them even if the logger does not
+			logRequestID := logger.SanitizeValue(requestID)
+			path := logger.SanitizeValue(r.URL.Path)
+
+			log.Info("http_request",
+				"request_id", logRequestID,
+				"method", logger.SanitizeValue(r.Method),
+				"path", path,
+				"query", logger.SanitizeValue(r.URL.RawQuery),
 				"remote_addr", r.RemoteAddr,
-				"user_agent", r.UserAgent(),
+				"user_agent", logger.SanitizeValue(r.UserAgent()),
 				"status_code", wrapped.statusCode,
 				"bytes_written", wrapped.written,
 				"duration_ms", duration.Milliseconds(),
@@ -75,10 +104,10 @@ func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
 
 			// Log errors for non-2xx status codes
 			if wrapped.statusCode >= 400 {
-				logger.Warn("http_request_error",
-					"request_id", requestID,
-					"method", r.Method,
-					"path", r.URL.Path,
+				log.Warn("http_request_error",
+					"request_id", logRequestID,
+					"method", logger.SanitizeValue(r.Method),
+					"path", path,
 					"status_code", wrapped.statusCode,
 					"duration_ms", duration.Milliseconds(),
 				)
@@ -93,18 +122,11 @@ func LoggingSimple() func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
-			// Generate request ID
-			requestID := r.Header.Get("X-Request-ID")
-			if requestID == "" {
-				requestID = uuid.New().String()
-			}
+			requestID := resolveRequestID(r)
 			w.Header().Set("X-Request-ID", requestID)
 
 			// Wrap response writer
-			wrapped := &responseWriter{
-				ResponseWriter: w,
-				statusCode:     http.StatusOK,
-			}
+			wrapped := wrapResponseWriter(w)
 
 			// Add request ID to context
 			ctx := contextWithRequestID(r.Context(), requestID)
@@ -118,9 +140,9 @@ func LoggingSimple() func(http.Handler) http.Handler {
 
 			// Simple logging to stdout
 			slog.Info("http_request",
-				"request_id", requestID,
-				"method", r.Method,
-				"path", r.URL.Path,
+				"request_id", logger.SanitizeValue(requestID),
+				"method", logger.SanitizeValue(r.Method),
+				"path", logger.SanitizeValue(r.URL.Path),
 				"status", wrapped.statusCode,
 				"duration_ms", duration.Milliseconds(),
 			)
@@ -128,18 +150,24 @@ func LoggingSimple() func(http.Handler) http.Handler {
 	}
 }
 
-// requestIDKey is the key for request ID in context
-type requestIDKey struct{}
-
 // contextWithRequestID adds request ID to context
 func contextWithRequestID(ctx context.Context, requestID string) context.Context {
-	return context.WithValue(ctx, requestIDKey{}, requestID)
+	return requestctx.WithRequestID(ctx, requestID)
 }
 
-// GetRequestIDFromContext retrieves request ID from context
-func GetRequestIDFromContext(ctx context.Context) string {
-	if requestID, ok := ctx.Value(requestIDKey{}).(string); ok {
+// resolveRequestID prefers the ID set by the RequestID middleware, then the
+// incoming header, and only generates a new one when neither is present
+func resolveRequestID(r *http.Request) string {
+	if requestID := GetRequestID(r.Context()); requestID != "" {
 		return requestID
 	}
-	return ""
+	if requestID := r.Header.Get("X-Request-ID"); requestID != "" {
+		return requestID
+	}
+	return uuid.New().String()
+}
+
+// GetRequestIDFromContext retrieves request ID from context
+func GetRequestIDFromContext(ctx context.Context) string {
+	return requestctx.RequestID(ctx)
 }
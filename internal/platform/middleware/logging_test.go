@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingHandler captures string attributes exactly as the middleware passed them
+type recordingHandler struct {
+	mu    sync.Mutex
+	attrs map[string]string
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	record.Attrs(func(a slog.Attr) bool {
+		h.attrs[record.Message+"."+a.Key] = a.Value.String()
+		return true
+	})
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestLoggingSimple_ReusesRequestID(t *testing.T) {
+	var seen, seenLegacy string
+	handler := RequestID()(LoggingSimple()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r.Context())
+		seenLegacy = GetRequestIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	header := rr.Header().Get("X-Request-ID")
+	if header == "" {
+		t.Fatal("expected X-Request-ID response header")
+	}
+	if seen != header || seenLegacy != header {
+		t.Errorf("context request IDs = %q/%q, want %q", seen, seenLegacy, header)
+	}
+}
+
+func TestLoggingSimple_UsesIncomingHeader(t *testing.T) {
+	var seen string
+	handler := LoggingSimple()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "upstream-id")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != "upstream-id" {
+		t.Errorf("request ID = %q, want %q", seen, "upstream-id")
+	}
+}
+
+func TestLogging_EscapesControlCharacters(t *testing.T) {
+	recorder := &recordingHandler{attrs: map[string]string{}}
+	handler := Logging(slog.New(recorder))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/courses/x%0Alevel=ERROR?q=a%0Db", nil)
+	req.Header.Set("User-Agent", "agent\x1b[2J")
+	req.Header.Set("X-Request-ID", "id\nforged")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	for key, value := range recorder.attrs {
+		if strings.ContainsAny(value, "\n\r\x1b") {
+			t.Errorf("%s = %q contains raw control characters", key, value)
+		}
+	}
+	if got, want := recorder.attrs["http_request.path"], `/courses/x\nlevel=ERROR`; got != want {
+		t.Errorf("path = %q, want %q", got, want)
+	}
+	if got, want := recorder.attrs["http_request.user_agent"], `agent\u001b[2J`; got != want {
+		t.Errorf("user_agent = %q, want %q", got, want)
+	}
+	if got, want := recorder.attrs["http_request_error.request_id"], `id\nforged`; got != want {
+		t.Errorf("request_id = %q, want %q", got, want)
+	}
+}
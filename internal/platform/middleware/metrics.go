@@ -1,42 +1,35 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"backend/internal/platform/metrics"
-)
 
-// metricsResponseWriter wraps http.ResponseWriter to capture metrics (distinct from responseWriter in logging.go)
-type metricsResponseWriter struct {
-	http.ResponseWriter
-	statusCode   int
-	bytesWritten int64
-}
+	"github.com/gorilla/mux"
+)
 
-func (rw *metricsResponseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
-}
+// routeTemplateKey carries the slot RouteTemplate fills with the matched route
+type routeTemplateKey struct{}
 
-func (rw *metricsResponseWriter) Write(b []byte) (int, error) {
-	n, err := rw.ResponseWriter.Write(b)
-	rw.bytesWritten += int64(n)
-	return n, err
-}
+// idSegment matches path segments that identify a resource rather than a route
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{24,})$`)
 
-// Metrics middleware records HTTP metrics for Prometheus
+// Metrics middleware records HTTP metrics for Prometheus. Requests are labeled
+// with the matched route template (/api/courses/{id}) so IDs do not create a
+// series each; this needs RouteTemplate registered on the router. Unmatched
+// requests fall back to the path with ID-like segments replaced.
 func Metrics() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
-			// Wrap response writer to capture metrics
-			rw := &metricsResponseWriter{
-				ResponseWriter: w,
-				statusCode:     http.StatusOK, // default status
-				bytesWritten:   0,
-			}
+			// Reuse the logging wrapper when present so the response is captured once
+			rw := wrapResponseWriter(w)
 
 			// Get request size
 			reqSize := r.ContentLength
@@ -44,21 +37,56 @@ func Metrics() func(http.Handler) http.Handler {
 				reqSize = 0
 			}
 
+			// The handler may outlive this middleware after a timeout, so the slot is atomic
+			template := &atomic.Pointer[string]{}
+			r = r.WithContext(context.WithValue(r.Context(), routeTemplateKey{}, template))
+
 			// Process request
 			next.ServeHTTP(rw, r)
 
 			// Calculate duration
 			duration := time.Since(start)
 
+			endpoint := sanitizeMetricsPath(r.URL.Path)
+			if matched := template.Load(); matched != nil {
+				endpoint = *matched
+			}
+
 			// Record metrics
 			metrics.RecordHTTPRequest(
 				r.Method,
-				r.URL.Path,
+				endpoint,
 				rw.statusCode,
 				duration,
 				reqSize,
-				rw.bytesWritten,
+				rw.written,
 			)
 		})
 	}
 }
+
+// RouteTemplate reports the matched route's path template to the Metrics
+// middleware. Register it with router.Use so it runs after route matching.
+func RouteTemplate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slot, ok := r.Context().Value(routeTemplateKey{}).(*atomic.Pointer[string]); ok {
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					slot.Store(&template)
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sanitizeMetricsPath replaces numeric, UUID and long hex path segments with {id}
+func sanitizeMetricsPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
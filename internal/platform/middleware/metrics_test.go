@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestCount returns http_requests_total for one method, endpoint and status
+func requestCount(t *testing.T, method, endpoint, status string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["method"] == method && labels["endpoint"] == endpoint && labels["status"] == status {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestMetrics_LabelsByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(RouteTemplate)
+	router.HandleFunc("/api/courses/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+	api := router.PathPrefix("/api/metrics-test").Subrouter()
+	api.HandleFunc("/{id}/modules", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+	handler := Metrics()(router)
+
+	before := requestCount(t, "GET", "/api/courses/{id}", "200")
+	beforeSub := requestCount(t, "GET", "/api/metrics-test/{id}/modules", "200")
+	for _, path := range []string{"/api/courses/abc-123", "/api/courses/def-456", "/api/metrics-test/abc/modules"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, requestCount(t, "GET", "/api/courses/{id}", "200")-before)
+	assert.Equal(t, 1.0, requestCount(t, "GET", "/api/metrics-test/{id}/modules", "200")-beforeSub)
+	assert.Zero(t, requestCount(t, "GET", "/api/courses/abc-123", "200"))
+}
+
+func TestMetrics_SanitizesUnmatchedPaths(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(RouteTemplate)
+	handler := Metrics()(router)
+
+	before := requestCount(t, "GET", "/api/unknown/{id}/items/{id}", "404")
+	handler.ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/api/unknown/3f2b8c1e-9a4d-4e2f-8b6a-1c2d3e4f5a6b/items/42", nil))
+
+	assert.Equal(t, 1.0, requestCount(t, "GET", "/api/unknown/{id}/items/{id}", "404")-before)
+}
+
+func TestSanitizeMetricsPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/courses", "/api/courses"},
+		{"/api/courses/42", "/api/courses/{id}"},
+		{"/api/users/3f2b8c1e-9a4d-4e2f-8b6a-1c2d3e4f5a6b/profile", "/api/users/{id}/profile"},
+		{"/api/objects/507f1f77bcf86cd799439011", "/api/objects/{id}"},
+		{"/api/courses/trending", "/api/courses/trending"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sanitizeMetricsPath(tt.path), tt.path)
+	}
+}
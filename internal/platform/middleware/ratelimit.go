@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,6 +19,21 @@ type RateLimiterConfig struct {
 	AuthRequestsPerMinute int
 	APIRequestsPerMinute  int
 	BurstSize             int
+
+	// TierResolver optionally picks an authenticated user's API requests per minute
+	// (e.g. higher for premium plans). It runs on every request, so it should be cheap
+	// or cached. A nil resolver or a non-positive result uses APIRequestsPerMinute.
+	TierResolver func(userID string) int
+}
+
+// apiLimitFor returns the API requests per minute that apply to userID
+func (c *RateLimiterConfig) apiLimitFor(userID string) int {
+	if c.TierResolver != nil {
+		if limit := c.TierResolver(userID); limit > 0 {
+			return limit
+		}
+	}
+	return c.APIRequestsPerMinute
 }
 
 // DefaultRateLimiterConfig returns default rate limiter settings
@@ -29,9 +45,24 @@ func DefaultRateLimiterConfig() *RateLimiterConfig {
 	}
 }
 
+// limiterEntry is a rate limiter and the last time it was used
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// removeIdleEntries deletes entries not used since cutoff; callers must hold the owning lock
+func removeIdleEntries(entries map[string]*limiterEntry, cutoff time.Time) {
+	for key, entry := range entries {
+		if entry.lastSeen.Before(cutoff) {
+			delete(entries, key)
+		}
+	}
+}
+
 // IPRateLimiter manages rate limiters for IP addresses
 type IPRateLimiter struct {
-	ips     map[string]*rate.Limiter
+	ips     map[string]*limiterEntry
 	mu      sync.RWMutex
 	limit   rate.Limit
 	burst   int
@@ -41,7 +72,7 @@ type IPRateLimiter struct {
 // NewIPRateLimiter creates a new IP-based rate limiter
 func NewIPRateLimiter(requestsPerMinute int, burst int) *IPRateLimiter {
 	limiter := &IPRateLimiter{
-		ips:     make(map[string]*rate.Limiter),
+		ips:     make(map[string]*limiterEntry),
 		limit:   rate.Limit(float64(requestsPerMinute) / 60.0), // Convert to per-second
 		burst:   burst,
 		cleanup: 10 * time.Minute,
@@ -58,31 +89,38 @@ func (l *IPRateLimiter) GetLimiter(ip string) *rate.Limiter {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	limiter, exists := l.ips[ip]
+	entry, exists := l.ips[ip]
 	if !exists {
-		limiter = rate.NewLimiter(l.limit, l.burst)
-		l.ips[ip] = limiter
+		entry = &limiterEntry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.ips[ip] = entry
 	}
+	entry.lastSeen = time.Now()
 
-	return limiter
+	return entry.limiter
 }
 
-// cleanupRoutine periodically removes old limiters
+// cleanupRoutine periodically removes limiters idle for longer than the cleanup window
 func (l *IPRateLimiter) cleanupRoutine() {
 	ticker := time.NewTicker(l.cleanup)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		l.mu.Lock()
-		// Create new map to avoid memory leaks
-		l.ips = make(map[string]*rate.Limiter)
-		l.mu.Unlock()
+	for now := range ticker.C {
+		l.removeIdle(now)
 	}
 }
 
+// removeIdle drops limiters not used within the cleanup window before now.
+// Active limiters keep their state so cleanup never resets a client's quota.
+func (l *IPRateLimiter) removeIdle(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removeIdleEntries(l.ips, now.Add(-l.cleanup))
+}
+
 // UserRateLimiter manages rate limiters for authenticated users
 type UserRateLimiter struct {
-	users   map[string]*rate.Limiter
+	users   map[string]*limiterEntry
 	mu      sync.RWMutex
 	limit   rate.Limit
 	burst   int
@@ -92,7 +130,7 @@ type UserRateLimiter struct {
 // NewUserRateLimiter creates a new user-based rate limiter
 func NewUserRateLimiter(requestsPerMinute int, burst int) *UserRateLimiter {
 	limiter := &UserRateLimiter{
-		users:   make(map[string]*rate.Limiter),
+		users:   make(map[string]*limiterEntry),
 		limit:   rate.Limit(float64(requestsPerMinute) / 60.0), // Convert to per-second
 		burst:   burst,
 		cleanup: 10 * time.Minute,
@@ -106,31 +144,50 @@ func NewUserRateLimiter(requestsPerMinute int, burst int) *UserRateLimiter {
 
 // GetLimiter returns the rate limiter for the given user
 func (l *UserRateLimiter) GetLimiter(userID string) *rate.Limiter {
+	return l.getLimiter(userID, l.limit)
+}
+
+// GetLimiterWithLimit returns the user's rate limiter running at requestsPerMinute.
+// An existing limiter is retuned in place, so tier changes apply without losing its state.
+func (l *UserRateLimiter) GetLimiterWithLimit(userID string, requestsPerMinute int) *rate.Limiter {
+	return l.getLimiter(userID, rate.Limit(float64(requestsPerMinute)/60.0))
+}
+
+// getLimiter returns the user's limiter, creating it or updating its limit as needed
+func (l *UserRateLimiter) getLimiter(userID string, limit rate.Limit) *rate.Limiter {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	limiter, exists := l.users[userID]
+	entry, exists := l.users[userID]
 	if !exists {
-		limiter = rate.NewLimiter(l.limit, l.burst)
-		l.users[userID] = limiter
+		entry = &limiterEntry{limiter: rate.NewLimiter(limit, l.burst)}
+		l.users[userID] = entry
+	} else if entry.limiter.Limit() != limit {
+		entry.limiter.SetLimit(limit)
 	}
+	entry.lastSeen = time.Now()
 
-	return limiter
+	return entry.limiter
 }
 
-// cleanupRoutine periodically removes old limiters
+// cleanupRoutine periodically removes limiters idle for longer than the cleanup window
 func (l *UserRateLimiter) cleanupRoutine() {
 	ticker := time.NewTicker(l.cleanup)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		l.mu.Lock()
-		// Create new map to avoid memory leaks
-		l.users = make(map[string]*rate.Limiter)
-		l.mu.Unlock()
+	for now := range ticker.C {
+		l.removeIdle(now)
 	}
 }
 
+// removeIdle drops limiters not used within the cleanup window before now
+func (l *UserRateLimiter) removeIdle(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removeIdleEntries(l.users, now.Add(-l.cleanup))
+}
+
 // RateLimitAuth creates a rate limiter for authentication endpoints
 func RateLimitAuth(config *RateLimiterConfig) func(http.Handler) http.Handler {
 	if config == nil {
@@ -158,42 +215,99 @@ func RateLimitAuth(config *RateLimiterConfig) func(http.Handler) http.Handler {
 	}
 }
 
-// RateLimitAPI creates a rate limiter for API endpoints (user-based)
-func RateLimitAPI(config *RateLimiterConfig) func(http.Handler) http.Handler {
+// APIRateLimiter limits API traffic per IP before authentication and per user after it.
+// The user tier is only known once Auth has verified the token, so the two halves run at
+// different points of the chain but share limiters and never count a request twice.
+type APIRateLimiter struct {
+	config *RateLimiterConfig
+	users  *UserRateLimiter
+	ips    *IPRateLimiter
+}
+
+// NewAPIRateLimiter creates an API rate limiter from config
+func NewAPIRateLimiter(config *RateLimiterConfig) *APIRateLimiter {
 	if config == nil {
 		config = DefaultRateLimiterConfig()
 	}
 
-	userLimiter := NewUserRateLimiter(config.APIRequestsPerMinute, config.BurstSize)
-	ipLimiter := NewIPRateLimiter(config.APIRequestsPerMinute, config.BurstSize)
+	return &APIRateLimiter{
+		config: config,
+		users:  NewUserRateLimiter(config.APIRequestsPerMinute, config.BurstSize),
+		ips:    NewIPRateLimiter(config.APIRequestsPerMinute, config.BurstSize),
+	}
+}
 
+// Global limits anonymous requests by IP. Requests that carry a bearer token (or are
+// already authenticated) are left to PerUser, which must run after authentication.
+func (l *APIRateLimiter) Global() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Try to get user ID from context (for authenticated requests)
-			userID, hasUser := GetUserIDFromContext(r.Context())
+			if _, hasUser := GetUserIDFromContext(r.Context()); hasUser || hasBearerToken(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			l.limitByIP(w, r, next)
+		})
+	}
+}
 
-			var allowed bool
-			if hasUser && userID != "" {
-				// Use user-based rate limiting for authenticated requests
-				allowed = userLimiter.GetLimiter(userID).Allow()
-			} else {
-				// Fall back to IP-based rate limiting for unauthenticated requests
-				ip := getIP(r)
-				if ip == "" {
-					writeRateLimitError(w, "unable to determine IP address", http.StatusBadRequest)
+// PerUser limits authenticated requests per user at the user's tier. Requests that
+// presented a token that did not authenticate fall back to the IP limit; anonymous
+// requests pass through because Global already counted them.
+func (l *APIRateLimiter) PerUser() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, hasUser := GetUserIDFromContext(r.Context())
+			switch {
+			case hasUser && userID != "":
+				limit := l.config.apiLimitFor(userID)
+				if !l.users.GetLimiterWithLimit(userID, limit).Allow() {
+					writeRateLimitExceeded(w, limit)
 					return
 				}
-				allowed = ipLimiter.GetLimiter(ip).Allow()
+				next.ServeHTTP(w, r)
+			case hasBearerToken(r):
+				l.limitByIP(w, r, next)
+			default:
+				next.ServeHTTP(w, r)
 			}
+		})
+	}
+}
 
-			if !allowed {
-				w.Header().Set("Retry-After", "60")
-				writeRateLimitError(w, fmt.Sprintf("rate limit exceeded: max %d requests per minute", config.APIRequestsPerMinute), http.StatusTooManyRequests)
-				return
-			}
+// limitByIP applies the IP-based API limit before calling next
+func (l *APIRateLimiter) limitByIP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	ip := getIP(r)
+	if ip == "" {
+		writeRateLimitError(w, "unable to determine IP address", http.StatusBadRequest)
+		return
+	}
+	if !l.ips.GetLimiter(ip).Allow() {
+		writeRateLimitExceeded(w, l.config.APIRequestsPerMinute)
+		return
+	}
+	next.ServeHTTP(w, r)
+}
 
-			next.ServeHTTP(w, r)
-		})
+// hasBearerToken reports whether the request presents a bearer token
+func hasBearerToken(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// writeRateLimitExceeded writes the 429 response for the given per-minute limit
+func writeRateLimitExceeded(w http.ResponseWriter, limit int) {
+	w.Header().Set("Retry-After", "60")
+	writeRateLimitError(w, fmt.Sprintf("rate limit exceeded: max %d requests per minute", limit), http.StatusTooManyRequests)
+}
+
+// RateLimitAPI creates a combined API rate limiter for handlers that already run after
+// authentication: per user at the user's tier, otherwise per IP. In a global middleware
+// chain use NewAPIRateLimiter instead, with PerUser placed after Auth.
+func RateLimitAPI(config *RateLimiterConfig) func(http.Handler) http.Handler {
+	limiter := NewAPIRateLimiter(config)
+	global, perUser := limiter.Global(), limiter.PerUser()
+	return func(next http.Handler) http.Handler {
+		return global(perUser(next))
 	}
 }
 
@@ -5,6 +5,8 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 func TestRateLimitAuth(t *testing.T) {
@@ -61,7 +63,7 @@ func TestRateLimitAPI_UserBased(t *testing.T) {
 		w.Write([]byte("OK"))
 	}))
 
-	// Test requests without user context (IP-based)
+	// Test requests without user context (IP-based): the burst is allowed, then limited
 	for i := 0; i < 10; i++ {
 		req := httptest.NewRequest("GET", "/api/courses", nil)
 		req.RemoteAddr = "10.0.0.1:54321"
@@ -69,9 +71,152 @@ func TestRateLimitAPI_UserBased(t *testing.T) {
 
 		handler.ServeHTTP(rr, req)
 
-		if i < 9 && rr.Code != http.StatusOK {
+		if i < config.BurstSize && rr.Code != http.StatusOK {
 			t.Errorf("Request %d: Expected OK, got %d", i, rr.Code)
 		}
+		if i >= config.BurstSize && rr.Code != http.StatusTooManyRequests {
+			t.Errorf("Request %d: Expected TooManyRequests, got %d", i, rr.Code)
+		}
+	}
+}
+
+// serveAsUser sends count API requests as userID and returns how many were allowed
+func serveAsUser(handler http.Handler, userID string, count int) int {
+	allowed := 0
+	for i := 0; i < count; i++ {
+		req := httptest.NewRequest("GET", "/api/courses", nil)
+		req = req.WithContext(ContextWithUser(req.Context(), &UserClaims{UserID: userID}))
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code == http.StatusOK {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestRateLimitAPI_TierResolver(t *testing.T) {
+	config := &RateLimiterConfig{
+		APIRequestsPerMinute: 1,
+		BurstSize:            2,
+		TierResolver: func(userID string) int {
+			if userID == "premium-user" {
+				return 60000
+			}
+			return 0
+		},
+	}
+
+	handler := RateLimitAPI(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Free users fall back to the configured limit: only the burst gets through
+	if allowed := serveAsUser(handler, "free-user", 5); allowed != config.BurstSize {
+		t.Errorf("Free user: expected %d allowed requests, got %d", config.BurstSize, allowed)
+	}
+
+	// Premium users refill at 1000 tokens per second, so spaced requests keep passing
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		allowed += serveAsUser(handler, "premium-user", 1)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if allowed != 5 {
+		t.Errorf("Premium user: expected 5 allowed requests, got %d", allowed)
+	}
+}
+
+func TestRateLimitAPI_DefaultsWithoutResolver(t *testing.T) {
+	config := &RateLimiterConfig{
+		APIRequestsPerMinute: 1,
+		BurstSize:            2,
+	}
+
+	handler := RateLimitAPI(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	if allowed := serveAsUser(handler, "user-1", 5); allowed != config.BurstSize {
+		t.Errorf("Expected %d allowed requests, got %d", config.BurstSize, allowed)
+	}
+}
+
+func TestAPIRateLimiter_TierAppliesAfterAuth(t *testing.T) {
+	config := &RateLimiterConfig{
+		APIRequestsPerMinute: 1,
+		BurstSize:            2,
+		TierResolver: func(userID string) int {
+			if userID == "premium-user" {
+				return 60000
+			}
+			return 0
+		},
+	}
+	limiter := NewAPIRateLimiter(config)
+
+	// Global runs before auth; a stand-in for Auth sets the user only for the valid token
+	fakeAuth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "Bearer premium" {
+				r = r.WithContext(ContextWithUser(r.Context(), &UserClaims{UserID: "premium-user"}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := limiter.Global()(fakeAuth(limiter.PerUser()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))))
+
+	serve := func(token string, count int) int {
+		allowed := 0
+		for i := 0; i < count; i++ {
+			req := httptest.NewRequest("GET", "/api/courses", nil)
+			req.RemoteAddr = "10.0.0.9:1234"
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code == http.StatusOK {
+				allowed++
+			}
+		}
+		return allowed
+	}
+
+	// The premium tier (1000 tokens per second) is not capped by the anonymous per-IP limit
+	premium := 0
+	for i := 0; i < 5; i++ {
+		premium += serve("premium", 1)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if allowed := premium; allowed != 5 {
+		t.Errorf("Premium user: expected 5 allowed requests, got %d", allowed)
+	}
+
+	// Anonymous and invalid-token requests share the IP limit
+	if allowed := serve("", 1) + serve("forged", 4); allowed != config.BurstSize {
+		t.Errorf("Anonymous/forged: expected %d allowed requests, got %d", config.BurstSize, allowed)
+	}
+}
+
+func TestUserRateLimiter_GetLimiterWithLimit_UpdatesTier(t *testing.T) {
+	limiter := NewUserRateLimiter(60, 1)
+
+	first := limiter.GetLimiterWithLimit("user-1", 60)
+	if first.Limit() != rate.Limit(1) {
+		t.Errorf("Expected limit 1/s, got %v", first.Limit())
+	}
+
+	upgraded := limiter.GetLimiterWithLimit("user-1", 600)
+	if upgraded != first {
+		t.Error("Expected the existing limiter to be reused")
+	}
+	if upgraded.Limit() != rate.Limit(10) {
+		t.Errorf("Expected limit 10/s after tier change, got %v", upgraded.Limit())
 	}
 }
 
@@ -132,7 +277,7 @@ func TestGetIP(t *testing.T) {
 
 func TestIPRateLimiter_Cleanup(t *testing.T) {
 	limiter := &IPRateLimiter{
-		ips:     make(map[string]*time.Ticker),
+		ips:     make(map[string]*limiterEntry),
 		cleanup: 100 * time.Millisecond,
 	}
 
@@ -148,3 +293,54 @@ func TestIPRateLimiter_Cleanup(t *testing.T) {
 	// Wait for cleanup (note: actual cleanup is in goroutine, this just tests the structure)
 	// In production code, cleanup would run periodically
 }
+
+func TestIPRateLimiter_RemoveIdleKeepsActive(t *testing.T) {
+	limiter := &IPRateLimiter{
+		ips:     make(map[string]*limiterEntry),
+		limit:   rate.Limit(1),
+		burst:   2,
+		cleanup: 10 * time.Minute,
+	}
+
+	active := limiter.GetLimiter("192.168.1.1")
+	limiter.GetLimiter("192.168.1.2")
+
+	// The idle client was last seen before the cleanup window; the active one just now
+	limiter.ips["192.168.1.2"].lastSeen = time.Now().Add(-11 * time.Minute)
+	active.Allow()
+	active.Allow()
+
+	limiter.removeIdle(time.Now())
+
+	if _, ok := limiter.ips["192.168.1.2"]; ok {
+		t.Error("Expected idle limiter to be removed")
+	}
+	if limiter.GetLimiter("192.168.1.1") != active {
+		t.Error("Expected active limiter to survive cleanup")
+	}
+	if active.Allow() {
+		t.Error("Expected active limiter to keep its exhausted quota after cleanup")
+	}
+}
+
+func TestUserRateLimiter_RemoveIdleKeepsActive(t *testing.T) {
+	limiter := &UserRateLimiter{
+		users:   make(map[string]*limiterEntry),
+		limit:   rate.Limit(1),
+		burst:   1,
+		cleanup: 10 * time.Minute,
+	}
+
+	active := limiter.GetLimiter("active-user")
+	limiter.GetLimiter("idle-user")
+	limiter.users["idle-user"].lastSeen = time.Now().Add(-11 * time.Minute)
+
+	limiter.removeIdle(time.Now())
+
+	if len(limiter.users) != 1 {
+		t.Errorf("Expected 1 limiter after cleanup, got %d", len(limiter.users))
+	}
+	if limiter.GetLimiter("active-user") != active {
+		t.Error("Expected active limiter to survive cleanup")
+	}
+}
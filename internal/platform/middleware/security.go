@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // SecurityHeadersConfig holds security headers configuration
@@ -12,6 +15,9 @@ type SecurityHeadersConfig struct {
 	XSSProtection                string
 	StrictTransportSecurity      string
 	ContentSecurityPolicy        string
+	CSPReportOnly                bool   // Send the policy as Content-Security-Policy-Report-Only so violations are reported, not blocked
+	CSPReportURI                 string // Appended as a report-uri directive when set
+	CSPReportTo                  string // Reporting group appended as a report-to directive when set
 	ReferrerPolicy               string
 	PermissionsPolicy            string
 	CrossOriginEmbedderPolicy    string
@@ -27,6 +33,9 @@ func DefaultSecurityHeadersConfig() *SecurityHeadersConfig {
 		XSSProtection:             getEnv("SECURITY_XSS_PROTECTION", "1; mode=block"),
 		StrictTransportSecurity:   getEnv("SECURITY_HSTS", "max-age=31536000; includeSubDomains"),
 		ContentSecurityPolicy:     getEnv("SECURITY_CSP", "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data: https:; font-src 'self'; connect-src 'self'; frame-ancestors 'none'"),
+		CSPReportOnly:             getEnvBool("SECURITY_CSP_REPORT_ONLY", false),
+		CSPReportURI:              getEnv("SECURITY_CSP_REPORT_URI", ""),
+		CSPReportTo:               getEnv("SECURITY_CSP_REPORT_TO", ""),
 		ReferrerPolicy:            getEnv("SECURITY_REFERRER_POLICY", "strict-origin-when-cross-origin"),
 		PermissionsPolicy:         getEnv("SECURITY_PERMISSIONS_POLICY", "geolocation=(), microphone=(), camera=()"),
 		CrossOriginEmbedderPolicy: getEnv("SECURITY_COEP", "require-corp"),
@@ -35,11 +44,39 @@ func DefaultSecurityHeadersConfig() *SecurityHeadersConfig {
 	}
 }
 
+// CSPHeader returns the header name and value the policy is sent with: the
+// report-only header in report-only mode, with any reporting directives appended
+func (c *SecurityHeadersConfig) CSPHeader() (string, string) {
+	name := "Content-Security-Policy"
+	if c.CSPReportOnly {
+		name = "Content-Security-Policy-Report-Only"
+	}
+	if c.ContentSecurityPolicy == "" {
+		return name, ""
+	}
+
+	directives := []string{strings.TrimRight(strings.TrimSpace(c.ContentSecurityPolicy), ";")}
+	if c.CSPReportURI != "" {
+		directives = append(directives, "report-uri "+c.CSPReportURI)
+	}
+	if c.CSPReportTo != "" {
+		directives = append(directives, "report-to "+c.CSPReportTo)
+	}
+	return name, strings.Join(directives, "; ")
+}
+
 // SecurityHeaders adds security headers to all responses
 func SecurityHeaders(config *SecurityHeadersConfig) func(http.Handler) http.Handler {
 	if config == nil {
 		config = DefaultSecurityHeadersConfig()
 	}
+	cspHeader, cspValue := config.CSPHeader()
+
+	// Browsers that support report-to look the group up in Reporting-Endpoints
+	reportingEndpoints := ""
+	if config.CSPReportTo != "" && config.CSPReportURI != "" {
+		reportingEndpoints = fmt.Sprintf("%s=%q", config.CSPReportTo, config.CSPReportURI)
+	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -63,9 +100,13 @@ func SecurityHeaders(config *SecurityHeadersConfig) func(http.Handler) http.Hand
 				w.Header().Set("Strict-Transport-Security", config.StrictTransportSecurity)
 			}
 
-			// Content-Security-Policy: Prevents XSS and data injection attacks
-			if config.ContentSecurityPolicy != "" {
-				w.Header().Set("Content-Security-Policy", config.ContentSecurityPolicy)
+			// Content-Security-Policy: Prevents XSS and data injection attacks,
+			// or only reports violations in report-only mode
+			if cspValue != "" {
+				w.Header().Set(cspHeader, cspValue)
+				if reportingEndpoints != "" {
+					w.Header().Set("Reporting-Endpoints", reportingEndpoints)
+				}
 			}
 
 			// Referrer-Policy: Controls referrer information
@@ -107,6 +148,16 @@ func SecureHeaders() func(http.Handler) http.Handler {
 	return SecurityHeaders(DefaultSecurityHeadersConfig())
 }
 
+// getEnvBool retrieves an environment variable as a boolean with a default
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnv retrieves an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
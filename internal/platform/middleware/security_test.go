@@ -114,3 +114,61 @@ func TestSecurityHeaders_CustomConfig(t *testing.T) {
 		t.Errorf("Expected X-XSS-Protection: 0, got: %s", rr.Header().Get("X-XSS-Protection"))
 	}
 }
+
+func TestSecurityHeaders_CSPMode(t *testing.T) {
+	const policy = "default-src 'self'"
+
+	tests := []struct {
+		name       string
+		reportOnly bool
+		wantHeader string
+		otherName  string
+	}{
+		{"enforcing", false, "Content-Security-Policy", "Content-Security-Policy-Report-Only"},
+		{"report only", true, "Content-Security-Policy-Report-Only", "Content-Security-Policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &SecurityHeadersConfig{ContentSecurityPolicy: policy, CSPReportOnly: tt.reportOnly}
+			handler := SecurityHeaders(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			if got := rr.Header().Get(tt.wantHeader); got != policy {
+				t.Errorf("Expected %s: %s, got: %s", tt.wantHeader, policy, got)
+			}
+			if got := rr.Header().Get(tt.otherName); got != "" {
+				t.Errorf("%s should not be set, got: %s", tt.otherName, got)
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders_CSPReportingDirectives(t *testing.T) {
+	config := &SecurityHeadersConfig{
+		ContentSecurityPolicy: "default-src 'self';",
+		CSPReportOnly:         true,
+		CSPReportURI:          "https://reports.example.com/csp",
+		CSPReportTo:           "csp-endpoint",
+	}
+
+	handler := SecurityHeaders(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	want := "default-src 'self'; report-uri https://reports.example.com/csp; report-to csp-endpoint"
+	if got := rr.Header().Get("Content-Security-Policy-Report-Only"); got != want {
+		t.Errorf("Expected policy: %s, got: %s", want, got)
+	}
+	wantEndpoints := `csp-endpoint="https://reports.example.com/csp"`
+	if got := rr.Header().Get("Reporting-Endpoints"); got != wantEndpoints {
+		t.Errorf("Expected Reporting-Endpoints: %s, got: %s", wantEndpoints, got)
+	}
+}
@@ -22,6 +22,26 @@ const (
 // SizeLimitConfig holds request size limit configuration
 type SizeLimitConfig struct {
 	MaxBodySize int64
+	Routes      []RouteSizeLimit // Per-route overrides of MaxBodySize; the first match wins
+}
+
+// RouteSizeLimit overrides the body size limit for a single route, e.g. to give
+// exercise submissions more room than the default. Method and Path match like
+// RouteTimeout; a non-positive MaxBodySize keeps the default limit.
+type RouteSizeLimit struct {
+	Method      string
+	Path        string
+	MaxBodySize int64
+}
+
+// limitFor returns the body size limit that applies to r
+func (c *SizeLimitConfig) limitFor(r *http.Request) int64 {
+	for _, route := range c.Routes {
+		if route.MaxBodySize > 0 && matchRoute(route.Method, route.Path, r) {
+			return route.MaxBodySize
+		}
+	}
+	return c.MaxBodySize
 }
 
 // DefaultSizeLimitConfig returns default size limit configuration
@@ -35,7 +55,8 @@ func DefaultSizeLimitConfig() *SizeLimitConfig {
 	}
 }
 
-// RequestSizeLimit limits the size of request bodies
+// RequestSizeLimit limits the size of request bodies to the config's limit for
+// the request's route
 func RequestSizeLimit(config *SizeLimitConfig) func(http.Handler) http.Handler {
 	if config == nil {
 		config = DefaultSizeLimitConfig()
@@ -43,21 +64,25 @@ func RequestSizeLimit(config *SizeLimitConfig) func(http.Handler) http.Handler {
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			maxBodySize := config.limitFor(r)
+
 			// Check Content-Length header first (optimization)
-			if r.ContentLength > config.MaxBodySize {
-				writeSizeLimitError(w, fmt.Sprintf("request body too large: %d bytes (max: %d bytes)", r.ContentLength, config.MaxBodySize), http.StatusRequestEntityTooLarge)
+			if r.ContentLength > maxBodySize {
+				writeSizeLimitError(w, fmt.Sprintf("request body too large: %d bytes (max: %d bytes)", r.ContentLength, maxBodySize), http.StatusRequestEntityTooLarge)
 				return
 			}
 
 			// Wrap the request body with a limited reader
-			r.Body = http.MaxBytesReader(w, r.Body, config.MaxBodySize)
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
 
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// RequestSizeLimitBytes creates a size limiter with a specific byte limit
+// RequestSizeLimitBytes creates a size limiter with a specific byte limit, e.g.
+// for a subrouter. Nested inside RequestSizeLimit it can only tighten the limit;
+// raise it for a route with a RouteSizeLimit instead.
 func RequestSizeLimitBytes(maxBytes int64) func(http.Handler) http.Handler {
 	return RequestSizeLimit(&SizeLimitConfig{MaxBodySize: maxBytes})
 }
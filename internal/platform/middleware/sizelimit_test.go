@@ -128,3 +128,76 @@ func TestDefaultSizeLimitConfig(t *testing.T) {
 		t.Errorf("Expected default max body size %d, got %d", DefaultMaxBodySize, config.MaxBodySize)
 	}
 }
+
+func TestRequestSizeLimit_RouteOverrides(t *testing.T) {
+	config := &SizeLimitConfig{
+		MaxBodySize: 100,
+		Routes: []RouteSizeLimit{
+			{Method: "POST", Path: "/api/exercises/{id}/submit", MaxBodySize: 1000},
+		},
+	}
+	handler := RequestSizeLimit(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		size   int
+		want   int
+	}{
+		{"submission within its limit", "POST", "/api/exercises/ex-1/submit", 500, http.StatusOK},
+		{"submission over its limit", "POST", "/api/exercises/ex-1/submit", 1500, http.StatusRequestEntityTooLarge},
+		{"other route keeps the default", "POST", "/api/exercises/ex-1", 500, http.StatusRequestEntityTooLarge},
+		{"other method keeps the default", "PUT", "/api/exercises/ex-1/submit", 500, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(strings.Repeat("a", tt.size)))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rr.Code)
+			}
+		})
+	}
+
+	// Without Content-Length the limit is enforced while reading
+	req := httptest.NewRequest("POST", "/api/exercises/ex-1/submit", strings.NewReader(strings.Repeat("a", 1500)))
+	req.ContentLength = -1
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for streamed oversized submission, got %d", rr.Code)
+	}
+}
+
+func TestRequestSizeLimitBytes_TightensNestedLimit(t *testing.T) {
+	// An auth subrouter with a tight cap inside the global limit
+	inner := RequestSizeLimitBytes(50)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler := RequestSizeLimit(&SizeLimitConfig{MaxBodySize: 1000})(inner)
+
+	for size, want := range map[int]int{40: http.StatusOK, 100: http.StatusRequestEntityTooLarge} {
+		req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(strings.Repeat("a", size)))
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != want {
+			t.Errorf("Expected status %d for %d-byte login, got %d", want, size, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RouteTimeout overrides the request timeout for a single route. Path uses the
+// router's template syntax, so a {name} segment matches any one path segment.
+// An empty Method matches every method and a non-positive Timeout exempts the route.
+type RouteTimeout struct {
+	Method  string
+	Path    string
+	Timeout time.Duration
+}
+
+// matches reports whether the override applies to r
+func (rt RouteTimeout) matches(r *http.Request) bool {
+	return matchRoute(rt.Method, rt.Path, r)
+}
+
+// matchRoute reports whether r is a request for method (empty for any) on the
+// route template path, in which a {name} segment matches any one path segment
+func matchRoute(method, path string, r *http.Request) bool {
+	if method != "" && method != r.Method {
+		return false
+	}
+
+	pattern := strings.Split(strings.Trim(path, "/"), "/")
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Timeout bounds how long a request may run. The request context gets a deadline,
+// so downstream calls that accept a context (database queries, AI requests) are
+// cancelled when it passes. If the handler has not started writing by then, the
+// client receives a 503 with a JSON body and any later writes are discarded.
+// A non-positive duration disables the timeout. Overrides give slow routes, such
+// as those waiting on AI generation, their own budget; the first match wins.
+//
+// Unlike http.TimeoutHandler the response is not buffered, so streaming handlers
+// can still flush as they go.
+func Timeout(d time.Duration, overrides ...RouteTimeout) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 && len(overrides) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := d
+			for _, override := range overrides {
+				if override.matches(r) {
+					timeout = override.Timeout
+					break
+				}
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, h: make(http.Header), ctx: ctx}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				// Re-panic on the serving goroutine so Recovery can handle it
+				panic(p)
+			case <-done:
+			case <-ctx.Done():
+			}
+
+			// The handler may have returned just after the deadline without writing
+			if ctx.Err() != nil {
+				tw.timeout(errors.Is(ctx.Err(), context.DeadlineExceeded))
+			}
+		})
+	}
+}
+
+// timeoutWriter passes writes through until the request context ends, after
+// which it rejects them with http.ErrHandlerTimeout
+type timeoutWriter struct {
+	w   http.ResponseWriter
+	h   http.Header // Handler headers, copied to w when the status is written
+	ctx context.Context
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Header returns the handler's header map
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+// WriteHeader sends the status code unless the request already timed out
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expiredLocked() || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+// Write sends body bytes unless the request already timed out
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expiredLocked() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+// Flush forwards to the underlying writer so streaming responses keep working
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expiredLocked() {
+		return
+	}
+	if flusher, ok := tw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// expiredLocked reports whether writes must be rejected; callers must hold mu
+func (tw *timeoutWriter) expiredLocked() bool {
+	return tw.timedOut || tw.ctx.Err() != nil
+}
+
+// writeHeaderLocked copies handler headers and writes the status; callers must hold mu
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	dst := tw.w.Header()
+	for key, values := range tw.h {
+		dst[key] = values
+	}
+	tw.wroteHeader = true
+	tw.w.WriteHeader(code)
+}
+
+// timeout stops further writes. When the deadline passed and nothing was sent
+// yet it responds with 503; a client that went away gets no response.
+func (tw *timeoutWriter) timeout(deadlineExceeded bool) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+	if tw.wroteHeader || !deadlineExceeded {
+		return
+	}
+
+	tw.wroteHeader = true
+	tw.w.Header().Set("Content-Type", "application/json")
+	tw.w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(tw.w).Encode(map[string]string{
+		"error":   http.StatusText(http.StatusServiceUnavailable),
+		"message": "request timed out",
+	})
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout_Returns503AndCancelsContext(t *testing.T) {
+	ctxErr := make(chan error, 1)
+	handler := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Simulate a slow downstream call that honors the request context
+		<-r.Context().Done()
+		ctxErr <- r.Context().Err()
+		w.Write([]byte("too late"))
+	}))
+
+	req := httptest.NewRequest("GET", "/api/courses", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON body, got %q: %v", rr.Body.String(), err)
+	}
+	if body["error"] != "Service Unavailable" || body["message"] != "request timed out" {
+		t.Errorf("Unexpected error body: %v", body)
+	}
+
+	select {
+	case err := <-ctxErr:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected handler context to hit its deadline, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Handler context was not cancelled")
+	}
+}
+
+func TestTimeout_PassesThroughFastResponses(t *testing.T) {
+	handler := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Expected request context to carry a deadline")
+		}
+		w.Header().Set("X-Custom", "value")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	req := httptest.NewRequest("POST", "/api/courses", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", rr.Code)
+	}
+	if rr.Header().Get("X-Custom") != "value" {
+		t.Error("Expected handler headers to be forwarded")
+	}
+	if rr.Body.String() != "created" {
+		t.Errorf("Unexpected body %q", rr.Body.String())
+	}
+}
+
+func TestTimeout_PanicReachesRecovery(t *testing.T) {
+	handler := Recovery()(Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest("GET", "/api/courses", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rr.Code)
+	}
+}
+
+func TestTimeout_DisabledWhenNonPositive(t *testing.T) {
+	handler := Timeout(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("Expected no deadline when the timeout is disabled")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/courses", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rr.Code)
+	}
+}
+
+func TestTimeout_RouteOverrides(t *testing.T) {
+	handler := Timeout(20*time.Millisecond,
+		RouteTimeout{Method: "POST", Path: "/api/submissions/{id}/review", Timeout: time.Second},
+		RouteTimeout{Method: "POST", Path: "/api/onboarding/complete"},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Outlive the default timeout but not the override
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{"POST", "/api/submissions/abc/review", http.StatusOK},
+		{"POST", "/api/onboarding/complete", http.StatusOK},
+		{"GET", "/api/submissions/abc/review", http.StatusServiceUnavailable},
+		{"POST", "/api/submissions/review", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rr.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
@@ -4,17 +4,14 @@ import (
 	"context"
 	"net/http"
 
+	"backend/internal/platform/requestctx"
+
 	"github.com/google/uuid"
 )
 
 // ContextKey type for context keys to avoid collisions
 type ContextKey string
 
-const (
-	// RequestIDKey is the context key for request ID
-	RequestIDKey ContextKey = "request_id"
-)
-
 // RequestID middleware generates a unique request ID and adds it to context and response headers
 func RequestID() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -28,7 +25,7 @@ func RequestID() func(http.Handler) http.Handler {
 			}
 
 			// Add request ID to context
-			ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+			ctx := requestctx.WithRequestID(r.Context(), requestID)
 
 			// Add request ID to response headers
 			w.Header().Set("X-Request-ID", requestID)
@@ -41,10 +38,7 @@ func RequestID() func(http.Handler) http.Handler {
 
 // GetRequestID extracts the request ID from context
 func GetRequestID(ctx context.Context) string {
-	if requestID, ok := ctx.Value(RequestIDKey).(string); ok {
-		return requestID
-	}
-	return ""
+	return requestctx.RequestID(ctx)
 }
 
 // GetUserID extracts the user ID from context (set by Auth middleware)
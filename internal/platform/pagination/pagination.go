@@ -0,0 +1,77 @@
+// Package pagination defines the envelope every list endpoint responds with,
+// so clients read a list the same way wherever it comes from:
+//
+//	{"items": [...], "total": 42, "limit": 20, "offset": 0}    offset-paginated
+//	{"items": [...], "limit": 50, "next_cursor": "..."}        cursor-paginated
+//
+// Endpoint-specific context, such as the category a list was filtered by, goes
+// under "meta".
+package pagination
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// PagedResponse is one page of a list. Offset-paginated lists always report
+// Total and Offset; cursor-paginated lists report NextCursor while more items
+// remain, and pass it back to fetch the next page.
+type PagedResponse[T any] struct {
+	Items      []T                    `json:"items"`
+	Total      *int                   `json:"total,omitempty"`  // Items across all pages
+	Limit      int                    `json:"limit"`            // Page size the server applied
+	Offset     *int                   `json:"offset,omitempty"` // Items skipped before this page
+	NextCursor string                 `json:"next_cursor,omitempty"`
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+}
+
+// Offset returns a page of an offset-paginated list of total items
+func Offset[T any](items []T, total, limit, offset int) PagedResponse[T] {
+	return PagedResponse[T]{
+		Items:  nonNil(items),
+		Total:  &total,
+		Limit:  limit,
+		Offset: &offset,
+	}
+}
+
+// All returns a list that is never paginated as a single page holding every item
+func All[T any](items []T) PagedResponse[T] {
+	return Offset(items, len(items), len(items), 0)
+}
+
+// Cursor returns a page of a cursor-paginated list. nextCursor is empty on the
+// last page.
+func Cursor[T any](items []T, limit int, nextCursor string) PagedResponse[T] {
+	return PagedResponse[T]{
+		Items:      nonNil(items),
+		Limit:      limit,
+		NextCursor: nextCursor,
+	}
+}
+
+// WithMeta returns the page with value set under key in its meta object
+func (p PagedResponse[T]) WithMeta(key string, value interface{}) PagedResponse[T] {
+	meta := make(map[string]interface{}, len(p.Meta)+1)
+	for k, v := range p.Meta {
+		meta[k] = v
+	}
+	meta[key] = value
+	p.Meta = meta
+	return p
+}
+
+// Write writes page as a 200 JSON response
+func Write[T any](w http.ResponseWriter, page PagedResponse[T]) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+// nonNil returns items, or an empty slice so an empty list encodes as []
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
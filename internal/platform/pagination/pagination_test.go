@@ -0,0 +1,42 @@
+package pagination
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite_OffsetPage(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Write(rr, Offset([]string{"a", "b"}, 5, 2, 2))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"items": ["a", "b"], "total": 5, "limit": 2, "offset": 2}`, rr.Body.String())
+}
+
+func TestWrite_CursorPage(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Write(rr, Cursor([]int{1, 2}, 2, "next"))
+	assert.JSONEq(t, `{"items": [1, 2], "limit": 2, "next_cursor": "next"}`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	Write(rr, Cursor([]int{1}, 2, ""))
+	assert.JSONEq(t, `{"items": [1], "limit": 2}`, rr.Body.String(), "the last page has no cursor")
+}
+
+func TestWrite_EmptyListEncodesAsArray(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Write(rr, All[string](nil))
+	assert.JSONEq(t, `{"items": [], "total": 0, "limit": 0, "offset": 0}`, rr.Body.String())
+}
+
+func TestWithMeta(t *testing.T) {
+	page := All([]string{"a"}).WithMeta("category", "Digital")
+	withPeriod := page.WithMeta("period", "week")
+
+	assert.Equal(t, map[string]interface{}{"category": "Digital"}, page.Meta, "WithMeta does not modify the original page")
+	assert.Equal(t, map[string]interface{}{"category": "Digital", "period": "week"}, withPeriod.Meta)
+}
@@ -0,0 +1,15 @@
+package requestctx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDRoundTrip(t *testing.T) {
+	assert.Equal(t, "", RequestID(context.Background()))
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	assert.Equal(t, "req-123", RequestID(ctx))
+}
@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldError reports which input field failed validation and why
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// OneOf returns the allowed value matching value case-insensitively, so callers
+// store the canonical spelling
+func OneOf(field, value string, allowed []string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return candidate, nil
+		}
+	}
+	return "", &FieldError{Field: field, Message: "must be one of: " + strings.Join(allowed, ", ")}
+}
+
+// topicPunctuation is the punctuation allowed in topic names such as "C++",
+// "UI/UX", "Node.js" or "R&D"
+const topicPunctuation = "-_.,&+/#'()"
+
+// ValidateTopic checks that a subject or field-of-study name has at least
+// minLength characters and contains only letters, numbers, spaces and common
+// punctuation. Input should already be cleaned (see CleanText).
+func ValidateTopic(field, input string, minLength int) error {
+	if utf8.RuneCountInString(input) < minLength {
+		return &FieldError{Field: field, Message: fmt.Sprintf("must be at least %d characters", minLength)}
+	}
+
+	hasLetter := false
+	for _, r := range input {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r) || unicode.IsMark(r) || r == ' ' || strings.ContainsRune(topicPunctuation, r):
+		default:
+			return &FieldError{
+				Field:   field,
+				Message: "may only contain letters, numbers, spaces and " + topicPunctuation,
+			}
+		}
+	}
+	if !hasLetter {
+		return &FieldError{Field: field, Message: "must contain at least one letter"}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOneOf(t *testing.T) {
+	allowed := []string{"Digital", "Economic"}
+
+	value, err := OneOf("meta_category", " digital ", allowed)
+	require.NoError(t, err)
+	assert.Equal(t, "Digital", value)
+
+	_, err = OneOf("meta_category", "Culinary", allowed)
+	var fieldErr *FieldError
+	require.True(t, errors.As(err, &fieldErr))
+	assert.Equal(t, "meta_category", fieldErr.Field)
+	assert.EqualError(t, err, "meta_category must be one of: Digital, Economic")
+}
+
+func TestValidateTopic(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{"e-commerce", true},
+		{"C++", true},
+		{"UI/UX design", true},
+		{"Node.js", true},
+		{"R&D (pharma)", true},
+		{"Café management", true},
+		{"机器学习", true},
+		{"x", false},
+		{"1234", false},
+		{"<script>alert(1)</script>", false},
+		{"drop; table", false},
+		{"line\nbreak", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTopic("domain", tt.input, 2)
+		if tt.valid {
+			assert.NoError(t, err, tt.input)
+			continue
+		}
+		var fieldErr *FieldError
+		if assert.True(t, errors.As(err, &fieldErr), tt.input) {
+			assert.Equal(t, "domain", fieldErr.Field)
+		}
+	}
+}
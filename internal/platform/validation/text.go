@@ -0,0 +1,89 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrTextTooLong is returned when free text exceeds its length cap and the policy rejects overflow
+var ErrTextTooLong = errors.New("text is too long")
+
+// TextPolicy controls how a free-text field is cleaned at ingest
+type TextPolicy struct {
+	MaxLength int  // Maximum stored length in characters (0 = unlimited)
+	Truncate  bool // Truncate overflowing text instead of rejecting it
+}
+
+// TextLimits holds the length caps applied to user-provided free text
+type TextLimits struct {
+	ShortMaxLength   int  // Names, domains, variable values
+	LongMaxLength    int  // Feedback, reviews, comments
+	TruncateOverflow bool // Truncate instead of rejecting overlong text
+}
+
+// DefaultTextLimits returns the standard free-text limits
+func DefaultTextLimits() TextLimits {
+	return TextLimits{
+		ShortMaxLength:   100,
+		LongMaxLength:    2000,
+		TruncateOverflow: false,
+	}
+}
+
+// Short returns the policy for short single-line fields
+func (l TextLimits) Short() TextPolicy {
+	return TextPolicy{MaxLength: l.ShortMaxLength, Truncate: l.TruncateOverflow}
+}
+
+// Long returns the policy for long free-form fields
+func (l TextLimits) Long() TextPolicy {
+	return TextPolicy{MaxLength: l.LongMaxLength, Truncate: l.TruncateOverflow}
+}
+
+// SanitizeText cleans user-provided free text for storage: it strips null bytes
+// and surrounding whitespace, escapes HTML, then enforces the length cap on the
+// escaped result so the stored value always fits its column.
+// Truncation drops whole characters, so an entity is never split.
+func SanitizeText(field, input string, policy TextPolicy) (string, error) {
+	text := SanitizeString(input)
+	escaped := SanitizeHTML(text)
+
+	if policy.MaxLength <= 0 || utf8.RuneCountInString(escaped) <= policy.MaxLength {
+		return escaped, nil
+	}
+	if !policy.Truncate {
+		return "", fmt.Errorf("%w: %s exceeds %d characters", ErrTextTooLong, field, policy.MaxLength)
+	}
+
+	var b strings.Builder
+	length := 0
+	for _, r := range text {
+		piece := SanitizeHTML(string(r))
+		n := utf8.RuneCountInString(piece)
+		if length+n > policy.MaxLength {
+			break
+		}
+		b.WriteString(piece)
+		length += n
+	}
+	return b.String(), nil
+}
+
+// CleanText strips null bytes and surrounding whitespace and enforces the length
+// cap without escaping HTML. Use it for values treated as data rather than display
+// text, such as domains and onboarding variables that feed AI prompts; JSON
+// responses escape them on output.
+func CleanText(field, input string, policy TextPolicy) (string, error) {
+	text := SanitizeString(input)
+
+	if policy.MaxLength > 0 && utf8.RuneCountInString(text) > policy.MaxLength {
+		if !policy.Truncate {
+			return "", fmt.Errorf("%w: %s exceeds %d characters", ErrTextTooLong, field, policy.MaxLength)
+		}
+		text = string([]rune(text)[:policy.MaxLength])
+	}
+
+	return text, nil
+}
@@ -0,0 +1,109 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		policy  TextPolicy
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Script content is escaped",
+			input:  "<script>alert('xss')</script>",
+			policy: TextPolicy{MaxLength: 100},
+			want:   "&lt;script&gt;alert(&#39;xss&#39;)&lt;/script&gt;",
+		},
+		{
+			name:   "Whitespace and null bytes removed",
+			input:  "  great\x00 course  ",
+			policy: TextPolicy{MaxLength: 100},
+			want:   "great course",
+		},
+		{
+			name:    "Overlong text rejected",
+			input:   strings.Repeat("a", 11),
+			policy:  TextPolicy{MaxLength: 10},
+			wantErr: true,
+		},
+		{
+			name:   "Overlong text truncated",
+			input:  strings.Repeat("a", 11),
+			policy: TextPolicy{MaxLength: 10, Truncate: true},
+			want:   strings.Repeat("a", 10),
+		},
+		{
+			name:   "Length counts characters not bytes",
+			input:  "ñññññ",
+			policy: TextPolicy{MaxLength: 5},
+			want:   "ñññññ",
+		},
+		{
+			name:    "Cap applies to the escaped text",
+			input:   "Tom & Jerry",
+			policy:  TextPolicy{MaxLength: 11},
+			wantErr: true,
+		},
+		{
+			name:   "Escaped text within the cap is kept",
+			input:  "Tom & Jerry",
+			policy: TextPolicy{MaxLength: 15},
+			want:   "Tom &amp; Jerry",
+		},
+		{
+			name:   "Truncation never splits an entity",
+			input:  "ab<cd",
+			policy: TextPolicy{MaxLength: 5, Truncate: true},
+			want:   "ab",
+		},
+		{
+			name:   "Zero max length is unlimited",
+			input:  strings.Repeat("a", 5000),
+			policy: TextPolicy{},
+			want:   strings.Repeat("a", 5000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SanitizeText("feedback", tt.input, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SanitizeText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrTextTooLong) {
+					t.Errorf("SanitizeText() error = %v, want ErrTextTooLong", err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SanitizeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	got, err := CleanText("domain", "  e-commerce & retail\x00 ", TextPolicy{MaxLength: 20})
+	if err != nil {
+		t.Fatalf("CleanText() error = %v", err)
+	}
+	if got != "e-commerce & retail" {
+		t.Errorf("CleanText() = %q, want unescaped text", got)
+	}
+
+	if _, err := CleanText("domain", strings.Repeat("a", 11), TextPolicy{MaxLength: 10}); !errors.Is(err, ErrTextTooLong) {
+		t.Errorf("CleanText() error = %v, want ErrTextTooLong", err)
+	}
+
+	got, err = CleanText("domain", strings.Repeat("a", 11), TextPolicy{MaxLength: 10, Truncate: true})
+	if err != nil || got != strings.Repeat("a", 10) {
+		t.Errorf("CleanText() = %q, %v, want truncated text", got, err)
+	}
+}
@@ -0,0 +1,59 @@
+package social
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidAchievementCriteria is returned when an achievement's stored
+// criteria cannot be evaluated
+var ErrInvalidAchievementCriteria = errors.New("invalid achievement criteria")
+
+// achievementMetrics maps the metric names usable in criteria to UserStats fields
+var achievementMetrics = map[string]func(*UserStats) int{
+	"courses_completed":      func(stats *UserStats) int { return stats.CoursesCompleted },
+	"modules_completed":      func(stats *UserStats) int { return stats.ModulesCompleted },
+	"exercises_solved":       func(stats *UserStats) int { return stats.ExercisesSolved },
+	"perfect_scores":         func(stats *UserStats) int { return stats.PerfectScores },
+	"review_scores_avg":      func(stats *UserStats) int { return stats.ReviewScoresAvg },
+	"consecutive_days":       func(stats *UserStats) int { return stats.ConsecutiveDays },
+	"total_time_spent_hours": func(stats *UserStats) int { return stats.TotalTimeSpentHours },
+}
+
+// AchievementCriteria is a threshold on one user statistic, stored in
+// achievements.criteria as e.g. {"metric": "exercises_solved", "gte": 50}
+type AchievementCriteria struct {
+	Metric string `json:"metric"`
+	Gte    int    `json:"gte"`
+}
+
+// ParseAchievementCriteria reads criteria as loaded from achievements.criteria
+func ParseAchievementCriteria(raw interface{}) (AchievementCriteria, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return AchievementCriteria{}, fmt.Errorf("%w: %v", ErrInvalidAchievementCriteria, err)
+	}
+
+	var parsed struct {
+		Metric string `json:"metric"`
+		Gte    *int   `json:"gte"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return AchievementCriteria{}, fmt.Errorf("%w: %v", ErrInvalidAchievementCriteria, err)
+	}
+	if _, ok := achievementMetrics[parsed.Metric]; !ok {
+		return AchievementCriteria{}, fmt.Errorf("%w: unknown metric %q", ErrInvalidAchievementCriteria, parsed.Metric)
+	}
+	if parsed.Gte == nil {
+		return AchievementCriteria{}, fmt.Errorf("%w: gte is required", ErrInvalidAchievementCriteria)
+	}
+
+	return AchievementCriteria{Metric: parsed.Metric, Gte: *parsed.Gte}, nil
+}
+
+// Met reports whether stats satisfy the criteria
+func (c AchievementCriteria) Met(stats *UserStats) bool {
+	value, ok := achievementMetrics[c.Metric]
+	return ok && value(stats) >= c.Gte
+}
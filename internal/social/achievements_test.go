@@ -0,0 +1,143 @@
+package social
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// achievementRows returns earned achievement rows as selected by achievementColumns
+func achievementRows(ids ...string) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "name", "description", "badge_icon", "criteria", "rarity", "created_at", "unlocked_at",
+	})
+	for _, id := range ids {
+		rows.AddRow(id, id, "", "", []byte(`{}`), "rare", time.Now(), time.Now())
+	}
+	return rows
+}
+
+// expectAchievementDefinitions mocks the stored definitions: one already earned
+// in these tests, one a user reaches with 100 hours of learning, one far out of
+// reach and one whose criteria cannot be evaluated
+func expectAchievementDefinitions(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`FROM achievements\s+ORDER BY created_at, id`).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "description", "badge_icon", "criteria", "rarity", "created_at",
+		}).
+			AddRow("first_steps", "First Steps", "Complete your first module", nil,
+				[]byte(`{"metric": "modules_completed", "gte": 0}`), "common", time.Now()).
+			AddRow("dedicated", "Dedicated Student", "Spend 100+ hours learning", nil,
+				[]byte(`{"metric": "total_time_spent_hours", "gte": 100}`), "legendary", time.Now()).
+			AddRow("code_legend", "Code Legend", "Solve 100 exercises", nil,
+				[]byte(`{"metric": "exercises_solved", "gte": 100}`), "epic", time.Now()).
+			AddRow("legacy", "Legacy", "Criteria in an old format", nil,
+				[]byte(`{"type": "modules", "count": 1}`), "common", time.Now()))
+}
+
+// achievementsRequest builds an authenticated achievements request for user-1
+func achievementsRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	return req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+}
+
+func TestGetAchievementsHandler_ListsWithoutUnlocking(t *testing.T) {
+	service, mock := newMockService(t)
+
+	// Only reads: no progress lookup and no INSERT INTO user_achievements
+	mock.ExpectQuery(`FROM achievements a.*AND \(\$2 = '' OR a.rarity = \$2\).*LIMIT \$3 OFFSET \$4`).
+		WithArgs("user-1", "rare", 2, 4).
+		WillReturnRows(achievementRows("fast_learner", "night_owl"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM achievements a`).
+		WithArgs("user-1", "rare").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).GetAchievements(rr, achievementsRequest(http.MethodGet, "/api/users/me/achievements?rarity=rare&limit=2&offset=4"))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Items  []Achievement `json:"items"`
+		Total  int           `json:"total"`
+		Limit  int           `json:"limit"`
+		Offset int           `json:"offset"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Len(t, body.Items, 2)
+	assert.Equal(t, 7, body.Total)
+	assert.Equal(t, 2, body.Limit)
+	assert.Equal(t, 4, body.Offset)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAchievementsHandler_RejectsInvalidQuery(t *testing.T) {
+	for _, query := range []string{"rarity=mythic", "limit=-1", "offset=abc"} {
+		t.Run(query, func(t *testing.T) {
+			service, mock := newMockService(t)
+			rr := httptest.NewRecorder()
+
+			NewHandler(service).GetAchievements(rr, achievementsRequest(http.MethodGet, "/api/users/me/achievements?"+query))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCheckAchievementsHandler_ReturnsNewUnlocks(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`FROM achievements a`).
+		WithArgs("user-1").
+		WillReturnRows(achievementRows("first_steps"))
+	expectAchievementDefinitions(mock)
+	mock.ExpectQuery(`SUM\(time_spent_seconds\)`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(100 * 3600))
+	mock.ExpectExec(`INSERT INTO user_achievements`).
+		WithArgs("user-1", "dedicated").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO activity_feed`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", time.Now()))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).CheckAchievements(rr, achievementsRequest(http.MethodPost, "/api/achievements/check"))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Unlocked []Achievement `json:"unlocked"`
+		Count    int           `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Len(t, body.Unlocked, 1, "already earned achievements are not repeated")
+	assert.Equal(t, "dedicated", body.Unlocked[0].ID)
+	assert.Equal(t, "Dedicated Student", body.Unlocked[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseAchievementCriteria(t *testing.T) {
+	criteria, err := ParseAchievementCriteria(map[string]interface{}{"metric": "exercises_solved", "gte": 50.0})
+	require.NoError(t, err)
+	assert.Equal(t, AchievementCriteria{Metric: "exercises_solved", Gte: 50}, criteria)
+	assert.False(t, criteria.Met(&UserStats{ExercisesSolved: 49}))
+	assert.True(t, criteria.Met(&UserStats{ExercisesSolved: 50}))
+
+	invalid := []interface{}{
+		nil,
+		"exercises_solved >= 50",
+		map[string]interface{}{"metric": "lines_written", "gte": 10.0},
+		map[string]interface{}{"metric": "exercises_solved"},
+		map[string]interface{}{"metric": "exercises_solved", "gte": "fifty"},
+	}
+	for _, raw := range invalid {
+		_, err := ParseAchievementCriteria(raw)
+		assert.ErrorIs(t, err, ErrInvalidAchievementCriteria, "criteria %v", raw)
+	}
+}
@@ -0,0 +1,80 @@
+package social
+
+import "backend/internal/platform/cache"
+
+// Cache names used as metrics labels
+const (
+	trendingCacheName        = "trending"
+	recommendationsCacheName = "recommendations"
+)
+
+// trendingCacheKey is the key for the overall top trending list
+const trendingCacheKey = "top"
+
+// trendingGroupedCacheKey is the key for the per-category top trending lists
+const trendingGroupedCacheKey = "grouped"
+
+// trendingCategoryCacheKey builds the key for one category's trending list;
+// the empty category is the overall list
+func trendingCategoryCacheKey(category string) string {
+	if category == "" {
+		return trendingCacheKey
+	}
+	return "category:" + category
+}
+
+// allRecommendationsKey marks the grouped (all types) recommendation entry
+const allRecommendationsKey = "all"
+
+// WithCaches enables in-memory caching of trending courses and recommendation rows.
+// A config with a zero TTL or size leaves that cache disabled.
+func (s *Service) WithCaches(trending, recommendations cache.Config) *Service {
+	if trending.Enabled() {
+		s.trendingCache = cache.New[string, []TrendingCourse](trendingCacheName, trending)
+	}
+	if recommendations.Enabled() {
+		s.recommendationsCache = cache.New[string, map[string][]Recommendation](recommendationsCacheName, recommendations)
+	}
+	return s
+}
+
+// recommendationsCacheKey builds the per-user key for a recommendation row or "all"
+func recommendationsCacheKey(userID, recType string) string {
+	return userID + ":" + recType
+}
+
+// invalidateRecommendations drops every cached recommendation row for the user
+func (s *Service) invalidateRecommendations(userID string) {
+	if s.recommendationsCache == nil {
+		return
+	}
+	s.recommendationsCache.Delete(recommendationsCacheKey(userID, allRecommendationsKey))
+	for _, recType := range []string{RecTypeCollaborativeFiltering, RecTypeSkillAdjacency, RecTypeSocialSignal, RecTypeTrending} {
+		s.recommendationsCache.Delete(recommendationsCacheKey(userID, recType))
+	}
+}
+
+// The cache hands out the stored slice and map headers, so every value goes in and
+// comes out as a copy; callers may modify what they get back without corrupting
+// the cached entry. Recommendation.Metadata is not deep-copied and must be treated
+// as read-only.
+
+// cloneTrending returns a copy of the trending list
+func cloneTrending(courses []TrendingCourse) []TrendingCourse {
+	if courses == nil {
+		return nil
+	}
+	return append([]TrendingCourse(nil), courses...)
+}
+
+// cloneRecommendations returns a copy of the grouped rows, including each row's slice
+func cloneRecommendations(grouped map[string][]Recommendation) map[string][]Recommendation {
+	if grouped == nil {
+		return nil
+	}
+	clone := make(map[string][]Recommendation, len(grouped))
+	for recType, recs := range grouped {
+		clone[recType] = append([]Recommendation{}, recs...)
+	}
+	return clone
+}
@@ -0,0 +1,132 @@
+package social
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// feedStreamBuffer is how many activities a stream subscriber may fall behind
+// before new ones are dropped for it
+const feedStreamBuffer = 16
+
+// FeedHub is an in-process pub/sub that fans new activities out to the users
+// connected to the live feed stream. Delivery is best effort: a subscriber whose
+// buffer is full misses the activity and can catch up with GET /api/feed.
+type FeedHub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan ActivityFeed]struct{}
+}
+
+// NewFeedHub creates an empty feed hub
+func NewFeedHub() *FeedHub {
+	return &FeedHub{subscribers: make(map[string]map[chan ActivityFeed]struct{})}
+}
+
+// Subscribe registers a stream for userID. The returned function unsubscribes
+// and closes the channel; it is safe to call more than once.
+func (h *FeedHub) Subscribe(userID string) (<-chan ActivityFeed, func()) {
+	ch := make(chan ActivityFeed, feedStreamBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan ActivityFeed]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// HasSubscribers reports whether anyone is connected, so publishers can skip
+// looking up recipients when nobody is listening
+func (h *FeedHub) HasSubscribers() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers) > 0
+}
+
+// Publish delivers activity to every stream of the given users without blocking
+func (h *FeedHub) Publish(activity ActivityFeed, userIDs ...string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for ch := range h.subscribers[userID] {
+			select {
+			case ch <- activity:
+			default:
+				slog.Warn("feed stream subscriber is behind, dropping activity",
+					"user_id", userID, "activity_id", activity.ID)
+			}
+		}
+	}
+}
+
+// SubscribeFeed streams the activities that would appear in userID's feed as
+// they are created. Call the returned function to stop.
+func (s *Service) SubscribeFeed(userID string) (<-chan ActivityFeed, func()) {
+	return s.feedHub.Subscribe(userID)
+}
+
+// createActivity stores activity and publishes it to connected feed streams
+func (s *Service) createActivity(ctx context.Context, activity *ActivityFeed) error {
+	if err := s.repo.CreateActivity(ctx, activity); err != nil {
+		return err
+	}
+	s.publishActivity(ctx, *activity)
+	return nil
+}
+
+// publishActivity pushes activity to the streams of the users whose feed shows
+// it: the author and their followers for public activity, or their friends
+// (mutual follows) for friends activity. A private follow event goes only to
+// the followed user.
+func (s *Service) publishActivity(ctx context.Context, activity ActivityFeed) {
+	if !s.feedHub.HasSubscribers() {
+		return
+	}
+	if isFollowNotice(activity) {
+		s.feedHub.Publish(activity, activity.ReferenceID)
+		return
+	}
+	if activity.Visibility != "public" && activity.Visibility != "friends" {
+		return
+	}
+
+	recipients := []string{activity.UserID}
+	var viewers []string
+	var err error
+	if activity.Visibility == VisibilityFriends {
+		viewers, err = s.repo.GetMutualFollows(ctx, activity.UserID)
+	} else {
+		viewers, err = s.repo.GetFollowers(ctx, activity.UserID)
+	}
+	if err != nil {
+		// The activity is saved; streams fall back to polling for it
+		slog.Warn("failed to get audience for feed stream", "user_id", activity.UserID, "error", err)
+	}
+	recipients = append(recipients, viewers...)
+
+	s.feedHub.Publish(activity, recipients...)
+}
+
+// isFollowNotice reports whether activity is a private follow event, shown only
+// to the user who was followed
+func isFollowNotice(activity ActivityFeed) bool {
+	return activity.ActivityType == ActivityUserFollowed &&
+		activity.Visibility == VisibilityPrivate &&
+		activity.ReferenceType == "user" &&
+		activity.ReferenceID != activity.UserID
+}
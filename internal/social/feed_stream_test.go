@@ -0,0 +1,212 @@
+package social
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedHubDeliversOnlyToRecipients(t *testing.T) {
+	hub := NewFeedHub()
+	follower, unsubscribeFollower := hub.Subscribe("follower-1")
+	stranger, unsubscribeStranger := hub.Subscribe("stranger-1")
+	defer unsubscribeStranger()
+
+	hub.Publish(ActivityFeed{ID: "activity-1"}, "follower-1")
+
+	assert.Equal(t, "activity-1", (<-follower).ID)
+	assert.Empty(t, stranger)
+
+	// A full buffer drops activities instead of blocking the publisher
+	for i := 0; i < feedStreamBuffer+5; i++ {
+		hub.Publish(ActivityFeed{ID: "flood"}, "follower-1")
+	}
+	assert.Len(t, follower, feedStreamBuffer)
+
+	unsubscribeFollower()
+	unsubscribeFollower()
+	unsubscribeStranger()
+	assert.False(t, hub.HasSubscribers())
+}
+
+func TestBroadcastActivityPublishesToFollowerStreams(t *testing.T) {
+	service, mock := newMockService(t)
+	follower, unsubscribeFollower := service.SubscribeFeed("follower-1")
+	defer unsubscribeFollower()
+	stranger, unsubscribeStranger := service.SubscribeFeed("stranger-1")
+	defer unsubscribeStranger()
+
+	mock.ExpectQuery(`INSERT INTO activity_feed`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", time.Now()))
+	mock.ExpectQuery(`SELECT follower_id\s+FROM user_relationships`).
+		WithArgs("author-1").
+		WillReturnRows(sqlmock.NewRows([]string{"follower_id"}).AddRow("follower-1"))
+
+	err := service.BroadcastActivity(context.Background(), "author-1", "course_completed", map[string]interface{}{"course_id": "course-1"})
+	require.NoError(t, err)
+
+	activity := <-follower
+	assert.Equal(t, "activity-1", activity.ID)
+	assert.Equal(t, "course", activity.ReferenceType)
+	assert.Empty(t, stranger)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBroadcastActivityStreamsFriendsActivityToMutualFollows(t *testing.T) {
+	service, mock := newMockService(t)
+	friend, unsubscribeFriend := service.SubscribeFeed("friend-1")
+	defer unsubscribeFriend()
+	follower, unsubscribeFollower := service.SubscribeFeed("follower-1")
+	defer unsubscribeFollower()
+
+	mock.ExpectQuery(`INSERT INTO activity_feed`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", time.Now()))
+	mock.ExpectQuery(`SELECT r.following_id\s+FROM user_relationships r\s+JOIN user_relationships back`).
+		WithArgs("author-1").
+		WillReturnRows(sqlmock.NewRows([]string{"following_id"}).AddRow("friend-1"))
+
+	err := service.BroadcastActivity(context.Background(), "author-1", "exercise_solved", map[string]interface{}{"exercise_id": "exercise-1"})
+	require.NoError(t, err)
+
+	activity := <-friend
+	assert.Equal(t, "friends", activity.Visibility)
+	assert.Empty(t, follower)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBroadcastActivityDoesNotStreamPrivateActivity(t *testing.T) {
+	service, mock := newMockService(t)
+	own, unsubscribe := service.SubscribeFeed("author-1")
+	defer unsubscribe()
+
+	// Private activities never appear in a feed, so followers are not looked up
+	mock.ExpectQuery(`INSERT INTO activity_feed`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", time.Now()))
+
+	require.NoError(t, service.BroadcastActivity(context.Background(), "author-1", "exercise_attempted", nil))
+
+	assert.Empty(t, own)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStreamActivityFeedHandler(t *testing.T) {
+	service, _ := newMockService(t)
+	handler := NewHandler(service)
+	handler.feedHeartbeat = 20 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(middleware.ContextWithUser(r.Context(), &middleware.UserClaims{UserID: "user-1"}))
+		handler.StreamActivityFeed(w, r)
+	}))
+	defer server.Close()
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/feed/stream", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var event strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if line == "\n" {
+				return event.String()
+			}
+			event.WriteString(line)
+		}
+	}
+
+	assert.Equal(t, ": connected\n", readEvent())
+	assert.Equal(t, ": heartbeat\n", readEvent(), "idle streams get heartbeats")
+
+	service.feedHub.Publish(ActivityFeed{ID: "activity-1", UserID: "author-1", ActivityType: "course_completed"}, "user-1")
+
+	event := readEvent()
+	for strings.HasPrefix(event, ":") { // Skip heartbeats sent meanwhile
+		event = readEvent()
+	}
+	lines := strings.Split(strings.TrimSuffix(event, "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id: activity-1", lines[0])
+	assert.Equal(t, "event: activity", lines[1])
+	var activity ActivityFeed
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &activity))
+	assert.Equal(t, "course_completed", activity.ActivityType)
+
+	// Disconnecting unsubscribes the stream
+	disconnect()
+	assert.Eventually(t, func() bool { return !service.feedHub.HasSubscribers() }, time.Second, 10*time.Millisecond)
+}
+
+func TestStreamActivityFeedRequiresUser(t *testing.T) {
+	service, _ := newMockService(t)
+	rr := httptest.NewRecorder()
+
+	NewHandler(service).StreamActivityFeed(rr, httptest.NewRequest(http.MethodGet, "/api/feed/stream", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestStreamActivityFeedHandler_ExcludesOwnActivity(t *testing.T) {
+	service, _ := newMockService(t)
+	handler := NewHandler(service)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(middleware.ContextWithUser(r.Context(), &middleware.UserClaims{UserID: "user-1"}))
+		handler.StreamActivityFeed(w, r)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/feed/stream?include_own=false")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": connected\n", line)
+
+	service.feedHub.Publish(ActivityFeed{ID: "own-1", UserID: "user-1", ActivityType: "course_completed"}, "user-1")
+	service.feedHub.Publish(ActivityFeed{ID: "activity-1", UserID: "author-1", ActivityType: "course_completed"}, "user-1")
+
+	for {
+		line, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		if strings.HasPrefix(line, "id: ") {
+			break
+		}
+	}
+	assert.Equal(t, "id: activity-1\n", line, "the user's own activity is skipped")
+}
+
+func TestStreamActivityFeedHandler_InvalidIncludeOwn(t *testing.T) {
+	service, _ := newMockService(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/feed/stream?include_own=maybe", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	NewHandler(service).StreamActivityFeed(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.False(t, service.feedHub.HasSubscribers())
+}
@@ -0,0 +1,91 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MaxFollowBatchSize caps how many users one batch follow or unfollow may name
+const MaxFollowBatchSize = 100
+
+// ErrInvalidFollowBatch is returned for an empty or oversized batch, or one with a malformed user ID
+var ErrInvalidFollowBatch = errors.New("invalid follow batch")
+
+// GetFollowStatus reports whether viewerID follows userID and whether userID follows them back
+func (s *Service) GetFollowStatus(ctx context.Context, viewerID, userID string) (*FollowStatus, error) {
+	following, err := s.repo.IsFollowing(ctx, viewerID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow status: %w", err)
+	}
+	followsYou, err := s.repo.IsFollowing(ctx, userID, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follow status: %w", err)
+	}
+
+	return &FollowStatus{Following: following, FollowsYou: followsYou}, nil
+}
+
+// FollowUsers follows every listed user in one insert and returns the users
+// that were newly followed. Users already followed, unknown users and the
+// follower themselves are skipped.
+func (s *Service) FollowUsers(ctx context.Context, followerID string, userIDs []string) ([]string, error) {
+	ids, err := followBatchIDs(followerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	followed, err := s.repo.FollowUsers(ctx, followerID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to follow users: %w", err)
+	}
+
+	for _, followingID := range followed {
+		s.recordFollowActivity(ctx, followerID, followingID)
+	}
+
+	return followed, nil
+}
+
+// UnfollowUsers removes every listed follow relationship in one statement and
+// returns the users that were unfollowed; users not followed are skipped
+func (s *Service) UnfollowUsers(ctx context.Context, followerID string, userIDs []string) ([]string, error) {
+	ids, err := followBatchIDs(followerID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	unfollowed, err := s.repo.UnfollowUsers(ctx, followerID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unfollow users: %w", err)
+	}
+
+	return unfollowed, nil
+}
+
+// followBatchIDs validates a batch and returns its IDs without duplicates or the follower
+func followBatchIDs(followerID string, userIDs []string) ([]string, error) {
+	if len(userIDs) == 0 {
+		return nil, fmt.Errorf("%w: user_ids must not be empty", ErrInvalidFollowBatch)
+	}
+	if len(userIDs) > MaxFollowBatchSize {
+		return nil, fmt.Errorf("%w: at most %d user_ids are allowed", ErrInvalidFollowBatch, MaxFollowBatchSize)
+	}
+
+	seen := make(map[string]bool, len(userIDs))
+	ids := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid user ID", ErrInvalidFollowBatch, id)
+		}
+		if id == followerID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
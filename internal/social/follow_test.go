@@ -0,0 +1,271 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	followerID = "11111111-1111-1111-1111-111111111111"
+	friendID   = "22222222-2222-2222-2222-222222222222"
+	strangerID = "33333333-3333-3333-3333-333333333333"
+)
+
+// expectIsFollowing mocks one IsFollowing lookup
+func expectIsFollowing(mock sqlmock.Sqlmock, followerID, followingID string, following bool) {
+	mock.ExpectQuery(`SELECT EXISTS`).WithArgs(followerID, followingID).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(following))
+}
+
+func TestGetFollowStatus(t *testing.T) {
+	service, mock := newMockService(t)
+	expectIsFollowing(mock, followerID, friendID, true)
+	expectIsFollowing(mock, friendID, followerID, false)
+
+	status, err := service.GetFollowStatus(context.Background(), followerID, friendID)
+	require.NoError(t, err)
+	assert.Equal(t, &FollowStatus{Following: true, FollowsYou: false}, status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowUsers_SingleInsertForBatch(t *testing.T) {
+	service, mock := newMockService(t)
+
+	// Duplicates and the follower themselves are dropped before the insert
+	mock.ExpectQuery(`INSERT INTO user_relationships`).
+		WithArgs(followerID, pq.Array([]string{friendID, strangerID})).
+		WillReturnRows(sqlmock.NewRows([]string{"following_id"}).AddRow(strangerID))
+	mock.ExpectQuery(`INSERT INTO activity_feed`).WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", time.Now()))
+
+	followed, err := service.FollowUsers(context.Background(), followerID, []string{friendID, followerID, strangerID, friendID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{strangerID}, followed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowUser_RecordsActivityOnlyForNewFollows(t *testing.T) {
+	service, mock := newMockService(t)
+	target, unsubscribeTarget := service.SubscribeFeed(friendID)
+	defer unsubscribeTarget()
+	own, unsubscribeOwn := service.SubscribeFeed(followerID)
+	defer unsubscribeOwn()
+
+	mock.ExpectExec(`INSERT INTO user_relationships`).WithArgs(followerID, friendID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO activity_feed`).
+		WithArgs(followerID, ActivityUserFollowed, "user", friendID, sqlmock.AnyArg(), VisibilityPrivate, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", time.Now()))
+	require.NoError(t, service.FollowUser(context.Background(), followerID, friendID))
+
+	// The follow event streams to the followed user, not to the follower or their followers
+	assert.Equal(t, "activity-1", (<-target).ID)
+	assert.Empty(t, own)
+
+	// Following again changes nothing and records no duplicate activity
+	mock.ExpectExec(`INSERT INTO user_relationships`).WithArgs(followerID, friendID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, service.FollowUser(context.Background(), followerID, friendID))
+
+	assert.Empty(t, target)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowUsers_RejectsInvalidBatches(t *testing.T) {
+	tooMany := make([]string, MaxFollowBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = friendID
+	}
+
+	for name, ids := range map[string][]string{
+		"empty":     nil,
+		"too large": tooMany,
+		"malformed": {friendID, "not-a-uuid"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			service, mock := newMockService(t)
+
+			_, err := service.FollowUsers(context.Background(), followerID, ids)
+			assert.ErrorIs(t, err, ErrInvalidFollowBatch)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUnfollowUsers_SingleDelete(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`DELETE FROM user_relationships`).
+		WithArgs(followerID, pq.Array([]string{friendID, strangerID})).
+		WillReturnRows(sqlmock.NewRows([]string{"following_id"}).AddRow(friendID))
+
+	unfollowed, err := service.UnfollowUsers(context.Background(), followerID, []string{friendID, strangerID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{friendID}, unfollowed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowStatusHandler(t *testing.T) {
+	service, mock := newMockService(t)
+	expectIsFollowing(mock, followerID, friendID, true)
+	expectIsFollowing(mock, friendID, followerID, true)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/"+friendID+"/follow-status", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": friendID})
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: followerID}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).GetFollowStatus(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"following": true, "follows_you": true}`, rec.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowUsersHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"invalid json", `{`, http.StatusBadRequest},
+		{"empty batch", `{"user_ids": []}`, http.StatusBadRequest},
+		{"oversized batch", `{"user_ids": [` + strings.Repeat(`"`+friendID+`",`, MaxFollowBatchSize) + `"` + friendID + `"]}`, http.StatusRequestEntityTooLarge},
+		{"followed", `{"user_ids": ["` + friendID + `"]}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			if tt.wantStatus == http.StatusOK {
+				mock.ExpectQuery(`INSERT INTO user_relationships`).
+					WillReturnRows(sqlmock.NewRows([]string{"following_id"}).AddRow(friendID))
+				mock.ExpectQuery(`INSERT INTO activity_feed`).WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("activity-1", time.Now()))
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/users/follow/batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: followerID}))
+			rec := httptest.NewRecorder()
+
+			NewHandler(service).FollowUsers(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				var resp struct {
+					Followed []string `json:"followed"`
+					Count    int      `json:"count"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, []string{friendID}, resp.Followed)
+				assert.Equal(t, 1, resp.Count)
+			} else {
+				var resp apierror.Response
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, apierror.CodeForStatus(tt.wantStatus), resp.Error)
+				assert.Equal(t, tt.wantStatus, resp.Status)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetFriendsHandler(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`JOIN user_relationships back`).WithArgs(followerID).
+		WillReturnRows(sqlmock.NewRows([]string{"following_id"}).AddRow(friendID))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me/friends", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: followerID}))
+	rec := httptest.NewRecorder()
+
+	NewHandler(service).GetFriends(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"items": ["`+friendID+`"], "total": 1, "limit": 1, "offset": 0}`, rec.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	rec = httptest.NewRecorder()
+	NewHandler(service).GetFriends(rec, httptest.NewRequest(http.MethodGet, "/api/users/me/friends", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+// followListRequest returns a GET of userID's follow list made by userID
+func followListRequest(list, userID, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/users/"+userID+"/"+list+query, nil)
+	req = mux.SetURLVars(req, map[string]string{"id": userID})
+	return req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: userID}))
+}
+
+func TestGetFollowersHandler_Expanded(t *testing.T) {
+	service, mock := newMockService(t)
+	followedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	// One joined query for the page, instead of a profile lookup per follower
+	mock.ExpectQuery(`FROM user_relationships r\s+JOIN users u ON u.id = r.follower_id`).
+		WithArgs(followerID, 2, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "avatar_url", "exists", "created_at"}).
+			AddRow(friendID, "Friend", "https://example.com/f.png", true, followedAt).
+			AddRow(strangerID, "Stranger", "", false, followedAt))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM user_relationships WHERE following_id = \$1`).
+		WithArgs(followerID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).GetFollowers(rr, followListRequest("followers", followerID, "?expand=true&limit=2&offset=4"))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"items": [
+			{"id": "`+friendID+`", "name": "Friend", "avatar_url": "https://example.com/f.png", "mutual": true, "followed_at": "2026-10-01T12:00:00Z"},
+			{"id": "`+strangerID+`", "name": "Stranger", "avatar_url": "", "mutual": false, "followed_at": "2026-10-01T12:00:00Z"}
+		],
+		"total": 7, "limit": 2, "offset": 4
+	}`, rr.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFollowingHandler_ExpandFlag(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	// Without expand the list stays a single page of IDs
+	mock.ExpectQuery(`SELECT following_id\s+FROM user_relationships`).
+		WithArgs(followerID).
+		WillReturnRows(sqlmock.NewRows([]string{"following_id"}).AddRow(friendID))
+	rr := httptest.NewRecorder()
+	handler.GetFollowing(rr, followListRequest("following", followerID, ""))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"items": ["`+friendID+`"], "total": 1, "limit": 1, "offset": 0}`, rr.Body.String())
+
+	// Oversized pages are clamped
+	mock.ExpectQuery(`JOIN users u ON u.id = r.following_id`).
+		WithArgs(followerID, MaxFollowPageSize, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "avatar_url", "exists", "created_at"}))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM user_relationships WHERE follower_id = \$1`).
+		WithArgs(followerID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	rr = httptest.NewRecorder()
+	handler.GetFollowing(rr, followListRequest("following", followerID, "?expand=1&limit=1000"))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"items": [], "total": 0, "limit": 200, "offset": 0}`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	handler.GetFollowing(rr, followListRequest("following", followerID, "?expand=maybe"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
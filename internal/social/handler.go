@@ -1,22 +1,43 @@
 package social
 
 import (
+	"backend/internal/platform/apierror"
+	"backend/internal/platform/jsonbody"
 	"backend/internal/platform/middleware"
+	"backend/internal/platform/pagination"
+	"backend/internal/platform/validation"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
+// FeedStreamHeartbeat is how often the live feed stream sends a comment line,
+// keeping idle connections open through proxies and detecting gone clients
+const FeedStreamHeartbeat = 25 * time.Second
+
 // Handler handles HTTP requests for social domain
 type Handler struct {
 	service *Service
+
+	feedHeartbeat time.Duration
 }
 
 // NewHandler creates a new social handler
 func NewHandler(service *Service) *Handler {
-	return &Handler{service: service}
+	return &Handler{service: service, feedHeartbeat: FeedStreamHeartbeat}
+}
+
+// writeError writes an error response in the shared apierror envelope
+func writeError(w http.ResponseWriter, status int, message string) {
+	apierror.WriteError(w, apierror.FromStatus(status, message))
 }
 
 // FollowUser handles POST /api/users/:id/follow
@@ -26,20 +47,20 @@ func (h *Handler) FollowUser(w http.ResponseWriter, r *http.Request) {
 	followingID := vars["id"]
 
 	if followingID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "User ID is required")
 		return
 	}
 
 	// Extract current user from JWT context
 	followerID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Follow user
-	if err := h.service.FollowUser(followerID, followingID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.FollowUser(r.Context(), followerID, followingID); err != nil {
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
@@ -57,20 +78,20 @@ func (h *Handler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
 	followingID := vars["id"]
 
 	if followingID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "User ID is required")
 		return
 	}
 
 	// Extract current user from JWT context
 	followerID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Unfollow user
-	if err := h.service.UnfollowUser(followerID, followingID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.UnfollowUser(r.Context(), followerID, followingID); err != nil {
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
@@ -80,12 +101,96 @@ func (h *Handler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetActivityFeed handles GET /api/feed
+// GetFollowStatus handles GET /api/users/:id/follow-status
+func (h *Handler) GetFollowStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["id"]
+
+	if userID == "" {
+		writeError(w, http.StatusBadRequest, "User ID is required")
+		return
+	}
+
+	viewerID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	status, err := h.service.GetFollowStatus(r.Context(), viewerID, userID)
+	if err != nil {
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// FollowBatchRequest lists the users a batch follow or unfollow applies to
+type FollowBatchRequest struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// FollowUsers handles POST /api/users/follow/batch
+func (h *Handler) FollowUsers(w http.ResponseWriter, r *http.Request) {
+	h.followBatch(w, r, "followed", h.service.FollowUsers)
+}
+
+// UnfollowUsers handles DELETE /api/users/follow/batch
+func (h *Handler) UnfollowUsers(w http.ResponseWriter, r *http.Request) {
+	h.followBatch(w, r, "unfollowed", h.service.UnfollowUsers)
+}
+
+// followBatch decodes a FollowBatchRequest, applies it for the current user and
+// responds with the affected user IDs under key
+func (h *Handler) followBatch(w http.ResponseWriter, r *http.Request, key string, apply func(context.Context, string, []string) ([]string, error)) {
+	followerID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req FollowBatchRequest
+	if err := jsonbody.DecodeLimited(r, &req, MaxFollowBatchSize); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	userIDs, err := apply(r.Context(), followerID, req.UserIDs)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFollowBatch) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		key:     userIDs,
+		"count": len(userIDs),
+	})
+}
+
+// feedPageSize returns the number of activities a feed page holds for a requested limit
+func feedPageSize(limit int) int {
+	if limit <= 0 {
+		return 50 // Default limit
+	}
+	if limit > 200 {
+		return 200 // Max limit
+	}
+	return limit
+}
+
+// GetActivityFeed handles GET /api/feed?limit=&from=&to=&include_own=
 func (h *Handler) GetActivityFeed(w http.ResponseWriter, r *http.Request) {
 	// Extract current user from JWT context
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
@@ -97,66 +202,369 @@ func (h *Handler) GetActivityFeed(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Optional created_at range, e.g. for "on this day"
+	rng, err := ParseFeedRange(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// cursor continues from the next_cursor of a previous page
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		rng.Before, err = ParseFeedCursor(cursor)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	includeOwn, err := parseIncludeOwn(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Get activity feed
-	activities, err := h.service.GetActivityFeed(userID, limit)
+	limit = feedPageSize(limit)
+	activities, err := h.service.GetActivityFeed(r.Context(), userID, limit, rng, includeOwn)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
+	// A full page may have older activity behind it
+	var nextCursor string
+	if len(activities) == limit {
+		nextCursor = NewFeedCursor(activities[len(activities)-1]).Encode()
+	}
+
 	// Return ticker data
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"activities": activities,
-		"count":      len(activities),
-	})
+	pagination.Write(w, pagination.Cursor(activities, limit, nextCursor))
 }
 
-// GetRecommendations handles GET /api/recommendations
+// parseIncludeOwn reads ?include_own=; the user's own activity is shown
+// alongside followees' unless it is false
+func parseIncludeOwn(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("include_own")
+	if value == "" {
+		return true, nil
+	}
+	includeOwn, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("include_own must be true or false")
+	}
+	return includeOwn, nil
+}
+
+// StreamActivityFeed handles GET /api/feed/stream?include_own=, pushing new feed
+// activities to the user as server-sent "activity" events until they disconnect.
+// Clients that cannot keep a connection open should poll GET /api/feed instead.
+func (h *Handler) StreamActivityFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	includeOwn, err := parseIncludeOwn(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.Warn("failed to clear write deadline for feed stream", "error", err)
+	}
+
+	activities, unsubscribe := h.service.SubscribeFeed(userID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.feedHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case activity, ok := <-activities:
+			if !ok {
+				return
+			}
+			if !includeOwn && activity.UserID == userID {
+				continue
+			}
+			data, err := json.Marshal(activity)
+			if err != nil {
+				slog.Error("failed to encode feed stream activity", "activity_id", activity.ID, "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: activity\ndata: %s\n\n", activity.ID, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// GetRecommendations handles GET /api/recommendations?type=
 func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
 	// Extract current user from JWT context
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Get recommendations grouped by type
-	recommendations, err := h.service.GetRecommendations(userID)
+	// Optional ?type= lets clients lazy-load a single row
+	recType := r.URL.Query().Get("type")
+
+	var recommendations map[string][]Recommendation
+	var err error
+	if recType != "" {
+		recommendations, err = h.service.GetRecommendationsByType(r.Context(), userID, recType)
+	} else {
+		recommendations, err = h.service.GetRecommendations(r.Context(), userID)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, ErrInvalidRecommendationType) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
+	sections := recommendationSections
+	if recType != "" {
+		sections = map[string]string{recType: recommendationSections[recType]}
+	}
+
 	// Return Netflix-style rows
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"recommendations": recommendations,
-		"sections": map[string]string{
-			"collaborative_filtering": "Because You Completed",
-			"skill_adjacency":        "Next Level Skills",
-			"social_signal":          "Friends Are Learning",
-			"trending":               "Trending Now",
-		},
+		"sections":        sections,
+	})
+}
+
+// DismissRecommendation handles POST /api/recommendations/{courseId}/dismiss
+func (h *Handler) DismissRecommendation(w http.ResponseWriter, r *http.Request) {
+	h.recordRecommendationFeedback(w, r, FeedbackDismissed)
+}
+
+// MarkRecommendationInterested handles POST /api/recommendations/{courseId}/interested
+func (h *Handler) MarkRecommendationInterested(w http.ResponseWriter, r *http.Request) {
+	h.recordRecommendationFeedback(w, r, FeedbackInterested)
+}
+
+// recordRecommendationFeedback stores the given feedback for the course in the URL
+func (h *Handler) recordRecommendationFeedback(w http.ResponseWriter, r *http.Request, feedbackType string) {
+	courseID := mux.Vars(r)["courseId"]
+	if courseID == "" {
+		writeError(w, http.StatusBadRequest, "Course ID is required")
+		return
+	}
+	if _, err := uuid.Parse(courseID); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid course ID")
+		return
+	}
+
+	// Extract current user from JWT context
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	feedback, err := h.service.RecordRecommendationFeedback(r.Context(), userID, courseID, feedbackType)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFeedbackType) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, ErrCourseNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "Recommendation feedback recorded",
+		"feedback": feedback,
 	})
 }
 
-// GetTrendingCourses handles GET /api/trending
+// FeedbackBatchRequest lists the courses a batch of recommendation feedback applies to
+type FeedbackBatchRequest struct {
+	CourseIDs    []string `json:"course_ids"`
+	FeedbackType string   `json:"feedback_type"`
+}
+
+// RecordFeedbackBatch handles POST /api/recommendations/feedback/batch
+func (h *Handler) RecordFeedbackBatch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req FeedbackBatchRequest
+	if err := jsonbody.DecodeLimited(r, &req, MaxFeedbackBatchSize); err != nil {
+		apierror.WriteError(w, err)
+		return
+	}
+
+	courseIDs, err := h.service.RecordRecommendationFeedbackBatch(r.Context(), userID, req.CourseIDs, req.FeedbackType)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFeedbackBatch) || errors.Is(err, ErrInvalidFeedbackType) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"course_ids":    courseIDs,
+		"feedback_type": req.FeedbackType,
+		"count":         len(courseIDs),
+	})
+}
+
+// recommendationSections maps recommendation types to their row titles
+var recommendationSections = map[string]string{
+	RecTypeCollaborativeFiltering: "Because You Completed",
+	RecTypeSkillAdjacency:         "Next Level Skills",
+	RecTypeSocialSignal:           "Friends Are Learning",
+	RecTypeTrending:               "Trending Now",
+}
+
+// GetTrendingCourses handles GET /api/trending?category=
+// With a category only that category's trending courses are returned; without
+// one the overall list is accompanied by the top courses of each category.
 func (h *Handler) GetTrendingCourses(w http.ResponseWriter, r *http.Request) {
-	// Get trending courses
-	courses, err := h.service.GetTrendingCourses()
+	category := r.URL.Query().Get("category")
+
+	courses, err := h.service.GetTrendingCourses(r.Context(), category)
+	if err != nil {
+		var fieldErr *validation.FieldError
+		if errors.As(err, &fieldErr) {
+			apierror.WriteError(w, apierror.InvalidField(fieldErr.Field, err.Error()))
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	page := pagination.All(courses)
+	if category != "" {
+		// Echo the category as stored, whatever case it was requested in
+		stored, _ := validation.OneOf("category", category, TrendingCategories)
+		page = page.WithMeta("category", stored)
+	} else {
+		grouped, err := h.service.GetTrendingByCategory(r.Context())
+		if err != nil {
+			apierror.WriteError(w, apierror.Internal(err))
+			return
+		}
+		page = page.WithMeta("by_category", grouped)
+	}
+
+	pagination.Write(w, page)
+}
+
+// GetLeaderboard handles GET /api/leaderboard?metric=&period=&limit=
+func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	metric := r.URL.Query().Get("metric")
+	period := r.URL.Query().Get("period")
+
+	limit := DefaultLeaderboardSize
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(parsedLimit, MaxLeaderboardSize)
+	}
+
+	entries, err := h.service.GetLeaderboard(r.Context(), metric, period, limit)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, ErrInvalidLeaderboard) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
+	if metric == "" {
+		metric = LeaderboardPoints
+	}
+	if period == "" {
+		period = LeaderboardAllTime
+	}
+
+	// The leaderboard is a top list, not a page of a longer one, so there is
+	// no total to report and never a next page
+	pagination.Write(w, pagination.Cursor(entries, limit, "").
+		WithMeta("metric", metric).
+		WithMeta("period", period))
+}
+
+// GetSkillGraph handles GET /api/skills/graph
+func (h *Handler) GetSkillGraph(w http.ResponseWriter, r *http.Request) {
+	skills := h.service.SkillNames()
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"trending": courses,
-		"count":    len(courses),
+		"graph":  h.service.GetSkillGraph(),
+		"skills": skills,
+		"count":  len(skills),
 	})
 }
 
+// GetAdjacentSkills handles GET /api/skills/:skill/adjacent
+func (h *Handler) GetAdjacentSkills(w http.ResponseWriter, r *http.Request) {
+	skill := mux.Vars(r)["skill"]
+
+	adjacent, err := h.service.GetAdjacentSkills(skill)
+	if err != nil {
+		if errors.Is(err, ErrSkillNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	pagination.Write(w, pagination.All(adjacent).WithMeta("skill", normalizeSkill(skill)))
+}
+
 // GetUserProfile handles GET /api/users/:id/profile
 func (h *Handler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
 	// Extract user ID from URL
@@ -164,14 +572,31 @@ func (h *Handler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
 	userID := vars["id"]
 
 	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "User ID is required")
 		return
 	}
 
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Admins see the profile as its owner does; everyone else gets what the
+	// owner's privacy settings allow
+	viewerID := claims.UserID
+	if claims.IsAdmin {
+		viewerID = userID
+	}
+
 	// Get complete user profile data from all domains
-	profileData, err := h.service.GetUserProfileData(userID)
+	profileData, err := h.service.GetUserProfileData(r.Context(), viewerID, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if errors.Is(err, ErrUserNotFound) {
+			writeError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
@@ -180,91 +605,255 @@ func (h *Handler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(profileData)
 }
 
-// GetAchievements handles GET /api/users/me/achievements
+// GetAchievements handles GET /api/users/me/achievements?rarity=&limit=&offset=
+// It only lists earned achievements; POST /api/achievements/check unlocks new ones.
 func (h *Handler) GetAchievements(w http.ResponseWriter, r *http.Request) {
 	// Extract current user from JWT context
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	limit, err := parseNonNegativeQueryInt(r, "limit", DefaultAchievementPageSize)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	offset, err := parseNonNegativeQueryInt(r, "offset", 0)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = DefaultAchievementPageSize
+	}
+	if limit > MaxAchievementPageSize {
+		limit = MaxAchievementPageSize
+	}
+
+	achievements, total, err := h.service.ListAchievements(r.Context(), userID, r.URL.Query().Get("rarity"), limit, offset)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAchievementRarity) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	pagination.Write(w, pagination.Offset(achievements, total, limit, offset))
+}
+
+// CheckAchievements handles POST /api/achievements/check, unlocking any
+// achievements whose criteria the user now meets
+func (h *Handler) CheckAchievements(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Get user achievements
-	achievements, err := h.service.CheckAchievements(userID)
+	unlocked, err := h.service.CheckAchievements(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"achievements": achievements,
-		"count":        len(achievements),
+		"unlocked": unlocked,
+		"count":    len(unlocked),
 	})
 }
 
-// GetFollowers handles GET /api/users/:id/followers
+// parseNonNegativeQueryInt reads an optional non-negative integer query parameter
+func parseNonNegativeQueryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return value, nil
+}
+
+// parseFollowListQuery reads the expand flag and, for expanded lists, the page
+// of a follower or following list
+func parseFollowListQuery(r *http.Request) (expand bool, limit, offset int, err error) {
+	if value := r.URL.Query().Get("expand"); value != "" {
+		if expand, err = strconv.ParseBool(value); err != nil {
+			return false, 0, 0, fmt.Errorf("expand must be true or false")
+		}
+	}
+	if !expand {
+		return false, 0, 0, nil
+	}
+
+	if limit, err = parseNonNegativeQueryInt(r, "limit", DefaultFollowPageSize); err != nil {
+		return false, 0, 0, err
+	}
+	if offset, err = parseNonNegativeQueryInt(r, "offset", 0); err != nil {
+		return false, 0, 0, err
+	}
+	if limit == 0 {
+		limit = DefaultFollowPageSize
+	}
+	if limit > MaxFollowPageSize {
+		limit = MaxFollowPageSize
+	}
+	return true, limit, offset, nil
+}
+
+// GetFollowers handles GET /api/users/:id/followers?expand=&limit=&offset=
+// By default it lists every follower ID; with expand=true it lists one page of
+// follower profiles instead.
 func (h *Handler) GetFollowers(w http.ResponseWriter, r *http.Request) {
 	// Extract user ID from URL
 	vars := mux.Vars(r)
 	userID := vars["id"]
 
 	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "User ID is required")
+		return
+	}
+
+	// Profiles and follow lists are visible to their owner and admins only
+	if err := middleware.AuthorizeOwnerOrAdmin(r.Context(), userID); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	expand, limit, offset, err := parseFollowListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if expand {
+		profiles, total, err := h.service.ListFollowers(r.Context(), userID, limit, offset)
+		if err != nil {
+			apierror.WriteError(w, apierror.Internal(err))
+			return
+		}
+		pagination.Write(w, pagination.Offset(profiles, total, limit, offset))
 		return
 	}
 
 	// Get followers
-	followers, err := h.service.GetFollowers(userID)
+	followers, err := h.service.GetFollowers(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"followers": followers,
-		"count":     len(followers),
-	})
+	pagination.Write(w, pagination.All(followers))
 }
 
-// GetFollowing handles GET /api/users/:id/following
+// GetFollowing handles GET /api/users/:id/following?expand=&limit=&offset=
+// Like GetFollowers, expand=true lists one page of profiles instead of every ID.
 func (h *Handler) GetFollowing(w http.ResponseWriter, r *http.Request) {
 	// Extract user ID from URL
 	vars := mux.Vars(r)
 	userID := vars["id"]
 
 	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "User ID is required")
+		return
+	}
+
+	// Profiles and follow lists are visible to their owner and admins only
+	if err := middleware.AuthorizeOwnerOrAdmin(r.Context(), userID); err != nil {
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	expand, limit, offset, err := parseFollowListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if expand {
+		profiles, total, err := h.service.ListFollowing(r.Context(), userID, limit, offset)
+		if err != nil {
+			apierror.WriteError(w, apierror.Internal(err))
+			return
+		}
+		pagination.Write(w, pagination.Offset(profiles, total, limit, offset))
 		return
 	}
 
 	// Get following
-	following, err := h.service.GetFollowing(userID)
+	following, err := h.service.GetFollowing(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"following": following,
-		"count":     len(following),
-	})
+	pagination.Write(w, pagination.All(following))
+}
+
+// GetFriends handles GET /api/users/me/friends
+// Friends are mutual follows; they are the only followers who see friends-only activity.
+func (h *Handler) GetFriends(w http.ResponseWriter, r *http.Request) {
+	// Extract current user from JWT context
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	friends, err := h.service.GetFriends(r.Context(), userID)
+	if err != nil {
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+	pagination.Write(w, pagination.All(friends))
 }
 
 // RefreshRecommendations handles POST /api/recommendations/refresh
+// An optional ?type= regenerates only that recommendation type
 func (h *Handler) RefreshRecommendations(w http.ResponseWriter, r *http.Request) {
 	// Extract current user from JWT context
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Regenerate a single recommendation type
+	if recType := r.URL.Query().Get("type"); recType != "" {
+		if err := h.service.RefreshRecommendationsByType(r.Context(), userID, recType); err != nil {
+			if errors.Is(err, ErrInvalidRecommendationType) {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			apierror.WriteError(w, apierror.Internal(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Recommendations refreshed successfully",
+			"type":    recType,
+		})
 		return
 	}
 
 	// Generate new recommendations
-	if err := h.service.GenerateRecommendations(userID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.service.GenerateRecommendations(r.Context(), userID); err != nil {
+		if errors.Is(err, ErrRecommendationsIncomplete) {
+			// Slow algorithms keep running and save their results when done
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]string{
+				"message": "Recommendations are still being generated",
+			})
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
@@ -275,18 +864,27 @@ func (h *Handler) RefreshRecommendations(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-// RefreshTrending handles POST /api/trending/refresh (admin only)
+// RefreshTrending handles POST /api/trending/refresh?category=
+// Without a category every category is refreshed.
+// Admin access is enforced by the route's middleware.
 func (h *Handler) RefreshTrending(w http.ResponseWriter, r *http.Request) {
-	// Check admin authorization from context
-	isAdmin, ok := r.Context().Value("is_admin").(bool)
-	if !ok || !isAdmin {
-		http.Error(w, "Forbidden: admin access required", http.StatusForbidden)
-		return
+	var err error
+	if category := r.URL.Query().Get("category"); category != "" {
+		err = h.service.RefreshTrendingCategory(r.Context(), category)
+	} else {
+		err = h.service.RefreshTrendingCache(r.Context())
 	}
-
-	// Refresh trending cache
-	if err := h.service.RefreshTrendingCache(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err != nil {
+		var fieldErr *validation.FieldError
+		switch {
+		case errors.As(err, &fieldErr):
+			apierror.WriteError(w, apierror.InvalidField(fieldErr.Field, err.Error()))
+			return
+		case errors.Is(err, ErrTrendingRefreshInProgress):
+			writeError(w, http.StatusConflict, ErrTrendingRefreshInProgress.Error())
+			return
+		}
+		apierror.WriteError(w, apierror.Internal(err))
 		return
 	}
 
@@ -296,26 +894,58 @@ func (h *Handler) RefreshTrending(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// CleanupExpiredRecommendations handles POST /api/admin/recommendations/cleanup
+// Admin access is enforced by the route's middleware.
+func (h *Handler) CleanupExpiredRecommendations(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.service.CleanupExpiredRecommendations(r.Context())
+	if err != nil {
+		apierror.WriteError(w, apierror.Internal(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{
+		"deleted": deleted,
+	})
+}
+
 // RegisterRoutes registers all social routes
 func (h *Handler) RegisterRoutes(r *mux.Router) {
 	// Follow/Unfollow
+	r.HandleFunc("/api/users/follow/batch", h.FollowUsers).Methods("POST")
+	r.HandleFunc("/api/users/follow/batch", h.UnfollowUsers).Methods("DELETE")
 	r.HandleFunc("/api/users/{id}/follow", h.FollowUser).Methods("POST")
 	r.HandleFunc("/api/users/{id}/follow", h.UnfollowUser).Methods("DELETE")
+	r.HandleFunc("/api/users/{id}/follow-status", h.GetFollowStatus).Methods("GET")
 	r.HandleFunc("/api/users/{id}/followers", h.GetFollowers).Methods("GET")
 	r.HandleFunc("/api/users/{id}/following", h.GetFollowing).Methods("GET")
+	r.HandleFunc("/api/users/me/friends", h.GetFriends).Methods("GET")
 
 	// Activity Feed
 	r.HandleFunc("/api/feed", h.GetActivityFeed).Methods("GET")
+	r.HandleFunc("/api/feed/stream", h.StreamActivityFeed).Methods("GET")
 
 	// Recommendations
 	r.HandleFunc("/api/recommendations", h.GetRecommendations).Methods("GET")
 	r.HandleFunc("/api/recommendations/refresh", h.RefreshRecommendations).Methods("POST")
+	r.HandleFunc("/api/recommendations/feedback/batch", h.RecordFeedbackBatch).Methods("POST")
+	r.HandleFunc("/api/recommendations/{courseId}/dismiss", h.DismissRecommendation).Methods("POST")
+	r.HandleFunc("/api/recommendations/{courseId}/interested", h.MarkRecommendationInterested).Methods("POST")
+	r.Handle("/api/admin/recommendations/cleanup", middleware.RequireAdmin()(http.HandlerFunc(h.CleanupExpiredRecommendations))).Methods("POST")
 
 	// Trending
 	r.HandleFunc("/api/trending", h.GetTrendingCourses).Methods("GET")
-	r.HandleFunc("/api/trending/refresh", h.RefreshTrending).Methods("POST")
+	r.Handle("/api/trending/refresh", middleware.RequireAdmin()(http.HandlerFunc(h.RefreshTrending))).Methods("POST")
+
+	// Leaderboard
+	r.HandleFunc("/api/leaderboard", h.GetLeaderboard).Methods("GET")
+
+	// Skill graph
+	r.HandleFunc("/api/skills/graph", h.GetSkillGraph).Methods("GET")
+	r.HandleFunc("/api/skills/{skill}/adjacent", h.GetAdjacentSkills).Methods("GET")
 
 	// Profile
 	r.HandleFunc("/api/users/{id}/profile", h.GetUserProfile).Methods("GET")
 	r.HandleFunc("/api/users/me/achievements", h.GetAchievements).Methods("GET")
+	r.HandleFunc("/api/achievements/check", h.CheckAchievements).Methods("POST")
 }
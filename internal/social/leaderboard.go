@@ -0,0 +1,67 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Leaderboard metrics
+const (
+	LeaderboardExercisesSolved  = "exercises_solved"
+	LeaderboardCoursesCompleted = "courses_completed"
+	LeaderboardPoints           = "points"
+)
+
+// Leaderboard periods
+const (
+	LeaderboardWeekly  = "weekly"
+	LeaderboardAllTime = "all_time"
+)
+
+// Leaderboard size bounds
+const (
+	DefaultLeaderboardSize = 10
+	MaxLeaderboardSize     = 100
+)
+
+// ErrInvalidLeaderboard is returned for an unknown leaderboard metric or period
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard")
+
+// leaderboardPeriods maps each period to how far back it looks; zero means all time
+var leaderboardPeriods = map[string]time.Duration{
+	LeaderboardWeekly:  7 * 24 * time.Hour,
+	LeaderboardAllTime: 0,
+}
+
+// GetLeaderboard ranks users by metric over period. Users who opted out with
+// ShowInLeaderboards are left out, and users with no score are not listed.
+// Empty metric and period default to points over all time.
+func (s *Service) GetLeaderboard(ctx context.Context, metric, period string, limit int) ([]LeaderboardEntry, error) {
+	if metric == "" {
+		metric = LeaderboardPoints
+	}
+	if _, ok := leaderboardScores[metric]; !ok {
+		return nil, fmt.Errorf("%w: metric must be one of: %s, %s, %s",
+			ErrInvalidLeaderboard, LeaderboardExercisesSolved, LeaderboardCoursesCompleted, LeaderboardPoints)
+	}
+
+	if period == "" {
+		period = LeaderboardAllTime
+	}
+	window, ok := leaderboardPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("%w: period must be one of: %s, %s", ErrInvalidLeaderboard, LeaderboardWeekly, LeaderboardAllTime)
+	}
+	var since time.Time
+	if window > 0 {
+		since = time.Now().Add(-window)
+	}
+
+	entries, err := s.repo.GetLeaderboard(ctx, metric, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,129 @@
+package social
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leaderboardRows returns entries as selected by GetLeaderboard
+func leaderboardRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"rank", "user_id", "name", "avatar_url", "score"}).
+		AddRow(1, "user-1", "Ada", "", 300).
+		AddRow(2, "user-2", "Grace", "", 200).
+		AddRow(2, "user-3", "Linus", "", 200)
+}
+
+// recentTime matches a leaderboard window start within a minute of want
+type recentTime struct {
+	want time.Time
+}
+
+func (r recentTime) Match(v driver.Value) bool {
+	since, ok := v.(time.Time)
+	return ok && since.Sub(r.want).Abs() < time.Minute
+}
+
+func TestGetLeaderboard_Periods(t *testing.T) {
+	tests := []struct {
+		period string
+		since  driver.Value
+	}{
+		{LeaderboardWeekly, recentTime{want: time.Now().Add(-7 * 24 * time.Hour)}},
+		{LeaderboardAllTime, nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			service, mock := newMockService(t)
+			mock.ExpectQuery(`LEFT JOIN user_privacy_settings p ON p.user_id = s.user_id`).
+				WithArgs(tt.since, DefaultLeaderboardSize).
+				WillReturnRows(leaderboardRows())
+
+			entries, err := service.GetLeaderboard(context.Background(), LeaderboardPoints, tt.period, DefaultLeaderboardSize)
+			require.NoError(t, err)
+			require.Len(t, entries, 3)
+			assert.Equal(t, []int{1, 2, 2}, []int{entries[0].Rank, entries[1].Rank, entries[2].Rank})
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetLeaderboardHandler_ClampsLimit(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`WITH scores AS`).
+		WithArgs(nil, MaxLeaderboardSize).
+		WillReturnRows(leaderboardRows())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/leaderboard?limit=1000", nil)
+	rec := httptest.NewRecorder()
+	NewHandler(service).GetLeaderboard(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Limit int `json:"limit"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, MaxLeaderboardSize, body.Limit, "the applied page size is reported")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLeaderboardHandler(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	mock.ExpectQuery(`FROM user_progress`).
+		WithArgs(sqlmock.AnyArg(), 5).
+		WillReturnRows(leaderboardRows())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/leaderboard?metric=courses_completed&period=weekly&limit=5", nil)
+	rec := httptest.NewRecorder()
+
+	handler.GetLeaderboard(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Items []LeaderboardEntry `json:"items"`
+		Limit int                `json:"limit"`
+		Meta  struct {
+			Metric string `json:"metric"`
+			Period string `json:"period"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	var raw map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &raw))
+	assert.NotContains(t, raw, "total", "a top list has no total to report")
+	assert.NotContains(t, raw, "next_cursor")
+	assert.Equal(t, LeaderboardCoursesCompleted, body.Meta.Metric)
+	assert.Equal(t, LeaderboardWeekly, body.Meta.Period)
+	assert.Equal(t, 5, body.Limit)
+	require.Len(t, body.Items, 3)
+	assert.Equal(t, "user-1", body.Items[0].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLeaderboardHandler_InvalidParams(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	for _, query := range []string{"metric=streak", "period=monthly", "limit=0", "limit=abc"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/leaderboard?"+query, nil)
+		rec := httptest.NewRecorder()
+
+		handler.GetLeaderboard(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+	// Invalid requests never reach the database
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
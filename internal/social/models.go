@@ -12,6 +12,22 @@ type UserRelationship struct {
 	CreatedAt   time.Time
 }
 
+// FollowStatus describes the follow relationship between the viewer and another user
+type FollowStatus struct {
+	Following  bool `json:"following"`   // The viewer follows the user
+	FollowsYou bool `json:"follows_you"` // The user follows the viewer
+}
+
+// FollowProfile is a follower or followed user with the profile fields a follow
+// list shows, so clients need not fetch each profile separately
+type FollowProfile struct {
+	UserID     string    `json:"id"`
+	Name       string    `json:"name"`
+	AvatarURL  string    `json:"avatar_url"`
+	Mutual     bool      `json:"mutual"` // The two users follow each other
+	FollowedAt time.Time `json:"followed_at"`
+}
+
 // ActivityFeed represents activity ticker item
 type ActivityFeed struct {
 	ID            string
@@ -56,14 +72,33 @@ type Recommendation struct {
 	ExpiresAt          *time.Time
 }
 
+// RecommendationFeedback is a user's signal about a recommended course
+type RecommendationFeedback struct {
+	ID           string
+	UserID       string
+	CourseID     string
+	FeedbackType string // "dismissed" or "interested"
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
 // TrendingCourse represents trending course data
 type TrendingCourse struct {
 	ID                   string
 	CourseID             string
 	Velocity             float64
-	Signups24h           int
-	SignupsPrevious24h   int
+	Signups24h           int // Signups in the trending policy's recent window (24 hours by default)
+	SignupsPrevious24h   int // Signups in the window before it
 	Rank                 int
 	MetaCategory         string
 	CalculatedAt         time.Time
 }
+
+// LeaderboardEntry is one ranked user on the leaderboard
+type LeaderboardEntry struct {
+	Rank      int     `json:"rank"` // Tied scores share a rank
+	UserID    string  `json:"user_id"`
+	Name      string  `json:"name"`
+	AvatarURL string  `json:"avatar_url"`
+	Score     float64 `json:"score"`
+}
@@ -0,0 +1,119 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Visibility levels used by profile privacy settings
+const (
+	VisibilityPublic  = "public"
+	VisibilityFriends = "friends"
+	VisibilityPrivate = "private"
+)
+
+// ViewerRelationship describes how a viewer relates to the profile they are viewing
+type ViewerRelationship string
+
+const (
+	RelationshipSelf   ViewerRelationship = "self"
+	RelationshipFriend ViewerRelationship = "friend" // Viewer and target follow each other
+	RelationshipPublic ViewerRelationship = "public"
+)
+
+// ProfilePrivacy is the subset of a user's privacy settings that governs profile views
+type ProfilePrivacy struct {
+	ProfileVisibility    string
+	ProgressVisibility   string
+	ShowCompletedCourses bool
+}
+
+// DefaultProfilePrivacy matches the defaults identity assigns to new users
+func DefaultProfilePrivacy() ProfilePrivacy {
+	return ProfilePrivacy{
+		ProfileVisibility:    VisibilityFriends,
+		ProgressVisibility:   VisibilityFriends,
+		ShowCompletedCourses: true,
+	}
+}
+
+// ErrUserNotFound is returned by a PrivacyLookup when the user does not exist
+var ErrUserNotFound = errors.New("user not found")
+
+// PrivacyLookup loads a user's profile privacy settings from the identity domain.
+// It returns ErrUserNotFound for an unknown user.
+type PrivacyLookup func(userID string) (ProfilePrivacy, error)
+
+// WithPrivacyLookup sets where profile privacy settings are read from.
+// Without one, every profile uses DefaultProfilePrivacy.
+func (s *Service) WithPrivacyLookup(lookup PrivacyLookup) *Service {
+	s.privacyLookup = lookup
+	return s
+}
+
+// ResolveViewerRelationship determines whether viewerID is the owner of userID's
+// profile, a friend (mutual follow), or anyone else. An empty viewer is public.
+func (s *Service) ResolveViewerRelationship(ctx context.Context, viewerID, userID string) (ViewerRelationship, error) {
+	if viewerID == "" {
+		return RelationshipPublic, nil
+	}
+	if viewerID == userID {
+		return RelationshipSelf, nil
+	}
+
+	following, err := s.repo.GetFollowing(ctx, viewerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve relationship: %w", err)
+	}
+	if !containsString(following, userID) {
+		return RelationshipPublic, nil
+	}
+
+	followers, err := s.repo.GetFollowers(ctx, viewerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve relationship: %w", err)
+	}
+	if !containsString(followers, userID) {
+		return RelationshipPublic, nil
+	}
+
+	return RelationshipFriend, nil
+}
+
+// profilePrivacy returns userID's privacy settings, or the defaults when no lookup is configured
+func (s *Service) profilePrivacy(userID string) (ProfilePrivacy, error) {
+	if s.privacyLookup == nil {
+		return DefaultProfilePrivacy(), nil
+	}
+	privacy, err := s.privacyLookup(userID)
+	if err != nil {
+		return ProfilePrivacy{}, fmt.Errorf("failed to get privacy settings: %w", err)
+	}
+	return privacy, nil
+}
+
+// visibleTo reports whether a section with the given visibility can be shown to
+// a viewer with the given relationship. Unknown visibility values are treated as private.
+func visibleTo(visibility string, relationship ViewerRelationship) bool {
+	switch {
+	case relationship == RelationshipSelf:
+		return true
+	case visibility == VisibilityPublic:
+		return true
+	case visibility == VisibilityFriends:
+		return relationship == RelationshipFriend
+	default:
+		return false
+	}
+}
+
+// containsString reports whether values contains target
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
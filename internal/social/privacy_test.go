@@ -0,0 +1,284 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLearningService returns a fixed course list and point total for profile tests
+type stubLearningService struct {
+	courses []interface{}
+	points  int
+}
+
+func (s stubLearningService) GetUserCoursesInterface(ctx context.Context, userID string) ([]interface{}, error) {
+	return s.courses, nil
+}
+
+func (s stubLearningService) GetUserPoints(ctx context.Context, userID string) (int, error) {
+	return s.points, nil
+}
+
+// expectRelationship mocks ResolveViewerRelationship for a viewer who follows
+// and/or is followed by target
+func expectRelationship(mock sqlmock.Sqlmock, viewerID, targetID string, follows, followedBack bool) {
+	following := sqlmock.NewRows([]string{"following_id"})
+	if follows {
+		following.AddRow(targetID)
+	}
+	mock.ExpectQuery(`SELECT following_id`).WithArgs(viewerID).WillReturnRows(following)
+	if !follows {
+		return
+	}
+
+	followers := sqlmock.NewRows([]string{"follower_id"})
+	if followedBack {
+		followers.AddRow(targetID)
+	}
+	mock.ExpectQuery(`SELECT follower_id`).WithArgs(viewerID).WillReturnRows(followers)
+}
+
+// expectProfileCounts mocks the follower and following lookups of a visible profile
+func expectProfileCounts(mock sqlmock.Sqlmock, userID string) {
+	mock.ExpectQuery(`SELECT follower_id`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"follower_id"}).AddRow("f-1").AddRow("f-2"))
+	mock.ExpectQuery(`SELECT following_id`).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"following_id"}).AddRow("f-1"))
+}
+
+// expectProgress mocks the earned achievements lookup of a visible profile
+func expectProgress(mock sqlmock.Sqlmock, userID string) {
+	mock.ExpectQuery(`FROM achievements a`).WithArgs(userID).
+		WillReturnRows(achievementRows())
+}
+
+func TestResolveViewerRelationship(t *testing.T) {
+	tests := []struct {
+		name         string
+		viewerID     string
+		follows      bool
+		followedBack bool
+		want         ViewerRelationship
+	}{
+		{"anonymous", "", false, false, RelationshipPublic},
+		{"self", "user-1", false, false, RelationshipSelf},
+		{"stranger", "user-2", false, false, RelationshipPublic},
+		{"one-way follower", "user-2", true, false, RelationshipPublic},
+		{"mutual follow", "user-2", true, true, RelationshipFriend},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			if tt.viewerID != "" && tt.viewerID != "user-1" {
+				expectRelationship(mock, tt.viewerID, "user-1", tt.follows, tt.followedBack)
+			}
+
+			got, err := service.ResolveViewerRelationship(context.Background(), tt.viewerID, "user-1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUserProfileData_RespectsPrivacySettings(t *testing.T) {
+	courses := []interface{}{"course-1", "course-2"}
+
+	tests := []struct {
+		name          string
+		viewerID      string
+		friend        bool
+		privacy       ProfilePrivacy
+		wantCounts    bool
+		wantProgress  bool
+		wantCourses   bool
+		wantRedaction bool
+	}{
+		{
+			name:         "owner sees everything regardless of settings",
+			viewerID:     "user-1",
+			privacy:      ProfilePrivacy{ProfileVisibility: VisibilityPrivate, ProgressVisibility: VisibilityPrivate},
+			wantCounts:   true,
+			wantProgress: true,
+			wantCourses:  true,
+		},
+		{
+			name:          "stranger cannot see a friends-only profile",
+			viewerID:      "user-2",
+			privacy:       DefaultProfilePrivacy(),
+			wantRedaction: true,
+		},
+		{
+			name:         "friend sees a friends-only profile",
+			viewerID:     "user-2",
+			friend:       true,
+			privacy:      DefaultProfilePrivacy(),
+			wantCounts:   true,
+			wantProgress: true,
+			wantCourses:  true,
+		},
+		{
+			name:          "friend cannot see a private profile",
+			viewerID:      "user-2",
+			friend:        true,
+			privacy:       ProfilePrivacy{ProfileVisibility: VisibilityPrivate, ProgressVisibility: VisibilityPublic, ShowCompletedCourses: true},
+			wantRedaction: true,
+		},
+		{
+			name:          "friend cannot see private progress",
+			viewerID:      "user-2",
+			friend:        true,
+			privacy:       ProfilePrivacy{ProfileVisibility: VisibilityFriends, ProgressVisibility: VisibilityPrivate, ShowCompletedCourses: true},
+			wantCounts:    true,
+			wantRedaction: true,
+		},
+		{
+			name:          "stranger sees a public profile but not friends-only progress",
+			viewerID:      "user-2",
+			privacy:       ProfilePrivacy{ProfileVisibility: VisibilityPublic, ProgressVisibility: VisibilityFriends, ShowCompletedCourses: true},
+			wantCounts:    true,
+			wantRedaction: true,
+		},
+		{
+			name:         "stranger sees public progress",
+			viewerID:     "user-2",
+			privacy:      ProfilePrivacy{ProfileVisibility: VisibilityPublic, ProgressVisibility: VisibilityPublic, ShowCompletedCourses: true},
+			wantCounts:   true,
+			wantProgress: true,
+			wantCourses:  true,
+		},
+		{
+			name:          "hidden completed courses are redacted from public progress",
+			viewerID:      "user-2",
+			privacy:       ProfilePrivacy{ProfileVisibility: VisibilityPublic, ProgressVisibility: VisibilityPublic},
+			wantCounts:    true,
+			wantProgress:  true,
+			wantRedaction: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			service.WithLearningService(stubLearningService{courses: courses, points: 42}).
+				WithPrivacyLookup(func(userID string) (ProfilePrivacy, error) {
+					assert.Equal(t, "user-1", userID)
+					return tt.privacy, nil
+				})
+
+			if tt.viewerID != "user-1" {
+				expectRelationship(mock, tt.viewerID, "user-1", tt.friend, tt.friend)
+			}
+			if tt.wantCounts {
+				expectProfileCounts(mock, "user-1")
+			}
+			if tt.wantProgress {
+				expectProgress(mock, "user-1")
+			}
+
+			profile, err := service.GetUserProfileData(context.Background(), tt.viewerID, "user-1")
+			require.NoError(t, err)
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, "user-1", profile.UserID)
+			assert.Equal(t, tt.wantRedaction, profile.Redacted)
+			if tt.wantCounts {
+				assert.Equal(t, 2, profile.FollowersCount)
+				assert.Equal(t, 1, profile.FollowingCount)
+			} else {
+				assert.Zero(t, profile.FollowersCount)
+				assert.Zero(t, profile.FollowingCount)
+			}
+			if tt.wantProgress {
+				assert.Equal(t, "intermediate", profile.SkillLevel)
+				assert.Equal(t, 42, profile.TotalPoints)
+			} else {
+				assert.Empty(t, profile.SkillLevel)
+				assert.Zero(t, profile.TotalPoints)
+			}
+			if tt.wantCourses {
+				assert.Equal(t, courses, profile.CompletedCourses)
+			} else {
+				assert.Empty(t, profile.CompletedCourses)
+			}
+		})
+	}
+}
+
+func TestGetUserProfileHandler_AppliesViewerPrivacy(t *testing.T) {
+	tests := []struct {
+		name         string
+		claims       *middleware.UserClaims
+		wantStatus   int
+		wantRedacted bool
+	}{
+		{"anonymous", nil, http.StatusUnauthorized, false},
+		{"stranger", &middleware.UserClaims{UserID: "user-2"}, http.StatusOK, true},
+		{"admin", &middleware.UserClaims{UserID: "admin-1", IsAdmin: true}, http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			handler := NewHandler(service)
+
+			switch {
+			case tt.claims == nil:
+			case tt.claims.IsAdmin:
+				// Admins view the profile as its owner
+				expectProfileCounts(mock, "user-1")
+				expectProgress(mock, "user-1")
+			default:
+				expectRelationship(mock, tt.claims.UserID, "user-1", false, false)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/users/user-1/profile", nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "user-1"})
+			if tt.claims != nil {
+				req = req.WithContext(middleware.ContextWithUser(req.Context(), tt.claims))
+			}
+			rec := httptest.NewRecorder()
+
+			handler.GetUserProfile(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				var profile UserProfileData
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &profile))
+				assert.Equal(t, tt.wantRedacted, profile.Redacted)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGetUserProfileHandler_UnknownUserIsNotFound(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithPrivacyLookup(func(userID string) (ProfilePrivacy, error) {
+		return ProfilePrivacy{}, ErrUserNotFound
+	})
+	handler := NewHandler(service)
+
+	expectRelationship(mock, "user-2", "missing", false, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/users/missing/profile", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-2"}))
+	rec := httptest.NewRecorder()
+
+	handler.GetUserProfile(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,9 +1,12 @@
 package social
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -19,27 +22,33 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// FollowUser creates follow relationship
-func (r *Repository) FollowUser(followerID, followingID string) error {
+// FollowUser creates follow relationship and reports whether it is new;
+// following a user already followed is a no-op that returns false
+func (r *Repository) FollowUser(ctx context.Context, followerID, followingID string) (bool, error) {
 	query := `
 		INSERT INTO user_relationships (follower_id, following_id, created_at)
 		VALUES ($1, $2, NOW())
 		ON CONFLICT (follower_id, following_id) DO NOTHING
 	`
-	_, err := r.db.Exec(query, followerID, followingID)
+	result, err := r.db.ExecContext(ctx, query, followerID, followingID)
 	if err != nil {
-		return fmt.Errorf("failed to create follow relationship: %w", err)
+		return false, fmt.Errorf("failed to create follow relationship: %w", err)
 	}
-	return nil
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
 }
 
 // UnfollowUser removes follow relationship
-func (r *Repository) UnfollowUser(followerID, followingID string) error {
+func (r *Repository) UnfollowUser(ctx context.Context, followerID, followingID string) error {
 	query := `
 		DELETE FROM user_relationships
 		WHERE follower_id = $1 AND following_id = $2
 	`
-	result, err := r.db.Exec(query, followerID, followingID)
+	result, err := r.db.ExecContext(ctx, query, followerID, followingID)
 	if err != nil {
 		return fmt.Errorf("failed to remove follow relationship: %w", err)
 	}
@@ -56,8 +65,88 @@ func (r *Repository) UnfollowUser(followerID, followingID string) error {
 	return nil
 }
 
+// IsFollowing reports whether followerID follows followingID
+func (r *Repository) IsFollowing(ctx context.Context, followerID, followingID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM user_relationships
+			WHERE follower_id = $1 AND following_id = $2
+		)
+	`
+
+	var following bool
+	if err := r.db.QueryRowContext(ctx, query, followerID, followingID).Scan(&following); err != nil {
+		return false, fmt.Errorf("failed to check follow relationship: %w", err)
+	}
+	return following, nil
+}
+
+// FollowUsers creates follow relationships to every existing user in
+// followingIDs with a single multi-row insert and returns the newly followed IDs
+func (r *Repository) FollowUsers(ctx context.Context, followerID string, followingIDs []string) ([]string, error) {
+	if len(followingIDs) == 0 {
+		return []string{}, nil
+	}
+
+	query := `
+		INSERT INTO user_relationships (follower_id, following_id, created_at)
+		SELECT $1, u.id, NOW()
+		FROM users u
+		WHERE u.id = ANY($2::uuid[]) AND u.id <> $1
+		ON CONFLICT (follower_id, following_id) DO NOTHING
+		RETURNING following_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, followerID, pq.Array(followingIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create follow relationships: %w", err)
+	}
+	defer rows.Close()
+
+	return scanUserIDs(rows)
+}
+
+// UnfollowUsers removes followerID's relationships to followingIDs and returns the unfollowed IDs
+func (r *Repository) UnfollowUsers(ctx context.Context, followerID string, followingIDs []string) ([]string, error) {
+	if len(followingIDs) == 0 {
+		return []string{}, nil
+	}
+
+	query := `
+		DELETE FROM user_relationships
+		WHERE follower_id = $1 AND following_id = ANY($2::uuid[])
+		RETURNING following_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, followerID, pq.Array(followingIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove follow relationships: %w", err)
+	}
+	defer rows.Close()
+
+	return scanUserIDs(rows)
+}
+
+// scanUserIDs reads single-column user ID rows
+func scanUserIDs(rows *sql.Rows) ([]string, error) {
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user IDs: %w", err)
+	}
+
+	return ids, nil
+}
+
 // GetFollowers retrieves user's followers
-func (r *Repository) GetFollowers(userID string) ([]string, error) {
+func (r *Repository) GetFollowers(ctx context.Context, userID string) ([]string, error) {
 	query := `
 		SELECT follower_id
 		FROM user_relationships
@@ -65,7 +154,7 @@ func (r *Repository) GetFollowers(userID string) ([]string, error) {
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query followers: %w", err)
 	}
@@ -88,7 +177,7 @@ func (r *Repository) GetFollowers(userID string) ([]string, error) {
 }
 
 // GetFollowing retrieves users that user follows
-func (r *Repository) GetFollowing(userID string) ([]string, error) {
+func (r *Repository) GetFollowing(ctx context.Context, userID string) ([]string, error) {
 	query := `
 		SELECT following_id
 		FROM user_relationships
@@ -96,7 +185,7 @@ func (r *Repository) GetFollowing(userID string) ([]string, error) {
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query following: %w", err)
 	}
@@ -118,8 +207,132 @@ func (r *Repository) GetFollowing(userID string) ([]string, error) {
 	return following, nil
 }
 
+// followProfileColumns selects a FollowProfile for relationship r, joined to the
+// other user as u, in the order scanFollowProfiles reads it
+const followProfileColumns = `
+		SELECT
+			u.id,
+			u.name,
+			COALESCE(u.avatar_url, ''),
+			EXISTS (
+				SELECT 1 FROM user_relationships back
+				WHERE back.follower_id = r.following_id AND back.following_id = r.follower_id
+			),
+			r.created_at
+		FROM user_relationships r`
+
+// GetFollowerProfiles retrieves one page of the user's followers with their
+// profiles, most recent first
+func (r *Repository) GetFollowerProfiles(ctx context.Context, userID string, limit, offset int) ([]FollowProfile, error) {
+	query := followProfileColumns + `
+		JOIN users u ON u.id = r.follower_id
+		WHERE r.following_id = $1
+		ORDER BY r.created_at DESC, u.id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query follower profiles: %w", err)
+	}
+	defer rows.Close()
+
+	return scanFollowProfiles(rows)
+}
+
+// GetFollowingProfiles retrieves one page of the users the user follows with
+// their profiles, most recently followed first
+func (r *Repository) GetFollowingProfiles(ctx context.Context, userID string, limit, offset int) ([]FollowProfile, error) {
+	query := followProfileColumns + `
+		JOIN users u ON u.id = r.following_id
+		WHERE r.follower_id = $1
+		ORDER BY r.created_at DESC, u.id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query following profiles: %w", err)
+	}
+	defer rows.Close()
+
+	return scanFollowProfiles(rows)
+}
+
+// CountFollowers returns how many users follow the user
+func (r *Repository) CountFollowers(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_relationships WHERE following_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count followers: %w", err)
+	}
+	return count, nil
+}
+
+// CountFollowing returns how many users the user follows
+func (r *Repository) CountFollowing(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_relationships WHERE follower_id = $1`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count following: %w", err)
+	}
+	return count, nil
+}
+
+// scanFollowProfiles reads rows selected with followProfileColumns
+func scanFollowProfiles(rows *sql.Rows) ([]FollowProfile, error) {
+	profiles := []FollowProfile{}
+	for rows.Next() {
+		var profile FollowProfile
+		if err := rows.Scan(&profile.UserID, &profile.Name, &profile.AvatarURL, &profile.Mutual, &profile.FollowedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follow profile: %w", err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating follow profiles: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// GetMutualFollows retrieves the user's friends: users who follow the user and
+// are followed back, most recently followed first
+func (r *Repository) GetMutualFollows(ctx context.Context, userID string) ([]string, error) {
+	query := `
+		SELECT r.following_id
+		FROM user_relationships r
+		JOIN user_relationships back
+			ON back.follower_id = r.following_id AND back.following_id = r.follower_id
+		WHERE r.follower_id = $1 AND r.following_id <> $1
+		ORDER BY r.created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mutual follows: %w", err)
+	}
+	defer rows.Close()
+
+	var friends []string
+	for rows.Next() {
+		var friendID string
+		if err := rows.Scan(&friendID); err != nil {
+			return nil, fmt.Errorf("failed to scan mutual follow: %w", err)
+		}
+		friends = append(friends, friendID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating mutual follows: %w", err)
+	}
+
+	return friends, nil
+}
+
 // CreateActivity creates activity feed item
-func (r *Repository) CreateActivity(activity *ActivityFeed) error {
+func (r *Repository) CreateActivity(ctx context.Context, activity *ActivityFeed) error {
 	metadataJSON, err := json.Marshal(activity.Metadata)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
@@ -135,7 +348,7 @@ func (r *Repository) CreateActivity(activity *ActivityFeed) error {
 		RETURNING id, created_at
 	`
 
-	err = r.db.QueryRow(
+	err = r.db.QueryRowContext(ctx,
 		query,
 		activity.UserID,
 		activity.ActivityType,
@@ -153,27 +366,53 @@ func (r *Repository) CreateActivity(activity *ActivityFeed) error {
 	return nil
 }
 
-// GetActivityFeed retrieves activity feed for user
-func (r *Repository) GetActivityFeed(userID string, limit int) ([]ActivityFeed, error) {
+// GetActivityFeed retrieves activity feed for user, newest first, limited to rng when its bounds are set.
+// Public activity comes from everyone the user follows, friends activity only
+// from mutual follows. With includeOwn the user's own public and friends
+// activity is merged in. Private activity is never shown, except follow events
+// addressed to the user.
+func (r *Repository) GetActivityFeed(ctx context.Context, userID string, limit int, rng FeedRange, includeOwn bool) ([]ActivityFeed, error) {
+	// Followed authors and follow notices are separate branches so each can use
+	// its own index. Both apply the same window and cursor and are limited on
+	// their own before being merged.
+	window := `
+				AND ($3::timestamp IS NULL OR af.created_at >= $3)
+				AND ($4::timestamp IS NULL OR af.created_at <= $4)
+				AND ($7::timestamp IS NULL OR (af.created_at, af.id) < ($7, $8::uuid))
+			ORDER BY af.created_at DESC, af.id DESC
+			LIMIT $2`
 	query := `
-		SELECT
-			af.id,
-			af.user_id,
-			af.activity_type,
-			af.reference_type,
-			af.reference_id,
-			af.metadata,
-			af.visibility,
-			af.created_at
-		FROM activity_feed af
-		INNER JOIN user_relationships ur ON af.user_id = ur.following_id
-		WHERE ur.follower_id = $1
-			AND (af.visibility = 'public' OR af.visibility = 'friends')
-		ORDER BY af.created_at DESC
+		SELECT id, user_id, activity_type, reference_type, reference_id, metadata, visibility, created_at
+		FROM ((
+			SELECT af.*
+			FROM activity_feed af
+			JOIN (
+				SELECT r.following_id AS author_id,
+					EXISTS (
+						SELECT 1 FROM user_relationships back
+						WHERE back.follower_id = r.following_id AND back.following_id = $1
+					) AS is_friend
+				FROM user_relationships r
+				WHERE r.follower_id = $1 AND r.following_id <> $1
+				UNION
+				SELECT $1::uuid, TRUE WHERE $5
+			) authors ON af.user_id = authors.author_id
+			WHERE (af.visibility = 'public' OR (af.visibility = 'friends' AND authors.is_friend))` + window + `
+		) UNION ALL (
+			SELECT af.*
+			FROM activity_feed af
+			WHERE af.reference_id = $1 AND af.activity_type = $6
+				AND af.visibility = 'private' AND af.reference_type = 'user' AND af.user_id <> $1` + window + `
+		)) feed
+		ORDER BY created_at DESC, id DESC
 		LIMIT $2
 	`
 
-	rows, err := r.db.Query(query, userID, limit)
+	from := sql.NullTime{Time: rng.From, Valid: !rng.From.IsZero()}
+	to := sql.NullTime{Time: rng.To, Valid: !rng.To.IsZero()}
+	beforeTime := sql.NullTime{Time: rng.Before.CreatedAt, Valid: !rng.Before.IsZero()}
+	beforeID := sql.NullString{String: rng.Before.ID, Valid: !rng.Before.IsZero()}
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, from, to, includeOwn, ActivityUserFollowed, beforeTime, beforeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query activity feed: %w", err)
 	}
@@ -214,33 +453,100 @@ func (r *Repository) GetActivityFeed(userID string, limit int) ([]ActivityFeed,
 	return activities, nil
 }
 
+// ErrCourseNotFound is returned when feedback references a course that does not exist
+var ErrCourseNotFound = errors.New("course not found")
+
+// UpsertRecommendationFeedback records the user's latest signal for a course
+// A course_id that does not reference an existing course returns ErrCourseNotFound
+func (r *Repository) UpsertRecommendationFeedback(ctx context.Context, feedback *RecommendationFeedback) error {
+	query := `
+		INSERT INTO recommendation_feedback (user_id, course_id, feedback_type, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (user_id, course_id)
+		DO UPDATE SET
+			feedback_type = EXCLUDED.feedback_type,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, feedback.UserID, feedback.CourseID, feedback.FeedbackType).
+		Scan(&feedback.ID, &feedback.CreatedAt, &feedback.UpdatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" && strings.Contains(pqErr.Constraint, "course_id") {
+		return ErrCourseNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record recommendation feedback: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertRecommendationFeedbackBatch records feedbackType for every listed course
+// in one statement and returns the courses it was recorded for; unknown and
+// deleted courses are skipped
+func (r *Repository) UpsertRecommendationFeedbackBatch(ctx context.Context, userID string, courseIDs []string, feedbackType string) ([]string, error) {
+	query := `
+		INSERT INTO recommendation_feedback (user_id, course_id, feedback_type, created_at, updated_at)
+		SELECT $1, gc.id, $3, NOW(), NOW()
+		FROM generated_courses gc
+		WHERE gc.id::text = ANY($2) AND gc.deleted_at IS NULL
+		ON CONFLICT (user_id, course_id)
+		DO UPDATE SET
+			feedback_type = EXCLUDED.feedback_type,
+			updated_at = NOW()
+		RETURNING course_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(courseIDs), feedbackType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record recommendation feedback: %w", err)
+	}
+	defer rows.Close()
+
+	recorded := make([]string, 0, len(courseIDs))
+	for rows.Next() {
+		var courseID string
+		if err := rows.Scan(&courseID); err != nil {
+			return nil, fmt.Errorf("failed to scan recommendation feedback: %w", err)
+		}
+		recorded = append(recorded, courseID)
+	}
+	return recorded, rows.Err()
+}
+
 // GetRecommendations retrieves course recommendations
-func (r *Repository) GetRecommendations(userID string, recType string) ([]Recommendation, error) {
+// Dismissed and soft-deleted courses are excluded and courses marked interested get a score boost
+func (r *Repository) GetRecommendations(ctx context.Context, userID string, recType string) ([]Recommendation, error) {
 	query := `
 		SELECT
-			id,
-			user_id,
-			course_id,
-			recommendation_type,
-			match_score,
-			reason,
-			metadata,
-			created_at,
-			expires_at
-		FROM recommendations
-		WHERE user_id = $1
-			AND (expires_at IS NULL OR expires_at > NOW())
+			r.id,
+			r.user_id,
+			r.course_id,
+			r.recommendation_type,
+			LEAST(r.match_score + CASE WHEN f.feedback_type = 'interested' THEN $2 ELSE 0 END, 100) AS match_score,
+			r.reason,
+			r.metadata,
+			r.created_at,
+			r.expires_at
+		FROM recommendations r
+		JOIN generated_courses gc ON gc.id = r.course_id AND gc.deleted_at IS NULL
+		LEFT JOIN recommendation_feedback f
+			ON f.user_id = r.user_id AND f.course_id = r.course_id
+		WHERE r.user_id = $1
+			AND (r.expires_at IS NULL OR r.expires_at > NOW())
+			AND (f.feedback_type IS NULL OR f.feedback_type <> 'dismissed')
 	`
 
-	args := []interface{}{userID}
+	args := []interface{}{userID, InterestedScoreBoost}
 	if recType != "" && recType != "all" {
-		query += " AND recommendation_type = $2"
+		query += " AND r.recommendation_type = $3"
 		args = append(args, recType)
 	}
 
 	query += " ORDER BY match_score DESC"
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recommendations: %w", err)
 	}
@@ -282,18 +588,68 @@ func (r *Repository) GetRecommendations(userID string, recType string) ([]Recomm
 	return recommendations, nil
 }
 
-// CreateRecommendation creates recommendation (or updates if exists)
-func (r *Repository) CreateRecommendation(rec *Recommendation) error {
-	metadataJSON, err := json.Marshal(rec.Metadata)
-	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
+// recommendationInsertColumns is the number of bind parameters per recommendation row
+const recommendationInsertColumns = 8
+
+// MaxRecommendationBatchSize keeps a single batch under Postgres' 65535 bind parameter limit
+const MaxRecommendationBatchSize = 65535 / recommendationInsertColumns
+
+// CreateRecommendations upserts recs with a single multi-row INSERT. When the same
+// user, course and type appear more than once, the last one wins. IDs are written
+// back to recs. The statement is atomic: one invalid row (e.g. an unknown course)
+// fails the whole batch and none of its rows are written.
+func (r *Repository) CreateRecommendations(ctx context.Context, recs []*Recommendation) error {
+	type recKey struct{ userID, courseID, recType string }
+
+	// ON CONFLICT cannot touch the same row twice in one statement
+	index := make(map[recKey]int, len(recs))
+	unique := make([]*Recommendation, 0, len(recs))
+	for _, rec := range recs {
+		key := recKey{rec.UserID, rec.CourseID, rec.RecommendationType}
+		if i, ok := index[key]; ok {
+			unique[i] = rec
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, rec)
+	}
+
+	if len(unique) == 0 {
+		return nil
+	}
+	if len(unique) > MaxRecommendationBatchSize {
+		return fmt.Errorf("recommendation batch of %d exceeds maximum of %d", len(unique), MaxRecommendationBatchSize)
+	}
+
+	now := time.Now()
+	values := make([]string, 0, len(unique))
+	args := make([]interface{}, 0, len(unique)*recommendationInsertColumns)
+	for i, rec := range unique {
+		metadataJSON, err := json.Marshal(rec.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+
+		base := i * recommendationInsertColumns
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8))
+		args = append(args,
+			rec.UserID,
+			rec.CourseID,
+			rec.RecommendationType,
+			rec.MatchScore,
+			rec.Reason,
+			metadataJSON,
+			now,
+			rec.ExpiresAt,
+		)
 	}
 
 	query := `
 		INSERT INTO recommendations (
 			user_id, course_id, recommendation_type, match_score,
 			reason, metadata, created_at, expires_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		) VALUES ` + strings.Join(values, ", ") + `
 		ON CONFLICT (user_id, course_id, recommendation_type)
 		DO UPDATE SET
 			match_score = EXCLUDED.match_score,
@@ -301,30 +657,80 @@ func (r *Repository) CreateRecommendation(rec *Recommendation) error {
 			metadata = EXCLUDED.metadata,
 			created_at = EXCLUDED.created_at,
 			expires_at = EXCLUDED.expires_at
-		RETURNING id
+		RETURNING id, user_id, course_id, recommendation_type
 	`
 
-	err = r.db.QueryRow(
-		query,
-		rec.UserID,
-		rec.CourseID,
-		rec.RecommendationType,
-		rec.MatchScore,
-		rec.Reason,
-		metadataJSON,
-		time.Now(),
-		rec.ExpiresAt,
-	).Scan(&rec.ID)
-
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to create recommendation: %w", err)
+		return fmt.Errorf("failed to create recommendations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var key recKey
+		if err := rows.Scan(&id, &key.userID, &key.courseID, &key.recType); err != nil {
+			return fmt.Errorf("failed to scan recommendation id: %w", err)
+		}
+		if i, ok := index[key]; ok {
+			unique[i].ID = id
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating created recommendations: %w", err)
+	}
+
+	// Duplicates that lost to a later entry share the stored row's ID
+	for _, rec := range recs {
+		if i := index[recKey{rec.UserID, rec.CourseID, rec.RecommendationType}]; rec != unique[i] {
+			rec.ID = unique[i].ID
+		}
 	}
 
 	return nil
 }
 
-// GetTrendingCourses retrieves trending courses
-func (r *Repository) GetTrendingCourses(limit int) ([]TrendingCourse, error) {
+// DeleteStaleRecommendationsByType removes a user's recommendations of a single type
+// that were written before the given time. Rows upserted by a refresh that started at
+// or after it are kept.
+func (r *Repository) DeleteStaleRecommendationsByType(ctx context.Context, userID, recType string, before time.Time) error {
+	query := `
+		DELETE FROM recommendations
+		WHERE user_id = $1 AND recommendation_type = $2 AND created_at < $3
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, recType, before); err != nil {
+		return fmt.Errorf("failed to delete recommendations: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredRecommendations removes recommendations past their expiry and
+// returns how many were deleted. Recommendations without an expiry are kept.
+func (r *Repository) DeleteExpiredRecommendations(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM recommendations
+		WHERE expires_at IS NOT NULL AND expires_at <= NOW()
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired recommendations: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted recommendations: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// GetTrendingCourses retrieves trending courses by rank; an empty category
+// matches every category
+func (r *Repository) GetTrendingCourses(ctx context.Context, limit int, category string) ([]TrendingCourse, error) {
 	query := `
 		SELECT
 			id,
@@ -336,16 +742,47 @@ func (r *Repository) GetTrendingCourses(limit int) ([]TrendingCourse, error) {
 			meta_category,
 			calculated_at
 		FROM trending_courses
+		WHERE ($2 = '' OR meta_category = $2)
 		ORDER BY rank ASC
 		LIMIT $1
 	`
 
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.QueryContext(ctx, query, limit, category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trending courses: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTrendingCourses(rows)
+}
+
+// GetTrendingCoursesPerCategory retrieves the top perCategory trending courses
+// of every category, ordered by category and rank
+func (r *Repository) GetTrendingCoursesPerCategory(ctx context.Context, perCategory int) ([]TrendingCourse, error) {
+	query := `
+		SELECT id, course_id, velocity, signups_24h, signups_previous_24h, rank, meta_category, calculated_at
+		FROM (
+			SELECT
+				*,
+				ROW_NUMBER() OVER (PARTITION BY meta_category ORDER BY rank ASC) AS category_rank
+			FROM trending_courses
+			WHERE meta_category IS NOT NULL
+		) ranked
+		WHERE category_rank <= $1
+		ORDER BY meta_category, rank ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, perCategory)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query trending courses: %w", err)
 	}
 	defer rows.Close()
 
+	return scanTrendingCourses(rows)
+}
+
+// scanTrendingCourses reads trending_courses rows in the column order of GetTrendingCourses
+func scanTrendingCourses(rows *sql.Rows) ([]TrendingCourse, error) {
 	var courses []TrendingCourse
 	for rows.Next() {
 		var course TrendingCourse
@@ -372,23 +809,37 @@ func (r *Repository) GetTrendingCourses(limit int) ([]TrendingCourse, error) {
 	return courses, nil
 }
 
-// UpdateTrendingCourses updates trending cache (batch operation)
-func (r *Repository) UpdateTrendingCourses(courses []TrendingCourse) error {
-	tx, err := r.db.Begin()
+// trendingRefreshLockKey identifies the advisory lock that serializes trending refreshes
+// across instances; it only needs to be unique among the application's advisory locks
+const trendingRefreshLockKey int64 = 0x7472656e64696e67 // "trending"
+
+// ErrTrendingRefreshInProgress is returned when another refresh holds the trending lock
+var ErrTrendingRefreshInProgress = errors.New("trending refresh already in progress")
+
+// UpdateTrendingCourses rebuilds the whole trending cache (batch operation)
+// The replacement runs under a transaction-scoped advisory lock so concurrent refreshes
+// cannot interleave their DELETE and COPY. With wait set the call blocks until the lock is
+// free; otherwise it returns ErrTrendingRefreshInProgress without touching the table.
+func (r *Repository) UpdateTrendingCourses(ctx context.Context, courses []TrendingCourse, wait bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	if err := lockTrendingRefresh(ctx, tx, wait); err != nil {
+		return err
+	}
+
 	// Delete old trending data
-	_, err = tx.Exec("DELETE FROM trending_courses")
+	_, err = tx.ExecContext(ctx, "DELETE FROM trending_courses")
 	if err != nil {
 		return fmt.Errorf("failed to delete old trending data: %w", err)
 	}
 
 	// Batch insert new trending data
 	if len(courses) > 0 {
-		stmt, err := tx.Prepare(pq.CopyIn(
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(
 			"trending_courses",
 			"course_id",
 			"velocity",
@@ -403,7 +854,7 @@ func (r *Repository) UpdateTrendingCourses(courses []TrendingCourse) error {
 		}
 
 		for _, course := range courses {
-			_, err = stmt.Exec(
+			_, err = stmt.ExecContext(ctx,
 				course.CourseID,
 				course.Velocity,
 				course.Signups24h,
@@ -417,7 +868,7 @@ func (r *Repository) UpdateTrendingCourses(courses []TrendingCourse) error {
 			}
 		}
 
-		_, err = stmt.Exec()
+		_, err = stmt.ExecContext(ctx)
 		if err != nil {
 			return fmt.Errorf("failed to execute batch insert: %w", err)
 		}
@@ -435,9 +886,135 @@ func (r *Repository) UpdateTrendingCourses(courses []TrendingCourse) error {
 	return nil
 }
 
-// GetUserAchievements retrieves earned achievements
-func (r *Repository) GetUserAchievements(userID string) ([]Achievement, error) {
-	query := `
+// trendingInsertColumns is the number of values inserted per trending course
+const trendingInsertColumns = 7
+
+// UpsertTrendingCategory replaces the trending courses of one meta-category,
+// leaving every other category untouched. Courses are upserted rather than
+// deleted and copied, so readers never see the category empty; courses that
+// stopped trending or belong to no TrendingCategories entry are removed, ranks
+// are renumbered across all categories and only the TrendingCandidateLimit
+// fastest are kept, as in a full rebuild. Locking works as in UpdateTrendingCourses.
+func (r *Repository) UpsertTrendingCategory(ctx context.Context, category string, courses []TrendingCourse, wait bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockTrendingRefresh(ctx, tx, wait); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	courseIDs := make([]string, 0, len(courses))
+	values := make([]string, 0, len(courses))
+	args := make([]interface{}, 0, len(courses)*trendingInsertColumns)
+	for i, course := range courses {
+		base := i * trendingInsertColumns
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			base+1, base+2, base+3, base+4, base+5, base+6, base+7))
+		args = append(args,
+			course.CourseID,
+			course.Velocity,
+			course.Signups24h,
+			course.SignupsPrevious24h,
+			course.Rank,
+			category,
+			now,
+		)
+		courseIDs = append(courseIDs, course.CourseID)
+	}
+
+	if len(courses) > 0 {
+		query := `
+			INSERT INTO trending_courses (
+				course_id, velocity, signups_24h, signups_previous_24h,
+				rank, meta_category, calculated_at
+			) VALUES ` + strings.Join(values, ", ") + `
+			ON CONFLICT (course_id)
+			DO UPDATE SET
+				velocity = EXCLUDED.velocity,
+				signups_24h = EXCLUDED.signups_24h,
+				signups_previous_24h = EXCLUDED.signups_previous_24h,
+				rank = EXCLUDED.rank,
+				meta_category = EXCLUDED.meta_category,
+				calculated_at = EXCLUDED.calculated_at
+		`
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to upsert trending courses: %w", err)
+		}
+	}
+
+	// Drop courses of this category that are no longer trending
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM trending_courses
+		WHERE meta_category = $1 AND NOT (course_id::text = ANY($2))
+	`, category, pq.Array(courseIDs))
+	if err != nil {
+		return fmt.Errorf("failed to delete stale trending courses: %w", err)
+	}
+
+	// Drop courses of categories trending no longer lists, which no refresh would replace
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM trending_courses
+		WHERE meta_category IS NULL OR NOT (meta_category = ANY($1))
+	`, pq.Array(TrendingCategories))
+	if err != nil {
+		return fmt.Errorf("failed to delete unlisted trending categories: %w", err)
+	}
+
+	// Ranks are across all categories, so renumber them by velocity
+	_, err = tx.ExecContext(ctx, `
+		UPDATE trending_courses t
+		SET rank = ranked.position
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY velocity DESC, signups_24h DESC, course_id) AS position
+			FROM trending_courses
+		) ranked
+		WHERE t.id = ranked.id AND t.rank IS DISTINCT FROM ranked.position
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to rerank trending courses: %w", err)
+	}
+
+	// Keep as many courses in total as a full rebuild does
+	_, err = tx.ExecContext(ctx, `DELETE FROM trending_courses WHERE rank > $1`, TrendingCandidateLimit)
+	if err != nil {
+		return fmt.Errorf("failed to trim trending courses: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// lockTrendingRefresh takes the transaction-scoped lock every trending write
+// holds, which is released when the transaction commits or rolls back. With wait
+// set it blocks until the lock is free; otherwise it returns
+// ErrTrendingRefreshInProgress when another refresh holds it.
+func lockTrendingRefresh(ctx context.Context, tx *sql.Tx, wait bool) error {
+	if wait {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", trendingRefreshLockKey); err != nil {
+			return fmt.Errorf("failed to acquire trending refresh lock: %w", err)
+		}
+		return nil
+	}
+
+	var acquired bool
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", trendingRefreshLockKey).Scan(&acquired); err != nil {
+		return fmt.Errorf("failed to acquire trending refresh lock: %w", err)
+	}
+	if !acquired {
+		return ErrTrendingRefreshInProgress
+	}
+	return nil
+}
+
+// achievementColumns selects an earned achievement in the order scanAchievements reads it
+const achievementColumns = `
 		SELECT
 			a.id,
 			a.name,
@@ -448,36 +1025,135 @@ func (r *Repository) GetUserAchievements(userID string) ([]Achievement, error) {
 			a.created_at,
 			ua.unlocked_at
 		FROM achievements a
-		INNER JOIN user_achievements ua ON a.id = ua.achievement_id
+		INNER JOIN user_achievements ua ON a.id = ua.achievement_id`
+
+// GetUserAchievements retrieves earned achievements
+func (r *Repository) GetUserAchievements(ctx context.Context, userID string) ([]Achievement, error) {
+	query := achievementColumns + `
 		WHERE ua.user_id = $1
 		ORDER BY ua.unlocked_at DESC
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query achievements: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAchievements(rows)
+}
+
+// ListUserAchievements retrieves one page of earned achievements, newest first.
+// An empty rarity matches every rarity.
+func (r *Repository) ListUserAchievements(ctx context.Context, userID, rarity string, limit, offset int) ([]Achievement, error) {
+	query := achievementColumns + `
+		WHERE ua.user_id = $1
+			AND ($2 = '' OR a.rarity = $2)
+		ORDER BY ua.unlocked_at DESC, a.id
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, rarity, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query achievements: %w", err)
 	}
 	defer rows.Close()
 
+	return scanAchievements(rows)
+}
+
+// CountUserAchievements returns how many achievements the user has earned,
+// optionally only those of one rarity
+func (r *Repository) CountUserAchievements(ctx context.Context, userID, rarity string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM achievements a
+		INNER JOIN user_achievements ua ON a.id = ua.achievement_id
+		WHERE ua.user_id = $1
+			AND ($2 = '' OR a.rarity = $2)
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, rarity).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count achievements: %w", err)
+	}
+	return count, nil
+}
+
+// GetAchievementDefinitions retrieves every achievement that can be unlocked
+func (r *Repository) GetAchievementDefinitions(ctx context.Context) ([]Achievement, error) {
+	query := `
+		SELECT id, name, description, badge_icon, criteria, rarity, created_at
+		FROM achievements
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query achievement definitions: %w", err)
+	}
+	defer rows.Close()
+
+	var definitions []Achievement
+	for rows.Next() {
+		var definition Achievement
+		var description, badgeIcon, rarity sql.NullString
+		var criteriaJSON []byte
+
+		if err := rows.Scan(
+			&definition.ID,
+			&definition.Name,
+			&description,
+			&badgeIcon,
+			&criteriaJSON,
+			&rarity,
+			&definition.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement definition: %w", err)
+		}
+		definition.Description = description.String
+		definition.BadgeIcon = badgeIcon.String
+		definition.Rarity = rarity.String
+
+		if err := json.Unmarshal(criteriaJSON, &definition.Criteria); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal criteria: %w", err)
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating achievement definitions: %w", err)
+	}
+
+	return definitions, nil
+}
+
+// scanAchievements reads rows selected with achievementColumns
+func scanAchievements(rows *sql.Rows) ([]Achievement, error) {
 	var achievements []Achievement
 	for rows.Next() {
 		var achievement Achievement
+		var description, badgeIcon, rarity sql.NullString
 		var criteriaJSON []byte
 		var unlockedAt time.Time
 
 		err := rows.Scan(
 			&achievement.ID,
 			&achievement.Name,
-			&achievement.Description,
-			&achievement.BadgeIcon,
+			&description,
+			&badgeIcon,
 			&criteriaJSON,
-			&achievement.Rarity,
+			&rarity,
 			&achievement.CreatedAt,
 			&unlockedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan achievement: %w", err)
 		}
+		achievement.Description = description.String
+		achievement.BadgeIcon = badgeIcon.String
+		achievement.Rarity = rarity.String
 
 		if len(criteriaJSON) > 0 {
 			if err := json.Unmarshal(criteriaJSON, &achievement.Criteria); err != nil {
@@ -495,15 +1171,31 @@ func (r *Repository) GetUserAchievements(userID string) ([]Achievement, error) {
 	return achievements, nil
 }
 
+// GetTotalTimeSpentSeconds sums the time a user has spent across all courses
+func (r *Repository) GetTotalTimeSpentSeconds(ctx context.Context, userID string) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(time_spent_seconds), 0)
+		FROM user_progress
+		WHERE user_id = $1
+	`
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum time spent: %w", err)
+	}
+
+	return total, nil
+}
+
 // UnlockAchievement awards achievement to user
-func (r *Repository) UnlockAchievement(userID, achievementID string) error {
+func (r *Repository) UnlockAchievement(ctx context.Context, userID, achievementID string) error {
 	query := `
 		INSERT INTO user_achievements (user_id, achievement_id, unlocked_at)
 		VALUES ($1, $2, NOW())
 		ON CONFLICT (user_id, achievement_id) DO NOTHING
 	`
 
-	result, err := r.db.Exec(query, userID, achievementID)
+	result, err := r.db.ExecContext(ctx, query, userID, achievementID)
 	if err != nil {
 		return fmt.Errorf("failed to unlock achievement: %w", err)
 	}
@@ -521,7 +1213,7 @@ func (r *Repository) UnlockAchievement(userID, achievementID string) error {
 }
 
 // GetCollaborativeFilteringCandidates finds users with similar course completions
-func (r *Repository) GetCollaborativeFilteringCandidates(userID string, minOverlap float64) ([]string, error) {
+func (r *Repository) GetCollaborativeFilteringCandidates(ctx context.Context, userID string, minOverlap float64) ([]string, error) {
 	query := `
 		WITH user_courses AS (
 			SELECT
@@ -546,7 +1238,7 @@ func (r *Repository) GetCollaborativeFilteringCandidates(userID string, minOverl
 		LIMIT 50
 	`
 
-	rows, err := r.db.Query(query, userID, minOverlap)
+	rows, err := r.db.QueryContext(ctx, query, userID, minOverlap)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query similar users: %w", err)
 	}
@@ -564,13 +1256,18 @@ func (r *Repository) GetCollaborativeFilteringCandidates(userID string, minOverl
 	return userIDs, nil
 }
 
-// GetCoursesCompletedByUsers retrieves courses completed by list of users
-func (r *Repository) GetCoursesCompletedByUsers(userIDs []string, excludeUserID string) ([]string, error) {
+// GetCoursesCompletedByUsers retrieves live courses completed by list of users
+func (r *Repository) GetCoursesCompletedByUsers(ctx context.Context, userIDs []string, excludeUserID string) ([]string, error) {
 	query := `
 		SELECT DISTINCT course_id
 		FROM user_progress
 		WHERE user_id = ANY($1)
 			AND completed_at IS NOT NULL
+			AND course_id IN (
+				SELECT id
+				FROM generated_courses
+				WHERE deleted_at IS NULL
+			)
 			AND course_id NOT IN (
 				SELECT course_id
 				FROM user_progress
@@ -578,7 +1275,7 @@ func (r *Repository) GetCoursesCompletedByUsers(userIDs []string, excludeUserID
 			)
 	`
 
-	rows, err := r.db.Query(query, pq.Array(userIDs), excludeUserID)
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(userIDs), excludeUserID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query courses: %w", err)
 	}
@@ -596,51 +1293,97 @@ func (r *Repository) GetCoursesCompletedByUsers(userIDs []string, excludeUserID
 	return courseIDs, nil
 }
 
-// CalculateTrendingVelocity calculates velocity for all courses
-func (r *Repository) CalculateTrendingVelocity() ([]TrendingCourse, error) {
+// GetCoursesInUserCategories returns other users' courses in the meta categories the
+// user already studies, excluding courses the user has started, newest first
+func (r *Repository) GetCoursesInUserCategories(ctx context.Context, userID string, limit int) ([]string, error) {
 	query := `
+		SELECT gc.id
+		FROM generated_courses gc
+		WHERE gc.user_id <> $1
+			AND gc.deleted_at IS NULL
+			AND gc.meta_category IN (
+				SELECT meta_category
+				FROM generated_courses
+				WHERE user_id = $1 AND deleted_at IS NULL
+			)
+			AND gc.id NOT IN (
+				SELECT course_id
+				FROM user_progress
+				WHERE user_id = $1
+			)
+		ORDER BY gc.created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query courses: %w", err)
+	}
+	defer rows.Close()
+
+	var courseIDs []string
+	for rows.Next() {
+		var cid string
+		if err := rows.Scan(&cid); err != nil {
+			return nil, fmt.Errorf("failed to scan course ID: %w", err)
+		}
+		courseIDs = append(courseIDs, cid)
+	}
+
+	return courseIDs, rows.Err()
+}
+
+// CalculateTrendingVelocity counts each course's signups in the policy's recent
+// and previous windows and returns the TrendingCandidateLimit fastest-growing
+// courses, ranked by the policy's velocity (ties go to the course with more
+// recent signups). Counts are compared as rates, so windows of different lengths
+// still give 1x for a steady signup pace; a course with no previous signups gets
+// the policy's new-course velocity. Courses without recent signups are not
+// trending, and only courses of the given meta-categories are considered.
+func (r *Repository) CalculateTrendingVelocity(ctx context.Context, policy TrendingPolicy, categories []string) ([]TrendingCourse, error) {
+	query := `
+		WITH signups AS (
+			SELECT
+				gc.id as course_id,
+				gc.meta_category,
+				COUNT(*) FILTER (
+					WHERE up.started_at > NOW() - make_interval(secs => $1)
+				) as signups_recent,
+				COUNT(*) FILTER (
+					WHERE up.started_at <= NOW() - make_interval(secs => $1)
+				) as signups_previous
+			FROM generated_courses gc
+			JOIN user_progress up ON gc.id = up.course_id
+				AND up.started_at > NOW() - make_interval(secs => $1 + $2)
+			WHERE gc.deleted_at IS NULL
+				AND gc.meta_category = ANY($3)
+			GROUP BY gc.id, gc.meta_category
+		)
 		SELECT
-			gc.id as course_id,
-			gc.meta_category,
-			COUNT(*) FILTER (
-				WHERE up.started_at > NOW() - INTERVAL '24 hours'
-			) as signups_24h,
-			COUNT(*) FILTER (
-				WHERE up.started_at BETWEEN NOW() - INTERVAL '48 hours'
-					AND NOW() - INTERVAL '24 hours'
-			) as signups_prev_24h,
+			course_id,
+			meta_category,
+			signups_recent,
+			signups_previous,
 			CASE
-				WHEN COUNT(*) FILTER (
-					WHERE up.started_at BETWEEN NOW() - INTERVAL '48 hours'
-						AND NOW() - INTERVAL '24 hours'
-				) > 0
-				THEN
-					COUNT(*) FILTER (WHERE up.started_at > NOW() - INTERVAL '24 hours')::decimal /
-					COUNT(*) FILTER (WHERE up.started_at BETWEEN NOW() - INTERVAL '48 hours'
-						AND NOW() - INTERVAL '24 hours')
-				ELSE
-					CASE
-						WHEN COUNT(*) FILTER (WHERE up.started_at > NOW() - INTERVAL '24 hours') > 0
-						THEN 10.0
-						ELSE 0.0
-					END
+				WHEN signups_previous + $4::float8 = 0 THEN $5::float8
+				ELSE ((signups_recent + $4::float8) * $2) / ((signups_previous + $4::float8) * $1)
 			END as velocity
-		FROM generated_courses gc
-		LEFT JOIN user_progress up ON gc.id = up.course_id
-		GROUP BY gc.id, gc.meta_category
-		HAVING COUNT(*) FILTER (WHERE up.started_at > NOW() - INTERVAL '24 hours') > 0
-		ORDER BY velocity DESC
-		LIMIT 100
+		FROM signups
+		WHERE signups_recent > 0
+		ORDER BY velocity DESC, signups_recent DESC, course_id
+		LIMIT $6
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query,
+		policy.Window.Seconds(), policy.PreviousWindow.Seconds(), pq.Array(categories),
+		policy.Smoothing, policy.NewCourseVelocity, TrendingCandidateLimit,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate velocity: %w", err)
 	}
 	defer rows.Close()
 
 	var courses []TrendingCourse
-	rank := 1
 	for rows.Next() {
 		var course TrendingCourse
 		err := rows.Scan(
@@ -653,9 +1396,8 @@ func (r *Repository) CalculateTrendingVelocity() ([]TrendingCourse, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan trending course: %w", err)
 		}
-		course.Rank = rank
+		course.Rank = len(courses) + 1
 		courses = append(courses, course)
-		rank++
 	}
 
 	if err := rows.Err(); err != nil {
@@ -664,3 +1406,73 @@ func (r *Repository) CalculateTrendingVelocity() ([]TrendingCourse, error) {
 
 	return courses, nil
 }
+
+// solvedExercises lists each user's solved exercises with the time of their first passing submission
+const solvedExercises = `
+	SELECT user_id, exercise_id, MIN(submitted_at) AS solved_at
+	FROM module_completions
+	WHERE passed AND exercise_id IS NOT NULL
+	GROUP BY user_id, exercise_id
+`
+
+// leaderboardScores holds, per metric, a query returning (user_id, score) for
+// activity at or after $1; a NULL $1 covers all time
+var leaderboardScores = map[string]string{
+	LeaderboardExercisesSolved: `
+		SELECT user_id, COUNT(*) AS score
+		FROM (` + solvedExercises + `) solved
+		WHERE $1::timestamp IS NULL OR solved_at >= $1
+		GROUP BY user_id`,
+	LeaderboardCoursesCompleted: `
+		SELECT user_id, COUNT(*) AS score
+		FROM user_progress
+		WHERE completed_at IS NOT NULL AND ($1::timestamp IS NULL OR completed_at >= $1)
+		GROUP BY user_id`,
+	LeaderboardPoints: `
+		SELECT solved.user_id, SUM(COALESCE(e.points, 0)) AS score
+		FROM (` + solvedExercises + `) solved
+		JOIN exercises e ON e.id = solved.exercise_id
+		WHERE $1::timestamp IS NULL OR solved.solved_at >= $1
+		GROUP BY solved.user_id`,
+}
+
+// GetLeaderboard returns the top users by metric since the given time (zero for
+// all time), skipping users whose privacy settings hide them from leaderboards
+func (r *Repository) GetLeaderboard(ctx context.Context, metric string, since time.Time, limit int) ([]LeaderboardEntry, error) {
+	scores, ok := leaderboardScores[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard metric %q", metric)
+	}
+
+	query := `
+		WITH scores AS (` + scores + `)
+		SELECT RANK() OVER (ORDER BY s.score DESC), s.user_id, u.name, COALESCE(u.avatar_url, ''), s.score
+		FROM scores s
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN user_privacy_settings p ON p.user_id = s.user_id
+		WHERE s.score > 0 AND COALESCE(p.show_in_leaderboards, TRUE)
+		ORDER BY s.score DESC, s.user_id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sql.NullTime{Time: since, Valid: !since.IsZero()}, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []LeaderboardEntry{}
+	for rows.Next() {
+		var entry LeaderboardEntry
+		if err := rows.Scan(&entry.Rank, &entry.UserID, &entry.Name, &entry.AvatarURL, &entry.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leaderboard: %w", err)
+	}
+
+	return entries, nil
+}
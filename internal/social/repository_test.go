@@ -0,0 +1,689 @@
+package social
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/tests/testutil"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateTrendingCourses_ConcurrentRefreshes(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Trending User")
+	courseID := uuid.New().String()
+	_, err := db.Exec(
+		`INSERT INTO generated_courses (id, user_id, title, meta_category, injected_variables) VALUES ($1, $2, 'Trending', 'Digital', '{}')`,
+		courseID, userID,
+	)
+	require.NoError(t, err)
+
+	courses := []TrendingCourse{{CourseID: courseID, Velocity: 2, Signups24h: 10, SignupsPrevious24h: 5, Rank: 1, MetaCategory: "Digital"}}
+	trendingCount := func() int {
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM trending_courses WHERE course_id = $1`, courseID).Scan(&count))
+		return count
+	}
+
+	// While another session holds the lock a skipping refresh does nothing
+	holder, err := db.Begin()
+	require.NoError(t, err)
+	_, err = holder.Exec(`SELECT pg_advisory_xact_lock($1)`, trendingRefreshLockKey)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, repo.UpdateTrendingCourses(context.Background(), courses, false), ErrTrendingRefreshInProgress)
+	assert.Equal(t, 0, trendingCount())
+	require.NoError(t, holder.Rollback())
+
+	// Concurrent skipping refreshes: at least one proceeds, the rest skip
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = repo.UpdateTrendingCourses(context.Background(), courses, false)
+		}(i)
+	}
+	wg.Wait()
+
+	proceeded := 0
+	for _, err := range errs {
+		if err == nil {
+			proceeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrTrendingRefreshInProgress)
+	}
+	assert.GreaterOrEqual(t, proceeded, 1)
+	assert.Equal(t, 1, trendingCount())
+
+	// Concurrent waiting refreshes all succeed one after another
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = repo.UpdateTrendingCourses(context.Background(), courses, true)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, trendingCount())
+}
+
+func TestUpsertTrendingCategory_LeavesOtherCategoriesIntact(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Trending User")
+	digitalID := uuid.New().String()
+	economicID := uuid.New().String()
+	for id, category := range map[string]string{digitalID: "Digital", economicID: "Economic"} {
+		_, err := db.Exec(
+			`INSERT INTO generated_courses (id, user_id, title, meta_category, injected_variables) VALUES ($1, $2, 'Trending', $3, '{}')`,
+			id, userID, category,
+		)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, repo.UpdateTrendingCourses(context.Background(), []TrendingCourse{
+		{CourseID: digitalID, Velocity: 3, Signups24h: 9, SignupsPrevious24h: 3, Rank: 1, MetaCategory: "Digital"},
+		{CourseID: economicID, Velocity: 2, Signups24h: 10, SignupsPrevious24h: 5, Rank: 2, MetaCategory: "Economic"},
+	}, false))
+
+	ranks := func() map[string]int {
+		rows, err := db.Query(`SELECT course_id, rank FROM trending_courses`)
+		require.NoError(t, err)
+		defer rows.Close()
+		ranks := map[string]int{}
+		for rows.Next() {
+			var courseID string
+			var rank int
+			require.NoError(t, rows.Scan(&courseID, &rank))
+			ranks[courseID] = rank
+		}
+		require.NoError(t, rows.Err())
+		return ranks
+	}
+
+	// Digital stops trending: only its course goes, and Economic moves up
+	require.NoError(t, repo.UpsertTrendingCategory(context.Background(), "Digital", nil, false))
+	assert.Equal(t, map[string]int{economicID: 1}, ranks())
+
+	// Digital trends again, faster than Economic
+	require.NoError(t, repo.UpsertTrendingCategory(context.Background(), "Digital", []TrendingCourse{
+		{CourseID: digitalID, Velocity: 5, Signups24h: 5, SignupsPrevious24h: 1, Rank: 1, MetaCategory: "Digital"},
+	}, false))
+	assert.Equal(t, map[string]int{digitalID: 1, economicID: 2}, ranks())
+
+	var velocity float64
+	require.NoError(t, db.QueryRow(`SELECT velocity FROM trending_courses WHERE course_id = $1`, economicID).Scan(&velocity))
+	assert.Equal(t, 2.0, velocity, "other categories keep their figures")
+}
+
+func TestGetActivityFeed_FiltersByDateRange(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	followerID := testutil.CreateUser(t, db, "Feed User")
+	followedID := testutil.CreateUser(t, db, "Feed User")
+	_, err := db.Exec(`INSERT INTO user_relationships (follower_id, following_id) VALUES ($1, $2)`, followerID, followedID)
+	require.NoError(t, err)
+
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, createdAt := range []time.Time{day.AddDate(0, 0, -1), day, day.AddDate(0, 0, 1)} {
+		_, err := db.Exec(
+			`INSERT INTO activity_feed (user_id, activity_type, reference_type, reference_id, visibility, created_at)
+			 VALUES ($1, 'exercise_solved', 'exercise', $2, 'public', $3)`,
+			followedID, uuid.New().String(), createdAt,
+		)
+		require.NoError(t, err)
+	}
+
+	rng, err := ParseFeedRange("2024-05-01", "2024-05-01")
+	require.NoError(t, err)
+	activities, err := repo.GetActivityFeed(context.Background(), followerID, 50, rng, false)
+	require.NoError(t, err)
+	require.Len(t, activities, 1)
+	assert.True(t, activities[0].CreatedAt.Equal(day))
+
+	all, err := repo.GetActivityFeed(context.Background(), followerID, 50, FeedRange{}, false)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestGetActivityFeed_CursorPagesThroughTies(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	followerID := testutil.CreateUser(t, db, "Feed User")
+	followedID := testutil.CreateUser(t, db, "Feed User")
+	_, err := db.Exec(`INSERT INTO user_relationships (follower_id, following_id) VALUES ($1, $2)`, followerID, followedID)
+	require.NoError(t, err)
+
+	// Activities created in the same instant are still each returned exactly once
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err := db.Exec(
+			`INSERT INTO activity_feed (user_id, activity_type, reference_type, reference_id, visibility, created_at)
+			 VALUES ($1, 'exercise_solved', 'exercise', $2, 'public', $3)`,
+			followedID, uuid.New().String(), createdAt,
+		)
+		require.NoError(t, err)
+	}
+
+	seen := map[string]bool{}
+	var rng FeedRange
+	for page := 0; page < 3; page++ {
+		activities, err := repo.GetActivityFeed(context.Background(), followerID, 2, rng, false)
+		require.NoError(t, err)
+		for _, activity := range activities {
+			assert.False(t, seen[activity.ID], "activity %s returned twice", activity.ID)
+			seen[activity.ID] = true
+		}
+		if len(activities) < 2 {
+			break
+		}
+		rng.Before = NewFeedCursor(activities[len(activities)-1])
+	}
+	assert.Len(t, seen, 3)
+}
+
+func TestGetLeaderboard_RanksAndRespectsPrivacy(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	// Three learners: one solves both exercises, one solves one, one opts out
+	userIDs := make([]string, 3)
+	for i := range userIDs {
+		userIDs[i] = testutil.CreateUser(t, db, "Leaderboard User")
+	}
+	_, err := db.Exec(
+		`INSERT INTO user_privacy_settings (user_id, show_in_leaderboards) VALUES ($1, FALSE)`,
+		userIDs[2],
+	)
+	require.NoError(t, err)
+
+	courseID, moduleID := uuid.New().String(), uuid.New().String()
+	_, err = db.Exec(
+		`INSERT INTO generated_courses (id, user_id, title, meta_category, injected_variables) VALUES ($1, $2, 'Ranked', 'Digital', '{}')`,
+		courseID, userIDs[0],
+	)
+	require.NoError(t, err)
+	_, err = db.Exec(
+		`INSERT INTO generated_modules (id, course_id, module_number, title) VALUES ($1, $2, 1, 'Module')`,
+		moduleID, courseID,
+	)
+	require.NoError(t, err)
+	exerciseIDs := []string{uuid.New().String(), uuid.New().String()}
+	for i, exerciseID := range exerciseIDs {
+		_, err := db.Exec(`
+			INSERT INTO exercises (id, module_id, exercise_number, title, language, test_cases, difficulty, points)
+			VALUES ($1, $2, $3, 'Exercise', 'go', '[]', 'easy', 100)`,
+			exerciseID, moduleID, i+1,
+		)
+		require.NoError(t, err)
+	}
+
+	solve := func(userID, exerciseID string, at time.Time) {
+		_, err := db.Exec(`
+			INSERT INTO module_completions (user_id, module_id, exercise_id, language, passed, submitted_at)
+			VALUES ($1, $2, $3, 'go', TRUE, $4)`,
+			userID, moduleID, exerciseID, at,
+		)
+		require.NoError(t, err)
+	}
+	longAgo := time.Now().Add(-30 * 24 * time.Hour)
+	solve(userIDs[0], exerciseIDs[0], longAgo)
+	solve(userIDs[0], exerciseIDs[0], time.Now()) // A repeat solve earns nothing new
+	solve(userIDs[0], exerciseIDs[1], longAgo)
+	solve(userIDs[1], exerciseIDs[0], time.Now())
+	solve(userIDs[2], exerciseIDs[0], time.Now())
+	solve(userIDs[2], exerciseIDs[1], time.Now())
+
+	scores := func(metric string, since time.Time) map[string]float64 {
+		entries, err := repo.GetLeaderboard(context.Background(), metric, since, MaxLeaderboardSize)
+		require.NoError(t, err)
+		byUser := make(map[string]float64)
+		for _, entry := range entries {
+			byUser[entry.UserID] = entry.Score
+		}
+		return byUser
+	}
+
+	allTime := scores(LeaderboardPoints, time.Time{})
+	assert.Equal(t, 200.0, allTime[userIDs[0]])
+	assert.Equal(t, 100.0, allTime[userIDs[1]])
+	assert.NotContains(t, allTime, userIDs[2])
+
+	weekly := scores(LeaderboardExercisesSolved, time.Now().Add(-7*24*time.Hour))
+	assert.NotContains(t, weekly, userIDs[0])
+	assert.Equal(t, 1.0, weekly[userIDs[1]])
+	assert.NotContains(t, weekly, userIDs[2])
+}
+
+func TestFollowUsers_SkipsExistingAndUnknownUsers(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userIDs := make([]string, 3)
+	for i := range userIDs {
+		userIDs[i] = testutil.CreateUser(t, db, "Follow User")
+	}
+	follower := userIDs[0]
+	created, err := repo.FollowUser(context.Background(), follower, userIDs[1])
+	require.NoError(t, err)
+	require.True(t, created)
+	created, err = repo.FollowUser(context.Background(), follower, userIDs[1])
+	require.NoError(t, err)
+	assert.False(t, created, "a repeated follow creates nothing")
+
+	unknown := uuid.New().String()
+	followed, err := repo.FollowUsers(context.Background(), follower, []string{userIDs[1], userIDs[2], unknown})
+	require.NoError(t, err)
+	assert.Equal(t, []string{userIDs[2]}, followed)
+
+	following, err := repo.IsFollowing(context.Background(), follower, userIDs[2])
+	require.NoError(t, err)
+	assert.True(t, following)
+	followsBack, err := repo.IsFollowing(context.Background(), userIDs[2], follower)
+	require.NoError(t, err)
+	assert.False(t, followsBack)
+
+	unfollowed, err := repo.UnfollowUsers(context.Background(), follower, []string{userIDs[1], userIDs[2], unknown})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{userIDs[1], userIDs[2]}, unfollowed)
+}
+
+func TestDeleteExpiredRecommendations_KeepsLiveRecommendations(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Recommended User")
+
+	expiries := map[string]interface{}{
+		"expired":   time.Now().Add(-time.Hour),
+		"live":      time.Now().Add(time.Hour),
+		"no_expiry": nil,
+	}
+	courseIDs := make(map[string]string, len(expiries))
+	for name, expiresAt := range expiries {
+		courseID := uuid.New().String()
+		courseIDs[name] = courseID
+		_, err := db.Exec(
+			`INSERT INTO generated_courses (id, user_id, title, meta_category, injected_variables) VALUES ($1, $2, $3, 'Digital', '{}')`,
+			courseID, userID, name,
+		)
+		require.NoError(t, err)
+		_, err = db.Exec(
+			`INSERT INTO recommendations (user_id, course_id, recommendation_type, match_score, expires_at) VALUES ($1, $2, 'trending', 50, $3)`,
+			userID, courseID, expiresAt,
+		)
+		require.NoError(t, err)
+	}
+
+	deleted, err := repo.DeleteExpiredRecommendations(context.Background())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(1))
+
+	remaining := func(name string) bool {
+		var exists bool
+		require.NoError(t, db.QueryRow(
+			`SELECT EXISTS (SELECT 1 FROM recommendations WHERE user_id = $1 AND course_id = $2)`,
+			userID, courseIDs[name],
+		).Scan(&exists))
+		return exists
+	}
+	assert.False(t, remaining("expired"))
+	assert.True(t, remaining("live"))
+	assert.True(t, remaining("no_expiry"))
+}
+
+func TestGetActivityFeed_IncludesOwnActivity(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Feed User")
+	followedID := testutil.CreateUser(t, db, "Feed User")
+	_, err := db.Exec(`INSERT INTO user_relationships (follower_id, following_id) VALUES ($1, $2)`, userID, followedID)
+	require.NoError(t, err)
+
+	now := time.Now()
+	activities := []struct {
+		userID     string
+		visibility string
+		createdAt  time.Time
+	}{
+		{userID, "public", now.Add(-time.Minute)},
+		{followedID, "public", now.Add(-2 * time.Minute)},
+		{userID, "friends", now.Add(-3 * time.Minute)},
+		{userID, "private", now.Add(-4 * time.Minute)},
+	}
+	for _, activity := range activities {
+		_, err := db.Exec(
+			`INSERT INTO activity_feed (user_id, activity_type, reference_type, reference_id, visibility, created_at)
+			 VALUES ($1, 'exercise_solved', 'exercise', $2, $3, $4)`,
+			activity.userID, uuid.New().String(), activity.visibility, activity.createdAt,
+		)
+		require.NoError(t, err)
+	}
+
+	withOwn, err := repo.GetActivityFeed(context.Background(), userID, 50, FeedRange{}, true)
+	require.NoError(t, err)
+	require.Len(t, withOwn, 3)
+	assert.Equal(t, []string{userID, followedID, userID},
+		[]string{withOwn[0].UserID, withOwn[1].UserID, withOwn[2].UserID})
+
+	followeesOnly, err := repo.GetActivityFeed(context.Background(), userID, 50, FeedRange{}, false)
+	require.NoError(t, err)
+	require.Len(t, followeesOnly, 1)
+	assert.Equal(t, followedID, followeesOnly[0].UserID)
+
+	// A new user who follows no one still sees their own activity
+	lonely, err := repo.GetActivityFeed(context.Background(), followedID, 50, FeedRange{}, true)
+	require.NoError(t, err)
+	assert.Len(t, lonely, 1)
+}
+
+func TestGetActivityFeed_ShowsFollowEventsToTheFollowedUser(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+	service := NewService(repo)
+
+	followerID := testutil.CreateUser(t, db, "Feed User")
+	targetID := testutil.CreateUser(t, db, "Feed User")
+	bystanderID := testutil.CreateUser(t, db, "Feed User")
+
+	// The bystander follows the follower, so sees their friends activity but not their follows
+	_, err := db.Exec(`INSERT INTO user_relationships (follower_id, following_id) VALUES ($1, $2)`, bystanderID, followerID)
+	require.NoError(t, err)
+
+	require.NoError(t, service.FollowUser(context.Background(), followerID, targetID))
+	require.NoError(t, service.FollowUser(context.Background(), followerID, targetID))
+
+	var count int
+	require.NoError(t, db.QueryRow(
+		`SELECT COUNT(*) FROM activity_feed WHERE user_id = $1 AND activity_type = $2`, followerID, ActivityUserFollowed,
+	).Scan(&count))
+	assert.Equal(t, 1, count, "a repeated follow records no second activity")
+
+	targetFeed, err := repo.GetActivityFeed(context.Background(), targetID, 50, FeedRange{}, true)
+	require.NoError(t, err)
+	require.Len(t, targetFeed, 1)
+	assert.Equal(t, ActivityUserFollowed, targetFeed[0].ActivityType)
+	assert.Equal(t, followerID, targetFeed[0].UserID)
+
+	followerFeed, err := repo.GetActivityFeed(context.Background(), followerID, 50, FeedRange{}, true)
+	require.NoError(t, err)
+	assert.Empty(t, followerFeed, "the follower's own private follow event is not in their feed")
+
+	bystanderFeed, err := repo.GetActivityFeed(context.Background(), bystanderID, 50, FeedRange{}, true)
+	require.NoError(t, err)
+	assert.Empty(t, bystanderFeed)
+}
+
+func TestGetActivityFeed_SelfFollowDoesNotBypassIncludeOwn(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Feed User")
+
+	// A self-follow row predating the service check must not pull the user's own activity in
+	_, err := db.Exec(`INSERT INTO user_relationships (follower_id, following_id) VALUES ($1, $1)`, userID)
+	require.NoError(t, err)
+	_, err = db.Exec(
+		`INSERT INTO activity_feed (user_id, activity_type, reference_type, reference_id, visibility)
+		 VALUES ($1, 'exercise_solved', 'exercise', $2, 'friends')`,
+		userID, uuid.New().String(),
+	)
+	require.NoError(t, err)
+
+	feed, err := repo.GetActivityFeed(context.Background(), userID, 50, FeedRange{}, false)
+	require.NoError(t, err)
+	assert.Empty(t, feed)
+
+	withOwn, err := repo.GetActivityFeed(context.Background(), userID, 50, FeedRange{}, true)
+	require.NoError(t, err)
+	assert.Len(t, withOwn, 1)
+}
+
+func TestGetActivityFeed_FriendsActivityOnlyReachesMutualFollows(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	authorID := testutil.CreateUser(t, db, "Feed User")
+	friendID := testutil.CreateUser(t, db, "Feed User")
+	followerID := testutil.CreateUser(t, db, "Feed User")
+
+	// The friend and the author follow each other; the follower is not followed back
+	for _, pair := range [][2]string{{friendID, authorID}, {authorID, friendID}, {followerID, authorID}} {
+		_, err := db.Exec(`INSERT INTO user_relationships (follower_id, following_id) VALUES ($1, $2)`, pair[0], pair[1])
+		require.NoError(t, err)
+	}
+	for _, visibility := range []string{"public", "friends"} {
+		_, err := db.Exec(
+			`INSERT INTO activity_feed (user_id, activity_type, reference_type, reference_id, visibility)
+			 VALUES ($1, 'exercise_solved', 'exercise', $2, $3)`,
+			authorID, uuid.New().String(), visibility,
+		)
+		require.NoError(t, err)
+	}
+
+	friends, err := repo.GetMutualFollows(context.Background(), authorID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{friendID}, friends)
+
+	friendFeed, err := repo.GetActivityFeed(context.Background(), friendID, 50, FeedRange{}, false)
+	require.NoError(t, err)
+	assert.Len(t, friendFeed, 2)
+
+	followerFeed, err := repo.GetActivityFeed(context.Background(), followerID, 50, FeedRange{}, false)
+	require.NoError(t, err)
+	require.Len(t, followerFeed, 1)
+	assert.Equal(t, "public", followerFeed[0].Visibility)
+}
+
+func TestGetFollowerProfiles_JoinsUsersAndMarksMutual(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	userID := testutil.CreateUser(t, db, "Followed User")
+	friendID := testutil.CreateUser(t, db, "Friend")
+	followerID := testutil.CreateUser(t, db, "Follower")
+
+	// The friend follows first and is followed back; the follower is not
+	base := time.Now().Add(-time.Hour)
+	for i, pair := range [][2]string{{friendID, userID}, {userID, friendID}, {followerID, userID}} {
+		_, err := db.Exec(
+			`INSERT INTO user_relationships (follower_id, following_id, created_at) VALUES ($1, $2, $3)`,
+			pair[0], pair[1], base.Add(time.Duration(i)*time.Minute),
+		)
+		require.NoError(t, err)
+	}
+
+	followers, err := repo.GetFollowerProfiles(context.Background(), userID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, followers, 2)
+	assert.Equal(t, followerID, followers[0].UserID, "most recent follower first")
+	assert.Equal(t, "Follower", followers[0].Name)
+	assert.False(t, followers[0].Mutual)
+	assert.Equal(t, friendID, followers[1].UserID)
+	assert.True(t, followers[1].Mutual)
+
+	page, err := repo.GetFollowerProfiles(context.Background(), userID, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, friendID, page[0].UserID)
+
+	total, err := repo.CountFollowers(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	following, err := repo.GetFollowingProfiles(context.Background(), userID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, following, 1)
+	assert.Equal(t, friendID, following[0].UserID)
+	assert.True(t, following[0].Mutual)
+}
+
+func TestSoftDeletedCoursesAreNotRecommendedOrTrending(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	authorID := testutil.CreateUser(t, db, "Course Author")
+	learnerID := testutil.CreateUser(t, db, "Learner")
+	signupID := testutil.CreateUser(t, db, "Recent Signup")
+
+	// The learner studies Digital, so the author's Digital courses are candidates
+	learnerCourseID := uuid.New().String()
+	liveID := uuid.New().String()
+	deletedID := uuid.New().String()
+	for id, owner := range map[string]string{learnerCourseID: learnerID, liveID: authorID, deletedID: authorID} {
+		_, err := db.Exec(
+			`INSERT INTO generated_courses (id, user_id, title, meta_category, injected_variables) VALUES ($1, $2, 'Course', 'Digital', '{}')`,
+			id, owner,
+		)
+		require.NoError(t, err)
+	}
+	for _, courseID := range []string{liveID, deletedID} {
+		_, err := db.Exec(`INSERT INTO user_progress (user_id, course_id, started_at, completed_at) VALUES ($1, $2, NOW(), NOW())`, signupID, courseID)
+		require.NoError(t, err)
+		_, err = db.Exec(
+			`INSERT INTO recommendations (user_id, course_id, recommendation_type, match_score) VALUES ($1, $2, 'trending', 50)`,
+			learnerID, courseID,
+		)
+		require.NoError(t, err)
+	}
+	_, err := db.Exec(`UPDATE generated_courses SET deleted_at = NOW() WHERE id = $1`, deletedID)
+	require.NoError(t, err)
+
+	candidates, err := repo.GetCoursesInUserCategories(context.Background(), learnerID, 10)
+	require.NoError(t, err)
+	assert.Contains(t, candidates, liveID)
+	assert.NotContains(t, candidates, deletedID)
+
+	completed, err := repo.GetCoursesCompletedByUsers(context.Background(), []string{signupID}, learnerID)
+	require.NoError(t, err)
+	assert.Contains(t, completed, liveID)
+	assert.NotContains(t, completed, deletedID)
+
+	trending, err := repo.CalculateTrendingVelocity(context.Background(), DefaultTrendingPolicy(), []string{"Digital"})
+	require.NoError(t, err)
+	var trendingIDs []string
+	for _, course := range trending {
+		trendingIDs = append(trendingIDs, course.CourseID)
+	}
+	assert.Contains(t, trendingIDs, liveID)
+	assert.NotContains(t, trendingIDs, deletedID)
+
+	recommendations, err := repo.GetRecommendations(context.Background(), learnerID, "")
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, liveID, recommendations[0].CourseID)
+}
+
+func TestCalculateTrendingVelocity_RankingDependsOnPolicy(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	authorID := testutil.CreateUser(t, db, "Course Author")
+	category := "Velocity " + uuid.New().String()
+	newID := uuid.New().String()
+	popularID := uuid.New().String()
+	for _, id := range []string{newID, popularID} {
+		_, err := db.Exec(
+			`INSERT INTO generated_courses (id, user_id, title, meta_category, injected_variables) VALUES ($1, $2, 'Course', $3, '{}')`,
+			id, authorID, category,
+		)
+		require.NoError(t, err)
+	}
+
+	// A brand-new course with one signup, and an established one that tripled
+	signup := func(courseID string, ago time.Duration) {
+		learnerID := testutil.CreateUser(t, db, "Learner")
+		_, err := db.Exec(
+			`INSERT INTO user_progress (user_id, course_id, started_at) VALUES ($1, $2, NOW() - make_interval(secs => $3))`,
+			learnerID, courseID, ago.Seconds(),
+		)
+		require.NoError(t, err)
+	}
+	signup(newID, time.Hour)
+	for i := 0; i < 3; i++ {
+		signup(popularID, time.Hour)
+	}
+	signup(popularID, 30*time.Hour)
+
+	ranking := func(policy TrendingPolicy) []TrendingCourse {
+		courses, err := repo.CalculateTrendingVelocity(context.Background(), policy, []string{category})
+		require.NoError(t, err)
+		require.Len(t, courses, 2)
+		for i, course := range courses {
+			assert.Equal(t, i+1, course.Rank)
+		}
+		return courses
+	}
+
+	courses := ranking(DefaultTrendingPolicy())
+	assert.Equal(t, newID, courses[0].CourseID, "without smoothing a new course dominates")
+	assert.Equal(t, 10.0, courses[0].Velocity)
+	assert.Equal(t, 3.0, courses[1].Velocity)
+
+	smoothed := DefaultTrendingPolicy()
+	smoothed.Smoothing = 1
+	courses = ranking(smoothed)
+	assert.Equal(t, popularID, courses[0].CourseID, "add-one smoothing ranks steady growth first")
+	assert.InDelta(t, 2.0, courses[0].Velocity, 1e-9)
+	assert.InDelta(t, 2.0, courses[1].Velocity, 1e-9, "ties go to more recent signups")
+
+	steady := DefaultTrendingPolicy()
+	steady.PreviousWindow = 72 * time.Hour
+	courses = ranking(steady)
+	assert.InDelta(t, 9.0, courses[1].Velocity, 1e-9, "the previous window is compared as a rate")
+}
+
+func TestUpsertTrendingCategory_KeepsFullRebuildLimitAndCategories(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	repo := NewRepository(db)
+
+	authorID := testutil.CreateUser(t, db, "Course Author")
+	newCourses := func(category string, count int, velocity float64) []TrendingCourse {
+		courses := make([]TrendingCourse, count)
+		for i := range courses {
+			id := uuid.New().String()
+			_, err := db.Exec(
+				`INSERT INTO generated_courses (id, user_id, title, meta_category, injected_variables) VALUES ($1, $2, 'Course', $3, '{}')`,
+				id, authorID, category,
+			)
+			require.NoError(t, err)
+			courses[i] = TrendingCourse{CourseID: id, Velocity: velocity, Signups24h: 1, Rank: i + 1, MetaCategory: category}
+		}
+		return courses
+	}
+
+	// A full rebuild leaves a course of a category trending no longer lists
+	require.NoError(t, repo.UpdateTrendingCourses(context.Background(), newCourses("Astrology", 1, 50), false))
+
+	require.NoError(t, repo.UpsertTrendingCategory(context.Background(), "Digital", newCourses("Digital", TrendingCandidateLimit, 2), false))
+	require.NoError(t, repo.UpsertTrendingCategory(context.Background(), "Economic", newCourses("Economic", 10, 3), false))
+
+	var total, unlisted, maxRank int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*), COALESCE(MAX(rank), 0) FROM trending_courses`).Scan(&total, &maxRank))
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM trending_courses WHERE meta_category = 'Astrology'`).Scan(&unlisted))
+	assert.Equal(t, TrendingCandidateLimit, total, "per-category refreshes keep as many courses as a full rebuild")
+	assert.Equal(t, TrendingCandidateLimit, maxRank)
+	assert.Zero(t, unlisted, "unlisted categories are purged")
+
+	var economic int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM trending_courses WHERE meta_category = 'Economic'`).Scan(&economic))
+	assert.Equal(t, 10, economic, "the faster category is kept over the cap")
+}
@@ -1,13 +1,26 @@
 package social
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"time"
+
+	"backend/internal/platform/cache"
+	"backend/internal/platform/validation"
+
+	"github.com/google/uuid"
 )
 
 // LearningService defines interface for learning operations (avoid circular dependency)
 type LearningService interface {
-	GetUserCoursesInterface(userID string) ([]interface{}, error)
+	GetUserCoursesInterface(ctx context.Context, userID string) ([]interface{}, error)
+	GetUserPoints(ctx context.Context, userID string) (int, error)
 }
 
 // IdentityService defines interface for identity operations (avoid circular dependency)
@@ -20,11 +33,76 @@ type Service struct {
 	repo            *Repository
 	learningService LearningService
 	identityService IdentityService
+
+	// Optional caches, enabled via WithCaches
+	trendingCache        *cache.Cache[string, []TrendingCourse]
+	recommendationsCache *cache.Cache[string, map[string][]Recommendation]
+
+	recommendationBatchSize int
+
+	skillGraph map[string][]string
+
+	trendingRefreshWait bool // Wait for a concurrent trending refresh instead of skipping
+	trendingFullRebuild bool // Rebuild the whole trending table instead of upserting per category
+	trendingPolicy      TrendingPolicy
+
+	recommendationTimeout time.Duration
+
+	privacyLookup PrivacyLookup // Optional, set via WithPrivacyLookup
+
+	feedHub *FeedHub // Live feed stream subscribers
 }
 
+// DefaultRecommendationBatchSize is how many recommendations are written per INSERT
+const DefaultRecommendationBatchSize = 100
+
 // NewService creates a new social service
 func NewService(repo *Repository) *Service {
-	return &Service{repo: repo}
+	return &Service{
+		repo:                    repo,
+		recommendationBatchSize: DefaultRecommendationBatchSize,
+		recommendationTimeout:   DefaultRecommendationTimeout,
+		trendingPolicy:          DefaultTrendingPolicy(),
+		skillGraph:              SkillGraph,
+		feedHub:                 NewFeedHub(),
+	}
+}
+
+// WithRecommendationBatchSize sets how many generated recommendations are written
+// per INSERT. Non-positive sizes use the default; sizes are capped at MaxRecommendationBatchSize.
+func (s *Service) WithRecommendationBatchSize(size int) *Service {
+	switch {
+	case size <= 0:
+		size = DefaultRecommendationBatchSize
+	case size > MaxRecommendationBatchSize:
+		size = MaxRecommendationBatchSize
+	}
+	s.recommendationBatchSize = size
+	return s
+}
+
+// WithRecommendationTimeout bounds how long GenerateRecommendations waits for its
+// algorithms. Non-positive durations use the default.
+func (s *Service) WithRecommendationTimeout(timeout time.Duration) *Service {
+	if timeout <= 0 {
+		timeout = DefaultRecommendationTimeout
+	}
+	s.recommendationTimeout = timeout
+	return s
+}
+
+// WithTrendingRefreshWait controls what RefreshTrendingCache does while another
+// refresh holds the lock: wait for it to finish, or skip with ErrTrendingRefreshInProgress
+func (s *Service) WithTrendingRefreshWait(wait bool) *Service {
+	s.trendingRefreshWait = wait
+	return s
+}
+
+// WithTrendingFullRebuild makes RefreshTrendingCache replace the whole trending
+// table in one transaction instead of upserting one category at a time
+func (s *Service) WithTrendingFullRebuild(fullRebuild bool) *Service {
+	s.trendingFullRebuild = fullRebuild
+	return s
 }
 
 // WithLearningService adds learning service to the social service
@@ -40,53 +118,151 @@ func (s *Service) WithIdentityService(identityService IdentityService) *Service
 }
 
 // FollowUser creates follow relationship
-func (s *Service) FollowUser(followerID, followingID string) error {
+func (s *Service) FollowUser(ctx context.Context, followerID, followingID string) error {
 	// Validate not following self
 	if followerID == followingID {
 		return fmt.Errorf("cannot follow yourself")
 	}
 
-	// Create relationship
-	if err := s.repo.FollowUser(followerID, followingID); err != nil {
+	// Create relationship; repeating a follow records no new activity
+	created, err := s.repo.FollowUser(ctx, followerID, followingID)
+	if err != nil {
 		return fmt.Errorf("failed to follow user: %w", err)
 	}
 
-	// Create activity for the followed user
+	if created {
+		s.recordFollowActivity(ctx, followerID, followingID)
+	}
+
+	return nil
+}
+
+// ActivityUserFollowed is the activity type recorded when a user gains a follower
+const ActivityUserFollowed = "user_followed"
+
+// recordFollowActivity creates the activity telling followingID about a new follower
+func (s *Service) recordFollowActivity(ctx context.Context, followerID, followingID string) {
 	activity := &ActivityFeed{
 		UserID:        followerID,
-		ActivityType:  "user_followed",
+		ActivityType:  ActivityUserFollowed,
 		ReferenceType: "user",
 		ReferenceID:   followingID,
-		Visibility:    "private", // Only visible to the followed user
+		Visibility:    VisibilityPrivate, // Only shown in the followed user's feed
 		Metadata: map[string]interface{}{
 			"action": "new_follower",
 		},
 	}
 
 	// Ignore error if activity creation fails (non-critical)
-	_ = s.repo.CreateActivity(activity)
-
-	return nil
+	_ = s.createActivity(ctx, activity)
 }
 
 // UnfollowUser removes follow relationship
-func (s *Service) UnfollowUser(followerID, followingID string) error {
-	if err := s.repo.UnfollowUser(followerID, followingID); err != nil {
+func (s *Service) UnfollowUser(ctx context.Context, followerID, followingID string) error {
+	if err := s.repo.UnfollowUser(ctx, followerID, followingID); err != nil {
 		return fmt.Errorf("failed to unfollow user: %w", err)
 	}
 	return nil
 }
 
-// GetActivityFeed retrieves personalized activity feed
-func (s *Service) GetActivityFeed(userID string, limit int) ([]ActivityFeed, error) {
-	if limit <= 0 {
-		limit = 50 // Default limit
+// FeedRange limits the feed to activities created within [From, To]; a zero bound is open.
+// A non-zero Before continues an earlier page with the activity older than it.
+type FeedRange struct {
+	From   time.Time
+	To     time.Time
+	Before FeedCursor
+}
+
+// FeedCursor is the position of the last activity on a feed page
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ErrInvalidFeedCursor is returned for a cursor not produced by FeedCursor.Encode
+var ErrInvalidFeedCursor = errors.New("invalid feed cursor")
+
+// NewFeedCursor returns the cursor positioned at activity
+func NewFeedCursor(activity ActivityFeed) FeedCursor {
+	return FeedCursor{CreatedAt: activity.CreatedAt, ID: activity.ID}
+}
+
+// IsZero reports whether the cursor is unset
+func (c FeedCursor) IsZero() bool {
+	return c.ID == ""
+}
+
+// Encode returns the cursor as the opaque next_cursor string clients pass back
+func (c FeedCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+// ParseFeedCursor decodes a cursor produced by FeedCursor.Encode
+func ParseFeedCursor(value string) (FeedCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidFeedCursor
 	}
-	if limit > 200 {
-		limit = 200 // Max limit
+	createdAt, id, ok := strings.Cut(string(decoded), "|")
+	if !ok {
+		return FeedCursor{}, ErrInvalidFeedCursor
 	}
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidFeedCursor
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return FeedCursor{}, ErrInvalidFeedCursor
+	}
+	return FeedCursor{CreatedAt: parsed, ID: id}, nil
+}
+
+// ErrInvalidFeedRange is returned when a feed range bound is malformed or from is after to
+var ErrInvalidFeedRange = errors.New("invalid feed range")
+
+// feedDateLayout is the date-only form accepted for feed range bounds
+const feedDateLayout = "2006-01-02"
 
-	activities, err := s.repo.GetActivityFeed(userID, limit)
+// ParseFeedRange reads the from and to bounds of a feed query. Each accepts
+// RFC 3339 or a plain date; a plain date for to covers that whole day.
+func ParseFeedRange(from, to string) (FeedRange, error) {
+	var rng FeedRange
+	if from != "" {
+		parsed, _, err := parseFeedTime(from)
+		if err != nil {
+			return FeedRange{}, fmt.Errorf("%w: from must be RFC 3339 or YYYY-MM-DD", ErrInvalidFeedRange)
+		}
+		rng.From = parsed
+	}
+	if to != "" {
+		parsed, dateOnly, err := parseFeedTime(to)
+		if err != nil {
+			return FeedRange{}, fmt.Errorf("%w: to must be RFC 3339 or YYYY-MM-DD", ErrInvalidFeedRange)
+		}
+		if dateOnly {
+			parsed = parsed.Add(24*time.Hour - time.Nanosecond)
+		}
+		rng.To = parsed
+	}
+	if !rng.From.IsZero() && !rng.To.IsZero() && rng.From.After(rng.To) {
+		return FeedRange{}, fmt.Errorf("%w: from must not be after to", ErrInvalidFeedRange)
+	}
+	return rng, nil
+}
+
+// parseFeedTime parses an RFC 3339 timestamp or a UTC date and reports which it was
+func parseFeedTime(value string) (time.Time, bool, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, false, nil
+	}
+	parsed, err := time.Parse(feedDateLayout, value)
+	return parsed, true, err
+}
+
+// GetActivityFeed retrieves personalized activity feed, optionally limited to a date range.
+// includeOwn adds the user's own activity to that of the people they follow.
+func (s *Service) GetActivityFeed(ctx context.Context, userID string, limit int, rng FeedRange, includeOwn bool) ([]ActivityFeed, error) {
+	activities, err := s.repo.GetActivityFeed(ctx, userID, limit, rng, includeOwn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get activity feed: %w", err)
 	}
@@ -100,7 +276,7 @@ type UserService interface {
 }
 
 // BroadcastActivity creates activity for followers
-func (s *Service) BroadcastActivity(userID, activityType string, metadata map[string]interface{}) error {
+func (s *Service) BroadcastActivity(ctx context.Context, userID, activityType string, metadata map[string]interface{}) error {
 	// Determine visibility based on activity type and user preferences
 	// Default to friends visibility
 	visibility := "friends"
@@ -146,7 +322,7 @@ func (s *Service) BroadcastActivity(userID, activityType string, metadata map[st
 		}
 	}
 
-	if err := s.repo.CreateActivity(activity); err != nil {
+	if err := s.createActivity(ctx, activity); err != nil {
 		return fmt.Errorf("failed to broadcast activity: %w", err)
 	}
 
@@ -154,9 +330,16 @@ func (s *Service) BroadcastActivity(userID, activityType string, metadata map[st
 }
 
 // GetRecommendations retrieves personalized recommendations grouped by type
-func (s *Service) GetRecommendations(userID string) (map[string][]Recommendation, error) {
+func (s *Service) GetRecommendations(ctx context.Context, userID string) (map[string][]Recommendation, error) {
+	cacheKey := recommendationsCacheKey(userID, allRecommendationsKey)
+	if s.recommendationsCache != nil {
+		if grouped, ok := s.recommendationsCache.Get(cacheKey); ok {
+			return cloneRecommendations(grouped), nil
+		}
+	}
+
 	// Get all recommendations for user
-	allRecs, err := s.repo.GetRecommendations(userID, "all")
+	allRecs, err := s.repo.GetRecommendations(ctx, userID, "all")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recommendations: %w", err)
 	}
@@ -167,42 +350,243 @@ func (s *Service) GetRecommendations(userID string) (map[string][]Recommendation
 		grouped[rec.RecommendationType] = append(grouped[rec.RecommendationType], rec)
 	}
 
+	if s.recommendationsCache != nil {
+		s.recommendationsCache.Set(cacheKey, cloneRecommendations(grouped))
+	}
+
 	return grouped, nil
 }
 
-// GenerateRecommendations computes recommendations for user
-func (s *Service) GenerateRecommendations(userID string) error {
-	// Run all recommendation algorithms in parallel
-	// For simplicity, we'll run them sequentially here
+// GetRecommendationsByType retrieves a single recommendation row, keyed by its type
+// so the response has the same shape as GetRecommendations
+func (s *Service) GetRecommendationsByType(ctx context.Context, userID, recType string) (map[string][]Recommendation, error) {
+	if !IsValidRecommendationType(recType) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRecommendationType, recType)
+	}
 
-	// 1. Collaborative Filtering
-	if err := s.generateCollaborativeFilteringRecs(userID); err != nil {
-		// Log error but don't fail the entire operation
-		fmt.Printf("Collaborative filtering failed: %v\n", err)
+	cacheKey := recommendationsCacheKey(userID, recType)
+	if s.recommendationsCache != nil {
+		if row, ok := s.recommendationsCache.Get(cacheKey); ok {
+			return cloneRecommendations(row), nil
+		}
 	}
 
-	// 2. Skill Adjacency (courses that follow completed courses)
-	if err := s.generateSkillAdjacencyRecs(userID); err != nil {
-		fmt.Printf("Skill adjacency failed: %v\n", err)
+	recs, err := s.repo.GetRecommendations(ctx, userID, recType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recommendations: %w", err)
+	}
+	if recs == nil {
+		recs = []Recommendation{}
 	}
 
-	// 3. Social Signals (courses friends are taking)
-	if err := s.generateSocialSignalRecs(userID); err != nil {
-		fmt.Printf("Social signals failed: %v\n", err)
+	row := map[string][]Recommendation{recType: recs}
+	if s.recommendationsCache != nil {
+		s.recommendationsCache.Set(cacheKey, cloneRecommendations(row))
 	}
 
-	// 4. Add trending courses as recommendations
-	if err := s.generateTrendingRecs(userID); err != nil {
-		fmt.Printf("Trending recommendations failed: %v\n", err)
+	return row, nil
+}
+
+// DefaultRecommendationTimeout bounds how long GenerateRecommendations waits for its algorithms
+const DefaultRecommendationTimeout = 30 * time.Second
+
+// ErrRecommendationsIncomplete is returned when GenerateRecommendations stops waiting
+// before every algorithm finished. The remaining ones keep running and their results
+// are still saved.
+var ErrRecommendationsIncomplete = errors.New("recommendation generation is still running")
+
+// recommendationGenerator is one recommendation algorithm
+type recommendationGenerator struct {
+	recType  string
+	generate func(ctx context.Context, userID string) error
+}
+
+// recommendationGenerators returns every algorithm GenerateRecommendations runs
+func (s *Service) recommendationGenerators() []recommendationGenerator {
+	return []recommendationGenerator{
+		{RecTypeCollaborativeFiltering, s.generateCollaborativeFilteringRecs},
+		{RecTypeSkillAdjacency, s.generateSkillAdjacencyRecs},
+		{RecTypeSocialSignal, s.generateSocialSignalRecs},
+		{RecTypeTrending, s.generateTrendingRecs},
+	}
+}
+
+// GenerateRecommendations computes recommendations for user by running every
+// algorithm concurrently. Each algorithm only reads shared state and writes its
+// own recommendation type through the repository, whose *sql.DB is safe for
+// concurrent use. A failing algorithm is logged without affecting the others;
+// an error is returned only when all of them fail. If ctx ends or the
+// recommendation timeout passes first, ErrRecommendationsIncomplete is returned.
+func (s *Service) GenerateRecommendations(ctx context.Context, userID string) error {
+	// The algorithms outlive ctx when it times out so their results are still saved
+	generateCtx := context.WithoutCancel(ctx)
+	ctx, cancel := context.WithTimeout(ctx, s.recommendationTimeout)
+	defer cancel()
+
+	generators := s.recommendationGenerators()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for _, generator := range generators {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := generator.generate(generateCtx, userID); err != nil {
+				slog.Warn("recommendation algorithm failed", "type", generator.recType, "user_id", userID, "error", err)
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", generator.recType, err))
+				mu.Unlock()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		s.invalidateRecommendations(userID)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// Show whatever has been saved so far; the late algorithms invalidate again when done
+		s.invalidateRecommendations(userID)
+		return fmt.Errorf("%w: %v", ErrRecommendationsIncomplete, ctx.Err())
+	}
+
+	if len(errs) == len(generators) {
+		return fmt.Errorf("all recommendation algorithms failed: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Recommendation types, one per recommendation row
+const (
+	RecTypeCollaborativeFiltering = "collaborative_filtering"
+	RecTypeSkillAdjacency         = "skill_adjacency"
+	RecTypeSocialSignal           = "social_signal"
+	RecTypeTrending               = "trending"
+)
+
+// ErrInvalidRecommendationType is returned for unknown recommendation types
+var ErrInvalidRecommendationType = errors.New("invalid recommendation type")
+
+// IsValidRecommendationType reports whether recType is a known recommendation type
+func IsValidRecommendationType(recType string) bool {
+	switch recType {
+	case RecTypeCollaborativeFiltering, RecTypeSkillAdjacency, RecTypeSocialSignal, RecTypeTrending:
+		return true
+	}
+	return false
+}
+
+// Recommendation feedback types
+const (
+	FeedbackDismissed  = "dismissed"
+	FeedbackInterested = "interested"
+)
+
+// InterestedScoreBoost is added to the match score of courses the user marked interested
+const InterestedScoreBoost = 15
+
+// ErrInvalidFeedbackType is returned for unknown recommendation feedback types
+var ErrInvalidFeedbackType = errors.New("feedback type must be one of: dismissed, interested")
+
+// RecordRecommendationFeedback stores a dismissal or interest signal for a course.
+// Dismissed courses no longer appear in GetRecommendations; interested ones rank higher.
+func (s *Service) RecordRecommendationFeedback(ctx context.Context, userID, courseID, feedbackType string) (*RecommendationFeedback, error) {
+	if feedbackType != FeedbackDismissed && feedbackType != FeedbackInterested {
+		return nil, ErrInvalidFeedbackType
+	}
+
+	feedback := &RecommendationFeedback{
+		UserID:       userID,
+		CourseID:     courseID,
+		FeedbackType: feedbackType,
+	}
+	if err := s.repo.UpsertRecommendationFeedback(ctx, feedback); err != nil {
+		return nil, err
+	}
+	s.invalidateRecommendations(userID)
+
+	return feedback, nil
+}
+
+// MaxFeedbackBatchSize caps how many courses one batch of recommendation feedback may name
+const MaxFeedbackBatchSize = 100
+
+// ErrInvalidFeedbackBatch is returned for an empty or oversized feedback batch, or one with a malformed course ID
+var ErrInvalidFeedbackBatch = errors.New("invalid recommendation feedback batch")
+
+// RecordRecommendationFeedbackBatch stores the same feedback for every listed
+// course in one insert and returns the courses it was recorded for. Unknown and
+// deleted courses are skipped.
+func (s *Service) RecordRecommendationFeedbackBatch(ctx context.Context, userID string, courseIDs []string, feedbackType string) ([]string, error) {
+	if feedbackType != FeedbackDismissed && feedbackType != FeedbackInterested {
+		return nil, ErrInvalidFeedbackType
+	}
+	if len(courseIDs) == 0 {
+		return nil, fmt.Errorf("%w: course_ids must not be empty", ErrInvalidFeedbackBatch)
+	}
+	if len(courseIDs) > MaxFeedbackBatchSize {
+		return nil, fmt.Errorf("%w: at most %d course_ids are allowed", ErrInvalidFeedbackBatch, MaxFeedbackBatchSize)
+	}
+	for _, id := range courseIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid course ID", ErrInvalidFeedbackBatch, id)
+		}
+	}
+
+	recorded, err := s.repo.UpsertRecommendationFeedbackBatch(ctx, userID, courseIDs, feedbackType)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateRecommendations(userID)
+
+	return recorded, nil
+}
+
+// RefreshRecommendationsByType regenerates a single recommendation type for the user,
+// leaving the other types untouched. New rows are upserted first and only rows the
+// refresh did not rewrite are removed afterwards, so a failed generation keeps the
+// previous recommendations instead of leaving the type empty.
+func (s *Service) RefreshRecommendationsByType(ctx context.Context, userID, recType string) error {
+	if !IsValidRecommendationType(recType) {
+		return fmt.Errorf("%w: %s", ErrInvalidRecommendationType, recType)
+	}
+	defer s.invalidateRecommendations(userID)
+
+	// Stored timestamps have microsecond precision
+	refreshStart := time.Now().Truncate(time.Microsecond)
+
+	var err error
+	switch recType {
+	case RecTypeCollaborativeFiltering:
+		err = s.generateCollaborativeFilteringRecs(ctx, userID)
+	case RecTypeSkillAdjacency:
+		err = s.generateSkillAdjacencyRecs(ctx, userID)
+	case RecTypeSocialSignal:
+		err = s.generateSocialSignalRecs(ctx, userID)
+	case RecTypeTrending:
+		err = s.generateTrendingRecs(ctx, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate %s recommendations: %w", recType, err)
+	}
+
+	if err := s.repo.DeleteStaleRecommendationsByType(ctx, userID, recType, refreshStart); err != nil {
+		return fmt.Errorf("failed to clear stale recommendations: %w", err)
 	}
 
 	return nil
 }
 
 // generateCollaborativeFilteringRecs finds users with 80%+ course overlap
-func (s *Service) generateCollaborativeFilteringRecs(userID string) error {
+func (s *Service) generateCollaborativeFilteringRecs(ctx context.Context, userID string) error {
 	// Find similar users (80% course overlap)
-	similarUsers, err := s.repo.GetCollaborativeFilteringCandidates(userID, 0.8)
+	similarUsers, err := s.repo.GetCollaborativeFilteringCandidates(ctx, userID, 0.8)
 	if err != nil {
 		return fmt.Errorf("failed to find similar users: %w", err)
 	}
@@ -212,13 +596,14 @@ func (s *Service) generateCollaborativeFilteringRecs(userID string) error {
 	}
 
 	// Get courses completed by similar users
-	courseIDs, err := s.repo.GetCoursesCompletedByUsers(similarUsers, userID)
+	courseIDs, err := s.repo.GetCoursesCompletedByUsers(ctx, similarUsers, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get courses: %w", err)
 	}
 
 	// Create recommendations
 	expiresAt := time.Now().Add(7 * 24 * time.Hour) // Expire in 7 days
+	recs := make([]*Recommendation, 0, min(len(courseIDs), 20))
 	for i, courseID := range courseIDs {
 		if i >= 20 {
 			break // Limit to top 20
@@ -227,7 +612,7 @@ func (s *Service) generateCollaborativeFilteringRecs(userID string) error {
 		rec := &Recommendation{
 			UserID:             userID,
 			CourseID:           courseID,
-			RecommendationType: "collaborative_filtering",
+			RecommendationType: RecTypeCollaborativeFiltering,
 			MatchScore:         90 - i, // Decreasing score
 			Reason:             "Users with similar progress completed this",
 			Metadata: map[string]interface{}{
@@ -236,67 +621,30 @@ func (s *Service) generateCollaborativeFilteringRecs(userID string) error {
 			ExpiresAt: &expiresAt,
 		}
 
-		if err := s.repo.CreateRecommendation(rec); err != nil {
-			fmt.Printf("Failed to create recommendation: %v\n", err)
-		}
+		recs = append(recs, rec)
 	}
 
-	return nil
+	return s.saveRecommendations(ctx, recs)
 }
 
-// SkillGraph defines skill progression paths
-var SkillGraph = map[string][]string{
-	// Digital Systems
-	"basics":           {"intermediate", "algorithms", "data_structures"},
-	"algorithms":       {"advanced_algorithms", "optimization", "distributed_systems"},
-	"data_structures":  {"advanced_data_structures", "database_design"},
-	"web_development":  {"backend_development", "frontend_frameworks", "full_stack"},
-	"backend":          {"microservices", "distributed_systems", "scalability"},
-	"frontend":         {"ui_design", "performance_optimization", "accessibility"},
-
-	// Economic Systems
-	"trading_basics":   {"technical_analysis", "risk_management", "portfolio_theory"},
-	"risk_management":  {"derivatives", "hedging_strategies", "quantitative_finance"},
-	"market_mechanics": {"market_microstructure", "algorithmic_trading", "hft"},
-
-	// Cognitive Systems
-	"ml_basics":        {"supervised_learning", "unsupervised_learning", "deep_learning"},
-	"deep_learning":    {"computer_vision", "nlp", "reinforcement_learning"},
-	"neural_networks":  {"advanced_architectures", "optimization_techniques"},
-
-	// Aesthetic Systems
-	"design_basics":    {"ui_design", "ux_design", "design_systems"},
-	"ui_design":        {"advanced_layouts", "animation", "accessibility"},
-
-	// Biological Systems
-	"biology_basics":   {"molecular_biology", "genetics", "bioinformatics"},
-	"genetics":         {"genomics", "gene_editing", "synthetic_biology"},
+// saveRecommendations writes generated recommendations in batches of recommendationBatchSize.
+// Batches are not wrapped in a transaction: when one fails, earlier batches stay written
+// and later ones are skipped. That is safe because every row is an upsert and callers
+// regenerate the full set on the next refresh.
+func (s *Service) saveRecommendations(ctx context.Context, recs []*Recommendation) error {
+	for start := 0; start < len(recs); start += s.recommendationBatchSize {
+		end := min(start+s.recommendationBatchSize, len(recs))
+		if err := s.repo.CreateRecommendations(ctx, recs[start:end]); err != nil {
+			return fmt.Errorf("failed to save recommendations: %w", err)
+		}
+	}
+	return nil
 }
 
 // generateSkillAdjacencyRecs recommends next logical courses
-func (s *Service) generateSkillAdjacencyRecs(userID string) error {
-	// Get user's completed courses (simplified - in production, query from learning domain)
-	// For now, we'll create recommendations based on meta_category matching
-
-	// This is a functional implementation of skill graph adjacency
-	// In production, this would:
-	// 1. Query completed courses for user
-	// 2. Extract skills/tags from those courses
-	// 3. Look up SkillGraph for adjacent skills
-	// 4. Find courses that teach those adjacent skills
-	// 5. Create recommendations
-
-	// For now, create placeholder recommendations
-	// In a real system, you'd query the learning domain for:
-	// - User's completed courses
-	// - Extract course tags/skills
-	// - Match against skill graph
-	// - Find courses with adjacent skills
-
-	expiresAt := time.Now().Add(7 * 24 * time.Hour)
-
-	// Example: If user completed "basics", recommend "intermediate" level courses
-	// This would be populated by actual course data in production
+// Until courses carry skill tags, other learners' courses in the meta categories the
+// user already studies stand in for the adjacent skills
+func (s *Service) generateSkillAdjacencyRecs(ctx context.Context, userID string) error {
 	skillBasedRecs := []struct {
 		reason string
 		score  int
@@ -306,16 +654,19 @@ func (s *Service) generateSkillAdjacencyRecs(userID string) error {
 		{"Advanced techniques in your domain", 82},
 	}
 
-	for i, recData := range skillBasedRecs {
-		if i >= 10 {
-			break
-		}
+	courseIDs, err := s.repo.GetCoursesInUserCategories(ctx, userID, len(skillBasedRecs))
+	if err != nil {
+		return fmt.Errorf("failed to find adjacent courses: %w", err)
+	}
 
-		// In production, this courseID would come from actual skill graph lookup
+	expiresAt := time.Now().Add(7 * 24 * time.Hour)
+	recs := make([]*Recommendation, 0, len(courseIDs))
+	for i, courseID := range courseIDs {
+		recData := skillBasedRecs[i]
 		rec := &Recommendation{
 			UserID:             userID,
-			CourseID:           fmt.Sprintf("skill-adjacent-%d", i),
-			RecommendationType: "skill_adjacency",
+			CourseID:           courseID,
+			RecommendationType: RecTypeSkillAdjacency,
 			MatchScore:         recData.score,
 			Reason:             recData.reason,
 			Metadata: map[string]interface{}{
@@ -325,18 +676,16 @@ func (s *Service) generateSkillAdjacencyRecs(userID string) error {
 			ExpiresAt: &expiresAt,
 		}
 
-		if err := s.repo.CreateRecommendation(rec); err != nil {
-			fmt.Printf("Failed to create skill adjacency recommendation: %v\n", err)
-		}
+		recs = append(recs, rec)
 	}
 
-	return nil
+	return s.saveRecommendations(ctx, recs)
 }
 
 // generateSocialSignalRecs recommends courses that 3+ friends are taking
-func (s *Service) generateSocialSignalRecs(userID string) error {
+func (s *Service) generateSocialSignalRecs(ctx context.Context, userID string) error {
 	// Get list of users that current user follows
-	following, err := s.repo.GetFollowing(userID)
+	following, err := s.repo.GetFollowing(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get following: %w", err)
 	}
@@ -346,13 +695,14 @@ func (s *Service) generateSocialSignalRecs(userID string) error {
 	}
 
 	// Get courses that friends are taking (exclude user's courses)
-	courseIDs, err := s.repo.GetCoursesCompletedByUsers(following, userID)
+	courseIDs, err := s.repo.GetCoursesCompletedByUsers(ctx, following, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get friend courses: %w", err)
 	}
 
 	// Create recommendations
 	expiresAt := time.Now().Add(3 * 24 * time.Hour) // Expire in 3 days
+	recs := make([]*Recommendation, 0, min(len(courseIDs), 15))
 	for i, courseID := range courseIDs {
 		if i >= 15 {
 			break // Limit to top 15
@@ -361,7 +711,7 @@ func (s *Service) generateSocialSignalRecs(userID string) error {
 		rec := &Recommendation{
 			UserID:             userID,
 			CourseID:           courseID,
-			RecommendationType: "social_signal",
+			RecommendationType: RecTypeSocialSignal,
 			MatchScore:         85 - i,
 			Reason:             "Friends are learning this",
 			Metadata: map[string]interface{}{
@@ -370,28 +720,27 @@ func (s *Service) generateSocialSignalRecs(userID string) error {
 			ExpiresAt: &expiresAt,
 		}
 
-		if err := s.repo.CreateRecommendation(rec); err != nil {
-			fmt.Printf("Failed to create recommendation: %v\n", err)
-		}
+		recs = append(recs, rec)
 	}
 
-	return nil
+	return s.saveRecommendations(ctx, recs)
 }
 
 // generateTrendingRecs adds trending courses as recommendations
-func (s *Service) generateTrendingRecs(userID string) error {
-	trending, err := s.repo.GetTrendingCourses(10)
+func (s *Service) generateTrendingRecs(ctx context.Context, userID string) error {
+	trending, err := s.repo.GetTrendingCourses(ctx, 10, "")
 	if err != nil {
 		return fmt.Errorf("failed to get trending: %w", err)
 	}
 
 	expiresAt := time.Now().Add(24 * time.Hour) // Expire in 24 hours
+	recs := make([]*Recommendation, 0, len(trending))
 	for _, course := range trending {
 		rec := &Recommendation{
 			UserID:             userID,
 			CourseID:           course.CourseID,
-			RecommendationType: "trending",
-			MatchScore:         int(course.Velocity * 10), // Convert velocity to score
+			RecommendationType: RecTypeTrending,
+			MatchScore:         s.trendingPolicy.MatchScore(course.Velocity),
 			Reason:             fmt.Sprintf("Trending with %.1fx velocity", course.Velocity),
 			Metadata: map[string]interface{}{
 				"velocity":    course.Velocity,
@@ -401,39 +750,161 @@ func (s *Service) generateTrendingRecs(userID string) error {
 			ExpiresAt: &expiresAt,
 		}
 
-		if err := s.repo.CreateRecommendation(rec); err != nil {
-			fmt.Printf("Failed to create trending recommendation: %v\n", err)
+		recs = append(recs, rec)
+	}
+
+	return s.saveRecommendations(ctx, recs)
+}
+
+// Trending list sizes
+const (
+	TrendingListSize        = 50 // Courses in the overall and single-category lists
+	TrendingPerCategorySize = 10 // Courses per category in the grouped list
+)
+
+// TrendingCategories are the course meta-categories trending can be filtered by
+// (generated_courses.meta_category CHECK)
+var TrendingCategories = []string{"Digital", "Economic", "Aesthetic", "Biological", "Cognitive"}
+
+// GetTrendingCourses retrieves trending courses, optionally only those of one
+// meta-category, from cache. The category is matched case-insensitively and an
+// unknown one is rejected with a validation.FieldError.
+func (s *Service) GetTrendingCourses(ctx context.Context, category string) ([]TrendingCourse, error) {
+	if category != "" {
+		var err error
+		if category, err = validation.OneOf("category", category, TrendingCategories); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return s.cachedTrending(trendingCategoryCacheKey(category), func() ([]TrendingCourse, error) {
+		return s.repo.GetTrendingCourses(ctx, TrendingListSize, category)
+	})
 }
 
-// GetTrendingCourses retrieves trending courses from cache
-func (s *Service) GetTrendingCourses() ([]TrendingCourse, error) {
-	courses, err := s.repo.GetTrendingCourses(50)
+// GetTrendingByCategory retrieves the top trending courses of each meta-category.
+// Every category is present, with an empty list when nothing in it is trending.
+func (s *Service) GetTrendingByCategory(ctx context.Context) (map[string][]TrendingCourse, error) {
+	courses, err := s.cachedTrending(trendingGroupedCacheKey, func() ([]TrendingCourse, error) {
+		return s.repo.GetTrendingCoursesPerCategory(ctx, TrendingPerCategorySize)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[string][]TrendingCourse, len(TrendingCategories))
+	for _, category := range TrendingCategories {
+		grouped[category] = []TrendingCourse{}
+	}
+	for _, course := range courses {
+		grouped[course.MetaCategory] = append(grouped[course.MetaCategory], course)
+	}
+	return grouped, nil
+}
+
+// cachedTrending returns the trending list stored under key, loading it on a miss
+func (s *Service) cachedTrending(key string, load func() ([]TrendingCourse, error)) ([]TrendingCourse, error) {
+	if s.trendingCache != nil {
+		if courses, ok := s.trendingCache.Get(key); ok {
+			return cloneTrending(courses), nil
+		}
+	}
+
+	courses, err := load()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trending courses: %w", err)
 	}
+
+	if s.trendingCache != nil {
+		s.trendingCache.Set(key, cloneTrending(courses))
+	}
 	return courses, nil
 }
 
-// RefreshTrendingCache updates trending courses cache
-func (s *Service) RefreshTrendingCache() error {
-	// Calculate velocity for all courses
-	courses, err := s.repo.CalculateTrendingVelocity()
+// RefreshTrendingCache recomputes the trending courses. Each meta-category is
+// recomputed and upserted in its own transaction, so a failure in one leaves the
+// others as they were; with WithTrendingFullRebuild the whole table is instead
+// rebuilt at once with a bulk COPY.
+// Only one refresh runs at a time across instances; see WithTrendingRefreshWait.
+func (s *Service) RefreshTrendingCache(ctx context.Context) error {
+	if s.trendingFullRebuild {
+		return s.rebuildTrending(ctx)
+	}
+
+	var errs []error
+	updated := false
+	for _, category := range TrendingCategories {
+		err := s.refreshTrendingCategory(ctx, category)
+		if errors.Is(err, ErrTrendingRefreshInProgress) {
+			// Another refresh is covering the remaining categories
+			errs = append(errs, err)
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		updated = true
+	}
+
+	if updated {
+		s.purgeTrendingCache()
+	}
+	return errors.Join(errs...)
+}
+
+// RefreshTrendingCategory recomputes the trending courses of one meta-category,
+// leaving the others untouched. The category is matched case-insensitively and
+// an unknown one is rejected with a validation.FieldError.
+func (s *Service) RefreshTrendingCategory(ctx context.Context, category string) error {
+	category, err := validation.OneOf("category", category, TrendingCategories)
+	if err != nil {
+		return err
+	}
+
+	if err := s.refreshTrendingCategory(ctx, category); err != nil {
+		return err
+	}
+	s.purgeTrendingCache()
+	return nil
+}
+
+// refreshTrendingCategory recomputes and upserts the trending courses of category
+func (s *Service) refreshTrendingCategory(ctx context.Context, category string) error {
+	courses, err := s.repo.CalculateTrendingVelocity(ctx, s.trendingPolicy, []string{category})
+	if err != nil {
+		return fmt.Errorf("failed to calculate %s velocity: %w", category, err)
+	}
+
+	if err := s.repo.UpsertTrendingCategory(ctx, category, courses, s.trendingRefreshWait); err != nil {
+		return fmt.Errorf("failed to update %s trending: %w", category, err)
+	}
+	return nil
+}
+
+// rebuildTrending recomputes every trending category and replaces the whole trending table
+func (s *Service) rebuildTrending(ctx context.Context) error {
+	courses, err := s.repo.CalculateTrendingVelocity(ctx, s.trendingPolicy, TrendingCategories)
 	if err != nil {
 		return fmt.Errorf("failed to calculate velocity: %w", err)
 	}
 
 	// Update cache with new trending data
-	if err := s.repo.UpdateTrendingCourses(courses); err != nil {
+	if err := s.repo.UpdateTrendingCourses(ctx, courses, s.trendingRefreshWait); err != nil {
 		return fmt.Errorf("failed to update trending cache: %w", err)
 	}
 
+	s.purgeTrendingCache()
 	return nil
 }
 
+// purgeTrendingCache drops cached trending lists after the table changed
+func (s *Service) purgeTrendingCache() {
+	if s.trendingCache != nil {
+		s.trendingCache.Purge()
+	}
+}
+
 // AchievementChecker defines interface for checking user progress
 type AchievementChecker interface {
 	GetUserStats(userID string) (*UserStats, error)
@@ -450,10 +921,47 @@ type UserStats struct {
 	TotalTimeSpentHours  int
 }
 
-// CheckAchievements checks if user unlocked new achievements
-func (s *Service) CheckAchievements(userID string) ([]Achievement, error) {
+// Achievement page sizes
+const (
+	DefaultAchievementPageSize = 20
+	MaxAchievementPageSize     = 100
+)
+
+// AchievementRarities are the rarities an achievement can have (achievements.rarity CHECK)
+var AchievementRarities = []string{"common", "rare", "epic", "legendary"}
+
+// ErrInvalidAchievementRarity is returned when achievements are filtered by an unknown rarity
+var ErrInvalidAchievementRarity = errors.New("rarity must be one of: common, rare, epic, legendary")
+
+// ListAchievements returns one page of the achievements userID has earned, newest
+// first, and how many they have earned in total. An empty rarity matches every
+// rarity. Listing never unlocks anything; see CheckAchievements.
+func (s *Service) ListAchievements(ctx context.Context, userID, rarity string, limit, offset int) ([]Achievement, int, error) {
+	if rarity != "" && !slices.Contains(AchievementRarities, rarity) {
+		return nil, 0, ErrInvalidAchievementRarity
+	}
+
+	achievements, err := s.repo.ListUserAchievements(ctx, userID, rarity, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get achievements: %w", err)
+	}
+
+	total, err := s.repo.CountUserAchievements(ctx, userID, rarity)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get achievements: %w", err)
+	}
+
+	if achievements == nil {
+		achievements = []Achievement{}
+	}
+	return achievements, total, nil
+}
+
+// CheckAchievements evaluates the achievement criteria against the user's
+// progress, unlocks any newly met achievements and returns only those
+func (s *Service) CheckAchievements(ctx context.Context, userID string) ([]Achievement, error) {
 	// Get existing achievements
-	existingAchievements, err := s.repo.GetUserAchievements(userID)
+	existingAchievements, err := s.repo.GetUserAchievements(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get achievements: %w", err)
 	}
@@ -464,84 +972,15 @@ func (s *Service) CheckAchievements(userID string) ([]Achievement, error) {
 		unlockedMap[ach.ID] = true
 	}
 
-	// Define achievement criteria
-	achievementDefinitions := []struct {
-		id          string
-		name        string
-		description string
-		rarity      string
-		check       func(*UserStats) bool
-	}{
-		{
-			id:          "first_module",
-			name:        "First Steps",
-			description: "Complete your first module",
-			rarity:      "common",
-			check:       func(stats *UserStats) bool { return stats.ModulesCompleted >= 1 },
-		},
-		{
-			id:          "course_completed",
-			name:        "Course Master",
-			description: "Complete your first course",
-			rarity:      "common",
-			check:       func(stats *UserStats) bool { return stats.CoursesCompleted >= 1 },
-		},
-		{
-			id:          "perfect_score",
-			name:        "Perfectionist",
-			description: "Achieve a perfect score on an exercise",
-			rarity:      "rare",
-			check:       func(stats *UserStats) bool { return stats.PerfectScores >= 1 },
-		},
-		{
-			id:          "ten_exercises",
-			name:        "Problem Solver",
-			description: "Solve 10 exercises",
-			rarity:      "common",
-			check:       func(stats *UserStats) bool { return stats.ExercisesSolved >= 10 },
-		},
-		{
-			id:          "fifty_exercises",
-			name:        "Code Warrior",
-			description: "Solve 50 exercises",
-			rarity:      "rare",
-			check:       func(stats *UserStats) bool { return stats.ExercisesSolved >= 50 },
-		},
-		{
-			id:          "hundred_exercises",
-			name:        "Code Legend",
-			description: "Solve 100 exercises",
-			rarity:      "epic",
-			check:       func(stats *UserStats) bool { return stats.ExercisesSolved >= 100 },
-		},
-		{
-			id:          "week_streak",
-			name:        "Consistent Learner",
-			description: "Learn for 7 consecutive days",
-			rarity:      "rare",
-			check:       func(stats *UserStats) bool { return stats.ConsecutiveDays >= 7 },
-		},
-		{
-			id:          "three_courses",
-			name:        "Polymath",
-			description: "Complete 3 different courses",
-			rarity:      "epic",
-			check:       func(stats *UserStats) bool { return stats.CoursesCompleted >= 3 },
-		},
-		{
-			id:          "high_reviewer",
-			name:        "Architecture Expert",
-			description: "Maintain 90+ average review score",
-			rarity:      "epic",
-			check:       func(stats *UserStats) bool { return stats.ReviewScoresAvg >= 90 },
-		},
-		{
-			id:          "dedicated",
-			name:        "Dedicated Student",
-			description: "Spend 100+ hours learning",
-			rarity:      "legendary",
-			check:       func(stats *UserStats) bool { return stats.TotalTimeSpentHours >= 100 },
-		},
+	// Definitions live in the achievements table so they can change without a deploy
+	definitions, err := s.repo.GetAchievementDefinitions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievement definitions: %w", err)
+	}
+
+	totalSeconds, err := s.repo.GetTotalTimeSpentSeconds(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time spent: %w", err)
 	}
 
 	// Mock user stats (in production, query from learning domain)
@@ -552,53 +991,50 @@ func (s *Service) CheckAchievements(userID string) ([]Achievement, error) {
 		PerfectScores:       0,
 		ReviewScoresAvg:     0,
 		ConsecutiveDays:     0,
-		TotalTimeSpentHours: 0,
+		TotalTimeSpentHours: totalSeconds / 3600,
 	}
 
 	// Check each achievement
 	newlyUnlocked := []Achievement{}
-	for _, def := range achievementDefinitions {
+	for _, def := range definitions {
 		// Skip if already unlocked
-		if unlockedMap[def.id] {
+		if unlockedMap[def.ID] {
+			continue
+		}
+
+		criteria, err := ParseAchievementCriteria(def.Criteria)
+		if err != nil {
+			slog.Warn("skipping achievement with invalid criteria", "achievement_id", def.ID, "error", err)
 			continue
 		}
 
 		// Check if criteria met
-		if def.check(userStats) {
+		if criteria.Met(userStats) {
 			// Unlock achievement
-			if err := s.repo.UnlockAchievement(userID, def.id); err == nil {
-				newAchievement := Achievement{
-					ID:          def.id,
-					Name:        def.name,
-					Description: def.description,
-					Rarity:      def.rarity,
-					CreatedAt:   time.Now(),
-				}
-				newlyUnlocked = append(newlyUnlocked, newAchievement)
+			if err := s.repo.UnlockAchievement(ctx, userID, def.ID); err == nil {
+				newlyUnlocked = append(newlyUnlocked, def)
 
 				// Broadcast achievement unlock
-				_ = s.BroadcastActivity(userID, "achievement_earned", map[string]interface{}{
-					"achievement_id":   def.id,
-					"achievement_name": def.name,
-					"rarity":           def.rarity,
+				_ = s.BroadcastActivity(ctx, userID, "achievement_earned", map[string]interface{}{
+					"achievement_id":   def.ID,
+					"achievement_name": def.Name,
+					"rarity":           def.Rarity,
 				})
 			}
 		}
 	}
 
-	// Return all achievements (existing + newly unlocked)
-	allAchievements := append(existingAchievements, newlyUnlocked...)
-	return allAchievements, nil
+	return newlyUnlocked, nil
 }
 
 // UnlockAchievement manually unlocks an achievement
-func (s *Service) UnlockAchievement(userID, achievementID string) error {
-	if err := s.repo.UnlockAchievement(userID, achievementID); err != nil {
+func (s *Service) UnlockAchievement(ctx context.Context, userID, achievementID string) error {
+	if err := s.repo.UnlockAchievement(ctx, userID, achievementID); err != nil {
 		return fmt.Errorf("failed to unlock achievement: %w", err)
 	}
 
 	// Broadcast achievement unlock to followers
-	_ = s.BroadcastActivity(userID, "achievement_earned", map[string]interface{}{
+	_ = s.BroadcastActivity(ctx, userID, "achievement_earned", map[string]interface{}{
 		"achievement_id": achievementID,
 	})
 
@@ -606,8 +1042,8 @@ func (s *Service) UnlockAchievement(userID, achievementID string) error {
 }
 
 // GetFollowers retrieves user's followers
-func (s *Service) GetFollowers(userID string) ([]string, error) {
-	followers, err := s.repo.GetFollowers(userID)
+func (s *Service) GetFollowers(ctx context.Context, userID string) ([]string, error) {
+	followers, err := s.repo.GetFollowers(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get followers: %w", err)
 	}
@@ -615,14 +1051,57 @@ func (s *Service) GetFollowers(userID string) ([]string, error) {
 }
 
 // GetFollowing retrieves users that user follows
-func (s *Service) GetFollowing(userID string) ([]string, error) {
-	following, err := s.repo.GetFollowing(userID)
+func (s *Service) GetFollowing(ctx context.Context, userID string) ([]string, error) {
+	following, err := s.repo.GetFollowing(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get following: %w", err)
 	}
 	return following, nil
 }
 
+// Follow list page sizes for expanded (profile) listings
+const (
+	DefaultFollowPageSize = 50
+	MaxFollowPageSize     = 200
+)
+
+// ListFollowers returns one page of userID's followers with their profiles,
+// most recent first, and how many followers they have in total
+func (s *Service) ListFollowers(ctx context.Context, userID string, limit, offset int) ([]FollowProfile, int, error) {
+	profiles, err := s.repo.GetFollowerProfiles(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get followers: %w", err)
+	}
+	total, err := s.repo.CountFollowers(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get followers: %w", err)
+	}
+	return profiles, total, nil
+}
+
+// ListFollowing returns one page of the users userID follows with their
+// profiles, most recently followed first, and how many they follow in total
+func (s *Service) ListFollowing(ctx context.Context, userID string, limit, offset int) ([]FollowProfile, int, error) {
+	profiles, err := s.repo.GetFollowingProfiles(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get following: %w", err)
+	}
+	total, err := s.repo.CountFollowing(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get following: %w", err)
+	}
+	return profiles, total, nil
+}
+
+// GetFriends retrieves the users who follow userID and are followed back
+func (s *Service) GetFriends(ctx context.Context, userID string) ([]string, error) {
+	friends, err := s.repo.GetMutualFollows(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friends: %w", err)
+	}
+	return friends, nil
+}
+
 // UserProfileData represents aggregated user profile data
 type UserProfileData struct {
 	UserID           string        `json:"user_id"`
@@ -632,31 +1111,81 @@ type UserProfileData struct {
 	CompletedCourses []interface{} `json:"completed_courses"`
 	CurrentArchetype interface{}   `json:"current_archetype"`
 	SkillLevel       string        `json:"skill_level"`
+	TotalPoints      int           `json:"total_points"`
+	Redacted         bool          `json:"redacted"` // Some sections were hidden by the owner's privacy settings
 }
 
-// GetUserProfileData retrieves complete user profile with data from all domains
-func (s *Service) GetUserProfileData(userID string) (*UserProfileData, error) {
-	// Get achievements
-	achievements, err := s.CheckAchievements(userID)
+// GetUserProfileData retrieves complete user profile with data from all domains,
+// redacted according to userID's privacy settings and how viewerID relates to them
+func (s *Service) GetUserProfileData(ctx context.Context, viewerID, userID string) (*UserProfileData, error) {
+	relationship, err := s.ResolveViewerRelationship(ctx, viewerID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get achievements: %w", err)
+		return nil, err
+	}
+	privacy, err := s.profilePrivacy(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A hidden profile reveals nothing beyond its ID
+	if !visibleTo(privacy.ProfileVisibility, relationship) {
+		return &UserProfileData{
+			UserID:           userID,
+			Achievements:     []Achievement{},
+			CompletedCourses: []interface{}{},
+			Redacted:         true,
+		}, nil
 	}
 
 	// Get followers and following
-	followers, err := s.GetFollowers(userID)
+	followers, err := s.GetFollowers(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get followers: %w", err)
 	}
 
-	following, err := s.GetFollowing(userID)
+	following, err := s.GetFollowing(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get following: %w", err)
 	}
 
+	// Get current archetype from identity domain
+	var currentArchetype interface{}
+	if s.identityService != nil {
+		archetype, err := s.identityService.GetArchetype(userID)
+		if err == nil {
+			currentArchetype = archetype
+		} else {
+			fmt.Printf("Warning: Failed to get archetype: %v\n", err)
+		}
+	}
+
+	profile := &UserProfileData{
+		UserID:           userID,
+		Achievements:     []Achievement{},
+		FollowersCount:   len(followers),
+		FollowingCount:   len(following),
+		CompletedCourses: []interface{}{},
+		CurrentArchetype: currentArchetype,
+	}
+
+	// Achievements, courses, points and skill level make up the user's progress
+	if !visibleTo(privacy.ProgressVisibility, relationship) {
+		profile.Redacted = true
+		return profile, nil
+	}
+
+	achievements, err := s.repo.GetUserAchievements(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	if achievements != nil {
+		profile.Achievements = achievements
+	}
+
 	// Get completed courses from learning domain
 	var completedCourses []interface{}
 	if s.learningService != nil {
-		courses, err := s.learningService.GetUserCoursesInterface(userID)
+		courses, err := s.learningService.GetUserCoursesInterface(ctx, userID)
 		if err == nil {
 			completedCourses = courses
 		} else {
@@ -667,14 +1196,12 @@ func (s *Service) GetUserProfileData(userID string) (*UserProfileData, error) {
 		completedCourses = []interface{}{}
 	}
 
-	// Get current archetype from identity domain
-	var currentArchetype interface{}
-	if s.identityService != nil {
-		archetype, err := s.identityService.GetArchetype(userID)
+	if s.learningService != nil {
+		points, err := s.learningService.GetUserPoints(ctx, userID)
 		if err == nil {
-			currentArchetype = archetype
+			profile.TotalPoints = points
 		} else {
-			fmt.Printf("Warning: Failed to get archetype: %v\n", err)
+			slog.Warn("failed to get user points", "user_id", userID, "error", err)
 		}
 	}
 
@@ -686,14 +1213,13 @@ func (s *Service) GetUserProfileData(userID string) (*UserProfileData, error) {
 	} else if courseCount >= 2 {
 		skillLevel = "intermediate"
 	}
+	profile.SkillLevel = skillLevel
 
-	return &UserProfileData{
-		UserID:           userID,
-		Achievements:     achievements,
-		FollowersCount:   len(followers),
-		FollowingCount:   len(following),
-		CompletedCourses: completedCourses,
-		CurrentArchetype: currentArchetype,
-		SkillLevel:       skillLevel,
-	}, nil
+	if relationship == RelationshipSelf || privacy.ShowCompletedCourses {
+		profile.CompletedCourses = completedCourses
+	} else {
+		profile.Redacted = true
+	}
+
+	return profile, nil
 }
@@ -0,0 +1,861 @@
+package social
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/platform/cache"
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockService returns a service backed by a sqlmock database
+func newMockService(t testing.TB) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewService(NewRepository(db)), mock
+}
+
+// createdRecommendationRows returns the RETURNING columns of CreateRecommendations
+func createdRecommendationRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "course_id", "recommendation_type"})
+}
+
+// trendingRows returns n trending courses as returned by GetTrendingCourses
+func trendingRows(n int) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "course_id", "velocity", "signups_24h", "signups_previous_24h", "rank", "meta_category", "calculated_at",
+	})
+	for i := 0; i < n; i++ {
+		rows.AddRow(fmt.Sprintf("t-%d", i), fmt.Sprintf("course-%d", i), 2.0, 10, 5, i+1, "Digital", time.Now())
+	}
+	return rows
+}
+
+func TestRefreshRecommendationsByType_OnlyTargetedType(t *testing.T) {
+	service, mock := newMockService(t)
+	userID := "user-1"
+
+	mock.ExpectQuery(`FROM trending_courses`).
+		WithArgs(10, "").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "course_id", "velocity", "signups_24h", "signups_previous_24h", "rank", "meta_category", "calculated_at",
+		}).AddRow("t-1", "course-1", 2.5, 10, 4, 1, "Digital", time.Now()))
+
+	// And only trending recommendations are written back
+	mock.ExpectQuery(`INSERT INTO recommendations`).
+		WithArgs(userID, "course-1", RecTypeTrending, 25, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(createdRecommendationRows().AddRow("rec-1", userID, "course-1", RecTypeTrending))
+
+	// Then only stale trending recommendations are cleared
+	mock.ExpectExec(`DELETE FROM recommendations\s+WHERE user_id = \$1 AND recommendation_type = \$2 AND created_at < \$3`).
+		WithArgs(userID, RecTypeTrending, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	err := service.RefreshRecommendationsByType(context.Background(), userID, RecTypeTrending)
+	require.NoError(t, err)
+
+	// No other DELETE or INSERT ran, so other types are untouched
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshRecommendationsByType_FailureKeepsExisting(t *testing.T) {
+	service, mock := newMockService(t)
+	userID := "user-1"
+
+	mock.ExpectQuery(`FROM trending_courses`).
+		WithArgs(10, "").
+		WillReturnRows(trendingRows(1))
+	mock.ExpectQuery(`INSERT INTO recommendations`).
+		WillReturnError(fmt.Errorf("insert failed"))
+
+	err := service.RefreshRecommendationsByType(context.Background(), userID, RecTypeTrending)
+	require.Error(t, err)
+
+	// No DELETE ran, so the previous trending recommendations remain
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshRecommendationsByType_SkillAdjacencyUsesRealCourses(t *testing.T) {
+	service, mock := newMockService(t)
+	userID := "user-1"
+
+	mock.ExpectQuery(`FROM generated_courses gc\s+WHERE gc.user_id <> \$1`).
+		WithArgs(userID, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("course-a").AddRow("course-b"))
+	mock.ExpectQuery(`INSERT INTO recommendations`).
+		WithArgs(
+			userID, "course-a", RecTypeSkillAdjacency, 88, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			userID, "course-b", RecTypeSkillAdjacency, 85, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		).
+		WillReturnRows(createdRecommendationRows().
+			AddRow("rec-1", userID, "course-a", RecTypeSkillAdjacency).
+			AddRow("rec-2", userID, "course-b", RecTypeSkillAdjacency))
+	mock.ExpectExec(`DELETE FROM recommendations`).
+		WithArgs(userID, RecTypeSkillAdjacency, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, service.RefreshRecommendationsByType(context.Background(), userID, RecTypeSkillAdjacency))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshRecommendationsByType_InvalidType(t *testing.T) {
+	service, mock := newMockService(t)
+
+	err := service.RefreshRecommendationsByType(context.Background(), "user-1", "not_a_type")
+
+	assert.ErrorIs(t, err, ErrInvalidRecommendationType)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshRecommendationsHandler_InvalidType(t *testing.T) {
+	service, _ := newMockService(t)
+	handler := NewHandler(service)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/recommendations/refresh?type=bogus", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	handler.RefreshRecommendations(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// recommendationRows returns recommendation rows as returned by GetRecommendations
+func recommendationRows(userID, recType string, courseIDs ...string) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "user_id", "course_id", "recommendation_type", "match_score", "reason", "metadata", "created_at", "expires_at",
+	})
+	for i, courseID := range courseIDs {
+		rows.AddRow("rec-"+courseID, userID, courseID, recType, 90-i, "reason", []byte(`{}`), time.Now(), time.Now().Add(time.Hour))
+	}
+	return rows
+}
+
+func TestGetRecommendationsHandler_FiltersByType(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	mock.ExpectQuery(`FROM recommendations r.*AND r.recommendation_type = \$3`).
+		WithArgs("user-1", InterestedScoreBoost, RecTypeCollaborativeFiltering).
+		WillReturnRows(recommendationRows("user-1", RecTypeCollaborativeFiltering, "course-1", "course-2"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recommendations?type="+RecTypeCollaborativeFiltering, nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	handler.GetRecommendations(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		Recommendations map[string][]Recommendation `json:"recommendations"`
+		Sections        map[string]string           `json:"sections"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Len(t, body.Recommendations, 1)
+	assert.Len(t, body.Recommendations[RecTypeCollaborativeFiltering], 2)
+	assert.Equal(t, map[string]string{RecTypeCollaborativeFiltering: "Because You Completed"}, body.Sections)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRecommendationsHandler_InvalidType(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recommendations?type=bogus", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	handler.GetRecommendations(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRecommendations_ExcludesDismissed(t *testing.T) {
+	service, mock := newMockService(t)
+
+	mock.ExpectQuery(`LEFT JOIN recommendation_feedback f.*f.feedback_type <> 'dismissed'`).
+		WithArgs("user-1", InterestedScoreBoost).
+		WillReturnRows(recommendationRows("user-1", RecTypeTrending, "course-2"))
+
+	grouped, err := service.GetRecommendations(context.Background(), "user-1")
+	require.NoError(t, err)
+
+	assert.Len(t, grouped[RecTypeTrending], 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDismissRecommendationHandler(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+	courseID := "6f1c7a52-3b9e-4d2a-8c1e-2f7a9b0c4d11"
+
+	mock.ExpectQuery(`INSERT INTO recommendation_feedback`).
+		WithArgs("user-1", courseID, FeedbackDismissed).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("fb-1", time.Now(), time.Now()))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/recommendations/"+courseID+"/dismiss", nil)
+	req = mux.SetURLVars(req, map[string]string{"courseId": courseID})
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr := httptest.NewRecorder()
+
+	handler.DismissRecommendation(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDismissRecommendationHandler_InvalidOrUnknownCourse(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+	unknownID := "6f1c7a52-3b9e-4d2a-8c1e-2f7a9b0c4d11"
+
+	mock.ExpectQuery(`INSERT INTO recommendation_feedback`).
+		WithArgs("user-1", unknownID, FeedbackDismissed).
+		WillReturnError(&pq.Error{Code: "23503", Constraint: "recommendation_feedback_course_id_fkey"})
+
+	tests := []struct {
+		courseID string
+		want     int
+	}{
+		{courseID: "not-a-uuid", want: http.StatusBadRequest},
+		{courseID: unknownID, want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/recommendations/"+tt.courseID+"/dismiss", nil)
+		req = mux.SetURLVars(req, map[string]string{"courseId": tt.courseID})
+		req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+		rr := httptest.NewRecorder()
+
+		handler.DismissRecommendation(rr, req)
+
+		assert.Equal(t, tt.want, rr.Code, tt.courseID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordFeedbackBatchHandler(t *testing.T) {
+	courseID := "6f1c7a52-3b9e-4d2a-8c1e-2f7a9b0c4d11"
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"empty batch", `{"course_ids": [], "feedback_type": "dismissed"}`, http.StatusBadRequest},
+		{"invalid type", `{"course_ids": ["` + courseID + `"], "feedback_type": "meh"}`, http.StatusBadRequest},
+		{"unknown field", `{"course_ids": ["` + courseID + `"], "feedback_type": "dismissed", "extra": 1}`, http.StatusBadRequest},
+		{"oversized batch", `{"course_ids": [` + strings.Repeat(`"`+courseID+`",`, MaxFeedbackBatchSize) + `"` + courseID + `"], "feedback_type": "dismissed"}`, http.StatusRequestEntityTooLarge},
+		{"recorded", `{"course_ids": ["` + courseID + `"], "feedback_type": "dismissed"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newMockService(t)
+			if tt.wantStatus == http.StatusOK {
+				mock.ExpectQuery(`INSERT INTO recommendation_feedback`).
+					WithArgs("user-1", pq.Array([]string{courseID}), FeedbackDismissed).
+					WillReturnRows(sqlmock.NewRows([]string{"course_id"}).AddRow(courseID))
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/recommendations/feedback/batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+			rr := httptest.NewRecorder()
+
+			NewHandler(service).RecordFeedbackBatch(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code, rr.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				var resp struct {
+					CourseIDs []string `json:"course_ids"`
+					Count     int      `json:"count"`
+				}
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				assert.Equal(t, []string{courseID}, resp.CourseIDs)
+				assert.Equal(t, 1, resp.Count)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRecordRecommendationFeedback_InvalidType(t *testing.T) {
+	service, mock := newMockService(t)
+
+	_, err := service.RecordRecommendationFeedback(context.Background(), "user-1", "course-1", "meh")
+
+	assert.ErrorIs(t, err, ErrInvalidFeedbackType)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRecommendations_CachedUntilFeedback(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithCaches(cache.Config{}, cache.Config{TTL: time.Minute, MaxSize: 10})
+
+	mock.ExpectQuery(`FROM recommendations r`).
+		WithArgs("user-1", InterestedScoreBoost).
+		WillReturnRows(recommendationRows("user-1", RecTypeTrending, "course-1"))
+
+	// Second read is served from cache
+	for i := 0; i < 2; i++ {
+		grouped, err := service.GetRecommendations(context.Background(), "user-1")
+		require.NoError(t, err)
+		assert.Len(t, grouped[RecTypeTrending], 1)
+	}
+
+	// Feedback invalidates the user's cached rows
+	mock.ExpectQuery(`INSERT INTO recommendation_feedback`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow("fb-1", time.Now(), time.Now()))
+	mock.ExpectQuery(`FROM recommendations r`).
+		WithArgs("user-1", InterestedScoreBoost).
+		WillReturnRows(recommendationRows("user-1", RecTypeTrending))
+
+	_, err := service.RecordRecommendationFeedback(context.Background(), "user-1", "course-1", FeedbackDismissed)
+	require.NoError(t, err)
+
+	grouped, err := service.GetRecommendations(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, grouped[RecTypeTrending])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRecommendations_CallerCannotMutateCache(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithCaches(cache.Config{}, cache.Config{TTL: time.Minute, MaxSize: 10})
+
+	mock.ExpectQuery(`FROM recommendations r`).
+		WithArgs("user-1", InterestedScoreBoost).
+		WillReturnRows(recommendationRows("user-1", RecTypeTrending, "course-1"))
+
+	grouped, err := service.GetRecommendations(context.Background(), "user-1")
+	require.NoError(t, err)
+	grouped[RecTypeTrending][0].CourseID = "tampered"
+	delete(grouped, RecTypeTrending)
+
+	cached, err := service.GetRecommendations(context.Background(), "user-1")
+	require.NoError(t, err)
+	require.Len(t, cached[RecTypeTrending], 1)
+	assert.Equal(t, "course-1", cached[RecTypeTrending][0].CourseID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateTrendingRecs_SingleBatchInsert(t *testing.T) {
+	service, mock := newMockService(t)
+	userID := "user-1"
+
+	mock.ExpectQuery(`FROM trending_courses`).
+		WithArgs(10, "").
+		WillReturnRows(trendingRows(5))
+
+	// All five recommendations go out in one multi-row upsert
+	returned := createdRecommendationRows()
+	for i := 0; i < 5; i++ {
+		returned.AddRow(fmt.Sprintf("rec-%d", i), userID, fmt.Sprintf("course-%d", i), RecTypeTrending)
+	}
+	mock.ExpectQuery(`INSERT INTO recommendations .* VALUES \(\$1, .*\), \(\$9, .*\), \(\$17, .*\), \(\$25, .*\), \(\$33, .*\$40\)\s+ON CONFLICT \(user_id, course_id, recommendation_type\)\s+DO UPDATE SET`).
+		WillReturnRows(returned)
+
+	require.NoError(t, service.generateTrendingRecs(context.Background(), userID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateTrendingRecs_RespectsBatchSize(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithRecommendationBatchSize(2)
+	userID := "user-1"
+
+	mock.ExpectQuery(`FROM trending_courses`).
+		WithArgs(10, "").
+		WillReturnRows(trendingRows(5))
+
+	// 5 recommendations in batches of 2 is three statements: 2, 2 and 1 rows
+	for _, size := range []int{2, 2, 1} {
+		args := make([]driver.Value, 0, size*recommendationInsertColumns)
+		for i := 0; i < size*recommendationInsertColumns; i++ {
+			args = append(args, sqlmock.AnyArg())
+		}
+		mock.ExpectQuery(`INSERT INTO recommendations`).
+			WithArgs(args...).
+			WillReturnRows(createdRecommendationRows())
+	}
+
+	require.NoError(t, service.generateTrendingRecs(context.Background(), userID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateRecommendations_UpsertKeepsLastDuplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewRepository(db)
+
+	first := &Recommendation{UserID: "user-1", CourseID: "course-1", RecommendationType: RecTypeTrending, MatchScore: 10}
+	other := &Recommendation{UserID: "user-1", CourseID: "course-2", RecommendationType: RecTypeTrending, MatchScore: 20}
+	latest := &Recommendation{UserID: "user-1", CourseID: "course-1", RecommendationType: RecTypeTrending, MatchScore: 30}
+
+	// The duplicate key is sent once, with the later score, so ON CONFLICT never hits the same row twice
+	mock.ExpectQuery(`INSERT INTO recommendations`).
+		WithArgs(
+			"user-1", "course-1", RecTypeTrending, 30, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			"user-1", "course-2", RecTypeTrending, 20, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		).
+		WillReturnRows(createdRecommendationRows().
+			AddRow("rec-2", "user-1", "course-2", RecTypeTrending).
+			AddRow("rec-1", "user-1", "course-1", RecTypeTrending))
+
+	require.NoError(t, repo.CreateRecommendations(context.Background(), []*Recommendation{first, other, latest}))
+
+	assert.Equal(t, "rec-1", first.ID)
+	assert.Equal(t, "rec-1", latest.ID)
+	assert.Equal(t, "rec-2", other.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithRecommendationBatchSize_Bounds(t *testing.T) {
+	service := NewService(nil)
+	assert.Equal(t, DefaultRecommendationBatchSize, service.recommendationBatchSize)
+
+	assert.Equal(t, DefaultRecommendationBatchSize, service.WithRecommendationBatchSize(0).recommendationBatchSize)
+	assert.Equal(t, MaxRecommendationBatchSize, service.WithRecommendationBatchSize(1_000_000).recommendationBatchSize)
+	assert.Equal(t, 25, service.WithRecommendationBatchSize(25).recommendationBatchSize)
+}
+
+// velocityRows returns a single course as returned by CalculateTrendingVelocity
+func velocityRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"course_id", "meta_category", "signups_recent", "signups_previous", "velocity"}).
+		AddRow("course-1", "Digital", 10, 5, 2.0)
+}
+
+func TestRefreshTrendingCache_OnlyOneRefreshProceeds(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithTrendingFullRebuild(true)
+	service.WithCaches(cache.Config{TTL: time.Minute, MaxSize: 1}, cache.Config{})
+	cached := []TrendingCourse{{CourseID: "cached"}}
+	service.trendingCache.Set(trendingCacheKey, cached)
+
+	// The first refresh takes the lock and replaces the table with every listed category
+	mock.ExpectQuery(`FROM generated_courses gc`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pq.Array(TrendingCategories), sqlmock.AnyArg(), sqlmock.AnyArg(), TrendingCandidateLimit).
+		WillReturnRows(velocityRows())
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+		WithArgs(trendingRefreshLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(true))
+	mock.ExpectExec(`DELETE FROM trending_courses`).WillReturnResult(sqlmock.NewResult(0, 3))
+	copyStmt := mock.ExpectPrepare(`COPY "trending_courses"`)
+	copyStmt.ExpectExec().
+		WithArgs("course-1", 2.0, 10, 5, 1, "Digital", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	copyStmt.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	copyStmt.WillBeClosed()
+	mock.ExpectCommit()
+
+	require.NoError(t, service.RefreshTrendingCache(context.Background()))
+	_, ok := service.trendingCache.Get(trendingCacheKey)
+	assert.False(t, ok, "a completed refresh purges the cache")
+
+	// A refresh that finds the lock held leaves the table and cache untouched
+	service.trendingCache.Set(trendingCacheKey, cached)
+	mock.ExpectQuery(`FROM generated_courses gc`).WillReturnRows(velocityRows())
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+		WithArgs(trendingRefreshLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(false))
+	mock.ExpectRollback()
+
+	err := service.RefreshTrendingCache(context.Background())
+	assert.ErrorIs(t, err, ErrTrendingRefreshInProgress)
+	courses, ok := service.trendingCache.Get(trendingCacheKey)
+	assert.True(t, ok)
+	assert.Equal(t, cached, courses)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTrendingCache_WaitsForLock(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithTrendingRefreshWait(true).WithTrendingFullRebuild(true)
+
+	mock.ExpectQuery(`FROM generated_courses gc`).
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "meta_category", "signups_recent", "signups_previous", "velocity"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+		WithArgs(trendingRefreshLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM trending_courses`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, service.RefreshTrendingCache(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshTrendingHandler_ConflictWhileLocked(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	mock.ExpectQuery(`FROM generated_courses gc`).WillReturnRows(velocityRows())
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(false))
+	mock.ExpectRollback()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/trending/refresh", nil)
+	rec := httptest.NewRecorder()
+	handler.RefreshTrending(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserEndpoints_RequireOwnerOrAdmin(t *testing.T) {
+	service, mock := newMockService(t)
+	handler := NewHandler(service)
+
+	endpoints := map[string]http.HandlerFunc{
+		"followers": handler.GetFollowers,
+		"following": handler.GetFollowing,
+	}
+	for name, endpoint := range endpoints {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/users/user-1/"+name, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": "user-1"})
+			req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-2"}))
+			rec := httptest.NewRecorder()
+
+			endpoint(rec, req)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+
+	// No query reaches the database for a forbidden request
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckAchievements_DedicatedFromTimeSpent(t *testing.T) {
+	service, mock := newMockService(t)
+	userID := "user-1"
+
+	mock.ExpectQuery(`FROM achievements a`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "name", "description", "badge_icon", "criteria", "rarity", "created_at", "unlocked_at",
+		}))
+	expectAchievementDefinitions(mock)
+	mock.ExpectQuery(`SUM\(time_spent_seconds\)`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(100 * 3600))
+	mock.ExpectExec(`INSERT INTO user_achievements`).
+		WithArgs(userID, "dedicated").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	unlocked, err := service.CheckAchievements(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, unlocked, 1)
+	assert.Equal(t, "dedicated", unlocked[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseFeedRange(t *testing.T) {
+	rng, err := ParseFeedRange("2024-05-01", "2024-05-01")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), rng.From)
+	assert.Equal(t, time.Date(2024, 5, 1, 23, 59, 59, 999999999, time.UTC), rng.To)
+
+	rng, err = ParseFeedRange("2024-05-01T08:00:00Z", "")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), rng.From)
+	assert.True(t, rng.To.IsZero())
+
+	for _, bounds := range [][2]string{{"2024-05-02", "2024-05-01"}, {"yesterday", ""}, {"", "05/01/2024"}} {
+		_, err := ParseFeedRange(bounds[0], bounds[1])
+		assert.ErrorIs(t, err, ErrInvalidFeedRange, "from=%q to=%q", bounds[0], bounds[1])
+	}
+}
+
+func TestParseFeedCursor(t *testing.T) {
+	cursor := FeedCursor{CreatedAt: time.Date(2024, 5, 1, 8, 0, 0, 123456000, time.UTC), ID: "0b6a4e8e-4f7e-4c1a-9f1e-1d2c3b4a5f60"}
+
+	parsed, err := ParseFeedCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, parsed.CreatedAt.Equal(cursor.CreatedAt))
+	assert.Equal(t, cursor.ID, parsed.ID)
+
+	for _, value := range []string{"not base64!", "bm8tc2VwYXJhdG9y", FeedCursor{CreatedAt: cursor.CreatedAt, ID: "activity-1"}.Encode()} {
+		_, err := ParseFeedCursor(value)
+		assert.ErrorIs(t, err, ErrInvalidFeedCursor, value)
+	}
+}
+
+func TestGetActivityFeedHandler_Cursor(t *testing.T) {
+	feedRequest := func(query string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/feed"+query, nil)
+		return req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	}
+	activityRows := func(ids ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{
+			"id", "user_id", "activity_type", "reference_type", "reference_id", "metadata", "visibility", "created_at",
+		})
+		for i, id := range ids {
+			rows.AddRow(id, "user-2", "exercise_solved", "exercise", "exercise-1", []byte(`{}`), "public", time.Date(2024, 5, 1, 12-i, 0, 0, 0, time.UTC))
+		}
+		return rows
+	}
+	const lastID = "0b6a4e8e-4f7e-4c1a-9f1e-1d2c3b4a5f60"
+
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`FROM activity_feed af`).
+		WithArgs("user-1", 2, sqlmock.AnyArg(), sqlmock.AnyArg(), true, ActivityUserFollowed, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(activityRows("activity-1", lastID))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).GetActivityFeed(rr, feedRequest("?limit=2"))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Items      []ActivityFeed `json:"items"`
+		Limit      int            `json:"limit"`
+		NextCursor string         `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, 2, body.Limit)
+	require.NotEmpty(t, body.NextCursor, "a full page may have more behind it")
+
+	// The cursor continues after the last activity of the previous page
+	mock.ExpectQuery(`\(af.created_at, af.id\) < \(\$7, \$8::uuid\)`).
+		WithArgs("user-1", 2, sqlmock.AnyArg(), sqlmock.AnyArg(), true, ActivityUserFollowed,
+			sql.NullTime{Time: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), Valid: true},
+			sql.NullString{String: lastID, Valid: true}).
+		WillReturnRows(activityRows())
+
+	rr = httptest.NewRecorder()
+	NewHandler(service).GetActivityFeed(rr, feedRequest("?limit=2&cursor="+body.NextCursor))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"items": [], "limit": 2}`, rr.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	rr = httptest.NewRecorder()
+	NewHandler(service).GetActivityFeed(rr, feedRequest("?cursor=garbage"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetActivityFeedHandler_DateRange(t *testing.T) {
+	feedRequest := func(query string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/feed"+query, nil)
+		return req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	}
+
+	t.Run("range is passed to the query", func(t *testing.T) {
+		service, mock := newMockService(t)
+		from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 5, 1, 23, 59, 59, 999999999, time.UTC)
+		mock.ExpectQuery(`FROM activity_feed af.*af.created_at >= \$3.*af.created_at <= \$4`).
+			WithArgs("user-1", 50, from, to, true, ActivityUserFollowed, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "user_id", "activity_type", "reference_type", "reference_id", "metadata", "visibility", "created_at",
+			}).AddRow("activity-1", "user-2", "exercise_solved", "exercise", "exercise-1", []byte(`{}`), "public", from.Add(time.Hour)))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetActivityFeed(rr, feedRequest("?from=2024-05-01&to=2024-05-01"))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var body struct {
+			Items      []ActivityFeed `json:"items"`
+			NextCursor string         `json:"next_cursor"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		require.Len(t, body.Items, 1)
+		assert.Equal(t, "activity-1", body.Items[0].ID)
+		assert.Empty(t, body.NextCursor, "a partial page is the last one")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("include_own=false is passed to the query", func(t *testing.T) {
+		service, mock := newMockService(t)
+		mock.ExpectQuery(`FROM activity_feed af`).
+			WithArgs("user-1", 50, sqlmock.AnyArg(), sqlmock.AnyArg(), false, ActivityUserFollowed, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "user_id", "activity_type", "reference_type", "reference_id", "metadata", "visibility", "created_at",
+			}))
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetActivityFeed(rr, feedRequest("?include_own=false"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid include_own is rejected", func(t *testing.T) {
+		service, mock := newMockService(t)
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetActivityFeed(rr, feedRequest("?include_own=maybe"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("from after to is rejected", func(t *testing.T) {
+		service, mock := newMockService(t)
+
+		rr := httptest.NewRecorder()
+		NewHandler(service).GetActivityFeed(rr, feedRequest("?from=2024-05-02&to=2024-05-01"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// expectRecommendationQueries mocks the first query of each recommendation algorithm,
+// in any order, each returning no rows after delay. failing names algorithms whose query errors.
+func expectRecommendationQueries(mock sqlmock.Sqlmock, userID string, delay time.Duration, failing ...string) {
+	mock.MatchExpectationsInOrder(false)
+
+	queries := []struct {
+		recType string
+		pattern string
+		args    []driver.Value
+		column  string
+	}{
+		{RecTypeCollaborativeFiltering, `WITH user_courses AS`, []driver.Value{userID, 0.8}, "user_id"},
+		{RecTypeSkillAdjacency, `gc\.meta_category IN`, []driver.Value{userID, 3}, "id"},
+		{RecTypeSocialSignal, `SELECT following_id`, []driver.Value{userID}, "following_id"},
+		{RecTypeTrending, `FROM trending_courses`, []driver.Value{10, ""}, "id"},
+	}
+	for _, q := range queries {
+		expectation := mock.ExpectQuery(q.pattern).WithArgs(q.args...).WillDelayFor(delay)
+		failed := false
+		for _, recType := range failing {
+			failed = failed || recType == q.recType
+		}
+		if failed {
+			expectation.WillReturnError(fmt.Errorf("%s unavailable", q.recType))
+		} else {
+			expectation.WillReturnRows(sqlmock.NewRows([]string{q.column}))
+		}
+	}
+}
+
+func TestGenerateRecommendations_FailureDoesNotFailBatch(t *testing.T) {
+	service, mock := newMockService(t)
+	expectRecommendationQueries(mock, "user-1", 0, RecTypeTrending)
+
+	require.NoError(t, service.GenerateRecommendations(context.Background(), "user-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateRecommendations_AllFailing(t *testing.T) {
+	service, mock := newMockService(t)
+	expectRecommendationQueries(mock, "user-1", 0,
+		RecTypeCollaborativeFiltering, RecTypeSkillAdjacency, RecTypeSocialSignal, RecTypeTrending)
+
+	err := service.GenerateRecommendations(context.Background(), "user-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "trending unavailable")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateRecommendations_RunsConcurrently(t *testing.T) {
+	service, mock := newMockService(t)
+	// Sequentially the four delayed queries would take at least 800ms
+	expectRecommendationQueries(mock, "user-1", 200*time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, service.GenerateRecommendations(context.Background(), "user-1"))
+	assert.Less(t, time.Since(start), 600*time.Millisecond)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateRecommendations_Timeout(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithRecommendationTimeout(20 * time.Millisecond)
+	expectRecommendationQueries(mock, "user-1", 200*time.Millisecond)
+
+	err := service.GenerateRecommendations(context.Background(), "user-1")
+	assert.ErrorIs(t, err, ErrRecommendationsIncomplete)
+
+	// The algorithms still finish in the background
+	assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+}
+
+func TestRefreshRecommendationsHandler_AcceptedWhenIncomplete(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithRecommendationTimeout(20 * time.Millisecond)
+	expectRecommendationQueries(mock, "user-1", 200*time.Millisecond)
+	handler := NewHandler(service)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/recommendations/refresh", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	handler.RefreshRecommendations(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+}
+
+// BenchmarkGenerateRecommendations compares running the algorithms one after
+// another with GenerateRecommendations, against a database with 2ms query latency
+func BenchmarkGenerateRecommendations(b *testing.B) {
+	const latency = 2 * time.Millisecond
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			service, mock := newMockService(b)
+			expectRecommendationQueries(mock, "user-1", latency)
+			b.StartTimer()
+
+			for _, generator := range service.recommendationGenerators() {
+				_ = generator.generate(context.Background(), "user-1")
+			}
+		}
+	})
+
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			service, mock := newMockService(b)
+			expectRecommendationQueries(mock, "user-1", latency)
+			b.StartTimer()
+
+			_ = service.GenerateRecommendations(context.Background(), "user-1")
+		}
+	})
+}
+
+func TestGetFollowers_ReturnsPromptlyWhenContextEnds(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery("SELECT follower_id").
+		WithArgs("user-1").
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"follower_id"}).AddRow("user-2"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := service.GetFollowers(ctx, "user-1")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
@@ -0,0 +1,122 @@
+package social
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ErrSkillNotFound is returned when a skill is not part of the skill graph
+var ErrSkillNotFound = errors.New("skill not found")
+
+// SkillGraph defines skill progression paths
+var SkillGraph = map[string][]string{
+	// Digital Systems
+	"basics":          {"intermediate", "algorithms", "data_structures"},
+	"algorithms":      {"advanced_algorithms", "optimization", "distributed_systems"},
+	"data_structures": {"advanced_data_structures", "database_design"},
+	"web_development": {"backend_development", "frontend_frameworks", "full_stack"},
+	"backend":         {"microservices", "distributed_systems", "scalability"},
+	"frontend":        {"ui_design", "performance_optimization", "accessibility"},
+
+	// Economic Systems
+	"trading_basics":   {"technical_analysis", "risk_management", "portfolio_theory"},
+	"risk_management":  {"derivatives", "hedging_strategies", "quantitative_finance"},
+	"market_mechanics": {"market_microstructure", "algorithmic_trading", "hft"},
+
+	// Cognitive Systems
+	"ml_basics":       {"supervised_learning", "unsupervised_learning", "deep_learning"},
+	"deep_learning":   {"computer_vision", "nlp", "reinforcement_learning"},
+	"neural_networks": {"advanced_architectures", "optimization_techniques"},
+
+	// Aesthetic Systems
+	"design_basics": {"ui_design", "ux_design", "design_systems"},
+	"ui_design":     {"advanced_layouts", "animation", "accessibility"},
+
+	// Biological Systems
+	"biology_basics": {"molecular_biology", "genetics", "bioinformatics"},
+	"genetics":       {"genomics", "gene_editing", "synthetic_biology"},
+}
+
+// LoadSkillGraph reads a skill graph from a JSON file mapping each skill to the
+// skills that follow it, e.g. {"basics": ["algorithms", "data_structures"]}
+func LoadSkillGraph(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read skill graph: %w", err)
+	}
+
+	var graph map[string][]string
+	if err := json.Unmarshal(data, &graph); err != nil {
+		return nil, fmt.Errorf("failed to parse skill graph: %w", err)
+	}
+	if len(graph) == 0 {
+		return nil, errors.New("skill graph is empty")
+	}
+	return graph, nil
+}
+
+// WithSkillGraph replaces the built-in skill graph, e.g. with one loaded via LoadSkillGraph.
+// An empty graph keeps the current one.
+func (s *Service) WithSkillGraph(graph map[string][]string) *Service {
+	if len(graph) > 0 {
+		s.skillGraph = graph
+	}
+	return s
+}
+
+// GetSkillGraph returns a copy of the skill progression map
+func (s *Service) GetSkillGraph() map[string][]string {
+	graph := make(map[string][]string, len(s.skillGraph))
+	for skill, next := range s.skillGraph {
+		graph[skill] = append([]string{}, next...)
+	}
+	return graph
+}
+
+// GetAdjacentSkills returns the skills that follow skill in the graph.
+// Skills that only appear as a destination are known but have no successors yet.
+func (s *Service) GetAdjacentSkills(skill string) ([]string, error) {
+	skill = normalizeSkill(skill)
+
+	if next, ok := s.skillGraph[skill]; ok {
+		return append([]string{}, next...), nil
+	}
+	for _, next := range s.skillGraph {
+		for _, candidate := range next {
+			if candidate == skill {
+				return []string{}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSkillNotFound, skill)
+}
+
+// SkillNames returns every skill in the graph, sources and destinations, sorted
+func (s *Service) SkillNames() []string {
+	seen := make(map[string]bool)
+	for skill, next := range s.skillGraph {
+		seen[skill] = true
+		for _, candidate := range next {
+			seen[candidate] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeSkill maps user input such as "Data Structures" onto graph keys
+func normalizeSkill(skill string) string {
+	skill = strings.ToLower(strings.TrimSpace(skill))
+	return strings.Join(strings.FieldsFunc(skill, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), "_")
+}
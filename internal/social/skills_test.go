@@ -0,0 +1,101 @@
+package social
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveSkills routes a request through the social handler's skill endpoints
+func serveSkills(service *Service, path string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestGetAdjacentSkills_KnownSkill(t *testing.T) {
+	service := NewService(nil)
+
+	rr := serveSkills(service, "/api/skills/Trading-Basics/adjacent")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		Items []string `json:"items"`
+		Total int      `json:"total"`
+		Meta  struct {
+			Skill string `json:"skill"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, "trading_basics", body.Meta.Skill)
+	assert.Equal(t, []string{"technical_analysis", "risk_management", "portfolio_theory"}, body.Items)
+	assert.Equal(t, 3, body.Total)
+}
+
+func TestGetAdjacentSkills_LeafSkillIsEmpty(t *testing.T) {
+	service := NewService(nil)
+
+	adjacent, err := service.GetAdjacentSkills("hedging_strategies")
+	require.NoError(t, err)
+	assert.Empty(t, adjacent)
+	assert.NotNil(t, adjacent, "known leaf skills return an empty list, not null")
+}
+
+func TestGetAdjacentSkills_UnknownSkill(t *testing.T) {
+	service := NewService(nil)
+
+	_, err := service.GetAdjacentSkills("underwater_basket_weaving")
+	assert.ErrorIs(t, err, ErrSkillNotFound)
+
+	rr := serveSkills(service, "/api/skills/underwater_basket_weaving/adjacent")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestGetSkillGraph(t *testing.T) {
+	service := NewService(nil)
+
+	rr := serveSkills(service, "/api/skills/graph")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		Graph  map[string][]string `json:"graph"`
+		Skills []string            `json:"skills"`
+		Count  int                 `json:"count"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, SkillGraph, body.Graph)
+	assert.Contains(t, body.Skills, "basics")
+	assert.Contains(t, body.Skills, "hft", "destination-only skills are listed too")
+	assert.Equal(t, len(body.Skills), body.Count)
+
+	// Callers get a copy, so mutating it leaves the service graph intact
+	graph := service.GetSkillGraph()
+	graph["basics"][0] = "changed"
+	assert.Equal(t, "intermediate", SkillGraph["basics"][0])
+}
+
+func TestWithSkillGraph_LoadedFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "graph.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"cooking": ["baking", "grilling"]}`), 0o600))
+
+	graph, err := LoadSkillGraph(path)
+	require.NoError(t, err)
+	service := NewService(nil).WithSkillGraph(graph)
+
+	adjacent, err := service.GetAdjacentSkills("cooking")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"baking", "grilling"}, adjacent)
+
+	_, err = service.GetAdjacentSkills("basics")
+	assert.ErrorIs(t, err, ErrSkillNotFound, "the loaded graph replaces the built-in one")
+}
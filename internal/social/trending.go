@@ -0,0 +1,81 @@
+package social
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// TrendingPolicy controls how course signups turn into a trending velocity and
+// how that velocity becomes a recommendation match score
+type TrendingPolicy struct {
+	Window         time.Duration // Recent period whose signups are counted
+	PreviousWindow time.Duration // Period just before Window that recent signups are compared against
+
+	// NewCourseVelocity is the velocity of a course with recent signups but none
+	// in the previous window. Only used without smoothing, which never divides by zero.
+	NewCourseVelocity float64
+
+	// Smoothing is added to both signup counts before comparing them, e.g. 1 for
+	// Laplace add-one smoothing, so a handful of signups on a brand-new course
+	// does not outrank steady growth on a popular one
+	Smoothing float64
+
+	ScorePerVelocity float64 // Match score points per 1x velocity, capped at 100
+}
+
+// TrendingCandidateLimit is how many of the fastest-growing courses a refresh keeps
+const TrendingCandidateLimit = 100
+
+// DefaultTrendingPolicy compares the last 24 hours with the 24 hours before,
+// without smoothing
+func DefaultTrendingPolicy() TrendingPolicy {
+	return TrendingPolicy{
+		Window:            24 * time.Hour,
+		PreviousWindow:    24 * time.Hour,
+		NewCourseVelocity: 10,
+		Smoothing:         0,
+		ScorePerVelocity:  10,
+	}
+}
+
+// Validate checks the windows are positive and no other setting is negative
+func (p TrendingPolicy) Validate() error {
+	if p.Window <= 0 || p.PreviousWindow <= 0 {
+		return fmt.Errorf("trending windows must be positive, got window=%s previous=%s", p.Window, p.PreviousWindow)
+	}
+	if p.NewCourseVelocity < 0 {
+		return fmt.Errorf("new course velocity must not be negative, got %g", p.NewCourseVelocity)
+	}
+	if p.Smoothing < 0 {
+		return fmt.Errorf("smoothing must not be negative, got %g", p.Smoothing)
+	}
+	if p.ScorePerVelocity < 0 {
+		return fmt.Errorf("score per velocity must not be negative, got %g", p.ScorePerVelocity)
+	}
+	return nil
+}
+
+// MatchScore converts a velocity into a recommendation match score (0-100)
+func (p TrendingPolicy) MatchScore(velocity float64) int {
+	score := math.Round(velocity * p.ScorePerVelocity)
+	switch {
+	case score < 0:
+		return 0
+	case score > 100:
+		return 100
+	}
+	return int(score)
+}
+
+// WithTrendingPolicy sets the signup windows, smoothing and score conversion
+// trending uses. An invalid policy is ignored; callers validate it at startup.
+func (s *Service) WithTrendingPolicy(policy TrendingPolicy) *Service {
+	if err := policy.Validate(); err != nil {
+		slog.Warn("ignoring invalid trending policy", "error", err)
+		return s
+	}
+	s.trendingPolicy = policy
+	return s
+}
@@ -0,0 +1,229 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/platform/cache"
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// categoryTrendingRows returns trending courses of the given categories, ranked in order
+func categoryTrendingRows(categories ...string) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "course_id", "velocity", "signups_24h", "signups_previous_24h", "rank", "meta_category", "calculated_at",
+	})
+	for i, category := range categories {
+		rows.AddRow("t-"+category, "course-"+category, 2.0, 10, 5, i+1, category, time.Now())
+	}
+	return rows
+}
+
+func TestGetTrendingCoursesHandler_FiltersByCategory(t *testing.T) {
+	service, mock := newMockService(t)
+
+	// The category is matched case-insensitively and filtered in the query
+	mock.ExpectQuery(`FROM trending_courses\s+WHERE \(\$2 = '' OR meta_category = \$2\)`).
+		WithArgs(TrendingListSize, "Economic").
+		WillReturnRows(categoryTrendingRows("Economic"))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).GetTrendingCourses(rr, httptest.NewRequest(http.MethodGet, "/api/trending?category=economic", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Total int                        `json:"total"`
+		Meta  map[string]json.RawMessage `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.JSONEq(t, `"Economic"`, string(body.Meta["category"]))
+	assert.Equal(t, 1, body.Total)
+	assert.NotContains(t, body.Meta, "by_category")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTrendingCoursesHandler_RejectsUnknownCategory(t *testing.T) {
+	service, mock := newMockService(t)
+	rr := httptest.NewRecorder()
+
+	NewHandler(service).GetTrendingCourses(rr, httptest.NewRequest(http.MethodGet, "/api/trending?category=Astrology", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "category")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTrendingCoursesHandler_GroupsByCategory(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectQuery(`FROM trending_courses\s+WHERE`).
+		WithArgs(TrendingListSize, "").
+		WillReturnRows(categoryTrendingRows("Digital", "Economic", "Digital"))
+	mock.ExpectQuery(`PARTITION BY meta_category`).
+		WithArgs(TrendingPerCategorySize).
+		WillReturnRows(categoryTrendingRows("Digital", "Digital", "Economic"))
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).GetTrendingCourses(rr, httptest.NewRequest(http.MethodGet, "/api/trending", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var body struct {
+		Items []TrendingCourse `json:"items"`
+		Total int              `json:"total"`
+		Meta  struct {
+			ByCategory map[string][]TrendingCourse `json:"by_category"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, 3, body.Total)
+	assert.Len(t, body.Items, 3)
+	assert.Len(t, body.Meta.ByCategory, len(TrendingCategories), "every category is listed, even when empty")
+	assert.Len(t, body.Meta.ByCategory["Digital"], 2)
+	assert.Len(t, body.Meta.ByCategory["Economic"], 1)
+	assert.Empty(t, body.Meta.ByCategory["Cognitive"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTrendingPolicy_MatchScore(t *testing.T) {
+	policy := DefaultTrendingPolicy()
+	assert.Equal(t, 25, policy.MatchScore(2.5))
+	assert.Equal(t, 100, policy.MatchScore(50), "scores are capped at 100")
+	assert.Equal(t, 0, policy.MatchScore(-1))
+}
+
+func TestTrendingPolicy_Validate(t *testing.T) {
+	require.NoError(t, DefaultTrendingPolicy().Validate())
+
+	invalid := map[string]func(*TrendingPolicy){
+		"zero window":         func(p *TrendingPolicy) { p.Window = 0 },
+		"zero previous":       func(p *TrendingPolicy) { p.PreviousWindow = 0 },
+		"negative new course": func(p *TrendingPolicy) { p.NewCourseVelocity = -1 },
+		"negative smoothing":  func(p *TrendingPolicy) { p.Smoothing = -1 },
+		"negative score":      func(p *TrendingPolicy) { p.ScorePerVelocity = -1 },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			policy := DefaultTrendingPolicy()
+			mutate(&policy)
+			assert.Error(t, policy.Validate())
+		})
+	}
+}
+
+func TestCalculateTrendingVelocity_PassesPolicyAndRanksInOrder(t *testing.T) {
+	service, mock := newMockService(t)
+	policy := DefaultTrendingPolicy()
+	policy.Smoothing = 1
+	policy.Window = 12 * time.Hour
+	mock.ExpectQuery(`FROM signups\s+WHERE signups_recent > 0\s+ORDER BY velocity DESC, signups_recent DESC, course_id\s+LIMIT \$6`).
+		WithArgs(float64(12*60*60), float64(24*60*60), pq.Array([]string{"Digital"}), 1.0, 10.0, TrendingCandidateLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "meta_category", "signups_recent", "signups_previous", "velocity"}).
+			AddRow("course-popular", "Digital", 60, 20, 5.8).
+			AddRow("course-new", "Digital", 1, 0, 4.0))
+
+	courses, err := service.repo.CalculateTrendingVelocity(context.Background(), policy, []string{"Digital"})
+	require.NoError(t, err)
+	require.Len(t, courses, 2)
+	assert.Equal(t, "course-popular", courses[0].CourseID)
+	assert.Equal(t, 1, courses[0].Rank)
+	assert.Equal(t, 5.8, courses[0].Velocity)
+	assert.Equal(t, "course-new", courses[1].CourseID)
+	assert.Equal(t, 2, courses[1].Rank)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTrendingPolicy_InvalidKeepsCurrent(t *testing.T) {
+	service := NewService(nil).WithTrendingPolicy(TrendingPolicy{})
+	assert.Equal(t, DefaultTrendingPolicy(), service.trendingPolicy)
+}
+
+// expectCategoryRefresh expects one category's trending to be recomputed and upserted
+func expectCategoryRefresh(mock sqlmock.Sqlmock, category string) {
+	mock.ExpectQuery(`FROM generated_courses gc`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pq.Array([]string{category}), sqlmock.AnyArg(), sqlmock.AnyArg(), TrendingCandidateLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "meta_category", "signups_recent", "signups_previous", "velocity"}).
+			AddRow("course-"+category, category, 10, 5, 2.0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+		WithArgs(trendingRefreshLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired"}).AddRow(true))
+	mock.ExpectExec(`INSERT INTO trending_courses`).
+		WithArgs("course-"+category, 2.0, 10, 5, 1, category, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM trending_courses\s+WHERE meta_category = \$1`).
+		WithArgs(category, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM trending_courses\s+WHERE meta_category IS NULL OR NOT \(meta_category = ANY\(\$1\)\)`).
+		WithArgs(pq.Array(TrendingCategories)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE trending_courses t\s+SET rank`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM trending_courses WHERE rank > \$1`).
+		WithArgs(TrendingCandidateLimit).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+}
+
+func TestRefreshTrendingCache_CategoryFailureKeepsOthers(t *testing.T) {
+	service, mock := newMockService(t)
+	service.WithCaches(cache.Config{TTL: time.Minute, MaxSize: 1}, cache.Config{})
+	service.trendingCache.Set(trendingCacheKey, []TrendingCourse{{CourseID: "cached"}})
+
+	for _, category := range TrendingCategories {
+		if category == "Economic" {
+			mock.ExpectQuery(`FROM generated_courses gc`).
+				WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), pq.Array([]string{category}), sqlmock.AnyArg(), sqlmock.AnyArg(), TrendingCandidateLimit).
+				WillReturnError(errors.New("query timeout"))
+			continue
+		}
+		expectCategoryRefresh(mock, category)
+	}
+
+	err := service.RefreshTrendingCache(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Economic")
+	_, ok := service.trendingCache.Get(trendingCacheKey)
+	assert.False(t, ok, "the categories that refreshed purge the cache")
+	assert.NoError(t, mock.ExpectationsWereMet(), "every other category is still refreshed")
+}
+
+func TestRefreshTrendingHandler_SingleCategory(t *testing.T) {
+	service, mock := newMockService(t)
+	expectCategoryRefresh(mock, "Economic")
+
+	rr := httptest.NewRecorder()
+	NewHandler(service).RefreshTrending(rr, httptest.NewRequest(http.MethodPost, "/api/trending/refresh?category=economic", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet(), "only the requested category is refreshed")
+
+	rr = httptest.NewRecorder()
+	NewHandler(service).RefreshTrending(rr, httptest.NewRequest(http.MethodPost, "/api/trending/refresh?category=Astrology", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestRefreshTrendingRoute_RequiresAdmin(t *testing.T) {
+	service, mock := newMockService(t)
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/trending/refresh", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/trending/refresh", nil)
+	req = req.WithContext(middleware.ContextWithUser(req.Context(), &middleware.UserClaims{UserID: "user-1"}))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "non-admins never trigger a refresh")
+}
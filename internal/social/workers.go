@@ -0,0 +1,104 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"backend/internal/platform/metrics"
+)
+
+// StartTrendingRefresher refreshes the trending courses every interval plus a
+// random delay of up to jitter, so replicas started together do not contend for
+// the refresh lock at the same moment. The refresher stops when ctx is
+// cancelled; a non-positive interval disables it.
+func (s *Service) StartTrendingRefresher(ctx context.Context, interval, jitter time.Duration) {
+	startWorker(ctx, "trending_refresh", interval, jitter, func() error {
+		start := time.Now()
+		err := s.RefreshTrendingCache(ctx)
+		switch {
+		case err == nil:
+			slog.Info("trending refresh completed", "duration", time.Since(start))
+		case errors.Is(err, ErrTrendingRefreshInProgress):
+			slog.Info("trending refresh skipped", "reason", err.Error())
+			return nil
+		}
+		return err
+	})
+}
+
+// StartRecommendationCleanup deletes expired recommendations every interval
+// until ctx is cancelled; a non-positive interval disables it.
+func (s *Service) StartRecommendationCleanup(ctx context.Context, interval time.Duration) {
+	startWorker(ctx, "recommendation_cleanup", interval, 0, func() error {
+		_, err := s.CleanupExpiredRecommendations(ctx)
+		return err
+	})
+}
+
+// CleanupExpiredRecommendations deletes recommendations past their expiry and
+// returns how many were removed
+func (s *Service) CleanupExpiredRecommendations(ctx context.Context) (int64, error) {
+	deleted, err := s.repo.DeleteExpiredRecommendations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	metrics.RecordExpiredRecommendationsDeleted(deleted)
+	slog.Info("expired recommendations deleted", "count", deleted)
+	return deleted, nil
+}
+
+// startWorker runs job in the background on schedule; a non-positive interval disables it
+func startWorker(ctx context.Context, name string, interval, jitter time.Duration, job func() error) {
+	if interval <= 0 {
+		slog.Info("background worker disabled", "worker", name)
+		return
+	}
+
+	slog.Info("background worker started", "worker", name, "interval", interval, "jitter", jitter)
+	go runWorker(ctx, name, interval, jitter, job)
+}
+
+// runWorker calls job every interval plus up to jitter until ctx is done. A
+// failed or panicking run is logged and retried on the next tick.
+func runWorker(ctx context.Context, name string, interval, jitter time.Duration, job func() error) {
+	timer := time.NewTimer(nextWorkerRun(interval, jitter))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("background worker stopped", "worker", name)
+			return
+		case <-timer.C:
+			runWorkerJob(name, job)
+			timer.Reset(nextWorkerRun(interval, jitter))
+		}
+	}
+}
+
+// nextWorkerRun returns the delay before the next run
+func nextWorkerRun(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int63n(int64(jitter)))
+}
+
+// runWorkerJob runs job once, logging failures and recovering from panics
+func runWorkerJob(name string, job func() error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("background worker panicked", "worker", name, "panic", fmt.Sprint(r))
+		}
+	}()
+
+	if err := job(); err != nil {
+		slog.Error("background worker failed", "worker", name, "duration", time.Since(start), "error", err)
+	}
+}
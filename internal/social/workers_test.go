@@ -0,0 +1,100 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/platform/middleware"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerSurvivesPanicsAndErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	refresh := func() error {
+		switch calls.Add(1) {
+		case 1:
+			panic("boom")
+		case 2:
+			return errors.New("database unavailable")
+		case 3:
+			return ErrTrendingRefreshInProgress
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		runWorker(ctx, "test", time.Millisecond, time.Millisecond, refresh)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return calls.Load() >= 5 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop after context cancellation")
+	}
+}
+
+func TestNextWorkerRunStaysWithinJitter(t *testing.T) {
+	assert.Equal(t, time.Minute, nextWorkerRun(time.Minute, 0))
+
+	for i := 0; i < 100; i++ {
+		delay := nextWorkerRun(time.Minute, 10*time.Second)
+		assert.GreaterOrEqual(t, delay, time.Minute)
+		assert.Less(t, delay, time.Minute+10*time.Second)
+	}
+}
+
+func TestCleanupExpiredRecommendationsHandler(t *testing.T) {
+	service, mock := newMockService(t)
+	mock.ExpectExec("DELETE FROM recommendations").WillReturnResult(sqlmock.NewResult(0, 3))
+
+	rec := httptest.NewRecorder()
+	NewHandler(service).CleanupExpiredRecommendations(rec, httptest.NewRequest(http.MethodPost, "/api/admin/recommendations/cleanup", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"deleted": 3}`, rec.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCleanupExpiredRecommendationsRoute_RequiresAdmin(t *testing.T) {
+	service, mock := newMockService(t)
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	cases := []struct {
+		name   string
+		claims *middleware.UserClaims
+		status int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"member", &middleware.UserClaims{UserID: "user-1"}, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/recommendations/cleanup", nil)
+			if tc.claims != nil {
+				req = req.WithContext(middleware.ContextWithUser(req.Context(), tc.claims))
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet(), "non-admins never reach the database")
+}
@@ -0,0 +1,11 @@
+-- Migration 007: User Locale
+-- Preferred language for AI-generated content (curriculum, descriptions, reviews)
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS locale VARCHAR(16) NOT NULL DEFAULT 'en';
+
+COMMENT ON COLUMN users.locale IS 'BCP 47 language tag used for AI-generated content (e.g., "en", "es", "pt-BR")';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('007', 'Add locale preference to users');
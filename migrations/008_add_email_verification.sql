@@ -0,0 +1,17 @@
+-- Migration 008: Email Verification
+-- Track whether a user has confirmed ownership of their email address
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+-- Accounts created before verification existed are treated as verified
+UPDATE users
+SET email_verified = true, email_verified_at = created_at
+WHERE email_verified = false;
+
+COMMENT ON COLUMN users.email_verified IS 'True once the user has confirmed their email via a signed verification token';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('008', 'Add email verification to users');
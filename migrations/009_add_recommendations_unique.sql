@@ -0,0 +1,17 @@
+-- Migration 009: Recommendation Uniqueness
+-- One recommendation per user, course and type so refreshes upsert instead of duplicating
+
+-- Keep the newest row for any duplicates created before the constraint existed
+DELETE FROM recommendations a
+USING recommendations b
+WHERE a.user_id = b.user_id
+  AND a.course_id = b.course_id
+  AND a.recommendation_type = b.recommendation_type
+  AND (a.created_at, a.id) < (b.created_at, b.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recommendations_user_course_type
+  ON recommendations(user_id, course_id, recommendation_type);
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('009', 'Add unique index on recommendations for upserts');
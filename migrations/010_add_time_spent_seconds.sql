@@ -0,0 +1,19 @@
+-- Migration 010: Time Spent Tracking
+-- Record time spent per submission and per course in seconds
+
+ALTER TABLE module_completions
+  ADD COLUMN IF NOT EXISTS time_spent_seconds INT NOT NULL DEFAULT 0;
+
+ALTER TABLE user_progress
+  ADD COLUMN IF NOT EXISTS time_spent_seconds INT NOT NULL DEFAULT 0;
+
+-- Carry over any minute-level data recorded so far
+UPDATE module_completions SET time_spent_seconds = time_spent_minutes * 60 WHERE time_spent_minutes > 0;
+UPDATE user_progress SET time_spent_seconds = time_spent_minutes * 60 WHERE time_spent_minutes > 0;
+
+COMMENT ON COLUMN module_completions.time_spent_seconds IS 'Client-reported time spent on this submission (capped at 24h)';
+COMMENT ON COLUMN user_progress.time_spent_seconds IS 'Total time spent on the course across all submissions';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('010', 'Add time_spent_seconds to submissions and progress');
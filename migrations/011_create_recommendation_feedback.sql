@@ -0,0 +1,22 @@
+-- Migration 011: Recommendation Feedback
+-- Lets users dismiss recommendations or mark them as interesting
+
+CREATE TABLE recommendation_feedback (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  course_id UUID NOT NULL REFERENCES generated_courses(id) ON DELETE CASCADE,
+  feedback_type VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  UNIQUE(user_id, course_id),
+  CHECK (feedback_type IN ('dismissed', 'interested'))
+);
+
+CREATE INDEX idx_recommendation_feedback_user_id ON recommendation_feedback(user_id);
+
+COMMENT ON TABLE recommendation_feedback IS 'Latest user signal per recommended course';
+COMMENT ON COLUMN recommendation_feedback.feedback_type IS 'dismissed hides the course; interested boosts its match score';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('011', 'Create recommendation_feedback table');
@@ -0,0 +1,25 @@
+-- Migration 012: Learning Goals
+-- Daily or weekly activity targets set by the learner
+
+CREATE TABLE learning_goals (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  period VARCHAR(10) NOT NULL,
+  target_exercises INT NOT NULL DEFAULT 0,
+  target_minutes INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  UNIQUE(user_id, period),
+  CHECK (period IN ('daily', 'weekly')),
+  CHECK (target_exercises >= 0 AND target_minutes >= 0)
+);
+
+CREATE INDEX idx_learning_goals_user_id ON learning_goals(user_id);
+
+COMMENT ON TABLE learning_goals IS 'At most one goal per user and period';
+COMMENT ON COLUMN learning_goals.target_exercises IS 'Passing exercise submissions to reach within the period (0 = no target)';
+COMMENT ON COLUMN learning_goals.target_minutes IS 'Minutes of practice to reach within the period (0 = no target)';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('012', 'Create learning_goals table');
@@ -0,0 +1,20 @@
+-- Migration 013: Course Certificates
+-- One certificate per user and completed course, verifiable by code
+
+CREATE TABLE certificates (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  course_id UUID NOT NULL REFERENCES generated_courses(id) ON DELETE CASCADE,
+  verification_code VARCHAR(20) NOT NULL UNIQUE,
+  issued_at TIMESTAMP DEFAULT NOW(),
+  UNIQUE(user_id, course_id)
+);
+
+CREATE INDEX idx_certificates_user_id ON certificates(user_id);
+
+COMMENT ON TABLE certificates IS 'Issued when a course reaches 100% progress; re-completion keeps the original';
+COMMENT ON COLUMN certificates.verification_code IS 'Public code used to verify the certificate';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('013', 'Create certificates table');
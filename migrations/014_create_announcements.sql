@@ -0,0 +1,33 @@
+-- Migration 014: Announcements
+-- Scheduled in-app announcements and per-user acknowledgments
+
+CREATE TABLE announcements (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  title VARCHAR(255) NOT NULL,
+  body TEXT NOT NULL DEFAULT '',
+  category VARCHAR(20) NOT NULL DEFAULT 'info',
+  starts_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  ends_at TIMESTAMP,
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  CHECK (category IN ('info', 'maintenance', 'feature')),
+  CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX idx_announcements_window ON announcements(starts_at, ends_at);
+
+CREATE TABLE announcement_acknowledgments (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  announcement_id UUID NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+  acknowledged_at TIMESTAMP DEFAULT NOW(),
+  PRIMARY KEY (user_id, announcement_id)
+);
+
+COMMENT ON TABLE announcements IS 'In-app announcements shown between starts_at and ends_at';
+COMMENT ON COLUMN announcements.ends_at IS 'NULL keeps the announcement active until deleted';
+COMMENT ON TABLE announcement_acknowledgments IS 'Announcements a user dismissed; they are not shown to that user again';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('014', 'Create announcements and announcement_acknowledgments tables');
@@ -0,0 +1,22 @@
+-- Migration 015: Normalized Email
+-- Store a canonical form of each email so case and provider variants cannot register twice
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS email_normalized VARCHAR(255);
+
+-- Backfill with the default normalization (whole address lowercased).
+-- Duplicate accounts that differ only by case must be merged before this runs.
+UPDATE users
+SET email_normalized = LOWER(TRIM(email))
+WHERE email_normalized IS NULL;
+
+ALTER TABLE users
+  ALTER COLUMN email_normalized SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_normalized ON users(email_normalized);
+
+COMMENT ON COLUMN users.email_normalized IS 'Canonical email used for uniqueness and login lookup; email keeps the address as entered';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('015', 'Add normalized email to users');
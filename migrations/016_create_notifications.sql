@@ -0,0 +1,20 @@
+-- Migration 016: Notifications
+-- Per-user in-app notifications with read state
+
+CREATE TABLE notifications (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  type VARCHAR(50) NOT NULL,
+  title VARCHAR(255) NOT NULL,
+  body TEXT NOT NULL DEFAULT '',
+  read_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_notifications_user_unread ON notifications(user_id, created_at DESC) WHERE read_at IS NULL;
+
+COMMENT ON COLUMN notifications.read_at IS 'NULL until the user marks the notification read';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('016', 'Create notifications table');
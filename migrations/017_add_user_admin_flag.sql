@@ -0,0 +1,11 @@
+-- Migration 017: User admin flag
+-- Marks administrators; the flag is copied into the JWT claims at login
+
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Admins are few, so a partial index keeps lookups of them cheap
+CREATE INDEX idx_users_is_admin ON users(id) WHERE is_admin;
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('017', 'Add is_admin flag to users');
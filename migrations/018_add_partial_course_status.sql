@@ -0,0 +1,12 @@
+-- Migration 018: Partially generated courses
+-- Courses whose AI curriculum came back short are stored as 'partial' until generation is completed
+
+ALTER TABLE generated_courses DROP CONSTRAINT IF EXISTS generated_courses_status_check;
+
+ALTER TABLE generated_courses
+  ADD CONSTRAINT generated_courses_status_check
+  CHECK (status IN ('active', 'partial', 'paused', 'completed', 'archived'));
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('018', 'Allow partial status on generated courses');
@@ -0,0 +1,22 @@
+-- Migration 019: User privacy settings
+-- Stores each user's privacy preferences; users without a row get the application defaults
+
+CREATE TABLE user_privacy_settings (
+  user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  profile_visibility VARCHAR(20) NOT NULL DEFAULT 'friends',
+  activity_visibility VARCHAR(20) NOT NULL DEFAULT 'friends',
+  progress_visibility VARCHAR(20) NOT NULL DEFAULT 'friends',
+  allow_followers BOOLEAN NOT NULL DEFAULT TRUE,
+  show_in_leaderboards BOOLEAN NOT NULL DEFAULT TRUE,
+  show_completed_courses BOOLEAN NOT NULL DEFAULT TRUE,
+  updated_at TIMESTAMP DEFAULT NOW(),
+  CHECK (profile_visibility IN ('public', 'friends', 'private')),
+  CHECK (activity_visibility IN ('public', 'friends', 'private')),
+  CHECK (progress_visibility IN ('public', 'friends', 'private'))
+);
+
+COMMENT ON TABLE user_privacy_settings IS 'Per-user privacy preferences for profiles, activity and progress';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('019', 'Create user_privacy_settings table');
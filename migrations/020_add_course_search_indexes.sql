@@ -0,0 +1,16 @@
+-- Migration 020: Course search indexes
+-- Supports GET /api/courses?q=&category=&status= for users with many courses
+
+-- Trigram indexes let ILIKE '%term%' on title and description use an index
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_generated_courses_title_trgm ON generated_courses USING GIN (title gin_trgm_ops);
+CREATE INDEX idx_generated_courses_description_trgm ON generated_courses USING GIN (description gin_trgm_ops);
+
+-- Category and status filters are always scoped to one user and ordered by recency
+CREATE INDEX idx_generated_courses_user_category ON generated_courses(user_id, meta_category, created_at DESC);
+CREATE INDEX idx_generated_courses_user_status ON generated_courses(user_id, status, created_at DESC);
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('020', 'Add course search indexes');
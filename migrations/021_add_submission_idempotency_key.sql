@@ -0,0 +1,13 @@
+-- Migration 021: Submission idempotency keys
+-- Lets clients retry POST /api/exercises/{id}/submit without recording a second attempt
+
+ALTER TABLE module_completions ADD COLUMN idempotency_key VARCHAR(255);
+
+-- Keys are scoped per user; submissions without a key are never deduplicated
+CREATE UNIQUE INDEX idx_module_completions_user_idempotency_key
+    ON module_completions(user_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('021', 'Add idempotency key to module completions');
@@ -0,0 +1,12 @@
+-- Migration 022: Course generation source
+-- Flags courses built from blueprint templates while the AI provider was unavailable
+
+ALTER TABLE generated_courses
+    ADD COLUMN generated_by VARCHAR(20) NOT NULL DEFAULT 'ai'
+    CHECK (generated_by IN ('ai', 'template'));
+
+COMMENT ON COLUMN generated_courses.generated_by IS 'ai when an AI curriculum was used, template when built from blueprints alone';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('022', 'Add generated_by to generated courses');
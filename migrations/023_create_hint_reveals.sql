@@ -0,0 +1,17 @@
+-- Migration 023: Hint reveals
+-- Tracks how many hints each learner revealed per exercise through
+-- GET /api/exercises/{id}/hints/{index}; submissions read it into hints_used
+
+CREATE TABLE hint_reveals (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+  hints_revealed INT NOT NULL CHECK (hints_revealed > 0),
+  revealed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, exercise_id)
+);
+
+COMMENT ON COLUMN hint_reveals.hints_revealed IS 'Hints used: one past the highest hint index revealed';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('023', 'Create hint_reveals table');
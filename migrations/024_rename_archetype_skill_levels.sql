@@ -0,0 +1,20 @@
+-- Migration 024: Archetype skill levels
+-- Onboarding accepts beginner/intermediate/advanced (as documented in the API),
+-- but user_archetypes only allowed novice/analyst/quant, so every documented
+-- value failed the CHECK constraint. Existing rows are mapped to the new names.
+
+ALTER TABLE user_archetypes DROP CONSTRAINT IF EXISTS user_archetypes_skill_level_check;
+
+UPDATE user_archetypes SET skill_level = CASE skill_level
+  WHEN 'novice' THEN 'beginner'
+  WHEN 'analyst' THEN 'intermediate'
+  WHEN 'quant' THEN 'advanced'
+  ELSE skill_level
+END;
+
+ALTER TABLE user_archetypes ADD CONSTRAINT user_archetypes_skill_level_check
+  CHECK (skill_level IN ('beginner', 'intermediate', 'advanced'));
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('024', 'Rename archetype skill levels to beginner/intermediate/advanced');
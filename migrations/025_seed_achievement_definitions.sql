@@ -0,0 +1,24 @@
+-- Migration 025: Achievement definitions
+-- Achievements used to be hardcoded in the social service. They are now read
+-- from this table, with criteria as a threshold on one user statistic:
+--   {"metric": "<name>", "gte": <number>}
+-- Metrics: courses_completed, modules_completed, exercises_solved,
+-- perfect_scores, review_scores_avg, consecutive_days, total_time_spent_hours.
+-- Existing rows with the same name are left untouched.
+
+INSERT INTO achievements (name, description, criteria, rarity) VALUES
+  ('First Steps', 'Complete your first module', '{"metric": "modules_completed", "gte": 1}', 'common'),
+  ('Course Master', 'Complete your first course', '{"metric": "courses_completed", "gte": 1}', 'common'),
+  ('Perfectionist', 'Achieve a perfect score on an exercise', '{"metric": "perfect_scores", "gte": 1}', 'rare'),
+  ('Problem Solver', 'Solve 10 exercises', '{"metric": "exercises_solved", "gte": 10}', 'common'),
+  ('Code Warrior', 'Solve 50 exercises', '{"metric": "exercises_solved", "gte": 50}', 'rare'),
+  ('Code Legend', 'Solve 100 exercises', '{"metric": "exercises_solved", "gte": 100}', 'epic'),
+  ('Consistent Learner', 'Learn for 7 consecutive days', '{"metric": "consecutive_days", "gte": 7}', 'rare'),
+  ('Polymath', 'Complete 3 different courses', '{"metric": "courses_completed", "gte": 3}', 'epic'),
+  ('Architecture Expert', 'Maintain 90+ average review score', '{"metric": "review_scores_avg", "gte": 90}', 'epic'),
+  ('Dedicated Student', 'Spend 100+ hours learning', '{"metric": "total_time_spent_hours", "gte": 100}', 'legendary')
+ON CONFLICT (name) DO NOTHING;
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('025', 'Seed achievement definitions with threshold criteria');
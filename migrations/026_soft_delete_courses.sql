@@ -0,0 +1,17 @@
+-- Migration 026: Soft-deleted courses
+-- Deleting a course used to cascade to its modules, exercises and the
+-- learner's submissions, with no way back. Courses are now marked deleted
+-- instead and can be restored; reads skip deleted rows unless asked not to.
+
+ALTER TABLE generated_courses ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- Course listings only ever page through a user's live courses
+CREATE INDEX IF NOT EXISTS idx_generated_courses_user_live
+  ON generated_courses (user_id, created_at DESC, id DESC)
+  WHERE deleted_at IS NULL;
+
+COMMENT ON COLUMN generated_courses.deleted_at IS 'Set when the course is soft-deleted; NULL for live courses';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('026', 'Add soft delete to generated courses');
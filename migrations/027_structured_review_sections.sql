@@ -0,0 +1,17 @@
+-- Migration 027: Structured AI review sections
+-- Reviews used to store only a flat map of feedback per category. They now
+-- also keep a summary, the strengths and suggestions the reviewer listed and
+-- the issues it found with their severity. The feedback column is still
+-- written for clients that read it.
+
+ALTER TABLE architecture_reviews ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT '';
+ALTER TABLE architecture_reviews ADD COLUMN IF NOT EXISTS strengths JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE architecture_reviews ADD COLUMN IF NOT EXISTS issues JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE architecture_reviews ADD COLUMN IF NOT EXISTS suggestions JSONB NOT NULL DEFAULT '[]';
+
+COMMENT ON COLUMN architecture_reviews.summary IS 'Short overview of the reviewed submission';
+COMMENT ON COLUMN architecture_reviews.issues IS 'Array of {category, severity, description}; severity is low, medium or high';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('027', 'Add structured sections to architecture reviews');
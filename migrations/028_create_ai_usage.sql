@@ -0,0 +1,21 @@
+-- Migration 028: Per-user daily AI usage
+-- Onboarding, course completion and code reviews each call the AI provider.
+-- Calls are counted per user and UTC day so a daily budget can stop one
+-- account from running up the provider bill.
+
+CREATE TABLE IF NOT EXISTS ai_usage (
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  usage_date DATE NOT NULL,
+  calls INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (user_id, usage_date),
+  CHECK (calls >= 0)
+);
+
+-- Old days are only kept for reporting and can be pruned by date
+CREATE INDEX IF NOT EXISTS idx_ai_usage_usage_date ON ai_usage(usage_date);
+
+COMMENT ON TABLE ai_usage IS 'AI calls made on behalf of each user per UTC day, checked against AI_DAILY_CALL_BUDGET';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('028', 'Create ai_usage table for per-user daily AI budgets');
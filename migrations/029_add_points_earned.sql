@@ -0,0 +1,15 @@
+-- Migration 029: Award exercise points
+-- Each submission now records the exercise points it earned, scaled by its
+-- score and the exercise difficulty. A user's total counts the best
+-- submission of each exercise.
+
+ALTER TABLE module_completions ADD COLUMN IF NOT EXISTS points_earned INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_module_completions_user_exercise_points
+    ON module_completions(user_id, exercise_id, points_earned);
+
+COMMENT ON COLUMN module_completions.points_earned IS 'Exercise points awarded for this submission, scaled by score and difficulty';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('029', 'Add points_earned to module_completions');
@@ -0,0 +1,12 @@
+-- Migration 030: Optimistic locking for course progress
+-- Progress is read, changed and written back on every submission. The write
+-- now only applies if updated_at still holds the value that was read, so two
+-- concurrent submissions can no longer overwrite each other's time spent.
+
+ALTER TABLE user_progress ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+COMMENT ON COLUMN user_progress.updated_at IS 'Version for optimistic locking; every write must match the value it read';
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('030', 'Add updated_at to user_progress for optimistic locking');
@@ -0,0 +1,11 @@
+-- Migration 031: Index activity by the user it refers to
+-- The activity feed shows follow events to the followed user. That branch of
+-- the feed query looks activities up by reference_id and activity_type, which
+-- no existing index covered.
+
+CREATE INDEX IF NOT EXISTS idx_activity_feed_reference
+  ON activity_feed (reference_id, activity_type);
+
+-- Insert migration record
+INSERT INTO schema_migrations (version, description)
+VALUES ('031', 'Index activity_feed by reference_id and activity_type');
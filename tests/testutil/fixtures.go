@@ -1,6 +1,8 @@
 package testutil
 
 import (
+	"database/sql"
+	"testing"
 	"time"
 
 	"github.com/google/uuid"
@@ -294,3 +296,20 @@ func CreateTestAchievement(id, name string) TestAchievement {
 		CreatedAt:   time.Now(),
 	}
 }
+
+// CreateUser inserts a user with a unique email for a database test, deletes it
+// when the test ends and returns its ID
+func CreateUser(t *testing.T, db *sql.DB, name string) string {
+	t.Helper()
+
+	id := uuid.New().String()
+	_, err := db.Exec(
+		`INSERT INTO users (id, email, email_normalized, password_hash, name) VALUES ($1, $2, $2, 'hash', $3)`,
+		id, id+"@example.com", name,
+	)
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, id) })
+	return id
+}
@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"backend/internal/platform/ai"
+	"context"
 	"errors"
 )
 
@@ -10,11 +11,11 @@ type MockAIClient struct {
 	ShouldFail        bool
 	ExtractVarsResult *ai.Variables
 	CurriculumResult  *ai.Curriculum
-	ReviewResult      *ai.CodeReview
+	ReviewResult      *ai.ArchitectureReview
 }
 
 // ExtractVariables mocks variable extraction
-func (m *MockAIClient) ExtractVariables(domain string) (*ai.Variables, error) {
+func (m *MockAIClient) ExtractVariables(ctx context.Context, domain string) (*ai.Variables, error) {
 	if m.ShouldFail {
 		return nil, errors.New("mock AI failure")
 	}
@@ -31,7 +32,7 @@ func (m *MockAIClient) ExtractVariables(domain string) (*ai.Variables, error) {
 }
 
 // GenerateCurriculum mocks curriculum generation
-func (m *MockAIClient) GenerateCurriculum(archetypeID, domain string, variables *ai.Variables) (*ai.Curriculum, error) {
+func (m *MockAIClient) GenerateCurriculum(ctx context.Context, archetypeID, domain string, variables *ai.Variables, locale string) (*ai.Curriculum, error) {
 	if m.ShouldFail {
 		return nil, errors.New("mock AI failure")
 	}
@@ -43,31 +44,31 @@ func (m *MockAIClient) GenerateCurriculum(archetypeID, domain string, variables
 		Description: "AI-generated course description",
 		Modules: []ai.Module{
 			{
+				Number:      1,
 				Title:       "Module 1",
 				Description: "First module",
-				Lessons:     []string{"Lesson 1", "Lesson 2"},
 			},
 		},
 	}, nil
 }
 
 // ReviewCode mocks code review
-func (m *MockAIClient) ReviewCode(code, language, context string) (*ai.CodeReview, error) {
+func (m *MockAIClient) ReviewCode(ctx context.Context, code, language, reviewContext, locale string) (*ai.ArchitectureReview, error) {
 	if m.ShouldFail {
 		return nil, errors.New("mock AI failure")
 	}
 	if m.ReviewResult != nil {
 		return m.ReviewResult, nil
 	}
-	return &ai.CodeReview{
+	return &ai.ArchitectureReview{
 		OverallScore: 85,
 		CodeSense:    88,
 		Efficiency:   82,
 		EdgeCases:    80,
 		Taste:        90,
-		Feedback: map[string]interface{}{
-			"strengths": []string{"Good structure"},
-			"improvements": []string{"Add error handling"},
+		Feedback: map[string]string{
+			"strengths":    "Good structure",
+			"improvements": "Add error handling",
 		},
 	}, nil
 }
@@ -79,10 +80,34 @@ type MockCourseGenerator struct {
 }
 
 // GenerateCourse mocks course generation
-func (m *MockCourseGenerator) GenerateCourse(userID, archetypeID string, variables map[string]string) error {
+func (m *MockCourseGenerator) GenerateCourse(ctx context.Context, userID, archetypeID string, variables map[string]string) error {
 	m.Called = true
 	if m.ShouldFail {
 		return errors.New("mock course generation failure")
 	}
 	return nil
 }
+
+// CountingMetrics counts the business events a service records. It satisfies
+// the identity and learning MetricsRecorder interfaces.
+type CountingMetrics struct {
+	Registrations     int
+	Logins            int
+	PassedSubmissions int
+	FailedSubmissions int
+}
+
+// RecordUserRegistration counts a registration
+func (m *CountingMetrics) RecordUserRegistration() { m.Registrations++ }
+
+// RecordUserLogin counts a login
+func (m *CountingMetrics) RecordUserLogin() { m.Logins++ }
+
+// RecordExerciseSubmission counts a submission by outcome
+func (m *CountingMetrics) RecordExerciseSubmission(passed bool) {
+	if passed {
+		m.PassedSubmissions++
+	} else {
+		m.FailedSubmissions++
+	}
+}